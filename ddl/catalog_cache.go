@@ -12,6 +12,8 @@ type CatalogCache struct {
 	schemas     map[string]int
 	enums       map[[2]string]int
 	domains     map[[2]string]int
+	sequences   map[[2]string]int
+	grants      map[[5]string]int
 	routines    map[[3]string]int
 	views       map[[2]string]int
 	tables      map[[2]string]int
@@ -19,6 +21,7 @@ type CatalogCache struct {
 	constraints map[[3]string]int
 	indexes     map[[3]string]int
 	triggers    map[[3]string]int
+	policies    map[[3]string]int
 	pkeys       map[[2]string]int
 	fkeys       map[[2]string][]int
 }
@@ -31,6 +34,8 @@ func NewCatalogCache(catalog *Catalog) *CatalogCache {
 		schemas:     make(map[string]int),
 		enums:       make(map[[2]string]int),
 		domains:     make(map[[2]string]int),
+		sequences:   make(map[[2]string]int),
+		grants:      make(map[[5]string]int),
 		routines:    make(map[[3]string]int),
 		views:       make(map[[2]string]int),
 		tables:      make(map[[2]string]int),
@@ -38,6 +43,7 @@ func NewCatalogCache(catalog *Catalog) *CatalogCache {
 		constraints: make(map[[3]string]int),
 		indexes:     make(map[[3]string]int),
 		triggers:    make(map[[3]string]int),
+		policies:    make(map[[3]string]int),
 		pkeys:       make(map[[2]string]int),
 		fkeys:       make(map[[2]string][]int),
 	}
@@ -54,6 +60,13 @@ func NewCatalogCache(catalog *Catalog) *CatalogCache {
 			domainID := [2]string{schema.SchemaName, domain.DomainName}
 			cache.domains[domainID] = j
 		}
+		for j, sequence := range schema.Sequences {
+			sequenceID := [2]string{schema.SchemaName, sequence.SequenceName}
+			cache.sequences[sequenceID] = j
+		}
+		for j, grant := range schema.Grants {
+			cache.grants[grantID(schema.SchemaName, grant)] = j
+		}
 		for j, routine := range schema.Routines {
 			identityArguments := ""
 			if cache.dialect == "postgres" {
@@ -105,6 +118,10 @@ func NewCatalogCache(catalog *Catalog) *CatalogCache {
 				triggerID := [3]string{schema.SchemaName, table.TableName, trigger.TriggerName}
 				cache.triggers[triggerID] = k
 			}
+			for k, policy := range table.Policies {
+				policyID := [3]string{schema.SchemaName, table.TableName, policy.PolicyName}
+				cache.policies[policyID] = k
+			}
 		}
 	}
 	return cache
@@ -263,6 +280,96 @@ func (c *CatalogCache) AddOrUpdateDomain(schema *Schema, domain Domain) {
 	c.domains[[2]string{schema.SchemaName, domain.DomainName}] = i
 }
 
+// GetSequence gets a Sequence with the given sequenceName from the Schema, or
+// returns nil if it doesn't exist. If a nil schema is passed in, GetSequence
+// returns nil.
+//
+// The returning Sequence pointer is valid as long as no new Sequence is added
+// to the Schema; if a new Sequence is added, the pointer may now be pointing
+// at a stale Sequence. Call GetSequence again in order to get the new pointer.
+func (c *CatalogCache) GetSequence(schema *Schema, sequenceName string) *Sequence {
+	if schema == nil {
+		return nil
+	}
+	i, ok := c.sequences[[2]string{schema.SchemaName, sequenceName}]
+	if ok && !schema.Sequences[i].Ignore {
+		return &schema.Sequences[i]
+	}
+	return nil
+}
+
+// GetOrCreateSequence gets a Sequence with the given sequenceName from the
+// Schema, or creates it if it doesn't exist.
+//
+// The returning Sequence pointer is valid as long as no new Sequence is added
+// to the Schema; if a new Sequence is added, the pointer may now be pointing
+// at a stale Sequence. Call GetSequence again in order to get the new pointer.
+func (c *CatalogCache) GetOrCreateSequence(schema *Schema, sequenceName string) *Sequence {
+	i, ok := c.sequences[[2]string{schema.SchemaName, sequenceName}]
+	if ok && !schema.Sequences[i].Ignore {
+		return &schema.Sequences[i]
+	}
+	schema.Sequences = append(schema.Sequences, Sequence{
+		SequenceSchema: schema.SchemaName,
+		SequenceName:   sequenceName,
+	})
+	i = len(schema.Sequences) - 1
+	c.sequences[[2]string{schema.SchemaName, sequenceName}] = i
+	return &schema.Sequences[i]
+}
+
+// AddOrUpdateSequence adds the given Sequence to the Schema, or updates it if
+// it already exists.
+func (c *CatalogCache) AddOrUpdateSequence(schema *Schema, sequence Sequence) {
+	sequence.SequenceSchema = schema.SchemaName
+	i, ok := c.sequences[[2]string{schema.SchemaName, sequence.SequenceName}]
+	if ok && !schema.Sequences[i].Ignore {
+		schema.Sequences[i] = sequence
+		return
+	}
+	schema.Sequences = append(schema.Sequences, sequence)
+	i = len(schema.Sequences) - 1
+	c.sequences[[2]string{schema.SchemaName, sequence.SequenceName}] = i
+}
+
+// grantID returns the key of a Grant within the schema. A Grant is
+// identified by its object, grantee and privilege.
+func grantID(schemaName string, grant Grant) [5]string {
+	return [5]string{schemaName, grant.ObjectType, grant.ObjectName, grant.Grantee, grant.Privilege}
+}
+
+// GetGrant gets the Grant of the privilege on the given object to the
+// grantee from the Schema, or returns nil if it doesn't exist. If a nil
+// schema is passed in, GetGrant returns nil.
+//
+// The returning Grant pointer is valid as long as no new Grant is added to
+// the Schema; if a new Grant is added, the pointer may now be pointing at a
+// stale Grant. Call GetGrant again in order to get the new pointer.
+func (c *CatalogCache) GetGrant(schema *Schema, objectType, objectName, grantee, privilege string) *Grant {
+	if schema == nil {
+		return nil
+	}
+	i, ok := c.grants[[5]string{schema.SchemaName, objectType, objectName, grantee, privilege}]
+	if ok && !schema.Grants[i].Ignore {
+		return &schema.Grants[i]
+	}
+	return nil
+}
+
+// AddOrUpdateGrant adds the given Grant to the Schema, or updates it if it
+// already exists.
+func (c *CatalogCache) AddOrUpdateGrant(schema *Schema, grant Grant) {
+	grant.GrantSchema = schema.SchemaName
+	i, ok := c.grants[grantID(schema.SchemaName, grant)]
+	if ok && !schema.Grants[i].Ignore {
+		schema.Grants[i] = grant
+		return
+	}
+	schema.Grants = append(schema.Grants, grant)
+	i = len(schema.Grants) - 1
+	c.grants[grantID(schema.SchemaName, grant)] = i
+}
+
 // GetRoutine gets a Routine with the given routineName (and identityArguments)
 // from the Schema, or returns nil if it doesn't exist. If a nil schema is
 // passed in, GetRoutine returns nil. The identityArguments string only applies
@@ -661,6 +768,60 @@ func (c *CatalogCache) AddOrUpdateTrigger(table *Table, trigger Trigger) {
 	c.triggers[[3]string{table.TableSchema, table.TableName, trigger.TriggerName}] = i
 }
 
+// GetPolicy gets a Policy with the given policyName from the Table, or
+// returns nil if it doesn't exist. If a nil table is passed in, GetPolicy
+// returns nil.
+//
+// The returning Policy pointer is valid as long as no new Policy is added to
+// the Table; if a new Policy is added, the pointer may now be pointing at a
+// stale Policy. Call GetPolicy again in order to get the new pointer.
+func (c *CatalogCache) GetPolicy(table *Table, policyName string) *Policy {
+	if table == nil {
+		return nil
+	}
+	i, ok := c.policies[[3]string{table.TableSchema, table.TableName, policyName}]
+	if ok && !table.Policies[i].Ignore {
+		return &table.Policies[i]
+	}
+	return nil
+}
+
+// GetOrCreatePolicy gets a Policy with the given policyName from the Table,
+// or creates it if it doesn't exist.
+//
+// The returning Policy pointer is valid as long as no new Policy is added to
+// the Table; if a new Policy is added, the pointer may now be pointing at a
+// stale Policy. Call GetPolicy again in order to get the new pointer.
+func (c *CatalogCache) GetOrCreatePolicy(table *Table, policyName string) *Policy {
+	i, ok := c.policies[[3]string{table.TableSchema, table.TableName, policyName}]
+	if ok && !table.Policies[i].Ignore {
+		return &table.Policies[i]
+	}
+	table.Policies = append(table.Policies, Policy{
+		TableSchema: table.TableSchema,
+		TableName:   table.TableName,
+		PolicyName:  policyName,
+	})
+	i = len(table.Policies) - 1
+	c.policies[[3]string{table.TableSchema, table.TableName, policyName}] = i
+	return &table.Policies[i]
+}
+
+// AddOrUpdatePolicy adds the given Policy to the Table, or updates it if it
+// already exists.
+func (c *CatalogCache) AddOrUpdatePolicy(table *Table, policy Policy) {
+	policy.TableSchema = table.TableSchema
+	policy.TableName = table.TableName
+	i, ok := c.policies[[3]string{table.TableSchema, table.TableName, policy.PolicyName}]
+	if ok && !table.Policies[i].Ignore {
+		table.Policies[i] = policy
+		return
+	}
+	table.Policies = append(table.Policies, policy)
+	i = len(table.Policies) - 1
+	c.policies[[3]string{table.TableSchema, table.TableName, policy.PolicyName}] = i
+}
+
 // GetViewIndex gets a Index with the given indexName from the View, or
 // returns nil if it doesn't exist.
 //
@@ -821,7 +982,11 @@ func (src *Catalog) WriteCatalog(dest *Catalog) error {
 		destSchema.Ignore = srcSchema.Ignore
 		destSchema.EnumsValid = srcSchema.EnumsValid
 		destSchema.DomainsValid = srcSchema.DomainsValid
+		destSchema.SequencesValid = srcSchema.SequencesValid
+		destSchema.GrantsValid = srcSchema.GrantsValid
+		destSchema.PoliciesValid = srcSchema.PoliciesValid
 		destSchema.RoutinesValid = srcSchema.RoutinesValid
+		destSchema.TriggersValid = srcSchema.TriggersValid
 		destSchema.ViewsValid = srcSchema.ViewsValid
 
 		for _, srcEnum := range srcSchema.Enums {
@@ -847,6 +1012,15 @@ func (src *Catalog) WriteCatalog(dest *Catalog) error {
 			destDomain.Ignore = srcDomain.Ignore
 		}
 
+		for _, srcSequence := range srcSchema.Sequences {
+			destSequence := cache.GetOrCreateSequence(destSchema, srcSequence.SequenceName)
+			*destSequence = srcSequence
+		}
+
+		for _, srcGrant := range srcSchema.Grants {
+			cache.AddOrUpdateGrant(destSchema, srcGrant)
+		}
+
 		for _, srcRoutine := range srcSchema.Routines {
 			destRoutine := cache.GetOrCreateRoutine(destSchema, srcRoutine.RoutineName, srcRoutine.IdentityArguments)
 			destRoutine.RoutineType = srcRoutine.RoutineType
@@ -888,7 +1062,19 @@ func (src *Catalog) WriteCatalog(dest *Catalog) error {
 			destTable.TableSchema = srcTable.TableSchema
 			destTable.TableName = srcTable.TableName
 			destTable.SQL = srcTable.SQL
+			destTable.IsStrict = srcTable.IsStrict
+			destTable.IsWithoutRowid = srcTable.IsWithoutRowid
+			destTable.PartitionBy = srcTable.PartitionBy
+			destTable.PartitionOf = srcTable.PartitionOf
+			destTable.PartitionBound = srcTable.PartitionBound
+			destTable.Engine = srcTable.Engine
+			destTable.CharacterSet = srcTable.CharacterSet
+			destTable.CollationName = srcTable.CollationName
+			destTable.RowFormat = srcTable.RowFormat
+			destTable.AutoIncrement = srcTable.AutoIncrement
+			destTable.RowSecurityEnabled = srcTable.RowSecurityEnabled
 			destTable.Comment = srcTable.Comment
+			destTable.RenamedFrom = srcTable.RenamedFrom
 			destTable.Ignore = srcTable.Ignore
 			for _, srcColumn := range srcTable.Columns {
 				destColumn := cache.GetOrCreateColumn(destTable, srcColumn.ColumnName, srcColumn.ColumnType)
@@ -914,6 +1100,11 @@ func (src *Catalog) WriteCatalog(dest *Catalog) error {
 				*destTrigger = srcTrigger
 				destTrigger.Attrs = cloneMap(srcTrigger.Attrs)
 			}
+			for _, srcPolicy := range srcTable.Policies {
+				destPolicy := cache.GetOrCreatePolicy(destTable, srcPolicy.PolicyName)
+				*destPolicy = srcPolicy
+				destPolicy.Roles = cloneSlice(srcPolicy.Roles)
+			}
 		}
 	}
 	return nil
@@ -119,6 +119,61 @@ func TestCatalogCache(t *testing.T) {
 		}
 	})
 
+	t.Run("sequence", func(t *testing.T) {
+		schema := cache.GetOrCreateSchema(catalog, lorem_ipsum)
+		// get nonexistent sequence
+		gotSequence := cache.GetSequence(schema, lorem_ipsum)
+		if diff := testutil.Diff(gotSequence, (*Sequence)(nil)); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		// create sequence and assert it was created
+		wantSequence := Sequence{
+			SequenceSchema: lorem_ipsum,
+			SequenceName:   lorem_ipsum,
+		}
+		cache.AddOrUpdateSequence(schema, wantSequence)
+		gotSequence = cache.GetSequence(schema, lorem_ipsum)
+		if diff := testutil.Diff(*gotSequence, wantSequence); diff != "" {
+			t.Fatal(testutil.Callers(), diff)
+		}
+		// modify sequence and assert it was modified
+		wantSequence.Increment = 10
+		cache.AddOrUpdateSequence(schema, wantSequence)
+		gotSequence = cache.GetOrCreateSequence(schema, lorem_ipsum)
+		if diff := testutil.Diff(*gotSequence, wantSequence); diff != "" {
+			t.Fatal(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("grant", func(t *testing.T) {
+		schema := cache.GetOrCreateSchema(catalog, lorem_ipsum)
+		// get nonexistent grant
+		gotGrant := cache.GetGrant(schema, "TABLE", lorem_ipsum, lorem_ipsum, "SELECT")
+		if diff := testutil.Diff(gotGrant, (*Grant)(nil)); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		// create grant and assert it was created
+		wantGrant := Grant{
+			GrantSchema: lorem_ipsum,
+			ObjectType:  "TABLE",
+			ObjectName:  lorem_ipsum,
+			Grantee:     lorem_ipsum,
+			Privilege:   "SELECT",
+		}
+		cache.AddOrUpdateGrant(schema, wantGrant)
+		gotGrant = cache.GetGrant(schema, "TABLE", lorem_ipsum, lorem_ipsum, "SELECT")
+		if diff := testutil.Diff(*gotGrant, wantGrant); diff != "" {
+			t.Fatal(testutil.Callers(), diff)
+		}
+		// modify grant and assert it was modified
+		wantGrant.IsGrantable = true
+		cache.AddOrUpdateGrant(schema, wantGrant)
+		gotGrant = cache.GetGrant(schema, "TABLE", lorem_ipsum, lorem_ipsum, "SELECT")
+		if diff := testutil.Diff(*gotGrant, wantGrant); diff != "" {
+			t.Fatal(testutil.Callers(), diff)
+		}
+	})
+
 	t.Run("routine", func(t *testing.T) {
 		schema := cache.GetOrCreateSchema(catalog, lorem_ipsum)
 		// get nonexistent routine
@@ -356,6 +411,34 @@ func TestCatalogCache(t *testing.T) {
 		}
 	})
 
+	t.Run("policy", func(t *testing.T) {
+		schema := cache.GetOrCreateSchema(catalog, lorem_ipsum)
+		table := cache.GetOrCreateTable(schema, lorem_ipsum)
+		// get nonexistent policy
+		gotPolicy := cache.GetPolicy(table, lorem_ipsum)
+		if diff := testutil.Diff(gotPolicy, (*Policy)(nil)); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		// create policy and assert it was created
+		wantPolicy := Policy{
+			TableSchema: lorem_ipsum,
+			TableName:   lorem_ipsum,
+			PolicyName:  lorem_ipsum,
+		}
+		cache.AddOrUpdatePolicy(table, wantPolicy)
+		gotPolicy = cache.GetPolicy(table, lorem_ipsum)
+		if diff := testutil.Diff(*gotPolicy, wantPolicy); diff != "" {
+			t.Fatal(testutil.Callers(), diff)
+		}
+		// modify policy and assert it was modified
+		wantPolicy.UsingExpr = lorem_ipsum
+		cache.AddOrUpdatePolicy(table, wantPolicy)
+		gotPolicy = cache.GetOrCreatePolicy(table, lorem_ipsum)
+		if diff := testutil.Diff(*gotPolicy, wantPolicy); diff != "" {
+			t.Fatal(testutil.Callers(), diff)
+		}
+	})
+
 	t.Run("view index", func(t *testing.T) {
 		const lorem_ipsum = "lorem ipsum view"
 		schema := cache.GetOrCreateSchema(catalog, lorem_ipsum)
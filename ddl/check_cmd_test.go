@@ -0,0 +1,315 @@
+package ddl
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/blink-io/sqddl/internal/testutil"
+)
+
+func TestCheckCmd(t *testing.T) {
+	const tables = `package _
+
+import "github.com/blink-io/sq"
+
+type PERSON struct {
+	sq.TableStruct
+	PERSON_ID sq.NumberField ` + "`" + `ddl:"primarykey"` + "`" + `
+	NAME      sq.StringField ` + "`" + `ddl:"notnull index"` + "`" + `
+	EMAIL     sq.StringField ` + "`" + `ddl:"unique"` + "`" + `
+}
+
+type PET struct {
+	sq.TableStruct
+	PET_ID    sq.NumberField ` + "`" + `ddl:"primarykey"` + "`" + `
+	PERSON_ID sq.NumberField ` + "`" + `ddl:"references=person"` + "`" + `
+}
+`
+	fsys := fstest.MapFS{"tables.go": {Data: []byte(tables)}}
+
+	newDB := func(t *testing.T) *sql.DB {
+		db, err := sql.Open("sqlite3", "file:/"+t.Name()+".db?vfs=memdb&_foreign_keys=true")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		automigrateCmd := &AutomigrateCmd{
+			DB:        db,
+			Dialect:   DialectSQLite,
+			DirFS:     fsys,
+			Filenames: []string{"tables.go"},
+			Stderr:    io.Discard,
+		}
+		err = automigrateCmd.Run()
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		return db
+	}
+
+	t.Run("no drift", func(t *testing.T) {
+		t.Parallel()
+		checkCmd := &CheckCmd{
+			DB:        newDB(t),
+			Dialect:   DialectSQLite,
+			DirFS:     fsys,
+			Filenames: []string{"tables.go"},
+			Stdout:    io.Discard,
+		}
+		err := checkCmd.Run()
+		if err != nil {
+			t.Error(testutil.Callers(), err)
+		}
+	})
+
+	t.Run("drift", func(t *testing.T) {
+		t.Parallel()
+		db := newDB(t)
+		for _, query := range []string{
+			"ALTER TABLE person ADD COLUMN nickname TEXT",
+			"DROP INDEX person_name_idx",
+			"DROP TABLE pet",
+			"CREATE TABLE audit_log (id INTEGER PRIMARY KEY)",
+		} {
+			_, err := db.Exec(query)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+		}
+		checkCmd := &CheckCmd{
+			DB:        db,
+			Dialect:   DialectSQLite,
+			DirFS:     fsys,
+			Filenames: []string{"tables.go"},
+		}
+		diffs, err := checkCmd.Results()
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		wantDiffs := []SchemaDiff{
+			{ObjectType: "column", Object: "person.nickname", Status: "removed"},
+			{ObjectType: "index", Object: "person.person_name_idx", Status: "added"},
+			{ObjectType: "table", Object: "pet", Status: "added"},
+			{ObjectType: "table", Object: "audit_log", Status: "removed"},
+		}
+		if diff := testutil.Diff(diffs, wantDiffs); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		// Text output.
+		buf := &bytes.Buffer{}
+		checkCmd.DestCatalog = nil
+		checkCmd.Stdout = buf
+		err = checkCmd.Run()
+		if err == nil {
+			t.Fatal(testutil.Callers(), "expected error, got nil")
+		}
+		wantOutput := "[removed] column person.nickname\n" +
+			"[added] index person.person_name_idx\n" +
+			"[added] table pet\n" +
+			"[removed] table audit_log\n"
+		if diff := testutil.Diff(buf.String(), wantOutput); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		// JSON output.
+		buf.Reset()
+		checkCmd.DestCatalog = nil
+		checkCmd.JSON = true
+		err = checkCmd.Run()
+		if err == nil {
+			t.Fatal(testutil.Callers(), "expected error, got nil")
+		}
+		var gotDiffs []SchemaDiff
+		err = json.Unmarshal(buf.Bytes(), &gotDiffs)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(gotDiffs, wantDiffs); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("changed column", func(t *testing.T) {
+		t.Parallel()
+		checkCmd := &CheckCmd{
+			DB:      newDB(t),
+			Dialect: DialectSQLite,
+		}
+		// Change the declared type, nullability and default of person.email.
+		destCatalog := &Catalog{Dialect: DialectSQLite}
+		err := writeCatalog(destCatalog, fsys, "sqddl_history", "tables.go")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		cache := NewCatalogCache(destCatalog)
+		table := cache.GetTable(cache.GetSchema(destCatalog, ""), "person")
+		column := cache.GetColumn(table, "email")
+		column.ColumnType = "INT"
+		column.IsNotNull = true
+		column.ColumnDefault = "0"
+		checkCmd.DestCatalog = destCatalog
+		diffs, err := checkCmd.Results()
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		wantDiffs := []SchemaDiff{{
+			ObjectType: "column",
+			Object:     "person.email",
+			Status:     "changed",
+			Changes:    []string{"type TEXT => INT", "NOT NULL added", "DEFAULT 0 added"},
+		}}
+		if diff := testutil.Diff(diffs, wantDiffs); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("changed index", func(t *testing.T) {
+		t.Parallel()
+		checkCmd := &CheckCmd{
+			DB:      newDB(t),
+			Dialect: DialectSQLite,
+		}
+		// Make person_name_idx a unique, descending, partial index.
+		destCatalog := &Catalog{Dialect: DialectSQLite}
+		err := writeCatalog(destCatalog, fsys, "sqddl_history", "tables.go")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		cache := NewCatalogCache(destCatalog)
+		table := cache.GetTable(cache.GetSchema(destCatalog, ""), "person")
+		index := cache.GetIndex(table, "person_name_idx")
+		index.IsUnique = true
+		index.Descending = []bool{true}
+		index.Predicate = "name IS NOT NULL"
+		checkCmd.DestCatalog = destCatalog
+		diffs, err := checkCmd.Results()
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		wantDiffs := []SchemaDiff{{
+			ObjectType: "index",
+			Object:     "person.person_name_idx",
+			Status:     "changed",
+			Changes:    []string{"UNIQUE added", "columns (name) => (name DESC)", "WHERE name IS NOT NULL added"},
+		}}
+		if diff := testutil.Diff(diffs, wantDiffs); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("missing flags", func(t *testing.T) {
+		t.Parallel()
+		_, err := CheckCommand("-dest", "tables.go")
+		if err == nil {
+			t.Error(testutil.Callers(), "expected error but got nil")
+		}
+		_, err = CheckCommand("-db", "file:/"+t.Name()+".db?vfs=memdb")
+		if err == nil {
+			t.Error(testutil.Callers(), "expected error but got nil")
+		}
+	})
+}
+
+func Test_diffCatalogs(t *testing.T) {
+	currentSchemas := map[string]string{
+		DialectSQLite:    "",
+		DialectPostgres:  "public",
+		DialectMySQL:     "sakila",
+		DialectSQLServer: "dbo",
+	}
+	newCatalog := func(t *testing.T, dialect, filename string) *Catalog {
+		file, err := os.Open(filename)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		defer file.Close()
+		p := NewStructParser(nil)
+		err = p.ParseFile(file)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		catalog := &Catalog{Dialect: dialect, CurrentSchema: currentSchemas[dialect]}
+		err = p.WriteCatalog(catalog)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		return catalog
+	}
+	// Every golden migration test must be described by check, and check
+	// must find nothing to migrate wherever the generators do neither.
+	dirs, err := filepath.Glob("testdata/*/dest.go.txt")
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+	for _, dir := range dirs {
+		dir := filepath.Dir(dir)
+		dialect, _, _ := strings.Cut(filepath.Base(dir), "_")
+		if _, ok := currentSchemas[dialect]; !ok {
+			continue
+		}
+		t.Run(dir, func(t *testing.T) {
+			t.Parallel()
+			for _, filenames := range [][2]string{
+				{"src.go.txt", "dest.go.txt"},
+				{"src.go.txt", "src.go.txt"},
+				{"dest.go.txt", "dest.go.txt"},
+			} {
+				srcCatalog := newCatalog(t, dialect, dir+"/"+filenames[0])
+				destCatalog := newCatalog(t, dialect, dir+"/"+filenames[1])
+				generated, _, _, err := generateMigration(dialect, tableCatalog(srcCatalog), tableCatalog(destCatalog), true, false, "")
+				if err != nil {
+					t.Fatal(testutil.Callers(), err)
+				}
+				diffs, err := diffCatalogs(dialect, srcCatalog, destCatalog)
+				if err != nil {
+					t.Fatal(testutil.Callers(), err)
+				}
+				if (len(diffs) > 0) != (len(generated) > 0) {
+					t.Errorf("%s %s => %s: check found %d differences but generate found %d migrations %v", testutil.Callers(), filenames[0], filenames[1], len(diffs), len(generated), generated)
+				}
+				for _, diff := range diffs {
+					if diff.ObjectType == "migration" {
+						t.Errorf("%s %s => %s: undescribed difference %s", testutil.Callers(), filenames[0], filenames[1], diff.Object)
+					}
+				}
+			}
+		})
+	}
+
+	t.Run("sqlite catalogs unchanged", func(t *testing.T) {
+		t.Parallel()
+		newSQLiteCatalog := func() *Catalog {
+			return &Catalog{Dialect: DialectSQLite, Schemas: []Schema{{Tables: []Table{{
+				TableName:   "actor",
+				Columns:     []Column{{TableName: "actor", ColumnName: "email", ColumnType: "TEXT"}},
+				Constraints: []Constraint{{TableName: "actor", ConstraintType: UNIQUE, Columns: []string{"email"}}},
+			}}}}}
+		}
+		srcCatalog, destCatalog := newSQLiteCatalog(), newSQLiteCatalog()
+		diffs, err := diffCatalogs(DialectSQLite, srcCatalog, destCatalog)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if len(diffs) > 0 {
+			t.Errorf(testutil.Callers()+" unexpected differences: %v", diffs)
+		}
+		for _, catalog := range []*Catalog{srcCatalog, destCatalog} {
+			if diff := testutil.Diff(catalog.Schemas[0].Tables[0].Constraints[0].ConstraintName, ""); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+		}
+	})
+
+	t.Run("unsupported dialect", func(t *testing.T) {
+		t.Parallel()
+		_, err := diffCatalogs("oracle", &Catalog{}, &Catalog{})
+		if err == nil {
+			t.Error(testutil.Callers(), "expected error but got nil")
+		}
+	})
+}
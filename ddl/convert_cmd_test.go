@@ -0,0 +1,385 @@
+package ddl
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/blink-io/sqddl/internal/testutil"
+)
+
+func TestConvertCmd(t *testing.T) {
+	// Every testdata schema converted into every other dialect should produce
+	// SQL that parses back into the converted Catalog.
+	dialects := []string{DialectSQLite, DialectPostgres, DialectMySQL, DialectSQLServer}
+	for _, srcDialect := range dialects {
+		for _, destDialect := range dialects {
+			if srcDialect == destDialect {
+				continue
+			}
+			srcDialect, destDialect := srcDialect, destDialect
+			t.Run(srcDialect+"_to_"+destDialect, func(t *testing.T) {
+				t.Parallel()
+				b, err := os.ReadFile("testdata/" + srcDialect + "/schema.json")
+				if err != nil {
+					t.Fatal(testutil.Callers(), err)
+				}
+				srcCatalog := &Catalog{}
+				err = json.Unmarshal(b, srcCatalog)
+				if err != nil {
+					t.Fatal(testutil.Callers(), err)
+				}
+				buf := &bytes.Buffer{}
+				cmd := &ConvertCmd{
+					SrcCatalog: srcCatalog,
+					Dialect:    destDialect,
+					Stdout:     buf,
+					Stderr:     io.Discard,
+				}
+				err = cmd.Run()
+				if err != nil {
+					t.Fatal(testutil.Callers(), err)
+				}
+				wantCatalog, _, err := srcCatalog.ConvertTo(destDialect)
+				if err != nil {
+					t.Fatal(testutil.Callers(), err)
+				}
+				file, err := fstest.MapFS{"schema.sql": {Data: buf.Bytes()}}.Open("schema.sql")
+				if err != nil {
+					t.Fatal(testutil.Callers(), err)
+				}
+				p := NewSQLParser()
+				err = p.ParseFile(file)
+				if err != nil {
+					t.Fatal(testutil.Callers(), err)
+				}
+				gotCatalog := &Catalog{Dialect: destDialect}
+				err = p.WriteCatalog(gotCatalog)
+				if err != nil {
+					t.Fatal(testutil.Callers(), err)
+				}
+				if destDialect == DialectSQLServer {
+					// SQL Server computed columns are written without a type.
+					for _, schema := range wantCatalog.Schemas {
+						for _, table := range schema.Tables {
+							for i := range table.Columns {
+								if table.Columns[i].GeneratedExpr != "" {
+									table.Columns[i].ColumnType = ""
+								}
+							}
+						}
+					}
+				}
+				gotSQL, err := migrationSQL(gotCatalog, wantCatalog)
+				if err != nil {
+					t.Fatal(testutil.Callers(), err)
+				}
+				if diff := testutil.Diff(gotSQL, ""); diff != "" {
+					t.Error(testutil.Callers(), diff)
+				}
+			})
+		}
+	}
+}
+
+func TestCatalog_ConvertTo(t *testing.T) {
+	type TT struct {
+		description  string
+		srcCatalog   *Catalog
+		dialect      string
+		wantTable    Table
+		wantWarnings []string
+	}
+
+	tests := []TT{{
+		description: "mysql to postgres",
+		srcCatalog: &Catalog{
+			Dialect:          DialectMySQL,
+			CurrentSchema:    "sakila",
+			DefaultCollation: "utf8mb4_0900_ai_ci",
+			Schemas: []Schema{{
+				SchemaName: "sakila",
+				Tables: []Table{{
+					TableSchema: "sakila",
+					TableName:   "film",
+					Columns: []Column{
+						{ColumnName: "film_id", ColumnType: "int unsigned", IsNotNull: true, IsAutoincrement: true},
+						{ColumnName: "title", ColumnType: "text", IsNotNull: true, CollationName: "utf8mb4_0900_ai_ci"},
+						{ColumnName: "code", ColumnType: "varchar(10)", CollationName: "utf8mb4_bin"},
+						{ColumnName: "rating", ColumnType: "enum('G','PG-13')", ColumnDefault: "'G'"},
+						{ColumnName: "is_active", ColumnType: "tinyint(1)", ColumnDefault: "1"},
+						{ColumnName: "rental_rate", ColumnType: "decimal(4,2)", ColumnDefault: "4.99"},
+						{ColumnName: "features", ColumnType: "set('Trailers','Commentaries')"},
+						{ColumnName: "last_update", ColumnType: "timestamp", ColumnDefault: "CURRENT_TIMESTAMP", OnUpdateCurrentTimestamp: true},
+					},
+					Constraints: []Constraint{
+						{TableSchema: "sakila", TableName: "film", ConstraintName: "PRIMARY", ConstraintType: PRIMARY_KEY, Columns: []string{"film_id"}},
+					},
+					Indexes: []Index{
+						{TableSchema: "sakila", TableName: "film", IndexName: "film_title_idx", IndexType: "FULLTEXT", Columns: []string{"title"}},
+					},
+				}},
+			}},
+		},
+		dialect: DialectPostgres,
+		wantTable: Table{
+			TableName: "film",
+			Columns: []Column{
+				{ColumnName: "film_id", ColumnType: "BIGINT", IsNotNull: true, IsPrimaryKey: true, ColumnIdentity: DEFAULT_IDENTITY},
+				{ColumnName: "title", ColumnType: "TEXT", IsNotNull: true},
+				{ColumnName: "code", ColumnType: "VARCHAR(10)", CharacterLength: "10", CollationName: "C"},
+				{ColumnName: "rating", ColumnType: "VARCHAR(5)", CharacterLength: "5", ColumnDefault: "'G'"},
+				{ColumnName: "is_active", ColumnType: "BOOLEAN", ColumnDefault: "TRUE"},
+				{ColumnName: "rental_rate", ColumnType: "NUMERIC(4,2)", NumericPrecision: "4", NumericScale: "2", ColumnDefault: "4.99"},
+				{ColumnName: "features", ColumnType: "TEXT"},
+				{ColumnName: "last_update", ColumnType: "TIMESTAMPTZ", ColumnDefault: "CURRENT_TIMESTAMP"},
+			},
+			Constraints: []Constraint{
+				{TableName: "film", ConstraintName: "film_film_id_pkey", ConstraintType: PRIMARY_KEY, Columns: []string{"film_id"}},
+				{TableName: "film", ConstraintName: "film_rating_check", ConstraintType: CHECK, Columns: []string{"rating"}, CheckExpr: "rating IN ('G', 'PG-13')"},
+			},
+			Indexes: []Index{
+				{TableName: "film", IndexName: "film_title_idx", Columns: []string{"title"}},
+			},
+		},
+		wantWarnings: []string{
+			`film: column "features": Postgres has no SET type, converted to text (set membership is no longer enforced)`,
+			`film: column "last_update": ON UPDATE CURRENT_TIMESTAMP is not supported by Postgres, dropped (use a trigger instead)`,
+			`film: index "film_title_idx": index type FULLTEXT is not supported by Postgres, converted to a regular index`,
+		},
+	}, {
+		description: "postgres to mysql",
+		srcCatalog: &Catalog{
+			Dialect:       DialectPostgres,
+			CurrentSchema: "public",
+			Extensions:    []string{"uuid-ossp"},
+			Schemas: []Schema{{
+				SchemaName: "public",
+				Tables: []Table{{
+					TableSchema: "public",
+					TableName:   "task",
+					Columns: []Column{
+						{ColumnName: "task_id", ColumnType: "uuid", IsNotNull: true, ColumnDefault: "gen_random_uuid()"},
+						{ColumnName: "seq", ColumnType: "integer", ColumnDefault: "nextval('task_seq_seq'::regclass)"},
+						{ColumnName: "name", ColumnType: "text", IsNotNull: true},
+						{ColumnName: "year", ColumnType: "int", DomainName: "year"},
+						{ColumnName: "tags", ColumnType: "text[]", ColumnDefault: "'{}'::text[]"},
+						{ColumnName: "data", ColumnType: "jsonb"},
+						{ColumnName: "due", ColumnType: "timestamp with time zone", ColumnDefault: "now()"},
+						{ColumnName: "slug", ColumnType: "varchar(50)", ColumnDefault: "lower('x'::text)"},
+					},
+					Constraints: []Constraint{
+						{TableSchema: "public", TableName: "task", ConstraintName: "task_task_id_pkey", ConstraintType: PRIMARY_KEY, Columns: []string{"task_id"}},
+						{TableSchema: "public", TableName: "task", ConstraintName: "task_name_key", ConstraintType: UNIQUE, Columns: []string{"name"}},
+					},
+					Indexes: []Index{
+						{TableSchema: "public", TableName: "task", IndexName: "task_due_idx", Columns: []string{"due"}, IncludeColumns: []string{"name"}, Predicate: "due IS NOT NULL"},
+					},
+				}},
+				Domains: []Domain{{
+					DomainSchema:   "public",
+					DomainName:     "year",
+					UnderlyingType: "integer",
+					CheckNames:     []string{"year_check"},
+					CheckExprs:     []string{"((VALUE >= 1901) AND (VALUE <= 2155))"},
+				}},
+			}},
+		},
+		dialect: DialectMySQL,
+		wantTable: Table{
+			TableName: "task",
+			Columns: []Column{
+				{ColumnName: "task_id", ColumnType: "BINARY(16)", CharacterLength: "16", IsNotNull: true, IsPrimaryKey: true, ColumnDefault: "(UUID_TO_BIN(UUID()))"},
+				{ColumnName: "seq", ColumnType: "INT", IsAutoincrement: true},
+				{ColumnName: "name", ColumnType: "VARCHAR(255)", CharacterLength: "255", IsNotNull: true},
+				{ColumnName: "year", ColumnType: "INT"},
+				{ColumnName: "tags", ColumnType: "JSON", ColumnDefault: "('[]')"},
+				{ColumnName: "data", ColumnType: "JSON"},
+				{ColumnName: "due", ColumnType: "DATETIME(6)", ColumnDefault: "CURRENT_TIMESTAMP(6)"},
+				{ColumnName: "slug", ColumnType: "VARCHAR(50)", CharacterLength: "50"},
+			},
+			Constraints: []Constraint{
+				{TableName: "task", ConstraintName: "PRIMARY", ConstraintType: PRIMARY_KEY, Columns: []string{"task_id"}},
+				{TableName: "task", ConstraintName: "task_name_key", ConstraintType: UNIQUE, Columns: []string{"name"}},
+				{TableName: "task", ConstraintName: "task_year_check", ConstraintType: CHECK, Columns: []string{"year"}, CheckExpr: "(year >= 1901) AND (year <= 2155)"},
+			},
+			Indexes: []Index{
+				{TableName: "task", IndexName: "task_due_idx", Columns: []string{"due"}},
+			},
+		},
+		wantWarnings: []string{
+			`extension "uuid-ossp": extensions are not supported by MySQL, dropped`,
+			`task: column "name": MySQL cannot index unbounded text, converted to VARCHAR(255) (longer values will not fit)`,
+			`task: column "tags": MySQL has no array types, TEXT[] converted to JSON (values must be stored as JSON arrays)`,
+			`task: column "due": MySQL has no TIMESTAMP WITH TIME ZONE type, converted to DATETIME (values should be stored in UTC)`,
+			`task: column "slug": DEFAULT lower('x'::text) cannot be converted to MySQL, dropped`,
+			`task: index "task_due_idx": INCLUDE columns are not supported by MySQL, dropped`,
+			`task: index "task_due_idx": partial indexes are not supported by MySQL, predicate "due IS NOT NULL" dropped`,
+		},
+	}, {
+		description: "sqlserver to sqlite",
+		srcCatalog: &Catalog{
+			Dialect:       DialectSQLServer,
+			CurrentSchema: "dbo",
+			Schemas: []Schema{{
+				SchemaName: "dbo",
+				Tables: []Table{{
+					TableSchema: "dbo",
+					TableName:   "staff",
+					Comment:     "Staff members.",
+					Columns: []Column{
+						{ColumnName: "staff_id", ColumnType: "int", IsNotNull: true, ColumnIdentity: IDENTITY},
+						{ColumnName: "store_id", ColumnType: "int", ReferencesSchema: "audit", ReferencesTable: "store", ReferencesColumn: "store_id"},
+						{ColumnName: "active", ColumnType: "bit", IsNotNull: true, ColumnDefault: "((1))"},
+						{ColumnName: "name", ColumnType: "nvarchar(MAX)", ColumnDefault: "(N'anonymous')", CollationName: "SQL_Latin1_General_CP1_CI_AS"},
+						{ColumnName: "picture", ColumnType: "varbinary(max)"},
+						{ColumnName: "last_update", ColumnType: "datetimeoffset", ColumnDefault: "(getdate())"},
+					},
+					Constraints: []Constraint{
+						{TableSchema: "dbo", TableName: "staff", ConstraintName: "staff_staff_id_pkey", ConstraintType: PRIMARY_KEY, Columns: []string{"staff_id"}, IsClustered: true},
+						{TableSchema: "dbo", TableName: "staff", ConstraintName: "staff_store_id_fkey", ConstraintType: FOREIGN_KEY, Columns: []string{"store_id"}, ReferencesSchema: "audit", ReferencesTable: "store", ReferencesColumns: []string{"store_id"}},
+					},
+				}},
+			}, {
+				SchemaName: "audit",
+				Tables: []Table{{
+					TableSchema: "audit",
+					TableName:   "store",
+					Columns: []Column{
+						{ColumnName: "store_id", ColumnType: "int", IsNotNull: true},
+					},
+				}},
+			}},
+		},
+		dialect: DialectSQLite,
+		wantTable: Table{
+			TableName: "staff",
+			Columns: []Column{
+				{ColumnName: "staff_id", ColumnType: "INTEGER", IsNotNull: true, IsPrimaryKey: true},
+				{ColumnName: "store_id", ColumnType: "INT", ReferencesTable: "store", ReferencesColumn: "store_id"},
+				{ColumnName: "active", ColumnType: "BOOLEAN", IsNotNull: true, ColumnDefault: "TRUE"},
+				{ColumnName: "name", ColumnType: "TEXT", ColumnDefault: "'anonymous'", CollationName: "NOCASE"},
+				{ColumnName: "picture", ColumnType: "BLOB"},
+				{ColumnName: "last_update", ColumnType: "DATETIME", ColumnDefault: "CURRENT_TIMESTAMP"},
+			},
+			Constraints: []Constraint{
+				{TableName: "staff", ConstraintName: "staff_staff_id_pkey", ConstraintType: PRIMARY_KEY, Columns: []string{"staff_id"}},
+				{TableName: "staff", ConstraintName: "staff_store_id_fkey", ConstraintType: FOREIGN_KEY, Columns: []string{"store_id"}, ReferencesTable: "store", ReferencesColumns: []string{"store_id"}},
+			},
+			Indexes: []Index{},
+		},
+		wantWarnings: []string{
+			`staff: comments are not supported by SQLite, dropped`,
+			`schema "audit": SQLite does not support schemas, its tables are moved into the main schema`,
+		},
+	}, {
+		description: "postgres expressions to sqlite",
+		srcCatalog: &Catalog{
+			Dialect:       DialectPostgres,
+			CurrentSchema: "public",
+			Schemas: []Schema{{
+				SchemaName: "public",
+				Tables: []Table{{
+					TableSchema: "public",
+					TableName:   "payment",
+					Columns: []Column{
+						{ColumnName: "payment_id", ColumnType: "integer", IsNotNull: true},
+						{ColumnName: "email", ColumnType: "text"},
+						{ColumnName: "status", ColumnType: "text"},
+						{ColumnName: "amount", ColumnType: "numeric(5,2)"},
+						{ColumnName: "total", ColumnType: "numeric(5,2)", IsGenerated: true, GeneratedExpr: "(amount * (2)::numeric)"},
+						{ColumnName: "paid_at", ColumnType: "timestamp with time zone"},
+					},
+					Constraints: []Constraint{
+						{TableSchema: "public", TableName: "payment", ConstraintName: "payment_amount_check", ConstraintType: CHECK, Columns: []string{"amount"}, CheckExpr: `("amount" > (0)::numeric)`},
+						{TableSchema: "public", TableName: "payment", ConstraintName: "payment_status_check", ConstraintType: CHECK, Columns: []string{"status"}, CheckExpr: "(status = ANY (ARRAY['paid'::text, 'refunded'::text]))"},
+					},
+					Indexes: []Index{
+						{TableSchema: "public", TableName: "payment", IndexName: "payment_email_idx", Columns: []string{"(lower(email))"}, Predicate: "(status <> 'refunded'::text)"},
+						{TableSchema: "public", TableName: "payment", IndexName: "payment_paid_at_idx", Columns: []string{"(date_trunc('day'::text, paid_at))"}},
+					},
+				}},
+			}},
+		},
+		dialect: DialectSQLite,
+		wantTable: Table{
+			TableName: "payment",
+			Columns: []Column{
+				{ColumnName: "payment_id", ColumnType: "INT", IsNotNull: true},
+				{ColumnName: "email", ColumnType: "TEXT"},
+				{ColumnName: "status", ColumnType: "TEXT"},
+				{ColumnName: "amount", ColumnType: "NUMERIC(5,2)", NumericPrecision: "5", NumericScale: "2"},
+				{ColumnName: "total", ColumnType: "NUMERIC(5,2)", NumericPrecision: "5", NumericScale: "2", IsGenerated: true, GeneratedExpr: "(amount * (2))"},
+				{ColumnName: "paid_at", ColumnType: "DATETIME"},
+			},
+			Constraints: []Constraint{
+				{TableName: "payment", ConstraintName: "payment_amount_check", ConstraintType: CHECK, Columns: []string{"amount"}, CheckExpr: "(amount > (0))"},
+				{TableName: "payment", ConstraintName: "payment_status_check", ConstraintType: CHECK, Columns: []string{"status"}, CheckExpr: "(status = ANY (ARRAY['paid', 'refunded']))"},
+			},
+			Indexes: []Index{
+				{TableName: "payment", IndexName: "payment_email_idx", Columns: []string{"(lower(email))"}, Predicate: "(status <> 'refunded')"},
+				{TableName: "payment", IndexName: "payment_paid_at_idx", Columns: []string{"(date_trunc('day', paid_at))"}},
+			},
+		},
+		wantWarnings: []string{
+			`payment: CHECK constraint "payment_status_check": the expression (status = ANY (ARRAY['paid', 'refunded'])) was copied as-is, check that it is valid SQLite`,
+			`payment: index "payment_paid_at_idx": the expression (date_trunc('day', paid_at)) was copied as-is, check that it is valid SQLite`,
+		},
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.description, func(t *testing.T) {
+			t.Parallel()
+			gotCatalog, gotWarnings, err := tt.srcCatalog.ConvertTo(tt.dialect)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			if diff := testutil.Diff(gotWarnings, tt.wantWarnings); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+			if gotCatalog.Dialect != tt.dialect {
+				t.Errorf(testutil.Callers()+" got dialect %q, want %q", gotCatalog.Dialect, tt.dialect)
+			}
+			if len(gotCatalog.Schemas) == 0 || len(gotCatalog.Schemas[0].Tables) == 0 {
+				t.Fatal(testutil.Callers(), "no tables converted")
+			}
+			gotTable := gotCatalog.Schemas[0].Tables[0]
+			if diff := testutil.Diff(gotTable, tt.wantTable); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+		})
+	}
+}
+
+func TestCatalog_ConvertTo_Errors(t *testing.T) {
+	catalog := &Catalog{Dialect: DialectPostgres}
+	_, _, err := catalog.ConvertTo("oracle")
+	if err == nil {
+		t.Error(testutil.Callers(), "expected error but got nil")
+	}
+	catalog = &Catalog{}
+	_, _, err = catalog.ConvertTo(DialectPostgres)
+	if err == nil {
+		t.Error(testutil.Callers(), "expected error but got nil")
+	}
+	// Converting into the same dialect returns an identical copy.
+	catalog = &Catalog{Dialect: DialectSQLite, Schemas: []Schema{{Tables: []Table{{TableName: "actor"}}}}}
+	gotCatalog, warnings, err := catalog.ConvertTo(DialectSQLite)
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+	if len(warnings) > 0 {
+		t.Error(testutil.Callers(), warnings)
+	}
+	if diff := testutil.Diff(gotCatalog, catalog); diff != "" {
+		t.Error(testutil.Callers(), diff)
+	}
+	gotCatalog.Schemas[0].Tables[0].TableName = "film"
+	if catalog.Schemas[0].Tables[0].TableName != "actor" {
+		t.Error(testutil.Callers(), "original catalog was modified")
+	}
+}
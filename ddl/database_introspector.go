@@ -8,9 +8,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/fs"
+	"slices"
 	"strconv"
 	"strings"
 	"text/template"
+	"unicode"
 )
 
 //go:embed introspection_scripts
@@ -118,6 +120,18 @@ func (dbi *DatabaseIntrospector) WriteCatalog(catalog *Catalog) error {
 		}
 	}
 
+	if includeObjectType("SEQUENCES") {
+		sequences, err := dbi.GetSequences()
+		if err != nil {
+			return err
+		}
+		for _, sequence := range sequences {
+			schema := cache.GetOrCreateSchema(catalog, sequence.SequenceSchema)
+			schema.SequencesValid = true
+			cache.AddOrUpdateSequence(schema, sequence)
+		}
+	}
+
 	if includeObjectType("ROUTINES") {
 		routines, err := dbi.GetRoutines()
 		if err != nil {
@@ -185,6 +199,28 @@ func (dbi *DatabaseIntrospector) WriteCatalog(catalog *Catalog) error {
 			}
 			cache.AddOrUpdateConstraint(table, constraint)
 		}
+
+		// SQLite does not expose check constraints anywhere other than the
+		// CREATE TABLE statement itself, so we have to dig them out from the
+		// tables we already have.
+		if dbi.Dialect == DialectSQLite && dbi.Filter.IncludeConstraintType(CHECK) {
+			for i := range tables {
+				if isVirtualTable(&tables[i]) {
+					continue
+				}
+				schema := cache.GetSchema(catalog, tables[i].TableSchema)
+				if schema == nil {
+					continue
+				}
+				table := cache.GetTable(schema, tables[i].TableName)
+				if table == nil {
+					continue
+				}
+				for _, constraint := range sqliteCheckConstraints(tables[i].TableName, tables[i].SQL) {
+					cache.AddOrUpdateConstraint(table, constraint)
+				}
+			}
+		}
 	}
 
 	if includeObjectType("TABLES") || includeObjectType("VIEWS") {
@@ -237,6 +273,56 @@ func (dbi *DatabaseIntrospector) WriteCatalog(catalog *Catalog) error {
 		}
 	}
 
+	// Grants are only kept for the schemas, tables, views and sequences
+	// that are in the catalog.
+	if includeObjectType("GRANTS") && dbi.Dialect != DialectSQLite {
+		grants, err := dbi.GetGrants()
+		if err != nil {
+			return err
+		}
+		for i := range catalog.Schemas {
+			catalog.Schemas[i].GrantsValid = true
+		}
+		for _, grant := range grants {
+			schema := cache.GetSchema(catalog, grant.GrantSchema)
+			if schema == nil {
+				continue
+			}
+			switch grant.ObjectType {
+			case "TABLE":
+				if cache.GetTable(schema, grant.ObjectName) == nil && cache.GetView(schema, grant.ObjectName) == nil {
+					continue
+				}
+			case "SEQUENCE":
+				if cache.GetSequence(schema, grant.ObjectName) == nil {
+					continue
+				}
+			}
+			cache.AddOrUpdateGrant(schema, grant)
+		}
+	}
+
+	if includeObjectType("POLICIES") && dbi.Dialect == DialectPostgres {
+		policies, err := dbi.GetPolicies()
+		if err != nil {
+			return err
+		}
+		for i := range catalog.Schemas {
+			catalog.Schemas[i].PoliciesValid = true
+		}
+		for _, policy := range policies {
+			schema := cache.GetSchema(catalog, policy.TableSchema)
+			if schema == nil {
+				continue
+			}
+			table := cache.GetTable(schema, policy.TableName)
+			if table == nil {
+				continue
+			}
+			cache.AddOrUpdatePolicy(table, policy)
+		}
+	}
+
 	// Set IsPrimaryKey and IsUnique fields for each primary key or unique
 	// column.
 	for _, schema := range catalog.Schemas {
@@ -613,6 +699,7 @@ func (dbi *DatabaseIntrospector) GetColumns() ([]Column, error) {
 				&column.GeneratedExprStored,
 				&column.CollationName,
 				&column.ColumnDefault,
+				&column.Comment,
 			)
 			if err != nil {
 				return nil, fmt.Errorf("scanning Column: %w", err)
@@ -824,7 +911,142 @@ func (dbi *DatabaseIntrospector) GetConstraints() ([]Constraint, error) {
 		}
 		constraints = append(constraints, constraint)
 	}
-	return constraints, closeRows(rows)
+	err = closeRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	return constraints, nil
+}
+
+// sqliteCheckConstraints extracts the named check constraints from an SQLite
+// CREATE TABLE statement. Unnamed check constraints are skipped because
+// constraints are identified by their name.
+func sqliteCheckConstraints(tableName, createTableSQL string) []Constraint {
+	var constraints []Constraint
+	s := createTableSQL
+	depth := 0
+	// skip returns the index just past the quoted string, quoted identifier
+	// or comment starting at s[i], or i if there isn't one.
+	skip := func(i int) int {
+		switch s[i] {
+		case '\'', '"', '`':
+			end := strings.IndexByte(s[i+1:], s[i])
+			if end < 0 {
+				return len(s)
+			}
+			return i + 1 + end + 1
+		case '[':
+			end := strings.IndexByte(s[i+1:], ']')
+			if end < 0 {
+				return len(s)
+			}
+			return i + 1 + end + 1
+		case '-':
+			if strings.HasPrefix(s[i:], "--") {
+				end := strings.IndexByte(s[i:], '\n')
+				if end < 0 {
+					return len(s)
+				}
+				return i + end + 1
+			}
+		case '/':
+			if strings.HasPrefix(s[i:], "/*") {
+				end := strings.Index(s[i+2:], "*/")
+				if end < 0 {
+					return len(s)
+				}
+				return i + 2 + end + 2
+			}
+		}
+		return i
+	}
+	// word returns the word (or quoted identifier) starting at s[i] after
+	// skipping whitespace, and the index just past it.
+	isWordChar := func(char byte) bool {
+		return char == '_' || char >= 0x80 || unicode.IsLetter(rune(char)) || unicode.IsDigit(rune(char))
+	}
+	word := func(i int) (string, int) {
+		for i < len(s) {
+			if unicode.IsSpace(rune(s[i])) {
+				i++
+				continue
+			}
+			end := skip(i)
+			if end == i {
+				break
+			}
+			if s[i] != '-' && s[i] != '/' {
+				return s[i+1 : end-1], end
+			}
+			i = end
+		}
+		start := i
+		for i < len(s) && isWordChar(s[i]) {
+			i++
+		}
+		return s[start:i], i
+	}
+	for i := 0; i < len(s); {
+		if end := skip(i); end > i {
+			i = end
+			continue
+		}
+		switch s[i] {
+		case '(':
+			depth++
+			i++
+			continue
+		case ')':
+			depth--
+			i++
+			continue
+		}
+		if depth != 1 || (i > 0 && isWordChar(s[i-1])) || len(s)-i < len("CONSTRAINT") || !strings.EqualFold(s[i:i+len("CONSTRAINT")], "CONSTRAINT") {
+			i++
+			continue
+		}
+		constraintName, j := word(i + len("CONSTRAINT"))
+		keyword, j := word(j)
+		if constraintName == "" || !strings.EqualFold(keyword, CHECK) {
+			i = j
+			continue
+		}
+		for j < len(s) && unicode.IsSpace(rune(s[j])) {
+			j++
+		}
+		if j >= len(s) || s[j] != '(' {
+			i = j
+			continue
+		}
+		// Find the closing bracket of the check expression.
+		start, exprDepth := j, 0
+		for j < len(s) {
+			if end := skip(j); end > j {
+				j = end
+				continue
+			}
+			if s[j] == '(' {
+				exprDepth++
+			} else if s[j] == ')' {
+				exprDepth--
+				if exprDepth == 0 {
+					break
+				}
+			}
+			j++
+		}
+		if j >= len(s) {
+			break
+		}
+		constraints = append(constraints, Constraint{
+			TableName:      tableName,
+			ConstraintName: constraintName,
+			ConstraintType: CHECK,
+			CheckExpr:      strings.TrimSpace(s[start+1 : j]),
+		})
+		i = j + 1
+	}
+	return constraints
 }
 
 // GetDomains returns the domains in the database. Postgres only.
@@ -872,23 +1094,154 @@ func (dbi *DatabaseIntrospector) GetDomains() ([]Domain, error) {
 		if err != nil {
 			return nil, fmt.Errorf("unmarshaling domain constraints: %w", err)
 		}
-		for i, checkExpr := range domain.CheckExprs {
-			domain.CheckExprs[i] = strings.TrimPrefix(checkExpr, "CHECK ")
-		}
-		// When deserializing a Catalog from JSON, empty slices are set as
-		// nil (because of omitempty). If we want to follow that precedent
-		// we have to set empty slices to nil here as well.
-		if len(domain.CheckNames) == 0 {
-			domain.CheckNames = nil
-		}
-		if len(domain.CheckExprs) == 0 {
-			domain.CheckExprs = nil
+		// A domain without check constraints still gets one row of empty
+		// strings from the LEFT JOIN, skip those.
+		checkNames, checkExprs := domain.CheckNames, domain.CheckExprs
+		domain.CheckNames, domain.CheckExprs = nil, nil
+		for i, checkExpr := range checkExprs {
+			if checkExpr == "" || i >= len(checkNames) {
+				continue
+			}
+			domain.CheckNames = append(domain.CheckNames, checkNames[i])
+			domain.CheckExprs = append(domain.CheckExprs, strings.TrimPrefix(checkExpr, "CHECK "))
 		}
 		domains = append(domains, domain)
 	}
 	return domains, closeRows(rows)
 }
 
+// GetSequences returns the sequences in the database, excluding the
+// sequences of identity columns. Postgres 10 and above only.
+//
+// To search for specific sequences, add the sequence names into the
+// DatabaseIntrospector.Filter.Sequences slice. To exclude specific sequences
+// from your search, add the sequence names into the
+// DatabaseIntrospector.Filter.ExcludeSequences slice.
+//
+// To narrow down your search to a specific schema, pass the schema name into
+// the DatabaseIntrospector.Filter.Schemas slice.
+func (dbi *DatabaseIntrospector) GetSequences() ([]Sequence, error) {
+	ctx := context.Background()
+	if dbi.Dialect != DialectPostgres || dbi.Filter.VersionNums.LowerThan(10) {
+		return nil, nil
+	}
+	var sequences []Sequence
+	rows, err := dbi.queryContext(ctx, "introspection_scripts/postgres_sequences.sql", &dbi.Filter)
+	if err != nil {
+		return nil, err
+	}
+	defer closeQuietly(rows.Close)
+	for rows.Next() {
+		var sequence Sequence
+		err = rows.Scan(
+			&sequence.SequenceSchema,
+			&sequence.SequenceName,
+			&sequence.DataType,
+			&sequence.StartValue,
+			&sequence.Increment,
+			&sequence.MinValue,
+			&sequence.MaxValue,
+			&sequence.IsCycle,
+			&sequence.OwnedByTable,
+			&sequence.OwnedByColumn,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scanning sequence: %w", err)
+		}
+		sequences = append(sequences, sequence)
+	}
+	return sequences, closeRows(rows)
+}
+
+// GetGrants returns the privileges granted on the schemas, tables, views and
+// sequences in the database. The privileges of the object owner are left
+// out. Postgres, MySQL and SQL Server only.
+//
+// To narrow down your search to a specific schema, pass the schema name into
+// the DatabaseIntrospector.Filter.Schemas slice.
+func (dbi *DatabaseIntrospector) GetGrants() ([]Grant, error) {
+	ctx := context.Background()
+	var err error
+	var rows *sql.Rows
+	switch dbi.Dialect {
+	case DialectPostgres:
+		rows, err = dbi.queryContext(ctx, "introspection_scripts/postgres_grants.sql", &dbi.Filter)
+	case DialectMySQL:
+		rows, err = dbi.queryContext(ctx, "introspection_scripts/mysql_grants.sql", &dbi.Filter)
+	case DialectSQLServer:
+		rows, err = dbi.queryContext(ctx, "introspection_scripts/sqlserver_grants.sql", &dbi.Filter)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer closeQuietly(rows.Close)
+	var grants []Grant
+	for rows.Next() {
+		var grant Grant
+		err = rows.Scan(
+			&grant.GrantSchema,
+			&grant.ObjectType,
+			&grant.ObjectName,
+			&grant.Grantee,
+			&grant.Privilege,
+			&grant.IsGrantable,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scanning grant: %w", err)
+		}
+		grants = append(grants, grant)
+	}
+	return grants, closeRows(rows)
+}
+
+// GetPolicies returns the row-level security policies in the database.
+// Postgres 9.5 and above only.
+//
+// To narrow down your search to a specific schema and table, pass the schema
+// and table names into the DatabaseIntrospector.Filter.Schemas slice and
+// the DatabaseIntrospector.Filter.Tables slice respectively.
+func (dbi *DatabaseIntrospector) GetPolicies() ([]Policy, error) {
+	ctx := context.Background()
+	if dbi.Dialect != DialectPostgres || dbi.Filter.VersionNums.LowerThan(9, 5) {
+		return nil, nil
+	}
+	rows, err := dbi.queryContext(ctx, "introspection_scripts/postgres_policies.sql", &dbi.Filter)
+	if err != nil {
+		return nil, err
+	}
+	defer closeQuietly(rows.Close)
+	var policies []Policy
+	for rows.Next() {
+		var policy Policy
+		var roles []byte
+		err = rows.Scan(
+			&policy.TableSchema,
+			&policy.TableName,
+			&policy.PolicyName,
+			&policy.IsRestrictive,
+			&policy.Command,
+			&roles,
+			&policy.UsingExpr,
+			&policy.CheckExpr,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scanning policy: %w", err)
+		}
+		err = json.Unmarshal(roles, &policy.Roles)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshaling policy roles: %w", err)
+		}
+		// A policy that applies to PUBLIC has no roles.
+		if len(policy.Roles) == 0 || (len(policy.Roles) == 1 && policy.Roles[0] == "PUBLIC") {
+			policy.Roles = nil
+		}
+		policies = append(policies, policy)
+	}
+	return policies, closeRows(rows)
+}
+
 // GetEnums returns the enums in the database. Postgres only.
 //
 // To search for specific enums, add the enum names into the
@@ -1000,12 +1353,13 @@ func (dbi *DatabaseIntrospector) GetIndexes() ([]Index, error) {
 		var index Index
 		switch dbi.Dialect {
 		case DialectSQLite:
-			var columns string
+			var columns, descending string
 			err = rows.Scan(
 				&index.TableName,
 				&index.IndexName,
 				&index.IsUnique,
 				&columns,
+				&descending,
 				&index.SQL,
 			)
 			if err != nil {
@@ -1014,8 +1368,17 @@ func (dbi *DatabaseIntrospector) GetIndexes() ([]Index, error) {
 			if columns != "" {
 				index.Columns = strings.Split(columns, ",")
 			}
+			// Only keep the descending flags if at least one column is
+			// descending.
+			if strings.Contains(descending, "1") {
+				for _, str := range strings.Split(descending, ",") {
+					b, _ := strconv.ParseBool(str)
+					index.Descending = append(index.Descending, b)
+				}
+			}
+			index.Predicate = sqliteIndexPredicate(index.SQL)
 		case DialectPostgres:
-			var columns, opclasses []byte
+			var columns, opclasses, descending []byte
 			var numKeyColumns int
 			err = rows.Scan(
 				&index.TableSchema,
@@ -1027,6 +1390,7 @@ func (dbi *DatabaseIntrospector) GetIndexes() ([]Index, error) {
 				&numKeyColumns,
 				&columns,
 				&opclasses,
+				&descending,
 				&index.Predicate,
 				&index.SQL,
 			)
@@ -1041,7 +1405,22 @@ func (dbi *DatabaseIntrospector) GetIndexes() ([]Index, error) {
 			if err != nil {
 				return nil, fmt.Errorf("unmarshaling %s into %T: %w", opclasses, index.Opclasses, err)
 			}
+			// Default opclasses are introspected as empty strings, so only
+			// keep the opclasses if at least one of them is not the default.
+			if !slices.ContainsFunc(index.Opclasses, func(opclass string) bool { return opclass != "" }) {
+				index.Opclasses = nil
+			}
 			index.Columns, index.IncludeColumns = index.Columns[:numKeyColumns], index.Columns[numKeyColumns:]
+			var isDescending []bool
+			err = json.Unmarshal(descending, &isDescending)
+			if err != nil {
+				return nil, fmt.Errorf("unmarshaling %s into %T: %w", descending, isDescending, err)
+			}
+			// Only keep the descending flags if at least one column is
+			// descending.
+			if slices.Contains(isDescending, true) {
+				index.Descending = isDescending[:numKeyColumns]
+			}
 		case DialectMySQL:
 			var columns, descending string
 			err = rows.Scan(
@@ -1129,6 +1508,42 @@ func (dbi *DatabaseIntrospector) GetIndexes() ([]Index, error) {
 	return indexes, closeRows(rows)
 }
 
+// sqliteIndexPredicate returns the predicate of a partial index from its
+// CREATE INDEX statement, or an empty string if it is not a partial index.
+func sqliteIndexPredicate(createIndexSQL string) string {
+	s := createIndexSQL
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\'', '"', '`', '[':
+			closing := s[i]
+			if closing == '[' {
+				closing = ']'
+			}
+			end := strings.IndexByte(s[i+1:], closing)
+			if end < 0 {
+				return ""
+			}
+			i += end + 1
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			// We have reached the end of the indexed column list, anything
+			// after it must be the WHERE clause.
+			rest := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s[i+1:]), ";"))
+			if len(rest) <= len("WHERE") || !strings.EqualFold(rest[:len("WHERE")], "WHERE") || !unicode.IsSpace(rune(rest[len("WHERE")])) {
+				return ""
+			}
+			return strings.TrimSpace(rest[len("WHERE"):])
+		}
+	}
+	return ""
+}
+
 // GetRoutines returns the routines (functions and procedures) in the database.
 //
 // To search for specific routines, add the routine names into the
@@ -1241,6 +1656,9 @@ func (dbi *DatabaseIntrospector) GetRoutines() ([]Routine, error) {
 //
 // To narrow down your search to a specific schema, pass the schema name into
 // the DatabaseIntrospector.Filter.Schemas slice.
+//
+// For Postgres, the partitions of a partitioned table are only included if
+// DatabaseIntrospector.Filter.IncludePartitions is true.
 func (dbi *DatabaseIntrospector) GetTables() ([]Table, error) {
 	ctx := context.Background()
 	var err error
@@ -1275,17 +1693,22 @@ func (dbi *DatabaseIntrospector) GetTables() ([]Table, error) {
 		var table Table
 		switch dbi.Dialect {
 		case DialectSQLite:
-			err = rows.Scan(&table.TableName, &table.SQL)
+			err = rows.Scan(&table.TableName, &table.SQL, &table.IsStrict, &table.IsWithoutRowid)
 			if err != nil {
 				return nil, fmt.Errorf("scanning Table: %w", err)
 			}
-		case DialectPostgres, DialectMySQL:
-			err = rows.Scan(&table.TableSchema, &table.TableName, &table.Comment)
+		case DialectPostgres:
+			err = rows.Scan(&table.TableSchema, &table.TableName, &table.Comment, &table.PartitionBy, &table.PartitionOf, &table.PartitionBound, &table.RowSecurityEnabled)
+			if err != nil {
+				return nil, fmt.Errorf("scanning Table: %w", err)
+			}
+		case DialectMySQL:
+			err = rows.Scan(&table.TableSchema, &table.TableName, &table.Comment, &table.Engine, &table.CharacterSet, &table.CollationName, &table.RowFormat, &table.AutoIncrement)
 			if err != nil {
 				return nil, fmt.Errorf("scanning Table: %w", err)
 			}
 		case DialectSQLServer:
-			err = rows.Scan(&table.TableSchema, &table.TableName)
+			err = rows.Scan(&table.TableSchema, &table.TableName, &table.Comment)
 			if err != nil {
 				return nil, fmt.Errorf("scanning Table: %w", err)
 			}
@@ -1553,6 +1976,11 @@ type Filter struct {
 	// etc). Default is false.
 	IncludeSystemCatalogs bool
 
+	// IncludePartitions controls whether the DatabaseIntrospector will include
+	// the partitions of partitioned tables in its search. Only the partitioned
+	// tables themselves are included by default. Postgres only.
+	IncludePartitions bool
+
 	// ConstraintTypes controls what constraint types will be included in the
 	// search. An empty slice means all constraint types will be included. The
 	// possible constraint types are: "PRIMARY KEY", "UNIQUE", "FOREIGN KEY",
@@ -1561,8 +1989,8 @@ type Filter struct {
 
 	// ObjectTypes controls what object types will be included in the search.
 	// An empty slice means all object types will be included. The possible
-	// object types are: "EXTENSIONS", "ENUMS", "DOMAINS", "ROUTINES", "VIEWS"
-	// and "TABLES".
+	// object types are: "EXTENSIONS", "ENUMS", "DOMAINS", "SEQUENCES",
+	// "ROUTINES", "VIEWS", "TABLES", "GRANTS" and "POLICIES".
 	ObjectTypes []string
 
 	// Tables is the list of tables to be included in the search. If empty, all
@@ -1607,6 +2035,14 @@ type Filter struct {
 	// ExcludeDomains is the list of domains to be excluded from the search.
 	ExcludeDomains []string
 
+	// Sequences is the list of sequences to be included in the search. If
+	// empty, all sequences will be included.
+	Sequences []string
+
+	// ExcludeSequences is the list of sequences to be excluded from the
+	// search.
+	ExcludeSequences []string
+
 	// Extensions is the list of extensions to include in the search by
 	// DatabaseIntrospector.GetExtensions. If empty, all extensions will be
 	// included.
@@ -0,0 +1,72 @@
+package ddl
+
+import (
+	"testing"
+
+	"github.com/blink-io/sqddl/internal/testutil"
+)
+
+func Test_sqliteCheckConstraints(t *testing.T) {
+	type TT struct {
+		description string
+		sql         string
+		want        []Constraint
+	}
+	tests := []TT{{
+		description: "no checks",
+		sql:         "CREATE TABLE film (film_id INTEGER PRIMARY KEY, title TEXT NOT NULL)",
+	}, {
+		description: "named and unnamed checks",
+		sql: `CREATE TABLE film (
+    film_id INTEGER PRIMARY KEY
+    ,title TEXT NOT NULL CHECK (title <> '')
+    ,length INT
+    ,rental_rate NUMERIC
+
+    ,CONSTRAINT film_length_check CHECK (length > 0)
+    ,CONSTRAINT "film rental_rate check" check(rental_rate IN (0.99, 2.99, 4.99))
+    ,CONSTRAINT film_film_id_title_key UNIQUE (film_id, title)
+)`,
+		want: []Constraint{
+			{TableName: "film", ConstraintName: "film_length_check", ConstraintType: CHECK, CheckExpr: "length > 0"},
+			{TableName: "film", ConstraintName: "film rental_rate check", ConstraintType: CHECK, CheckExpr: "rental_rate IN (0.99, 2.99, 4.99)"},
+		},
+	}, {
+		description: "brackets in strings and comments",
+		sql: `CREATE TABLE film (
+    title TEXT -- a comment with a CONSTRAINT fake CHECK (1)
+    ,CONSTRAINT film_title_check CHECK (title NOT IN (')', '(')) /* ( */
+)`,
+		want: []Constraint{
+			{TableName: "film", ConstraintName: "film_title_check", ConstraintType: CHECK, CheckExpr: "title NOT IN (')', '(')"},
+		},
+	}}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.description, func(t *testing.T) {
+			t.Parallel()
+			got := sqliteCheckConstraints("film", tt.sql)
+			if diff := testutil.Diff(got, tt.want); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+		})
+	}
+}
+
+func Test_sqliteIndexPredicate(t *testing.T) {
+	type TT struct {
+		sql  string
+		want string
+	}
+	tests := []TT{
+		{"CREATE INDEX users_email_idx ON users (email);", ""},
+		{"CREATE UNIQUE INDEX users_email_idx ON users (email) WHERE deleted_at IS NULL;", "deleted_at IS NULL"},
+		{"CREATE INDEX \"idx (where)\" ON users (lower(email)) where (status = ')') AND deleted_at IS NULL", "(status = ')') AND deleted_at IS NULL"},
+	}
+	for _, tt := range tests {
+		got := sqliteIndexPredicate(tt.sql)
+		if diff := testutil.Diff(got, tt.want); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	}
+}
@@ -7,8 +7,10 @@ import (
 	"flag"
 	"fmt"
 	"io/fs"
+	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -105,6 +107,10 @@ type Schema struct {
 	// If RoutinesValid is false, the schema's routines are unknown.
 	RoutinesValid bool `json:",omitempty"`
 
+	// If TriggersValid is false, the triggers of the schema's tables and
+	// views are unknown.
+	TriggersValid bool `json:",omitempty"`
+
 	// The list of enum types within the schema. Postgres only.
 	Enums []Enum `json:",omitempty"`
 
@@ -117,6 +123,26 @@ type Schema struct {
 	// If DomainsValid is false, the schema's domain types are unknown.
 	DomainsValid bool `json:",omitempty"`
 
+	// The list of sequences within the schema. Identity sequences belong to
+	// their identity column and are not included. Postgres only.
+	Sequences []Sequence `json:",omitempty"`
+
+	// If SequencesValid is false, the schema's sequences are unknown.
+	SequencesValid bool `json:",omitempty"`
+
+	// Grants is the list of privileges granted on the schema and on the
+	// tables, views and sequences within it. Postgres, MySQL and SQL Server
+	// only.
+	Grants []Grant `json:",omitempty"`
+
+	// If GrantsValid is false, the schema's grants are unknown.
+	GrantsValid bool `json:",omitempty"`
+
+	// If PoliciesValid is false, the row-level security policies of the
+	// schema's tables (and whether row-level security is enabled on them)
+	// are unknown.
+	PoliciesValid bool `json:",omitempty"`
+
 	// Comment stores the comment on the schema object.
 	Comment string `json:",omitempty"`
 
@@ -179,6 +205,75 @@ type Domain struct {
 	Ignore bool `json:",omitempty"`
 }
 
+// Sequence represents a database sequence. Postgres only.
+type Sequence struct {
+	// SequenceSchema is the name of schema that the sequence belongs to.
+	SequenceSchema string `json:",omitempty"`
+
+	// SequenceName is the name of the sequence.
+	SequenceName string `json:",omitempty"`
+
+	// DataType is the data type of the sequence. Possible values: "smallint",
+	// "integer", "bigint".
+	DataType string `json:",omitempty"`
+
+	// StartValue is the start value of the sequence.
+	StartValue int64 `json:",omitempty"`
+
+	// Increment is the value added to the sequence on every call to nextval.
+	Increment int64 `json:",omitempty"`
+
+	// MinValue is the minimum value of the sequence.
+	MinValue int64 `json:",omitempty"`
+
+	// MaxValue is the maximum value of the sequence.
+	MaxValue int64 `json:",omitempty"`
+
+	// IsCycle indicates if the sequence wraps around once it reaches its
+	// MaxValue (or MinValue for a descending sequence).
+	IsCycle bool `json:",omitempty"`
+
+	// OwnedByTable and OwnedByColumn store the column that owns the sequence,
+	// if any. The sequence is dropped together with the column. The table
+	// always belongs to the same schema as the sequence.
+	OwnedByTable  string `json:",omitempty"`
+	OwnedByColumn string `json:",omitempty"`
+
+	// If Ignore is true, the sequence should be treated like it doesn't exist
+	// (a soft delete flag).
+	Ignore bool `json:",omitempty"`
+}
+
+// Grant represents a single privilege granted to a role on a schema, table,
+// view or sequence. Postgres, MySQL and SQL Server only.
+type Grant struct {
+	// GrantSchema is the name of the schema that the object belongs to (or
+	// the schema itself, for a schema privilege).
+	GrantSchema string `json:",omitempty"`
+
+	// ObjectType is the type of the object that the privilege is granted on.
+	// Possible values: "SCHEMA", "TABLE" (which includes views), "SEQUENCE".
+	ObjectType string `json:",omitempty"`
+
+	// ObjectName is the name of the object that the privilege is granted on.
+	// It is empty for a schema privilege.
+	ObjectName string `json:",omitempty"`
+
+	// Grantee is the role that the privilege is granted to, or "PUBLIC" for
+	// every role (Postgres). For MySQL it is the account e.g. "'app'@'%'".
+	Grantee string `json:",omitempty"`
+
+	// Privilege is the privilege granted e.g. "SELECT", "INSERT", "USAGE".
+	Privilege string `json:",omitempty"`
+
+	// IsGrantable indicates if the privilege is granted WITH GRANT OPTION.
+	IsGrantable bool `json:",omitempty"`
+
+	// If Ignore is true, the grant should be treated like it doesn't exist (a
+	// soft delete flag).
+	Ignore bool `json:",omitempty"`
+}
+
 // Routine represents a database routine (either a stored procedure or a
 // function).
 type Routine struct {
@@ -262,6 +357,49 @@ type Table struct {
 	// IsVirtual indicates if the table is a virtual table. SQLite only.
 	IsVirtual bool `json:",omitempty"`
 
+	// IsStrict indicates if the table is a STRICT table. SQLite only.
+	IsStrict bool `json:",omitempty"`
+
+	// IsWithoutRowid indicates if the table is a WITHOUT ROWID table. SQLite
+	// only.
+	IsWithoutRowid bool `json:",omitempty"`
+
+	// PartitionBy is the partition key of a partitioned table e.g. "RANGE
+	// (created_at)". Postgres only.
+	PartitionBy string `json:",omitempty"`
+
+	// PartitionOf is the name of the partitioned table that the table is a
+	// partition of. It is schema-qualified only if the partitioned table
+	// belongs to a different schema. Postgres only.
+	PartitionOf string `json:",omitempty"`
+
+	// PartitionBound is the partition bound of a partition e.g. "FOR VALUES
+	// FROM ('2023-01-01') TO ('2023-02-01')" or "DEFAULT". Postgres only.
+	PartitionBound string `json:",omitempty"`
+
+	// Engine is the storage engine of the table e.g. "InnoDB". MySQL only.
+	Engine string `json:",omitempty"`
+
+	// CharacterSet is the default character set of the table e.g.
+	// "utf8mb4". MySQL only.
+	CharacterSet string `json:",omitempty"`
+
+	// CollationName is the default collation of the table e.g.
+	// "utf8mb4_0900_ai_ci". MySQL only.
+	CollationName string `json:",omitempty"`
+
+	// RowFormat is the row format of the table e.g. "DYNAMIC". It is only
+	// set if the row format was explicitly specified. MySQL only.
+	RowFormat string `json:",omitempty"`
+
+	// AutoIncrement is the next AUTO_INCREMENT value of the table. MySQL
+	// only.
+	AutoIncrement int64 `json:",omitempty"`
+
+	// RowSecurityEnabled indicates if row-level security is enabled on the
+	// table. Postgres only.
+	RowSecurityEnabled bool `json:",omitempty"`
+
 	// Columns is the list of columns within the table.
 	Columns []Column `json:",omitempty"`
 
@@ -274,9 +412,22 @@ type Table struct {
 	// Triggers is the list of triggers within the table.
 	Triggers []Trigger `json:",omitempty"`
 
+	// Policies is the list of row-level security policies on the table.
+	// Postgres only.
+	Policies []Policy `json:",omitempty"`
+
 	// Comment stores the comment on the table.
 	Comment string `json:",omitempty"`
 
+	// RenamedFrom is the previous name of the table. It is a hint for
+	// migration generators to rename the table instead of dropping and
+	// recreating it.
+	RenamedFrom string `json:",omitempty"`
+
+	// AcceptWarnings is the list of warning codes that are accepted for the
+	// table and its columns when generating migrations.
+	AcceptWarnings []string `json:",omitempty"`
+
 	// If Ignore is true, the table should be treated like it doesn't exist (a
 	// soft delete flag).
 	Ignore bool `json:",omitempty"`
@@ -401,6 +552,15 @@ type Column struct {
 	// Comment stores the comment on the column.
 	Comment string `json:",omitempty"`
 
+	// RenamedFrom is the previous name of the column. It is a hint for
+	// migration generators to rename the column instead of dropping and
+	// recreating it.
+	RenamedFrom string `json:",omitempty"`
+
+	// AcceptWarnings is the list of warning codes that are accepted for the
+	// column when generating migrations.
+	AcceptWarnings []string `json:",omitempty"`
+
 	// If Ignore is true, the column should be treated like it doesn't exist (a
 	// soft delete flag).
 	Ignore bool `json:",omitempty"`
@@ -518,7 +678,9 @@ type Index struct {
 	// Descending indicates if each column of the index is descending.
 	Descending []bool `json:",omitempty"`
 
-	// Opclasses holds the opclass of each column of the index. Postgres only.
+	// Opclasses holds the opclass of each column of the index, or an empty
+	// string if the column uses the default opclass for its data type.
+	// Postgres only.
 	Opclasses []string `json:",omitempty"`
 
 	// Predicate stores the index predicate i.e. the index is a partial index.
@@ -563,6 +725,41 @@ type Trigger struct {
 	Ignore bool `json:",omitempty"`
 }
 
+// Policy represents a row-level security policy on a table. Postgres only.
+type Policy struct {
+	// TableSchema is the name of the schema that the table and policy belong
+	// to.
+	TableSchema string `json:",omitempty"`
+
+	// TableName is the name of the table that the policy belongs to.
+	TableName string `json:",omitempty"`
+
+	// PolicyName is the name of the policy.
+	PolicyName string `json:",omitempty"`
+
+	// IsRestrictive indicates if the policy is RESTRICTIVE instead of
+	// PERMISSIVE.
+	IsRestrictive bool `json:",omitempty"`
+
+	// Command is the command that the policy applies to. Possible values:
+	// "ALL", "SELECT", "INSERT", "UPDATE", "DELETE".
+	Command string `json:",omitempty"`
+
+	// Roles is the list of roles that the policy applies to. An empty list
+	// means PUBLIC.
+	Roles []string `json:",omitempty"`
+
+	// UsingExpr is the USING expression of the policy.
+	UsingExpr string `json:",omitempty"`
+
+	// CheckExpr is the WITH CHECK expression of the policy.
+	CheckExpr string `json:",omitempty"`
+
+	// If Ignore is true, the policy should be treated like it doesn't exist
+	// (a soft delete flag).
+	Ignore bool `json:",omitempty"`
+}
+
 // GenerateName generates the appropriate constraint/index name for a given
 // table and columns. The nameType should be one of "PRIMARY KEY", "FOREIGN
 // KEY", "UNIQUE" or "INDEX".
@@ -913,6 +1110,122 @@ func normalizeColumnDefault(dialect string, columnDefault string) (normalizedDef
 	return columnDefault
 }
 
+// normalizeSequenceType normalizes the data type of a sequence into the name
+// that Postgres reports it as ("smallint", "integer" or "bigint"). Sequences
+// are bigint by default.
+func normalizeSequenceType(dataType string) string {
+	switch dataType = strings.ToLower(strings.TrimSpace(dataType)); dataType {
+	case "smallint", "int2":
+		return "smallint"
+	case "integer", "int", "int4":
+		return "integer"
+	case "", "bigint", "int8":
+		return "bigint"
+	}
+	return dataType
+}
+
+// setSequenceDefaults fills in the parts of a sequence definition that were
+// not explicitly provided, using the same defaults as CREATE SEQUENCE. An
+// ascending sequence goes from 1 to the maximum value of its data type, a
+// descending sequence goes from -1 to the minimum value of its data type.
+func setSequenceDefaults(sequence *Sequence, hasMinValue, hasMaxValue, hasStartValue bool) {
+	sequence.DataType = normalizeSequenceType(sequence.DataType)
+	if sequence.Increment == 0 {
+		sequence.Increment = 1
+	}
+	var typeMin, typeMax int64 = math.MinInt64, math.MaxInt64
+	switch sequence.DataType {
+	case "smallint":
+		typeMin, typeMax = math.MinInt16, math.MaxInt16
+	case "integer":
+		typeMin, typeMax = math.MinInt32, math.MaxInt32
+	}
+	if !hasMinValue {
+		sequence.MinValue = 1
+		if sequence.Increment < 0 {
+			sequence.MinValue = typeMin
+		}
+	}
+	if !hasMaxValue {
+		sequence.MaxValue = typeMax
+		if sequence.Increment < 0 {
+			sequence.MaxValue = -1
+		}
+	}
+	if !hasStartValue {
+		sequence.StartValue = sequence.MinValue
+		if sequence.Increment < 0 {
+			sequence.StartValue = sequence.MaxValue
+		}
+	}
+}
+
+// partitionParent returns the schema and name of the partitioned table that
+// the table is a partition of. An unqualified PartitionOf belongs to the same
+// schema as the table.
+func partitionParent(table *Table) (parentSchema, parentName string) {
+	parentSchema, parentName = table.TableSchema, table.PartitionOf
+	if i := strings.IndexByte(parentName, '.'); i >= 0 {
+		parentSchema, parentName = parentName[:i], parentName[i+1:]
+	}
+	return parentSchema, parentName
+}
+
+// sortPartitionsLast sorts the tables so that every partition comes after the
+// partitioned table it is a partition of (partitions may themselves be
+// partitioned). The tables are otherwise kept in the same order.
+func sortPartitionsLast(tables []*Table) {
+	tablesByName := make(map[[2]string]*Table)
+	for _, table := range tables {
+		tablesByName[[2]string{table.TableSchema, table.TableName}] = table
+	}
+	depths := make(map[*Table]int)
+	for _, table := range tables {
+		depth := 0
+		for current := table; current != nil && current.PartitionOf != "" && depth <= len(tables); depth++ {
+			parentSchema, parentName := partitionParent(current)
+			current = tablesByName[[2]string{parentSchema, parentName}]
+		}
+		depths[table] = depth
+	}
+	slices.SortStableFunc(tables, func(a, b *Table) int {
+		return depths[a] - depths[b]
+	})
+}
+
+// normalizePartitionKey normalizes a partition key into the form that
+// Postgres reports it as e.g. "range(created_at)" becomes "RANGE
+// (created_at)".
+func normalizePartitionKey(partitionBy string) (string, error) {
+	strategy, columns, ok := strings.Cut(strings.TrimSpace(partitionBy), "(")
+	strategy = strings.ToUpper(strings.TrimSpace(strategy))
+	columns = strings.TrimSpace(columns)
+	if !ok || !strings.HasSuffix(columns, ")") || strings.TrimSpace(strings.TrimSuffix(columns, ")")) == "" {
+		return "", fmt.Errorf("invalid partition key %q, expected e.g. range(created_at)", partitionBy)
+	}
+	switch strategy {
+	case "RANGE", "LIST", "HASH":
+	default:
+		return "", fmt.Errorf("invalid partition strategy %q, must be one of RANGE, LIST or HASH", strategy)
+	}
+	return strategy + " (" + strings.TrimSpace(strings.TrimSuffix(columns, ")")) + ")", nil
+}
+
+// partitionKeysAreEqual reports whether two partition keys are equal,
+// ignoring differences in letter case, whitespace and identifier quoting.
+func partitionKeysAreEqual(partitionBy1, partitionBy2 string) bool {
+	replacer := strings.NewReplacer(" ", "", "\t", "", "\n", "", `"`, "")
+	return strings.EqualFold(replacer.Replace(partitionBy1), replacer.Replace(partitionBy2))
+}
+
+// collationCharacterSet returns the MySQL character set that a collation
+// belongs to e.g. "utf8mb4_0900_ai_ci" belongs to "utf8mb4".
+func collationCharacterSet(collationName string) string {
+	characterSet, _, _ := strings.Cut(collationName, "_")
+	return strings.ToLower(characterSet)
+}
+
 // dirFS is like os.DirFS without the restriction of banning filenames like
 // '../../somefile.sql'.
 type dirFS string
@@ -1076,6 +1389,14 @@ func (strs *strslice) Set(value string) error {
 	return nil
 }
 
+// commentIsChanged reports if a comment needs to be changed from srcComment
+// to destComment. Comments are only removed if dropObjects is true, so that
+// comments added directly in the database are not wiped out by table structs
+// that don't declare any comments.
+func commentIsChanged(srcComment, destComment string, dropObjects bool) bool {
+	return srcComment != destComment && (destComment != "" || dropObjects)
+}
+
 // Really hacky way to detect virtual tables, switch to a more sophisticated
 // method if problems are reported.
 func isVirtualTable(table *Table) bool {
@@ -89,6 +89,11 @@ type DumpCmd struct {
 	// (Postgres only) Dump UUIDs as bytes (in hexadecimal form e.g. 0x267f4bdb50a041399704c26a16f8f019).
 	UUIDAsBytes bool
 
+	// (Postgres only) Include the partitions of partitioned tables in the
+	// schema dump. The rows of a partition are always dumped as part of its
+	// partitioned table.
+	IncludePartitions bool
+
 	// Ctx is the command's context.
 	Ctx context.Context
 
@@ -127,6 +132,7 @@ func DumpCommand(args ...string) (*DumpCmd, error) {
 	flagset.Var(&extendedSubsetQueries, "extended-subset", "A query that pulls in a subset of a table which the rest of the dump will be derived from. This flag can be specified multiple times.")
 	flagset.BoolVar(&cmd.ArrayAsJSON, "array-as-json", false, "(Postgres only) Dump arrays as JSON arrays.")
 	flagset.BoolVar(&cmd.UUIDAsBytes, "uuid-as-bytes", false, "(Postgres only) Dump UUIDs as bytes (in hexadecimal form e.g. 0x267f4bdb50a041399704c26a16f8f019).")
+	flagset.BoolVar(&cmd.IncludePartitions, "include-partitions", false, "(Postgres only) Include the partitions of partitioned tables in the schema dump.")
 	flagset.Usage = func() {
 		fmt.Fprint(flagset.Output(), `Usage:
   sqddl dump -db <DATABASE_URL> [FLAGS]
@@ -210,6 +216,7 @@ func (cmd *DumpCmd) Run() error {
 	dbi.ExcludeSchemas = cmd.ExcludeSchemas
 	dbi.Tables = cmd.Tables
 	dbi.ExcludeTables = append(cmd.ExcludeTables, cmd.HistoryTable)
+	dbi.IncludePartitions = cmd.IncludePartitions
 	cmd.catalog = &Catalog{}
 	err := dbi.WriteCatalog(cmd.catalog)
 	if err != nil {
@@ -225,7 +232,11 @@ func (cmd *DumpCmd) Run() error {
 
 	if !cmd.SchemaOnly {
 		if len(cmd.SubsetQueries) > 0 || len(cmd.ExtendedSubsetQueries) > 0 {
-			subsetter, err := NewInMemorySubsetter(cmd.Dialect, cmd.DB, dbi.Filter)
+			// The rows of a partition are subsetted as part of its
+			// partitioned table.
+			filter := dbi.Filter
+			filter.IncludePartitions = false
+			subsetter, err := NewInMemorySubsetter(cmd.Dialect, cmd.DB, filter)
 			if err != nil {
 				return err
 			}
@@ -255,6 +266,11 @@ func (cmd *DumpCmd) Run() error {
 					if cmd.Dialect == DialectSQLite && isVirtualTable(table) {
 						continue
 					}
+					// The rows of a partition are already dumped as part
+					// of its partitioned table.
+					if table.PartitionOf != "" {
+						continue
+					}
 					buf.Reset()
 					buf.WriteString("SELECT ")
 					written := false
@@ -644,28 +660,13 @@ func (cmd *DumpCmd) dumpSchema(w io.Writer) error {
 			}
 			writeCreateSchema(cmd.Dialect, buf, schema.SchemaName)
 		}
-		var schemaName string
-		if schema.SchemaName != "" && schema.SchemaName != cmd.catalog.CurrentSchema {
-			schemaName = QuoteIdentifier(cmd.Dialect, schema.SchemaName)
-		}
 
 		// CREATE ENUM.
 		for _, enum := range schema.Enums {
 			if buf.Len() > 0 {
 				buf.WriteString("\n")
 			}
-			enumName := QuoteIdentifier(cmd.Dialect, enum.EnumName)
-			if schemaName != "" {
-				enumName = schemaName + "." + enumName
-			}
-			buf.WriteString("CREATE TYPE " + enumName + " AS ENUM (")
-			for i, label := range enum.EnumLabels {
-				if i > 0 {
-					buf.WriteString(", ")
-				}
-				buf.WriteString("'" + EscapeQuote(label, '\'') + "'")
-			}
-			buf.WriteString(");\n")
+			writeCreateEnum(cmd.Dialect, buf, cmd.catalog.CurrentSchema, &enum)
 		}
 
 		// CREATE DOMAIN.
@@ -673,51 +674,96 @@ func (cmd *DumpCmd) dumpSchema(w io.Writer) error {
 			if buf.Len() > 0 {
 				buf.WriteString("\n")
 			}
-			domainName := QuoteIdentifier(cmd.Dialect, domain.DomainName)
-			if schemaName != "" {
-				domainName = schemaName + "." + domainName
-			}
-			buf.WriteString("CREATE DOMAIN " + domainName + " AS " + domain.UnderlyingType)
-			if domain.IsNotNull {
-				buf.WriteString(" NOT NULL")
-			}
-			if domain.CollationName != "" && domain.CollationName != cmd.catalog.DefaultCollation {
-				buf.WriteString(` COLLATE "` + EscapeQuote(domain.CollationName, '"') + `"`)
-			}
-			if domain.ColumnDefault != "" {
-				buf.WriteString(" DEFAULT " + domain.ColumnDefault)
-			}
-			if len(domain.CheckExprs) > 0 {
-				for i, checkExpr := range domain.CheckExprs {
-					var constraintName string
-					if i < len(domain.CheckNames) {
-						constraintName = domain.CheckNames[i]
-					}
-					if constraintName != "" {
-						buf.WriteString(" CONSTRAINT " + QuoteIdentifier(cmd.Dialect, constraintName))
-					}
-					buf.WriteString(" CHECK " + wrapBrackets(checkExpr))
-				}
+			writeCreateDomain(cmd.Dialect, buf, cmd.catalog.CurrentSchema, cmd.catalog.DefaultCollation, &domain)
+		}
+
+		// CREATE SEQUENCE.
+		for _, sequence := range schema.Sequences {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
 			}
-			buf.WriteString(";\n")
+			writeCreateSequence(cmd.Dialect, buf, cmd.catalog.CurrentSchema, &sequence)
 		}
 	}
 
+	// CREATE TABLE.
+	var tables []*Table
 	for i := range cmd.catalog.Schemas {
 		schema := &cmd.catalog.Schemas[i]
-		// CREATE TABLE.
 		for j := range schema.Tables {
 			table := &schema.Tables[j]
 			if table.IsVirtual && table.SQL == "" {
 				continue
 			}
+			tables = append(tables, table)
+		}
+	}
+	sortPartitionsLast(tables)
+	for _, table := range tables {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		writeCreateTable(cmd.Dialect, buf, cmd.catalog.CurrentSchema, cmd.catalog.DefaultCollation, table, false)
+	}
+
+	// ALTER SEQUENCE OWNED BY.
+	for i := range cmd.catalog.Schemas {
+		schema := &cmd.catalog.Schemas[i]
+		for j := range schema.Sequences {
+			sequence := &schema.Sequences[j]
+			if sequence.OwnedByTable == "" {
+				continue
+			}
 			if buf.Len() > 0 {
 				buf.WriteString("\n")
 			}
-			writeCreateTable(cmd.Dialect, buf, cmd.catalog.CurrentSchema, cmd.catalog.DefaultCollation, table, false)
+			unowned := *sequence
+			unowned.OwnedByTable, unowned.OwnedByColumn = "", ""
+			writeAlterSequence(cmd.Dialect, buf, cmd.catalog.CurrentSchema, &unowned, sequence)
+		}
+	}
+
+	// ALTER TABLE ENABLE ROW LEVEL SECURITY, CREATE POLICY.
+	isDumped := make(map[[2]string]bool)
+	for _, table := range tables {
+		isDumped[[2]string{table.TableSchema, table.TableName}] = true
+		if !table.RowSecurityEnabled && len(table.Policies) == 0 {
+			continue
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		if table.RowSecurityEnabled {
+			writeRowSecurity(cmd.Dialect, buf, cmd.catalog.CurrentSchema, table, true)
+		}
+		for i := range table.Policies {
+			if !table.Policies[i].Ignore {
+				writeCreatePolicy(cmd.Dialect, buf, cmd.catalog.CurrentSchema, &table.Policies[i])
+			}
 		}
 	}
 
+	// GRANT. Views are not part of the schema dump, so neither are their
+	// grants.
+	for i := range cmd.catalog.Schemas {
+		schema := &cmd.catalog.Schemas[i]
+		var grants []*Grant
+		for j := range schema.Grants {
+			grant := &schema.Grants[j]
+			if grant.Ignore || (grant.ObjectType == "TABLE" && !isDumped[[2]string{schema.SchemaName, grant.ObjectName}]) {
+				continue
+			}
+			grants = append(grants, grant)
+		}
+		if len(grants) == 0 {
+			continue
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		writeGrants(cmd.Dialect, buf, cmd.catalog.CurrentSchema, grants, false)
+	}
+
 	if isBuffer {
 		return nil
 	}
@@ -1022,6 +1068,10 @@ func writeCreateTable(dialect string, buf *bytes.Buffer, currentSchema, defaultC
 	if table.TableSchema != "" && table.TableSchema != currentSchema {
 		tableName = QuoteIdentifier(dialect, table.TableSchema) + "." + tableName
 	}
+	if dialect == DialectPostgres && table.PartitionOf != "" {
+		writeCreatePartition(dialect, buf, currentSchema, tableName, table, includeConstraints)
+		return
+	}
 	buf.WriteString("CREATE TABLE " + tableName + " (")
 	columnWritten := false
 	for i := range table.Columns {
@@ -1055,7 +1105,16 @@ func writeCreateTable(dialect string, buf *bytes.Buffer, currentSchema, defaultC
 			}
 			writeConstraintDefinition(dialect, buf, currentSchema, constraint)
 		}
-		buf.WriteString("\n);\n")
+		buf.WriteString("\n)")
+		switch {
+		case table.IsWithoutRowid && table.IsStrict:
+			buf.WriteString(" WITHOUT ROWID, STRICT")
+		case table.IsWithoutRowid:
+			buf.WriteString(" WITHOUT ROWID")
+		case table.IsStrict:
+			buf.WriteString(" STRICT")
+		}
+		buf.WriteString(";\n")
 		return
 	}
 	if !includeConstraints {
@@ -1069,7 +1128,7 @@ func writeCreateTable(dialect string, buf *bytes.Buffer, currentSchema, defaultC
 				}
 			}
 		}
-		buf.WriteString("\n);\n")
+		writeCreateTableEnd(dialect, buf, currentSchema, table)
 		return
 	}
 	newlineSeparatorWritten := false
@@ -1089,7 +1148,410 @@ func writeCreateTable(dialect string, buf *bytes.Buffer, currentSchema, defaultC
 		}
 		writeConstraintDefinition(dialect, buf, currentSchema, constraint)
 	}
-	buf.WriteString("\n);\n")
+	writeCreateTableEnd(dialect, buf, currentSchema, table)
+}
+
+// writeCreateTableEnd closes off a CREATE TABLE statement and writes the table
+// and column comments (if any).
+func writeCreateTableEnd(dialect string, buf *bytes.Buffer, currentSchema string, table *Table) {
+	if dialect == DialectMySQL {
+		buf.WriteString("\n)")
+		if table.Engine != "" {
+			buf.WriteString(" ENGINE = " + table.Engine)
+		}
+		if table.CharacterSet != "" {
+			buf.WriteString(" DEFAULT CHARSET = " + table.CharacterSet)
+		}
+		if table.CollationName != "" {
+			buf.WriteString(" COLLATE = " + table.CollationName)
+		}
+		if table.RowFormat != "" {
+			buf.WriteString(" ROW_FORMAT = " + table.RowFormat)
+		}
+		if table.AutoIncrement > 0 {
+			buf.WriteString(" AUTO_INCREMENT = " + strconv.FormatInt(table.AutoIncrement, 10))
+		}
+		if table.Comment != "" {
+			buf.WriteString(" COMMENT = " + quoteComment(dialect, table.Comment))
+		}
+		buf.WriteString(";\n")
+		return
+	}
+	if dialect == DialectPostgres && table.PartitionBy != "" {
+		buf.WriteString("\n) PARTITION BY " + table.PartitionBy + ";\n")
+	} else {
+		buf.WriteString("\n);\n")
+	}
+	if table.Comment != "" {
+		writeComment(dialect, buf, currentSchema, table.TableSchema, table.TableName, "", "", table.Comment)
+	}
+	for i := range table.Columns {
+		column := &table.Columns[i]
+		if column.Ignore || column.Comment == "" || (column.IsGenerated && column.GeneratedExpr == "") {
+			continue
+		}
+		writeComment(dialect, buf, currentSchema, table.TableSchema, table.TableName, column.ColumnName, "", column.Comment)
+	}
+}
+
+// writeCreatePartition writes the CREATE TABLE ... PARTITION OF statement of a
+// Postgres partition. The columns of a partition are inherited from the
+// partitioned table, so only the constraints declared on the partition itself
+// are written.
+func writeCreatePartition(dialect string, buf *bytes.Buffer, currentSchema, tableName string, table *Table, includeConstraints bool) {
+	parentSchema, parentName := partitionParent(table)
+	parentTableName := QuoteIdentifier(dialect, parentName)
+	if parentSchema != "" && parentSchema != currentSchema {
+		parentTableName = QuoteIdentifier(dialect, parentSchema) + "." + parentTableName
+	}
+	buf.WriteString("CREATE TABLE " + tableName + " PARTITION OF " + parentTableName)
+	if includeConstraints {
+		constraintWritten := false
+		for i := range table.Constraints {
+			constraint := &table.Constraints[i]
+			if constraint.Ignore || constraint.ConstraintType == FOREIGN_KEY {
+				continue
+			}
+			if !constraintWritten {
+				constraintWritten = true
+				buf.WriteString(" (\n    ")
+			} else {
+				buf.WriteString("\n    ,")
+			}
+			writeConstraintDefinition(dialect, buf, currentSchema, constraint)
+		}
+		if constraintWritten {
+			buf.WriteString("\n)")
+		}
+	}
+	if table.PartitionBound != "" {
+		buf.WriteString(" " + table.PartitionBound)
+	} else {
+		buf.WriteString(" DEFAULT")
+	}
+	if table.PartitionBy != "" {
+		buf.WriteString(" PARTITION BY " + table.PartitionBy)
+	}
+	buf.WriteString(";\n")
+	if table.Comment != "" {
+		writeComment(dialect, buf, currentSchema, table.TableSchema, table.TableName, "", "", table.Comment)
+	}
+}
+
+// quoteComment quotes a comment as an SQL string literal.
+func quoteComment(dialect string, comment string) string {
+	switch dialect {
+	case DialectMySQL:
+		return "'" + EscapeQuote(strings.ReplaceAll(comment, `\`, `\\`), '\'') + "'"
+	case DialectSQLServer:
+		return "N'" + EscapeQuote(comment, '\'') + "'"
+	default:
+		return "'" + EscapeQuote(comment, '\'') + "'"
+	}
+}
+
+// writeComment writes the statement that changes the comment on a table (or
+// on a column if the columnName is not empty) from srcComment to destComment.
+// Only Postgres and SQL Server are supported, MySQL comments are part of the
+// table and column definitions while SQLite does not support comments.
+func writeComment(dialect string, buf *bytes.Buffer, currentSchema, tableSchema, tableName, columnName, srcComment, destComment string) {
+	switch dialect {
+	case DialectPostgres:
+		name := QuoteIdentifier(dialect, tableName)
+		if tableSchema != "" && tableSchema != currentSchema {
+			name = QuoteIdentifier(dialect, tableSchema) + "." + name
+		}
+		if columnName == "" {
+			buf.WriteString("COMMENT ON TABLE " + name)
+		} else {
+			buf.WriteString("COMMENT ON COLUMN " + name + "." + QuoteIdentifier(dialect, columnName))
+		}
+		if destComment == "" {
+			buf.WriteString(" IS NULL;\n")
+		} else {
+			buf.WriteString(" IS " + quoteComment(dialect, destComment) + ";\n")
+		}
+	case DialectSQLServer:
+		if tableSchema == "" {
+			tableSchema = currentSchema
+		}
+		if tableSchema == "" {
+			tableSchema = "dbo"
+		}
+		switch {
+		case srcComment == "":
+			buf.WriteString("EXEC sp_addextendedproperty")
+		case destComment == "":
+			buf.WriteString("EXEC sp_dropextendedproperty")
+		default:
+			buf.WriteString("EXEC sp_updateextendedproperty")
+		}
+		buf.WriteString(" @name = N'MS_Description'")
+		if destComment != "" {
+			buf.WriteString(", @value = " + quoteComment(dialect, destComment))
+		}
+		buf.WriteString(", @level0type = N'SCHEMA', @level0name = " + quoteComment(dialect, tableSchema))
+		buf.WriteString(", @level1type = N'TABLE', @level1name = " + quoteComment(dialect, tableName))
+		if columnName != "" {
+			buf.WriteString(", @level2type = N'COLUMN', @level2name = " + quoteComment(dialect, columnName))
+		}
+		buf.WriteString(";\n")
+	}
+}
+
+func writeCreateDomain(dialect string, buf *bytes.Buffer, currentSchema, defaultCollation string, domain *Domain) {
+	domainName := QuoteIdentifier(dialect, domain.DomainName)
+	if domain.DomainSchema != "" && domain.DomainSchema != currentSchema {
+		domainName = QuoteIdentifier(dialect, domain.DomainSchema) + "." + domainName
+	}
+	buf.WriteString("CREATE DOMAIN " + domainName + " AS " + domain.UnderlyingType)
+	if domain.IsNotNull {
+		buf.WriteString(" NOT NULL")
+	}
+	if domain.CollationName != "" && domain.CollationName != defaultCollation {
+		buf.WriteString(` COLLATE "` + EscapeQuote(domain.CollationName, '"') + `"`)
+	}
+	if domain.ColumnDefault != "" {
+		buf.WriteString(" DEFAULT " + domain.ColumnDefault)
+	}
+	for i, checkExpr := range domain.CheckExprs {
+		var constraintName string
+		if i < len(domain.CheckNames) {
+			constraintName = domain.CheckNames[i]
+		}
+		if constraintName != "" {
+			buf.WriteString(" CONSTRAINT " + QuoteIdentifier(dialect, constraintName))
+		}
+		buf.WriteString(" CHECK " + wrapBrackets(checkExpr))
+	}
+	buf.WriteString(";\n")
+}
+
+// writeCreateSequence writes the CREATE SEQUENCE statement of a sequence,
+// leaving out the options that have their default values. The owner of the
+// sequence is not included because the owning table may not exist yet, it is
+// set by writeAlterSequence instead.
+func writeCreateSequence(dialect string, buf *bytes.Buffer, currentSchema string, sequence *Sequence) {
+	sequenceName := QuoteIdentifier(dialect, sequence.SequenceName)
+	if sequence.SequenceSchema != "" && sequence.SequenceSchema != currentSchema {
+		sequenceName = QuoteIdentifier(dialect, sequence.SequenceSchema) + "." + sequenceName
+	}
+	defaults := Sequence{DataType: sequence.DataType, Increment: sequence.Increment}
+	setSequenceDefaults(&defaults, false, false, false)
+	buf.WriteString("CREATE SEQUENCE " + sequenceName)
+	if defaults.DataType != "bigint" {
+		buf.WriteString(" AS " + defaults.DataType)
+	}
+	if defaults.Increment != 1 {
+		buf.WriteString(" INCREMENT BY " + strconv.FormatInt(defaults.Increment, 10))
+	}
+	if sequence.MinValue != defaults.MinValue {
+		buf.WriteString(" MINVALUE " + strconv.FormatInt(sequence.MinValue, 10))
+	}
+	if sequence.MaxValue != defaults.MaxValue {
+		buf.WriteString(" MAXVALUE " + strconv.FormatInt(sequence.MaxValue, 10))
+	}
+	// The start value defaults to the MINVALUE (or MAXVALUE for a descending
+	// sequence).
+	if (sequence.Increment > 0 && sequence.StartValue != sequence.MinValue) || (sequence.Increment < 0 && sequence.StartValue != sequence.MaxValue) {
+		buf.WriteString(" START WITH " + strconv.FormatInt(sequence.StartValue, 10))
+	}
+	if sequence.IsCycle {
+		buf.WriteString(" CYCLE")
+	}
+	buf.WriteString(";\n")
+}
+
+// writeAlterSequence writes the ALTER SEQUENCE statement that turns
+// srcSequence into destSequence. If the data type changes, MINVALUE and
+// MAXVALUE are always included so that they are not adjusted to the bounds
+// of the new data type.
+func writeAlterSequence(dialect string, buf *bytes.Buffer, currentSchema string, srcSequence, destSequence *Sequence) {
+	sequenceName := QuoteIdentifier(dialect, destSequence.SequenceName)
+	if destSequence.SequenceSchema != "" && destSequence.SequenceSchema != currentSchema {
+		sequenceName = QuoteIdentifier(dialect, destSequence.SequenceSchema) + "." + sequenceName
+	}
+	buf.WriteString("ALTER SEQUENCE " + sequenceName)
+	isTypeChanged := normalizeSequenceType(srcSequence.DataType) != normalizeSequenceType(destSequence.DataType)
+	if isTypeChanged {
+		buf.WriteString(" AS " + normalizeSequenceType(destSequence.DataType))
+	}
+	if srcSequence.Increment != destSequence.Increment {
+		buf.WriteString(" INCREMENT BY " + strconv.FormatInt(destSequence.Increment, 10))
+	}
+	if isTypeChanged || srcSequence.MinValue != destSequence.MinValue {
+		buf.WriteString(" MINVALUE " + strconv.FormatInt(destSequence.MinValue, 10))
+	}
+	if isTypeChanged || srcSequence.MaxValue != destSequence.MaxValue {
+		buf.WriteString(" MAXVALUE " + strconv.FormatInt(destSequence.MaxValue, 10))
+	}
+	if srcSequence.StartValue != destSequence.StartValue {
+		buf.WriteString(" START WITH " + strconv.FormatInt(destSequence.StartValue, 10))
+	}
+	if !srcSequence.IsCycle && destSequence.IsCycle {
+		buf.WriteString(" CYCLE")
+	} else if srcSequence.IsCycle && !destSequence.IsCycle {
+		buf.WriteString(" NO CYCLE")
+	}
+	if srcSequence.OwnedByTable != destSequence.OwnedByTable || srcSequence.OwnedByColumn != destSequence.OwnedByColumn {
+		if destSequence.OwnedByTable == "" {
+			buf.WriteString(" OWNED BY NONE")
+		} else {
+			tableName := QuoteIdentifier(dialect, destSequence.OwnedByTable)
+			if destSequence.SequenceSchema != "" && destSequence.SequenceSchema != currentSchema {
+				tableName = QuoteIdentifier(dialect, destSequence.SequenceSchema) + "." + tableName
+			}
+			buf.WriteString(" OWNED BY " + tableName + "." + QuoteIdentifier(dialect, destSequence.OwnedByColumn))
+		}
+	}
+	buf.WriteString(";\n")
+}
+
+// sequencesAreEqual reports whether two sequences have the same definition.
+func sequencesAreEqual(srcSequence, destSequence *Sequence) bool {
+	return normalizeSequenceType(srcSequence.DataType) == normalizeSequenceType(destSequence.DataType) &&
+		srcSequence.Increment == destSequence.Increment &&
+		srcSequence.MinValue == destSequence.MinValue &&
+		srcSequence.MaxValue == destSequence.MaxValue &&
+		srcSequence.StartValue == destSequence.StartValue &&
+		srcSequence.IsCycle == destSequence.IsCycle &&
+		srcSequence.OwnedByTable == destSequence.OwnedByTable &&
+		srcSequence.OwnedByColumn == destSequence.OwnedByColumn
+}
+
+// writeGrants writes the GRANT (or REVOKE, if revoke is true) statements of
+// the grants. Consecutive grants on the same object to the same grantee are
+// combined into a single statement.
+func writeGrants(dialect string, buf *bytes.Buffer, currentSchema string, grants []*Grant, revoke bool) {
+	for i := 0; i < len(grants); {
+		grant := grants[i]
+		privileges := []string{grant.Privilege}
+		for i++; i < len(grants); i++ {
+			next := grants[i]
+			if next.GrantSchema != grant.GrantSchema || next.ObjectType != grant.ObjectType || next.ObjectName != grant.ObjectName ||
+				next.Grantee != grant.Grantee || next.IsGrantable != grant.IsGrantable {
+				break
+			}
+			privileges = append(privileges, next.Privilege)
+		}
+		object := grantObject(dialect, currentSchema, grant)
+		grantee := granteeName(dialect, grant.Grantee)
+		if revoke {
+			buf.WriteString("REVOKE " + strings.Join(privileges, ", ") + " ON " + object + " FROM " + grantee)
+			if dialect == DialectSQLServer && grant.IsGrantable {
+				// SQL Server refuses to revoke a privilege granted WITH
+				// GRANT OPTION without CASCADE.
+				buf.WriteString(" CASCADE")
+			}
+		} else {
+			buf.WriteString("GRANT " + strings.Join(privileges, ", ") + " ON " + object + " TO " + grantee)
+			if grant.IsGrantable {
+				buf.WriteString(" WITH GRANT OPTION")
+			}
+		}
+		buf.WriteString(";\n")
+	}
+}
+
+// grantObject returns the object that a grant is on, in the form that comes
+// after the ON keyword of a GRANT statement.
+func grantObject(dialect string, currentSchema string, grant *Grant) string {
+	schemaName := QuoteIdentifier(dialect, grant.GrantSchema)
+	if grant.ObjectType == "SCHEMA" {
+		switch dialect {
+		case DialectMySQL:
+			return schemaName + ".*"
+		case DialectSQLServer:
+			return "SCHEMA::" + schemaName
+		default:
+			return "SCHEMA " + schemaName
+		}
+	}
+	objectName := QuoteIdentifier(dialect, grant.ObjectName)
+	if grant.GrantSchema != "" && grant.GrantSchema != currentSchema {
+		objectName = schemaName + "." + objectName
+	}
+	if dialect == DialectPostgres {
+		return grant.ObjectType + " " + objectName
+	}
+	return objectName
+}
+
+// granteeName returns the grantee of a grant as it appears in a GRANT
+// statement. MySQL grantees are accounts which are already quoted.
+func granteeName(dialect string, grantee string) string {
+	if dialect == DialectMySQL || (dialect == DialectPostgres && strings.EqualFold(grantee, "PUBLIC")) {
+		return grantee
+	}
+	return QuoteIdentifier(dialect, grantee)
+}
+
+// writeRowSecurity writes the ALTER TABLE statement that enables (or
+// disables) row-level security on a table.
+func writeRowSecurity(dialect string, buf *bytes.Buffer, currentSchema string, table *Table, enable bool) {
+	tableName := QuoteIdentifier(dialect, table.TableName)
+	if table.TableSchema != "" && table.TableSchema != currentSchema {
+		tableName = QuoteIdentifier(dialect, table.TableSchema) + "." + tableName
+	}
+	if enable {
+		buf.WriteString("ALTER TABLE " + tableName + " ENABLE ROW LEVEL SECURITY;\n")
+	} else {
+		buf.WriteString("ALTER TABLE " + tableName + " DISABLE ROW LEVEL SECURITY;\n")
+	}
+}
+
+// writeCreatePolicy writes the CREATE POLICY statement of a policy. The
+// defaults (AS PERMISSIVE, FOR ALL and TO PUBLIC) are left out.
+func writeCreatePolicy(dialect string, buf *bytes.Buffer, currentSchema string, policy *Policy) {
+	tableName := QuoteIdentifier(dialect, policy.TableName)
+	if policy.TableSchema != "" && policy.TableSchema != currentSchema {
+		tableName = QuoteIdentifier(dialect, policy.TableSchema) + "." + tableName
+	}
+	buf.WriteString("CREATE POLICY " + QuoteIdentifier(dialect, policy.PolicyName) + " ON " + tableName)
+	if policy.IsRestrictive {
+		buf.WriteString(" AS RESTRICTIVE")
+	}
+	if policy.Command != "" && !strings.EqualFold(policy.Command, "ALL") {
+		buf.WriteString(" FOR " + strings.ToUpper(policy.Command))
+	}
+	writePolicyRoles(dialect, buf, policy)
+	if policy.UsingExpr != "" {
+		buf.WriteString(" USING " + wrapBrackets(policy.UsingExpr))
+	}
+	if policy.CheckExpr != "" {
+		buf.WriteString(" WITH CHECK " + wrapBrackets(policy.CheckExpr))
+	}
+	buf.WriteString(";\n")
+}
+
+// writePolicyRoles writes the TO clause of a policy, if the policy applies
+// to specific roles.
+func writePolicyRoles(dialect string, buf *bytes.Buffer, policy *Policy) {
+	if len(policy.Roles) == 0 {
+		return
+	}
+	buf.WriteString(" TO ")
+	for i, role := range policy.Roles {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(granteeName(dialect, role))
+	}
+}
+
+func writeCreateEnum(dialect string, buf *bytes.Buffer, currentSchema string, enum *Enum) {
+	enumName := QuoteIdentifier(dialect, enum.EnumName)
+	if enum.EnumSchema != "" && enum.EnumSchema != currentSchema {
+		enumName = QuoteIdentifier(dialect, enum.EnumSchema) + "." + enumName
+	}
+	buf.WriteString("CREATE TYPE " + enumName + " AS ENUM (")
+	for i, label := range enum.EnumLabels {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString("'" + EscapeQuote(label, '\'') + "'")
+	}
+	buf.WriteString(");\n")
 }
 
 func writeCreateIndex(dialect string, buf *bytes.Buffer, currentSchema string, index *Index, createConcurrently bool) {
@@ -1152,6 +1614,9 @@ func writeIndexDefinition(dialect string, buf *bytes.Buffer, currentSchema strin
 		} else {
 			buf.WriteString(QuoteIdentifier(dialect, column))
 		}
+		if i < len(index.Opclasses) && index.Opclasses[i] != "" && dialect == DialectPostgres {
+			buf.WriteString(" " + index.Opclasses[i])
+		}
 		if i < len(index.Descending) && index.Descending[i] {
 			buf.WriteString(" DESC")
 		}
@@ -1258,6 +1723,10 @@ func writeColumnDefinition(dialect string, buf *bytes.Buffer, defaultCollation s
 			}
 		}
 	}
+	// COMMENT
+	if column.Comment != "" && dialect == DialectMySQL {
+		buf.WriteString(" COMMENT " + quoteComment(dialect, column.Comment))
+	}
 }
 
 func writeColumnNames(dialect string, buf *bytes.Buffer, columns []string) {
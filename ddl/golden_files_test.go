@@ -292,7 +292,8 @@ func TestMain(m *testing.M) {
 			if err != nil {
 				l.Fatalf("%s: %s: %s", database.dialect, tc.dir, err)
 			}
-			var filenames, warnings []string
+			var filenames []string
+			var warnings []Warning
 			var bufs []*bytes.Buffer
 			switch database.dialect {
 			case "sqlite":
@@ -329,7 +330,7 @@ func TestMain(m *testing.M) {
 				}
 			}
 			if len(warnings) > 0 {
-				err := os.WriteFile(filepath.Join(tc.dir, "warnings.txt"), []byte(strings.Join(warnings, "\n")), 0644)
+				err := os.WriteFile(filepath.Join(tc.dir, "warnings.txt"), []byte(strings.Join(warningMessages(warnings), "\n")), 0644)
 				if err != nil {
 					l.Fatalf("%s: %s: %s", database.dialect, tc.dir, err)
 				}
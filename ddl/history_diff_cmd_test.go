@@ -0,0 +1,120 @@
+package ddl
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/blink-io/sqddl/internal/testutil"
+)
+
+func TestHistoryDiffCmd(t *testing.T) {
+	t.Run("empty dbs", func(t *testing.T) {
+		t.Parallel()
+		dsn1 := "file:/" + t.Name() + "1.db?vfs=memdb&_foreign_keys=true"
+		dsn2 := "file:/" + t.Name() + "2.db?vfs=memdb&_foreign_keys=true"
+		// Assert OK.
+		historyDiffCmd, err := HistoryDiffCommand("-db", dsn1, "-db", dsn2)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		historyDiffCmd.Stdout = io.Discard
+		err = historyDiffCmd.Run()
+		if err != nil {
+			t.Error(testutil.Callers(), err)
+		}
+	})
+
+	t.Run("missing -db", func(t *testing.T) {
+		t.Parallel()
+		dsn := "file:/" + t.Name() + ".db?vfs=memdb&_foreign_keys=true"
+		_, err := HistoryDiffCommand("-db", dsn)
+		if err == nil {
+			t.Error(testutil.Callers(), "expected error but got nil")
+		}
+	})
+
+	t.Run("invalid second -db", func(t *testing.T) {
+		t.Parallel()
+		dsn := "file:/" + t.Name() + ".db?vfs=memdb&_foreign_keys=true"
+		_, err := HistoryDiffCommand("-db", dsn, "-db", "not-a-dsn")
+		if err == nil {
+			t.Error(testutil.Callers(), "expected error but got nil")
+		}
+	})
+
+	t.Run("different dbs", func(t *testing.T) {
+		t.Parallel()
+		srcDB, err := sql.Open("sqlite3", "file:/"+t.Name()+"1.db?vfs=memdb&_foreign_keys=true")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		destDB, err := sql.Open("sqlite3", "file:/"+t.Name()+"2.db?vfs=memdb&_foreign_keys=true")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		// Run all migrations on srcDB.
+		migrateCmd := &MigrateCmd{
+			DB:      srcDB,
+			Dialect: "sqlite",
+			DirFS:   os.DirFS("sqlite_migrations"),
+			Stderr:  io.Discard,
+		}
+		err = migrateCmd.Run()
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		// Only touch 01_sakila.sql on destDB.
+		touchCmd := &TouchCmd{
+			DB:        destDB,
+			Dialect:   "sqlite",
+			DirFS:     os.DirFS("sqlite_migrations"),
+			Filenames: []string{"01_sakila.sql"},
+			Stderr:    io.Discard,
+		}
+		err = touchCmd.Run()
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		_, err = destDB.Exec("UPDATE sqddl_history SET success = FALSE WHERE filename = '01_sakila.sql'")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		// Assert the differences.
+		buf := &bytes.Buffer{}
+		historyDiffCmd := &HistoryDiffCmd{
+			SrcDB:       srcDB,
+			SrcDialect:  "sqlite",
+			DestDB:      destDB,
+			DestDialect: "sqlite",
+			Stdout:      buf,
+			JSON:        true,
+		}
+		err = historyDiffCmd.Run()
+		if err == nil {
+			t.Error(testutil.Callers(), "expected error but got nil")
+		}
+		var diffs []HistoryDiff
+		err = json.Unmarshal(buf.Bytes(), &diffs)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if len(diffs) < 2 {
+			t.Fatalf(testutil.Callers()+" expected at least 2 differences, got %d", len(diffs))
+		}
+		if diff := testutil.Diff(diffs[0], HistoryDiff{Filename: "01_sakila.sql", Status: "dest_failed"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		if diff := testutil.Diff(diffs[1], HistoryDiff{Filename: "02_employee.sql", Status: "src_only"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		for _, diff := range diffs[2:] {
+			if diff.Status != "src_only" {
+				t.Errorf(testutil.Callers()+" expected src_only, got %s (%s)", diff.Status, diff.Filename)
+			}
+		}
+	})
+}
@@ -0,0 +1,46 @@
+SELECT
+    table_schema AS grant_schema
+    ,'TABLE' AS object_type
+    ,table_name AS object_name
+    ,grantee
+    ,privilege_type AS privilege
+    ,is_grantable = 'YES' AS is_grantable
+FROM
+    information_schema.table_privileges
+WHERE
+    TRUE
+    {{- if not .IncludeSystemCatalogs }}
+    AND table_schema NOT IN ('mysql', 'information_schema', 'performance_schema', 'sys')
+    {{- end }}
+    {{- if .Schemas }}
+    AND table_schema IN ({{ mklist .Schemas }})
+    {{- else if .ExcludeSchemas }}
+    AND table_schema NOT IN ({{ mklist .ExcludeSchemas }})
+    {{- end }}
+UNION ALL
+SELECT
+    table_schema AS grant_schema
+    ,'SCHEMA' AS object_type
+    ,'' AS object_name
+    ,grantee
+    ,privilege_type AS privilege
+    ,is_grantable = 'YES' AS is_grantable
+FROM
+    information_schema.schema_privileges
+WHERE
+    TRUE
+    {{- if not .IncludeSystemCatalogs }}
+    AND table_schema NOT IN ('mysql', 'information_schema', 'performance_schema', 'sys')
+    {{- end }}
+    {{- if .Schemas }}
+    AND table_schema IN ({{ mklist .Schemas }})
+    {{- else if .ExcludeSchemas }}
+    AND table_schema NOT IN ({{ mklist .ExcludeSchemas }})
+    {{- end }}
+ORDER BY
+    grant_schema
+    ,object_type
+    ,object_name
+    ,grantee
+    ,privilege
+;
@@ -3,7 +3,7 @@ SELECT
     ,routines.routine_name
     ,routines.routine_type
     ,COALESCE(routines.dtd_identifier, '') AS return_type
-    ,parameters.parameters
+    ,COALESCE(parameters.parameters, '') AS parameters
     ,routines.routine_definition AS `sql`
     ,routines.routine_comment
     ,routines.routine_body
@@ -12,7 +12,7 @@ SELECT
     ,routines.security_type
 FROM
     information_schema.routines
-    JOIN (
+    LEFT JOIN (
         SELECT
             specific_schema
             ,specific_name
@@ -2,6 +2,21 @@ SELECT
     table_schema
     ,table_name
     ,COALESCE(table_comment, '') AS table_comment
+    ,COALESCE(engine, '') AS engine
+    ,COALESCE((
+        SELECT collations.character_set_name
+        FROM information_schema.collations
+        WHERE collations.collation_name = tables.table_collation
+        LIMIT 1
+    ), '') AS character_set
+    ,COALESCE(table_collation, '') AS collation_name
+    -- row_format always reports the actual row format, create_options only
+    -- contains it if it was explicitly specified.
+    ,CASE
+        WHEN create_options LIKE '%row_format=%' THEN UPPER(SUBSTRING_INDEX(SUBSTRING_INDEX(create_options, 'row_format=', -1), ' ', 1))
+        ELSE ''
+    END AS row_format
+    ,COALESCE(auto_increment, 0) AS auto_increment
 FROM
     information_schema.tables
 WHERE
@@ -41,7 +41,7 @@ SELECT
     ,COALESCE(col_description(columns.attrelid, columns.attnum), '') AS comment
 FROM
     pg_attribute AS columns
-    JOIN pg_class AS tables ON tables.relkind = 'r' AND tables.oid = columns.attrelid
+    JOIN pg_class AS tables ON tables.relkind IN ('r', 'p') AND tables.oid = columns.attrelid
     JOIN pg_namespace AS schemas ON schemas.oid = tables.relnamespace
     LEFT JOIN pg_attrdef ON pg_attrdef.adrelid = tables.oid AND pg_attrdef.adnum = columns.attnum
     LEFT JOIN pg_collation ON pg_collation.oid = columns.attcollation
@@ -64,6 +64,11 @@ WHERE
     {{- else if .ExcludeTables }}
     AND tables.relname NOT IN ({{ mklist .ExcludeTables }})
     {{- end }}
+    {{- if not (.VersionNums.LowerThan 10) }}
+    {{- if not .IncludePartitions }}
+    AND NOT tables.relispartition
+    {{- end }}
+    {{- end }}
 ORDER BY
     schemas.nspname
     ,tables.relname
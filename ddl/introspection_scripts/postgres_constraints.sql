@@ -70,6 +70,13 @@ FROM (
         {{- else if .ExcludeTables }}
         AND tables.relname NOT IN ({{ mklist .ExcludeTables }})
         {{- end }}
+        {{- if not (.VersionNums.LowerThan 10) }}
+        {{- if .IncludePartitions }}
+        AND (NOT tables.relispartition OR pg_constraint.conislocal)
+        {{- else }}
+        AND NOT tables.relispartition
+        {{- end }}
+        {{- end }}
 ) AS primary_key_columns
 GROUP BY
     table_schema
@@ -129,6 +136,13 @@ FROM (
         {{- else if .ExcludeTables }}
         AND tables.relname NOT IN ({{ mklist .ExcludeTables }})
         {{- end }}
+        {{- if not (.VersionNums.LowerThan 10) }}
+        {{- if .IncludePartitions }}
+        AND (NOT tables.relispartition OR pg_constraint.conislocal)
+        {{- else }}
+        AND NOT tables.relispartition
+        {{- end }}
+        {{- end }}
 ) AS unique_columns
 GROUP BY
     table_schema
@@ -215,6 +229,13 @@ FROM (
         {{- else if .ExcludeTables }}
         AND tables1.relname NOT IN ({{ mklist .ExcludeTables }})
         {{- end }}
+        {{- if not (.VersionNums.LowerThan 10) }}
+        {{- if .IncludePartitions }}
+        AND (NOT tables1.relispartition OR pg_constraint.conislocal)
+        {{- else }}
+        AND NOT tables1.relispartition
+        {{- end }}
+        {{- end }}
 ) AS foreign_key_columns
 GROUP BY
     table_schema
@@ -269,6 +290,13 @@ WHERE
     {{- else if .ExcludeTables }}
     AND tables.relname NOT IN ({{ mklist .ExcludeTables }})
     {{- end }}
+    {{- if not (.VersionNums.LowerThan 10) }}
+    {{- if .IncludePartitions }}
+    AND (NOT tables.relispartition OR pg_constraint.conislocal)
+    {{- else }}
+    AND NOT tables.relispartition
+    {{- end }}
+    {{- end }}
 {{- end }}
 {{- if .IncludeConstraintType "EXCLUDE" }}
 UNION ALL
@@ -330,6 +358,13 @@ FROM (
         {{- else if .ExcludeTables }}
         AND tables.relname NOT IN ({{ mklist .ExcludeTables }})
         {{- end }}
+        {{- if not (.VersionNums.LowerThan 10) }}
+        {{- if .IncludePartitions }}
+        AND (NOT tables.relispartition OR pg_constraint.conislocal)
+        {{- else }}
+        AND NOT tables.relispartition
+        {{- end }}
+        {{- end }}
 ) AS exclude_columns
 GROUP BY
     table_schema
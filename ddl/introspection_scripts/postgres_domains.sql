@@ -25,9 +25,9 @@ WHERE
     AND schemas.nspname NOT IN ({{ mklist .ExcludeSchemas }})
     {{- end }}
     {{- if .Domains }}
-    pg_type.typname IN ({{ mklist .Domains }})
+    AND pg_type.typname IN ({{ mklist .Domains }})
     {{- else if .ExcludeDomains }}
-    pg_type.typname NOT IN ({{ mklist .ExcludeDomains }})
+    AND pg_type.typname NOT IN ({{ mklist .ExcludeDomains }})
     {{- end }}
 GROUP BY
     schemas.nspname
@@ -0,0 +1,62 @@
+WITH grants AS (
+    SELECT
+        schemas.nspname AS grant_schema
+        ,CASE WHEN pg_class.relkind = 'S' THEN 'SEQUENCE' ELSE 'TABLE' END AS object_type
+        ,pg_class.relname AS object_name
+        ,acl.grantee
+        ,acl.privilege_type
+        ,acl.is_grantable
+    FROM
+        pg_class
+        JOIN pg_namespace AS schemas ON schemas.oid = pg_class.relnamespace
+        CROSS JOIN LATERAL aclexplode(pg_class.relacl) AS acl
+    WHERE
+        pg_class.relkind IN ('r', 'p', 'v', 'm', 'f', 'S')
+        -- The owner's privileges are implicit.
+        AND acl.grantee <> pg_class.relowner
+    UNION ALL
+    SELECT
+        schemas.nspname AS grant_schema
+        ,'SCHEMA' AS object_type
+        ,'' AS object_name
+        ,acl.grantee
+        ,acl.privilege_type
+        ,acl.is_grantable
+    FROM
+        pg_namespace AS schemas
+        CROSS JOIN LATERAL aclexplode(schemas.nspacl) AS acl
+    WHERE
+        acl.grantee <> schemas.nspowner
+)
+SELECT
+    grants.grant_schema
+    ,grants.object_type
+    ,grants.object_name
+    ,CASE WHEN grants.grantee = 0 THEN 'PUBLIC' ELSE pg_get_userbyid(grants.grantee) END AS grantee
+    ,grants.privilege_type AS privilege
+    ,bool_or(grants.is_grantable) AS is_grantable
+FROM
+    grants
+WHERE
+    TRUE
+    {{- if not .IncludeSystemCatalogs }}
+    AND grants.grant_schema <> 'information_schema' AND grants.grant_schema NOT LIKE 'pg_%'
+    {{- end }}
+    {{- if .Schemas }}
+    AND grants.grant_schema IN ({{ mklist .Schemas }})
+    {{- else if .ExcludeSchemas }}
+    AND grants.grant_schema NOT IN ({{ mklist .ExcludeSchemas }})
+    {{- end }}
+GROUP BY
+    grants.grant_schema
+    ,grants.object_type
+    ,grants.object_name
+    ,grants.grantee
+    ,grants.privilege_type
+ORDER BY
+    grants.grant_schema
+    ,grants.object_type
+    ,grants.object_name
+    ,4
+    ,grants.privilege_type
+;
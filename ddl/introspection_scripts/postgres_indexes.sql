@@ -8,6 +8,7 @@ SELECT
     ,num_key_columns
     ,json_agg(column_name ORDER BY seq) AS columns
     ,json_agg(opclass ORDER BY seq) AS opclasses
+    ,json_agg(is_descending ORDER BY seq) AS descending
     ,COALESCE(pg_get_expr(predicate_oid, table_oid, TRUE), '') AS predicate
     ,pg_get_indexdef(index_oid, 0, TRUE) || ';' AS sql
 FROM (
@@ -20,7 +21,8 @@ FROM (
         ,pg_index.indisunique AS is_unique
         ,pg_index.indnkeyatts AS num_key_columns
         ,pg_get_indexdef(indexes.oid, c.seq::INT, TRUE) AS column_name
-        ,pg_opclass.opcname AS opclass
+        ,CASE WHEN pg_opclass.opcdefault THEN NULL ELSE pg_opclass.opcname END AS opclass
+        ,COALESCE(pg_index.indoption[c.seq - 1] & 1 = 1, FALSE) AS is_descending
         ,pg_index.indexrelid AS index_oid
         ,pg_index.indrelid AS table_oid
         ,pg_index.indpred AS predicate_oid
@@ -55,6 +57,13 @@ FROM (
         {{- else if .ExcludeTables }}
         AND tables.relname NOT IN ({{ mklist .ExcludeTables }})
         {{- end }}
+        {{- if not (.VersionNums.LowerThan 10) }}
+        {{- if .IncludePartitions }}
+        AND (NOT tables.relispartition OR NOT EXISTS (SELECT 1 FROM pg_inherits WHERE pg_inherits.inhrelid = indexes.oid))
+        {{- else }}
+        AND NOT tables.relispartition
+        {{- end }}
+        {{- end }}
     ) AS indexed_columns
 GROUP BY
     table_schema
@@ -0,0 +1,46 @@
+SELECT
+    schemas.nspname AS table_schema
+    ,tables.relname AS table_name
+    ,pg_policy.polname AS policy_name
+    {{- if .VersionNums.LowerThan 10 }}
+    ,FALSE AS is_restrictive
+    {{- else }}
+    ,NOT pg_policy.polpermissive AS is_restrictive
+    {{- end }}
+    ,CASE pg_policy.polcmd
+        WHEN 'r' THEN 'SELECT'
+        WHEN 'a' THEN 'INSERT'
+        WHEN 'w' THEN 'UPDATE'
+        WHEN 'd' THEN 'DELETE'
+        ELSE 'ALL'
+    END AS command
+    ,COALESCE((
+        SELECT json_agg(CASE WHEN roles.role = 0 THEN 'PUBLIC' ELSE pg_get_userbyid(roles.role) END ORDER BY roles.ordinality)
+        FROM unnest(pg_policy.polroles) WITH ORDINALITY AS roles (role, ordinality)
+    ), '[]') AS roles
+    ,COALESCE(pg_get_expr(pg_policy.polqual, pg_policy.polrelid, TRUE), '') AS using_expr
+    ,COALESCE(pg_get_expr(pg_policy.polwithcheck, pg_policy.polrelid, TRUE), '') AS check_expr
+FROM
+    pg_policy
+    JOIN pg_class AS tables ON tables.oid = pg_policy.polrelid
+    JOIN pg_namespace AS schemas ON schemas.oid = tables.relnamespace
+WHERE
+    TRUE
+    {{- if not .IncludeSystemCatalogs }}
+    AND schemas.nspname <> 'information_schema' AND schemas.nspname NOT LIKE 'pg_%'
+    {{- end }}
+    {{- if .Schemas }}
+    AND schemas.nspname IN ({{ mklist .Schemas }})
+    {{- else if .ExcludeSchemas }}
+    AND schemas.nspname NOT IN ({{ mklist .ExcludeSchemas }})
+    {{- end }}
+    {{- if .Tables }}
+    AND tables.relname IN ({{ mklist .Tables }})
+    {{- else if .ExcludeTables }}
+    AND tables.relname NOT IN ({{ mklist .ExcludeTables }})
+    {{- end }}
+ORDER BY
+    schemas.nspname
+    ,tables.relname
+    ,pg_policy.polname
+;
@@ -0,0 +1,42 @@
+SELECT
+    schemas.nspname AS sequence_schema
+    ,pg_class.relname AS sequence_name
+    ,pg_catalog.format_type(pg_sequence.seqtypid, NULL) AS data_type
+    ,pg_sequence.seqstart AS start_value
+    ,pg_sequence.seqincrement AS increment
+    ,pg_sequence.seqmin AS min_value
+    ,pg_sequence.seqmax AS max_value
+    ,pg_sequence.seqcycle AS is_cycle
+    ,COALESCE(owned_by_table.relname, '') AS owned_by_table
+    ,COALESCE(owned_by_column.attname, '') AS owned_by_column
+FROM
+    pg_sequence
+    JOIN pg_class ON pg_class.oid = pg_sequence.seqrelid
+    JOIN pg_namespace AS schemas ON schemas.oid = pg_class.relnamespace
+    LEFT JOIN pg_depend ON pg_depend.classid = 'pg_class'::regclass
+        AND pg_depend.objid = pg_class.oid
+        AND pg_depend.refclassid = 'pg_class'::regclass
+        AND pg_depend.refobjsubid > 0
+        AND pg_depend.deptype IN ('a', 'i')
+    LEFT JOIN pg_class AS owned_by_table ON owned_by_table.oid = pg_depend.refobjid
+    LEFT JOIN pg_attribute AS owned_by_column ON owned_by_column.attrelid = pg_depend.refobjid AND owned_by_column.attnum = pg_depend.refobjsubid
+WHERE
+    -- Identity sequences belong to their identity column.
+    (pg_depend.deptype IS NULL OR pg_depend.deptype <> 'i')
+    {{- if not .IncludeSystemCatalogs }}
+    AND schemas.nspname <> 'information_schema' AND schemas.nspname NOT LIKE 'pg_%'
+    {{- end }}
+    {{- if .Schemas }}
+    AND schemas.nspname IN ({{ mklist .Schemas }})
+    {{- else if .ExcludeSchemas }}
+    AND schemas.nspname NOT IN ({{ mklist .ExcludeSchemas }})
+    {{- end }}
+    {{- if .Sequences }}
+    AND pg_class.relname IN ({{ mklist .Sequences }})
+    {{- else if .ExcludeSequences }}
+    AND pg_class.relname NOT IN ({{ mklist .ExcludeSequences }})
+    {{- end }}
+ORDER BY
+    schemas.nspname
+    ,pg_class.relname
+;
@@ -2,12 +2,35 @@ SELECT
     schemas.nspname AS table_schema
     ,tables.relname AS table_name
     ,COALESCE(pg_description.description, '') AS table_comment
+    {{- if .VersionNums.LowerThan 10 }}
+    ,'' AS partition_by
+    ,'' AS partition_of
+    ,'' AS partition_bound
+    {{- else }}
+    ,COALESCE(pg_get_partkeydef(tables.oid), '') AS partition_by
+    ,CASE
+        WHEN parent_schemas.nspname IS NULL THEN ''
+        WHEN parent_schemas.nspname = schemas.nspname THEN parent_tables.relname
+        ELSE parent_schemas.nspname || '.' || parent_tables.relname
+    END AS partition_of
+    ,COALESCE(pg_get_expr(tables.relpartbound, tables.oid, TRUE), '') AS partition_bound
+    {{- end }}
+    {{- if .VersionNums.LowerThan 9 5 }}
+    ,FALSE AS row_security_enabled
+    {{- else }}
+    ,tables.relrowsecurity AS row_security_enabled
+    {{- end }}
 FROM
     pg_class AS tables
     JOIN pg_namespace AS schemas ON schemas.oid = tables.relnamespace
     LEFT JOIN pg_description ON pg_description.objoid = tables.oid
+    {{- if not (.VersionNums.LowerThan 10) }}
+    LEFT JOIN pg_inherits ON tables.relispartition AND pg_inherits.inhrelid = tables.oid
+    LEFT JOIN pg_class AS parent_tables ON parent_tables.oid = pg_inherits.inhparent
+    LEFT JOIN pg_namespace AS parent_schemas ON parent_schemas.oid = parent_tables.relnamespace
+    {{- end }}
 WHERE
-    tables.relkind = 'r'
+    tables.relkind IN ('r', 'p')
     {{- if not .IncludeSystemCatalogs }}
     AND schemas.nspname <> 'information_schema' AND schemas.nspname NOT LIKE 'pg_%'
     {{- end }}
@@ -21,6 +44,11 @@ WHERE
     {{- else if .ExcludeTables }}
     AND tables.relname NOT IN ({{ mklist .ExcludeTables }})
     {{- end }}
+    {{- if not (.VersionNums.LowerThan 10) }}
+    {{- if not .IncludePartitions }}
+    AND NOT tables.relispartition
+    {{- end }}
+    {{- end }}
 ORDER BY
     schemas.nspname
     ,tables.relname
@@ -25,6 +25,13 @@ WHERE
     {{- else if .ExcludeTables }}
     AND tables.relname NOT IN ({{ mklist .ExcludeTables }})
     {{- end }}
+    {{- if not (.VersionNums.LowerThan 10) }}
+    {{- if not .IncludePartitions }}
+    AND NOT tables.relispartition
+    {{- else if not (.VersionNums.LowerThan 13) }}
+    AND (NOT tables.relispartition OR pg_trigger.tgparentid = 0) -- exclude triggers cloned from the partitioned table
+    {{- end }}
+    {{- end }}
 ORDER BY
     schemas.nspname
     ,tables.relname
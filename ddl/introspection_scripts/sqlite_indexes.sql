@@ -3,6 +3,7 @@ SELECT
     ,index_name
     ,is_unique
     ,group_concat(column_name) AS columns
+    ,group_concat(is_descending) AS descending
     ,sql || ';' AS sql
 FROM (
     SELECT
@@ -14,6 +15,7 @@ FROM (
             WHEN -2 THEN '' -- column is an expression
             ELSE columns.name
         END AS column_name
+        ,columns."desc" AS is_descending
         ,columns.seqno
         ,m.sql
     FROM (
@@ -33,10 +35,11 @@ FROM (
             {{- end }}
         ) AS tables
         CROSS JOIN pragma_index_list(tables.tbl_name) AS indexes
-        CROSS JOIN pragma_index_info(indexes.name) AS columns
+        CROSS JOIN pragma_index_xinfo(indexes.name) AS columns
         JOIN sqlite_schema AS m ON m.type = 'index' AND m.tbl_name = tables.tbl_name AND m.name = indexes.name
     WHERE
         indexes.origin = 'c' -- 'c' = 'CREATE INDEX', 'u' = 'UNIQUE', 'pk' = 'PRIMARY KEY'
+        AND columns.key -- exclude the auxiliary columns (e.g. the rowid)
     ORDER BY
         indexes.name
         ,columns.seqno
@@ -1,10 +1,20 @@
 SELECT
     m.tbl_name AS table_name
     ,m.sql || ';' AS sql
+    {{- if .VersionNums.LowerThan 3 37 }}
+    ,FALSE AS is_strict
+    ,REPLACE(REPLACE(m.sql, char(10), ' '), char(9), ' ') LIKE '%WITHOUT ROWID' AS is_without_rowid
+    {{- else }}
+    ,COALESCE(tl.strict, FALSE) AS is_strict
+    ,COALESCE(tl.wr, FALSE) AS is_without_rowid
+    {{- end }}
 FROM
     sqlite_schema AS m
+    {{- if not (.VersionNums.LowerThan 3 37) }}
+    LEFT JOIN pragma_table_list AS tl ON tl.schema = 'main' AND tl.name = m.tbl_name
+    {{- end }}
 WHERE
-    type = 'table'
+    m.type = 'table'
     {{- if not .IncludeSystemCatalogs }}
     {{- if .VersionNums.LowerThan 3 37 }}
     AND m.tbl_name NOT LIKE 'sqlite_%' AND m.sql NOT LIKE 'CREATE TABLE ''%'
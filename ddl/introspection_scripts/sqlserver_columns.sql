@@ -28,6 +28,7 @@ SELECT
         ELSE COALESCE(columns.collation_name, '')
     END AS collation_name
     ,COALESCE(OBJECT_DEFINITION(columns.default_object_id), '') AS column_default
+    ,COALESCE(CAST(extended_properties.value AS NVARCHAR(MAX)), '') AS column_comment
 FROM
     sys.columns
     JOIN sys.tables ON tables.object_id = columns.object_id
@@ -39,6 +40,11 @@ FROM
     LEFT JOIN sys.computed_columns
         ON computed_columns.object_id = columns.object_id
         AND computed_columns.column_id = columns.column_id
+    LEFT JOIN sys.extended_properties
+        ON extended_properties.class = 1
+        AND extended_properties.major_id = columns.object_id
+        AND extended_properties.minor_id = columns.column_id
+        AND extended_properties.name = 'MS_Description'
 WHERE
     tables.type = 'U' -- User-defined table (https://stackoverflow.com/a/2907204)
     {{- if not .IncludeSystemCatalogs }}
@@ -0,0 +1,36 @@
+SELECT
+    schemas.name AS grant_schema
+    ,CASE WHEN permissions.class = 3 THEN 'SCHEMA' ELSE 'TABLE' END AS object_type
+    ,COALESCE(objects.name, '') AS object_name
+    ,principals.name AS grantee
+    ,permissions.permission_name AS privilege
+    ,CAST(CASE WHEN permissions.state = 'W' THEN 1 ELSE 0 END AS BIT) AS is_grantable
+FROM
+    sys.database_permissions AS permissions
+    JOIN sys.database_principals AS principals ON principals.principal_id = permissions.grantee_principal_id
+    LEFT JOIN sys.objects AS objects ON permissions.class = 1 AND objects.object_id = permissions.major_id
+    JOIN sys.schemas ON schemas.schema_id = CASE WHEN permissions.class = 3 THEN permissions.major_id ELSE objects.schema_id END
+WHERE
+    -- GRANT (G) and GRANT WITH GRANT OPTION (W), DENY is not supported.
+    permissions.state IN ('G', 'W')
+    -- Object permissions (1) on tables and views, and schema
+    -- permissions (3). Column permissions (minor_id <> 0) are not supported.
+    AND (
+        (permissions.class = 1 AND permissions.minor_id = 0 AND objects.type IN ('U', 'V'))
+        OR permissions.class = 3
+    )
+    {{- if not .IncludeSystemCatalogs }}
+    AND schemas.name NOT IN ('sys', 'INFORMATION_SCHEMA')
+    {{- end }}
+    {{- if .Schemas }}
+    AND schemas.name IN ({{ mklist .Schemas }})
+    {{- else if .ExcludeSchemas }}
+    AND schemas.name NOT IN ({{ mklist .ExcludeSchemas }})
+    {{- end }}
+ORDER BY
+    schemas.name
+    ,object_type
+    ,object_name
+    ,principals.name
+    ,permissions.permission_name
+;
@@ -0,0 +1,651 @@
+package ddl
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Lint rule IDs. A lint issue can be suppressed by adding a
+// `-- sqddl:lint-ignore=RULE` comment on the line before the offending
+// statement (or on the same line as it).
+const (
+	// LintIndexNotConcurrent flags a (Postgres) CREATE INDEX that is not
+	// created concurrently, which locks the table against writes while the
+	// index is being built.
+	LintIndexNotConcurrent = "index-not-concurrent"
+
+	// LintAddColumnNotNull flags an ADD COLUMN NOT NULL without a DEFAULT,
+	// which fails if the table already contains rows.
+	LintAddColumnNotNull = "add-column-not-null"
+
+	// LintAlterColumnType flags a column type change, which may rewrite the
+	// entire table while holding an exclusive lock.
+	LintAlterColumnType = "alter-column-type"
+
+	// LintDropColumn flags a DROP COLUMN. Application code that no longer
+	// references the column should be deployed before the column is dropped.
+	LintDropColumn = "drop-column"
+
+	// LintMissingUndo flags a non-transactional migration that has no
+	// corresponding undo migration. Migrations that only add enum labels
+	// with ADD VALUE IF NOT EXISTS can be rerun and are not flagged.
+	LintMissingUndo = "missing-undo"
+
+	// LintRename flags a table or column rename, which breaks application
+	// code that still references the old name.
+	LintRename = "rename"
+)
+
+// LintCmd implements the `sqddl lint` subcommand.
+type LintCmd struct {
+	// (Required) Dialect is the database dialect.
+	Dialect string
+
+	// (Required) DirFS is the migration directory.
+	DirFS fs.FS
+
+	// Filenames specifies the list of migrations (loaded from the DirFS) to
+	// lint. If empty, all migrations in the DirFS are linted.
+	Filenames []string
+
+	// Stdout specifies the command's standard out. If nil, the command writes
+	// to os.Stdout.
+	Stdout io.Writer
+}
+
+// LintIssue is an issue found by the LintCmd.
+type LintIssue struct {
+	// Filename is the migration filename.
+	Filename string
+
+	// Line is the line number of the offending statement. It is 0 for issues
+	// that apply to the whole file.
+	Line int
+
+	// Rule is the lint rule ID e.g. "index-not-concurrent".
+	Rule string
+
+	// Message describes the issue.
+	Message string
+}
+
+// String returns the lint issue in the format `filename:line: [rule] message`.
+func (issue LintIssue) String() string {
+	if issue.Line > 0 {
+		return issue.Filename + ":" + strconv.Itoa(issue.Line) + ": [" + issue.Rule + "] " + issue.Message
+	}
+	return issue.Filename + ": [" + issue.Rule + "] " + issue.Message
+}
+
+// LintCommand creates a new LintCmd with the given arguments. E.g.
+//
+//	sqddl lint -dialect <DIALECT> -dir <MIGRATION_DIR> [FILENAMES...]
+//
+//	LintCommand("-dialect", "postgres", "-dir", "./migrations")
+func LintCommand(args ...string) (*LintCmd, error) {
+	var cmd LintCmd
+	var db, dir, historyTable string
+	flagset := flag.NewFlagSet("", flag.ContinueOnError)
+	flagset.StringVar(&cmd.Dialect, "dialect", "", "(required) The database dialect used. Not needed if -db is provided.")
+	flagset.StringVar(&dir, "dir", "", "(required) Migration directory.")
+	flagset.StringVar(&db, "db", "", "Database URL/DSN. Only used to infer the dialect if -dialect is not provided.")
+	flagset.StringVar(&historyTable, "history-table", "", "(ignored)")
+	flagset.Usage = func() {
+		fmt.Fprint(flagset.Output(), `Usage:
+  sqddl lint -dialect <DIALECT> -dir <MIGRATION_DIR> [FILENAMES...]
+  sqddl lint -dialect postgres -dir ./migrations
+  sqddl lint -dialect postgres -dir ./migrations 02_sakila.sql 04_extras.sql
+Flags:
+`)
+		flagset.PrintDefaults()
+	}
+	err := flagset.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	if cmd.Dialect == "" && db != "" {
+		cmd.Dialect, _, _ = NormalizeDSN(db)
+		if cmd.Dialect == "" {
+			return nil, fmt.Errorf("could not identity dialect for -db %q", db)
+		}
+	}
+	if cmd.Dialect == "" {
+		return nil, fmt.Errorf("-dialect empty or not provided")
+	}
+	if dir == "" {
+		return nil, fmt.Errorf("-dir empty or not provided")
+	}
+	cmd.DirFS = dirFS(dir)
+	for _, filename := range flagset.Args() {
+		cmd.Filenames = append(cmd.Filenames, normalizeFilename(filename, dir))
+	}
+	return &cmd, nil
+}
+
+// Run runs the LintCmd. If any issues are found, Run returns a non-nil error
+// after writing out the issues.
+func (cmd *LintCmd) Run() error {
+	if cmd.Stdout == nil {
+		cmd.Stdout = os.Stdout
+	}
+	issues, err := cmd.Results()
+	if err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		_, err = io.WriteString(cmd.Stdout, issue.String()+"\n")
+		if err != nil {
+			return err
+		}
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("%d lint issues found", len(issues))
+	}
+	return nil
+}
+
+// Results returns the lint issues found in the migrations.
+func (cmd *LintCmd) Results() ([]LintIssue, error) {
+	switch cmd.Dialect {
+	case DialectSQLite, DialectPostgres, DialectMySQL, DialectSQLServer:
+	case "":
+		return nil, fmt.Errorf("empty Dialect")
+	default:
+		return nil, fmt.Errorf("unsupported dialect: %q", cmd.Dialect)
+	}
+	if cmd.DirFS == nil {
+		return nil, fmt.Errorf("nil DirFS")
+	}
+	var filenames []string
+	var err error
+	if len(cmd.Filenames) > 0 {
+		err = validateFilesExist(cmd.DirFS, cmd.Filenames)
+		if err != nil {
+			return nil, err
+		}
+		filenames = sortAndFilterFilenames(cmd.Filenames)
+	} else {
+		filenames, err = walkDir(cmd.DirFS)
+		if err != nil {
+			return nil, err
+		}
+		filenames = sortAndFilterFilenames(filenames)
+	}
+	buf := bufpool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufpool.Put(buf)
+	var issues []LintIssue
+	for _, filename := range filenames {
+		file, err := cmd.DirFS.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		buf.Reset()
+		_, err = buf.ReadFrom(file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+		fileIssues, err := cmd.lintFile(filename, buf.String())
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, fileIssues...)
+	}
+	return issues, nil
+}
+
+func (cmd *LintCmd) lintFile(filename, contents string) ([]LintIssue, error) {
+	var issues []LintIssue
+	stmts, fileIgnores := splitStatements(cmd.Dialect, contents)
+
+	// Check for a missing undo migration. Undo migrations are only run for
+	// non-transactional migrations, which is every migration for MySQL
+	// (except *.tx.sql) and *.txoff.sql migrations for everything else.
+	isRepeatable := strings.HasPrefix(filename, "repeatable/")
+	isTxoff := strings.HasSuffix(filename, ".txoff.sql") || (cmd.Dialect == DialectMySQL && !strings.HasSuffix(filename, ".tx.sql"))
+	if !isRepeatable && isTxoff && len(stmts) > 0 && !fileIgnores[LintMissingUndo] && !isRerunnable(stmts) {
+		undofile := strings.TrimSuffix(strings.TrimSuffix(filename, ".sql"), ".txoff") + ".undo.sql"
+		_, err := fs.Stat(cmd.DirFS, undofile)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		if err != nil {
+			issues = append(issues, LintIssue{
+				Filename: filename,
+				Rule:     LintMissingUndo,
+				Message:  "non-transactional migration has no undo migration " + undofile,
+			})
+		}
+	}
+
+	// Tables created in the same migration are empty and not yet in use, so
+	// most operations on them are safe.
+	createdTables := make(map[string]bool)
+	for _, stmt := range stmts {
+		report := func(rule, message string) {
+			if stmt.ignores[rule] {
+				return
+			}
+			issues = append(issues, LintIssue{
+				Filename: filename,
+				Line:     stmt.line,
+				Rule:     rule,
+				Message:  message,
+			})
+		}
+		tokens := stmt.tokens
+		switch {
+		// CREATE TABLE
+		case matchKeywords(tokens, "CREATE", "TABLE"),
+			matchKeywords(tokens, "CREATE", "TEMPORARY", "TABLE"),
+			matchKeywords(tokens, "CREATE", "UNLOGGED", "TABLE"):
+			i := indexKeyword(tokens, "TABLE") + 1
+			if matchKeywords(tokens[i:], "IF", "NOT", "EXISTS") {
+				i += 3
+			}
+			if i < len(tokens) {
+				createdTables[lintObjectName(tokens[i])] = true
+			}
+		// CREATE INDEX
+		case matchKeywords(tokens, "CREATE", "INDEX"),
+			matchKeywords(tokens, "CREATE", "UNIQUE", "INDEX"):
+			if cmd.Dialect != DialectPostgres {
+				continue
+			}
+			i := indexKeyword(tokens, "INDEX") + 1
+			if matchKeywords(tokens[i:], "CONCURRENTLY") {
+				continue
+			}
+			on := indexKeyword(tokens, "ON")
+			if on < 0 {
+				continue
+			}
+			j := on + 1
+			if matchKeywords(tokens[j:], "ONLY") {
+				j++
+			}
+			if j < len(tokens) && createdTables[lintObjectName(tokens[j])] {
+				continue
+			}
+			report(LintIndexNotConcurrent, "CREATE INDEX without CONCURRENTLY blocks writes to the table, use CREATE INDEX CONCURRENTLY in a *.txoff.sql migration instead")
+		// ALTER TABLE
+		case matchKeywords(tokens, "ALTER", "TABLE"):
+			i := 2
+			if matchKeywords(tokens[i:], "IF", "EXISTS") {
+				i += 2
+			}
+			if matchKeywords(tokens[i:], "ONLY") {
+				i++
+			}
+			if i >= len(tokens) {
+				continue
+			}
+			tableName := lintObjectName(tokens[i])
+			for _, action := range splitTokens(tokens[i+1:], ",") {
+				cmd.lintAlterTableAction(action, createdTables[tableName], report)
+			}
+		// RENAME TABLE (MySQL)
+		case matchKeywords(tokens, "RENAME", "TABLE"):
+			report(LintRename, "renaming a table breaks application code still referencing the old name")
+		// sp_rename (SQL Server)
+		case matchKeywords(tokens, "EXEC", "sp_rename"),
+			matchKeywords(tokens, "EXECUTE", "sp_rename"),
+			matchKeywords(tokens, "sp_rename"):
+			report(LintRename, "renaming an object breaks application code still referencing the old name")
+		}
+	}
+	return issues, nil
+}
+
+func (cmd *LintCmd) lintAlterTableAction(tokens []string, isNewTable bool, report func(rule, message string)) {
+	switch {
+	case matchKeywords(tokens, "ADD"):
+		i := 1
+		if matchKeywords(tokens[i:], "COLUMN") {
+			i++
+		}
+		if matchKeywords(tokens[i:], "IF", "NOT", "EXISTS") {
+			i += 3
+		}
+		if i >= len(tokens) {
+			return
+		}
+		switch strings.ToUpper(tokens[i]) {
+		case "CONSTRAINT", "PRIMARY", "UNIQUE", "FOREIGN", "CHECK", "EXCLUDE", "INDEX", "KEY", "FULLTEXT", "SPATIAL", "PARTITION":
+			return
+		}
+		if isNewTable {
+			return
+		}
+		var isNotNull, hasDefault bool
+		for j := i + 1; j < len(tokens); j++ {
+			switch strings.ToUpper(tokens[j]) {
+			case "NOT":
+				if matchKeywords(tokens[j+1:], "NULL") {
+					isNotNull = true
+				}
+			case "DEFAULT", "GENERATED", "IDENTITY", "AUTO_INCREMENT", "AUTOINCREMENT", "AS":
+				hasDefault = true
+			}
+		}
+		if isNotNull && !hasDefault {
+			report(LintAddColumnNotNull, "ADD COLUMN "+tokens[i]+" NOT NULL without a DEFAULT fails if the table already contains rows")
+		}
+	case matchKeywords(tokens, "ALTER"):
+		i := 1
+		if matchKeywords(tokens[i:], "COLUMN") {
+			i++
+		}
+		if i >= len(tokens) || isNewTable {
+			return
+		}
+		columnName := tokens[i]
+		rest := tokens[i+1:]
+		isAlterType := false
+		switch cmd.Dialect {
+		case DialectPostgres:
+			isAlterType = matchKeywords(rest, "TYPE") || matchKeywords(rest, "SET", "DATA", "TYPE")
+		case DialectSQLServer:
+			isAlterType = len(rest) > 0 && !matchKeywords(rest, "ADD") && !matchKeywords(rest, "DROP")
+		}
+		if isAlterType {
+			report(LintAlterColumnType, "changing the type of column "+columnName+" may rewrite the entire table while holding an exclusive lock")
+		}
+	case matchKeywords(tokens, "MODIFY"), matchKeywords(tokens, "CHANGE"):
+		if cmd.Dialect != DialectMySQL || isNewTable {
+			return
+		}
+		i := 1
+		if matchKeywords(tokens[i:], "COLUMN") {
+			i++
+		}
+		if i >= len(tokens) {
+			return
+		}
+		if matchKeywords(tokens, "CHANGE") && i+1 < len(tokens) && lintObjectName(tokens[i]) != lintObjectName(tokens[i+1]) {
+			report(LintRename, "renaming column "+tokens[i]+" breaks application code still referencing the old name")
+		}
+		report(LintAlterColumnType, "changing the definition of column "+tokens[i]+" may rewrite the entire table while holding an exclusive lock")
+	case matchKeywords(tokens, "DROP"):
+		i := 1
+		if matchKeywords(tokens[i:], "COLUMN") {
+			i++
+		} else if i >= len(tokens) || cmd.Dialect == DialectSQLServer {
+			return
+		} else {
+			switch strings.ToUpper(tokens[i]) {
+			case "CONSTRAINT", "PRIMARY", "UNIQUE", "FOREIGN", "CHECK", "INDEX", "KEY", "PARTITION", "DEFAULT":
+				return
+			}
+		}
+		if matchKeywords(tokens[i:], "IF", "EXISTS") {
+			i += 2
+		}
+		if i >= len(tokens) || isNewTable {
+			return
+		}
+		report(LintDropColumn, "dropping column "+tokens[i]+" breaks application code still referencing it, make sure code that no longer uses the column has already been deployed")
+	case matchKeywords(tokens, "RENAME"):
+		if matchKeywords(tokens[1:], "CONSTRAINT") || matchKeywords(tokens[1:], "INDEX") || matchKeywords(tokens[1:], "KEY") {
+			return
+		}
+		report(LintRename, "renaming a table or column breaks application code still referencing the old name")
+	}
+}
+
+// lintStatement is an SQL statement in a migration file.
+type lintStatement struct {
+	line    int             // Line number where the statement starts.
+	tokens  []string        // Statement tokens, excluding comments.
+	offsets []int           // Byte offset of each token in the file.
+	ignores map[string]bool // Lint rules to ignore for this statement.
+}
+
+// splitStatements splits an SQL file into its individual statements. A
+// `-- sqddl:lint-ignore=RULE` comment applies to the statement that follows
+// it, unless the comment is on the same line as the end of the previous
+// statement. fileIgnores contains every lint rule ignored anywhere in the
+// file, and is used for rules that apply to the whole file.
+func splitStatements(dialect string, contents string) (stmts []lintStatement, fileIgnores map[string]bool) {
+	fileIgnores = make(map[string]bool)
+	var stmt lintStatement
+	var pendingIgnores []string
+	line := 1
+	lastStmtLine := 0 // Line of the last statement terminator.
+	i := 0            // Current byte offset.
+	addIgnores := func(comment string, commentLine int) {
+		const prefix = "sqddl:lint-ignore="
+		comment = strings.TrimSpace(comment)
+		if !strings.HasPrefix(comment, prefix) {
+			return
+		}
+		fields := strings.Fields(strings.TrimPrefix(comment, prefix))
+		if len(fields) == 0 {
+			return
+		}
+		rules := strings.Split(fields[0], ",")
+		for _, rule := range rules {
+			fileIgnores[rule] = true
+		}
+		if len(stmt.tokens) == 0 && commentLine == lastStmtLine && len(stmts) > 0 {
+			last := &stmts[len(stmts)-1]
+			if last.ignores == nil {
+				last.ignores = make(map[string]bool)
+			}
+			for _, rule := range rules {
+				last.ignores[rule] = true
+			}
+			return
+		}
+		pendingIgnores = append(pendingIgnores, rules...)
+	}
+	addToken := func(token string) {
+		if len(stmt.tokens) == 0 {
+			stmt.line = line
+		}
+		stmt.tokens = append(stmt.tokens, token)
+		stmt.offsets = append(stmt.offsets, i)
+	}
+	endStatement := func() {
+		lastStmtLine = line
+		if len(stmt.tokens) == 0 {
+			return
+		}
+		stmt.ignores = make(map[string]bool)
+		for _, rule := range pendingIgnores {
+			stmt.ignores[rule] = true
+		}
+		pendingIgnores = pendingIgnores[:0]
+		stmts = append(stmts, stmt)
+		stmt = lintStatement{}
+	}
+	for i < len(contents) {
+		char := contents[i]
+		switch {
+		case char == '\n':
+			line++
+			i++
+		case char == ' ' || char == '\t' || char == '\r':
+			i++
+		case char == ';':
+			endStatement()
+			i++
+		case char == '-' && i+1 < len(contents) && contents[i+1] == '-':
+			end := strings.IndexByte(contents[i:], '\n')
+			if end < 0 {
+				end = len(contents) - i
+			}
+			addIgnores(contents[i+2:i+end], line)
+			i += end
+		case char == '/' && i+1 < len(contents) && contents[i+1] == '*':
+			end := strings.Index(contents[i+2:], "*/")
+			if end < 0 {
+				end = len(contents) - i - 2
+			} else {
+				end += 2
+			}
+			comment := contents[i+2 : i+2+end]
+			addIgnores(strings.TrimSuffix(comment, "*/"), line)
+			line += strings.Count(comment, "\n")
+			i += 2 + end
+		case char == '\'':
+			j := scanQuoted(contents, i, '\'')
+			token := contents[i:j]
+			addToken(token)
+			line += strings.Count(token, "\n")
+			i = j
+		case char == '$' && dialect == DialectPostgres:
+			// Dollar-quoted string e.g. $$ ... $$ or $tag$ ... $tag$.
+			j := i + 1
+			for j < len(contents) && isIdentifierChar(contents[j]) && !(j == i+1 && contents[j] >= '0' && contents[j] <= '9') {
+				j++
+			}
+			if j >= len(contents) || contents[j] != '$' {
+				addToken("$")
+				i++
+				continue
+			}
+			tag := contents[i : j+1]
+			end := strings.Index(contents[j+1:], tag)
+			if end < 0 {
+				j = len(contents)
+			} else {
+				j = j + 1 + end + len(tag)
+			}
+			token := contents[i:j]
+			addToken(token)
+			line += strings.Count(token, "\n")
+			i = j
+		case isIdentifierChar(char) || char == '"' || char == '`' || (char == '[' && dialect == DialectSQLServer):
+			// An identifier, possibly quoted and possibly qualified e.g.
+			// "public".actor.
+			j := i
+			for {
+				switch contents[j] {
+				case '"', '`':
+					j = scanQuoted(contents, j, contents[j])
+				case '[':
+					j = scanQuoted(contents, j, ']')
+				default:
+					for j < len(contents) && isIdentifierChar(contents[j]) {
+						j++
+					}
+				}
+				if j+1 < len(contents) && contents[j] == '.' && (isIdentifierChar(contents[j+1]) || contents[j+1] == '"' || contents[j+1] == '`' || (contents[j+1] == '[' && dialect == DialectSQLServer)) {
+					j++
+					continue
+				}
+				break
+			}
+			token := contents[i:j]
+			addToken(token)
+			line += strings.Count(token, "\n")
+			i = j
+		default:
+			addToken(string(char))
+			i++
+		}
+	}
+	endStatement()
+	return stmts, fileIgnores
+}
+
+// scanQuoted returns the index after the closing quote of the quoted string
+// or identifier starting at contents[start]. Doubled closing quotes are treated
+// as escaped.
+func scanQuoted(contents string, start int, closing byte) int {
+	j := start + 1
+	for j < len(contents) {
+		if contents[j] == closing {
+			if j+1 < len(contents) && contents[j+1] == closing {
+				j += 2
+				continue
+			}
+			return j + 1
+		}
+		j++
+	}
+	return j
+}
+
+func isIdentifierChar(char byte) bool {
+	return char == '_' || char == '@' || char == '#' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9') || char >= 0x80
+}
+
+// matchKeywords reports whether the tokens start with the given keywords
+// (case insensitive).
+func matchKeywords(tokens []string, keywords ...string) bool {
+	if len(tokens) < len(keywords) {
+		return false
+	}
+	for i, keyword := range keywords {
+		if !strings.EqualFold(tokens[i], keyword) {
+			return false
+		}
+	}
+	return true
+}
+
+// isRerunnable reports whether every statement can safely be run again
+// after a partial failure, in which case a non-transactional migration does
+// not need an undo migration. Only ALTER TYPE ... ADD VALUE IF NOT EXISTS
+// qualifies (added enum labels cannot be removed anyway).
+func isRerunnable(stmts []lintStatement) bool {
+	for _, stmt := range stmts {
+		tokens := stmt.tokens
+		if !matchKeywords(tokens, "ALTER", "TYPE") || len(tokens) < 3 || !matchKeywords(tokens[3:], "ADD", "VALUE", "IF", "NOT", "EXISTS") {
+			return false
+		}
+	}
+	return true
+}
+
+// indexKeyword returns the index of the first token matching the keyword
+// (case insensitive), or -1 if not found.
+func indexKeyword(tokens []string, keyword string) int {
+	for i, token := range tokens {
+		if strings.EqualFold(token, keyword) {
+			return i
+		}
+	}
+	return -1
+}
+
+// splitTokens splits tokens by the separator, ignoring separators inside
+// brackets.
+func splitTokens(tokens []string, separator string) [][]string {
+	var parts [][]string
+	var bracketLevel, start int
+	for i, token := range tokens {
+		switch token {
+		case "(":
+			bracketLevel++
+		case ")":
+			bracketLevel--
+		case separator:
+			if bracketLevel == 0 {
+				parts = append(parts, tokens[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, tokens[start:])
+}
+
+// lintObjectName normalizes a (possibly schema-qualified and quoted) object
+// name for comparison.
+func lintObjectName(name string) string {
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.Trim(name, "\"`[]")
+	return strings.ToLower(name)
+}
@@ -0,0 +1,147 @@
+package ddl
+
+import (
+	"io"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/blink-io/sqddl/internal/testutil"
+)
+
+func TestLintCmd(t *testing.T) {
+	type TT struct {
+		description string
+		dialect     string
+		dirFS       fstest.MapFS
+		wantIssues  []LintIssue
+	}
+
+	tests := []TT{{
+		description: "postgres",
+		dialect:     DialectPostgres,
+		dirFS: fstest.MapFS{
+			"01_init.sql": &fstest.MapFile{Data: []byte(`
+CREATE TABLE actor (actor_id INT, first_name TEXT);
+CREATE INDEX actor_first_name_idx ON actor (first_name);
+ALTER TABLE actor ADD COLUMN last_name TEXT NOT NULL;
+`)},
+			"02_film.sql": &fstest.MapFile{Data: []byte(`
+CREATE INDEX film_title_idx ON "public".film (title);
+CREATE UNIQUE INDEX CONCURRENTLY film_slug_idx ON film (slug);
+ALTER TABLE film
+    ADD COLUMN rating TEXT NOT NULL,
+    ADD COLUMN language TEXT NOT NULL DEFAULT 'en',
+    ADD CONSTRAINT film_rating_check CHECK (rating IS NOT NULL);
+-- sqddl:lint-ignore=alter-column-type
+ALTER TABLE film ALTER COLUMN film_id TYPE BIGINT;
+ALTER TABLE film ALTER COLUMN title SET DATA TYPE VARCHAR(255); -- sqddl:lint-ignore=rename
+ALTER TABLE film DROP COLUMN IF EXISTS description, DROP CONSTRAINT film_slug_key;
+ALTER TABLE film RENAME COLUMN name TO title;
+ALTER TABLE film RENAME TO movie;
+CREATE FUNCTION f() RETURNS void AS $$
+BEGIN
+    ALTER TABLE film DROP COLUMN x;
+END
+$$ LANGUAGE plpgsql;
+`)},
+			"03_index.txoff.sql": &fstest.MapFile{Data: []byte(`
+-- sqddl:lint-ignore=index-not-concurrent
+CREATE INDEX film_rating_idx ON film (rating);
+`)},
+			"04_enum.txoff.sql": &fstest.MapFile{Data: []byte(`
+ALTER TYPE "public".mpaa_rating ADD VALUE IF NOT EXISTS 'PG' AFTER 'G';
+alter type mpaa_rating add value if not exists 'R';
+`)},
+			"05_enum.txoff.sql": &fstest.MapFile{Data: []byte(`
+ALTER TYPE mpaa_rating ADD VALUE 'NC-17';
+`)},
+		},
+		wantIssues: []LintIssue{
+			{Filename: "02_film.sql", Line: 2, Rule: LintIndexNotConcurrent},
+			{Filename: "02_film.sql", Line: 4, Rule: LintAddColumnNotNull},
+			{Filename: "02_film.sql", Line: 10, Rule: LintAlterColumnType},
+			{Filename: "02_film.sql", Line: 11, Rule: LintDropColumn},
+			{Filename: "02_film.sql", Line: 12, Rule: LintRename},
+			{Filename: "02_film.sql", Line: 13, Rule: LintRename},
+			{Filename: "03_index.txoff.sql", Rule: LintMissingUndo},
+			{Filename: "05_enum.txoff.sql", Rule: LintMissingUndo},
+		},
+	}, {
+		description: "mysql",
+		dialect:     DialectMySQL,
+		dirFS: fstest.MapFS{
+			"01_init.sql": &fstest.MapFile{Data: []byte(`
+ALTER TABLE actor MODIFY COLUMN first_name VARCHAR(100);
+ALTER TABLE actor CHANGE fname first_name VARCHAR(100), DROP last_name, DROP INDEX actor_idx;
+RENAME TABLE actor TO actors;
+`)},
+			"01_init.undo.sql": &fstest.MapFile{Data: []byte(`SELECT 1;`)},
+			"02_data.tx.sql":   &fstest.MapFile{Data: []byte(`UPDATE actor SET first_name = 'a;b';`)},
+			"03_misc.sql":      &fstest.MapFile{Data: []byte(`CREATE INDEX actor_name_idx ON actor (first_name);`)},
+		},
+		wantIssues: []LintIssue{
+			{Filename: "01_init.sql", Line: 2, Rule: LintAlterColumnType},
+			{Filename: "01_init.sql", Line: 3, Rule: LintRename},
+			{Filename: "01_init.sql", Line: 3, Rule: LintAlterColumnType},
+			{Filename: "01_init.sql", Line: 3, Rule: LintDropColumn},
+			{Filename: "01_init.sql", Line: 4, Rule: LintRename},
+			{Filename: "03_misc.sql", Rule: LintMissingUndo},
+		},
+	}, {
+		description: "sqlserver",
+		dialect:     DialectSQLServer,
+		dirFS: fstest.MapFS{
+			"01_init.sql": &fstest.MapFile{Data: []byte(`
+ALTER TABLE [dbo].[actor] ALTER COLUMN first_name NVARCHAR(100) NOT NULL;
+ALTER TABLE actor ADD last_name NVARCHAR(100) NOT NULL;
+ALTER TABLE actor ADD age INT NOT NULL CONSTRAINT actor_age_df DEFAULT 0;
+EXEC sp_rename 'actor.fname', 'first_name', 'COLUMN';
+`)},
+		},
+		wantIssues: []LintIssue{
+			{Filename: "01_init.sql", Line: 2, Rule: LintAlterColumnType},
+			{Filename: "01_init.sql", Line: 3, Rule: LintAddColumnNotNull},
+			{Filename: "01_init.sql", Line: 5, Rule: LintRename},
+		},
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.description, func(t *testing.T) {
+			t.Parallel()
+			lintCmd := &LintCmd{
+				Dialect: tt.dialect,
+				DirFS:   tt.dirFS,
+				Stdout:  io.Discard,
+			}
+			gotIssues, err := lintCmd.Results()
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			for i := range gotIssues {
+				gotIssues[i].Message = ""
+			}
+			if diff := testutil.Diff(gotIssues, tt.wantIssues); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+			err = lintCmd.Run()
+			if err == nil {
+				t.Error(testutil.Callers(), "expected error but got nil")
+			}
+		})
+	}
+
+	t.Run("sqlite_migrations", func(t *testing.T) {
+		t.Parallel()
+		lintCmd := &LintCmd{
+			Dialect: DialectSQLite,
+			DirFS:   os.DirFS("sqlite_migrations"),
+			Stdout:  io.Discard,
+		}
+		err := lintCmd.Run()
+		if err != nil {
+			t.Error(testutil.Callers(), err)
+		}
+	})
+}
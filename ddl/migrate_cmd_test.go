@@ -1,9 +1,11 @@
 package ddl
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"io"
+	"strings"
 	"testing"
 	"testing/fstest"
 
@@ -230,4 +232,84 @@ func TestMigrateCmd(t *testing.T) {
 			"table1",
 		})
 	})
+
+	t.Run("migration failure (non-transactional) with down script", func(t *testing.T) {
+		t.Parallel()
+		db, err := sql.Open("sqlite3", ":memory:?_foreign_keys=true")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		// The film table already exists (with data) but is missing from the
+		// source schema, so creating it fails partway through the migration.
+		_, err = db.Exec("CREATE TABLE film (film_id INT); INSERT INTO film (film_id) VALUES (1);")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		destCatalog := &Catalog{Dialect: DialectSQLite}
+		p := NewSQLParser()
+		err = p.ParseFile(&bufferFile{name: "schema.sql", buf: bytes.NewBufferString("CREATE TABLE actor (actor_id INT);\nCREATE TABLE film (film_id INT);")})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		err = p.WriteCatalog(destCatalog)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		generateCmd := &GenerateCmd{
+			Dialect:     DialectSQLite,
+			SrcCatalog:  &Catalog{Dialect: DialectSQLite},
+			DestCatalog: destCatalog,
+			Prefix:      "01_tables",
+		}
+		files, _, err := generateCmd.Results()
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		fsys := fstest.MapFS{}
+		for _, file := range files {
+			fileinfo, err := file.Stat()
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			b, err := io.ReadAll(file)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			// Make the migration non-transactional.
+			name := strings.Replace(fileinfo.Name(), "01_tables.sql", "01_tables.txoff.sql", 1)
+			fsys[name] = &fstest.MapFile{Data: b}
+		}
+		if _, ok := fsys["01_tables.down.sql"]; !ok {
+			t.Fatal(testutil.Callers(), "missing 01_tables.down.sql")
+		}
+		migrateCmd := &MigrateCmd{
+			Dialect: "sqlite",
+			DB:      db,
+			DirFS:   fsys,
+			Stderr:  io.Discard,
+		}
+		err = migrateCmd.Run()
+		if err == nil {
+			t.Error(testutil.Callers(), "expected error but got nil")
+		}
+		// Make sure the down script was neither run as a migration nor run to
+		// undo the failed migration, so the existing film table and its data
+		// are kept.
+		assertHistoryTable(t, db, []historyTableEntry{
+			{"01_tables.txoff.sql", false},
+		})
+		assertTables(t, db, []string{
+			"actor",
+			"film",
+			"sqddl_history",
+		})
+		var count int
+		err = db.QueryRow("SELECT COUNT(*) FROM film").Scan(&count)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if count != 1 {
+			t.Errorf(testutil.Callers()+" film: got %d rows, want 1", count)
+		}
+	})
 }
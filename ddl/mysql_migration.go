@@ -3,6 +3,7 @@ package ddl
 import (
 	"bytes"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -18,11 +19,22 @@ type mysqlMigration struct {
 	createTables     []*Table
 	alterTables      []mysqlAlterTable
 	addFkeys         []*Constraint
+	views            viewMigration
+	routines         routineMigration
+
+	// warnings are the warnings found while working out the migration.
+	warnings []Warning
+
+	// undoScopes are the objects changed by each file written by sql(), in
+	// order. Undo scripts are not included.
+	undoScopes []undoScope
 }
 
 type mysqlAlterTable struct {
 	tableSchema     string
 	tableName       string
+	renameFrom      string // The old name of the table if it is being renamed.
+	renameIndexes   [][2]string
 	dropConstraints []*Constraint
 	dropIndexes     []*Index
 	dropColumns     []*Column
@@ -30,6 +42,21 @@ type mysqlAlterTable struct {
 	alterColumns    [][2]*Column
 	createIndexes   []*Index
 	addConstraints  []*Constraint
+
+	// If isCommentChanged is true, the table comment is changed to comment.
+	isCommentChanged bool
+	comment          string
+
+	// The table options are changed from srcEngine to engine, from
+	// srcCharacterSet and srcCollationName to characterSet and collationName
+	// and to rowFormat. They are empty if unchanged.
+	srcEngine        string
+	engine           string
+	srcCharacterSet  string
+	characterSet     string
+	srcCollationName string
+	collationName    string
+	rowFormat        string
 }
 
 func newMySQLMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) mysqlMigration {
@@ -38,8 +65,11 @@ func newMySQLMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) mysql
 		versionNums:      srcCatalog.VersionNums,
 		currentSchema:    srcCatalog.CurrentSchema,
 		defaultCollation: srcCatalog.DefaultCollation,
+		views:            newViewMigration(dialect, srcCatalog, destCatalog, dropObjects),
 	}
+	m.routines = newRoutineMigration(dialect, srcCatalog, destCatalog, dropObjects, &m.views)
 	srcCache, destCache := NewCatalogCache(srcCatalog), NewCatalogCache(destCatalog)
+	m.warnings = renamedFromWarnings(srcCache, srcCatalog, destCatalog)
 	if dropObjects {
 		for i := range srcCatalog.Schemas {
 			srcSchema := &srcCatalog.Schemas[i]
@@ -90,9 +120,10 @@ func newMySQLMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) mysql
 			continue
 		}
 		if dropObjects {
+			isRenamedTable := renamedTables(srcCache, srcSchema, destSchema)
 			for j := range srcSchema.Tables {
 				srcTable := &srcSchema.Tables[j]
-				if srcTable.Ignore {
+				if srcTable.Ignore || isRenamedTable[srcTable.TableName] {
 					continue
 				}
 				destTable := destCache.GetTable(destSchema, srcTable.TableName)
@@ -110,7 +141,7 @@ func newMySQLMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) mysql
 			if destTable.Ignore {
 				continue
 			}
-			srcTable := srcCache.GetTable(srcSchema, destTable.TableName)
+			srcTable, isRenamed := findSrcTable(srcCache, srcSchema, destTable)
 			if srcTable == nil {
 				// CREATE TABLE.
 				m.createTables = append(m.createTables, destTable)
@@ -124,18 +155,40 @@ func newMySQLMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) mysql
 				tableSchema: destTable.TableSchema,
 				tableName:   destTable.TableName,
 			}
+			if isRenamed {
+				// RENAME TABLE.
+				alterTable.renameFrom = srcTable.TableName
+			}
+			// COMMENT.
+			if commentIsChanged(srcTable.Comment, destTable.Comment, dropObjects) {
+				alterTable.isCommentChanged = true
+				alterTable.comment = destTable.Comment
+			}
+			// ENGINE, CONVERT TO CHARACTER SET, ROW_FORMAT.
+			alterTable.engine, alterTable.characterSet, alterTable.collationName, alterTable.rowFormat = mysqlTableOptions(srcTable, destTable)
+			alterTable.srcEngine, alterTable.srcCharacterSet, alterTable.srcCollationName = srcTable.Engine, tableCharacterSet(srcTable), srcTable.CollationName
 			if dropObjects {
+				isRenamedConstraint := renamedConstraints(srcCache, srcTable, destTable)
 				for k := range srcTable.Constraints {
 					srcConstraint := &srcTable.Constraints[k]
 					if srcConstraint.Ignore {
 						continue
 					}
+					if isRenamedConstraint[srcConstraint.ConstraintName] && m.canRenameConstraint(srcConstraint) {
+						continue
+					}
 					destConstraint := destCache.GetConstraint(destTable, srcConstraint.ConstraintName)
 					if destConstraint == nil {
 						switch srcConstraint.ConstraintType {
 						case PRIMARY_KEY, UNIQUE:
 							// DROP PRIMARY KEY, DROP UNIQUE.
 							alterTable.dropConstraints = append(alterTable.dropConstraints, srcConstraint)
+						case CHECK:
+							// DROP CHECK. MySQL only enforces check constraints
+							// from version 8 onwards.
+							if !m.versionNums.LowerThan(8) {
+								alterTable.dropConstraints = append(alterTable.dropConstraints, srcConstraint)
+							}
 						case FOREIGN_KEY:
 							// DROP FOREIGN KEY.
 							m.dropFkeys = append(m.dropFkeys, srcConstraint)
@@ -143,9 +196,10 @@ func newMySQLMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) mysql
 						continue
 					}
 				}
+				isRenamedIndex := renamedIndexes(srcCache, srcTable, destTable)
 				for k := range srcTable.Indexes {
 					srcIndex := &srcTable.Indexes[k]
-					if srcIndex.Ignore {
+					if srcIndex.Ignore || (isRenamedIndex[srcIndex.IndexName] && !m.versionNums.LowerThan(5, 7)) {
 						continue
 					}
 					destIndex := destCache.GetIndex(destTable, srcIndex.IndexName)
@@ -154,9 +208,10 @@ func newMySQLMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) mysql
 						alterTable.dropIndexes = append(alterTable.dropIndexes, srcIndex)
 					}
 				}
+				isRenamedColumn := renamedColumns(srcCache, srcTable, destTable)
 				for k := range srcTable.Columns {
 					srcColumn := &srcTable.Columns[k]
-					if srcColumn.Ignore {
+					if srcColumn.Ignore || isRenamedColumn[srcColumn.ColumnName] {
 						continue
 					}
 					destColumn := destCache.GetColumn(destTable, srcColumn.ColumnName)
@@ -171,7 +226,7 @@ func newMySQLMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) mysql
 				if destColumn.Ignore {
 					continue
 				}
-				srcColumn := srcCache.GetColumn(srcTable, destColumn.ColumnName)
+				srcColumn, isRenamed := findSrcColumn(srcCache, srcTable, destColumn)
 				if srcColumn == nil {
 					// ADD COLUMN.
 					alterTable.addColumns = append(alterTable.addColumns, destColumn)
@@ -205,10 +260,22 @@ func newMySQLMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) mysql
 					if srcCollation != destCollation {
 						return true
 					}
+					if commentIsChanged(srcColumn.Comment, destColumn.Comment, dropObjects) {
+						return true
+					}
 					return false
 				}()
-				if columnsAreDifferent {
-					// ALTER COLUMN.
+				if columnsAreDifferent || isRenamed {
+					// ALTER COLUMN (or RENAME COLUMN, which is also done
+					// with the full column definition).
+					if destColumn.Comment == "" && srcColumn.Comment != "" && !dropObjects {
+						// MODIFY COLUMN replaces the entire column definition
+						// (including the comment), so we have to carry the
+						// existing comment over.
+						column := *destColumn
+						column.Comment = srcColumn.Comment
+						destColumn = &column
+					}
 					alterTable.alterColumns = append(alterTable.alterColumns, [2]*Column{srcColumn, destColumn})
 				}
 			}
@@ -217,10 +284,21 @@ func newMySQLMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) mysql
 				if destIndex.Ignore {
 					continue
 				}
-				srcIndex := srcCache.GetIndex(srcTable, destIndex.IndexName)
+				srcIndex, isRenamed := findSrcIndex(srcCache, srcTable, destTable, destIndex)
+				if isRenamed && m.versionNums.LowerThan(5, 7) {
+					// RENAME INDEX is only supported from MySQL 5.7 onwards.
+					srcIndex = nil
+				}
 				if srcIndex == nil {
 					// CREATE INDEX.
 					alterTable.createIndexes = append(alterTable.createIndexes, destIndex)
+				} else if !srcIndex.Ignore && !indexesAreEqual(dialect, srcIndex, destIndex) {
+					// DROP INDEX, CREATE INDEX.
+					alterTable.dropIndexes = append(alterTable.dropIndexes, srcIndex)
+					alterTable.createIndexes = append(alterTable.createIndexes, destIndex)
+				} else if isRenamed {
+					// RENAME INDEX.
+					alterTable.renameIndexes = append(alterTable.renameIndexes, [2]string{srcIndex.IndexName, destIndex.IndexName})
 				}
 			}
 			for k := range destTable.Constraints {
@@ -228,18 +306,39 @@ func newMySQLMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) mysql
 				if destConstraint.Ignore {
 					continue
 				}
-				srcConstraint := srcCache.GetConstraint(srcTable, destConstraint.ConstraintName)
+				srcConstraint, isRenamed := findSrcConstraint(srcCache, srcTable, destTable, destConstraint)
+				if isRenamed && !m.canRenameConstraint(srcConstraint) {
+					srcConstraint = nil
+				}
 				if srcConstraint == nil {
 					switch destConstraint.ConstraintType {
 					case PRIMARY_KEY, UNIQUE:
 						// ADD PRIMARY KEY | ADD UNIQUE.
 						alterTable.addConstraints = append(alterTable.addConstraints, destConstraint)
+					case CHECK:
+						// ADD CHECK. MySQL only enforces check constraints from
+						// version 8 onwards.
+						if !m.versionNums.LowerThan(8) {
+							alterTable.addConstraints = append(alterTable.addConstraints, destConstraint)
+						}
 					case FOREIGN_KEY:
 						// ADD FOREIGN KEY.
 						m.addFkeys = append(m.addFkeys, destConstraint)
 					}
 					continue
 				}
+				if destConstraint.ConstraintType == CHECK && !srcConstraint.Ignore && !checksAreEqual(DialectMySQL, srcConstraint, destConstraint) {
+					// DROP CHECK, ADD CHECK.
+					if !m.versionNums.LowerThan(8) {
+						alterTable.dropConstraints = append(alterTable.dropConstraints, srcConstraint)
+						alterTable.addConstraints = append(alterTable.addConstraints, destConstraint)
+					}
+					continue
+				}
+				if isRenamed && destConstraint.ConstraintType == UNIQUE {
+					// RENAME INDEX.
+					alterTable.renameIndexes = append(alterTable.renameIndexes, [2]string{srcConstraint.ConstraintName, destConstraint.ConstraintName})
+				}
 				// MySQL primary keys are always called `PRIMARY` so we cannot
 				// rely on the constraint name as their identity. Instead we
 				// have to manually check if their columns are the same. If the
@@ -254,12 +353,17 @@ func newMySQLMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) mysql
 					}
 				}
 			}
-			if len(alterTable.dropConstraints) > 0 ||
+			if alterTable.renameFrom != "" ||
+				len(alterTable.renameIndexes) > 0 ||
+				len(alterTable.dropConstraints) > 0 ||
 				len(alterTable.dropIndexes) > 0 ||
+				len(alterTable.dropColumns) > 0 ||
 				len(alterTable.addColumns) > 0 ||
 				len(alterTable.alterColumns) > 0 ||
 				len(alterTable.createIndexes) > 0 ||
-				len(alterTable.addConstraints) > 0 {
+				len(alterTable.addConstraints) > 0 ||
+				alterTable.isCommentChanged ||
+				alterTable.hasTableOptions() {
 				m.alterTables = append(m.alterTables, alterTable)
 			}
 		}
@@ -267,9 +371,82 @@ func newMySQLMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) mysql
 	return m
 }
 
-func (m *mysqlMigration) sql(prefix string) (filenames []string, bufs []*bytes.Buffer, warnings []string) {
+// mysqlTableOptions returns the table options of the destTable that are
+// different from the srcTable, or empty strings if unchanged. Table options
+// that the destTable leaves out are not compared.
+func mysqlTableOptions(srcTable, destTable *Table) (engine, characterSet, collationName, rowFormat string) {
+	if destTable.Engine != "" && !strings.EqualFold(srcTable.Engine, destTable.Engine) {
+		engine = destTable.Engine
+	}
+	srcCharacterSet, destCharacterSet := tableCharacterSet(srcTable), tableCharacterSet(destTable)
+	if destCharacterSet != "" && (!strings.EqualFold(srcCharacterSet, destCharacterSet) ||
+		(destTable.CollationName != "" && !strings.EqualFold(srcTable.CollationName, destTable.CollationName))) {
+		characterSet, collationName = destCharacterSet, destTable.CollationName
+	}
+	if destTable.RowFormat != "" && !strings.EqualFold(srcTable.RowFormat, destTable.RowFormat) {
+		rowFormat = destTable.RowFormat
+	}
+	return engine, characterSet, collationName, rowFormat
+}
+
+// tableCharacterSet returns the character set of a MySQL table, falling back
+// to the character set of its collation.
+func tableCharacterSet(table *Table) string {
+	if table.CharacterSet != "" {
+		return table.CharacterSet
+	}
+	return collationCharacterSet(table.CollationName)
+}
+
+// hasTableOptions reports whether any table options of the table are changed.
+func (alterTable *mysqlAlterTable) hasTableOptions() bool {
+	return alterTable.engine != "" || alterTable.characterSet != "" || alterTable.rowFormat != ""
+}
+
+// canRenameConstraint reports whether the constraint can be renamed in place.
+// Primary keys are always called PRIMARY and unique constraints are renamed
+// with RENAME INDEX (MySQL 5.7 onwards), but foreign keys and check
+// constraints have to be dropped and added back under the new name.
+func (m *mysqlMigration) canRenameConstraint(constraint *Constraint) bool {
+	switch constraint.ConstraintType {
+	case PRIMARY_KEY:
+		return true
+	case UNIQUE:
+		return !m.versionNums.LowerThan(5, 7)
+	default:
+		return false
+	}
+}
+
+func (m *mysqlMigration) sql(prefix string) (filenames []string, bufs []*bytes.Buffer, warnings []Warning) {
 	const dialect = DialectMySQL
 	n := 0
+	warnings = append(warnings, m.warnings...)
+	m.undoScopes = m.undoScopes[:0]
+
+	// DROP TRIGGER.
+	if m.routines.hasDropTriggers() {
+		n++
+		// ${prefix}_${n}_drop_triggers.sql
+		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_drop_triggers.sql")
+		m.undoScopes = append(m.undoScopes, m.routines.undoDropTriggers())
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		m.routines.writeDropTriggers(buf)
+	}
+
+	// DROP VIEW.
+	if m.views.hasDropViews() {
+		n++
+		// ${prefix}_${n}_drop_views.sql
+		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_drop_views.sql")
+		m.undoScopes = append(m.undoScopes, m.views.undoDropViews())
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		m.views.writeDropViews(buf)
+	}
 
 	// DROP FOREIGN KEY.
 	for _, fkey := range m.dropFkeys {
@@ -277,6 +454,7 @@ func (m *mysqlMigration) sql(prefix string) (filenames []string, bufs []*bytes.B
 		name := strings.ReplaceAll(fkey.ConstraintName, " ", "_")
 		// ${prefix}_${n}_drop_${constraint}.sql
 		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_drop_"+name+".sql")
+		m.undoScopes = append(m.undoScopes, undoScope{dropFkeys: []*Constraint{fkey}})
 		buf := bufpool.Get().(*bytes.Buffer)
 		buf.Reset()
 		bufs = append(bufs, buf)
@@ -293,6 +471,7 @@ func (m *mysqlMigration) sql(prefix string) (filenames []string, bufs []*bytes.B
 		n++
 		// ${prefix}_${n}_schemas.sql
 		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_schemas.sql")
+		m.undoScopes = append(m.undoScopes, undoScope{schemas: append(slices.Clone(m.dropSchemas), m.createSchemas...)})
 		buf := bufpool.Get().(*bytes.Buffer)
 		buf.Reset()
 		bufs = append(bufs, buf)
@@ -326,6 +505,7 @@ func (m *mysqlMigration) sql(prefix string) (filenames []string, bufs []*bytes.B
 		n++
 		// ${prefix}_${n}_tables.sql
 		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_tables.sql")
+		m.undoScopes = append(m.undoScopes, undoScope{tables: append(slices.Clone(m.dropTables), m.createTables...)})
 		buf := bufpool.Get().(*bytes.Buffer)
 		buf.Reset()
 		bufs = append(bufs, buf)
@@ -379,11 +559,41 @@ func (m *mysqlMigration) sql(prefix string) (filenames []string, bufs []*bytes.B
 		}
 		// ${prefix}_${n}_alter_${table}.sql
 		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_alter_"+name+".sql")
+		m.undoScopes = append(m.undoScopes, undoScope{tables: []*Table{{TableSchema: alterTable.tableSchema, TableName: alterTable.tableName}}})
 		buf := bufpool.Get().(*bytes.Buffer)
 		buf.Reset()
 		bufs = append(bufs, buf)
+		// RENAME TABLE.
+		if alterTable.renameFrom != "" {
+			oldTableName := QuoteIdentifier(dialect, alterTable.renameFrom)
+			if alterTable.tableSchema != "" && alterTable.tableSchema != m.currentSchema {
+				oldTableName = QuoteIdentifier(dialect, alterTable.tableSchema) + "." + oldTableName
+			}
+			buf.WriteString("RENAME TABLE " + oldTableName + " TO " + tableName + ";\n")
+			if len(alterTable.renameIndexes) == 0 &&
+				len(alterTable.dropConstraints) == 0 &&
+				len(alterTable.dropIndexes) == 0 &&
+				len(alterTable.dropColumns) == 0 &&
+				len(alterTable.addColumns) == 0 &&
+				len(alterTable.alterColumns) == 0 &&
+				len(alterTable.createIndexes) == 0 &&
+				len(alterTable.addConstraints) == 0 &&
+				!alterTable.isCommentChanged &&
+				!alterTable.hasTableOptions() {
+				continue
+			}
+			buf.WriteString("\n")
+		}
 		buf.WriteString("ALTER TABLE " + tableName)
 		written := false
+		for _, names := range alterTable.renameIndexes {
+			buf.WriteString("\n    ")
+			if written {
+				buf.WriteString(",")
+			}
+			written = true
+			buf.WriteString("RENAME INDEX " + QuoteIdentifier(dialect, names[0]) + " TO " + QuoteIdentifier(dialect, names[1]))
+		}
 		for _, constraint := range alterTable.dropConstraints {
 			buf.WriteString("\n    ")
 			if written {
@@ -422,23 +632,13 @@ func (m *mysqlMigration) sql(prefix string) (filenames []string, bufs []*bytes.B
 		}
 		for _, columns := range alterTable.alterColumns {
 			srcColumn, destColumn := columns[0], columns[1]
-			columnName := destColumn.ColumnName
 			srcType, srcArg1, srcArg2 := normalizeColumnType(dialect, srcColumn.ColumnType)
 			destType, destArg1, destArg2 := normalizeColumnType(dialect, destColumn.ColumnType)
 			if [3]string{srcType, srcArg1, srcArg2} != [3]string{destType, destArg1, destArg2} {
-				switch [2]string{srcType, destType} {
-				case [2]string{"VARCHAR", "VARCHAR"}:
-					srcLimit, _ := strconv.Atoi(srcArg1)
-					destLimit, _ := strconv.Atoi(destArg1)
-					if srcLimit > 0 && destLimit > 0 {
-						if srcLimit <= 255 && destLimit > 255 {
-							warnings = append(warnings, fmt.Sprintf("%s: column %q changing type from %q to %q is unsafe (cannot increase limit from less than or equal to 255 to greater than 255)", tableName, columnName, srcColumn.ColumnType, destColumn.ColumnType))
-						} else if srcLimit > 255 && destLimit <= 255 {
-							warnings = append(warnings, fmt.Sprintf("%s: column %q changing type from %q to %q is unsafe (cannot decrease limit from greater than 255 to less than or equal to 255)", tableName, columnName, srcColumn.ColumnType, destColumn.ColumnType))
-						}
-					}
-				default:
-					warnings = append(warnings, fmt.Sprintf("%s: column %q changing type from %q to %q may be unsafe", tableName, columnName, srcColumn.ColumnType, destColumn.ColumnType))
+				if warning, ok := mysqlAlterTypeWarning(srcColumn, destColumn); ok {
+					warning.Object = warningObject(m.currentSchema, alterTable.tableSchema, alterTable.tableName, destColumn.ColumnName)
+					warning.Message = tableName + ": " + warning.Message
+					warnings = append(warnings, warning)
 				}
 			}
 			buf.WriteString("\n    ")
@@ -446,7 +646,11 @@ func (m *mysqlMigration) sql(prefix string) (filenames []string, bufs []*bytes.B
 				buf.WriteString(",")
 			}
 			written = true
-			buf.WriteString("MODIFY COLUMN ")
+			if srcColumn.ColumnName != destColumn.ColumnName {
+				buf.WriteString("CHANGE COLUMN " + QuoteIdentifier(dialect, srcColumn.ColumnName) + " ")
+			} else {
+				buf.WriteString("MODIFY COLUMN ")
+			}
 			writeColumnDefinition(dialect, buf, m.defaultCollation, destColumn, false)
 		}
 		for _, index := range alterTable.createIndexes {
@@ -467,6 +671,57 @@ func (m *mysqlMigration) sql(prefix string) (filenames []string, bufs []*bytes.B
 			buf.WriteString("ADD ")
 			writeConstraintDefinition(dialect, buf, m.currentSchema, constraint)
 		}
+		if alterTable.engine != "" {
+			warnings = append(warnings, Warning{
+				Code:     WarnMySQLChangeEngine,
+				Severity: SeverityMedium,
+				Object:   warningObject(m.currentSchema, alterTable.tableSchema, alterTable.tableName),
+				Message:  fmt.Sprintf("%s: changing engine from %q to %q copies the entire table", tableName, alterTable.srcEngine, alterTable.engine),
+			})
+			buf.WriteString("\n    ")
+			if written {
+				buf.WriteString(",")
+			}
+			written = true
+			buf.WriteString("ENGINE = " + alterTable.engine)
+		}
+		if alterTable.characterSet != "" {
+			message := fmt.Sprintf("converting character set from %q to %q", alterTable.srcCharacterSet, alterTable.characterSet)
+			if strings.EqualFold(alterTable.srcCharacterSet, alterTable.characterSet) {
+				message = fmt.Sprintf("converting collation from %q to %q", alterTable.srcCollationName, alterTable.collationName)
+			}
+			warnings = append(warnings, Warning{
+				Code:     WarnMySQLConvertCharset,
+				Severity: SeverityHigh,
+				Object:   warningObject(m.currentSchema, alterTable.tableSchema, alterTable.tableName),
+				Message:  tableName + ": " + message + " rewrites every text column and is unsafe",
+			})
+			buf.WriteString("\n    ")
+			if written {
+				buf.WriteString(",")
+			}
+			written = true
+			buf.WriteString("CONVERT TO CHARACTER SET " + alterTable.characterSet)
+			if alterTable.collationName != "" {
+				buf.WriteString(" COLLATE " + alterTable.collationName)
+			}
+		}
+		if alterTable.rowFormat != "" {
+			buf.WriteString("\n    ")
+			if written {
+				buf.WriteString(",")
+			}
+			written = true
+			buf.WriteString("ROW_FORMAT = " + alterTable.rowFormat)
+		}
+		if alterTable.isCommentChanged {
+			buf.WriteString("\n    ")
+			if written {
+				buf.WriteString(",")
+			}
+			written = true
+			buf.WriteString("COMMENT = " + quoteComment(dialect, alterTable.comment))
+		}
 		buf.WriteString("\n;\n")
 	}
 
@@ -476,6 +731,7 @@ func (m *mysqlMigration) sql(prefix string) (filenames []string, bufs []*bytes.B
 		name := strings.ReplaceAll(fkey.ConstraintName, " ", "_")
 		// ${prefix}_${n}_add_${constraint}.sql
 		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_add_"+name+".sql")
+		m.undoScopes = append(m.undoScopes, undoScope{constraints: []*Constraint{fkey}})
 		buf := bufpool.Get().(*bytes.Buffer)
 		buf.Reset()
 		bufs = append(bufs, buf)
@@ -488,5 +744,116 @@ func (m *mysqlMigration) sql(prefix string) (filenames []string, bufs []*bytes.B
 		buf.WriteString(";\n")
 	}
 
+	// CREATE FUNCTION | CREATE PROCEDURE.
+	if m.routines.hasCreateRoutines() {
+		n++
+		// ${prefix}_${n}_routines.sql
+		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_routines.sql")
+		m.undoScopes = append(m.undoScopes, m.routines.undoCreateRoutines())
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		m.routines.writeCreateRoutines(buf)
+		undobuf := bufpool.Get().(*bytes.Buffer)
+		undobuf.Reset()
+		m.routines.writeUndoCreateRoutines(undobuf)
+		if undobuf.Len() > 0 {
+			// ${prefix}_${n}_routines.undo.sql
+			filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_routines.undo.sql")
+			bufs = append(bufs, undobuf)
+		} else {
+			bufpool.Put(undobuf)
+		}
+	}
+
+	// CREATE VIEW.
+	if m.views.hasCreateViews() {
+		n++
+		// ${prefix}_${n}_views.sql
+		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_views.sql")
+		m.undoScopes = append(m.undoScopes, m.views.undoCreateViews())
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		m.views.writeCreateViews(buf)
+		undobuf := bufpool.Get().(*bytes.Buffer)
+		undobuf.Reset()
+		m.views.writeUndoCreateViews(undobuf)
+		if undobuf.Len() > 0 {
+			// ${prefix}_${n}_views.undo.sql
+			filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_views.undo.sql")
+			bufs = append(bufs, undobuf)
+		} else {
+			bufpool.Put(undobuf)
+		}
+	}
+
+	// CREATE TRIGGER.
+	if m.routines.hasCreateTriggers() {
+		n++
+		// ${prefix}_${n}_triggers.sql
+		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_triggers.sql")
+		m.undoScopes = append(m.undoScopes, m.routines.undoCreateTriggers())
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		m.routines.writeCreateTriggers(buf)
+		undobuf := bufpool.Get().(*bytes.Buffer)
+		undobuf.Reset()
+		m.routines.writeUndoCreateTriggers(undobuf)
+		// ${prefix}_${n}_triggers.undo.sql
+		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_triggers.undo.sql")
+		bufs = append(bufs, undobuf)
+	}
+
+	// DROP FUNCTION | DROP PROCEDURE.
+	if m.routines.hasDropRoutines() {
+		n++
+		// ${prefix}_${n}_drop_routines.sql
+		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_drop_routines.sql")
+		m.undoScopes = append(m.undoScopes, m.routines.undoDropRoutines())
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		m.routines.writeDropRoutines(buf)
+	}
+
 	return filenames, bufs, warnings
 }
+
+// mysqlAlterTypeWarning returns the warning raised by changing the type of
+// the srcColumn to the type of the destColumn. The type change is safe if ok
+// is false.
+func mysqlAlterTypeWarning(srcColumn, destColumn *Column) (warning Warning, ok bool) {
+	const dialect = DialectMySQL
+	columnName := destColumn.ColumnName
+	srcType, srcArg1, _ := normalizeColumnType(dialect, srcColumn.ColumnType)
+	destType, destArg1, _ := normalizeColumnType(dialect, destColumn.ColumnType)
+	switch [2]string{srcType, destType} {
+	case [2]string{"VARCHAR", "VARCHAR"}:
+		srcLimit, _ := strconv.Atoi(srcArg1)
+		destLimit, _ := strconv.Atoi(destArg1)
+		if srcLimit > 0 && destLimit > 0 {
+			if srcLimit <= 255 && destLimit > 255 {
+				return Warning{
+					Code:     WarnMySQLChangeVarcharLimit,
+					Severity: SeverityHigh,
+					Message:  fmt.Sprintf("column %q changing type from %q to %q is unsafe (cannot increase limit from less than or equal to 255 to greater than 255)", columnName, srcColumn.ColumnType, destColumn.ColumnType),
+				}, true
+			} else if srcLimit > 255 && destLimit <= 255 {
+				return Warning{
+					Code:     WarnMySQLChangeVarcharLimit,
+					Severity: SeverityHigh,
+					Message:  fmt.Sprintf("column %q changing type from %q to %q is unsafe (cannot decrease limit from greater than 255 to less than or equal to 255)", columnName, srcColumn.ColumnType, destColumn.ColumnType),
+				}, true
+			}
+		}
+		return Warning{}, false
+	default:
+		return Warning{
+			Code:     WarnMySQLChangeColumnType,
+			Severity: SeverityMedium,
+			Message:  fmt.Sprintf("column %q changing type from %q to %q may be unsafe", columnName, srcColumn.ColumnType, destColumn.ColumnType),
+		}, true
+	}
+}
@@ -23,6 +23,11 @@ func Test_mysqlMigration(t *testing.T) {
 		{"testdata/mysql_add", false},
 		{"testdata/mysql_alter", false},
 		{"testdata/mysql_ignore", true},
+		{"testdata/mysql_check", true},
+		{"testdata/mysql_index", false},
+		{"testdata/mysql_comment", true},
+		{"testdata/mysql_rename", true},
+		{"testdata/mysql_table_options", true},
 	}
 	newCatalog := func(t *testing.T, filename string) *Catalog {
 		file, err := os.Open(filename)
@@ -70,7 +75,7 @@ func Test_mysqlMigration(t *testing.T) {
 			}
 			wantWarnings = string(bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n")))
 			if len(warnings) > 0 {
-				gotWarnings = strings.Join(warnings, "\n")
+				gotWarnings = strings.Join(warningMessages(warnings), "\n")
 			}
 			if diff := testutil.Diff(gotWarnings, wantWarnings); diff != "" {
 				t.Error(testutil.Callers(), diff)
@@ -78,3 +83,34 @@ func Test_mysqlMigration(t *testing.T) {
 		})
 	}
 }
+
+func Test_mysqlMigration_checkBeforeMySQL8(t *testing.T) {
+	// MySQL only enforces check constraints from version 8 onwards, so no
+	// migration should be generated for them.
+	src := &Catalog{Dialect: DialectMySQL, CurrentSchema: "sakila", VersionNums: VersionNums{5, 7}}
+	dest := &Catalog{Dialect: DialectMySQL, CurrentSchema: "sakila", VersionNums: VersionNums{5, 7}}
+	src.Schemas = []Schema{{SchemaName: "sakila", Tables: []Table{{
+		TableSchema: "sakila",
+		TableName:   "film",
+		Columns:     []Column{{TableSchema: "sakila", TableName: "film", ColumnName: "length", ColumnType: "INT"}},
+		Constraints: []Constraint{
+			{TableSchema: "sakila", TableName: "film", ConstraintName: "film_length_check", ConstraintType: CHECK, Columns: []string{"length"}, CheckExpr: "length > 0"},
+		},
+	}}}}
+	dest.Schemas = []Schema{{SchemaName: "sakila", Tables: []Table{{
+		TableSchema: "sakila",
+		TableName:   "film",
+		Columns:     []Column{{TableSchema: "sakila", TableName: "film", ColumnName: "length", ColumnType: "INT"}},
+		Constraints: []Constraint{
+			{TableSchema: "sakila", TableName: "film", ConstraintName: "film_length_check1", ConstraintType: CHECK, Columns: []string{"length"}, CheckExpr: "length >= 0"},
+		},
+	}}}}
+	m := newMySQLMigration(src, dest, true)
+	filenames, _, warnings := m.sql("check")
+	if len(filenames) > 0 {
+		t.Errorf(testutil.Callers()+" unexpected migration files: %v", filenames)
+	}
+	if len(warnings) > 0 {
+		t.Errorf(testutil.Callers()+" unexpected warnings: %v", warnings)
+	}
+}
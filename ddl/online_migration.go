@@ -0,0 +1,621 @@
+package ddl
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// onlineBatchSize is the number of rows updated by each batch of a backfill.
+const onlineBatchSize = 1000
+
+// onlineColumn is a column change that is broken up into expand/contract
+// steps by an online migration.
+type onlineColumn struct {
+	// srcColumn is the column in the srcCatalog, or nil if the column is being
+	// added.
+	srcColumn *Column
+
+	// destColumn is the column in the destCatalog.
+	destColumn *Column
+
+	// shadowName is the name of the column that the values are backfilled
+	// into when the column type changes. It is empty if the column is being
+	// added.
+	shadowName string
+}
+
+// onlineMigration generates the migration files that migrate the srcCatalog
+// to the destCatalog using the expand/contract pattern. Column changes that
+// cannot be done safely on a large table are broken up into these steps, each
+// step getting its own migration file(s):
+//
+//  1. Expand: columns being added are added as nullable without a default.
+//     Columns changing type get a nullable shadow column with the new type,
+//     and a trigger that copies every write of the old column into it.
+//  2. The column defaults are set, so that new rows get them.
+//  3. Backfill: existing rows are updated in batches.
+//  4. Swap: the triggers, the old columns (and the views that depend on
+//     them) are dropped and the shadow columns are renamed to the old column
+//     names, all in one file.
+//  5. Contract: NOT NULL, the defaults of the renamed columns and the views
+//     are added, together with every other change.
+//
+// Every other change is done in the expand and contract steps as usual.
+func onlineMigration(dialect string, srcCatalog, destCatalog *Catalog, dropObjects, down bool, prefix string) (filenames []string, bufs []*bytes.Buffer, warnings []Warning, err error) {
+	tables, columns := onlineColumns(dialect, srcCatalog, destCatalog)
+
+	// Work out the intermediate catalogs of each step.
+	expandCatalog := copyCatalog(destCatalog)
+	for _, table := range tables {
+		expandTable := onlineTable(expandCatalog, table)
+		for _, column := range columns[table] {
+			expandColumn := onlineTableColumn(expandTable, column.destColumn.ColumnName)
+			if column.srcColumn == nil {
+				expandColumn.IsNotNull = false
+				expandColumn.ColumnDefault = ""
+				continue
+			}
+			shadowColumn := *column.destColumn
+			shadowColumn.ColumnName = column.shadowName
+			shadowColumn.IsNotNull = false
+			shadowColumn.ColumnDefault = ""
+			shadowColumn.IsPrimaryKey = false
+			shadowColumn.IsUnique = false
+			shadowColumn.ReferencesSchema = ""
+			shadowColumn.ReferencesTable = ""
+			shadowColumn.ReferencesColumn = ""
+			shadowColumn.Comment = ""
+			shadowColumn.RenamedFrom = ""
+			*expandColumn = *column.srcColumn
+			expandColumn.ColumnName = column.destColumn.ColumnName
+			expandColumn.RenamedFrom = column.destColumn.RenamedFrom
+			expandTable.Columns = append(expandTable.Columns, shadowColumn)
+		}
+	}
+	defaultsCatalog := copyCatalog(expandCatalog)
+	clearRenames(defaultsCatalog)
+	for _, table := range tables {
+		defaultsTable := onlineTable(defaultsCatalog, table)
+		for _, column := range columns[table] {
+			if column.srcColumn == nil {
+				onlineTableColumn(defaultsTable, column.destColumn.ColumnName).ColumnDefault = column.destColumn.ColumnDefault
+			}
+		}
+	}
+	dropCatalog := copyCatalog(defaultsCatalog)
+	dropViews := onlineDependentViews(dialect, dropCatalog, tables, columns)
+	for i := range dropCatalog.Schemas {
+		schema := &dropCatalog.Schemas[i]
+		schema.Views = slices.DeleteFunc(schema.Views, func(view View) bool {
+			return dropViews[viewKey(view.ViewSchema, view.ViewName)]
+		})
+	}
+	for _, table := range tables {
+		dropTable := onlineTable(dropCatalog, table)
+		for _, column := range columns[table] {
+			if column.srcColumn != nil {
+				dropTable.Columns = slices.DeleteFunc(dropTable.Columns, func(c Column) bool {
+					return c.ColumnName == column.destColumn.ColumnName
+				})
+			}
+		}
+	}
+	renameCatalog := copyCatalog(dropCatalog)
+	for _, table := range tables {
+		renameTable := onlineTable(renameCatalog, table)
+		for _, column := range columns[table] {
+			if column.srcColumn != nil {
+				renameColumn := onlineTableColumn(renameTable, column.shadowName)
+				renameColumn.ColumnName = column.destColumn.ColumnName
+				renameColumn.RenamedFrom = column.shadowName
+			}
+		}
+	}
+	contractCatalog := copyCatalog(destCatalog)
+	clearRenames(contractCatalog)
+
+	type step struct {
+		srcCatalog, destCatalog *Catalog
+		dropObjects, down       bool
+	}
+	steps := []step{
+		{srcCatalog, expandCatalog, dropObjects, down},
+		// The sync triggers go here.
+		{expandCatalog, defaultsCatalog, dropObjects, down},
+		// The backfill goes here.
+		{defaultsCatalog, dropCatalog, true, false},
+		{dropCatalog, renameCatalog, dropObjects, false},
+		{renameCatalog, contractCatalog, dropObjects, down},
+	}
+	n := 0
+	var swapBuf *bytes.Buffer
+	for i, step := range steps {
+		if i == 2 {
+			for _, table := range tables {
+				n++
+				name := strings.ReplaceAll(table[1], " ", "_")
+				if table[0] != "" && table[0] != destCatalog.CurrentSchema {
+					name = strings.ReplaceAll(table[0], " ", "_") + "_" + name
+				}
+				if dialect == DialectPostgres {
+					// ${prefix}_${n}_backfill_${table}.txoff.sql
+					filenames = append(filenames, fmt.Sprintf("%s_%02d_backfill_%s.txoff.sql", prefix, n, name))
+				} else {
+					// ${prefix}_${n}_backfill_${table}.sql
+					filenames = append(filenames, fmt.Sprintf("%s_%02d_backfill_%s.sql", prefix, n, name))
+				}
+				buf := bufpool.Get().(*bytes.Buffer)
+				buf.Reset()
+				bufs = append(bufs, buf)
+				writeBackfill(dialect, buf, destCatalog.CurrentSchema, table, columns[table])
+			}
+		}
+		stepFilenames, stepBufs, stepWarnings, err := generateMigration(dialect, step.srcCatalog, step.destCatalog, step.dropObjects, step.down, prefix)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		warnings = append(warnings, stepWarnings...)
+		if i == 2 || i == 3 {
+			// The old columns are dropped and the shadow columns renamed in
+			// the same file, so that (Postgres) the swap happens in a single
+			// transaction and no write goes in between.
+			if swapBuf == nil {
+				swapBuf = bufpool.Get().(*bytes.Buffer)
+				swapBuf.Reset()
+				for _, table := range tables {
+					writeDropSync(dialect, swapBuf, destCatalog.CurrentSchema, table, columns[table])
+				}
+			}
+			for j, stepBuf := range stepBufs {
+				if !strings.HasSuffix(stepFilenames[j], ".undo.sql") && stepBuf.Len() > 0 {
+					if swapBuf.Len() > 0 {
+						swapBuf.WriteString("\n")
+					}
+					swapBuf.Write(stepBuf.Bytes())
+				}
+				bufpool.Put(stepBuf)
+			}
+			if i == 3 && swapBuf.Len() > 0 {
+				n++
+				if dialect == DialectPostgres {
+					// ${prefix}_${n}_swap_columns.tx.sql
+					filenames = append(filenames, fmt.Sprintf("%s_%02d_swap_columns.tx.sql", prefix, n))
+				} else {
+					// ${prefix}_${n}_swap_columns.sql
+					filenames = append(filenames, fmt.Sprintf("%s_%02d_swap_columns.sql", prefix, n))
+				}
+				bufs = append(bufs, swapBuf)
+			}
+			continue
+		}
+		n = renumberMigration(prefix, stepFilenames, n)
+		filenames = append(filenames, stepFilenames...)
+		bufs = append(bufs, stepBufs...)
+		if i != 0 {
+			continue
+		}
+		for _, table := range tables {
+			if !slices.ContainsFunc(columns[table], func(column onlineColumn) bool { return column.srcColumn != nil }) {
+				continue
+			}
+			n++
+			name := strings.ReplaceAll(table[1], " ", "_")
+			if table[0] != "" && table[0] != destCatalog.CurrentSchema {
+				name = strings.ReplaceAll(table[0], " ", "_") + "_" + name
+			}
+			syncBuf := bufpool.Get().(*bytes.Buffer)
+			syncBuf.Reset()
+			writeSync(dialect, syncBuf, destCatalog.CurrentSchema, table, columns[table])
+			dropBuf := bufpool.Get().(*bytes.Buffer)
+			dropBuf.Reset()
+			writeDropSync(dialect, dropBuf, destCatalog.CurrentSchema, table, columns[table])
+			if dialect == DialectPostgres {
+				// ${prefix}_${n}_sync_${table}.tx.sql
+				filenames = append(filenames, fmt.Sprintf("%s_%02d_sync_%s.tx.sql", prefix, n, name))
+				bufs = append(bufs, syncBuf)
+			} else {
+				// ${prefix}_${n}_sync_${table}.sql
+				// ${prefix}_${n}_sync_${table}.undo.sql
+				filenames = append(filenames, fmt.Sprintf("%s_%02d_sync_%s.sql", prefix, n, name), fmt.Sprintf("%s_%02d_sync_%s.undo.sql", prefix, n, name))
+				undoBuf := bufpool.Get().(*bytes.Buffer)
+				undoBuf.Reset()
+				undoBuf.Write(dropBuf.Bytes())
+				bufs = append(bufs, syncBuf, undoBuf)
+			}
+			if down {
+				// ${prefix}_${n}_sync_${table}.down.sql
+				filenames = append(filenames, fmt.Sprintf("%s_%02d_sync_%s.down.sql", prefix, n, name))
+				bufs = append(bufs, dropBuf)
+			} else {
+				bufpool.Put(dropBuf)
+			}
+		}
+	}
+	return filenames, bufs, warnings, nil
+}
+
+// onlineColumns returns the columns (grouped by table) whose changes are
+// broken up into expand/contract steps, together with the tables in catalog
+// order. A table is identified by its schema and name in the destCatalog.
+//
+// A column being added is broken up if it is NOT NULL with a default, since
+// every existing row has to be filled in. A column changing type is broken up
+// if the type change is unsafe, unless the column is part of an identity,
+// generated column, index or constraint or the new type is narrower (those
+// are changed directly).
+func onlineColumns(dialect string, srcCatalog, destCatalog *Catalog) (tables [][2]string, columns map[[2]string][]onlineColumn) {
+	columns = make(map[[2]string][]onlineColumn)
+	srcCache := NewCatalogCache(srcCatalog)
+	for i := range destCatalog.Schemas {
+		destSchema := &destCatalog.Schemas[i]
+		if destSchema.Ignore {
+			continue
+		}
+		srcSchema := srcCache.GetSchema(srcCatalog, destSchema.SchemaName)
+		if srcSchema == nil {
+			continue
+		}
+		for j := range destSchema.Tables {
+			destTable := &destSchema.Tables[j]
+			if destTable.Ignore {
+				continue
+			}
+			srcTable, _ := findSrcTable(srcCache, srcSchema, destTable)
+			if srcTable == nil || srcTable.Ignore {
+				continue
+			}
+			table := [2]string{destTable.TableSchema, destTable.TableName}
+			for k := range destTable.Columns {
+				destColumn := &destTable.Columns[k]
+				if destColumn.Ignore || destColumn.ColumnIdentity != "" || destColumn.IsAutoincrement || destColumn.IsGenerated || destColumn.GeneratedExpr != "" {
+					continue
+				}
+				srcColumn, _ := findSrcColumn(srcCache, srcTable, destColumn)
+				if srcColumn == nil {
+					if destColumn.IsNotNull && destColumn.ColumnDefault != "" && !isColumnReferenced(destCatalog, destTable, destColumn.ColumnName) {
+						columns[table] = append(columns[table], onlineColumn{destColumn: destColumn})
+					}
+					continue
+				}
+				if srcColumn.Ignore || srcColumn.ColumnIdentity != "" || srcColumn.IsAutoincrement || srcColumn.IsGenerated || srcColumn.GeneratedExpr != "" {
+					continue
+				}
+				srcType, srcArg1, srcArg2 := normalizeColumnType(dialect, srcColumn.ColumnType)
+				destType, destArg1, destArg2 := normalizeColumnType(dialect, destColumn.ColumnType)
+				if [3]string{srcType, srcArg1, srcArg2} == [3]string{destType, destArg1, destArg2} {
+					continue
+				}
+				switch dialect {
+				case DialectPostgres:
+					if srcColumn.DomainName != "" && srcColumn.DomainName == destColumn.DomainName {
+						continue
+					}
+					if len(postgresAlterTypeWarnings(srcColumn, destColumn)) == 0 {
+						continue
+					}
+				case DialectMySQL:
+					if _, ok := mysqlAlterTypeWarning(srcColumn, destColumn); !ok {
+						continue
+					}
+				}
+				if isNarrowingTypeChange(dialect, srcColumn, destColumn) {
+					continue
+				}
+				shadowName := destColumn.ColumnName + "_new"
+				if isColumnReferenced(srcCatalog, srcTable, srcColumn.ColumnName) ||
+					isColumnReferenced(destCatalog, destTable, destColumn.ColumnName) ||
+					srcCache.GetColumn(srcTable, shadowName) != nil ||
+					slices.ContainsFunc(destTable.Columns, func(c Column) bool { return c.ColumnName == shadowName }) {
+					continue
+				}
+				columns[table] = append(columns[table], onlineColumn{
+					srcColumn:  srcColumn,
+					destColumn: destColumn,
+					shadowName: shadowName,
+				})
+			}
+			if len(columns[table]) > 0 {
+				tables = append(tables, table)
+			}
+		}
+	}
+	return tables, columns
+}
+
+// isNarrowingTypeChange reports if changing the type of the srcColumn to the
+// type of the destColumn can make existing values no longer fit, because the
+// limit, precision or scale becomes smaller. Such a change is not broken up:
+// copying the values into a shadow column would not check them any earlier
+// than changing the column directly, and the warning about the change would
+// be lost.
+func isNarrowingTypeChange(dialect string, srcColumn, destColumn *Column) bool {
+	switch dialect {
+	case DialectPostgres:
+		for _, warning := range postgresAlterTypeWarnings(srcColumn, destColumn) {
+			switch warning.Code {
+			case WarnPGLimitTextColumn, WarnPGDecreaseColumnLimit, WarnPGDecreaseColumnPrecision, WarnPGChangeColumnScale:
+				return true
+			}
+		}
+	case DialectMySQL:
+		srcType, srcArg1, _ := normalizeColumnType(dialect, srcColumn.ColumnType)
+		destType, destArg1, _ := normalizeColumnType(dialect, destColumn.ColumnType)
+		srcLimit, _ := strconv.Atoi(srcArg1)
+		destLimit, _ := strconv.Atoi(destArg1)
+		if srcType == "VARCHAR" && destType == "VARCHAR" && destLimit < srcLimit {
+			return true
+		}
+	}
+	return false
+}
+
+// isColumnReferenced reports if the column is part of an index or constraint
+// of the table, or is referenced by a foreign key of any table in the
+// catalog.
+func isColumnReferenced(catalog *Catalog, table *Table, columnName string) bool {
+	for _, constraint := range table.Constraints {
+		if !constraint.Ignore && slices.Contains(constraint.Columns, columnName) {
+			return true
+		}
+	}
+	for _, index := range table.Indexes {
+		if !index.Ignore && (slices.Contains(index.Columns, columnName) || slices.Contains(index.IncludeColumns, columnName)) {
+			return true
+		}
+	}
+	for i := range catalog.Schemas {
+		for j := range catalog.Schemas[i].Tables {
+			for _, constraint := range catalog.Schemas[i].Tables[j].Constraints {
+				if constraint.Ignore || constraint.ConstraintType != FOREIGN_KEY || constraint.ReferencesTable != table.TableName {
+					continue
+				}
+				referencesSchema := constraint.ReferencesSchema
+				if referencesSchema == "" {
+					referencesSchema = constraint.TableSchema
+				}
+				if referencesSchema == table.TableSchema && slices.Contains(constraint.ReferencesColumns, columnName) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// onlineDependentViews returns the views (keyed by viewKey) that depend on
+// the tables whose old columns are dropped, directly or through other views.
+// They have to be dropped together with the old columns and recreated once
+// the shadow columns are renamed.
+func onlineDependentViews(dialect string, catalog *Catalog, tables [][2]string, columns map[[2]string][]onlineColumn) map[[2]string]bool {
+	isDropped := make(map[[2]string]bool)
+	for _, table := range tables {
+		for _, column := range columns[table] {
+			if column.srcColumn != nil {
+				isDropped[viewKey(table[0], table[1])] = true
+			}
+		}
+	}
+	dropViews := make(map[[2]string]bool)
+	if len(isDropped) == 0 {
+		return dropViews
+	}
+	keys, nodes := newViewNodes(dialect, catalog)
+	for changed := true; changed; {
+		changed = false
+		for _, key := range keys {
+			if dropViews[key] {
+				continue
+			}
+			node := nodes[key]
+			if slices.ContainsFunc(node.tables, func(table [2]string) bool { return isDropped[table] }) ||
+				slices.ContainsFunc(node.views, func(view [2]string) bool { return dropViews[view] }) {
+				dropViews[key] = true
+				changed = true
+			}
+		}
+	}
+	return dropViews
+}
+
+// writeBackfill writes the script that backfills the columns of a table in
+// batches of onlineBatchSize rows, committing after every batch. Columns
+// being added are filled with their default and shadow columns are filled
+// with the values of their old columns. The script can safely be rerun.
+func writeBackfill(dialect string, buf *bytes.Buffer, currentSchema string, table [2]string, columns []onlineColumn) {
+	tableName := QuoteIdentifier(dialect, table[1])
+	if table[0] != "" && table[0] != currentSchema {
+		tableName = QuoteIdentifier(dialect, table[0]) + "." + tableName
+	}
+	var assignments, conditions []string
+	for _, column := range columns {
+		columnName := QuoteIdentifier(dialect, column.destColumn.ColumnName)
+		if column.srcColumn == nil {
+			assignments = append(assignments, columnName+" = COALESCE("+columnName+", "+column.destColumn.ColumnDefault+")")
+			conditions = append(conditions, columnName+" IS NULL")
+			continue
+		}
+		shadowName := QuoteIdentifier(dialect, column.shadowName)
+		value := columnName
+		if dialect == DialectPostgres {
+			value = "CAST(" + columnName + " AS " + unmodifiedType(column.destColumn.ColumnType) + ")"
+		}
+		assignments = append(assignments, shadowName+" = COALESCE("+shadowName+", "+value+")")
+		conditions = append(conditions, "("+shadowName+" IS NULL AND "+columnName+" IS NOT NULL)")
+	}
+	buf.WriteString("-- sqddl:lint-ignore=" + LintMissingUndo + "\n")
+	switch dialect {
+	case DialectPostgres:
+		buf.WriteString("DO $$\nDECLARE\n    n BIGINT;\nBEGIN\n    LOOP\n" +
+			"        UPDATE " + tableName + " SET " + strings.Join(assignments, ", ") + "\n" +
+			"        WHERE ctid IN (SELECT ctid FROM " + tableName + " WHERE " + strings.Join(conditions, " OR ") + " LIMIT " + strconv.Itoa(onlineBatchSize) + ");\n" +
+			"        GET DIAGNOSTICS n = ROW_COUNT;\n" +
+			"        EXIT WHEN n = 0;\n" +
+			"        COMMIT;\n" +
+			"    END LOOP;\nEND $$;\n")
+	case DialectMySQL:
+		procedureName := QuoteIdentifier(dialect, "sqddl_backfill_"+table[1])
+		if table[0] != "" && table[0] != currentSchema {
+			procedureName = QuoteIdentifier(dialect, table[0]) + "." + procedureName
+		}
+		buf.WriteString("DROP PROCEDURE IF EXISTS " + procedureName + ";\n\n" +
+			"CREATE PROCEDURE " + procedureName + "()\nBEGIN\n    REPEAT\n" +
+			"        UPDATE " + tableName + " SET " + strings.Join(assignments, ", ") + "\n" +
+			"        WHERE " + strings.Join(conditions, " OR ") + "\n" +
+			"        LIMIT " + strconv.Itoa(onlineBatchSize) + ";\n" +
+			"    UNTIL ROW_COUNT() = 0 END REPEAT;\nEND;\n\n" +
+			"CALL " + procedureName + "();\n\n" +
+			"DROP PROCEDURE " + procedureName + ";\n")
+	}
+}
+
+// unmodifiedType returns the column type without its type modifiers e.g.
+// VARCHAR(255) becomes VARCHAR. An explicit cast to a type with modifiers
+// silently truncates or rounds the value, while assigning the cast value to
+// the shadow column applies the modifiers of the column and raises an error
+// if the value does not fit.
+func unmodifiedType(columnType string) string {
+	return typeModifiers.ReplaceAllString(columnType, "")
+}
+
+var typeModifiers = regexp.MustCompile(`\s*\([0-9\s,]*\)`)
+
+// writeSync writes the trigger that copies the old column of every shadow
+// column of the table into the shadow column whenever a row is inserted or
+// updated.
+func writeSync(dialect string, buf *bytes.Buffer, currentSchema string, table [2]string, columns []onlineColumn) {
+	tableName := QuoteIdentifier(dialect, table[1])
+	triggerName := QuoteIdentifier(dialect, "sqddl_sync_"+table[1])
+	if table[0] != "" && table[0] != currentSchema {
+		tableName = QuoteIdentifier(dialect, table[0]) + "." + tableName
+		triggerName = QuoteIdentifier(dialect, table[0]) + "." + triggerName
+	}
+	var assignments []string
+	for _, column := range columns {
+		if column.srcColumn == nil {
+			continue
+		}
+		columnName := QuoteIdentifier(dialect, column.destColumn.ColumnName)
+		shadowName := QuoteIdentifier(dialect, column.shadowName)
+		if dialect == DialectPostgres {
+			assignments = append(assignments, "NEW."+shadowName+" = CAST(NEW."+columnName+" AS "+unmodifiedType(column.destColumn.ColumnType)+")")
+		} else {
+			assignments = append(assignments, "NEW."+shadowName+" = NEW."+columnName)
+		}
+	}
+	switch dialect {
+	case DialectPostgres:
+		buf.WriteString("CREATE OR REPLACE FUNCTION " + triggerName + "() RETURNS trigger AS $$ BEGIN\n")
+		for _, assignment := range assignments {
+			buf.WriteString("    " + assignment + ";\n")
+		}
+		buf.WriteString("    RETURN NEW;\nEND; $$ LANGUAGE plpgsql;\n\n")
+		buf.WriteString("CREATE TRIGGER " + QuoteIdentifier(dialect, "sqddl_sync_"+table[1]) + " BEFORE INSERT OR UPDATE ON " + tableName + " FOR EACH ROW EXECUTE FUNCTION " + triggerName + "();\n")
+	case DialectMySQL:
+		for i, event := range []string{"INSERT", "UPDATE"} {
+			if i > 0 {
+				buf.WriteString("\n")
+			}
+			buf.WriteString("CREATE TRIGGER " + mysqlSyncTriggerName(currentSchema, table, event) + " BEFORE " + event + " ON " + tableName + " FOR EACH ROW SET " + strings.Join(assignments, ", ") + ";\n")
+		}
+	}
+}
+
+// writeDropSync writes the statements that drop the trigger written by
+// writeSync, if the table has any shadow columns.
+func writeDropSync(dialect string, buf *bytes.Buffer, currentSchema string, table [2]string, columns []onlineColumn) {
+	if !slices.ContainsFunc(columns, func(column onlineColumn) bool { return column.srcColumn != nil }) {
+		return
+	}
+	tableName := QuoteIdentifier(dialect, table[1])
+	triggerName := QuoteIdentifier(dialect, "sqddl_sync_"+table[1])
+	if table[0] != "" && table[0] != currentSchema {
+		tableName = QuoteIdentifier(dialect, table[0]) + "." + tableName
+		triggerName = QuoteIdentifier(dialect, table[0]) + "." + triggerName
+	}
+	if buf.Len() > 0 {
+		buf.WriteString("\n")
+	}
+	switch dialect {
+	case DialectPostgres:
+		buf.WriteString("DROP TRIGGER IF EXISTS " + QuoteIdentifier(dialect, "sqddl_sync_"+table[1]) + " ON " + tableName + ";\n")
+		buf.WriteString("DROP FUNCTION IF EXISTS " + triggerName + "();\n")
+	case DialectMySQL:
+		buf.WriteString("DROP TRIGGER IF EXISTS " + mysqlSyncTriggerName(currentSchema, table, "INSERT") + ";\n")
+		buf.WriteString("DROP TRIGGER IF EXISTS " + mysqlSyncTriggerName(currentSchema, table, "UPDATE") + ";\n")
+	}
+}
+
+// mysqlSyncTriggerName returns the name of the MySQL sync trigger of a table
+// for the event. MySQL needs a separate trigger for every event.
+func mysqlSyncTriggerName(currentSchema string, table [2]string, event string) string {
+	triggerName := QuoteIdentifier(DialectMySQL, "sqddl_sync_"+table[1]+"_"+strings.ToLower(event))
+	if table[0] != "" && table[0] != currentSchema {
+		triggerName = QuoteIdentifier(DialectMySQL, table[0]) + "." + triggerName
+	}
+	return triggerName
+}
+
+// renumberMigration renumbers the migration files generated with the prefix
+// so that they continue on from the nth migration file, and returns the new
+// number of the last migration file. Undo scripts keep the number of their
+// migration file.
+func renumberMigration(prefix string, filenames []string, n int) int {
+	numbers := make(map[string]string)
+	for i, filename := range filenames {
+		rest := strings.TrimPrefix(filename, prefix+"_")
+		num, rest, ok := strings.Cut(rest, "_")
+		if !ok {
+			continue
+		}
+		newNum, ok := numbers[num]
+		if !ok {
+			n++
+			newNum = fmt.Sprintf("%02d", n)
+			numbers[num] = newNum
+		}
+		filenames[i] = prefix + "_" + newNum + "_" + rest
+	}
+	return n
+}
+
+// copyCatalog returns a deep copy of the catalog.
+func copyCatalog(catalog *Catalog) *Catalog {
+	dest := &Catalog{}
+	_ = catalog.WriteCatalog(dest) // Copying into an empty catalog never fails.
+	return dest
+}
+
+// clearRenames removes the renamedfrom hints from the catalog. Once the
+// tables and columns have been renamed, the hints no longer apply.
+func clearRenames(catalog *Catalog) {
+	for i := range catalog.Schemas {
+		for j := range catalog.Schemas[i].Tables {
+			table := &catalog.Schemas[i].Tables[j]
+			table.RenamedFrom = ""
+			for k := range table.Columns {
+				table.Columns[k].RenamedFrom = ""
+			}
+		}
+	}
+}
+
+// onlineTable returns the table in the catalog identified by its schema and
+// name.
+func onlineTable(catalog *Catalog, table [2]string) *Table {
+	cache := NewCatalogCache(catalog)
+	return cache.GetTable(cache.GetSchema(catalog, table[0]), table[1])
+}
+
+// onlineTableColumn returns the column in the table with the given name.
+func onlineTableColumn(table *Table, columnName string) *Column {
+	for i := range table.Columns {
+		if table.Columns[i].ColumnName == columnName {
+			return &table.Columns[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package ddl
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blink-io/sqddl/internal/testutil"
+)
+
+func TestOnlineMigration(t *testing.T) {
+	type TT struct {
+		dialect      string
+		dir          string
+		wantFiles    map[string][]string
+		wantWarnings []string
+	}
+
+	assert := func(t *testing.T, generateCmd *GenerateCmd, tt TT) {
+		files, warnings, err := generateCmd.Results()
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		gotFiles := make(map[string]string)
+		var gotNames []string
+		for _, file := range files {
+			fileinfo, err := file.Stat()
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			b, err := io.ReadAll(file)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			gotFiles[fileinfo.Name()] = string(b)
+			gotNames = append(gotNames, fileinfo.Name())
+		}
+		for name, wantStmts := range tt.wantFiles {
+			content, ok := gotFiles[name]
+			if !ok {
+				t.Errorf(testutil.Callers()+" missing file %s in %q", name, gotNames)
+				continue
+			}
+			for _, stmt := range wantStmts {
+				if !strings.Contains(content, stmt) {
+					t.Errorf(testutil.Callers()+" %s: missing %q in\n%s", name, stmt, content)
+				}
+			}
+		}
+		if diff := testutil.Diff(warningMessages(warnings), tt.wantWarnings); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	}
+
+	tests := []TT{{
+		dialect: "postgres",
+		dir:     "testdata/postgres_online",
+		wantFiles: map[string][]string{
+			"online_01_alter_person.tx.sql": {
+				"ALTER TABLE person ADD COLUMN is_active BOOLEAN;",
+				"ALTER TABLE person ADD COLUMN score_new BIGINT;",
+				"ALTER TABLE person ALTER COLUMN bio TYPE VARCHAR(255);",
+				"ALTER TABLE person ALTER COLUMN notes TYPE VARCHAR(100);",
+			},
+			"online_03_sync_person.tx.sql": {
+				"CREATE OR REPLACE FUNCTION sqddl_sync_person() RETURNS trigger AS $$ BEGIN\n" +
+					"    NEW.score_new = CAST(NEW.score AS BIGINT);\n" +
+					"    RETURN NEW;\n" +
+					"END; $$ LANGUAGE plpgsql;",
+				"CREATE TRIGGER sqddl_sync_person BEFORE INSERT OR UPDATE ON person FOR EACH ROW EXECUTE FUNCTION sqddl_sync_person();",
+			},
+			"online_04_alter_person.tx.sql": {
+				"ALTER TABLE person ALTER COLUMN is_active SET DEFAULT '1';",
+			},
+			"online_05_backfill_person.txoff.sql": {
+				"-- sqddl:lint-ignore=missing-undo",
+				"UPDATE person SET is_active = COALESCE(is_active, TRUE), score_new = COALESCE(score_new, CAST(score AS BIGINT))",
+				"WHERE ctid IN (SELECT ctid FROM person WHERE is_active IS NULL OR (score_new IS NULL AND score IS NOT NULL) LIMIT 1000);",
+				"COMMIT;",
+			},
+			// The trigger is dropped, and the old column dropped and the
+			// shadow column renamed, in a single transaction.
+			"online_06_swap_columns.tx.sql": {
+				"DROP TRIGGER IF EXISTS sqddl_sync_person ON person;\n" +
+					"DROP FUNCTION IF EXISTS sqddl_sync_person();\n" +
+					"\nALTER TABLE person DROP COLUMN IF EXISTS score;\n" +
+					"\nALTER TABLE person RENAME COLUMN score_new TO score;\n",
+			},
+			"online_07_alter_person.tx.sql": {
+				"ALTER TABLE person ALTER COLUMN score SET DEFAULT '0';",
+				"ALTER TABLE person ADD CONSTRAINT person_score_not_null_check CHECK (score IS NOT NULL) NOT VALID;",
+			},
+			"online_08_validate_person_not_null.tx.sql": {
+				"ALTER TABLE person ALTER COLUMN is_active SET NOT NULL;",
+			},
+		},
+		// bio is narrowed and notes is indexed, so they are changed directly
+		// and keep their warnings.
+		wantWarnings: []string{
+			`person: column "bio" decreasing limit from "VARCHAR(1000)" to "VARCHAR(255)" is unsafe`,
+			`person: column "notes" decreasing limit from "VARCHAR(255)" to "VARCHAR(100)" is unsafe`,
+		},
+	}, {
+		dialect: "mysql",
+		dir:     "testdata/mysql_online",
+		wantFiles: map[string][]string{
+			"online_01_alter_person.sql": {
+				"ADD COLUMN is_active BOOLEAN",
+				",ADD COLUMN score_new BIGINT",
+				",MODIFY COLUMN bio VARCHAR(255) NOT NULL DEFAULT ''",
+			},
+			"online_02_sync_person.sql": {
+				"CREATE TRIGGER sqddl_sync_person_insert BEFORE INSERT ON person FOR EACH ROW SET NEW.score_new = NEW.score;",
+				"CREATE TRIGGER sqddl_sync_person_update BEFORE UPDATE ON person FOR EACH ROW SET NEW.score_new = NEW.score;",
+			},
+			"online_02_sync_person.undo.sql": {
+				"DROP TRIGGER IF EXISTS sqddl_sync_person_insert;",
+				"DROP TRIGGER IF EXISTS sqddl_sync_person_update;",
+			},
+			"online_03_alter_person.sql": {
+				"MODIFY COLUMN is_active BOOLEAN DEFAULT TRUE",
+			},
+			"online_04_backfill_person.sql": {
+				"-- sqddl:lint-ignore=missing-undo",
+				"CREATE PROCEDURE sqddl_backfill_person()",
+				"UPDATE person SET is_active = COALESCE(is_active, TRUE), score_new = COALESCE(score_new, score)",
+				"LIMIT 1000;",
+				"CALL sqddl_backfill_person();",
+			},
+			"online_05_swap_columns.sql": {
+				"DROP TRIGGER IF EXISTS sqddl_sync_person_insert;",
+				"DROP TRIGGER IF EXISTS sqddl_sync_person_update;",
+				"DROP COLUMN score",
+				"CHANGE COLUMN score_new score BIGINT",
+			},
+			"online_06_alter_person.sql": {
+				"MODIFY COLUMN is_active BOOLEAN NOT NULL DEFAULT TRUE",
+				",MODIFY COLUMN score BIGINT NOT NULL DEFAULT 0",
+			},
+		},
+		wantWarnings: []string{
+			`person: column "bio" changing type from "VARCHAR(1000)" to "VARCHAR(255)" is unsafe (cannot decrease limit from greater than 255 to less than or equal to 255)`,
+		},
+	}}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.dir, func(t *testing.T) {
+			t.Parallel()
+			generateCmd, err := GenerateCommand(
+				"-src", filepath.Join(tt.dir, "src.go.txt"),
+				"-dest", filepath.Join(tt.dir, "dest.go.txt"),
+				"-prefix", "online",
+				"-dialect", tt.dialect,
+				"-strategy", "online",
+			)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			assert(t, generateCmd, tt)
+		})
+	}
+
+	t.Run("views", func(t *testing.T) {
+		t.Parallel()
+		person := func(codeType string) Table {
+			return Table{
+				TableSchema: "public",
+				TableName:   "person",
+				Columns: []Column{
+					{TableSchema: "public", TableName: "person", ColumnName: "person_id", ColumnType: "INT", IsNotNull: true},
+					{TableSchema: "public", TableName: "person", ColumnName: "code", ColumnType: codeType},
+				},
+			}
+		}
+		catalog := func(codeType string) *Catalog {
+			return &Catalog{
+				Dialect:       DialectPostgres,
+				CurrentSchema: "public",
+				Schemas: []Schema{{
+					SchemaName: "public",
+					ViewsValid: true,
+					Tables:     []Table{person(codeType)},
+					Views: []View{
+						{ViewSchema: "public", ViewName: "person_codes", SQL: "SELECT person_id, code FROM person"},
+						{ViewSchema: "public", ViewName: "short_codes", SQL: "SELECT * FROM person_codes"},
+					},
+				}},
+			}
+		}
+		generateCmd := &GenerateCmd{
+			SrcCatalog:  catalog("INT"),
+			DestCatalog: catalog("VARCHAR(20)"),
+			Dialect:     DialectPostgres,
+			Prefix:      "online",
+			Strategy:    StrategyOnline,
+		}
+		assert(t, generateCmd, TT{
+			wantFiles: map[string][]string{
+				// The values are cast to VARCHAR without its limit, so that
+				// assigning a value that is too long raises an error instead
+				// of truncating it.
+				"online_02_sync_person.tx.sql": {
+					"NEW.code_new = CAST(NEW.code AS VARCHAR);",
+				},
+				"online_03_backfill_person.txoff.sql": {
+					"UPDATE person SET code_new = COALESCE(code_new, CAST(code AS VARCHAR))",
+				},
+				"online_04_swap_columns.tx.sql": {
+					"DROP VIEW IF EXISTS short_codes;",
+					"DROP VIEW IF EXISTS person_codes;",
+					"ALTER TABLE person DROP COLUMN IF EXISTS code;",
+					"ALTER TABLE person RENAME COLUMN code_new TO code;",
+				},
+				"online_05_views.sql": {
+					"CREATE VIEW person_codes AS SELECT person_id, code FROM person;",
+					"CREATE VIEW short_codes AS SELECT * FROM person_codes;",
+				},
+			},
+		})
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		t.Parallel()
+		for _, args := range [][]string{
+			{"-dialect", "sqlite", "-strategy", "online"},
+			{"-dialect", "postgres", "-strategy", "yolo"},
+		} {
+			generateCmd, err := GenerateCommand(append([]string{
+				"-src", "testdata/postgres_online/src.go.txt",
+				"-dest", "testdata/postgres_online/dest.go.txt",
+			}, args...)...)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			_, _, err = generateCmd.Results()
+			if err == nil {
+				t.Errorf(testutil.Callers()+" %q: expected error, got nil", args)
+			}
+		}
+	})
+}
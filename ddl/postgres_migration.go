@@ -3,6 +3,7 @@ package ddl
 import (
 	"bytes"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -12,15 +13,45 @@ type postgresMigration struct {
 	currentSchema    string
 	defaultCollation string
 
+	// 0. Drop the views that depend on changed tables.
+	views viewMigration
+
 	// 1. Drop the foreign keys.
 	dropFkeys [][]*Constraint
 
+	// Create the extensions before anything that may depend on them.
+	// Extensions are dropped after every table has been changed.
+	createExtensions []string
+	dropExtensions   []string
+
 	// 2. Execute all DROP SCHEMA + CREATE SCHEMA + DROP TABLE + CREATE TABLE in one transaction.
 	dropSchemas   []string
 	createSchemas []string
 	dropTables    []*Table
 	createTables  []*Table
 
+	// Create the enum types and add new labels to existing enum types before
+	// any table is created (new labels must be committed before they can be
+	// used). Enum types are dropped after every table has been changed.
+	createEnums []*Enum
+	alterEnums  []postgresAlterEnum
+	dropEnums   []*Enum
+
+	// Domain types are handled the same way as enum types. New check
+	// constraints are added as NOT VALID and validated in a separate
+	// transaction.
+	createDomains []*Domain
+	alterDomains  []postgresAlterDomain
+	dropDomains   []*Domain
+
+	// Create the sequences before any table is created (column defaults may
+	// call nextval). Sequences are altered after every table has been
+	// changed, because OWNED BY needs the owning column to exist. Sequences
+	// are dropped after every table has been changed.
+	createSequences []*Sequence
+	alterSequences  [][2]*Sequence
+	dropSequences   []*Sequence
+
 	// 3. Execute each ALTER TABLE.
 	alterTables []postgresAlterTable
 
@@ -30,12 +61,44 @@ type postgresMigration struct {
 
 	// 5. Add the foreign keys for existing tables.
 	addFkeys [][]*Constraint
+
+	// 6. Create the routines, views and triggers (see routines and views).
+	// The removed routines are dropped last.
+	routines routineMigration
+
+	// warnings are the warnings found while working out the migration.
+	warnings []Warning
+
+	// undoScopes are the objects changed by each file written by sql(), in
+	// order. Undo scripts are not included.
+	undoScopes []undoScope
 }
 
 type postgresAlterTable struct {
 	tableSchema string
 	tableName   string
 
+	// isPartitioned is true if the table is a partitioned table, whose
+	// indexes cannot be created concurrently.
+	isPartitioned bool
+
+	// partitions are the existing partitions of a partitioned table. A new
+	// index on the partitioned table is created ON ONLY the partitioned
+	// table, created concurrently on every partition and then attached.
+	partitions []*Table
+
+	// renameFrom is the old name of the table if it is being renamed.
+	renameFrom string
+
+	// renameColumns are the columns to rename as {oldName, newName}.
+	renameColumns [][2]string
+
+	// renameConstraints and renameIndexes are the constraints and indexes
+	// whose names were generated from the old table name, to rename as
+	// {oldName, newName}.
+	renameConstraints [][2]string
+	renameIndexes     [][2]string
+
 	// Do these in one transaction.
 	dropIndexes      []*Index
 	dropConstraints  []*Constraint
@@ -43,10 +106,23 @@ type postgresAlterTable struct {
 	addColumns       []*Column
 	alterColumns     [][2]*Column
 	alterConstraints [][2]*Constraint
+	addChecks        []*Constraint
+	addExclusions    []*Constraint
+
+	// addKeyConstraints are the primary key and unique constraints of a
+	// partitioned table, which cannot be added concurrently.
+	addKeyConstraints []*Constraint
+
+	// comments are the comments to change as {columnName, srcComment,
+	// destComment}. The columnName is empty for the table comment.
+	comments [][3]string
 
 	// Validate NOT NULL check constraints in a separate transaction.
 	validateNotNull []*Column
 
+	// Validate the added check constraints in a separate transaction.
+	validateChecks []*Constraint
+
 	// Create indexes concurrently outside a transaction.
 	createIndexesConcurrently []*Index
 
@@ -54,14 +130,48 @@ type postgresAlterTable struct {
 	addConstraintsConcurrently []*Constraint
 }
 
+type postgresAlterEnum struct {
+	enumSchema string
+	enumName   string
+
+	// addLabels are the labels to add in order, as {label, position,
+	// neighbour} where position is "BEFORE", "AFTER" or "" (add to the end).
+	addLabels [][3]string
+
+	// removedLabels and isReordered are only used for warnings because
+	// Postgres cannot remove or reorder enum labels.
+	removedLabels []string
+	isReordered   bool
+}
+
+type postgresAlterDomain struct {
+	domainSchema string
+	domainName   string
+	setDefault   string
+	dropDefault  bool
+	setNotNull   bool
+	dropNotNull  bool
+
+	// dropChecks and addChecks are {constraintName, checkExpr} pairs.
+	dropChecks [][2]string
+	addChecks  [][2]string
+
+	// isTypeChanged is only used for warnings because the underlying type
+	// or collation of a domain type cannot be changed.
+	isTypeChanged bool
+}
+
 func newPostgresMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) postgresMigration {
 	const dialect = DialectPostgres
 	m := postgresMigration{
 		versionNums:      srcCatalog.VersionNums,
 		currentSchema:    srcCatalog.CurrentSchema,
 		defaultCollation: srcCatalog.DefaultCollation,
+		views:            newViewMigration(dialect, srcCatalog, destCatalog, dropObjects),
 	}
+	m.routines = newRoutineMigration(dialect, srcCatalog, destCatalog, dropObjects, &m.views)
 	srcCache, destCache := NewCatalogCache(srcCatalog), NewCatalogCache(destCatalog)
+	m.warnings = renamedFromWarnings(srcCache, srcCatalog, destCatalog)
 	dropFkeysPos := make(map[[4]string]int)
 	addFastFkeysPos := make(map[[4]string]int)
 	addFkeysPos := make(map[[4]string]int)
@@ -81,6 +191,150 @@ func newPostgresMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) po
 		}
 		return tablesID
 	}
+	for _, extension := range destCatalog.Extensions {
+		if !slices.Contains(srcCatalog.Extensions, extension) {
+			// CREATE EXTENSION.
+			m.createExtensions = append(m.createExtensions, extension)
+		}
+	}
+	if dropObjects && destCatalog.ExtensionsValid {
+		for _, extension := range srcCatalog.Extensions {
+			// plpgsql is installed by default and should never be dropped.
+			if extension != "plpgsql" && !slices.Contains(destCatalog.Extensions, extension) {
+				// DROP EXTENSION.
+				m.dropExtensions = append(m.dropExtensions, extension)
+			}
+		}
+	}
+	for i := range destCatalog.Schemas {
+		destSchema := &destCatalog.Schemas[i]
+		if destSchema.Ignore {
+			continue
+		}
+		srcSchema := srcCache.GetSchema(srcCatalog, destSchema.SchemaName)
+		for j := range destSchema.Enums {
+			destEnum := &destSchema.Enums[j]
+			if destEnum.Ignore {
+				continue
+			}
+			srcEnum := srcCache.GetEnum(srcSchema, destEnum.EnumName)
+			if srcEnum == nil {
+				// CREATE TYPE.
+				m.createEnums = append(m.createEnums, destEnum)
+				continue
+			}
+			// ALTER TYPE.
+			alterEnum := newPostgresAlterEnum(srcEnum, destEnum)
+			if len(alterEnum.addLabels) > 0 || len(alterEnum.removedLabels) > 0 || alterEnum.isReordered {
+				m.alterEnums = append(m.alterEnums, alterEnum)
+			}
+		}
+		for j := range destSchema.Domains {
+			destDomain := &destSchema.Domains[j]
+			if destDomain.Ignore {
+				continue
+			}
+			srcDomain := srcCache.GetDomain(srcSchema, destDomain.DomainName)
+			if srcDomain == nil {
+				// CREATE DOMAIN.
+				m.createDomains = append(m.createDomains, destDomain)
+				continue
+			}
+			// ALTER DOMAIN.
+			alterDomain := newPostgresAlterDomain(srcDomain, destDomain, m.defaultCollation, dropObjects)
+			if alterDomain.setDefault != "" || alterDomain.dropDefault ||
+				alterDomain.setNotNull || alterDomain.dropNotNull ||
+				len(alterDomain.dropChecks) > 0 || len(alterDomain.addChecks) > 0 ||
+				alterDomain.isTypeChanged {
+				m.alterDomains = append(m.alterDomains, alterDomain)
+			}
+		}
+		for j := range destSchema.Sequences {
+			destSequence := &destSchema.Sequences[j]
+			if destSequence.Ignore {
+				continue
+			}
+			srcSequence := srcCache.GetSequence(srcSchema, destSequence.SequenceName)
+			if srcSequence == nil {
+				// CREATE SEQUENCE.
+				m.createSequences = append(m.createSequences, destSequence)
+				continue
+			}
+			// ALTER SEQUENCE.
+			if !sequencesAreEqual(srcSequence, destSequence) {
+				m.alterSequences = append(m.alterSequences, [2]*Sequence{srcSequence, destSequence})
+			}
+		}
+	}
+	if dropObjects {
+		for i := range srcCatalog.Schemas {
+			srcSchema := &srcCatalog.Schemas[i]
+			if srcSchema.Ignore {
+				continue
+			}
+			// If the schema is dropped, its enum types are dropped with it.
+			destSchema := destCache.GetSchema(destCatalog, srcSchema.SchemaName)
+			if destSchema == nil || destSchema.Ignore || !destSchema.EnumsValid {
+				continue
+			}
+			for j := range srcSchema.Enums {
+				srcEnum := &srcSchema.Enums[j]
+				if srcEnum.Ignore {
+					continue
+				}
+				if destCache.GetEnum(destSchema, srcEnum.EnumName) == nil {
+					// DROP TYPE.
+					m.dropEnums = append(m.dropEnums, srcEnum)
+				}
+			}
+		}
+		for i := range srcCatalog.Schemas {
+			srcSchema := &srcCatalog.Schemas[i]
+			if srcSchema.Ignore {
+				continue
+			}
+			// If the schema is dropped, its domain types are dropped with it.
+			destSchema := destCache.GetSchema(destCatalog, srcSchema.SchemaName)
+			if destSchema == nil || destSchema.Ignore || !destSchema.DomainsValid {
+				continue
+			}
+			for j := range srcSchema.Domains {
+				srcDomain := &srcSchema.Domains[j]
+				if srcDomain.Ignore {
+					continue
+				}
+				if destCache.GetDomain(destSchema, srcDomain.DomainName) == nil {
+					// DROP DOMAIN.
+					m.dropDomains = append(m.dropDomains, srcDomain)
+				}
+			}
+		}
+		for i := range srcCatalog.Schemas {
+			srcSchema := &srcCatalog.Schemas[i]
+			if srcSchema.Ignore {
+				continue
+			}
+			// If the schema is dropped, its sequences are dropped with it.
+			destSchema := destCache.GetSchema(destCatalog, srcSchema.SchemaName)
+			if destSchema == nil || destSchema.Ignore || !destSchema.SequencesValid {
+				continue
+			}
+			for j := range srcSchema.Sequences {
+				srcSequence := &srcSchema.Sequences[j]
+				if srcSequence.Ignore || destCache.GetSequence(destSchema, srcSequence.SequenceName) != nil {
+					continue
+				}
+				// The sequence of a SERIAL column is created by the column
+				// itself, so it is not declared in destCatalog.
+				destTable := destCache.GetTable(destSchema, srcSequence.OwnedByTable)
+				if destColumn := destCache.GetColumn(destTable, srcSequence.OwnedByColumn); destColumn != nil && strings.Contains(strings.ToUpper(destColumn.ColumnType), "SERIAL") {
+					continue
+				}
+				// DROP SEQUENCE.
+				m.dropSequences = append(m.dropSequences, srcSequence)
+			}
+		}
+	}
 	if dropObjects {
 		for i := range srcCatalog.Schemas {
 			srcSchema := &srcCatalog.Schemas[i]
@@ -148,13 +402,21 @@ func newPostgresMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) po
 			continue
 		}
 		if dropObjects {
+			isRenamedTable := renamedTables(srcCache, srcSchema, destSchema)
 			for j := range srcSchema.Tables {
 				srcTable := &srcSchema.Tables[j]
-				if srcTable.Ignore {
+				if srcTable.Ignore || isRenamedTable[srcTable.TableName] {
 					continue
 				}
 				destTable := destCache.GetTable(destSchema, srcTable.TableName)
 				if destTable == nil {
+					// Partitions are often created and dropped outside of
+					// the schema (e.g. a new partition every month), so they
+					// are only dropped if destCatalog declares the partitions
+					// of the partitioned table.
+					if srcTable.PartitionOf != "" && !hasPartitions(destCatalog, srcTable) {
+						continue
+					}
 					// DROP TABLE.
 					m.dropTables = append(m.dropTables, srcTable)
 					// DROP FOREIGN KEY.
@@ -177,7 +439,7 @@ func newPostgresMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) po
 			if destTable.Ignore {
 				continue
 			}
-			srcTable := srcCache.GetTable(srcSchema, destTable.TableName)
+			srcTable, isRenamed := findSrcTable(srcCache, srcSchema, destTable)
 			if srcTable == nil {
 				// CREATE TABLE.
 				m.createTables = append(m.createTables, destTable)
@@ -195,22 +457,49 @@ func newPostgresMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) po
 				}
 				continue
 			}
+			if !partitionKeysAreEqual(srcTable.PartitionBy, destTable.PartitionBy) || srcTable.PartitionOf != destTable.PartitionOf {
+				object := warningObject(m.currentSchema, destTable.TableSchema, destTable.TableName)
+				m.warnings = append(m.warnings, Warning{
+					Code:     WarnPGChangePartitioning,
+					Severity: SeverityMedium,
+					Object:   object,
+					Message:  fmt.Sprintf("%s: the partitioning of an existing table cannot be changed, the table has to be recreated (or the partition detached and attached) manually", object),
+				})
+			}
+			// The columns, constraints and indexes of a partition are
+			// changed through its partitioned table.
+			if destTable.PartitionOf != "" {
+				continue
+			}
 			// ALTER TABLE.
 			alterTable := postgresAlterTable{
-				tableSchema: destTable.TableSchema,
-				tableName:   destTable.TableName,
+				tableSchema:   destTable.TableSchema,
+				tableName:     destTable.TableName,
+				isPartitioned: destTable.PartitionBy != "",
+			}
+			if alterTable.isPartitioned {
+				alterTable.partitions = partitionsOf(srcCatalog, srcTable)
+			}
+			if isRenamed {
+				// RENAME TO.
+				alterTable.renameFrom = srcTable.TableName
+			}
+			// COMMENT ON TABLE.
+			if commentIsChanged(srcTable.Comment, destTable.Comment, dropObjects) {
+				alterTable.comments = append(alterTable.comments, [3]string{"", srcTable.Comment, destTable.Comment})
 			}
 			if dropObjects {
+				isRenamedConstraint := renamedConstraints(srcCache, srcTable, destTable)
 				for k := range srcTable.Constraints {
 					srcConstraint := &srcTable.Constraints[k]
-					if srcConstraint.Ignore {
+					if srcConstraint.Ignore || isRenamedConstraint[srcConstraint.ConstraintName] {
 						continue
 					}
 					destConstraint := destCache.GetConstraint(destTable, srcConstraint.ConstraintName)
 					if destConstraint == nil {
 						switch srcConstraint.ConstraintType {
-						case PRIMARY_KEY, UNIQUE:
-							// DROP PRIMARY KEY, DROP UNIQUE.
+						case PRIMARY_KEY, UNIQUE, CHECK, EXCLUDE:
+							// DROP PRIMARY KEY, DROP UNIQUE, DROP CHECK, DROP EXCLUDE.
 							alterTable.dropConstraints = append(alterTable.dropConstraints, srcConstraint)
 						case FOREIGN_KEY:
 							// DROP FOREIGN KEY.
@@ -225,9 +514,10 @@ func newPostgresMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) po
 						}
 					}
 				}
+				isRenamedIndex := renamedIndexes(srcCache, srcTable, destTable)
 				for k := range srcTable.Indexes {
 					srcIndex := &srcTable.Indexes[k]
-					if srcIndex.Ignore {
+					if srcIndex.Ignore || isRenamedIndex[srcIndex.IndexName] {
 						continue
 					}
 					destIndex := destCache.GetIndex(destTable, srcIndex.IndexName)
@@ -236,9 +526,10 @@ func newPostgresMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) po
 						alterTable.dropIndexes = append(alterTable.dropIndexes, srcIndex)
 					}
 				}
+				isRenamedColumn := renamedColumns(srcCache, srcTable, destTable)
 				for k := range srcTable.Columns {
 					srcColumn := &srcTable.Columns[k]
-					if srcColumn.Ignore {
+					if srcColumn.Ignore || isRenamedColumn[srcColumn.ColumnName] {
 						continue
 					}
 					destColumn := destCache.GetColumn(destTable, srcColumn.ColumnName)
@@ -253,16 +544,30 @@ func newPostgresMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) po
 				if destColumn.Ignore {
 					continue
 				}
-				srcColumn := srcCache.GetColumn(srcTable, destColumn.ColumnName)
+				srcColumn, isRenamed := findSrcColumn(srcCache, srcTable, destColumn)
+				if isRenamed {
+					// RENAME COLUMN.
+					alterTable.renameColumns = append(alterTable.renameColumns, [2]string{srcColumn.ColumnName, destColumn.ColumnName})
+				}
 				if srcColumn == nil {
 					// ADD COLUMN.
 					alterTable.addColumns = append(alterTable.addColumns, destColumn)
+					if destColumn.Comment != "" {
+						alterTable.comments = append(alterTable.comments, [3]string{destColumn.ColumnName, "", destColumn.Comment})
+					}
 					continue
 				}
+				// COMMENT ON COLUMN.
+				if commentIsChanged(srcColumn.Comment, destColumn.Comment, dropObjects) {
+					alterTable.comments = append(alterTable.comments, [3]string{destColumn.ColumnName, srcColumn.Comment, destColumn.Comment})
+				}
 				columnsAreDifferent := func() bool {
 					srcType, srcArg1, srcArg2 := normalizeColumnType(dialect, srcColumn.ColumnType)
 					destType, destArg1, destArg2 := normalizeColumnType(dialect, destColumn.ColumnType)
-					if [3]string{srcType, srcArg1, srcArg2} != [3]string{destType, destArg1, destArg2} {
+					// The underlying type of a domain column changes with the
+					// domain, not the column.
+					sameDomain := srcColumn.DomainName != "" && srcColumn.DomainName == destColumn.DomainName
+					if !sameDomain && [3]string{srcType, srcArg1, srcArg2} != [3]string{destType, destArg1, destArg2} {
 						return true
 					}
 					srcDefault := normalizeColumnDefault(dialect, srcColumn.ColumnDefault)
@@ -302,10 +607,17 @@ func newPostgresMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) po
 				if destIndex.Ignore {
 					continue
 				}
-				srcIndex := srcCache.GetIndex(srcTable, destIndex.IndexName)
+				srcIndex, isRenamed := findSrcIndex(srcCache, srcTable, destTable, destIndex)
 				if srcIndex == nil {
 					// CREATE INDEX CONCURRENTLY.
 					alterTable.createIndexesConcurrently = append(alterTable.createIndexesConcurrently, destIndex)
+				} else if !srcIndex.Ignore && !indexesAreEqual(dialect, srcIndex, destIndex) {
+					// DROP INDEX, CREATE INDEX CONCURRENTLY.
+					alterTable.dropIndexes = append(alterTable.dropIndexes, srcIndex)
+					alterTable.createIndexesConcurrently = append(alterTable.createIndexesConcurrently, destIndex)
+				} else if isRenamed {
+					// ALTER INDEX RENAME.
+					alterTable.renameIndexes = append(alterTable.renameIndexes, [2]string{srcIndex.IndexName, destIndex.IndexName})
 				}
 			}
 			addingPrimaryKey := false
@@ -314,16 +626,37 @@ func newPostgresMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) po
 				if destConstraint.Ignore {
 					continue
 				}
-				srcConstraint := srcCache.GetConstraint(srcTable, destConstraint.ConstraintName)
+				srcConstraint, isRenamed := findSrcConstraint(srcCache, srcTable, destTable, destConstraint)
 				if srcConstraint == nil {
 					switch destConstraint.ConstraintType {
-					case PRIMARY_KEY:
-						// ADD PRIMARY KEY CONCURRENTLY.
-						addingPrimaryKey = true
-						alterTable.addConstraintsConcurrently = append(alterTable.addConstraintsConcurrently, destConstraint)
-					case UNIQUE:
-						// ADD UNIQUE CONCURRENTLY.
-						alterTable.addConstraintsConcurrently = append(alterTable.addConstraintsConcurrently, destConstraint)
+					case PRIMARY_KEY, UNIQUE:
+						if destConstraint.ConstraintType == PRIMARY_KEY {
+							addingPrimaryKey = true
+						}
+						if alterTable.isPartitioned {
+							// ADD PRIMARY KEY, ADD UNIQUE.
+							alterTable.addKeyConstraints = append(alterTable.addKeyConstraints, destConstraint)
+							if len(alterTable.partitions) > 0 {
+								object := warningObject(m.currentSchema, destTable.TableSchema, destTable.TableName)
+								m.warnings = append(m.warnings, Warning{
+									Code:     WarnPGPartitionedIndexLock,
+									Severity: SeverityHigh,
+									Object:   object,
+									Message:  fmt.Sprintf("%s: adding %s %s to a partitioned table builds its index on every partition while holding a lock that blocks writes", object, destConstraint.ConstraintType, QuoteIdentifier(dialect, destConstraint.ConstraintName)),
+								})
+							}
+						} else {
+							// ADD PRIMARY KEY CONCURRENTLY, ADD UNIQUE CONCURRENTLY.
+							alterTable.addConstraintsConcurrently = append(alterTable.addConstraintsConcurrently, destConstraint)
+						}
+					case CHECK:
+						// ADD CHECK NOT VALID + VALIDATE CHECK.
+						alterTable.addChecks = append(alterTable.addChecks, destConstraint)
+						alterTable.validateChecks = append(alterTable.validateChecks, destConstraint)
+					case EXCLUDE:
+						// ADD EXCLUDE.
+						alterTable.addExclusions = append(alterTable.addExclusions, destConstraint)
+						m.warnExclusionLock(destTable, destConstraint)
 					case FOREIGN_KEY:
 						// ADD FOREIGN KEY + VALIDATE FOREIGN KEY.
 						tablesID := getTablesID(destConstraint)
@@ -337,6 +670,24 @@ func newPostgresMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) po
 					}
 					continue
 				}
+				if destConstraint.ConstraintType == CHECK && !srcConstraint.Ignore && !checksAreEqual(dialect, srcConstraint, destConstraint) {
+					// DROP CHECK, ADD CHECK NOT VALID + VALIDATE CHECK.
+					alterTable.dropConstraints = append(alterTable.dropConstraints, srcConstraint)
+					alterTable.addChecks = append(alterTable.addChecks, destConstraint)
+					alterTable.validateChecks = append(alterTable.validateChecks, destConstraint)
+					continue
+				}
+				if destConstraint.ConstraintType == EXCLUDE && !srcConstraint.Ignore && !exclusionsAreEqual(dialect, srcConstraint, destConstraint) {
+					// DROP EXCLUDE, ADD EXCLUDE.
+					alterTable.dropConstraints = append(alterTable.dropConstraints, srcConstraint)
+					alterTable.addExclusions = append(alterTable.addExclusions, destConstraint)
+					m.warnExclusionLock(destTable, destConstraint)
+					continue
+				}
+				if isRenamed {
+					// RENAME CONSTRAINT.
+					alterTable.renameConstraints = append(alterTable.renameConstraints, [2]string{srcConstraint.ConstraintName, destConstraint.ConstraintName})
+				}
 				// ALTER CONSTRAINT.
 				if srcConstraint.IsDeferrable != destConstraint.IsDeferrable || srcConstraint.IsInitiallyDeferred != destConstraint.IsInitiallyDeferred {
 					alterTable.alterConstraints = append(alterTable.alterConstraints, [2]*Constraint{srcConstraint, destConstraint})
@@ -351,33 +702,258 @@ func newPostgresMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) po
 					alterTable.dropConstraints = append(alterTable.dropConstraints, srcPkey)
 				}
 			}
-			if len(alterTable.dropConstraints) > 0 ||
+			if alterTable.renameFrom != "" ||
+				len(alterTable.renameColumns) > 0 ||
+				len(alterTable.renameConstraints) > 0 ||
+				len(alterTable.renameIndexes) > 0 ||
+				len(alterTable.dropConstraints) > 0 ||
 				len(alterTable.dropIndexes) > 0 ||
+				len(alterTable.dropColumns) > 0 ||
 				len(alterTable.addColumns) > 0 ||
 				len(alterTable.alterColumns) > 0 ||
 				len(alterTable.alterConstraints) > 0 ||
+				len(alterTable.addChecks) > 0 ||
+				len(alterTable.addExclusions) > 0 ||
+				len(alterTable.addKeyConstraints) > 0 ||
+				len(alterTable.comments) > 0 ||
 				len(alterTable.createIndexesConcurrently) > 0 ||
 				len(alterTable.addConstraintsConcurrently) > 0 {
 				m.alterTables = append(m.alterTables, alterTable)
 			}
 		}
 	}
+	sortPartitionsLast(m.createTables)
 	return m
 }
 
-func (m *postgresMigration) sql(prefix string) (filenames []string, bufs []*bytes.Buffer, warnings []string) {
+// hasPartitions reports whether the catalog declares any partition of the
+// partitioned table that the partition belongs to.
+func hasPartitions(catalog *Catalog, partition *Table) bool {
+	parentSchema, parentName := partitionParent(partition)
+	for _, schema := range catalog.Schemas {
+		for i := range schema.Tables {
+			table := &schema.Tables[i]
+			if table.Ignore || table.PartitionOf == "" {
+				continue
+			}
+			if tableParentSchema, tableParentName := partitionParent(table); tableParentSchema == parentSchema && tableParentName == parentName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// partitionsOf returns the partitions of the partitioned table in the
+// catalog.
+func partitionsOf(catalog *Catalog, table *Table) []*Table {
+	var partitions []*Table
+	for _, schema := range catalog.Schemas {
+		for i := range schema.Tables {
+			partition := &schema.Tables[i]
+			if partition.Ignore || partition.PartitionOf == "" {
+				continue
+			}
+			if parentSchema, parentName := partitionParent(partition); parentSchema == table.TableSchema && parentName == table.TableName {
+				partitions = append(partitions, partition)
+			}
+		}
+	}
+	return partitions
+}
+
+// newPostgresAlterDomain works out the changes needed to turn srcDomain into
+// destDomain. Check constraints are matched by name, and check constraints
+// missing from destDomain are only dropped if dropObjects is true.
+func newPostgresAlterDomain(srcDomain, destDomain *Domain, defaultCollation string, dropObjects bool) postgresAlterDomain {
+	const dialect = DialectPostgres
+	alterDomain := postgresAlterDomain{
+		domainSchema: destDomain.DomainSchema,
+		domainName:   destDomain.DomainName,
+	}
+	srcType, srcArg1, srcArg2 := normalizeColumnType(dialect, srcDomain.UnderlyingType)
+	destType, destArg1, destArg2 := normalizeColumnType(dialect, destDomain.UnderlyingType)
+	if [3]string{srcType, srcArg1, srcArg2} != [3]string{destType, destArg1, destArg2} {
+		alterDomain.isTypeChanged = true
+	}
+	srcCollation := srcDomain.CollationName
+	if srcCollation == "" {
+		srcCollation = defaultCollation
+	}
+	destCollation := destDomain.CollationName
+	if destCollation == "" {
+		destCollation = defaultCollation
+	}
+	if srcCollation != destCollation {
+		alterDomain.isTypeChanged = true
+	}
+	srcDefault := normalizeColumnDefault(dialect, srcDomain.ColumnDefault)
+	destDefault := normalizeColumnDefault(dialect, destDomain.ColumnDefault)
+	if srcDefault != destDefault {
+		if destDomain.ColumnDefault == "" {
+			alterDomain.dropDefault = true
+		} else {
+			alterDomain.setDefault = destDomain.ColumnDefault
+		}
+	}
+	if !srcDomain.IsNotNull && destDomain.IsNotNull {
+		alterDomain.setNotNull = true
+	} else if srcDomain.IsNotNull && !destDomain.IsNotNull {
+		alterDomain.dropNotNull = true
+	}
+	if dropObjects {
+		for i, checkName := range srcDomain.CheckNames {
+			if i < len(srcDomain.CheckExprs) && !slices.Contains(destDomain.CheckNames, checkName) {
+				alterDomain.dropChecks = append(alterDomain.dropChecks, [2]string{checkName, srcDomain.CheckExprs[i]})
+			}
+		}
+	}
+	for i, checkName := range destDomain.CheckNames {
+		if i >= len(destDomain.CheckExprs) {
+			continue
+		}
+		j := slices.Index(srcDomain.CheckNames, checkName)
+		if j < 0 {
+			alterDomain.addChecks = append(alterDomain.addChecks, [2]string{checkName, destDomain.CheckExprs[i]})
+			continue
+		}
+		// A check whose expression changed is dropped and added again.
+		if j < len(srcDomain.CheckExprs) && normalizeIndexPredicate(dialect, srcDomain.CheckExprs[j]) != normalizeIndexPredicate(dialect, destDomain.CheckExprs[i]) {
+			alterDomain.dropChecks = append(alterDomain.dropChecks, [2]string{checkName, srcDomain.CheckExprs[j]})
+			alterDomain.addChecks = append(alterDomain.addChecks, [2]string{checkName, destDomain.CheckExprs[i]})
+		}
+	}
+	return alterDomain
+}
+
+// newPostgresAlterEnum works out the labels that have to be added to srcEnum
+// to turn it into destEnum. Each new label is added after the label preceding
+// it in destEnum, or before the first existing label if it comes first.
+func newPostgresAlterEnum(srcEnum, destEnum *Enum) postgresAlterEnum {
+	alterEnum := postgresAlterEnum{
+		enumSchema: destEnum.EnumSchema,
+		enumName:   destEnum.EnumName,
+	}
+	srcPositions := make(map[string]int)
+	for i, label := range srcEnum.EnumLabels {
+		srcPositions[label] = i
+	}
+	destLabels := make(map[string]bool)
+	lastPosition := -1
+	for _, label := range destEnum.EnumLabels {
+		destLabels[label] = true
+		position, ok := srcPositions[label]
+		if !ok {
+			continue
+		}
+		if position < lastPosition {
+			alterEnum.isReordered = true
+		}
+		lastPosition = position
+	}
+	for _, label := range srcEnum.EnumLabels {
+		if !destLabels[label] {
+			alterEnum.removedLabels = append(alterEnum.removedLabels, label)
+		}
+	}
+	labels := cloneSlice(srcEnum.EnumLabels) // The labels after each ADD VALUE.
+	for i, label := range destEnum.EnumLabels {
+		if slices.Contains(labels, label) {
+			continue
+		}
+		switch {
+		case i > 0:
+			neighbour := destEnum.EnumLabels[i-1]
+			j := slices.Index(labels, neighbour)
+			if j == len(labels)-1 {
+				alterEnum.addLabels = append(alterEnum.addLabels, [3]string{label, "", ""})
+			} else {
+				alterEnum.addLabels = append(alterEnum.addLabels, [3]string{label, "AFTER", neighbour})
+			}
+			labels = slices.Insert(labels, j+1, label)
+		case len(labels) > 0:
+			alterEnum.addLabels = append(alterEnum.addLabels, [3]string{label, "BEFORE", labels[0]})
+			labels = slices.Insert(labels, 0, label)
+		default:
+			alterEnum.addLabels = append(alterEnum.addLabels, [3]string{label, "", ""})
+			labels = append(labels, label)
+		}
+	}
+	return alterEnum
+}
+
+// warnExclusionLock warns that adding the EXCLUDE constraint to the existing
+// table blocks reads and writes until its index is built.
+func (m *postgresMigration) warnExclusionLock(table *Table, constraint *Constraint) {
+	object := warningObject(m.currentSchema, table.TableSchema, table.TableName)
+	m.warnings = append(m.warnings, Warning{
+		Code:     WarnPGAddExclusionLock,
+		Severity: SeverityHigh,
+		Object:   object,
+		Message:  fmt.Sprintf("%s: adding EXCLUDE %s builds its index while holding a lock that blocks reads and writes", object, QuoteIdentifier(DialectPostgres, constraint.ConstraintName)),
+	})
+}
+
+// filenameLabel replaces every character of an enum label that is not
+// [A-Za-z0-9_] with an underscore, so that the label can be used in a
+// filename.
+func filenameLabel(label string) string {
+	return strings.Map(func(char rune) rune {
+		if char == '_' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9') {
+			return char
+		}
+		return '_'
+	}, label)
+}
+
+func (m *postgresMigration) sql(prefix string) (filenames []string, bufs []*bytes.Buffer, warnings []Warning) {
 	const dialect = DialectPostgres
 	n := 0
+	warnings = append(warnings, m.warnings...)
+	m.undoScopes = m.undoScopes[:0]
+
+	// DROP TRIGGER.
+	if m.routines.hasDropTriggers() {
+		n++
+		// ${prefix}_${n}_drop_triggers.sql
+		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_drop_triggers.sql")
+		m.undoScopes = append(m.undoScopes, m.routines.undoDropTriggers())
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		m.routines.writeDropTriggers(buf)
+	}
+
+	// DROP VIEW.
+	if m.views.hasDropViews() {
+		n++
+		// ${prefix}_${n}_drop_views.sql
+		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_drop_views.sql")
+		m.undoScopes = append(m.undoScopes, m.views.undoDropViews())
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		m.views.writeDropViews(buf)
+	}
+
+	// fkeyTableName returns the table name of a foreign key. Foreign keys are
+	// grouped by the pair of tables they are between, so the foreign keys in
+	// a group may belong to different tables.
+	fkeyTableName := func(fkey *Constraint) string {
+		tableName := QuoteIdentifier(dialect, fkey.TableName)
+		if fkey.TableSchema != "" && fkey.TableSchema != m.currentSchema {
+			tableName = QuoteIdentifier(dialect, fkey.TableSchema) + "." + tableName
+		}
+		return tableName
+	}
 
 	// DROP FOREIGN KEY.
 	for _, fkeys := range m.dropFkeys {
 		n++
 		fk := *fkeys[0]
 		name := strings.ReplaceAll(fk.TableName, " ", "_") + "_"
-		tableName := QuoteIdentifier(dialect, fk.TableName)
 		if fk.TableSchema != "" && fk.TableSchema != m.currentSchema {
 			name = strings.ReplaceAll(fk.TableSchema, " ", "_") + "_" + name
-			tableName = QuoteIdentifier(dialect, fk.TableSchema) + "." + tableName
 		}
 		if fk.ReferencesSchema != "" && fk.ReferencesSchema != m.currentSchema {
 			name += strings.ReplaceAll(fk.ReferencesSchema, " ", "_") + "_"
@@ -385,6 +961,7 @@ func (m *postgresMigration) sql(prefix string) (filenames []string, bufs []*byte
 		name += strings.ReplaceAll(fk.ReferencesTable, " ", "_")
 		// ${prefix}_${n}_drop_${table1}_${table2}_fkeys.tx.sql
 		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_drop_"+name+"_fkeys.tx.sql")
+		m.undoScopes = append(m.undoScopes, undoScope{dropFkeys: fkeys})
 		buf := bufpool.Get().(*bytes.Buffer)
 		buf.Reset()
 		bufs = append(bufs, buf)
@@ -393,7 +970,24 @@ func (m *postgresMigration) sql(prefix string) (filenames []string, bufs []*byte
 				buf.WriteString("\n")
 			}
 			constraintName := QuoteIdentifier(dialect, fkey.ConstraintName)
-			buf.WriteString("ALTER TABLE " + tableName + " DROP CONSTRAINT IF EXISTS " + constraintName + ";\n")
+			buf.WriteString("ALTER TABLE " + fkeyTableName(fkey) + " DROP CONSTRAINT IF EXISTS " + constraintName + ";\n")
+		}
+	}
+
+	// CREATE EXTENSION.
+	if len(m.createExtensions) > 0 {
+		n++
+		// ${prefix}_${n}_extensions.sql
+		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_extensions.sql")
+		m.undoScopes = append(m.undoScopes, undoScope{extensions: m.createExtensions})
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		for _, extension := range m.createExtensions {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			buf.WriteString("CREATE EXTENSION IF NOT EXISTS " + QuoteIdentifier(dialect, extension) + ";\n")
 		}
 	}
 
@@ -402,6 +996,7 @@ func (m *postgresMigration) sql(prefix string) (filenames []string, bufs []*byte
 		n++
 		// ${prefix}_${n}_schemas.sql
 		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_schemas.sql")
+		m.undoScopes = append(m.undoScopes, undoScope{schemas: append(slices.Clone(m.dropSchemas), m.createSchemas...)})
 		buf := bufpool.Get().(*bytes.Buffer)
 		buf.Reset()
 		bufs = append(bufs, buf)
@@ -419,11 +1014,215 @@ func (m *postgresMigration) sql(prefix string) (filenames []string, bufs []*byte
 		}
 	}
 
+	// CREATE TYPE.
+	if len(m.createEnums) > 0 {
+		n++
+		// ${prefix}_${n}_enums.sql
+		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_enums.sql")
+		m.undoScopes = append(m.undoScopes, undoScope{enums: m.createEnums})
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		for _, enum := range m.createEnums {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			writeCreateEnum(dialect, buf, m.currentSchema, enum)
+		}
+	}
+
+	// ALTER TYPE.
+	for _, alterEnum := range m.alterEnums {
+		name := strings.ReplaceAll(alterEnum.enumName, " ", "_")
+		displayName := alterEnum.enumName
+		enumName := QuoteIdentifier(dialect, alterEnum.enumName)
+		if alterEnum.enumSchema != "" && alterEnum.enumSchema != m.currentSchema {
+			name = strings.ReplaceAll(alterEnum.enumSchema, " ", "_") + "_" + name
+			displayName = alterEnum.enumSchema + "." + displayName
+			enumName = QuoteIdentifier(dialect, alterEnum.enumSchema) + "." + enumName
+		}
+		for _, label := range alterEnum.removedLabels {
+			warnings = append(warnings, Warning{
+				Code:     WarnPGEnumRemoveLabel,
+				Severity: SeverityMedium,
+				Object:   displayName,
+				Message:  fmt.Sprintf("%s: enum label %q cannot be removed, the enum type has to be recreated manually", displayName, label),
+			})
+		}
+		if alterEnum.isReordered {
+			warnings = append(warnings, Warning{
+				Code:     WarnPGEnumReorderLabels,
+				Severity: SeverityMedium,
+				Object:   displayName,
+				Message:  fmt.Sprintf("%s: enum labels cannot be reordered, the enum type has to be recreated manually", displayName),
+			})
+		}
+		var buf *bytes.Buffer
+		for _, addLabel := range alterEnum.addLabels {
+			label, position, neighbour := addLabel[0], addLabel[1], addLabel[2]
+			// Before Postgres 12, ALTER TYPE ... ADD VALUE cannot run inside
+			// a transaction so every label gets its own file.
+			if buf == nil || m.versionNums.LowerThan(12) {
+				n++
+				if m.versionNums.LowerThan(12) {
+					// ${prefix}_${n}_alter_${enum}_${label}.txoff.sql
+					filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_alter_"+name+"_"+filenameLabel(label)+".txoff.sql")
+				} else {
+					// ${prefix}_${n}_alter_${enum}.tx.sql
+					filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_alter_"+name+".tx.sql")
+				}
+				m.undoScopes = append(m.undoScopes, undoScope{enums: []*Enum{{EnumSchema: alterEnum.enumSchema, EnumName: alterEnum.enumName}}})
+				buf = bufpool.Get().(*bytes.Buffer)
+				buf.Reset()
+				bufs = append(bufs, buf)
+			}
+			if buf.Len() > 0 && !m.versionNums.LowerThan(12) {
+				buf.WriteString("\n")
+			}
+			buf.WriteString("ALTER TYPE " + enumName + " ADD VALUE IF NOT EXISTS '" + EscapeQuote(label, '\'') + "'")
+			if position != "" {
+				buf.WriteString(" " + position + " '" + EscapeQuote(neighbour, '\'') + "'")
+			}
+			buf.WriteString(";\n")
+		}
+	}
+
+	// CREATE FUNCTION | CREATE PROCEDURE (called by domains and tables).
+	if m.routines.hasCreateTableRoutines() {
+		n++
+		// ${prefix}_${n}_routines.sql
+		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_routines.sql")
+		m.undoScopes = append(m.undoScopes, m.routines.undoCreateTableRoutines())
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		m.routines.writeCreateTableRoutines(buf)
+	}
+
+	// CREATE DOMAIN.
+	if len(m.createDomains) > 0 {
+		n++
+		// ${prefix}_${n}_domains.sql
+		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_domains.sql")
+		m.undoScopes = append(m.undoScopes, undoScope{domains: m.createDomains})
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		for _, domain := range m.createDomains {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			writeCreateDomain(dialect, buf, m.currentSchema, m.defaultCollation, domain)
+		}
+	}
+
+	// ALTER DOMAIN.
+	for _, alterDomain := range m.alterDomains {
+		name := strings.ReplaceAll(alterDomain.domainName, " ", "_")
+		displayName := alterDomain.domainName
+		domainName := QuoteIdentifier(dialect, alterDomain.domainName)
+		if alterDomain.domainSchema != "" && alterDomain.domainSchema != m.currentSchema {
+			name = strings.ReplaceAll(alterDomain.domainSchema, " ", "_") + "_" + name
+			displayName = alterDomain.domainSchema + "." + displayName
+			domainName = QuoteIdentifier(dialect, alterDomain.domainSchema) + "." + domainName
+		}
+		if alterDomain.isTypeChanged {
+			warnings = append(warnings, Warning{
+				Code:     WarnPGDomainChangeType,
+				Severity: SeverityMedium,
+				Object:   displayName,
+				Message:  fmt.Sprintf("%s: the underlying type or collation of a domain cannot be changed, the domain type has to be recreated manually", displayName),
+			})
+		}
+		if alterDomain.setDefault == "" && !alterDomain.dropDefault &&
+			!alterDomain.setNotNull && !alterDomain.dropNotNull &&
+			len(alterDomain.dropChecks) == 0 && len(alterDomain.addChecks) == 0 {
+			continue
+		}
+		n++
+		// ${prefix}_${n}_alter_${domain}.tx.sql
+		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_alter_"+name+".tx.sql")
+		m.undoScopes = append(m.undoScopes, undoScope{domains: []*Domain{{DomainSchema: alterDomain.domainSchema, DomainName: alterDomain.domainName}}})
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		for _, check := range alterDomain.dropChecks {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			buf.WriteString("ALTER DOMAIN " + domainName + " DROP CONSTRAINT IF EXISTS " + QuoteIdentifier(dialect, check[0]) + ";\n")
+		}
+		if alterDomain.dropDefault {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			buf.WriteString("ALTER DOMAIN " + domainName + " DROP DEFAULT;\n")
+		}
+		if alterDomain.setDefault != "" {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			buf.WriteString("ALTER DOMAIN " + domainName + " SET DEFAULT " + alterDomain.setDefault + ";\n")
+		}
+		if alterDomain.dropNotNull {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			buf.WriteString("ALTER DOMAIN " + domainName + " DROP NOT NULL;\n")
+		}
+		if alterDomain.setNotNull {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			buf.WriteString("ALTER DOMAIN " + domainName + " SET NOT NULL;\n")
+		}
+		for _, check := range alterDomain.addChecks {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			buf.WriteString("ALTER DOMAIN " + domainName + " ADD CONSTRAINT " + QuoteIdentifier(dialect, check[0]) + " CHECK " + wrapBrackets(check[1]) + " NOT VALID;\n")
+		}
+		if len(alterDomain.addChecks) == 0 {
+			continue
+		}
+		n++
+		// ${prefix}_${n}_validate_${domain}.tx.sql
+		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_validate_"+name+".tx.sql")
+		m.undoScopes = append(m.undoScopes, undoScope{})
+		buf = bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		for _, check := range alterDomain.addChecks {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			buf.WriteString("ALTER DOMAIN " + domainName + " VALIDATE CONSTRAINT " + QuoteIdentifier(dialect, check[0]) + ";\n")
+		}
+	}
+
+	// CREATE SEQUENCE.
+	if len(m.createSequences) > 0 {
+		n++
+		// ${prefix}_${n}_sequences.sql
+		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_sequences.sql")
+		m.undoScopes = append(m.undoScopes, undoScope{sequences: m.createSequences})
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		for _, sequence := range m.createSequences {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			writeCreateSequence(dialect, buf, m.currentSchema, sequence)
+		}
+	}
+
 	// DROP TABLE + CREATE TABLE.
 	if len(m.dropTables) > 0 || len(m.createTables) > 0 {
 		n++
 		// ${prefix}_${n}_tables.sql
 		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_tables.sql")
+		m.undoScopes = append(m.undoScopes, undoScope{tables: append(slices.Clone(m.dropTables), m.createTables...)})
 		buf := bufpool.Get().(*bytes.Buffer)
 		buf.Reset()
 		bufs = append(bufs, buf)
@@ -460,11 +1259,50 @@ func (m *postgresMigration) sql(prefix string) (filenames []string, bufs []*byte
 			name = strings.ReplaceAll(alterTable.tableSchema, " ", "_") + "_" + name
 			tableName = QuoteIdentifier(dialect, alterTable.tableSchema) + "." + tableName
 		}
+		object := warningObject(m.currentSchema, alterTable.tableSchema, alterTable.tableName)
 		// ${prefix}_${n}_alter_${table}.tx.sql
 		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_alter_"+name+".tx.sql")
+		m.undoScopes = append(m.undoScopes, undoScope{
+			tables:          []*Table{{TableSchema: alterTable.tableSchema, TableName: alterTable.tableName}},
+			skipIndexes:     alterTable.createIndexesConcurrently,
+			skipConstraints: alterTable.addConstraintsConcurrently,
+		})
 		buf := bufpool.Get().(*bytes.Buffer)
 		buf.Reset()
 		bufs = append(bufs, buf)
+		// RENAME TO.
+		if alterTable.renameFrom != "" {
+			oldTableName := QuoteIdentifier(dialect, alterTable.renameFrom)
+			if alterTable.tableSchema != "" && alterTable.tableSchema != m.currentSchema {
+				oldTableName = QuoteIdentifier(dialect, alterTable.tableSchema) + "." + oldTableName
+			}
+			buf.WriteString("ALTER TABLE " + oldTableName + " RENAME TO " + QuoteIdentifier(dialect, alterTable.tableName) + ";\n")
+		}
+		// RENAME COLUMN.
+		for _, names := range alterTable.renameColumns {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			buf.WriteString("ALTER TABLE " + tableName + " RENAME COLUMN " + QuoteIdentifier(dialect, names[0]) + " TO " + QuoteIdentifier(dialect, names[1]) + ";\n")
+		}
+		// RENAME CONSTRAINT.
+		for _, names := range alterTable.renameConstraints {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			buf.WriteString("ALTER TABLE " + tableName + " RENAME CONSTRAINT " + QuoteIdentifier(dialect, names[0]) + " TO " + QuoteIdentifier(dialect, names[1]) + ";\n")
+		}
+		// ALTER INDEX RENAME.
+		for _, names := range alterTable.renameIndexes {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			indexName := QuoteIdentifier(dialect, names[0])
+			if alterTable.tableSchema != "" && alterTable.tableSchema != m.currentSchema {
+				indexName = QuoteIdentifier(dialect, alterTable.tableSchema) + "." + indexName
+			}
+			buf.WriteString("ALTER INDEX " + indexName + " RENAME TO " + QuoteIdentifier(dialect, names[1]) + ";\n")
+		}
 		// DROP INDEX.
 		for _, index := range alterTable.dropIndexes {
 			if buf.Len() > 0 {
@@ -499,9 +1337,19 @@ func (m *postgresMigration) sql(prefix string) (filenames []string, bufs []*byte
 			}
 			columnName := QuoteIdentifier(dialect, column.ColumnName)
 			if column.ColumnDefault != "" && m.versionNums.LowerThan(11) {
-				warnings = append(warnings, fmt.Sprintf("%s: adding column %q with DEFAULT is unsafe for large tables. Upgrade to Postgres 11+ to avoid this issue. If not, you should add a column without the default first, backfill the default values, then set the column default", tableName, columnName))
+				warnings = append(warnings, Warning{
+					Code:     WarnPGAddColumnDefaultLargeTable,
+					Severity: SeverityHigh,
+					Object:   object + "." + column.ColumnName,
+					Message:  fmt.Sprintf("%s: adding column %q with DEFAULT is unsafe for large tables. Upgrade to Postgres 11+ to avoid this issue. If not, you should add a column without the default first, backfill the default values, then set the column default", tableName, columnName),
+				})
 			} else if column.ColumnIdentity != "" && m.versionNums.LowerThan(11) {
-				warnings = append(warnings, fmt.Sprintf("%s: adding column %q with %q is unsafe for large tables. Upgrade to Postgres 11+ to avoid this issue. If not, you should add a column without the identity first, backfill the identity values, then add the identity to the column and finally update the identity sequence", tableName, column.ColumnIdentity, columnName))
+				warnings = append(warnings, Warning{
+					Code:     WarnPGAddColumnIdentityLargeTable,
+					Severity: SeverityHigh,
+					Object:   object + "." + column.ColumnName,
+					Message:  fmt.Sprintf("%s: adding column %q with %q is unsafe for large tables. Upgrade to Postgres 11+ to avoid this issue. If not, you should add a column without the identity first, backfill the identity values, then add the identity to the column and finally update the identity sequence", tableName, column.ColumnIdentity, columnName),
+				})
 			}
 			buf.WriteString("ALTER TABLE " + tableName + " ADD COLUMN ")
 			writeColumnDefinition(dialect, buf, m.defaultCollation, column, false)
@@ -514,42 +1362,12 @@ func (m *postgresMigration) sql(prefix string) (filenames []string, bufs []*byte
 			srcType, srcArg1, srcArg2 := normalizeColumnType(dialect, srcColumn.ColumnType)
 			destType, destArg1, destArg2 := normalizeColumnType(dialect, destColumn.ColumnType)
 			// Do we need to ALTER TYPE?
-			if [3]string{srcType, srcArg1, srcArg2} != [3]string{destType, destArg1, destArg2} {
-				// I'm going to ignore CITEXT because using it doesn't seem to
-				// be a good idea. "Personally, I stay away from citext after
-				// mixed experiences."
-				// (https://dba.stackexchange.com/a/230688). Besides,
-				// accounting for CITEXT would complicate code here because
-				// ALTER TYPE safety depends on whether the CITEXT column is
-				// indexed. I'll just treat all CITEXT conversions as unsafe
-				// and let the user decide if it is safe. Reference:
-				// https://github.com/ankane/strong_migrations#changing-the-type-of-a-column.
-				switch [2]string{srcType, destType} {
-				case [2]string{"CIDR", "INET"}, [2]string{"VARCHAR", "TEXT"}:
-					// Always ok.
-				case [2]string{"TEXT", "VARCHAR"}:
-					if destArg1 != "" {
-						warnings = append(warnings, fmt.Sprintf("%s: column %q changing type from \"TEXT\" to %q is unsafe", tableName, columnName, destColumn.ColumnType))
-					}
-				case [2]string{"VARCHAR", "VARCHAR"}:
-					srcLimit, _ := strconv.Atoi(srcArg1)
-					destLimit, _ := strconv.Atoi(destArg1)
-					if srcLimit > 0 && destLimit > 0 && destLimit < srcLimit {
-						warnings = append(warnings, fmt.Sprintf("%s: column %q decreasing limit from %q to %q is unsafe", tableName, columnName, srcColumn.ColumnType, destColumn.ColumnType))
-					}
-				case [2]string{"NUMERIC", "NUMERIC"}:
-					srcScale, _ := strconv.Atoi(srcArg2)
-					destScale, _ := strconv.Atoi(destArg2)
-					if srcScale != destScale && destScale != 0 {
-						warnings = append(warnings, fmt.Sprintf("%s: column %q changing scale from %q to %q is unsafe", tableName, columnName, srcColumn.ColumnType, destColumn.ColumnType))
-					}
-					srcPrecision, _ := strconv.Atoi(srcArg1)
-					destPrecision, _ := strconv.Atoi(destArg1)
-					if srcPrecision > 0 && destPrecision > 0 && destPrecision < srcPrecision {
-						warnings = append(warnings, fmt.Sprintf("%s: column %q decreasing precision from %q to %q is unsafe", tableName, columnName, srcColumn.ColumnType, destColumn.ColumnType))
-					}
-				default:
-					warnings = append(warnings, fmt.Sprintf("%s: column %q changing type from %q to %q may be unsafe", tableName, columnName, srcColumn.ColumnType, destColumn.ColumnType))
+			sameDomain := srcColumn.DomainName != "" && srcColumn.DomainName == destColumn.DomainName
+			if !sameDomain && [3]string{srcType, srcArg1, srcArg2} != [3]string{destType, destArg1, destArg2} {
+				for _, warning := range postgresAlterTypeWarnings(srcColumn, destColumn) {
+					warning.Object = object + "." + destColumn.ColumnName
+					warning.Message = tableName + ": " + warning.Message
+					warnings = append(warnings, warning)
 				}
 				if buf.Len() > 0 {
 					buf.WriteString("\n")
@@ -570,10 +1388,13 @@ func (m *postgresMigration) sql(prefix string) (filenames []string, bufs []*byte
 					destCollation = m.defaultCollation
 				}
 				if srcCollation != destCollation {
+					if destCollation == "" {
+						destCollation = "default"
+					}
 					if buf.Len() > 0 {
 						buf.WriteString("\n")
 					}
-					buf.WriteString("ALTER TABLE " + tableName + " ALTER COLUMN " + columnName + " TYPE " + destColumn.ColumnType + ` COLLATE "` + EscapeQuote(destColumn.CollationName, '"') + `"` + ";\n")
+					buf.WriteString("ALTER TABLE " + tableName + " ALTER COLUMN " + columnName + " TYPE " + destColumn.ColumnType + ` COLLATE "` + EscapeQuote(destCollation, '"') + `"` + ";\n")
 				}
 			}
 			srcDefault := normalizeColumnDefault(dialect, srcColumn.ColumnDefault)
@@ -601,7 +1422,12 @@ func (m *postgresMigration) sql(prefix string) (filenames []string, bufs []*byte
 					buf.WriteString("\n")
 				}
 				if m.versionNums.LowerThan(12) {
-					warnings = append(warnings, fmt.Sprintf("%s: setting NOT NULL on column %q is unsafe for large tables. Upgrade to Postgres 12+ to avoid this issue", tableName, columnName))
+					warnings = append(warnings, Warning{
+						Code:     WarnPGSetNotNullLargeTable,
+						Severity: SeverityHigh,
+						Object:   object + "." + destColumn.ColumnName,
+						Message:  fmt.Sprintf("%s: setting NOT NULL on column %q is unsafe for large tables. Upgrade to Postgres 12+ to avoid this issue", tableName, columnName),
+					})
 					buf.WriteString("ALTER TABLE " + tableName + " ALTER COLUMN " + columnName + " SET NOT NULL;\n")
 				} else {
 					constraintName := QuoteIdentifier(dialect, destColumn.TableName+"_"+destColumn.ColumnName+"_not_null_check")
@@ -639,12 +1465,47 @@ func (m *postgresMigration) sql(prefix string) (filenames []string, bufs []*byte
 			}
 			buf.WriteString(";\n")
 		}
+		// ADD CHECK NOT VALID.
+		for _, constraint := range alterTable.addChecks {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			buf.WriteString("ALTER TABLE " + tableName + " ADD ")
+			writeConstraintDefinition(dialect, buf, m.currentSchema, constraint)
+			buf.WriteString(" NOT VALID;\n")
+		}
+		// ADD EXCLUDE.
+		for _, constraint := range alterTable.addExclusions {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			buf.WriteString("ALTER TABLE " + tableName + " ADD ")
+			writeConstraintDefinition(dialect, buf, m.currentSchema, constraint)
+			buf.WriteString(";\n")
+		}
+		// ADD PRIMARY KEY, ADD UNIQUE.
+		for _, constraint := range alterTable.addKeyConstraints {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			buf.WriteString("ALTER TABLE " + tableName + " ADD ")
+			writeConstraintDefinition(dialect, buf, m.currentSchema, constraint)
+			buf.WriteString(";\n")
+		}
+		// COMMENT ON.
+		for _, comment := range alterTable.comments {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			writeComment(dialect, buf, m.currentSchema, alterTable.tableSchema, alterTable.tableName, comment[0], comment[1], comment[2])
+		}
 
 		// VALIDATE NOT NULL CHECK.
 		if len(alterTable.validateNotNull) > 0 {
 			n++
 			// ${prefix}_${n}_validate_${table}_not_null.tx.sql
 			filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_validate_"+name+"_not_null.tx.sql")
+			m.undoScopes = append(m.undoScopes, undoScope{})
 			buf := bufpool.Get().(*bytes.Buffer)
 			buf.Reset()
 			bufs = append(bufs, buf)
@@ -660,17 +1521,111 @@ func (m *postgresMigration) sql(prefix string) (filenames []string, bufs []*byte
 			}
 		}
 
+		// VALIDATE CHECK.
+		if len(alterTable.validateChecks) > 0 {
+			n++
+			// ${prefix}_${n}_validate_${table}_checks.tx.sql
+			filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_validate_"+name+"_checks.tx.sql")
+			m.undoScopes = append(m.undoScopes, undoScope{})
+			buf := bufpool.Get().(*bytes.Buffer)
+			buf.Reset()
+			bufs = append(bufs, buf)
+			for _, constraint := range alterTable.validateChecks {
+				if buf.Len() > 0 {
+					buf.WriteString("\n")
+				}
+				constraintName := QuoteIdentifier(dialect, constraint.ConstraintName)
+				buf.WriteString("ALTER TABLE " + tableName + " VALIDATE CONSTRAINT " + constraintName + ";\n")
+			}
+		}
+
 		// CREATE INDEX CONCURRENTLY.
 		for _, index := range alterTable.createIndexesConcurrently {
+			if len(alterTable.partitions) > 0 {
+				// Creating an index on a partitioned table locks every
+				// partition while the index is built. Instead, create the
+				// index ON ONLY the partitioned table (which leaves it
+				// invalid), create the index concurrently on every partition
+				// and then attach the partition indexes, which makes the
+				// index valid.
+				indexName := func(index *Index) string {
+					if index.TableSchema != "" && index.TableSchema != m.currentSchema {
+						return QuoteIdentifier(dialect, index.TableSchema) + "." + QuoteIdentifier(dialect, index.IndexName)
+					}
+					return QuoteIdentifier(dialect, index.IndexName)
+				}
+				n++
+				name := strings.ReplaceAll(index.IndexName, " ", "_")
+				// ${prefix}_${n}_create_${index}.tx.sql
+				filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_create_"+name+".tx.sql")
+				m.undoScopes = append(m.undoScopes, undoScope{indexes: []*Index{index}})
+				buf := bufpool.Get().(*bytes.Buffer)
+				buf.Reset()
+				bufs = append(bufs, buf)
+				writeCreateIndex(dialect, buf, m.currentSchema, index, false)
+				b := bytes.Replace(buf.Bytes(), []byte(" ON "), []byte(" ON ONLY "), 1)
+				buf.Reset()
+				buf.Write(b)
+				partitionIndexes := make([]*Index, 0, len(alterTable.partitions))
+				for _, partition := range alterTable.partitions {
+					partitionIndex := *index
+					partitionIndex.TableSchema = partition.TableSchema
+					partitionIndex.TableName = partition.TableName
+					partitionIndex.SQL = ""
+					if index.IndexName == GenerateName(INDEX, index.TableName, index.Columns) {
+						partitionIndex.IndexName = GenerateName(INDEX, partition.TableName, index.Columns)
+					} else {
+						partitionIndex.IndexName = partition.TableName + "_" + index.IndexName
+					}
+					partitionIndexes = append(partitionIndexes, &partitionIndex)
+					n++
+					name := strings.ReplaceAll(partitionIndex.IndexName, " ", "_")
+					num := fmt.Sprintf("%02d", n)
+					// ${prefix}_${n}_create_${partition_index}.txoff.sql
+					filenames = append(filenames, prefix+"_"+num+"_create_"+name+".txoff.sql")
+					// The partition index is dropped together with the index
+					// on the partitioned table once it is attached.
+					m.undoScopes = append(m.undoScopes, undoScope{})
+					buf := bufpool.Get().(*bytes.Buffer)
+					buf.Reset()
+					bufs = append(bufs, buf)
+					// A partition that is itself partitioned cannot have
+					// its indexes created concurrently.
+					writeCreateIndex(dialect, buf, m.currentSchema, &partitionIndex, partition.PartitionBy == "")
+					// ${prefix}_${n}_create_${partition_index}.undo.sql
+					filenames = append(filenames, prefix+"_"+num+"_create_"+name+".undo.sql")
+					buf = bufpool.Get().(*bytes.Buffer)
+					buf.Reset()
+					bufs = append(bufs, buf)
+					buf.WriteString("DROP INDEX IF EXISTS " + indexName(&partitionIndex) + ";\n")
+				}
+				n++
+				// ${prefix}_${n}_attach_${index}.tx.sql
+				filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_attach_"+name+".tx.sql")
+				m.undoScopes = append(m.undoScopes, undoScope{})
+				buf = bufpool.Get().(*bytes.Buffer)
+				buf.Reset()
+				bufs = append(bufs, buf)
+				for _, partitionIndex := range partitionIndexes {
+					if buf.Len() > 0 {
+						buf.WriteString("\n")
+					}
+					buf.WriteString("ALTER INDEX " + indexName(index) + " ATTACH PARTITION " + indexName(partitionIndex) + ";\n")
+				}
+				continue
+			}
 			n++
 			name := strings.ReplaceAll(index.IndexName, " ", "_")
 			num := fmt.Sprintf("%02d", n)
 			// ${prefix}_${n}_create_${index}.txoff.sql
 			filenames = append(filenames, prefix+"_"+num+"_create_"+name+".txoff.sql")
+			m.undoScopes = append(m.undoScopes, undoScope{indexes: []*Index{index}})
 			buf := bufpool.Get().(*bytes.Buffer)
 			buf.Reset()
 			bufs = append(bufs, buf)
-			writeCreateIndex(dialect, buf, m.currentSchema, index, true)
+			// Indexes on a partitioned table cannot be created
+			// concurrently.
+			writeCreateIndex(dialect, buf, m.currentSchema, index, !alterTable.isPartitioned)
 			// ${prefix}_${n}_create_${index}.undo.sql
 			filenames = append(filenames, prefix+"_"+num+"_create_"+name+".undo.sql")
 			buf = bufpool.Get().(*bytes.Buffer)
@@ -701,6 +1656,7 @@ func (m *postgresMigration) sql(prefix string) (filenames []string, bufs []*byte
 				Columns:     addKeyConstraint.Columns,
 			}
 			writeCreateIndex(dialect, buf, m.currentSchema, createIndex, true)
+			m.undoScopes = append(m.undoScopes, undoScope{indexes: []*Index{createIndex}})
 			// ${prefix}_${n}_create_${index}.undo.sql
 			filenames = append(filenames, fmt.Sprintf("%s_%02d_create_%s.undo.sql", prefix, n, name))
 			buf = bufpool.Get().(*bytes.Buffer)
@@ -714,6 +1670,7 @@ func (m *postgresMigration) sql(prefix string) (filenames []string, bufs []*byte
 			// ${prefix}_${n}_add_${constraint}.tx.sql
 			n++
 			filenames = append(filenames, fmt.Sprintf("%s_%02d_add_%s.tx.sql", prefix, n, name))
+			m.undoScopes = append(m.undoScopes, undoScope{constraints: []*Constraint{addKeyConstraint}})
 			buf = bufpool.Get().(*bytes.Buffer)
 			buf.Reset()
 			bufs = append(bufs, buf)
@@ -726,10 +1683,8 @@ func (m *postgresMigration) sql(prefix string) (filenames []string, bufs []*byte
 		n++
 		fk := *fkeys[0]
 		name := strings.ReplaceAll(fk.TableName, " ", "_") + "_"
-		tableName := QuoteIdentifier(dialect, fk.TableName)
 		if fk.TableSchema != "" && fk.TableSchema != m.currentSchema {
 			name = strings.ReplaceAll(fk.TableSchema, " ", "_") + "_" + name
-			tableName = QuoteIdentifier(dialect, fk.TableSchema) + "." + tableName
 		}
 		if fk.ReferencesSchema != "" && fk.ReferencesSchema != m.currentSchema {
 			name += strings.ReplaceAll(fk.ReferencesSchema, " ", "_") + "_"
@@ -737,6 +1692,7 @@ func (m *postgresMigration) sql(prefix string) (filenames []string, bufs []*byte
 		name += strings.ReplaceAll(fk.ReferencesTable, " ", "_")
 		// ${prefix}_${n}_add_${table1}_${table2}_fkeys.tx.sql
 		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_add_"+name+"_fkeys.tx.sql")
+		m.undoScopes = append(m.undoScopes, undoScope{constraints: fkeys})
 		buf := bufpool.Get().(*bytes.Buffer)
 		buf.Reset()
 		bufs = append(bufs, buf)
@@ -744,7 +1700,7 @@ func (m *postgresMigration) sql(prefix string) (filenames []string, bufs []*byte
 			if buf.Len() > 0 {
 				buf.WriteString("\n")
 			}
-			buf.WriteString("ALTER TABLE " + tableName + " ADD ")
+			buf.WriteString("ALTER TABLE " + fkeyTableName(fkey) + " ADD ")
 			writeConstraintDefinition(dialect, buf, m.currentSchema, fkey)
 			buf.WriteString(";\n")
 		}
@@ -755,10 +1711,8 @@ func (m *postgresMigration) sql(prefix string) (filenames []string, bufs []*byte
 		n++
 		fk := *fkeys[0]
 		name := strings.ReplaceAll(fk.TableName, " ", "_") + "_"
-		tableName := QuoteIdentifier(dialect, fk.TableName)
 		if fk.TableSchema != "" && fk.TableSchema != m.currentSchema {
 			name = strings.ReplaceAll(fk.TableSchema, " ", "_") + "_" + name
-			tableName = QuoteIdentifier(dialect, fk.TableSchema) + "." + tableName
 		}
 		if fk.ReferencesSchema != "" && fk.ReferencesSchema != m.currentSchema {
 			name += strings.ReplaceAll(fk.ReferencesSchema, " ", "_") + "_"
@@ -766,6 +1720,7 @@ func (m *postgresMigration) sql(prefix string) (filenames []string, bufs []*byte
 		name += strings.ReplaceAll(fk.ReferencesTable, " ", "_")
 		// ${prefix}_${n}_add_${table1}_${table2}_fkeys.tx.sql
 		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_add_"+name+"_fkeys.tx.sql")
+		m.undoScopes = append(m.undoScopes, undoScope{constraints: fkeys})
 		buf := bufpool.Get().(*bytes.Buffer)
 		buf.Reset()
 		bufs = append(bufs, buf)
@@ -773,13 +1728,14 @@ func (m *postgresMigration) sql(prefix string) (filenames []string, bufs []*byte
 			if buf.Len() > 0 {
 				buf.WriteString("\n")
 			}
-			buf.WriteString("ALTER TABLE " + tableName + " ADD ")
+			buf.WriteString("ALTER TABLE " + fkeyTableName(fkey) + " ADD ")
 			writeConstraintDefinition(dialect, buf, m.currentSchema, fkey)
 			buf.WriteString(" NOT VALID;\n")
 		}
 		n++
 		// ${prefix}_${n}_validate_${table1}_${table2}_fkeys.tx.sql
 		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_validate_"+name+"_fkeys.tx.sql")
+		m.undoScopes = append(m.undoScopes, undoScope{})
 		buf = bufpool.Get().(*bytes.Buffer)
 		buf.Reset()
 		bufs = append(bufs, buf)
@@ -788,9 +1744,218 @@ func (m *postgresMigration) sql(prefix string) (filenames []string, bufs []*byte
 				buf.WriteString("\n")
 			}
 			constraintName := QuoteIdentifier(dialect, fkey.ConstraintName)
-			buf.WriteString("ALTER TABLE " + tableName + " VALIDATE CONSTRAINT " + constraintName + ";\n")
+			buf.WriteString("ALTER TABLE " + fkeyTableName(fkey) + " VALIDATE CONSTRAINT " + constraintName + ";\n")
 		}
 	}
 
+	// ALTER SEQUENCE. The sequences created earlier are given their owner
+	// here.
+	var ownSequences []*Sequence
+	for _, sequence := range m.createSequences {
+		if sequence.OwnedByTable != "" {
+			ownSequences = append(ownSequences, sequence)
+		}
+	}
+	if len(m.alterSequences) > 0 || len(ownSequences) > 0 {
+		n++
+		// ${prefix}_${n}_alter_sequences.sql
+		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_alter_sequences.sql")
+		scope := undoScope{sequenceOwners: ownSequences}
+		for _, sequences := range m.alterSequences {
+			scope.sequences = append(scope.sequences, sequences[1])
+		}
+		m.undoScopes = append(m.undoScopes, scope)
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		for _, sequences := range m.alterSequences {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			writeAlterSequence(dialect, buf, m.currentSchema, sequences[0], sequences[1])
+		}
+		for _, sequence := range ownSequences {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			unowned := *sequence
+			unowned.OwnedByTable, unowned.OwnedByColumn = "", ""
+			writeAlterSequence(dialect, buf, m.currentSchema, &unowned, sequence)
+		}
+	}
+
+	// DROP TYPE.
+	if len(m.dropEnums) > 0 {
+		n++
+		// ${prefix}_${n}_drop_enums.sql
+		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_drop_enums.sql")
+		m.undoScopes = append(m.undoScopes, undoScope{enums: m.dropEnums})
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		for _, enum := range m.dropEnums {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			enumName := QuoteIdentifier(dialect, enum.EnumName)
+			if enum.EnumSchema != "" && enum.EnumSchema != m.currentSchema {
+				enumName = QuoteIdentifier(dialect, enum.EnumSchema) + "." + enumName
+			}
+			buf.WriteString("DROP TYPE IF EXISTS " + enumName + ";\n")
+		}
+	}
+
+	// DROP DOMAIN.
+	if len(m.dropDomains) > 0 {
+		n++
+		// ${prefix}_${n}_drop_domains.sql
+		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_drop_domains.sql")
+		m.undoScopes = append(m.undoScopes, undoScope{domains: m.dropDomains})
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		for _, domain := range m.dropDomains {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			domainName := QuoteIdentifier(dialect, domain.DomainName)
+			if domain.DomainSchema != "" && domain.DomainSchema != m.currentSchema {
+				domainName = QuoteIdentifier(dialect, domain.DomainSchema) + "." + domainName
+			}
+			buf.WriteString("DROP DOMAIN IF EXISTS " + domainName + ";\n")
+		}
+	}
+
+	// DROP SEQUENCE.
+	if len(m.dropSequences) > 0 {
+		n++
+		// ${prefix}_${n}_drop_sequences.sql
+		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_drop_sequences.sql")
+		m.undoScopes = append(m.undoScopes, undoScope{sequences: m.dropSequences})
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		for _, sequence := range m.dropSequences {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			sequenceName := QuoteIdentifier(dialect, sequence.SequenceName)
+			if sequence.SequenceSchema != "" && sequence.SequenceSchema != m.currentSchema {
+				sequenceName = QuoteIdentifier(dialect, sequence.SequenceSchema) + "." + sequenceName
+			}
+			buf.WriteString("DROP SEQUENCE IF EXISTS " + sequenceName + ";\n")
+		}
+	}
+
+	// DROP EXTENSION.
+	if len(m.dropExtensions) > 0 {
+		n++
+		// ${prefix}_${n}_drop_extensions.sql
+		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_drop_extensions.sql")
+		m.undoScopes = append(m.undoScopes, undoScope{extensions: m.dropExtensions})
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		for _, extension := range m.dropExtensions {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			buf.WriteString("DROP EXTENSION IF EXISTS " + QuoteIdentifier(dialect, extension) + ";\n")
+		}
+	}
+
+	// CREATE FUNCTION | CREATE PROCEDURE.
+	if m.routines.hasCreateRoutines() {
+		n++
+		// ${prefix}_${n}_routines.sql
+		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_routines.sql")
+		m.undoScopes = append(m.undoScopes, m.routines.undoCreateRoutines())
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		m.routines.writeCreateRoutines(buf)
+	}
+
+	// CREATE VIEW.
+	if m.views.hasCreateViews() {
+		n++
+		// ${prefix}_${n}_views.sql
+		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_views.sql")
+		m.undoScopes = append(m.undoScopes, m.views.undoCreateViews())
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		m.views.writeCreateViews(buf)
+	}
+
+	// CREATE TRIGGER.
+	if m.routines.hasCreateTriggers() {
+		n++
+		// ${prefix}_${n}_triggers.sql
+		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_triggers.sql")
+		m.undoScopes = append(m.undoScopes, m.routines.undoCreateTriggers())
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		m.routines.writeCreateTriggers(buf)
+	}
+
+	// DROP FUNCTION | DROP PROCEDURE.
+	if m.routines.hasDropRoutines() {
+		n++
+		// ${prefix}_${n}_drop_routines.sql
+		filenames = append(filenames, prefix+"_"+fmt.Sprintf("%02d", n)+"_drop_routines.sql")
+		m.undoScopes = append(m.undoScopes, m.routines.undoDropRoutines())
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		m.routines.writeDropRoutines(buf)
+	}
+
 	return filenames, bufs, warnings
 }
+
+// postgresAlterTypeWarnings returns the warnings raised by changing the type
+// of the srcColumn to the type of the destColumn. A type change without any
+// warnings is safe.
+func postgresAlterTypeWarnings(srcColumn, destColumn *Column) (warnings []Warning) {
+	const dialect = DialectPostgres
+	columnName := destColumn.ColumnName
+	srcType, srcArg1, srcArg2 := normalizeColumnType(dialect, srcColumn.ColumnType)
+	destType, destArg1, destArg2 := normalizeColumnType(dialect, destColumn.ColumnType)
+	// I'm going to ignore CITEXT because using it doesn't seem to be a good
+	// idea. "Personally, I stay away from citext after mixed experiences."
+	// (https://dba.stackexchange.com/a/230688). Besides, accounting for
+	// CITEXT would complicate code here because ALTER TYPE safety depends on
+	// whether the CITEXT column is indexed. I'll just treat all CITEXT
+	// conversions as unsafe and let the user decide if it is safe. Reference:
+	// https://github.com/ankane/strong_migrations#changing-the-type-of-a-column.
+	switch [2]string{srcType, destType} {
+	case [2]string{"CIDR", "INET"}, [2]string{"VARCHAR", "TEXT"}:
+		// Always ok.
+	case [2]string{"TEXT", "VARCHAR"}:
+		if destArg1 != "" {
+			warnings = append(warnings, Warning{Code: WarnPGLimitTextColumn, Severity: SeverityHigh, Message: fmt.Sprintf("column %q changing type from \"TEXT\" to %q is unsafe", columnName, destColumn.ColumnType)})
+		}
+	case [2]string{"VARCHAR", "VARCHAR"}:
+		srcLimit, _ := strconv.Atoi(srcArg1)
+		destLimit, _ := strconv.Atoi(destArg1)
+		if srcLimit > 0 && destLimit > 0 && destLimit < srcLimit {
+			warnings = append(warnings, Warning{Code: WarnPGDecreaseColumnLimit, Severity: SeverityHigh, Message: fmt.Sprintf("column %q decreasing limit from %q to %q is unsafe", columnName, srcColumn.ColumnType, destColumn.ColumnType)})
+		}
+	case [2]string{"NUMERIC", "NUMERIC"}:
+		srcScale, _ := strconv.Atoi(srcArg2)
+		destScale, _ := strconv.Atoi(destArg2)
+		if srcScale != destScale && destScale != 0 {
+			warnings = append(warnings, Warning{Code: WarnPGChangeColumnScale, Severity: SeverityHigh, Message: fmt.Sprintf("column %q changing scale from %q to %q is unsafe", columnName, srcColumn.ColumnType, destColumn.ColumnType)})
+		}
+		srcPrecision, _ := strconv.Atoi(srcArg1)
+		destPrecision, _ := strconv.Atoi(destArg1)
+		if srcPrecision > 0 && destPrecision > 0 && destPrecision < srcPrecision {
+			warnings = append(warnings, Warning{Code: WarnPGDecreaseColumnPrecision, Severity: SeverityHigh, Message: fmt.Sprintf("column %q decreasing precision from %q to %q is unsafe", columnName, srcColumn.ColumnType, destColumn.ColumnType)})
+		}
+	default:
+		warnings = append(warnings, Warning{Code: WarnPGChangeColumnType, Severity: SeverityMedium, Message: fmt.Sprintf("column %q changing type from %q to %q may be unsafe", columnName, srcColumn.ColumnType, destColumn.ColumnType)})
+	}
+	return warnings
+}
@@ -23,6 +23,16 @@ func Test_postgresMigration(t *testing.T) {
 		{"testdata/postgres_add", false},
 		{"testdata/postgres_alter", false},
 		{"testdata/postgres_ignore", true},
+		{"testdata/postgres_enum", true},
+		{"testdata/postgres_domain", true},
+		{"testdata/postgres_sequence", true},
+		{"testdata/postgres_check", true},
+		{"testdata/postgres_exclude", true},
+		{"testdata/postgres_partition", true},
+		{"testdata/postgres_index", false},
+		{"testdata/postgres_predicate", true},
+		{"testdata/postgres_comment", true},
+		{"testdata/postgres_rename", true},
 	}
 	newCatalog := func(t *testing.T, filename string) *Catalog {
 		file, err := os.Open(filename)
@@ -70,7 +80,7 @@ func Test_postgresMigration(t *testing.T) {
 			}
 			wantWarnings = string(bytes.TrimSpace(bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n"))))
 			if len(warnings) > 0 {
-				gotWarnings = strings.Join(warnings, "\n")
+				gotWarnings = strings.Join(warningMessages(warnings), "\n")
 			}
 			if diff := testutil.Diff(gotWarnings, wantWarnings); diff != "" {
 				t.Error(testutil.Callers(), diff)
@@ -78,3 +88,151 @@ func Test_postgresMigration(t *testing.T) {
 		})
 	}
 }
+
+func Test_postgresMigration_enumBeforePostgres12(t *testing.T) {
+	// Before Postgres 12, ALTER TYPE ... ADD VALUE cannot run inside a
+	// transaction block so every label should be added in its own file. Labels
+	// are sanitized before being used in filenames.
+	src := &Catalog{Dialect: DialectPostgres, CurrentSchema: "public", VersionNums: VersionNums{11, 18}}
+	dest := &Catalog{Dialect: DialectPostgres, CurrentSchema: "public", VersionNums: VersionNums{11, 18}}
+	src.Schemas = []Schema{{SchemaName: "public", EnumsValid: true, Enums: []Enum{
+		{EnumSchema: "public", EnumName: "mpaa_rating", EnumLabels: []string{"G", "R"}},
+	}}}
+	dest.Schemas = []Schema{{SchemaName: "public", EnumsValid: true, Enums: []Enum{
+		{EnumSchema: "public", EnumName: "mpaa_rating", EnumLabels: []string{"G", "PG", "R", "NC-17", "N/A"}},
+	}}}
+	m := newPostgresMigration(src, dest, false)
+	filenames, bufs, warnings := m.sql("enum")
+	wantFilenames := []string{
+		"enum_01_alter_mpaa_rating_PG.txoff.sql",
+		"enum_02_alter_mpaa_rating_NC_17.txoff.sql",
+		"enum_03_alter_mpaa_rating_N_A.txoff.sql",
+	}
+	if diff := testutil.Diff(filenames, wantFilenames); diff != "" {
+		t.Fatal(testutil.Callers(), diff)
+	}
+	wantContents := []string{
+		"ALTER TYPE mpaa_rating ADD VALUE IF NOT EXISTS 'PG' AFTER 'G';\n",
+		"ALTER TYPE mpaa_rating ADD VALUE IF NOT EXISTS 'NC-17';\n",
+		"ALTER TYPE mpaa_rating ADD VALUE IF NOT EXISTS 'N/A';\n",
+	}
+	for i, buf := range bufs {
+		if diff := testutil.Diff(buf.String(), wantContents[i]); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	}
+	if len(warnings) > 0 {
+		t.Errorf(testutil.Callers()+" unexpected warnings: %v", warnings)
+	}
+}
+
+func Test_postgresMigration_fkeysBetweenTwoTables(t *testing.T) {
+	// Foreign keys are grouped by the pair of tables they are between, but
+	// each foreign key must still be added to and dropped from its own table.
+	parse := func(s string) *Catalog {
+		p := NewSQLParser()
+		err := p.ParseFile(&bufferFile{name: "schema.sql", buf: bytes.NewBufferString(s)})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		catalog := &Catalog{Dialect: DialectPostgres, CurrentSchema: "public"}
+		err = p.WriteCatalog(catalog)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		return catalog
+	}
+	tables := `
+CREATE TABLE store (store_id INT PRIMARY KEY, manager_id INT);
+CREATE TABLE staff (staff_id INT PRIMARY KEY, store_id INT);
+`
+	withFkeys := tables + `
+ALTER TABLE store ADD CONSTRAINT store_manager_id_fkey FOREIGN KEY (manager_id) REFERENCES staff (staff_id);
+ALTER TABLE staff ADD CONSTRAINT staff_store_id_fkey FOREIGN KEY (store_id) REFERENCES store (store_id);
+`
+	m := newPostgresMigration(parse(tables), parse(withFkeys), true)
+	_, bufs, _ := m.sql("fkey")
+	var b strings.Builder
+	for _, buf := range bufs {
+		b.WriteString(buf.String())
+	}
+	gotSQL := b.String()
+	for _, wantSQL := range []string{
+		"ALTER TABLE store ADD CONSTRAINT store_manager_id_fkey FOREIGN KEY (manager_id) REFERENCES staff (staff_id) NOT VALID;\n",
+		"ALTER TABLE staff ADD CONSTRAINT staff_store_id_fkey FOREIGN KEY (store_id) REFERENCES store (store_id) NOT VALID;\n",
+		"ALTER TABLE store VALIDATE CONSTRAINT store_manager_id_fkey;\n",
+		"ALTER TABLE staff VALIDATE CONSTRAINT staff_store_id_fkey;\n",
+	} {
+		if !strings.Contains(gotSQL, wantSQL) {
+			t.Errorf(testutil.Callers()+" missing %q in:\n%s", wantSQL, gotSQL)
+		}
+	}
+
+	m = newPostgresMigration(parse(withFkeys), parse(tables), true)
+	_, bufs, _ = m.sql("fkey")
+	b.Reset()
+	for _, buf := range bufs {
+		b.WriteString(buf.String())
+	}
+	gotSQL = b.String()
+	for _, wantSQL := range []string{
+		"ALTER TABLE store DROP CONSTRAINT IF EXISTS store_manager_id_fkey;\n",
+		"ALTER TABLE staff DROP CONSTRAINT IF EXISTS staff_store_id_fkey;\n",
+	} {
+		if !strings.Contains(gotSQL, wantSQL) {
+			t.Errorf(testutil.Callers()+" missing %q in:\n%s", wantSQL, gotSQL)
+		}
+	}
+}
+
+func Test_postgresMigration_partitionedIndex(t *testing.T) {
+	parse := func(s string) *Catalog {
+		p := NewSQLParser()
+		err := p.ParseFile(&bufferFile{name: "schema.sql", buf: bytes.NewBufferString(s)})
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		catalog := &Catalog{Dialect: DialectPostgres, CurrentSchema: "public"}
+		err = p.WriteCatalog(catalog)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		return catalog
+	}
+	tables := `
+CREATE TABLE measurement (measurement_id INT NOT NULL, created_at TIMESTAMPTZ NOT NULL, sensor_id INT) PARTITION BY RANGE (created_at);
+CREATE TABLE measurement_2026_01 PARTITION OF measurement FOR VALUES FROM ('2026-01-01') TO ('2026-02-01');
+CREATE TABLE measurement_2026_02 PARTITION OF measurement FOR VALUES FROM ('2026-02-01') TO ('2026-03-01');
+`
+	withIndexes := tables + `
+CREATE INDEX measurement_sensor_id_idx ON measurement (sensor_id);
+ALTER TABLE measurement ADD CONSTRAINT measurement_measurement_id_created_at_pkey PRIMARY KEY (measurement_id, created_at);
+`
+	m := newPostgresMigration(parse(tables), parse(withIndexes), true)
+	filenames, bufs, warnings := m.sql("partition")
+	gotFiles := make(map[string]string)
+	for i, filename := range filenames {
+		gotFiles[filename] = bufs[i].String()
+	}
+	wantFiles := map[string]string{
+		"partition_01_alter_measurement.tx.sql":                           "ALTER TABLE measurement ADD CONSTRAINT measurement_measurement_id_created_at_pkey PRIMARY KEY (measurement_id, created_at);\n",
+		"partition_02_create_measurement_sensor_id_idx.tx.sql":            "CREATE INDEX measurement_sensor_id_idx ON ONLY measurement (sensor_id);\n",
+		"partition_03_create_measurement_2026_01_sensor_id_idx.txoff.sql": "CREATE INDEX CONCURRENTLY measurement_2026_01_sensor_id_idx ON measurement_2026_01 (sensor_id);\n",
+		"partition_03_create_measurement_2026_01_sensor_id_idx.undo.sql":  "DROP INDEX IF EXISTS measurement_2026_01_sensor_id_idx;\n",
+		"partition_04_create_measurement_2026_02_sensor_id_idx.txoff.sql": "CREATE INDEX CONCURRENTLY measurement_2026_02_sensor_id_idx ON measurement_2026_02 (sensor_id);\n",
+		"partition_04_create_measurement_2026_02_sensor_id_idx.undo.sql":  "DROP INDEX IF EXISTS measurement_2026_02_sensor_id_idx;\n",
+		"partition_05_attach_measurement_sensor_id_idx.tx.sql": "ALTER INDEX measurement_sensor_id_idx ATTACH PARTITION measurement_2026_01_sensor_id_idx;\n" +
+			"\n" +
+			"ALTER INDEX measurement_sensor_id_idx ATTACH PARTITION measurement_2026_02_sensor_id_idx;\n",
+	}
+	if diff := testutil.Diff(gotFiles, wantFiles); diff != "" {
+		t.Error(testutil.Callers(), diff)
+	}
+	var gotCodes []string
+	for _, warning := range warnings {
+		gotCodes = append(gotCodes, warning.Code)
+	}
+	if diff := testutil.Diff(gotCodes, []string{WarnPGPartitionedIndexLock}); diff != "" {
+		t.Error(testutil.Callers(), diff)
+	}
+}
@@ -0,0 +1,352 @@
+package ddl
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// privilegeMigration contains the grant and row-level security policy
+// changes of a migration. They are written into a separate migration file
+// that comes after every other migration file, so the objects they refer to
+// already exist (under their new names). Removed grants are only revoked if
+// the destination catalog knows every grant of their schema (GrantsValid),
+// and removed policies are only dropped if it knows every policy of their
+// schema (PoliciesValid).
+type privilegeMigration struct {
+	dialect       string
+	currentSchema string
+
+	// 1. Revoke the removed grants, and the grants whose grant option is
+	// changed.
+	revokeGrants []*Grant
+
+	// 2. Drop the removed and changed policies.
+	dropPolicies []*Policy
+
+	// 3. Enable or disable row-level security on the tables.
+	enableRowSecurity  []*Table
+	disableRowSecurity []*Table
+
+	// 4. Create the new and changed policies.
+	createPolicies []*Policy
+
+	// 5. Grant the new grants, and the grants whose grant option is changed.
+	addGrants []*Grant
+}
+
+func newPrivilegeMigration(dialect string, srcCatalog, destCatalog *Catalog, dropObjects bool) privilegeMigration {
+	pm := privilegeMigration{
+		dialect:       dialect,
+		currentSchema: srcCatalog.CurrentSchema,
+	}
+	if dialect != DialectPostgres && dialect != DialectMySQL && dialect != DialectSQLServer {
+		return pm
+	}
+	srcCache, destCache := NewCatalogCache(srcCatalog), NewCatalogCache(destCatalog)
+
+	// Views that are dropped and recreated lose their grants (except in
+	// MySQL, which keeps privileges by name), so their grants have to be
+	// granted again.
+	recreatedViews := make(map[[2]string]bool)
+	if dialect != DialectMySQL {
+		views := newViewMigration(dialect, srcCatalog, destCatalog, dropObjects)
+		for _, view := range views.dropViews {
+			recreatedViews[viewKey(view.ViewSchema, view.ViewName)] = true
+		}
+	}
+
+	// DROP POLICY, ALTER TABLE ENABLE ROW LEVEL SECURITY, CREATE POLICY.
+	renamedTables := make(map[[2]string]string)
+	for i := range destCatalog.Schemas {
+		destSchema := &destCatalog.Schemas[i]
+		if destSchema.Ignore {
+			continue
+		}
+		srcSchema := srcCache.GetSchema(srcCatalog, destSchema.SchemaName)
+		for j := range destSchema.Tables {
+			destTable := &destSchema.Tables[j]
+			if destTable.Ignore {
+				continue
+			}
+			var srcTable *Table
+			if srcSchema != nil && !srcSchema.Ignore {
+				var isRenamed bool
+				srcTable, isRenamed = findSrcTable(srcCache, srcSchema, destTable)
+				if srcTable != nil && srcTable.Ignore {
+					srcTable = nil
+				}
+				if srcTable != nil && isRenamed {
+					renamedTables[[2]string{srcSchema.SchemaName, srcTable.TableName}] = destTable.TableName
+				}
+			}
+			if dialect == DialectPostgres {
+				pm.diffPolicies(srcCache, destCache, srcTable, destTable, dropObjects && destSchema.PoliciesValid, destSchema.PoliciesValid)
+			}
+		}
+	}
+
+	// REVOKE. The grants on renamed tables are keyed by the new table name.
+	var srcKeys [][5]string
+	srcGrants := make(map[[5]string]*Grant)
+	for i := range srcCatalog.Schemas {
+		srcSchema := &srcCatalog.Schemas[i]
+		if srcSchema.Ignore {
+			continue
+		}
+		for j := range srcSchema.Grants {
+			srcGrant := &srcSchema.Grants[j]
+			if srcGrant.Ignore {
+				continue
+			}
+			objectName := srcGrant.ObjectName
+			if srcGrant.ObjectType == "TABLE" {
+				if newName, ok := renamedTables[[2]string{srcSchema.SchemaName, objectName}]; ok {
+					objectName = newName
+				} else if recreatedViews[viewKey(srcSchema.SchemaName, objectName)] {
+					continue
+				}
+			}
+			key := grantKey(srcSchema.SchemaName, srcGrant.ObjectType, objectName, srcGrant.Grantee, srcGrant.Privilege)
+			if srcGrants[key] == nil {
+				srcKeys = append(srcKeys, key)
+			}
+			srcGrants[key] = srcGrant
+		}
+	}
+	destGrants := make(map[[5]string]*Grant)
+	var destKeys [][5]string
+	for i := range destCatalog.Schemas {
+		destSchema := &destCatalog.Schemas[i]
+		if destSchema.Ignore {
+			continue
+		}
+		for j := range destSchema.Grants {
+			destGrant := &destSchema.Grants[j]
+			if destGrant.Ignore || !grantObjectExists(destCache, destSchema, destGrant.ObjectType, destGrant.ObjectName) {
+				continue
+			}
+			key := grantKey(destSchema.SchemaName, destGrant.ObjectType, destGrant.ObjectName, destGrant.Grantee, destGrant.Privilege)
+			if destGrants[key] == nil {
+				destKeys = append(destKeys, key)
+			}
+			destGrants[key] = destGrant
+		}
+	}
+	for _, key := range srcKeys {
+		srcGrant, destGrant := srcGrants[key], destGrants[key]
+		if destGrant != nil && destGrant.IsGrantable == srcGrant.IsGrantable {
+			continue
+		}
+		if destGrant == nil {
+			// A grant is only revoked if its object still exists, otherwise
+			// it is removed together with its object.
+			destSchema := destCache.GetSchema(destCatalog, key[0])
+			if destSchema == nil || destSchema.Ignore || !destSchema.GrantsValid || !grantObjectExists(destCache, destSchema, srcGrant.ObjectType, key[2]) {
+				continue
+			}
+		}
+		revokeGrant := *srcGrant
+		revokeGrant.GrantSchema, revokeGrant.ObjectName = key[0], key[2]
+		pm.revokeGrants = append(pm.revokeGrants, &revokeGrant)
+	}
+
+	// GRANT.
+	for _, key := range destKeys {
+		srcGrant, destGrant := srcGrants[key], destGrants[key]
+		if srcGrant == nil || srcGrant.IsGrantable != destGrant.IsGrantable {
+			pm.addGrants = append(pm.addGrants, destGrant)
+		}
+	}
+	return pm
+}
+
+// diffPolicies works out the row-level security changes of a table. The
+// srcTable is nil if the destTable is a new table. Policies are compared by
+// their normalized expressions and changed policies are dropped and created
+// again.
+func (pm *privilegeMigration) diffPolicies(srcCache, destCache *CatalogCache, srcTable, destTable *Table, dropPolicies, policiesValid bool) {
+	var srcRowSecurity bool
+	if srcTable != nil {
+		srcRowSecurity = srcTable.RowSecurityEnabled
+	}
+	if destTable.RowSecurityEnabled && !srcRowSecurity {
+		pm.enableRowSecurity = append(pm.enableRowSecurity, destTable)
+	} else if !destTable.RowSecurityEnabled && srcRowSecurity && policiesValid {
+		pm.disableRowSecurity = append(pm.disableRowSecurity, destTable)
+	}
+	if srcTable != nil {
+		for i := range srcTable.Policies {
+			srcPolicy := &srcTable.Policies[i]
+			if srcPolicy.Ignore {
+				continue
+			}
+			destPolicy := destCache.GetPolicy(destTable, srcPolicy.PolicyName)
+			if destPolicy != nil && !destPolicy.Ignore {
+				if policiesAreEqual(pm.dialect, srcPolicy, destPolicy) {
+					continue
+				}
+				pm.createPolicies = append(pm.createPolicies, destPolicy)
+			} else if !dropPolicies {
+				continue
+			}
+			// The table may have been renamed, so the policy is dropped
+			// from the table under its new name.
+			dropPolicy := *srcPolicy
+			dropPolicy.TableSchema = destTable.TableSchema
+			dropPolicy.TableName = destTable.TableName
+			pm.dropPolicies = append(pm.dropPolicies, &dropPolicy)
+		}
+	}
+	for i := range destTable.Policies {
+		destPolicy := &destTable.Policies[i]
+		if destPolicy.Ignore {
+			continue
+		}
+		var srcPolicy *Policy
+		if srcTable != nil {
+			srcPolicy = srcCache.GetPolicy(srcTable, destPolicy.PolicyName)
+		}
+		if srcPolicy == nil || srcPolicy.Ignore {
+			pm.createPolicies = append(pm.createPolicies, destPolicy)
+		}
+	}
+}
+
+// grantKey returns the key that identifies a grant. Privileges are compared
+// case insensitively.
+func grantKey(schemaName, objectType, objectName, grantee, privilege string) [5]string {
+	return [5]string{schemaName, objectType, objectName, grantee, strings.ToUpper(privilege)}
+}
+
+// grantObjectExists reports whether the object that a grant is on exists in
+// the schema.
+func grantObjectExists(cache *CatalogCache, schema *Schema, objectType, objectName string) bool {
+	switch objectType {
+	case "SCHEMA":
+		return true
+	case "SEQUENCE":
+		sequence := cache.GetSequence(schema, objectName)
+		return sequence != nil && !sequence.Ignore
+	default:
+		table := cache.GetTable(schema, objectName)
+		if table != nil && !table.Ignore {
+			return true
+		}
+		view := cache.GetView(schema, objectName)
+		return view != nil && !view.Ignore
+	}
+}
+
+// policiesAreEqual reports whether two policies are the same. The USING and
+// WITH CHECK expressions are compared after normalizing them.
+func policiesAreEqual(dialect string, srcPolicy, destPolicy *Policy) bool {
+	srcCommand, destCommand := strings.ToUpper(srcPolicy.Command), strings.ToUpper(destPolicy.Command)
+	if srcCommand == "" {
+		srcCommand = "ALL"
+	}
+	if destCommand == "" {
+		destCommand = "ALL"
+	}
+	return srcCommand == destCommand &&
+		srcPolicy.IsRestrictive == destPolicy.IsRestrictive &&
+		slices.Equal(policyRoles(srcPolicy), policyRoles(destPolicy)) &&
+		normalizeIndexPredicate(dialect, srcPolicy.UsingExpr) == normalizeIndexPredicate(dialect, destPolicy.UsingExpr) &&
+		normalizeIndexPredicate(dialect, srcPolicy.CheckExpr) == normalizeIndexPredicate(dialect, destPolicy.CheckExpr)
+}
+
+// policyRoles returns the sorted roles of a policy, with PUBLIC treated the
+// same as an empty list of roles.
+func policyRoles(policy *Policy) []string {
+	if len(policy.Roles) == 1 && strings.EqualFold(policy.Roles[0], "PUBLIC") {
+		return nil
+	}
+	roles := slices.Clone(policy.Roles)
+	slices.Sort(roles)
+	return roles
+}
+
+// sql returns the migration file that makes the grant and policy changes,
+// numbered after the n migration files that come before it. If down is true,
+// its down script is returned as well. No files are returned if there are no
+// changes.
+func (pm *privilegeMigration) sql(prefix string, n int, down bool) (filenames []string, bufs []*bytes.Buffer) {
+	if !pm.hasChanges() {
+		return nil, nil
+	}
+	// ${prefix}_${n}_privileges.sql
+	filename := prefix + "_" + fmt.Sprintf("%02d", n+1) + "_privileges"
+	buf := bufpool.Get().(*bytes.Buffer)
+	buf.Reset()
+	pm.writeChanges(buf, pm.revokeGrants, pm.dropPolicies, pm.enableRowSecurity, pm.disableRowSecurity, pm.createPolicies, pm.addGrants)
+	filenames = append(filenames, filename+".sql")
+	bufs = append(bufs, buf)
+	if down {
+		downbuf := bufpool.Get().(*bytes.Buffer)
+		downbuf.Reset()
+		pm.writeChanges(downbuf, pm.addGrants, pm.createPolicies, pm.disableRowSecurity, pm.enableRowSecurity, pm.dropPolicies, pm.revokeGrants)
+		filenames = append(filenames, filename+".down.sql")
+		bufs = append(bufs, downbuf)
+	}
+	return filenames, bufs
+}
+
+// writeChanges writes the grant and policy changes into the buffer. The down
+// script is written by passing in the changes the other way around.
+func (pm *privilegeMigration) writeChanges(buf *bytes.Buffer, revokeGrants []*Grant, dropPolicies []*Policy, enableRowSecurity, disableRowSecurity []*Table, createPolicies []*Policy, addGrants []*Grant) {
+	// REVOKE.
+	if len(revokeGrants) > 0 {
+		writeGrants(pm.dialect, buf, pm.currentSchema, revokeGrants, true)
+	}
+
+	// DROP POLICY.
+	if len(dropPolicies) > 0 {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		for _, policy := range dropPolicies {
+			tableName := QuoteIdentifier(pm.dialect, policy.TableName)
+			if policy.TableSchema != "" && policy.TableSchema != pm.currentSchema {
+				tableName = QuoteIdentifier(pm.dialect, policy.TableSchema) + "." + tableName
+			}
+			buf.WriteString("DROP POLICY " + QuoteIdentifier(pm.dialect, policy.PolicyName) + " ON " + tableName + ";\n")
+		}
+	}
+
+	// ALTER TABLE ENABLE ROW LEVEL SECURITY | ALTER TABLE DISABLE ROW LEVEL SECURITY.
+	if len(enableRowSecurity) > 0 || len(disableRowSecurity) > 0 {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		for _, table := range enableRowSecurity {
+			writeRowSecurity(pm.dialect, buf, pm.currentSchema, table, true)
+		}
+		for _, table := range disableRowSecurity {
+			writeRowSecurity(pm.dialect, buf, pm.currentSchema, table, false)
+		}
+	}
+
+	// CREATE POLICY.
+	if len(createPolicies) > 0 {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		for _, policy := range createPolicies {
+			writeCreatePolicy(pm.dialect, buf, pm.currentSchema, policy)
+		}
+	}
+
+	// GRANT.
+	if len(addGrants) > 0 {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		writeGrants(pm.dialect, buf, pm.currentSchema, addGrants, false)
+	}
+}
+
+func (pm *privilegeMigration) hasChanges() bool {
+	return len(pm.revokeGrants) > 0 || len(pm.dropPolicies) > 0 || len(pm.enableRowSecurity) > 0 ||
+		len(pm.disableRowSecurity) > 0 || len(pm.createPolicies) > 0 || len(pm.addGrants) > 0
+}
@@ -0,0 +1,211 @@
+package ddl
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/blink-io/sqddl/internal/testutil"
+)
+
+func Test_privilegeMigration(t *testing.T) {
+	type TT struct {
+		description   string
+		dialect       string
+		currentSchema string
+		srcSQL        string
+		destSQL       string
+		dropObjects   bool
+		wantFiles     map[string]string
+	}
+
+	tests := []TT{{
+		description:   "postgres",
+		dialect:       DialectPostgres,
+		currentSchema: "public",
+		srcSQL: `
+CREATE TABLE actor (actor_id INT, owner TEXT);
+CREATE TABLE film (film_id INT);
+ALTER TABLE actor ENABLE ROW LEVEL SECURITY;
+CREATE POLICY actor_owner ON actor USING (owner = current_user);
+CREATE POLICY actor_read ON actor FOR SELECT TO app USING (true);
+GRANT USAGE ON SCHEMA public TO app;
+GRANT SELECT, INSERT ON actor TO app;
+GRANT SELECT ON film TO app WITH GRANT OPTION;
+`,
+		destSQL: `
+CREATE TABLE actor (actor_id INT, owner TEXT);
+CREATE TABLE film (film_id INT);
+ALTER TABLE film ENABLE ROW LEVEL SECURITY;
+CREATE POLICY actor_owner ON actor AS RESTRICTIVE USING ((owner = CURRENT_USER));
+CREATE POLICY actor_write ON actor FOR INSERT TO app, "Reporting" WITH CHECK (owner IS NOT NULL);
+GRANT USAGE ON SCHEMA public TO app;
+GRANT SELECT, UPDATE ON actor TO app;
+GRANT SELECT ON film TO app, PUBLIC;
+`,
+		dropObjects: true,
+		wantFiles: map[string]string{
+			"test_01_privileges.sql": "REVOKE INSERT ON TABLE actor FROM app;\n" +
+				"REVOKE SELECT ON TABLE film FROM app;\n" +
+				"\nDROP POLICY actor_owner ON actor;\n" +
+				"DROP POLICY actor_read ON actor;\n" +
+				"\nALTER TABLE film ENABLE ROW LEVEL SECURITY;\n" +
+				"ALTER TABLE actor DISABLE ROW LEVEL SECURITY;\n" +
+				"\nCREATE POLICY actor_owner ON actor AS RESTRICTIVE USING (owner = CURRENT_USER);\n" +
+				"CREATE POLICY actor_write ON actor FOR INSERT TO app, \"Reporting\" WITH CHECK (owner IS NOT NULL);\n" +
+				"\nGRANT UPDATE ON TABLE actor TO app;\n" +
+				"GRANT SELECT ON TABLE film TO app;\n" +
+				"GRANT SELECT ON TABLE film TO PUBLIC;\n",
+			"test_01_privileges.down.sql": "REVOKE UPDATE ON TABLE actor FROM app;\n" +
+				"REVOKE SELECT ON TABLE film FROM app;\n" +
+				"REVOKE SELECT ON TABLE film FROM PUBLIC;\n" +
+				"\nDROP POLICY actor_owner ON actor;\n" +
+				"DROP POLICY actor_write ON actor;\n" +
+				"\nALTER TABLE actor ENABLE ROW LEVEL SECURITY;\n" +
+				"ALTER TABLE film DISABLE ROW LEVEL SECURITY;\n" +
+				"\nCREATE POLICY actor_owner ON actor USING (owner = current_user);\n" +
+				"CREATE POLICY actor_read ON actor FOR SELECT TO app USING (true);\n" +
+				"\nGRANT INSERT ON TABLE actor TO app;\n" +
+				"GRANT SELECT ON TABLE film TO app WITH GRANT OPTION;\n",
+		},
+	}, {
+		description:   "postgres without dropObjects",
+		dialect:       DialectPostgres,
+		currentSchema: "public",
+		srcSQL: `
+CREATE TABLE actor (actor_id INT, owner TEXT);
+CREATE POLICY actor_owner ON actor USING (owner = current_user);
+GRANT SELECT, INSERT ON actor TO app;
+`,
+		destSQL: `
+CREATE TABLE actor (actor_id INT, owner TEXT);
+CREATE TABLE film (film_id INT);
+GRANT SELECT ON actor TO app;
+GRANT SELECT ON film TO app;
+`,
+		wantFiles: map[string]string{
+			"test_01_tables.sql":      "CREATE TABLE film (\n    film_id INT\n);\n",
+			"test_01_tables.down.sql": "DROP TABLE IF EXISTS film;\n",
+			// The grants are revoked because the destination lists every
+			// grant of the schema, but the policy is left alone.
+			"test_02_privileges.sql":      "REVOKE INSERT ON TABLE actor FROM app;\n\nGRANT SELECT ON TABLE film TO app;\n",
+			"test_02_privileges.down.sql": "REVOKE SELECT ON TABLE film FROM app;\n\nGRANT INSERT ON TABLE actor TO app;\n",
+		},
+	}, {
+		description:   "postgres policy grouping",
+		dialect:       DialectPostgres,
+		currentSchema: "public",
+		srcSQL: `
+CREATE TABLE film (tenant_id INT, is_public BOOLEAN, is_deleted BOOLEAN);
+CREATE POLICY film_tenant ON film USING ((tenant_id = current_tenant() OR is_public) AND NOT is_deleted);
+CREATE POLICY film_public ON film FOR SELECT USING (is_public AND NOT is_deleted);
+`,
+		destSQL: `
+CREATE TABLE film (tenant_id INT, is_public BOOLEAN, is_deleted BOOLEAN);
+CREATE POLICY film_tenant ON film USING (tenant_id = current_tenant() OR (is_public AND NOT is_deleted));
+CREATE POLICY film_public ON film FOR SELECT USING (((is_public AND (NOT is_deleted))));
+`,
+		dropObjects: true,
+		wantFiles: map[string]string{
+			"test_01_privileges.sql": "DROP POLICY film_tenant ON film;\n" +
+				"\nCREATE POLICY film_tenant ON film USING (tenant_id = current_tenant() OR (is_public AND NOT is_deleted));\n",
+			"test_01_privileges.down.sql": "DROP POLICY film_tenant ON film;\n" +
+				"\nCREATE POLICY film_tenant ON film USING ((tenant_id = current_tenant() OR is_public) AND NOT is_deleted);\n",
+		},
+	}, {
+		description:   "mysql",
+		dialect:       DialectMySQL,
+		currentSchema: "sakila",
+		srcSQL: `
+CREATE TABLE actor (actor_id INT);
+GRANT SELECT ON actor TO 'app'@'%';
+`,
+		destSQL: `
+CREATE TABLE actor (actor_id INT);
+GRANT SELECT, CREATE VIEW ON sakila.* TO app;
+GRANT SELECT, INSERT ON actor TO 'app'@'%' WITH GRANT OPTION;
+`,
+		dropObjects: true,
+		wantFiles: map[string]string{
+			"test_01_privileges.sql": "REVOKE SELECT ON actor FROM 'app'@'%';\n" +
+				"\nGRANT SELECT, CREATE VIEW ON sakila.* TO 'app'@'%';\n" +
+				"GRANT SELECT, INSERT ON actor TO 'app'@'%' WITH GRANT OPTION;\n",
+			"test_01_privileges.down.sql": "REVOKE SELECT, CREATE VIEW ON sakila.* FROM 'app'@'%';\n" +
+				"REVOKE SELECT, INSERT ON actor FROM 'app'@'%';\n" +
+				"\nGRANT SELECT ON actor TO 'app'@'%';\n",
+		},
+	}, {
+		description:   "sqlserver",
+		dialect:       DialectSQLServer,
+		currentSchema: "dbo",
+		srcSQL: `
+CREATE TABLE actor (actor_id INT);
+GRANT SELECT, INSERT ON actor TO app WITH GRANT OPTION;
+`,
+		destSQL: `
+CREATE TABLE actor (actor_id INT);
+GRANT SELECT ON SCHEMA::dbo TO app;
+GRANT SELECT ON actor TO app WITH GRANT OPTION;
+`,
+		dropObjects: true,
+		wantFiles: map[string]string{
+			"test_01_privileges.sql":      "REVOKE INSERT ON actor FROM app CASCADE;\n\nGRANT SELECT ON SCHEMA::dbo TO app;\n",
+			"test_01_privileges.down.sql": "REVOKE SELECT ON SCHEMA::dbo FROM app;\n\nGRANT INSERT ON actor TO app WITH GRANT OPTION;\n",
+		},
+	}, {
+		description: "sqlite",
+		dialect:     DialectSQLite,
+		srcSQL:      "CREATE TABLE actor (actor_id INT);",
+		destSQL:     "CREATE TABLE actor (actor_id INT);",
+		dropObjects: true,
+		wantFiles:   map[string]string{},
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.description, func(t *testing.T) {
+			t.Parallel()
+			parse := func(s string) *Catalog {
+				p := NewSQLParser()
+				err := p.ParseFile(&bufferFile{name: "schema.sql", buf: bytes.NewBufferString(s)})
+				if err != nil {
+					t.Fatal(testutil.Callers(), err)
+				}
+				catalog := &Catalog{Dialect: tt.dialect, CurrentSchema: tt.currentSchema}
+				err = p.WriteCatalog(catalog)
+				if err != nil {
+					t.Fatal(testutil.Callers(), err)
+				}
+				return catalog
+			}
+			cmd := &GenerateCmd{
+				SrcCatalog:        parse(tt.srcSQL),
+				DestCatalog:       parse(tt.destSQL),
+				Dialect:           tt.dialect,
+				DropObjects:       tt.dropObjects,
+				Prefix:            "test",
+				IncludePrivileges: true,
+			}
+			files, _, err := cmd.Results()
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			gotFiles := make(map[string]string)
+			for _, file := range files {
+				fileinfo, err := file.Stat()
+				if err != nil {
+					t.Fatal(testutil.Callers(), err)
+				}
+				b, err := io.ReadAll(file)
+				if err != nil {
+					t.Fatal(testutil.Callers(), err)
+				}
+				file.Close()
+				gotFiles[fileinfo.Name()] = string(b)
+			}
+			if diff := testutil.Diff(gotFiles, tt.wantFiles); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package ddl
+
+import "fmt"
+
+// findSrcTable returns the table in the srcSchema that corresponds to the
+// destTable. If there is no table with the same name but the destTable is
+// renamed from a table that does exist, that table is returned instead and
+// isRenamed is true.
+func findSrcTable(srcCache *CatalogCache, srcSchema *Schema, destTable *Table) (srcTable *Table, isRenamed bool) {
+	srcTable = srcCache.GetTable(srcSchema, destTable.TableName)
+	if srcTable != nil || destTable.RenamedFrom == "" {
+		return srcTable, false
+	}
+	srcTable = srcCache.GetTable(srcSchema, destTable.RenamedFrom)
+	return srcTable, srcTable != nil
+}
+
+// findSrcColumn returns the column in the srcTable that corresponds to the
+// destColumn. If there is no column with the same name but the destColumn is
+// renamed from a column that does exist, that column is returned instead and
+// isRenamed is true.
+func findSrcColumn(srcCache *CatalogCache, srcTable *Table, destColumn *Column) (srcColumn *Column, isRenamed bool) {
+	srcColumn = srcCache.GetColumn(srcTable, destColumn.ColumnName)
+	if srcColumn != nil || destColumn.RenamedFrom == "" {
+		return srcColumn, false
+	}
+	srcColumn = srcCache.GetColumn(srcTable, destColumn.RenamedFrom)
+	return srcColumn, srcColumn != nil
+}
+
+// renamedTables returns the set of table names in the srcSchema that are
+// renamed to a table in the destSchema. Those tables must not be dropped.
+func renamedTables(srcCache *CatalogCache, srcSchema, destSchema *Schema) map[string]bool {
+	renamed := make(map[string]bool)
+	for i := range destSchema.Tables {
+		destTable := &destSchema.Tables[i]
+		if destTable.Ignore {
+			continue
+		}
+		if srcTable, isRenamed := findSrcTable(srcCache, srcSchema, destTable); isRenamed {
+			renamed[srcTable.TableName] = true
+		}
+	}
+	return renamed
+}
+
+// renamedColumns returns the set of column names in the srcTable that are
+// renamed to a column in the destTable. Those columns must not be dropped.
+func renamedColumns(srcCache *CatalogCache, srcTable, destTable *Table) map[string]bool {
+	renamed := make(map[string]bool)
+	for i := range destTable.Columns {
+		destColumn := &destTable.Columns[i]
+		if destColumn.Ignore {
+			continue
+		}
+		if srcColumn, isRenamed := findSrcColumn(srcCache, srcTable, destColumn); isRenamed {
+			renamed[srcColumn.ColumnName] = true
+		}
+	}
+	return renamed
+}
+
+// findSrcConstraint returns the constraint in the srcTable that corresponds to
+// the destConstraint. If there is no constraint with the same name but the
+// table is being renamed and the destConstraint has the name generated from
+// the new table name, the constraint with the name generated from the old
+// table name is returned instead and isRenamed is true.
+func findSrcConstraint(srcCache *CatalogCache, srcTable, destTable *Table, destConstraint *Constraint) (srcConstraint *Constraint, isRenamed bool) {
+	srcConstraint = srcCache.GetConstraint(srcTable, destConstraint.ConstraintName)
+	if srcConstraint != nil || srcTable.TableName == destTable.TableName {
+		return srcConstraint, false
+	}
+	if destConstraint.ConstraintName != GenerateName(destConstraint.ConstraintType, destTable.TableName, destConstraint.Columns) {
+		return nil, false
+	}
+	srcConstraint = srcCache.GetConstraint(srcTable, GenerateName(destConstraint.ConstraintType, srcTable.TableName, destConstraint.Columns))
+	if srcConstraint == nil || srcConstraint.ConstraintType != destConstraint.ConstraintType {
+		return nil, false
+	}
+	return srcConstraint, true
+}
+
+// findSrcIndex returns the index in the srcTable that corresponds to the
+// destIndex, following the same rules as findSrcConstraint.
+func findSrcIndex(srcCache *CatalogCache, srcTable, destTable *Table, destIndex *Index) (srcIndex *Index, isRenamed bool) {
+	srcIndex = srcCache.GetIndex(srcTable, destIndex.IndexName)
+	if srcIndex != nil || srcTable.TableName == destTable.TableName {
+		return srcIndex, false
+	}
+	if destIndex.IndexName != GenerateName(INDEX, destTable.TableName, destIndex.Columns) {
+		return nil, false
+	}
+	srcIndex = srcCache.GetIndex(srcTable, GenerateName(INDEX, srcTable.TableName, destIndex.Columns))
+	return srcIndex, srcIndex != nil
+}
+
+// renamedConstraints returns the set of constraint names in the srcTable that
+// are renamed to a constraint in the destTable. Those constraints must not be
+// dropped.
+func renamedConstraints(srcCache *CatalogCache, srcTable, destTable *Table) map[string]bool {
+	renamed := make(map[string]bool)
+	for i := range destTable.Constraints {
+		destConstraint := &destTable.Constraints[i]
+		if destConstraint.Ignore {
+			continue
+		}
+		if srcConstraint, isRenamed := findSrcConstraint(srcCache, srcTable, destTable, destConstraint); isRenamed {
+			renamed[srcConstraint.ConstraintName] = true
+		}
+	}
+	return renamed
+}
+
+// renamedIndexes returns the set of index names in the srcTable that are
+// renamed to an index in the destTable. Those indexes must not be dropped.
+func renamedIndexes(srcCache *CatalogCache, srcTable, destTable *Table) map[string]bool {
+	renamed := make(map[string]bool)
+	for i := range destTable.Indexes {
+		destIndex := &destTable.Indexes[i]
+		if destIndex.Ignore {
+			continue
+		}
+		if srcIndex, isRenamed := findSrcIndex(srcCache, srcTable, destTable, destIndex); isRenamed {
+			renamed[srcIndex.IndexName] = true
+		}
+	}
+	return renamed
+}
+
+// renamedFromWarnings returns a warning for every table or column in the
+// destCatalog that is renamed from a table or column that does not exist in
+// the srcCatalog. Such hints have either already been applied or are wrong,
+// and can be removed.
+func renamedFromWarnings(srcCache *CatalogCache, srcCatalog, destCatalog *Catalog) []Warning {
+	var warnings []Warning
+	for i := range destCatalog.Schemas {
+		destSchema := &destCatalog.Schemas[i]
+		if destSchema.Ignore {
+			continue
+		}
+		srcSchema := srcCache.GetSchema(srcCatalog, destSchema.SchemaName)
+		for j := range destSchema.Tables {
+			destTable := &destSchema.Tables[j]
+			if destTable.Ignore {
+				continue
+			}
+			tableName := destTable.TableName
+			if destTable.TableSchema != "" && destTable.TableSchema != destCatalog.CurrentSchema {
+				tableName = destTable.TableSchema + "." + tableName
+			}
+			srcTable, _ := findSrcTable(srcCache, srcSchema, destTable)
+			if destTable.RenamedFrom != "" && srcCache.GetTable(srcSchema, destTable.RenamedFrom) == nil {
+				warnings = append(warnings, Warning{
+					Code:     WarnRenamedFromNotFound,
+					Severity: SeverityLow,
+					Object:   tableName,
+					Message:  fmt.Sprintf("%s: renamedfrom: table %q does not exist, the hint can be removed", tableName, destTable.RenamedFrom),
+				})
+			}
+			for k := range destTable.Columns {
+				destColumn := &destTable.Columns[k]
+				if destColumn.Ignore || destColumn.RenamedFrom == "" {
+					continue
+				}
+				if srcCache.GetColumn(srcTable, destColumn.RenamedFrom) == nil {
+					warnings = append(warnings, Warning{
+						Code:     WarnRenamedFromNotFound,
+						Severity: SeverityLow,
+						Object:   tableName + "." + destColumn.ColumnName,
+						Message:  fmt.Sprintf("%s: column %q: renamedfrom: column %q does not exist, the hint can be removed", tableName, destColumn.ColumnName, destColumn.RenamedFrom),
+					})
+				}
+			}
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,1098 @@
+package ddl
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// routineMigration contains the routine (function and procedure) and trigger
+// changes of a migration. Routines and triggers are compared by their
+// normalized SQL. Removed routines and triggers are only dropped if the
+// destination catalog knows every routine (RoutinesValid) or trigger
+// (TriggersValid) of their schema.
+type routineMigration struct {
+	dialect       string
+	currentSchema string
+
+	// 1. Drop the removed and changed triggers. This happens before any
+	// table is changed.
+	dropTriggers []*Trigger
+
+	// 2. Create (or replace) the routines called by the domains and tables
+	// (in their defaults, checks, generated columns and indexes), before any
+	// domain or table is created or changed. Postgres and SQL Server only.
+	tableRoutines []*Routine
+
+	// 3. Create (or replace) the rest of the routines. This happens after
+	// every table has been changed and before the views are created.
+	// Routines are created after the routines they call, otherwise in the
+	// order they are defined. replaceRoutines maps each replaced routine to
+	// its current definition.
+	createRoutines  []*Routine
+	replaceRoutines map[*Routine]*Routine
+
+	// 4. Create the triggers, after the views have been created.
+	createTriggers []*Trigger
+
+	// 5. Drop the removed routines, after the triggers that call them have
+	// been dropped.
+	dropRoutines []*Routine
+
+	// targetTriggers are the triggers each table or view (keyed by viewKey)
+	// should end up with. They are recreated if the table or view is
+	// recreated.
+	targetTriggers map[[2]string][]*Trigger
+}
+
+func newRoutineMigration(dialect string, srcCatalog, destCatalog *Catalog, dropObjects bool, views *viewMigration) routineMigration {
+	rm := routineMigration{
+		dialect:         dialect,
+		currentSchema:   srcCatalog.CurrentSchema,
+		replaceRoutines: make(map[*Routine]*Routine),
+		targetTriggers:  make(map[[2]string][]*Trigger),
+	}
+	destCache := NewCatalogCache(destCatalog)
+
+	// CREATE FUNCTION | CREATE PROCEDURE.
+	srcKeys, srcRoutines := rm.routines(srcCatalog)
+	destKeys, destRoutines := rm.routines(destCatalog)
+	for _, key := range destKeys {
+		destRoutine := destRoutines[key]
+		if destRoutine.SQL == "" {
+			continue
+		}
+		srcRoutine := srcRoutines[key]
+		if srcRoutine == nil {
+			rm.createRoutines = append(rm.createRoutines, destRoutine)
+			continue
+		}
+		if srcRoutine.SQL != "" && !routinesAreEqual(dialect, rm.currentSchema, srcRoutine, destRoutine) {
+			rm.createRoutines = append(rm.createRoutines, destRoutine)
+			rm.replaceRoutines[destRoutine] = srcRoutine
+		}
+	}
+	rm.createRoutines = sortRoutines(rm.createRoutines)
+	if dialect == DialectPostgres || dialect == DialectSQLServer {
+		rm.tableRoutines, rm.createRoutines = splitTableRoutines(destCatalog, rm.createRoutines)
+	}
+
+	// DROP FUNCTION | DROP PROCEDURE.
+	if dropObjects {
+		for i := len(srcKeys) - 1; i >= 0; i-- {
+			key := srcKeys[i]
+			if destRoutines[key] != nil {
+				continue
+			}
+			srcRoutine := srcRoutines[key]
+			// Dropping a schema already drops the routines inside it.
+			destSchema := destCache.GetSchema(destCatalog, srcRoutine.RoutineSchema)
+			if destSchema != nil && destSchema.RoutinesValid {
+				rm.dropRoutines = append(rm.dropRoutines, srcRoutine)
+			}
+		}
+	}
+
+	// DROP TRIGGER.
+	srcKeys3, srcTriggers := rm.triggers(srcCatalog)
+	destKeys3, destTriggers := rm.triggers(destCatalog)
+	destParents := make(map[[2]string]bool)
+	for i := range destCatalog.Schemas {
+		schema := &destCatalog.Schemas[i]
+		for j := range schema.Tables {
+			if !schema.Tables[j].Ignore {
+				destParents[viewKey(schema.Tables[j].TableSchema, schema.Tables[j].TableName)] = true
+			}
+		}
+		for j := range schema.Views {
+			if !schema.Views[j].Ignore {
+				destParents[viewKey(schema.Views[j].ViewSchema, schema.Views[j].ViewName)] = true
+			}
+		}
+	}
+	isCreated := make(map[*Trigger]bool)
+	for _, key := range srcKeys3 {
+		srcTrigger := srcTriggers[key]
+		parent := [2]string{key[0], key[1]}
+		destTrigger := destTriggers[key]
+		if destTrigger == nil {
+			// A trigger is only dropped if the table or view it is on still
+			// exists, otherwise it is dropped together with its table or
+			// view.
+			destSchema := destCache.GetSchema(destCatalog, srcTrigger.TableSchema)
+			if dropObjects && destSchema != nil && destSchema.TriggersValid && destParents[parent] {
+				rm.dropTriggers = append(rm.dropTriggers, srcTrigger)
+			} else {
+				rm.targetTriggers[parent] = append(rm.targetTriggers[parent], srcTrigger)
+			}
+			continue
+		}
+		if destTrigger.SQL == "" {
+			// The trigger's definition is unknown, keep its current
+			// definition.
+			rm.targetTriggers[parent] = append(rm.targetTriggers[parent], srcTrigger)
+			continue
+		}
+		if srcTrigger.SQL != "" && !triggersAreEqual(dialect, rm.currentSchema, srcTrigger, destTrigger) {
+			rm.dropTriggers = append(rm.dropTriggers, srcTrigger)
+			rm.createTriggers = append(rm.createTriggers, destTrigger)
+			isCreated[destTrigger] = true
+		}
+	}
+
+	// CREATE TRIGGER.
+	for _, key := range destKeys3 {
+		destTrigger := destTriggers[key]
+		if destTrigger.SQL == "" {
+			continue
+		}
+		parent := [2]string{key[0], key[1]}
+		rm.targetTriggers[parent] = append(rm.targetTriggers[parent], destTrigger)
+		if srcTriggers[key] == nil {
+			rm.createTriggers = append(rm.createTriggers, destTrigger)
+			isCreated[destTrigger] = true
+		}
+	}
+
+	// A view that is dropped and recreated loses its triggers, so they have
+	// to be recreated too.
+	if views != nil {
+		isDropped := make(map[[2]string]bool)
+		for _, view := range views.dropViews {
+			isDropped[viewKey(view.ViewSchema, view.ViewName)] = true
+		}
+		for _, view := range views.createViews {
+			key := viewKey(view.ViewSchema, view.ViewName)
+			if !isDropped[key] {
+				continue
+			}
+			for _, trigger := range rm.targetTriggers[key] {
+				if !isCreated[trigger] {
+					rm.createTriggers = append(rm.createTriggers, trigger)
+					isCreated[trigger] = true
+				}
+			}
+		}
+	}
+	return rm
+}
+
+// routines returns the functions and procedures in the catalog (keyed by
+// routineKey), together with their keys in catalog order. Other kinds of
+// routines (e.g. aggregate functions) are left out.
+func (rm *routineMigration) routines(catalog *Catalog) (keys [][4]string, routines map[[4]string]*Routine) {
+	routines = make(map[[4]string]*Routine)
+	for i := range catalog.Schemas {
+		schema := &catalog.Schemas[i]
+		if schema.Ignore {
+			continue
+		}
+		for j := range schema.Routines {
+			routine := &schema.Routines[j]
+			if routine.Ignore {
+				continue
+			}
+			routineType := strings.ToUpper(routine.RoutineType)
+			if routineType != "FUNCTION" && routineType != "PROCEDURE" {
+				continue
+			}
+			key := routineKey(rm.dialect, rm.currentSchema, routine)
+			if routines[key] == nil {
+				keys = append(keys, key)
+			}
+			routines[key] = routine
+		}
+	}
+	return keys, routines
+}
+
+// triggers returns the triggers in the catalog keyed by their {schema,
+// table, trigger} names, together with their keys in catalog order.
+func (rm *routineMigration) triggers(catalog *Catalog) (keys [][3]string, triggers map[[3]string]*Trigger) {
+	triggers = make(map[[3]string]*Trigger)
+	add := func(trigger *Trigger) {
+		if trigger.Ignore {
+			return
+		}
+		key := [3]string{strings.ToLower(trigger.TableSchema), strings.ToLower(trigger.TableName), strings.ToLower(trigger.TriggerName)}
+		if triggers[key] == nil {
+			keys = append(keys, key)
+		}
+		triggers[key] = trigger
+	}
+	for i := range catalog.Schemas {
+		schema := &catalog.Schemas[i]
+		if schema.Ignore {
+			continue
+		}
+		for j := range schema.Tables {
+			if schema.Tables[j].Ignore {
+				continue
+			}
+			for k := range schema.Tables[j].Triggers {
+				add(&schema.Tables[j].Triggers[k])
+			}
+		}
+		for j := range schema.Views {
+			if schema.Views[j].Ignore {
+				continue
+			}
+			for k := range schema.Views[j].Triggers {
+				add(&schema.Views[j].Triggers[k])
+			}
+		}
+	}
+	return keys, triggers
+}
+
+// rebuiltTableTriggers returns the triggers to recreate on a table that is
+// rebuilt (i.e. copied into a new table that replaces the old one), which
+// drops the triggers of the old table.
+func (rm *routineMigration) rebuiltTableTriggers(srcTable, destTable *Table) []*Trigger {
+	srcKey := viewKey(srcTable.TableSchema, srcTable.TableName)
+	destKey := viewKey(destTable.TableSchema, destTable.TableName)
+	triggers := rm.targetTriggers[destKey]
+	if srcKey != destKey {
+		triggers = append(triggers[:len(triggers):len(triggers)], rm.targetTriggers[srcKey]...)
+	}
+	return triggers
+}
+
+// callsRoutine reports if the SQL calls a routine with the same name as the
+// routine (in any schema, since the SQL may rely on the search path).
+func callsRoutine(sql string, routine *Routine) bool {
+	if sql == "" {
+		return false
+	}
+	re, err := regexp.Compile(`(?i)(^|[^\w$])` + regexp.QuoteMeta(routine.RoutineName) + `["\]` + "`" + `]?\s*\(`)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(sql)
+}
+
+// sortRoutines orders the routines so that every routine comes right after
+// the routines it calls, otherwise keeping their order. Routines that call
+// each other are left in their order.
+func sortRoutines(routines []*Routine) []*Routine {
+	sorted := make([]*Routine, 0, len(routines))
+	isVisited := make([]bool, len(routines))
+	var visit func(i int)
+	visit = func(i int) {
+		if isVisited[i] {
+			return
+		}
+		isVisited[i] = true
+		for j, callee := range routines {
+			if j != i && callsRoutine(routines[i].SQL, callee) {
+				visit(j)
+			}
+		}
+		sorted = append(sorted, routines[i])
+	}
+	for i := range routines {
+		visit(i)
+	}
+	return sorted
+}
+
+// splitTableRoutines splits the (sorted) routines into the routines called
+// by the domains and tables of the catalog, together with every routine
+// that those routines call, and the rest of the routines.
+func splitTableRoutines(catalog *Catalog, routines []*Routine) (tableRoutines, otherRoutines []*Routine) {
+	var exprs []string
+	for i := range catalog.Schemas {
+		schema := &catalog.Schemas[i]
+		if schema.Ignore {
+			continue
+		}
+		for j := range schema.Domains {
+			domain := &schema.Domains[j]
+			if domain.Ignore {
+				continue
+			}
+			exprs = append(exprs, domain.ColumnDefault)
+			exprs = append(exprs, domain.CheckExprs...)
+		}
+		for j := range schema.Tables {
+			table := &schema.Tables[j]
+			if table.Ignore {
+				continue
+			}
+			for k := range table.Columns {
+				exprs = append(exprs, table.Columns[k].ColumnDefault, table.Columns[k].GeneratedExpr)
+			}
+			for k := range table.Constraints {
+				constraint := &table.Constraints[k]
+				exprs = append(exprs, constraint.CheckExpr, constraint.ExclusionPredicate)
+				if constraint.ConstraintType == EXCLUDE {
+					exprs = append(exprs, constraint.Columns...)
+				}
+			}
+			for k := range table.Indexes {
+				exprs = append(exprs, table.Indexes[k].Predicate)
+				exprs = append(exprs, table.Indexes[k].Columns...)
+			}
+		}
+	}
+	sql := strings.Join(exprs, "\n")
+	isTableRoutine := make([]bool, len(routines))
+	for i, routine := range routines {
+		isTableRoutine[i] = callsRoutine(sql, routine)
+	}
+	// The routines are sorted, so the routines called by a table routine
+	// come before it.
+	for i := len(routines) - 1; i >= 0; i-- {
+		if !isTableRoutine[i] {
+			continue
+		}
+		for j := 0; j < i; j++ {
+			if callsRoutine(routines[i].SQL, routines[j]) {
+				isTableRoutine[j] = true
+			}
+		}
+	}
+	for i, routine := range routines {
+		if isTableRoutine[i] {
+			tableRoutines = append(tableRoutines, routine)
+		} else {
+			otherRoutines = append(otherRoutines, routine)
+		}
+	}
+	return tableRoutines, otherRoutines
+}
+
+// routineKey returns the key used to match up the routines of two catalogs.
+// Postgres routines may be overloaded so their arguments are part of the
+// key, and MySQL functions and procedures have separate namespaces.
+func routineKey(dialect, currentSchema string, routine *Routine) [4]string {
+	key := [4]string{strings.ToLower(routine.RoutineSchema), strings.ToLower(routine.RoutineName)}
+	switch dialect {
+	case DialectPostgres:
+		// Procedures report the IN mode of their arguments, functions don't.
+		stmts, _ := splitStatements(dialect, routine.IdentityArguments)
+		for _, stmt := range stmts {
+			tokens := make([]string, 0, len(stmt.tokens))
+			for i, token := range stmt.tokens {
+				if strings.EqualFold(token, "IN") && (i == 0 || stmt.tokens[i-1] == ",") {
+					continue
+				}
+				tokens = append(tokens, token)
+			}
+			key[3] = normalizeRoutineType(dialect, currentSchema, tokens)
+		}
+	case DialectMySQL:
+		key[2] = strings.ToUpper(routine.RoutineType)
+	}
+	return key
+}
+
+// routineOptionKeywords are the keywords that end the return type of a
+// function.
+var routineOptionKeywords = map[string]bool{
+	"AS": true, "BEGIN": true, "CALLED": true, "COMMENT": true, "CONTAINS": true,
+	"COST": true, "DETERMINISTIC": true, "EXTERNAL": true, "IMMUTABLE": true,
+	"LANGUAGE": true, "LEAKPROOF": true, "MODIFIES": true, "NO": true, "NOT": true,
+	"PARALLEL": true, "READS": true, "RETURN": true, "RETURNS": true, "ROWS": true,
+	"SECURITY": true, "SET": true, "SQL": true, "STABLE": true, "STRICT": true,
+	"SUPPORT": true, "TRANSFORM": true, "VOLATILE": true, "WINDOW": true, "WITH": true,
+}
+
+// routineStatement returns the CREATE statement of a routine or trigger
+// definition as a single statement, including the semicolons in its body.
+// Any DROP statements before it are skipped, and so are the trailing
+// semicolon and SQL Server GO batch separator.
+func routineStatement(dialect string, contents string) (lintStatement, bool) {
+	var stmt lintStatement
+	stmts, _ := splitStatements(dialect, contents)
+	for _, s := range stmts {
+		for i, batch := range splitBatches(contents, s) {
+			if len(stmt.tokens) == 0 {
+				// Skip the DROP statements, which may be separated from the
+				// CREATE statement by a GO instead of a semicolon.
+				if !matchKeywords(batch.tokens, "DROP") {
+					stmt = lintStatement{
+						line:    batch.line,
+						tokens:  append([]string(nil), batch.tokens...),
+						offsets: append([]int(nil), batch.offsets...),
+					}
+				}
+				continue
+			}
+			if i > 0 {
+				// Only one CREATE statement is allowed.
+				return lintStatement{}, false
+			}
+			// Put back the semicolon that ended the previous statement.
+			last := len(stmt.tokens) - 1
+			end := stmt.offsets[last] + len(stmt.tokens[last])
+			stmt.tokens = append(stmt.tokens, ";")
+			stmt.offsets = append(stmt.offsets, end+strings.IndexByte(contents[end:], ';'))
+			stmt.tokens = append(stmt.tokens, batch.tokens...)
+			stmt.offsets = append(stmt.offsets, batch.offsets...)
+		}
+	}
+	if !matchKeywords(stmt.tokens, "CREATE") {
+		return lintStatement{}, false
+	}
+	return stmt, true
+}
+
+// routineIdentityArguments returns the (normalized) arguments that identify
+// a Postgres routine, given the ranges of its argument tokens. Argument
+// defaults are left out, and so are the OUT arguments of functions.
+func routineIdentityArguments(dialect string, stmt lintStatement, routineType string, arguments [][2]int) string {
+	var tokens []string
+	for _, argument := range arguments {
+		argTokens := stmt.tokens[argument[0]:argument[1]]
+		if len(argTokens) > 0 && strings.EqualFold(argTokens[0], "IN") {
+			argTokens = argTokens[1:]
+		} else if len(argTokens) > 0 && strings.EqualFold(argTokens[0], "OUT") && routineType == "FUNCTION" {
+			continue
+		}
+		for i, token := range argTokens {
+			if token == "=" || strings.EqualFold(token, "DEFAULT") {
+				argTokens = argTokens[:i]
+				break
+			}
+		}
+		if len(argTokens) == 0 {
+			continue
+		}
+		if len(tokens) > 0 {
+			tokens = append(tokens, ",")
+		}
+		tokens = append(tokens, argTokens...)
+	}
+	return normalizeRoutineType(dialect, "", tokens)
+}
+
+// postgresTypeAliases maps the Postgres type aliases to the type names that
+// Postgres reports back.
+var postgresTypeAliases = map[string]string{
+	"int":         "integer",
+	"int4":        "integer",
+	"int8":        "bigint",
+	"int2":        "smallint",
+	"serial":      "integer",
+	"bigserial":   "bigint",
+	"bool":        "boolean",
+	"float8":      "double precision",
+	"float4":      "real",
+	"decimal":     "numeric",
+	"varchar":     "character varying",
+	"char":        "character",
+	"bpchar":      "character",
+	"timestamptz": "timestamp with time zone",
+	"timetz":      "time with time zone",
+}
+
+// mysqlTypeAliases maps the MySQL type aliases to the type names that MySQL
+// reports back.
+var mysqlTypeAliases = map[string]string{
+	"integer": "int",
+	"bool":    "tinyint(1)",
+	"boolean": "tinyint(1)",
+	"dec":     "decimal",
+	"fixed":   "decimal",
+	"numeric": "decimal",
+}
+
+// normalizeRoutineType normalizes the tokens of a type (or of a list of
+// arguments made up of names and types) so that it can be compared with the
+// type reported by the database. Type aliases are replaced by the type they
+// stand for and the type modifiers that the database drops are removed.
+func normalizeRoutineType(dialect, currentSchema string, tokens []string) string {
+	var words []string
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		if token[0] == '\'' || token[0] == '$' {
+			words = append(words, token)
+			continue
+		}
+		word := normalizeRoutineToken(currentSchema, token)
+		switch dialect {
+		case DialectPostgres:
+			// Argument and return types don't keep their type modifiers
+			// e.g. VARCHAR(255) is reported as character varying.
+			if word == "(" && i+2 < len(tokens) && isNumber(tokens[i+1]) {
+				j := i + 2
+				if j+1 < len(tokens) && tokens[j] == "," && isNumber(tokens[j+1]) {
+					j += 2
+				}
+				if j < len(tokens) && tokens[j] == ")" {
+					i = j
+					continue
+				}
+			}
+			if alias, ok := postgresTypeAliases[word]; ok {
+				word = alias
+			} else if (word == "timestamp" || word == "time") && !(i+1 < len(tokens) && (strings.EqualFold(tokens[i+1], "WITH") || strings.EqualFold(tokens[i+1], "WITHOUT"))) {
+				word += " without time zone"
+			}
+		case DialectMySQL:
+			if alias, ok := mysqlTypeAliases[word]; ok {
+				word = alias
+			}
+			// Integer display widths are deprecated and not reported back.
+			switch word {
+			case "int", "bigint", "smallint", "mediumint":
+				if i+3 < len(tokens) && tokens[i+1] == "(" && isNumber(tokens[i+2]) && tokens[i+3] == ")" {
+					i += 3
+				}
+			}
+		}
+		words = append(words, word)
+	}
+	var b strings.Builder
+	for i, word := range words {
+		if i > 0 {
+			switch word {
+			case "(", ")", ",", "[", "]":
+			default:
+				switch words[i-1] {
+				case "(", "[":
+				default:
+					b.WriteString(" ")
+				}
+			}
+		}
+		b.WriteString(word)
+	}
+	return b.String()
+}
+
+// isNumber reports whether the token is made up of digits only.
+func isNumber(token string) bool {
+	if token == "" {
+		return false
+	}
+	for i := 0; i < len(token); i++ {
+		if token[i] < '0' || token[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeRoutineToken lowercases a (non-literal) token and removes the
+// current schema from it if it is a qualified identifier.
+func normalizeRoutineToken(currentSchema string, token string) string {
+	if !(isIdentifierChar(token[0]) || token[0] == '"' || token[0] == '`' || token[0] == '[') {
+		return token
+	}
+	parts := splitIdentifier(token)
+	if len(parts) > 1 && currentSchema != "" && strings.EqualFold(parts[0], currentSchema) {
+		parts = parts[1:]
+	}
+	for i := range parts {
+		parts[i] = strings.ToLower(parts[i])
+	}
+	return strings.Join(parts, ".")
+}
+
+// routineWords returns the normalized tokens of a routine or trigger's SQL.
+// Comments and whitespace are ignored, keywords and identifiers are
+// lowercased, the current schema is removed from qualified identifiers and
+// the options that don't change the routine or trigger (OR REPLACE, OR
+// ALTER, DEFINER and IF NOT EXISTS) are left out.
+func routineWords(dialect, currentSchema string, sql string) []string {
+	stmt, ok := routineStatement(dialect, sql)
+	if !ok {
+		stmts, _ := splitStatements(dialect, sql)
+		for i, s := range stmts {
+			if i > 0 {
+				stmt.tokens = append(stmt.tokens, ";")
+			}
+			stmt.tokens = append(stmt.tokens, s.tokens...)
+		}
+	}
+	tokens := stmt.tokens
+	words := make([]string, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		if ok && i > 0 && len(words) == 1 {
+			switch {
+			case matchKeywords(tokens[i:], "OR", "REPLACE"), matchKeywords(tokens[i:], "OR", "ALTER"):
+				i++
+				continue
+			case strings.EqualFold(token, "DEFINER"):
+				for i+1 < len(tokens) && !strings.EqualFold(tokens[i+1], "FUNCTION") && !strings.EqualFold(tokens[i+1], "PROCEDURE") && !strings.EqualFold(tokens[i+1], "TRIGGER") {
+					i++
+				}
+				continue
+			}
+		}
+		if ok && len(words) > 0 && len(words) <= 3 && matchKeywords(tokens[i:], "IF", "NOT", "EXISTS") {
+			switch words[len(words)-1] {
+			case "function", "procedure", "trigger":
+				i += 2
+				continue
+			}
+		}
+		if token[0] == '\'' || token[0] == '$' {
+			words = append(words, token)
+			continue
+		}
+		word := normalizeRoutineToken(currentSchema, token)
+		if word == "procedure" && len(words) > 0 && words[len(words)-1] == "execute" {
+			// EXECUTE PROCEDURE is the older spelling of EXECUTE FUNCTION.
+			word = "function"
+		}
+		words = append(words, word)
+	}
+	return words
+}
+
+// routinesAreEqual reports if two routines with the same key have the same
+// definition.
+func routinesAreEqual(dialect, currentSchema string, srcRoutine, destRoutine *Routine) bool {
+	srcWords := routineWords(dialect, currentSchema, srcRoutine.SQL)
+	destWords := routineWords(dialect, currentSchema, destRoutine.SQL)
+	switch dialect {
+	case DialectPostgres:
+		if !routineReturnTypesAreEqual(dialect, currentSchema, srcRoutine, destRoutine) {
+			return false
+		}
+		// pg_get_functiondef() rearranges the routine's options, so only
+		// the options that matter are compared. The body is kept as it was
+		// written.
+		srcBody, srcOptions := postgresRoutineBody(srcWords)
+		destBody, destOptions := postgresRoutineBody(destWords)
+		if srcBody == "" || destBody == "" {
+			break
+		}
+		return srcBody == destBody && srcOptions == destOptions
+	case DialectMySQL:
+		if !routineReturnTypesAreEqual(dialect, currentSchema, srcRoutine, destRoutine) {
+			return false
+		}
+		srcParameters, ok1 := srcRoutine.Attrs["parameters"]
+		destParameters, ok2 := destRoutine.Attrs["parameters"]
+		if ok1 && ok2 && normalizeMySQLParameters(srcParameters) != normalizeMySQLParameters(destParameters) {
+			return false
+		}
+		for _, attr := range []string{"isDeterministic", "sqlDataAccess", "securityType"} {
+			srcValue, destValue := srcRoutine.Attrs[attr], destRoutine.Attrs[attr]
+			if srcValue != "" && destValue != "" && !strings.EqualFold(srcValue, destValue) {
+				return false
+			}
+		}
+		// MySQL only reports the body of a routine.
+		if len(srcWords) > 0 && srcWords[0] != "create" {
+			return hasWordsSuffix(destWords, srcWords)
+		}
+	}
+	return strings.Join(srcWords, " ") == strings.Join(destWords, " ")
+}
+
+// routineReturnTypesAreEqual reports if two functions return the same type.
+// The return types are considered equal if either of them is unknown.
+func routineReturnTypesAreEqual(dialect, currentSchema string, srcRoutine, destRoutine *Routine) bool {
+	srcReturnType := srcRoutine.Attrs["returnType"]
+	destReturnType := destRoutine.Attrs["returnType"]
+	if srcReturnType == "" || destReturnType == "" {
+		return true
+	}
+	srcStmts, _ := splitStatements(dialect, srcReturnType)
+	destStmts, _ := splitStatements(dialect, destReturnType)
+	if len(srcStmts) != 1 || len(destStmts) != 1 {
+		return srcReturnType == destReturnType
+	}
+	return normalizeRoutineType(dialect, currentSchema, srcStmts[0].tokens) == normalizeRoutineType(dialect, currentSchema, destStmts[0].tokens)
+}
+
+// normalizeMySQLParameters normalizes the parameters of a MySQL routine
+// (separated by "|") for comparison.
+func normalizeMySQLParameters(parameters string) string {
+	var b strings.Builder
+	for i, parameter := range strings.Split(parameters, "|") {
+		if i > 0 {
+			b.WriteString("|")
+		}
+		stmts, _ := splitStatements(DialectMySQL, parameter)
+		for _, stmt := range stmts {
+			b.WriteString(normalizeRoutineType(DialectMySQL, "", stmt.tokens))
+		}
+	}
+	return b.String()
+}
+
+// postgresRoutineBody returns the body of a Postgres routine (the contents
+// of its dollar-quoted or single-quoted string) together with the options
+// that change how it behaves. The body is empty if it is written in the
+// SQL-standard style (BEGIN ATOMIC ... END or RETURN expression).
+func postgresRoutineBody(words []string) (body, options string) {
+	var optionWords []string
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		switch {
+		case word == "as" && i+1 < len(words) && (words[i+1][0] == '$' || words[i+1][0] == '\''):
+			literal := words[i+1]
+			if literal[0] == '$' {
+				tagEnd := strings.IndexByte(literal[1:], '$') + 2
+				body = literal[tagEnd : len(literal)-tagEnd]
+			} else {
+				body = strings.ReplaceAll(literal[1:len(literal)-1], "''", "'")
+			}
+			i++
+		case word == "language" && i+1 < len(words):
+			optionWords = append(optionWords, "language "+strings.Trim(words[i+1], "'"))
+			i++
+		case word == "immutable", word == "stable", word == "strict", word == "leakproof":
+			optionWords = append(optionWords, word)
+		case word == "security" && i+1 < len(words) && words[i+1] == "definer":
+			optionWords = append(optionWords, "security definer")
+			i++
+		case word == "returns" && i+3 < len(words) && words[i+1] == "null" && words[i+2] == "on":
+			optionWords = append(optionWords, "strict")
+			i += 4
+		}
+	}
+	return strings.TrimSpace(body), strings.Join(optionWords, " ")
+}
+
+// hasWordsSuffix reports whether the words end with the suffix words.
+func hasWordsSuffix(words, suffix []string) bool {
+	if len(suffix) > len(words) {
+		return false
+	}
+	for i := range suffix {
+		if words[len(words)-len(suffix)+i] != suffix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// triggersAreEqual reports if two triggers with the same name on the same
+// table have the same definition.
+func triggersAreEqual(dialect, currentSchema string, srcTrigger, destTrigger *Trigger) bool {
+	srcWords := routineWords(dialect, currentSchema, srcTrigger.SQL)
+	destWords := routineWords(dialect, currentSchema, destTrigger.SQL)
+	switch dialect {
+	case DialectPostgres:
+		// pg_get_triggerdef() always spells out FOR EACH ROW or FOR EACH
+		// STATEMENT.
+		srcWords = postgresTriggerWords(srcWords)
+		destWords = postgresTriggerWords(destWords)
+	case DialectMySQL:
+		// MySQL only reports the body of a trigger.
+		if len(srcWords) > 0 && srcWords[0] != "create" {
+			for _, attr := range []string{"actionTiming", "eventManipulation"} {
+				srcValue, destValue := srcTrigger.Attrs[attr], destTrigger.Attrs[attr]
+				if srcValue != "" && destValue != "" && !strings.EqualFold(srcValue, destValue) {
+					return false
+				}
+			}
+			return hasWordsSuffix(destWords, srcWords)
+		}
+	}
+	return strings.Join(srcWords, " ") == strings.Join(destWords, " ")
+}
+
+// postgresTriggerWords adds the default FOR EACH STATEMENT to the words of a
+// Postgres trigger that doesn't specify it.
+func postgresTriggerWords(words []string) []string {
+	on := -1
+	for i, word := range words {
+		switch word {
+		case "on":
+			if on < 0 {
+				on = i
+			}
+		case "each":
+			return words
+		case "when", "execute":
+			if on >= 0 {
+				result := make([]string, 0, len(words)+3)
+				result = append(result, words[:i]...)
+				result = append(result, "for", "each", "statement")
+				return append(result, words[i:]...)
+			}
+		}
+	}
+	return words
+}
+
+// writeCreateRoutine writes the CREATE statement of a routine or trigger. If
+// replace is true, it replaces an existing routine or trigger of the same
+// name.
+func writeCreateRoutine(dialect string, buf *bytes.Buffer, sql string, replace bool) {
+	stmt, ok := routineStatement(dialect, sql)
+	if !ok {
+		buf.WriteString(strings.TrimSpace(sql) + "\n")
+		return
+	}
+	start := 1
+	if matchKeywords(stmt.tokens[1:], "OR", "REPLACE") || matchKeywords(stmt.tokens[1:], "OR", "ALTER") {
+		start = 3
+	}
+	last := len(stmt.tokens) - 1
+	definition := sql[stmt.offsets[start] : stmt.offsets[last]+len(stmt.tokens[last])]
+	switch dialect {
+	case DialectSQLServer:
+		// CREATE FUNCTION, CREATE PROCEDURE and CREATE TRIGGER must be the
+		// only statement in their batch, so they are executed dynamically.
+		stmt := "CREATE "
+		if replace {
+			stmt = "CREATE OR ALTER "
+		}
+		buf.WriteString("EXEC('" + strings.ReplaceAll(stmt+definition, "'", "''") + "');\n")
+	default:
+		buf.WriteString("CREATE ")
+		if replace {
+			buf.WriteString("OR REPLACE ")
+		}
+		buf.WriteString(definition + ";\n")
+	}
+}
+
+// canReplaceRoutine reports if the src routine can be replaced in-place by
+// the dest routine instead of being dropped and recreated.
+func canReplaceRoutine(dialect, currentSchema string, srcRoutine, destRoutine *Routine) bool {
+	switch dialect {
+	case DialectPostgres:
+		// CREATE OR REPLACE FUNCTION cannot change the return type of a
+		// function.
+		return strings.EqualFold(srcRoutine.RoutineType, destRoutine.RoutineType) &&
+			routineReturnTypesAreEqual(dialect, currentSchema, srcRoutine, destRoutine)
+	case DialectSQLServer:
+		return strings.EqualFold(srcRoutine.RoutineType, destRoutine.RoutineType)
+	default:
+		return false
+	}
+}
+
+// writeDropTriggers writes the DROP TRIGGER statements of the migration.
+func (rm *routineMigration) writeDropTriggers(buf *bytes.Buffer) {
+	for _, trigger := range rm.dropTriggers {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		rm.writeDropTrigger(buf, trigger)
+	}
+}
+
+// writeCreateTableRoutines writes the CREATE FUNCTION and CREATE PROCEDURE
+// statements of the routines called by the domains and tables.
+func (rm *routineMigration) writeCreateTableRoutines(buf *bytes.Buffer) {
+	rm.writeRoutines(buf, rm.tableRoutines)
+}
+
+// writeCreateRoutines writes the CREATE FUNCTION and CREATE PROCEDURE
+// statements of the rest of the routines.
+func (rm *routineMigration) writeCreateRoutines(buf *bytes.Buffer) {
+	rm.writeRoutines(buf, rm.createRoutines)
+}
+
+// writeRoutines writes the CREATE statements of the routines. A replaced
+// routine that cannot be replaced in-place is dropped first.
+func (rm *routineMigration) writeRoutines(buf *bytes.Buffer, routines []*Routine) {
+	for _, routine := range routines {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		srcRoutine := rm.replaceRoutines[routine]
+		replace := srcRoutine != nil && canReplaceRoutine(rm.dialect, rm.currentSchema, srcRoutine, routine)
+		if srcRoutine != nil && !replace {
+			rm.writeDropRoutine(buf, srcRoutine)
+		}
+		writeCreateRoutine(rm.dialect, buf, rm.routineSQL(routine), replace)
+	}
+}
+
+// writeCreateTriggers writes the CREATE TRIGGER statements of the migration.
+func (rm *routineMigration) writeCreateTriggers(buf *bytes.Buffer) {
+	for _, trigger := range rm.createTriggers {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		writeCreateRoutine(rm.dialect, buf, rm.triggerSQL(trigger), false)
+	}
+}
+
+// routineSQL returns the CREATE statement of a routine. MySQL only reports
+// the body of a routine, so the rest of the statement is rebuilt from the
+// routine's attributes.
+func (rm *routineMigration) routineSQL(routine *Routine) string {
+	if rm.dialect != DialectMySQL {
+		return routine.SQL
+	}
+	if _, ok := routineStatement(rm.dialect, routine.SQL); ok {
+		return routine.SQL
+	}
+	routineType := "FUNCTION"
+	if strings.EqualFold(routine.RoutineType, "PROCEDURE") {
+		routineType = "PROCEDURE"
+	}
+	var b strings.Builder
+	b.WriteString("CREATE " + routineType + " " + rm.objectName(routine.RoutineSchema, routine.RoutineName) + "(")
+	if parameters := routine.Attrs["parameters"]; parameters != "" {
+		b.WriteString(strings.ReplaceAll(parameters, "|", ", "))
+	}
+	b.WriteString(")")
+	if returnType := routine.Attrs["returnType"]; routineType == "FUNCTION" && returnType != "" {
+		b.WriteString(" RETURNS " + returnType)
+	}
+	if routine.Attrs["isDeterministic"] == "true" {
+		b.WriteString(" DETERMINISTIC")
+	}
+	if sqlDataAccess := routine.Attrs["sqlDataAccess"]; sqlDataAccess != "" && !strings.EqualFold(sqlDataAccess, "CONTAINS SQL") {
+		b.WriteString(" " + sqlDataAccess)
+	}
+	if strings.EqualFold(routine.Attrs["securityType"], "INVOKER") {
+		b.WriteString(" SQL SECURITY INVOKER")
+	}
+	b.WriteString("\n" + strings.TrimSpace(routine.SQL))
+	return b.String()
+}
+
+// triggerSQL returns the CREATE TRIGGER statement of a trigger. MySQL only
+// reports the body of a trigger, so the rest of the statement is rebuilt
+// from the trigger's attributes.
+func (rm *routineMigration) triggerSQL(trigger *Trigger) string {
+	if rm.dialect != DialectMySQL {
+		return trigger.SQL
+	}
+	if _, ok := routineStatement(rm.dialect, trigger.SQL); ok {
+		return trigger.SQL
+	}
+	return "CREATE TRIGGER " + rm.objectName(trigger.TableSchema, trigger.TriggerName) +
+		" " + strings.ToUpper(trigger.Attrs["actionTiming"]) + " " + strings.ToUpper(trigger.Attrs["eventManipulation"]) +
+		" ON " + rm.objectName(trigger.TableSchema, trigger.TableName) + " FOR EACH ROW\n" + strings.TrimSpace(trigger.SQL)
+}
+
+// writeDropRoutines writes the DROP FUNCTION and DROP PROCEDURE statements
+// of the migration.
+func (rm *routineMigration) writeDropRoutines(buf *bytes.Buffer) {
+	for _, routine := range rm.dropRoutines {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		rm.writeDropRoutine(buf, routine)
+	}
+}
+
+// writeUndoCreateRoutines writes the DROP statements that undo the routines
+// created (but not replaced) by the migration.
+func (rm *routineMigration) writeUndoCreateRoutines(buf *bytes.Buffer) {
+	for i := len(rm.createRoutines) - 1; i >= 0; i-- {
+		routine := rm.createRoutines[i]
+		if rm.replaceRoutines[routine] != nil {
+			continue
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		rm.writeDropRoutine(buf, routine)
+	}
+}
+
+// writeUndoCreateTriggers writes the DROP TRIGGER statements that undo the
+// triggers created by the migration.
+func (rm *routineMigration) writeUndoCreateTriggers(buf *bytes.Buffer) {
+	for i := len(rm.createTriggers) - 1; i >= 0; i-- {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		rm.writeDropTrigger(buf, rm.createTriggers[i])
+	}
+}
+
+func (rm *routineMigration) writeDropRoutine(buf *bytes.Buffer, routine *Routine) {
+	routineType := "FUNCTION"
+	if strings.EqualFold(routine.RoutineType, "PROCEDURE") {
+		routineType = "PROCEDURE"
+	}
+	buf.WriteString("DROP " + routineType + " IF EXISTS " + rm.objectName(routine.RoutineSchema, routine.RoutineName))
+	if rm.dialect == DialectPostgres {
+		buf.WriteString("(" + routine.IdentityArguments + ")")
+	}
+	buf.WriteString(";\n")
+}
+
+func (rm *routineMigration) writeDropTrigger(buf *bytes.Buffer, trigger *Trigger) {
+	switch rm.dialect {
+	case DialectPostgres:
+		buf.WriteString("DROP TRIGGER IF EXISTS " + QuoteIdentifier(rm.dialect, trigger.TriggerName) + " ON " + rm.objectName(trigger.TableSchema, trigger.TableName) + ";\n")
+	default:
+		buf.WriteString("DROP TRIGGER IF EXISTS " + rm.objectName(trigger.TableSchema, trigger.TriggerName) + ";\n")
+	}
+}
+
+func (rm *routineMigration) objectName(schemaName, name string) string {
+	objectName := QuoteIdentifier(rm.dialect, name)
+	if schemaName != "" && schemaName != rm.currentSchema {
+		objectName = QuoteIdentifier(rm.dialect, schemaName) + "." + objectName
+	}
+	return objectName
+}
+
+// undoDropTriggers returns the undo scope of the DROP TRIGGER statements.
+func (rm *routineMigration) undoDropTriggers() undoScope {
+	return undoScope{srcTriggers: rm.dropTriggers}
+}
+
+// undoCreateTableRoutines returns the undo scope of the CREATE FUNCTION and
+// CREATE PROCEDURE statements of the routines called by the domains and
+// tables.
+func (rm *routineMigration) undoCreateTableRoutines() undoScope {
+	return rm.undoRoutines(rm.tableRoutines)
+}
+
+// undoCreateRoutines returns the undo scope of the CREATE FUNCTION and
+// CREATE PROCEDURE statements of the rest of the routines.
+func (rm *routineMigration) undoCreateRoutines() undoScope {
+	return rm.undoRoutines(rm.createRoutines)
+}
+
+// undoRoutines returns the undo scope of creating the routines.
+func (rm *routineMigration) undoRoutines(routines []*Routine) undoScope {
+	scope := undoScope{destRoutines: routines}
+	for _, routine := range routines {
+		if srcRoutine := rm.replaceRoutines[routine]; srcRoutine != nil {
+			scope.srcRoutines = append(scope.srcRoutines, srcRoutine)
+		}
+	}
+	return scope
+}
+
+// undoCreateTriggers returns the undo scope of the CREATE TRIGGER
+// statements.
+func (rm *routineMigration) undoCreateTriggers() undoScope {
+	return undoScope{destTriggers: rm.createTriggers}
+}
+
+// undoDropRoutines returns the undo scope of the DROP FUNCTION and DROP
+// PROCEDURE statements.
+func (rm *routineMigration) undoDropRoutines() undoScope {
+	return undoScope{srcRoutines: rm.dropRoutines}
+}
+
+// hasDropTriggers reports if the migration drops any triggers.
+func (rm *routineMigration) hasDropTriggers() bool {
+	return len(rm.dropTriggers) > 0
+}
+
+// hasCreateTableRoutines reports if the migration creates or replaces any
+// routines called by the domains and tables.
+func (rm *routineMigration) hasCreateTableRoutines() bool {
+	return len(rm.tableRoutines) > 0
+}
+
+// hasCreateRoutines reports if the migration creates or replaces any other
+// routines.
+func (rm *routineMigration) hasCreateRoutines() bool {
+	return len(rm.createRoutines) > 0
+}
+
+// hasCreateTriggers reports if the migration creates any triggers.
+func (rm *routineMigration) hasCreateTriggers() bool {
+	return len(rm.createTriggers) > 0
+}
+
+// hasDropRoutines reports if the migration drops any routines.
+func (rm *routineMigration) hasDropRoutines() bool {
+	return len(rm.dropRoutines) > 0
+}
@@ -0,0 +1,642 @@
+package ddl
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/blink-io/sqddl/internal/testutil"
+)
+
+func Test_routineMigration(t *testing.T) {
+	type TT struct {
+		description string
+		dialect     string
+		srcCatalog  *Catalog
+		destCatalog *Catalog
+		dropObjects bool
+		wantFiles   map[string]string
+	}
+
+	actorTable := func(schemaName, columnType string, triggers ...Trigger) Table {
+		return Table{
+			TableSchema: schemaName,
+			TableName:   "actor",
+			Columns: []Column{
+				{TableSchema: schemaName, TableName: "actor", ColumnName: "actor_id", ColumnType: "INT"},
+				{TableSchema: schemaName, TableName: "actor", ColumnName: "last_update", ColumnType: columnType},
+			},
+			Triggers: triggers,
+		}
+	}
+
+	tests := []TT{{
+		description: "postgres unchanged routines and triggers",
+		dialect:     DialectPostgres,
+		srcCatalog: &Catalog{CurrentSchema: "public", Schemas: []Schema{{
+			SchemaName: "public",
+			Tables: []Table{actorTable("public", "TIMESTAMPTZ", Trigger{
+				TableSchema: "public",
+				TableName:   "actor",
+				TriggerName: "actor_last_update_trg",
+				SQL:         "CREATE TRIGGER actor_last_update_trg BEFORE UPDATE ON actor FOR EACH ROW EXECUTE FUNCTION last_update_trg();",
+			})},
+			Routines: []Routine{{
+				RoutineSchema: "public",
+				RoutineName:   "last_update_trg",
+				RoutineType:   "FUNCTION",
+				SQL:           "CREATE OR REPLACE FUNCTION public.last_update_trg()\n RETURNS trigger\n LANGUAGE plpgsql\nAS $function$ BEGIN\n    NEW.last_update = NOW();\n    RETURN NEW;\nEND; $function$\n;",
+				Attrs:         map[string]string{"returnType": "trigger"},
+			}, {
+				RoutineSchema:     "public",
+				RoutineName:       "film_in_stock",
+				RoutineType:       "PROCEDURE",
+				IdentityArguments: "IN p_film_id integer, OUT p_film_count integer",
+				SQL:               "CREATE OR REPLACE PROCEDURE public.film_in_stock(IN p_film_id integer, OUT p_film_count integer)\n LANGUAGE sql\nAS $procedure$ SELECT 1; $procedure$\n;",
+			}},
+			RoutinesValid: true,
+			TriggersValid: true,
+		}}},
+		destCatalog: &Catalog{CurrentSchema: "public", Schemas: []Schema{{
+			SchemaName: "public",
+			Tables: []Table{actorTable("public", "TIMESTAMPTZ", Trigger{
+				TableSchema: "public",
+				TableName:   "actor",
+				TriggerName: "actor_last_update_trg",
+				SQL:         "CREATE TRIGGER actor_last_update_trg BEFORE UPDATE ON public.actor\nFOR EACH ROW EXECUTE PROCEDURE last_update_trg();",
+			})},
+			Routines: []Routine{{
+				RoutineSchema: "public",
+				RoutineName:   "last_update_trg",
+				RoutineType:   "FUNCTION",
+				SQL:           "CREATE OR REPLACE FUNCTION last_update_trg() RETURNS TRIGGER AS $$ BEGIN\n    NEW.last_update = NOW();\n    RETURN NEW;\nEND; $$ LANGUAGE plpgsql;",
+				Attrs:         map[string]string{"returnType": "TRIGGER"},
+			}, {
+				RoutineSchema:     "public",
+				RoutineName:       "film_in_stock",
+				RoutineType:       "PROCEDURE",
+				IdentityArguments: "p_film_id int, OUT p_film_count int",
+				SQL:               "CREATE PROCEDURE film_in_stock(p_film_id INT, OUT p_film_count INT) AS $$ SELECT 1; $$ LANGUAGE sql;",
+			}},
+			RoutinesValid: true,
+			TriggersValid: true,
+		}}},
+		dropObjects: true,
+		wantFiles:   map[string]string{},
+	}, {
+		description: "postgres changed routines and triggers",
+		dialect:     DialectPostgres,
+		srcCatalog: &Catalog{CurrentSchema: "public", Schemas: []Schema{{
+			SchemaName: "public",
+			Tables: []Table{actorTable("public", "TIMESTAMPTZ", Trigger{
+				TableSchema: "public",
+				TableName:   "actor",
+				TriggerName: "actor_last_update_trg",
+				SQL:         "CREATE TRIGGER actor_last_update_trg BEFORE UPDATE ON actor FOR EACH ROW EXECUTE FUNCTION last_update_trg();",
+			}, Trigger{
+				TableSchema: "public",
+				TableName:   "actor",
+				TriggerName: "actor_audit_trg",
+				SQL:         "CREATE TRIGGER actor_audit_trg AFTER UPDATE ON actor FOR EACH ROW EXECUTE FUNCTION audit_trg();",
+			})},
+			Routines: []Routine{{
+				RoutineSchema: "public",
+				RoutineName:   "last_update_trg",
+				RoutineType:   "FUNCTION",
+				SQL:           "CREATE OR REPLACE FUNCTION public.last_update_trg()\n RETURNS trigger\n LANGUAGE plpgsql\nAS $function$ BEGIN NEW.last_update = NOW(); RETURN NEW; END; $function$\n;",
+				Attrs:         map[string]string{"returnType": "trigger"},
+			}, {
+				RoutineSchema:     "public",
+				RoutineName:       "actor_count",
+				RoutineType:       "FUNCTION",
+				IdentityArguments: "",
+				SQL:               "CREATE OR REPLACE FUNCTION public.actor_count()\n RETURNS integer\n LANGUAGE sql\nAS $function$ SELECT count(*) FROM actor $function$\n;",
+				Attrs:             map[string]string{"returnType": "integer"},
+			}, {
+				RoutineSchema: "public",
+				RoutineName:   "audit_trg",
+				RoutineType:   "FUNCTION",
+				SQL:           "CREATE OR REPLACE FUNCTION public.audit_trg()\n RETURNS trigger\n LANGUAGE plpgsql\nAS $function$ BEGIN RETURN NEW; END; $function$\n;",
+				Attrs:         map[string]string{"returnType": "trigger"},
+			}},
+			RoutinesValid: true,
+			TriggersValid: true,
+		}}},
+		destCatalog: &Catalog{CurrentSchema: "public", Schemas: []Schema{{
+			SchemaName: "public",
+			Tables: []Table{actorTable("public", "TIMESTAMPTZ", Trigger{
+				TableSchema: "public",
+				TableName:   "actor",
+				TriggerName: "actor_last_update_trg",
+				SQL:         "CREATE TRIGGER actor_last_update_trg BEFORE INSERT OR UPDATE ON actor FOR EACH ROW EXECUTE FUNCTION last_update_trg();",
+			})},
+			Routines: []Routine{{
+				RoutineSchema: "public",
+				RoutineName:   "last_update_trg",
+				RoutineType:   "FUNCTION",
+				SQL:           "CREATE FUNCTION last_update_trg() RETURNS trigger AS $$ BEGIN NEW.last_update = CURRENT_TIMESTAMP; RETURN NEW; END; $$ LANGUAGE plpgsql;",
+				Attrs:         map[string]string{"returnType": "trigger"},
+			}, {
+				RoutineSchema: "public",
+				RoutineName:   "actor_count",
+				RoutineType:   "FUNCTION",
+				SQL:           "CREATE FUNCTION actor_count() RETURNS bigint AS $$ SELECT count(*) FROM actor $$ LANGUAGE sql;",
+				Attrs:         map[string]string{"returnType": "bigint"},
+			}},
+			RoutinesValid: true,
+			TriggersValid: true,
+		}}},
+		dropObjects: true,
+		wantFiles: map[string]string{
+			"r_01_drop_triggers.sql": "DROP TRIGGER IF EXISTS actor_last_update_trg ON actor;\n" +
+				"\nDROP TRIGGER IF EXISTS actor_audit_trg ON actor;\n",
+			"r_02_routines.sql": "CREATE OR REPLACE FUNCTION last_update_trg() RETURNS trigger AS $$ BEGIN NEW.last_update = CURRENT_TIMESTAMP; RETURN NEW; END; $$ LANGUAGE plpgsql;\n" +
+				"\nDROP FUNCTION IF EXISTS actor_count();\n" +
+				"CREATE FUNCTION actor_count() RETURNS bigint AS $$ SELECT count(*) FROM actor $$ LANGUAGE sql;\n",
+			"r_03_triggers.sql":      "CREATE TRIGGER actor_last_update_trg BEFORE INSERT OR UPDATE ON actor FOR EACH ROW EXECUTE FUNCTION last_update_trg();\n",
+			"r_04_drop_routines.sql": "DROP FUNCTION IF EXISTS audit_trg();\n",
+		},
+	}, {
+		description: "postgres routines not dropped if RoutinesValid is false",
+		dialect:     DialectPostgres,
+		srcCatalog: &Catalog{CurrentSchema: "public", Schemas: []Schema{{
+			SchemaName: "public",
+			Tables: []Table{actorTable("public", "TIMESTAMPTZ", Trigger{
+				TableSchema: "public",
+				TableName:   "actor",
+				TriggerName: "actor_audit_trg",
+				SQL:         "CREATE TRIGGER actor_audit_trg AFTER UPDATE ON actor FOR EACH ROW EXECUTE FUNCTION audit_trg();",
+			})},
+			Routines: []Routine{{
+				RoutineSchema: "public",
+				RoutineName:   "audit_trg",
+				RoutineType:   "FUNCTION",
+				SQL:           "CREATE OR REPLACE FUNCTION public.audit_trg()\n RETURNS trigger\n LANGUAGE plpgsql\nAS $function$ BEGIN RETURN NEW; END; $function$\n;",
+				Attrs:         map[string]string{"returnType": "trigger"},
+			}},
+		}}},
+		destCatalog: &Catalog{CurrentSchema: "public", Schemas: []Schema{{
+			SchemaName: "public",
+			Tables:     []Table{actorTable("public", "TIMESTAMPTZ")},
+		}}},
+		dropObjects: true,
+		wantFiles:   map[string]string{},
+	}, {
+		description: "mysql changed routines and triggers",
+		dialect:     DialectMySQL,
+		srcCatalog: &Catalog{CurrentSchema: "sakila", Schemas: []Schema{{
+			SchemaName: "sakila",
+			Tables: []Table{actorTable("sakila", "DATETIME", Trigger{
+				TableSchema: "sakila",
+				TableName:   "actor",
+				TriggerName: "actor_before_update_trg",
+				SQL:         "SET NEW.last_update = NOW()",
+				Attrs:       map[string]string{"actionTiming": "BEFORE", "eventManipulation": "UPDATE"},
+			})},
+			Routines: []Routine{{
+				RoutineSchema: "sakila",
+				RoutineName:   "actor_count",
+				RoutineType:   "FUNCTION",
+				SQL:           "BEGIN\n    RETURN (SELECT COUNT(*) FROM actor);\nEND",
+				Attrs: map[string]string{
+					"returnType":      "int",
+					"parameters":      "",
+					"isDeterministic": "false",
+					"sqlDataAccess":   "READS SQL DATA",
+					"securityType":    "DEFINER",
+				},
+			}, {
+				RoutineSchema: "sakila",
+				RoutineName:   "touch_actor",
+				RoutineType:   "PROCEDURE",
+				SQL:           "BEGIN\n    UPDATE actor SET last_update = NOW() WHERE actor_id = p_actor_id;\nEND",
+				Attrs: map[string]string{
+					"returnType":      "",
+					"parameters":      "IN p_actor_id int",
+					"isDeterministic": "false",
+					"sqlDataAccess":   "MODIFIES SQL DATA",
+					"securityType":    "DEFINER",
+				},
+			}},
+			RoutinesValid: true,
+			TriggersValid: true,
+		}}},
+		destCatalog: &Catalog{CurrentSchema: "sakila", Schemas: []Schema{{
+			SchemaName: "sakila",
+			Tables: []Table{actorTable("sakila", "DATETIME", Trigger{
+				TableSchema: "sakila",
+				TableName:   "actor",
+				TriggerName: "actor_before_update_trg",
+				SQL:         "CREATE TRIGGER actor_before_update_trg BEFORE UPDATE ON actor FOR EACH ROW SET NEW.last_update = NOW();",
+				Attrs:       map[string]string{"actionTiming": "BEFORE", "eventManipulation": "UPDATE"},
+			})},
+			Routines: []Routine{{
+				RoutineSchema: "sakila",
+				RoutineName:   "actor_count",
+				RoutineType:   "FUNCTION",
+				SQL:           "CREATE FUNCTION actor_count() RETURNS INT\nREADS SQL DATA\nBEGIN\n    RETURN (SELECT COUNT(*) FROM actor);\nEND;",
+				Attrs: map[string]string{
+					"returnType":      "INT",
+					"parameters":      "",
+					"isDeterministic": "false",
+					"sqlDataAccess":   "READS SQL DATA",
+					"securityType":    "DEFINER",
+				},
+			}, {
+				RoutineSchema: "sakila",
+				RoutineName:   "touch_actor",
+				RoutineType:   "PROCEDURE",
+				SQL:           "CREATE PROCEDURE touch_actor(IN p_actor_id INT(11))\nMODIFIES SQL DATA\nBEGIN\n    UPDATE actor SET last_update = CURRENT_TIMESTAMP WHERE actor_id = p_actor_id;\nEND;",
+				Attrs: map[string]string{
+					"returnType":      "",
+					"parameters":      "IN p_actor_id int(11)",
+					"isDeterministic": "false",
+					"sqlDataAccess":   "MODIFIES SQL DATA",
+					"securityType":    "DEFINER",
+				},
+			}},
+			RoutinesValid: true,
+			TriggersValid: true,
+		}}},
+		dropObjects: true,
+		wantFiles: map[string]string{
+			"r_01_routines.sql": "DROP PROCEDURE IF EXISTS touch_actor;\n" +
+				"CREATE PROCEDURE touch_actor(IN p_actor_id INT(11))\nMODIFIES SQL DATA\nBEGIN\n    UPDATE actor SET last_update = CURRENT_TIMESTAMP WHERE actor_id = p_actor_id;\nEND;\n",
+		},
+	}, {
+		description: "sqlserver changed routines",
+		dialect:     DialectSQLServer,
+		srcCatalog: &Catalog{CurrentSchema: "dbo", Schemas: []Schema{{
+			SchemaName: "dbo",
+			Tables:     []Table{actorTable("dbo", "DATETIME")},
+			Routines: []Routine{{
+				RoutineSchema: "dbo",
+				RoutineName:   "actor_count",
+				RoutineType:   "FUNCTION",
+				SQL:           "CREATE FUNCTION actor_count() RETURNS INT AS BEGIN RETURN (SELECT COUNT(*) FROM actor) END",
+			}},
+			RoutinesValid: true,
+			TriggersValid: true,
+		}}},
+		destCatalog: &Catalog{CurrentSchema: "dbo", Schemas: []Schema{{
+			SchemaName: "dbo",
+			Tables: []Table{actorTable("dbo", "DATETIME", Trigger{
+				TableSchema: "dbo",
+				TableName:   "actor",
+				TriggerName: "actor_last_update_trg",
+				SQL:         "CREATE OR ALTER TRIGGER actor_last_update_trg ON actor AFTER UPDATE AS\nUPDATE actor SET last_update = CURRENT_TIMESTAMP FROM actor JOIN INSERTED ON INSERTED.actor_id = actor.actor_id;",
+			})},
+			Routines: []Routine{{
+				RoutineSchema: "dbo",
+				RoutineName:   "actor_count",
+				RoutineType:   "FUNCTION",
+				SQL:           "CREATE OR ALTER FUNCTION actor_count() RETURNS INT AS BEGIN RETURN (SELECT COUNT(*) FROM actor WHERE name <> 'x') END\nGO",
+			}},
+			RoutinesValid: true,
+			TriggersValid: true,
+		}}},
+		dropObjects: true,
+		wantFiles: map[string]string{
+			"r_01_routines.sql": "EXEC('CREATE OR ALTER FUNCTION actor_count() RETURNS INT AS BEGIN RETURN (SELECT COUNT(*) FROM actor WHERE name <> ''x'') END');\n",
+			"r_02_triggers.sql": "EXEC('CREATE TRIGGER actor_last_update_trg ON actor AFTER UPDATE AS\nUPDATE actor SET last_update = CURRENT_TIMESTAMP FROM actor JOIN INSERTED ON INSERTED.actor_id = actor.actor_id');\n",
+		},
+	}, {
+		description: "postgres routines called by tables",
+		dialect:     DialectPostgres,
+		srcCatalog:  &Catalog{CurrentSchema: "public"},
+		destCatalog: &Catalog{CurrentSchema: "public", Schemas: []Schema{{
+			SchemaName: "public",
+			Tables: []Table{{
+				TableSchema: "public",
+				TableName:   "film",
+				Columns: []Column{
+					{TableSchema: "public", TableName: "film", ColumnName: "film_id", ColumnType: "INT", ColumnDefault: "next_film_id()"},
+					{TableSchema: "public", TableName: "film", ColumnName: "title", ColumnType: "TEXT"},
+				},
+				Constraints: []Constraint{
+					{TableSchema: "public", TableName: "film", ConstraintName: "film_title_check", ConstraintType: CHECK, Columns: []string{"title"}, CheckExpr: "public.is_title(title)"},
+				},
+			}},
+			Routines: []Routine{{
+				RoutineSchema: "public",
+				RoutineName:   "film_count",
+				RoutineType:   "FUNCTION",
+				SQL:           "CREATE FUNCTION film_count() RETURNS BIGINT LANGUAGE sql AS $$ SELECT COUNT(*) FROM film $$;",
+			}, {
+				RoutineSchema: "public",
+				RoutineName:   "next_film_id",
+				RoutineType:   "FUNCTION",
+				SQL:           "CREATE FUNCTION next_film_id() RETURNS INT LANGUAGE sql AS $$ SELECT film_id_base() + 1 $$;",
+			}, {
+				RoutineSchema: "public",
+				RoutineName:   "film_id_base",
+				RoutineType:   "FUNCTION",
+				SQL:           "CREATE FUNCTION film_id_base() RETURNS INT LANGUAGE sql AS $$ SELECT 1000 $$;",
+			}, {
+				RoutineSchema: "public",
+				RoutineName:   "is_title",
+				RoutineType:   "FUNCTION",
+				SQL:           "CREATE FUNCTION is_title(t TEXT) RETURNS BOOLEAN LANGUAGE sql IMMUTABLE AS $$ SELECT t <> '' $$;",
+			}},
+			RoutinesValid: true,
+		}}},
+		wantFiles: map[string]string{
+			"r_01_schemas.sql": "CREATE SCHEMA IF NOT EXISTS public;\n",
+			"r_02_routines.sql": "CREATE FUNCTION film_id_base() RETURNS INT LANGUAGE sql AS $$ SELECT 1000 $$;\n" +
+				"\nCREATE FUNCTION next_film_id() RETURNS INT LANGUAGE sql AS $$ SELECT film_id_base() + 1 $$;\n" +
+				"\nCREATE FUNCTION is_title(t TEXT) RETURNS BOOLEAN LANGUAGE sql IMMUTABLE AS $$ SELECT t <> '' $$;\n",
+			"r_03_tables.sql":   "CREATE TABLE film (\n    film_id INT DEFAULT next_film_id()\n    ,title TEXT\n\n    ,CONSTRAINT film_title_check CHECK (public.is_title(title))\n);\n",
+			"r_04_routines.sql": "CREATE FUNCTION film_count() RETURNS BIGINT LANGUAGE sql AS $$ SELECT COUNT(*) FROM film $$;\n",
+		},
+	}, {
+		description: "sqlite copied table keeps its triggers",
+		dialect:     DialectSQLite,
+		srcCatalog: &Catalog{Schemas: []Schema{{
+			Tables: []Table{actorTable("", "INT", Trigger{
+				TableName:   "actor",
+				TriggerName: "actor_last_update_trg",
+				SQL:         "CREATE TRIGGER actor_last_update_trg AFTER UPDATE ON actor BEGIN\n    UPDATE actor SET last_update = unixepoch() WHERE ROWID = NEW.ROWID;\nEND;",
+			}, Trigger{
+				TableName:   "actor",
+				TriggerName: "actor_audit_trg",
+				SQL:         "CREATE TRIGGER actor_audit_trg AFTER DELETE ON actor BEGIN\n    SELECT 1;\nEND;",
+			})},
+			RoutinesValid: true,
+			TriggersValid: true,
+		}}},
+		destCatalog: &Catalog{Schemas: []Schema{{
+			Tables: []Table{actorTable("", "TEXT", Trigger{
+				TableName:   "actor",
+				TriggerName: "actor_last_update_trg",
+				SQL:         "CREATE TRIGGER actor_last_update_trg AFTER UPDATE ON actor BEGIN\n    UPDATE actor SET last_update = CURRENT_TIMESTAMP WHERE ROWID = NEW.ROWID;\nEND;",
+			}, Trigger{
+				TableName:   "actor",
+				TriggerName: "actor_insert_trg",
+				SQL:         "CREATE TRIGGER actor_insert_trg AFTER INSERT ON actor BEGIN\n    SELECT 1;\nEND;",
+			})},
+		}}},
+		dropObjects: true,
+		wantFiles: map[string]string{
+			"r.sql": "DROP TRIGGER IF EXISTS actor_last_update_trg;\n" +
+				"\nPRAGMA legacy_alter_table = ON;\n" +
+				"\nCREATE TABLE actor_new (\n    actor_id INT\n    ,last_update TEXT\n);\n" +
+				"INSERT INTO actor_new\n    (actor_id, last_update)\nSELECT\n    actor_id, last_update\nFROM\n    actor\n;\n" +
+				"DROP TABLE actor;\n" +
+				"ALTER TABLE actor_new RENAME TO actor;\n" +
+				"\nCREATE TRIGGER actor_audit_trg AFTER DELETE ON actor BEGIN\n    SELECT 1;\nEND;\n" +
+				"\nCREATE TRIGGER actor_last_update_trg AFTER UPDATE ON actor BEGIN\n    UPDATE actor SET last_update = CURRENT_TIMESTAMP WHERE ROWID = NEW.ROWID;\nEND;\n" +
+				"\nCREATE TRIGGER actor_insert_trg AFTER INSERT ON actor BEGIN\n    SELECT 1;\nEND;\n" +
+				"\nPRAGMA legacy_alter_table = OFF;\n",
+		},
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.description, func(t *testing.T) {
+			t.Parallel()
+			var filenames []string
+			var bufs []*bytes.Buffer
+			switch tt.dialect {
+			case DialectSQLite:
+				m := newSQLiteMigration(tt.srcCatalog, tt.destCatalog, tt.dropObjects)
+				filenames, bufs, _ = m.sql("r")
+			case DialectPostgres:
+				m := newPostgresMigration(tt.srcCatalog, tt.destCatalog, tt.dropObjects)
+				filenames, bufs, _ = m.sql("r")
+			case DialectMySQL:
+				m := newMySQLMigration(tt.srcCatalog, tt.destCatalog, tt.dropObjects)
+				filenames, bufs, _ = m.sql("r")
+			case DialectSQLServer:
+				m := newSQLServerMigration(tt.srcCatalog, tt.destCatalog, tt.dropObjects)
+				filenames, bufs, _ = m.sql("r")
+			}
+			gotFiles := make(map[string]string)
+			for i, filename := range filenames {
+				if strings.HasSuffix(filename, ".undo.sql") {
+					continue
+				}
+				gotFiles[filename] = bufs[i].String()
+			}
+			if diff := testutil.Diff(gotFiles, tt.wantFiles); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+		})
+	}
+}
+
+func Test_routineMigration_repeatable(t *testing.T) {
+	// The routines and triggers defined by the repeatable migrations should
+	// match the ones introspected from the database they were run against.
+	tests := []struct {
+		dialect string
+		dirs    []string
+	}{
+		{DialectSQLite, []string{"sqlite_migrations/repeatable/triggers"}},
+		{DialectPostgres, []string{"postgres_migrations/repeatable/functions", "postgres_migrations/repeatable/triggers"}},
+		{DialectMySQL, []string{"mysql_migrations/repeatable/functions"}},
+		{DialectSQLServer, []string{"sqlserver_migrations/repeatable/functions", "sqlserver_migrations/repeatable/triggers"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.dialect, func(t *testing.T) {
+			t.Parallel()
+			b, err := os.ReadFile("testdata/" + tt.dialect + "/schema.json")
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			srcCatalog, destCatalog := &Catalog{}, &Catalog{}
+			for _, catalog := range []*Catalog{srcCatalog, destCatalog} {
+				err = json.Unmarshal(b, catalog)
+				if err != nil {
+					t.Fatal(testutil.Callers(), err)
+				}
+			}
+			for i := range destCatalog.Schemas {
+				schema := &destCatalog.Schemas[i]
+				schema.Routines = nil
+				for j := range schema.Tables {
+					schema.Tables[j].Triggers = nil
+				}
+				for j := range schema.Views {
+					schema.Views[j].Triggers = nil
+				}
+			}
+			for _, dir := range tt.dirs {
+				err = writeRoutines(destCatalog, dirFS("."), dir)
+				if err != nil {
+					t.Fatal(testutil.Callers(), err)
+				}
+			}
+			rm := newRoutineMigration(tt.dialect, srcCatalog, destCatalog, false, nil)
+			if len(rm.createRoutines) > 0 || len(rm.createTriggers) > 0 || len(rm.dropTriggers) > 0 {
+				var buf bytes.Buffer
+				rm.writeDropTriggers(&buf)
+				rm.writeCreateRoutines(&buf)
+				rm.writeCreateTriggers(&buf)
+				t.Errorf(testutil.Callers()+" expected no changes, got:\n%s", buf.String())
+			}
+		})
+	}
+}
+
+func Test_routineStatement(t *testing.T) {
+	type TT struct {
+		dialect string
+		sql     string
+		want    string
+	}
+	tests := []TT{
+		{DialectSQLite, "DROP TRIGGER IF EXISTS t;\nCREATE TRIGGER t AFTER UPDATE ON a BEGIN\n    SELECT 1;\n    SELECT 2;\nEND;\n", "CREATE TRIGGER t AFTER UPDATE ON a BEGIN SELECT 1 ; SELECT 2 ; END"},
+		{DialectSQLServer, "DROP FUNCTION IF EXISTS f\nGO\nCREATE FUNCTION f() RETURNS INT AS BEGIN RETURN 1; END\nGO\n", "CREATE FUNCTION f ( ) RETURNS INT AS BEGIN RETURN 1 ; END"},
+		{DialectPostgres, "-- comment\nCREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql;", "CREATE FUNCTION f ( ) RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql"},
+		{DialectMySQL, "DROP PROCEDURE p;", ""},
+		{DialectSQLServer, "CREATE FUNCTION f() RETURNS INT AS BEGIN RETURN 1 END\nGO\nCREATE FUNCTION g() RETURNS INT AS BEGIN RETURN 1 END", ""},
+	}
+	for _, tt := range tests {
+		stmt, _ := routineStatement(tt.dialect, tt.sql)
+		if diff := testutil.Diff(strings.Join(stmt.tokens, " "), tt.want); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	}
+}
+
+func TestSQLParser_ParseRoutineFile(t *testing.T) {
+	t.Run("routines", func(t *testing.T) {
+		t.Parallel()
+		catalog := &Catalog{Dialect: DialectMySQL, CurrentSchema: "sakila"}
+		p := NewSQLParser()
+		for name, sql := range map[string]string{
+			"film_in_stock.sql": "DROP PROCEDURE IF EXISTS film_in_stock;\n" +
+				"CREATE DEFINER = `root`@`%` PROCEDURE film_in_stock(IN p_film_id INT, OUT p_film_count INT)\nREADS SQL DATA\nBEGIN\n    SELECT COUNT(*) INTO p_film_count FROM inventory WHERE film_id = p_film_id;\nEND;\n",
+			"get_customer_balance.sql": "CREATE FUNCTION get_customer_balance(p_customer_id INT, p_effective_date DATETIME) RETURNS DECIMAL(5,2)\nDETERMINISTIC\nREADS SQL DATA\nRETURN 0;",
+		} {
+			err := p.ParseRoutineFile(&bufferFile{name: name, buf: bytes.NewBufferString(sql)})
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+		}
+		err := p.WriteCatalog(catalog)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if len(catalog.Schemas) != 1 {
+			t.Fatalf(testutil.Callers()+" expected 1 schema, got %d", len(catalog.Schemas))
+		}
+		gotAttrs := make(map[string]map[string]string)
+		for _, routine := range catalog.Schemas[0].Routines {
+			gotAttrs[routine.RoutineType+" "+routine.RoutineName] = routine.Attrs
+		}
+		wantAttrs := map[string]map[string]string{
+			"PROCEDURE film_in_stock": {
+				"parameters":      "IN p_film_id int|OUT p_film_count int",
+				"isDeterministic": "false",
+				"sqlDataAccess":   "READS SQL DATA",
+				"securityType":    "DEFINER",
+			},
+			"FUNCTION get_customer_balance": {
+				"parameters":      "p_customer_id int|p_effective_date datetime",
+				"returnType":      "DECIMAL(5,2)",
+				"isDeterministic": "true",
+				"sqlDataAccess":   "READS SQL DATA",
+				"securityType":    "DEFINER",
+			},
+		}
+		if diff := testutil.Diff(gotAttrs, wantAttrs); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			dialect string
+			sql     string
+			wantErr string
+		}{
+			{DialectPostgres, "DROP FUNCTION IF EXISTS f;", "f.sql: no CREATE FUNCTION, CREATE PROCEDURE or CREATE TRIGGER statement found"},
+			{DialectPostgres, "CREATE TRIGGER t BEFORE UPDATE ON actor FOR EACH ROW EXECUTE FUNCTION f();", "CREATE TRIGGER t: table actor does not exist"},
+			{DialectSQLite, "CREATE FUNCTION f() RETURNS INT AS $$ SELECT 1 $$;", "CREATE FUNCTION is not supported by sqlite"},
+			{DialectMySQL, "CREATE TABLE actor (actor_id INT);", "expected CREATE FUNCTION, CREATE PROCEDURE or CREATE TRIGGER"},
+		}
+		for _, tt := range tests {
+			p := NewSQLParser()
+			err := p.ParseRoutineFile(&bufferFile{name: "f.sql", buf: bytes.NewBufferString(tt.sql)})
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			err = p.WriteCatalog(&Catalog{Dialect: tt.dialect})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf(testutil.Callers()+" expected error containing %q, got %v", tt.wantErr, err)
+			}
+		}
+	})
+}
+
+func TestAutomigrateCmd_RoutinesDir(t *testing.T) {
+	db, err := sql.Open("sqlite3", "file:/"+t.Name()+".db?vfs=memdb&_foreign_keys=true")
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+	defer db.Close()
+	dirFS := fstest.MapFS{
+		"schema.sql": {Data: []byte("CREATE TABLE actor (actor_id INT, name TEXT, last_update INT);")},
+		"routines/actor_last_update_trg.sql": {Data: []byte("DROP TRIGGER IF EXISTS actor_last_update_trg;\n" +
+			"CREATE TRIGGER actor_last_update_trg AFTER UPDATE ON actor BEGIN\n" +
+			"    UPDATE actor SET last_update = 1 WHERE ROWID = NEW.ROWID;\n" +
+			"END;\n")},
+	}
+	automigrate := func(dryRun bool) string {
+		var stdout bytes.Buffer
+		automigrateCmd := &AutomigrateCmd{
+			DB:          db,
+			Dialect:     DialectSQLite,
+			DirFS:       dirFS,
+			Filenames:   []string{"schema.sql"},
+			RoutinesDir: "routines",
+			DropObjects: true,
+			DryRun:      dryRun,
+			Stdout:      &stdout,
+			Stderr:      io.Discard,
+		}
+		err := automigrateCmd.Run()
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		return stdout.String()
+	}
+	automigrate(false)
+	_, err = db.Exec("INSERT INTO actor (actor_id, name) VALUES (1, 'a'); UPDATE actor SET name = 'b' WHERE actor_id = 1")
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+	var lastUpdate int
+	err = db.QueryRow("SELECT last_update FROM actor WHERE actor_id = 1").Scan(&lastUpdate)
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+	if diff := testutil.Diff(lastUpdate, 1); diff != "" {
+		t.Error(testutil.Callers(), diff)
+	}
+	// The trigger is unchanged so there is nothing left to migrate.
+	if got := automigrate(true); strings.Contains(got, "TRIGGER") {
+		t.Errorf(testutil.Callers()+" expected no trigger changes, got:\n%s", got)
+	}
+	// Removing the trigger's file drops the trigger.
+	delete(dirFS, "routines/actor_last_update_trg.sql")
+	dirFS["routines"] = &fstest.MapFile{Mode: fs.ModeDir}
+	automigrate(false)
+	var n int
+	err = db.QueryRow("SELECT COUNT(*) FROM sqlite_schema WHERE type = 'trigger'").Scan(&n)
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+	if diff := testutil.Diff(n, 0); diff != "" {
+		t.Error(testutil.Callers(), diff)
+	}
+}
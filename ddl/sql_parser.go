@@ -0,0 +1,2235 @@
+package ddl
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SQLParser is used to parse SQL DDL statements into a Catalog. It
+// understands the CREATE SCHEMA, CREATE EXTENSION, CREATE TYPE ... AS ENUM,
+// CREATE DOMAIN, CREATE SEQUENCE, CREATE TABLE, CREATE INDEX, CREATE VIEW,
+// ALTER TABLE ADD, ALTER TABLE ATTACH PARTITION, ALTER SEQUENCE and COMMENT
+// ON statements (as well as their SQL Server equivalents), which covers the
+// files written by `sqddl dump`. Any other statement is skipped.
+// Routines and triggers are parsed from their own files (see
+// ParseRoutineFile).
+type SQLParser struct {
+	files        []sqlFile
+	routineFiles []sqlFile
+	dialect      string
+	catalog      *Catalog
+	cache        *CatalogCache
+	tables       [][2]string // The tables created by the parsed statements.
+}
+
+// sqlFile is a file containing SQL statements.
+type sqlFile struct {
+	name     string
+	contents string
+}
+
+// sqlStatement is an SQL statement being parsed. Only the tokens between pos
+// and end are visible to the sqlStatement's methods.
+type sqlStatement struct {
+	lintStatement
+	filename string
+	contents string
+	pos      int
+	end      int
+}
+
+// NewSQLParser creates a new SQLParser.
+func NewSQLParser() *SQLParser {
+	return &SQLParser{}
+}
+
+// ParseFile reads an fs.File containing SQL statements. The statements are
+// only parsed when WriteCatalog is called, because how they are parsed
+// depends on the Catalog's dialect.
+func (p *SQLParser) ParseFile(f fs.File) error {
+	fileinfo, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	p.files = append(p.files, sqlFile{name: fileinfo.Name(), contents: string(b)})
+	return nil
+}
+
+// ParseRoutineFile reads an fs.File containing the definition of a single
+// function, procedure or trigger. Unlike ParseFile, the definition is not
+// split at semicolons so it may have a body made up of multiple statements
+// (e.g. BEGIN ... END). Any DROP statements before the definition are
+// skipped.
+func (p *SQLParser) ParseRoutineFile(f fs.File) error {
+	fileinfo, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	p.routineFiles = append(p.routineFiles, sqlFile{name: fileinfo.Name(), contents: string(b)})
+	return nil
+}
+
+// WriteCatalog populates the Catalog using the SQLParser's SQL statements.
+// Unqualified object names are placed in the Catalog's CurrentSchema.
+func (p *SQLParser) WriteCatalog(catalog *Catalog) error {
+	if catalog.Dialect == "" {
+		return fmt.Errorf("dialect is required to parse SQL statements")
+	}
+	p.dialect = catalog.Dialect
+	p.catalog = catalog
+	p.cache = NewCatalogCache(catalog)
+	p.tables = p.tables[:0]
+	for _, file := range p.files {
+		err := p.parseSQL(file.name, file.contents, 1)
+		if err != nil {
+			return err
+		}
+	}
+	p.resolveTables()
+	for _, file := range p.routineFiles {
+		err := p.parseRoutine(file)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseSQL parses the statements in contents. The line number of the first
+// line of contents is startLine.
+func (p *SQLParser) parseSQL(filename, contents string, startLine int) error {
+	stmts, _ := splitStatements(p.dialect, contents)
+	for _, stmt := range stmts {
+		stmt.line += startLine - 1
+		for _, batch := range splitBatches(contents, stmt) {
+			s := &sqlStatement{
+				lintStatement: batch,
+				filename:      filename,
+				contents:      contents,
+				end:           len(batch.tokens),
+			}
+			err := p.parseStatement(s)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// splitBatches splits a statement at every GO batch separator (which SQL
+// Server scripts use in place of semicolons). A GO is only recognized if it
+// is the first token on its line.
+func splitBatches(contents string, stmt lintStatement) []lintStatement {
+	var batches []lintStatement
+	start := 0
+	for i, token := range stmt.tokens {
+		if !strings.EqualFold(token, "GO") || !startsLine(contents, stmt.offsets[i]) {
+			continue
+		}
+		if i > start {
+			batches = append(batches, subStatement(contents, stmt, start, i))
+		}
+		start = i + 1
+	}
+	if start == 0 {
+		return []lintStatement{stmt}
+	}
+	if start < len(stmt.tokens) {
+		batches = append(batches, subStatement(contents, stmt, start, len(stmt.tokens)))
+	}
+	return batches
+}
+
+// subStatement returns the statement made up of stmt.tokens[start:end].
+func subStatement(contents string, stmt lintStatement, start, end int) lintStatement {
+	return lintStatement{
+		line:    stmt.line + strings.Count(contents[stmt.offsets[0]:stmt.offsets[start]], "\n"),
+		tokens:  stmt.tokens[start:end],
+		offsets: stmt.offsets[start:end],
+	}
+}
+
+// startsLine reports whether only whitespace precedes the offset on its line.
+func startsLine(contents string, offset int) bool {
+	for i := offset - 1; i >= 0; i-- {
+		switch contents[i] {
+		case '\n':
+			return true
+		case ' ', '\t', '\r':
+			continue
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (p *SQLParser) parseStatement(s *sqlStatement) error {
+	switch {
+	case s.accept("CREATE"):
+		return p.parseCreate(s)
+	case s.accept("ALTER", "TABLE"):
+		return p.parseAlterTable(s)
+	case s.accept("ALTER", "SEQUENCE"):
+		return p.parseAlterSequence(s)
+	case s.accept("DROP"):
+		return p.parseDrop(s)
+	case s.accept("COMMENT", "ON"):
+		return p.parseCommentOn(s)
+	case s.accept("GRANT"):
+		return p.parseGrant(s)
+	case s.peek("IF"), s.peek("EXEC"), s.peek("EXECUTE"):
+		return p.parseExec(s)
+	}
+	return nil
+}
+
+func (p *SQLParser) parseCreate(s *sqlStatement) error {
+	var isUnique, isMaterialized, isVirtual, isTemporary bool
+	var indexType string
+	for !s.done() {
+		switch {
+		case s.accept("OR", "REPLACE"), s.accept("OR", "ALTER"):
+		case s.accept("UNIQUE"):
+			isUnique = true
+		case s.accept("CLUSTERED"):
+			indexType = "CLUSTERED"
+		case s.accept("NONCLUSTERED"):
+			indexType = "NONCLUSTERED"
+		case s.accept("FULLTEXT"):
+			indexType = "FULLTEXT"
+		case s.accept("SPATIAL"):
+			indexType = "SPATIAL"
+		case s.accept("MATERIALIZED"):
+			isMaterialized = true
+		case s.accept("VIRTUAL"):
+			isVirtual = true
+		case s.accept("TEMP"), s.accept("TEMPORARY"):
+			isTemporary = true
+		case s.accept("GLOBAL"), s.accept("LOCAL"), s.accept("UNLOGGED"), s.accept("RECURSIVE"):
+		case s.accept("ALGORITHM"), s.accept("DEFINER"), s.accept("SQL", "SECURITY"):
+			// MySQL view options e.g. ALGORITHM = MERGE, DEFINER = `root`@`%`.
+			s.accept("=")
+			for !s.done() && !s.peek("VIEW") && !s.peek("SQL") && !s.peek("ALGORITHM") {
+				s.pos++
+			}
+		case s.accept("SCHEMA"):
+			return p.parseCreateSchema(s)
+		case s.accept("EXTENSION"):
+			return p.parseCreateExtension(s)
+		case s.accept("TYPE"):
+			return p.parseCreateEnum(s)
+		case s.accept("DOMAIN"):
+			return p.parseCreateDomain(s)
+		case s.accept("SEQUENCE"):
+			if isTemporary {
+				return nil
+			}
+			return p.parseCreateSequence(s)
+		case s.accept("TABLE"):
+			if isTemporary {
+				return nil
+			}
+			return p.parseCreateTable(s, isVirtual)
+		case s.accept("INDEX"):
+			return p.parseCreateIndex(s, isUnique, indexType)
+		case s.accept("VIEW"):
+			if isTemporary {
+				return nil
+			}
+			return p.parseCreateView(s, isMaterialized)
+		case s.accept("POLICY"):
+			return p.parseCreatePolicy(s)
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// parseCreateSchema parses a CREATE SCHEMA statement.
+//
+//	CREATE SCHEMA [IF NOT EXISTS] name [AUTHORIZATION owner]
+//	CREATE SCHEMA AUTHORIZATION owner
+func (p *SQLParser) parseCreateSchema(s *sqlStatement) error {
+	if p.dialect == DialectSQLite {
+		return nil
+	}
+	s.accept("IF", "NOT", "EXISTS")
+	s.accept("AUTHORIZATION")
+	schemaName := p.name(s.next())
+	if schemaName == "" {
+		return p.errorf(s, "CREATE SCHEMA: missing schema name")
+	}
+	p.cache.GetOrCreateSchema(p.catalog, schemaName)
+	return nil
+}
+
+// parseCreateExtension parses a CREATE EXTENSION statement.
+//
+//	CREATE EXTENSION [IF NOT EXISTS] name [WITH] [SCHEMA schema] [VERSION version] [CASCADE]
+func (p *SQLParser) parseCreateExtension(s *sqlStatement) error {
+	if p.dialect != DialectPostgres {
+		return nil
+	}
+	s.accept("IF", "NOT", "EXISTS")
+	extension := p.name(s.next())
+	if extension == "" {
+		return p.errorf(s, "CREATE EXTENSION: missing extension name")
+	}
+	if !slices.Contains(p.catalog.Extensions, extension) {
+		p.catalog.Extensions = append(p.catalog.Extensions, extension)
+	}
+	p.catalog.ExtensionsValid = true
+	return nil
+}
+
+// parseCreateEnum parses a CREATE TYPE ... AS ENUM statement. Other kinds of
+// CREATE TYPE statements are skipped.
+//
+//	CREATE TYPE name AS ENUM ('label1', 'label2', ...)
+func (p *SQLParser) parseCreateEnum(s *sqlStatement) error {
+	if p.dialect != DialectPostgres {
+		return nil
+	}
+	enumSchema, enumName := p.objectName(s.next())
+	if !s.accept("AS", "ENUM") {
+		return nil
+	}
+	if !s.peek("(") {
+		return p.errorf(s, "CREATE TYPE %s: expected (", enumName)
+	}
+	var labels []string
+	for _, element := range s.elements() {
+		label, ok := p.unquoteString(s.tokens[element[0]])
+		if !ok {
+			return p.errorf(s, "CREATE TYPE %s: invalid enum label %s", enumName, s.tokens[element[0]])
+		}
+		labels = append(labels, label)
+	}
+	schema := p.cache.GetOrCreateSchema(p.catalog, enumSchema)
+	schema.EnumsValid = true
+	p.cache.AddOrUpdateEnum(schema, Enum{
+		EnumSchema: enumSchema,
+		EnumName:   enumName,
+		EnumLabels: labels,
+	})
+	return nil
+}
+
+// domainKeywords are the keywords that end a domain's type or default.
+var domainKeywords = map[string]bool{
+	"COLLATE": true, "DEFAULT": true, "CONSTRAINT": true, "NOT": true,
+	"NULL": true, "CHECK": true,
+}
+
+// parseCreateDomain parses a CREATE DOMAIN statement. Unnamed check
+// constraints are named {domain}_check, {domain}_check1, {domain}_check2 etc
+// (the same way Postgres names them).
+//
+//	CREATE DOMAIN name [AS] type [COLLATE collation] [DEFAULT expr] [[CONSTRAINT name] {NOT NULL | NULL | CHECK (expr)}]...
+func (p *SQLParser) parseCreateDomain(s *sqlStatement) error {
+	if p.dialect != DialectPostgres {
+		return nil
+	}
+	domainSchema, domainName := p.objectName(s.next())
+	s.accept("AS")
+	start := s.pos
+	for !s.done() && !domainKeywords[strings.ToUpper(s.tokens[s.pos])] {
+		s.skip()
+	}
+	domain := Domain{
+		DomainSchema:   domainSchema,
+		DomainName:     domainName,
+		UnderlyingType: p.typeText(s, start, s.pos),
+	}
+	if domain.UnderlyingType == "" {
+		return p.errorf(s, "CREATE DOMAIN %s: missing type", domainName)
+	}
+	var constraintName string
+	for !s.done() {
+		switch {
+		case s.accept("COLLATE"):
+			domain.CollationName = p.name(s.next())
+		case s.accept("DEFAULT"):
+			domain.ColumnDefault = p.expr(s, domainKeywords)
+		case s.accept("CONSTRAINT"):
+			constraintName = p.name(s.next())
+			continue
+		case s.accept("NOT", "NULL"):
+			domain.IsNotNull = true
+		case s.accept("NULL"):
+		case s.accept("CHECK"):
+			if constraintName == "" {
+				constraintName = domain.DomainName + "_check"
+				if n := len(domain.CheckNames); n > 0 {
+					constraintName += strconv.Itoa(n)
+				}
+			}
+			domain.CheckNames = append(domain.CheckNames, constraintName)
+			domain.CheckExprs = append(domain.CheckExprs, p.groupText(s))
+		default:
+			s.skip()
+		}
+		constraintName = ""
+	}
+	schema := p.cache.GetOrCreateSchema(p.catalog, domainSchema)
+	schema.DomainsValid = true
+	p.cache.AddOrUpdateDomain(schema, domain)
+	return nil
+}
+
+// parseCreateSequence parses a CREATE SEQUENCE statement. Options that are not
+// provided get the same defaults that Postgres gives them.
+//
+//	CREATE SEQUENCE [IF NOT EXISTS] name [AS type] [INCREMENT [BY] n] [MINVALUE n | NO MINVALUE] [MAXVALUE n | NO MAXVALUE] [START [WITH] n] [CACHE n] [[NO] CYCLE] [OWNED BY {table.column | NONE}]
+func (p *SQLParser) parseCreateSequence(s *sqlStatement) error {
+	if p.dialect != DialectPostgres {
+		return nil
+	}
+	s.accept("IF", "NOT", "EXISTS")
+	sequenceSchema, sequenceName := p.objectName(s.next())
+	if sequenceName == "" {
+		return p.errorf(s, "CREATE SEQUENCE: missing sequence name")
+	}
+	sequence := Sequence{
+		SequenceSchema: sequenceSchema,
+		SequenceName:   sequenceName,
+	}
+	hasMinValue, hasMaxValue, hasStartValue, err := p.parseSequenceOptions(s, &sequence)
+	if err != nil {
+		return err
+	}
+	setSequenceDefaults(&sequence, hasMinValue, hasMaxValue, hasStartValue)
+	schema := p.cache.GetOrCreateSchema(p.catalog, sequenceSchema)
+	schema.SequencesValid = true
+	p.cache.AddOrUpdateSequence(schema, sequence)
+	return nil
+}
+
+// parseAlterSequence parses an ALTER SEQUENCE statement. The options are
+// applied to a sequence created by an earlier statement.
+//
+//	ALTER SEQUENCE [IF EXISTS] name [AS type] [INCREMENT [BY] n] ... [OWNED BY {table.column | NONE}]
+func (p *SQLParser) parseAlterSequence(s *sqlStatement) error {
+	if p.dialect != DialectPostgres {
+		return nil
+	}
+	s.accept("IF", "EXISTS")
+	sequenceSchema, sequenceName := p.objectName(s.next())
+	sequence := p.cache.GetSequence(p.cache.GetSchema(p.catalog, sequenceSchema), sequenceName)
+	if sequence == nil {
+		return p.errorf(s, "ALTER SEQUENCE: sequence %s does not exist", sequenceName)
+	}
+	_, _, _, err := p.parseSequenceOptions(s, sequence)
+	if err != nil {
+		return err
+	}
+	sequence.DataType = normalizeSequenceType(sequence.DataType)
+	return nil
+}
+
+// parseSequenceOptions parses the options of a CREATE SEQUENCE or ALTER
+// SEQUENCE statement into the sequence, and reports which of the MINVALUE,
+// MAXVALUE and START options were provided.
+func (p *SQLParser) parseSequenceOptions(s *sqlStatement, sequence *Sequence) (hasMinValue, hasMaxValue, hasStartValue bool, err error) {
+	for !s.done() {
+		ok := true
+		switch {
+		case s.accept("AS"):
+			start := s.pos
+			s.skip()
+			sequence.DataType = p.typeText(s, start, s.pos)
+		case s.accept("INCREMENT"):
+			s.accept("BY")
+			sequence.Increment, ok = p.number(s)
+		case s.accept("NO", "MINVALUE"), s.accept("NO", "MAXVALUE"):
+		case s.accept("MINVALUE"):
+			sequence.MinValue, ok = p.number(s)
+			hasMinValue = true
+		case s.accept("MAXVALUE"):
+			sequence.MaxValue, ok = p.number(s)
+			hasMaxValue = true
+		case s.accept("START"):
+			s.accept("WITH")
+			sequence.StartValue, ok = p.number(s)
+			hasStartValue = true
+		case s.accept("CACHE"):
+			_, ok = p.number(s)
+		case s.accept("NO", "CYCLE"):
+			sequence.IsCycle = false
+		case s.accept("CYCLE"):
+			sequence.IsCycle = true
+		case s.accept("OWNED", "BY"):
+			sequence.OwnedByTable, sequence.OwnedByColumn = "", ""
+			if parts := p.identifier(s.next()); len(parts) >= 2 {
+				sequence.OwnedByTable, sequence.OwnedByColumn = parts[len(parts)-2], parts[len(parts)-1]
+			}
+		default:
+			s.skip()
+		}
+		if !ok {
+			return false, false, false, p.errorf(s, "SEQUENCE %s: invalid number %s", sequence.SequenceName, s.tokens[s.pos-1])
+		}
+	}
+	return hasMinValue, hasMaxValue, hasStartValue, nil
+}
+
+// parseCreateTable parses a CREATE TABLE statement. CREATE TABLE ... AS
+// SELECT and other statements without a list of columns are skipped.
+//
+//	CREATE TABLE [IF NOT EXISTS] name (column_or_constraint, ...) [table_options]
+//	CREATE TABLE [IF NOT EXISTS] name PARTITION OF parent ...
+//	CREATE VIRTUAL TABLE [IF NOT EXISTS] name USING module(args)
+func (p *SQLParser) parseCreateTable(s *sqlStatement, isVirtual bool) error {
+	ifNotExists := s.accept("IF", "NOT", "EXISTS")
+	tableSchema, tableName := p.objectName(s.next())
+	if tableName == "" {
+		return p.errorf(s, "CREATE TABLE: missing table name")
+	}
+	isPartition := p.dialect == DialectPostgres && s.peek("PARTITION", "OF")
+	if !isVirtual && !isPartition && !s.peek("(") {
+		return nil
+	}
+	schema := p.cache.GetOrCreateSchema(p.catalog, tableSchema)
+	if p.cache.GetTable(schema, tableName) != nil {
+		if ifNotExists {
+			return nil
+		}
+		return p.errorf(s, "table %s is created more than once", tableName)
+	}
+	table := p.cache.GetOrCreateTable(schema, tableName)
+	p.tables = append(p.tables, [2]string{tableSchema, tableName})
+	if p.dialect == DialectSQLite {
+		// SQLite keeps the CREATE TABLE statement of every table (which is
+		// what the DatabaseIntrospector returns as well).
+		table.SQL = s.text(0, len(s.tokens)) + ";"
+	}
+	if isVirtual {
+		table.IsVirtual = true
+		return nil
+	}
+	if isPartition {
+		return p.parsePartitionOf(s, table)
+	}
+	end := s.end
+	for _, element := range s.elements() {
+		s.pos, s.end = element[0], element[1]
+		err := p.parseTableElement(s, table)
+		if err != nil {
+			return err
+		}
+	}
+	s.end = end
+	for !s.done() {
+		if s.accept("COMMENT") {
+			s.accept("=")
+			if comment, ok := p.unquoteString(s.next()); ok && p.dialect == DialectMySQL {
+				table.Comment = comment
+			}
+			continue
+		}
+		if p.dialect == DialectPostgres && s.accept("PARTITION", "BY") {
+			table.PartitionBy = p.partitionKey(s)
+			continue
+		}
+		if p.dialect == DialectSQLite {
+			switch {
+			case s.accept("WITHOUT", "ROWID"):
+				table.IsWithoutRowid = true
+				continue
+			case s.accept("STRICT"):
+				table.IsStrict = true
+				continue
+			}
+		}
+		if p.dialect == DialectMySQL {
+			switch {
+			case s.accept("ENGINE"):
+				table.Engine = p.tableOption(s)
+				continue
+			case s.accept("CHARACTER", "SET"), s.accept("CHARSET"):
+				table.CharacterSet = strings.ToLower(p.tableOption(s))
+				continue
+			case s.accept("COLLATE"):
+				table.CollationName = strings.ToLower(p.tableOption(s))
+				if table.CharacterSet == "" {
+					table.CharacterSet = collationCharacterSet(table.CollationName)
+				}
+				continue
+			case s.accept("ROW_FORMAT"):
+				table.RowFormat = strings.ToUpper(p.tableOption(s))
+				continue
+			case s.accept("AUTO_INCREMENT"):
+				s.accept("=")
+				autoIncrement, err := strconv.ParseInt(s.next(), 10, 64)
+				if err != nil {
+					return p.errorf(s, "CREATE TABLE %s: invalid AUTO_INCREMENT %s", tableName, s.tokens[s.pos-1])
+				}
+				table.AutoIncrement = autoIncrement
+				continue
+			}
+		}
+		s.skip()
+	}
+	return nil
+}
+
+// tableOption returns the value of a MySQL table option, which may be
+// preceded by an equals sign and may be quoted.
+//
+//	option [=] value
+func (p *SQLParser) tableOption(s *sqlStatement) string {
+	s.accept("=")
+	token := s.next()
+	if value, ok := p.unquoteString(token); ok {
+		return value
+	}
+	return p.name(token)
+}
+
+// parsePartitionOf parses the rest of a Postgres CREATE TABLE ... PARTITION OF
+// statement. The columns of a partition are inherited from the partitioned
+// table, so only the table constraints declared on the partition are kept.
+//
+//	PARTITION OF parent [( {column WITH OPTIONS ... | table_constraint} [, ...] )]
+//	{FOR VALUES ... | DEFAULT} [PARTITION BY {RANGE | LIST | HASH} ( ... )]
+func (p *SQLParser) parsePartitionOf(s *sqlStatement, table *Table) error {
+	s.accept("PARTITION", "OF")
+	parentSchema, parentName := p.objectName(s.next())
+	if parentName == "" {
+		return p.errorf(s, "CREATE TABLE %s: missing partitioned table name", table.TableName)
+	}
+	table.PartitionOf = parentName
+	if parentSchema != table.TableSchema {
+		table.PartitionOf = parentSchema + "." + parentName
+	}
+	if s.peek("(") {
+		elements := s.elements()
+		pos, end := s.pos, s.end
+		for _, element := range elements {
+			s.pos, s.end = element[0], element[1]
+			if !s.peek("CONSTRAINT") && !s.peek("PRIMARY", "KEY") && !s.peek("UNIQUE") && !s.peek("FOREIGN", "KEY") && !s.peek("CHECK") && !s.peek("EXCLUDE") {
+				continue
+			}
+			err := p.parseTableConstraint(s, table)
+			if err != nil {
+				return err
+			}
+		}
+		s.pos, s.end = pos, end
+	}
+	partitionBound, ok := p.partitionBound(s)
+	if !ok {
+		return p.errorf(s, "CREATE TABLE %s: missing partition bound", table.TableName)
+	}
+	table.PartitionBound = partitionBound
+	for !s.done() {
+		if s.accept("PARTITION", "BY") {
+			table.PartitionBy = p.partitionKey(s)
+			continue
+		}
+		s.skip()
+	}
+	return nil
+}
+
+// partitionKey parses the partition key of a Postgres partitioned table,
+// which follows PARTITION BY.
+//
+//	{RANGE | LIST | HASH} ( {column | ( expression )} [, ...] )
+func (p *SQLParser) partitionKey(s *sqlStatement) string {
+	strategy := strings.ToUpper(s.next())
+	return strategy + " (" + p.groupText(s) + ")"
+}
+
+// partitionBound parses the partition bound of a Postgres partition.
+//
+//	FOR VALUES {IN ( ... ) | FROM ( ... ) TO ( ... ) | WITH ( ... )} | DEFAULT
+func (p *SQLParser) partitionBound(s *sqlStatement) (partitionBound string, ok bool) {
+	if s.accept("DEFAULT") {
+		return "DEFAULT", true
+	}
+	if !s.accept("FOR", "VALUES") {
+		return "", false
+	}
+	switch {
+	case s.accept("FROM"):
+		partitionBound = "FOR VALUES FROM (" + p.groupText(s) + ")"
+		if !s.accept("TO") {
+			return "", false
+		}
+		return partitionBound + " TO (" + p.groupText(s) + ")", true
+	case s.accept("IN"):
+		return "FOR VALUES IN (" + p.groupText(s) + ")", true
+	case s.accept("WITH"):
+		return "FOR VALUES WITH (" + p.groupText(s) + ")", true
+	}
+	return "", false
+}
+
+// parseTableElement parses a column definition or a table constraint.
+func (p *SQLParser) parseTableElement(s *sqlStatement, table *Table) error {
+	switch {
+	case s.peek("CONSTRAINT"), s.peek("PRIMARY", "KEY"), s.peek("UNIQUE"), s.peek("FOREIGN", "KEY"), s.peek("CHECK"), s.peek("EXCLUDE"):
+		return p.parseTableConstraint(s, table)
+	case p.dialect == DialectMySQL && (s.peek("INDEX") || s.peek("KEY") || s.peek("FULLTEXT") || s.peek("SPATIAL")):
+		return p.parseInlineIndex(s, table)
+	case p.dialect == DialectSQLServer && s.peek("INDEX"):
+		return p.parseInlineIndex(s, table)
+	case s.peek("LIKE"), s.peek("PERIOD"):
+		return nil
+	}
+	return p.parseColumn(s, table)
+}
+
+// columnKeywords are the keywords that end a column's type or default.
+var columnKeywords = map[string]bool{
+	"CONSTRAINT": true, "NOT": true, "NULL": true, "PRIMARY": true,
+	"UNIQUE": true, "KEY": true, "REFERENCES": true, "CHECK": true,
+	"DEFAULT": true, "COLLATE": true, "GENERATED": true, "AS": true,
+	"IDENTITY": true, "AUTO_INCREMENT": true, "AUTOINCREMENT": true,
+	"ON": true, "COMMENT": true, "CHARSET": true, "PERSISTED": true,
+	"SPARSE": true, "ROWGUIDCOL": true,
+}
+
+// isColumnKeyword reports whether the current token ends a column's type or
+// default.
+func isColumnKeyword(s *sqlStatement) bool {
+	if s.peek("CHARACTER", "SET") {
+		return true
+	}
+	return columnKeywords[strings.ToUpper(s.tokens[s.pos])]
+}
+
+// parseColumn parses a column definition.
+//
+//	name [type] [column_constraint | DEFAULT expr | COLLATE collation | ...]...
+func (p *SQLParser) parseColumn(s *sqlStatement, table *Table) error {
+	column := Column{ColumnName: p.name(s.next())}
+	if column.ColumnName == "" {
+		return p.errorf(s, "table %s: missing column name", table.TableName)
+	}
+	start := s.pos
+	for !s.done() && !isColumnKeyword(s) {
+		s.skip()
+	}
+	column.ColumnType = p.typeText(s, start, s.pos)
+	normalizedType, arg1, arg2 := normalizeColumnType(p.dialect, column.ColumnType)
+	switch normalizedType {
+	case "VARBINARY", "BINARY", "NVARCHAR", "VARCHAR", "CHAR":
+		column.CharacterLength = arg1
+	case "NUMERIC":
+		column.NumericPrecision, column.NumericScale = arg1, arg2
+	}
+	var constraints []Constraint
+	var constraintName string
+	for !s.done() {
+		switch {
+		case s.accept("CONSTRAINT"):
+			constraintName = p.name(s.next())
+			continue
+		case s.accept("NOT", "NULL"):
+			column.IsNotNull = true
+		case s.accept("NULL"):
+		case s.accept("PRIMARY", "KEY"), p.dialect == DialectMySQL && s.accept("KEY"):
+			constraint := Constraint{
+				ConstraintName: constraintName,
+				ConstraintType: PRIMARY_KEY,
+				Columns:        []string{column.ColumnName},
+			}
+			_ = s.accept("ASC") || s.accept("DESC")
+			p.parseClustered(s, &constraint)
+			if s.accept("AUTOINCREMENT") {
+				column.IsAutoincrement = true
+			}
+			constraints = append(constraints, constraint)
+		case s.accept("UNIQUE"):
+			s.accept("KEY")
+			constraint := Constraint{
+				ConstraintName: constraintName,
+				ConstraintType: UNIQUE,
+				Columns:        []string{column.ColumnName},
+			}
+			p.parseClustered(s, &constraint)
+			constraints = append(constraints, constraint)
+		case s.accept("REFERENCES"):
+			constraint := Constraint{
+				ConstraintName: constraintName,
+				ConstraintType: FOREIGN_KEY,
+				Columns:        []string{column.ColumnName},
+			}
+			p.parseReferences(s, &constraint)
+			constraints = append(constraints, constraint)
+		case s.accept("CHECK"):
+			constraints = append(constraints, Constraint{
+				ConstraintName: constraintName,
+				ConstraintType: CHECK,
+				Columns:        []string{column.ColumnName},
+				CheckExpr:      p.groupText(s),
+			})
+		case s.accept("DEFAULT"):
+			column.ColumnDefault = p.expr(s, columnKeywords)
+		case s.accept("COLLATE"):
+			column.CollationName = p.name(s.next())
+		case s.accept("GENERATED", "ALWAYS", "AS", "IDENTITY"):
+			column.ColumnIdentity = ALWAYS_IDENTITY
+			if s.peek("(") {
+				s.skip()
+			}
+		case s.accept("GENERATED", "BY", "DEFAULT", "AS", "IDENTITY"):
+			column.ColumnIdentity = DEFAULT_IDENTITY
+			if s.peek("(") {
+				s.skip()
+			}
+		case s.accept("GENERATED", "ALWAYS", "AS"), s.accept("AS"):
+			column.GeneratedExpr = p.groupText(s)
+			switch {
+			case s.accept("STORED"), s.accept("PERSISTED"):
+				column.GeneratedExprStored = true
+			case s.accept("VIRTUAL"):
+			default:
+				// Postgres only supports stored generated columns.
+				column.GeneratedExprStored = p.dialect == DialectPostgres
+			}
+		case s.accept("IDENTITY"):
+			column.ColumnIdentity = IDENTITY
+			if s.peek("(") {
+				s.skip()
+			}
+		case s.accept("AUTO_INCREMENT"), s.accept("AUTOINCREMENT"):
+			column.IsAutoincrement = true
+		case s.accept("ON", "UPDATE"):
+			if s.accept("CURRENT_TIMESTAMP") || s.accept("NOW") {
+				column.OnUpdateCurrentTimestamp = true
+			}
+			if s.peek("(") {
+				s.skip()
+			}
+		case s.accept("COMMENT"):
+			if comment, ok := p.unquoteString(s.next()); ok && p.dialect == DialectMySQL {
+				column.Comment = comment
+			}
+		case s.accept("CHARACTER", "SET"), s.accept("CHARSET"):
+			s.next()
+		default:
+			s.skip()
+		}
+		constraintName = ""
+	}
+	p.cache.AddOrUpdateColumn(table, column)
+	for _, constraint := range constraints {
+		p.addConstraint(table, constraint)
+	}
+	return nil
+}
+
+// parseTableConstraint parses a table constraint.
+//
+//	[CONSTRAINT name] PRIMARY KEY (columns)
+//	[CONSTRAINT name] UNIQUE (columns)
+//	[CONSTRAINT name] FOREIGN KEY (columns) REFERENCES table [(columns)] [ON UPDATE action] [ON DELETE action]
+//	[CONSTRAINT name] CHECK (expr)
+//	[CONSTRAINT name] EXCLUDE [USING method] (expr WITH operator, ...) [WHERE (predicate)]
+func (p *SQLParser) parseTableConstraint(s *sqlStatement, table *Table) error {
+	var constraint Constraint
+	if s.accept("CONSTRAINT") {
+		constraint.ConstraintName = p.name(s.next())
+	}
+	switch {
+	case s.accept("PRIMARY", "KEY"):
+		constraint.ConstraintType = PRIMARY_KEY
+	case s.accept("UNIQUE"):
+		constraint.ConstraintType = UNIQUE
+		if (s.accept("KEY") || s.accept("INDEX")) && !s.peek("(") && !s.peek("USING") {
+			constraint.ConstraintName = p.name(s.next())
+		}
+	case s.accept("FOREIGN", "KEY"):
+		constraint.ConstraintType = FOREIGN_KEY
+		if !s.peek("(") {
+			constraint.ConstraintName = p.name(s.next())
+		}
+	case s.accept("CHECK"):
+		constraint.ConstraintType = CHECK
+	case s.accept("EXCLUDE"):
+		constraint.ConstraintType = EXCLUDE
+	default:
+		return p.errorf(s, "table %s: invalid constraint", table.TableName)
+	}
+	p.parseClustered(s, &constraint)
+	if s.accept("USING") {
+		if constraint.ConstraintType == EXCLUDE {
+			constraint.ExclusionIndexType = s.next()
+		} else {
+			s.next()
+		}
+	}
+	if !s.peek("(") {
+		return p.errorf(s, "table %s: expected ( after %s", table.TableName, constraint.ConstraintType)
+	}
+	switch constraint.ConstraintType {
+	case CHECK:
+		constraint.CheckExpr = p.groupText(s)
+	case EXCLUDE:
+		end := s.end
+		for _, element := range s.elements() {
+			i := element[0]
+			for i < element[1] && !strings.EqualFold(s.tokens[i], "WITH") {
+				i++
+			}
+			constraint.Columns = append(constraint.Columns, s.text(element[0], i))
+			if i < element[1] {
+				constraint.ExclusionOperators = append(constraint.ExclusionOperators, s.text(i+1, element[1]))
+			}
+		}
+		s.end = end
+		if s.accept("WHERE") {
+			constraint.ExclusionPredicate = p.groupText(s)
+		}
+	default:
+		constraint.Columns = p.columnNames(s)
+	}
+	if constraint.ConstraintType == FOREIGN_KEY && s.accept("REFERENCES") {
+		p.parseReferences(s, &constraint)
+	}
+	for !s.done() {
+		switch {
+		case s.accept("NOT", "DEFERRABLE"):
+		case s.accept("DEFERRABLE"):
+			constraint.IsDeferrable = true
+		case s.accept("INITIALLY", "DEFERRED"):
+			constraint.IsInitiallyDeferred = true
+		case s.accept("NOT", "VALID"):
+			constraint.IsNotValid = true
+		default:
+			s.skip()
+		}
+	}
+	p.addConstraint(table, constraint)
+	return nil
+}
+
+// parseClustered parses the CLUSTERED or NONCLUSTERED keyword of an SQL
+// Server constraint. Primary keys are clustered by default.
+func (p *SQLParser) parseClustered(s *sqlStatement, constraint *Constraint) {
+	if p.dialect != DialectSQLServer {
+		return
+	}
+	switch {
+	case s.accept("CLUSTERED"):
+		constraint.IsClustered = true
+	case s.accept("NONCLUSTERED"):
+	default:
+		constraint.IsClustered = constraint.ConstraintType == PRIMARY_KEY
+	}
+}
+
+// parseReferences parses the part of a foreign key after the REFERENCES
+// keyword.
+//
+//	table [(columns)] [MATCH option] [ON UPDATE action] [ON DELETE action] [[NOT] DEFERRABLE] [INITIALLY DEFERRED]
+func (p *SQLParser) parseReferences(s *sqlStatement, constraint *Constraint) {
+	constraint.ReferencesSchema, constraint.ReferencesTable = p.objectName(s.next())
+	if s.peek("(") {
+		constraint.ReferencesColumns = p.columnNames(s)
+	}
+	for !s.done() {
+		switch {
+		case s.accept("MATCH"):
+			constraint.MatchOption = strings.ToUpper(s.next())
+		case s.accept("ON", "UPDATE"):
+			constraint.UpdateRule = p.referentialAction(s)
+		case s.accept("ON", "DELETE"):
+			constraint.DeleteRule = p.referentialAction(s)
+		case s.accept("NOT", "DEFERRABLE"):
+		case s.accept("DEFERRABLE"):
+			constraint.IsDeferrable = true
+		case s.accept("INITIALLY", "DEFERRED"):
+			constraint.IsInitiallyDeferred = true
+		case s.accept("INITIALLY", "IMMEDIATE"):
+		default:
+			return
+		}
+	}
+}
+
+// referentialAction parses the action of an ON UPDATE or ON DELETE clause.
+func (p *SQLParser) referentialAction(s *sqlStatement) string {
+	switch {
+	case s.accept("SET", "NULL"):
+		return SET_NULL
+	case s.accept("SET", "DEFAULT"):
+		return SET_DEFAULT
+	case s.accept("NO", "ACTION"):
+		return NO_ACTION
+	}
+	return strings.ToUpper(s.next())
+}
+
+// parseInlineIndex parses an index defined inside a MySQL or SQL Server
+// CREATE TABLE statement.
+//
+//	{INDEX | KEY} [name] [USING type] (columns)              -- MySQL
+//	{FULLTEXT | SPATIAL} [INDEX | KEY] [name] (columns)      -- MySQL
+//	INDEX name [CLUSTERED | NONCLUSTERED] (columns)          -- SQL Server
+func (p *SQLParser) parseInlineIndex(s *sqlStatement, table *Table) error {
+	var index Index
+	switch {
+	case s.accept("FULLTEXT"):
+		index.IndexType = "FULLTEXT"
+	case s.accept("SPATIAL"):
+		index.IndexType = "SPATIAL"
+	}
+	_ = s.accept("INDEX") || s.accept("KEY")
+	if !s.peek("(") && !s.peek("USING") {
+		index.IndexName = p.name(s.next())
+	}
+	switch {
+	case s.accept("CLUSTERED"):
+		index.IndexType = "CLUSTERED"
+	case s.accept("NONCLUSTERED"):
+		index.IndexType = "NONCLUSTERED"
+	case s.accept("USING"):
+		index.IndexType = strings.ToUpper(s.next())
+	}
+	if !s.peek("(") {
+		return p.errorf(s, "table %s: expected ( after INDEX", table.TableName)
+	}
+	p.parseIndexColumns(s, &index)
+	if index.IndexName == "" {
+		index.IndexName = GenerateName(INDEX, table.TableName, index.Columns)
+	}
+	p.cache.AddOrUpdateIndex(table, index)
+	return nil
+}
+
+// parseCreateIndex parses a CREATE INDEX statement.
+//
+//	CREATE [UNIQUE] INDEX [CONCURRENTLY] [IF NOT EXISTS] [name] ON table [USING method] (columns) [INCLUDE (columns)] [WHERE predicate]
+func (p *SQLParser) parseCreateIndex(s *sqlStatement, isUnique bool, indexType string) error {
+	index := Index{IsUnique: isUnique, IndexType: indexType}
+	s.accept("CONCURRENTLY")
+	s.accept("IF", "NOT", "EXISTS")
+	if !s.peek("ON") {
+		index.IndexName = p.name(s.next())
+	}
+	if s.accept("USING") {
+		index.IndexType = strings.ToUpper(s.next())
+	}
+	if !s.accept("ON") {
+		return p.errorf(s, "CREATE INDEX %s: expected ON", index.IndexName)
+	}
+	s.accept("ONLY")
+	tableSchema, tableName := p.objectName(s.next())
+	if s.accept("USING") {
+		index.IndexType = s.next()
+	}
+	if !s.peek("(") {
+		return p.errorf(s, "CREATE INDEX %s: expected (", index.IndexName)
+	}
+	p.parseIndexColumns(s, &index)
+	for !s.done() {
+		switch {
+		case s.accept("INCLUDE"):
+			index.IncludeColumns = p.columnNames(s)
+		case s.accept("WHERE"):
+			index.Predicate = s.text(s.pos, s.end)
+			s.pos = s.end
+		case s.accept("USING"):
+			index.IndexType = strings.ToUpper(s.next())
+		default:
+			s.skip()
+		}
+	}
+	if index.IndexName == "" {
+		index.IndexName = GenerateName(INDEX, tableName, index.Columns)
+	}
+	schema := p.cache.GetSchema(p.catalog, tableSchema)
+	if table := p.cache.GetTable(schema, tableName); table != nil {
+		p.cache.AddOrUpdateIndex(table, index)
+		return nil
+	}
+	if view := p.cache.GetView(schema, tableName); view != nil {
+		index.TableSchema, index.TableName = view.ViewSchema, view.ViewName
+		index.IsViewIndex = true
+		p.cache.AddOrUpdateViewIndex(view, index)
+		return nil
+	}
+	return p.errorf(s, "CREATE INDEX %s: table %s does not exist", index.IndexName, tableName)
+}
+
+// parseIndexColumns parses the columns of an index. Each column is either a
+// column name or an expression wrapped in brackets, optionally followed by an
+// opclass and ASC or DESC.
+func (p *SQLParser) parseIndexColumns(s *sqlStatement, index *Index) {
+	end := s.end
+	var hasDescending, hasOpclass bool
+	var descending []bool
+	var opclasses []string
+	for _, element := range s.elements() {
+		s.pos, s.end = element[0], element[1]
+		var column string
+		start := s.pos
+		if s.peek("(") {
+			s.skip()
+			column = s.text(start, s.pos)
+		} else {
+			name := s.next()
+			if s.peek("(") {
+				s.skip()
+				if p.dialect == DialectMySQL && s.pos-start == 4 {
+					// MySQL index prefix length e.g. name(10).
+					column = p.name(name)
+				} else {
+					column = "(" + s.text(start, s.pos) + ")"
+				}
+			} else {
+				column = p.name(name)
+			}
+		}
+		var isDescending bool
+		var opclass string
+		for !s.done() {
+			switch {
+			case s.accept("COLLATE"):
+				s.next()
+			case s.accept("ASC"):
+			case s.accept("DESC"):
+				isDescending = true
+			case s.accept("NULLS", "FIRST"), s.accept("NULLS", "LAST"):
+			default:
+				opclass = s.next()
+				if s.peek("(") {
+					s.skip()
+				}
+			}
+		}
+		index.Columns = append(index.Columns, column)
+		descending = append(descending, isDescending)
+		opclasses = append(opclasses, opclass)
+		hasDescending = hasDescending || isDescending
+		hasOpclass = hasOpclass || opclass != ""
+	}
+	s.end = end
+	if hasDescending {
+		index.Descending = descending
+	}
+	if hasOpclass && p.dialect == DialectPostgres {
+		index.Opclasses = opclasses
+	}
+}
+
+// parseCreateView parses a CREATE VIEW statement.
+//
+//	CREATE [MATERIALIZED] VIEW [IF NOT EXISTS] name [(columns)] AS query
+func (p *SQLParser) parseCreateView(s *sqlStatement, isMaterialized bool) error {
+	s.accept("IF", "NOT", "EXISTS")
+	viewSchema, viewName := p.objectName(s.next())
+	if viewName == "" {
+		return p.errorf(s, "CREATE VIEW: missing view name")
+	}
+	view := View{
+		ViewSchema:     viewSchema,
+		ViewName:       viewName,
+		IsMaterialized: isMaterialized && p.dialect == DialectPostgres,
+	}
+	if s.peek("(") {
+		view.Columns = p.columnNames(s)
+	}
+	for !s.done() && !s.peek("AS") {
+		s.skip()
+	}
+	if !s.accept("AS") {
+		return p.errorf(s, "CREATE VIEW %s: expected AS", viewName)
+	}
+	end := s.end
+	if view.IsMaterialized {
+		for _, suffix := range [][]string{{"WITH", "DATA"}, {"WITH", "NO", "DATA"}} {
+			if n := len(suffix); end-n > s.pos && matchKeywords(s.tokens[end-n:end], suffix...) {
+				end -= n
+			}
+		}
+	}
+	view.SQL = s.text(s.pos, end)
+	schema := p.cache.GetOrCreateSchema(p.catalog, viewSchema)
+	schema.ViewsValid = true
+	p.cache.AddOrUpdateView(schema, view)
+	return nil
+}
+
+// parseRoutine parses the definition of a function, procedure or trigger.
+// A trigger is added to its table (or view), which must already exist.
+//
+//	CREATE [OR REPLACE | OR ALTER] [DEFINER = user] {FUNCTION | PROCEDURE} [IF NOT EXISTS] name [(arguments)] [RETURNS type] ...
+//	CREATE [OR REPLACE | OR ALTER] [DEFINER = user] [CONSTRAINT] TRIGGER [IF NOT EXISTS] name ... ON table ...
+func (p *SQLParser) parseRoutine(file sqlFile) error {
+	stmt, ok := routineStatement(p.dialect, file.contents)
+	if !ok {
+		return fmt.Errorf("%s: no CREATE FUNCTION, CREATE PROCEDURE or CREATE TRIGGER statement found", file.name)
+	}
+	s := &sqlStatement{
+		lintStatement: stmt,
+		filename:      file.name,
+		contents:      file.contents,
+		end:           len(stmt.tokens),
+	}
+	if !s.accept("CREATE") {
+		return p.errorf(s, "expected CREATE FUNCTION, CREATE PROCEDURE or CREATE TRIGGER")
+	}
+	var objectType string
+	for objectType == "" {
+		switch {
+		case s.accept("OR", "REPLACE"), s.accept("OR", "ALTER"), s.accept("CONSTRAINT"):
+		case s.accept("DEFINER"):
+			s.accept("=")
+			for !s.done() && !s.peek("FUNCTION") && !s.peek("PROCEDURE") && !s.peek("TRIGGER") {
+				s.pos++
+			}
+		case s.accept("FUNCTION"):
+			objectType = "FUNCTION"
+		case s.accept("PROCEDURE"), s.accept("PROC"):
+			objectType = "PROCEDURE"
+		case s.accept("TRIGGER"):
+			objectType = "TRIGGER"
+		default:
+			return p.errorf(s, "expected CREATE FUNCTION, CREATE PROCEDURE or CREATE TRIGGER")
+		}
+	}
+	if p.dialect == DialectSQLite && objectType != "TRIGGER" {
+		return p.errorf(s, "CREATE %s is not supported by %s", objectType, p.dialect)
+	}
+	s.accept("IF", "NOT", "EXISTS")
+	schemaName, name := p.objectName(s.next())
+	if name == "" {
+		return p.errorf(s, "CREATE %s: missing name", objectType)
+	}
+	sql := s.text(0, s.end) + ";"
+	if objectType == "TRIGGER" {
+		return p.parseTrigger(s, schemaName, name, sql)
+	}
+	routine := Routine{
+		RoutineName: name,
+		RoutineType: objectType,
+		SQL:         sql,
+		Attrs:       make(map[string]string),
+	}
+	var arguments [][2]int
+	if s.peek("(") {
+		arguments = s.elements()
+	}
+	switch p.dialect {
+	case DialectPostgres:
+		routine.IdentityArguments = routineIdentityArguments(p.dialect, s.lintStatement, objectType, arguments)
+	case DialectMySQL:
+		parameters := make([]string, 0, len(arguments))
+		for _, argument := range arguments {
+			start := argument[0]
+			mode := "IN "
+			if objectType == "PROCEDURE" {
+				if token := strings.ToUpper(s.tokens[start]); token == "IN" || token == "OUT" || token == "INOUT" {
+					mode = token + " "
+					start++
+				}
+			}
+			if start+1 >= argument[1] {
+				continue
+			}
+			parameter := p.name(s.tokens[start]) + " " + strings.ToLower(p.typeText(s, start+1, argument[1]))
+			if objectType == "PROCEDURE" {
+				parameter = mode + parameter
+			}
+			parameters = append(parameters, parameter)
+		}
+		routine.Attrs["parameters"] = strings.Join(parameters, "|")
+	}
+	if objectType == "FUNCTION" && s.accept("RETURNS") {
+		start := s.pos
+		for !s.done() && !routineOptionKeywords[strings.ToUpper(s.tokens[s.pos])] {
+			s.skip()
+		}
+		routine.Attrs["returnType"] = p.typeText(s, start, s.pos)
+	}
+	if p.dialect == DialectMySQL {
+		// MySQL only reports the body of a routine, so its characteristics
+		// are compared separately.
+		routine.Attrs["isDeterministic"] = "false"
+		routine.Attrs["sqlDataAccess"] = "CONTAINS SQL"
+		routine.Attrs["securityType"] = "DEFINER"
+		for !s.done() {
+			switch {
+			case s.accept("DETERMINISTIC"):
+				routine.Attrs["isDeterministic"] = "true"
+			case s.accept("NOT", "DETERMINISTIC"):
+				routine.Attrs["isDeterministic"] = "false"
+			case s.accept("CONTAINS", "SQL"):
+				routine.Attrs["sqlDataAccess"] = "CONTAINS SQL"
+			case s.accept("NO", "SQL"):
+				routine.Attrs["sqlDataAccess"] = "NO SQL"
+			case s.accept("READS", "SQL", "DATA"):
+				routine.Attrs["sqlDataAccess"] = "READS SQL DATA"
+			case s.accept("MODIFIES", "SQL", "DATA"):
+				routine.Attrs["sqlDataAccess"] = "MODIFIES SQL DATA"
+			case s.accept("SQL", "SECURITY"):
+				routine.Attrs["securityType"] = strings.ToUpper(s.next())
+			case s.accept("LANGUAGE", "SQL"):
+			case s.accept("COMMENT"):
+				s.skip()
+			default:
+				s.pos = s.end
+			}
+		}
+	}
+	schema := p.cache.GetOrCreateSchema(p.catalog, schemaName)
+	p.cache.AddOrUpdateRoutine(schema, routine)
+	return nil
+}
+
+// parseTrigger parses the rest of a CREATE TRIGGER statement after the
+// trigger name, and adds the trigger to the table (or view) it is on.
+func (p *SQLParser) parseTrigger(s *sqlStatement, schemaName, triggerName, sql string) error {
+	trigger := Trigger{
+		TriggerName: triggerName,
+		SQL:         sql,
+	}
+	var actionTiming, eventManipulation string
+	for !s.done() && !s.peek("ON") {
+		switch token := strings.ToUpper(s.tokens[s.pos]); token {
+		case "BEFORE", "AFTER":
+			actionTiming = token
+		case "INSERT", "UPDATE", "DELETE":
+			if eventManipulation == "" {
+				eventManipulation = token
+			}
+		}
+		s.skip()
+	}
+	if !s.accept("ON") {
+		return p.errorf(s, "CREATE TRIGGER %s: expected ON", triggerName)
+	}
+	tableSchema, tableName := p.objectName(s.next())
+	if p.dialect == DialectMySQL {
+		// MySQL triggers belong to the schema of their table.
+		trigger.Attrs = map[string]string{
+			"actionTiming":      actionTiming,
+			"eventManipulation": eventManipulation,
+		}
+	} else if schemaName != p.catalog.CurrentSchema && tableSchema == p.catalog.CurrentSchema {
+		// The trigger name may be qualified instead of the table name
+		// (SQL Server).
+		tableSchema = schemaName
+	}
+	schema := p.cache.GetSchema(p.catalog, tableSchema)
+	if table := p.cache.GetTable(schema, tableName); table != nil {
+		p.cache.AddOrUpdateTrigger(table, trigger)
+		return nil
+	}
+	if view := p.cache.GetView(schema, tableName); view != nil {
+		trigger.IsViewTrigger = true
+		p.cache.AddOrUpdateViewTrigger(view, trigger)
+		return nil
+	}
+	return p.errorf(s, "CREATE TRIGGER %s: table %s does not exist", triggerName, tableName)
+}
+
+// parseAlterTable parses an ALTER TABLE statement. Only ADD actions are
+// parsed, other actions are skipped.
+//
+//	ALTER TABLE [IF EXISTS] [ONLY] name ADD [CONSTRAINT name] constraint [NOT VALID]
+//	ALTER TABLE [IF EXISTS] [ONLY] name ADD [COLUMN] [IF NOT EXISTS] column_definition
+func (p *SQLParser) parseAlterTable(s *sqlStatement) error {
+	s.accept("IF", "EXISTS")
+	s.accept("ONLY")
+	tableSchema, tableName := p.objectName(s.next())
+	table := p.cache.GetTable(p.cache.GetSchema(p.catalog, tableSchema), tableName)
+	if table == nil {
+		return p.errorf(s, "ALTER TABLE: table %s does not exist", tableName)
+	}
+	_ = s.accept("WITH", "CHECK") || s.accept("WITH", "NOCHECK")
+	for _, action := range s.split(s.pos, s.end) {
+		s.pos, s.end = action[0], action[1]
+		if p.dialect == DialectPostgres && s.accept("ATTACH", "PARTITION") {
+			err := p.parseAttachPartition(s, table)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if p.dialect == DialectPostgres && (s.peek("ENABLE", "ROW", "LEVEL", "SECURITY") || s.peek("DISABLE", "ROW", "LEVEL", "SECURITY")) {
+			table.RowSecurityEnabled = s.accept("ENABLE")
+			p.cache.GetSchema(p.catalog, tableSchema).PoliciesValid = true
+			continue
+		}
+		if s.peek("ALTER") && !s.peek("ALTER", "CONSTRAINT") {
+			err := p.parseAlterColumn(s, table)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if isIgnoredAlterTableAction(s) {
+			continue
+		}
+		if !s.accept("ADD") {
+			return p.errorf(s, "ALTER TABLE %s: unsupported action %s", table.TableName, strings.ToUpper(s.text(s.pos, min(s.pos+2, s.end))))
+		}
+		switch {
+		case s.peek("CONSTRAINT"), s.peek("PRIMARY", "KEY"), s.peek("UNIQUE"), s.peek("FOREIGN", "KEY"), s.peek("CHECK"), s.peek("EXCLUDE"),
+			p.dialect == DialectMySQL && (s.peek("INDEX") || s.peek("KEY") || s.peek("FULLTEXT") || s.peek("SPATIAL")):
+			err := p.parseTableElement(s, table)
+			if err != nil {
+				return err
+			}
+		default:
+			s.accept("COLUMN")
+			s.accept("IF", "NOT", "EXISTS")
+			err := p.parseColumn(s, table)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ignoredAlterTableActions are the ALTER TABLE actions that do not change
+// anything a Catalog describes, so they are skipped.
+var ignoredAlterTableActions = [][]string{
+	{"OWNER", "TO"},
+	{"CLUSTER", "ON"},
+	{"SET", "WITHOUT"},
+	{"SET", "TABLESPACE"},
+	{"SET", "LOGGED"},
+	{"SET", "UNLOGGED"},
+	{"SET", "("},
+	{"RESET", "("},
+	{"REPLICA", "IDENTITY"},
+	{"VALIDATE", "CONSTRAINT"},
+	{"ENABLE", "TRIGGER"},
+	{"ENABLE", "ALWAYS", "TRIGGER"},
+	{"ENABLE", "REPLICA", "TRIGGER"},
+	{"DISABLE", "TRIGGER"},
+	{"FORCE", "ROW", "LEVEL", "SECURITY"},
+	{"NO", "FORCE", "ROW", "LEVEL", "SECURITY"},
+	{"CHECK", "CONSTRAINT"},
+	{"NOCHECK", "CONSTRAINT"},
+}
+
+// isIgnoredAlterTableAction reports whether the ALTER TABLE action at the
+// current token is one of the ignoredAlterTableActions.
+func isIgnoredAlterTableAction(s *sqlStatement) bool {
+	for _, keywords := range ignoredAlterTableActions {
+		if s.peek(keywords...) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAlterColumn parses the ALTER COLUMN action of an ALTER TABLE
+// statement. Changing the type of a column is not supported.
+//
+//	ALTER [COLUMN] column SET DEFAULT expr
+//	ALTER [COLUMN] column DROP DEFAULT
+//	ALTER [COLUMN] column {SET | DROP} NOT NULL
+//	ALTER [COLUMN] column ADD GENERATED {ALWAYS | BY DEFAULT} AS IDENTITY [(options)]
+//	ALTER [COLUMN] column {SET STATISTICS | SET STORAGE | SET COMPRESSION | SET | RESET} ...
+func (p *SQLParser) parseAlterColumn(s *sqlStatement, table *Table) error {
+	s.accept("ALTER")
+	s.accept("COLUMN")
+	columnName := p.name(s.next())
+	column := p.cache.GetColumn(table, columnName)
+	if column == nil {
+		return p.errorf(s, "ALTER TABLE %s: column %s does not exist", table.TableName, columnName)
+	}
+	switch {
+	case s.accept("SET", "DEFAULT"):
+		column.ColumnDefault = p.expr(s, nil)
+	case s.accept("DROP", "DEFAULT"):
+		column.ColumnDefault = ""
+	case s.accept("SET", "NOT", "NULL"):
+		column.IsNotNull = true
+	case s.accept("DROP", "NOT", "NULL"):
+		column.IsNotNull = false
+	case s.accept("ADD", "GENERATED", "ALWAYS", "AS", "IDENTITY"):
+		column.ColumnIdentity = ALWAYS_IDENTITY
+	case s.accept("ADD", "GENERATED", "BY", "DEFAULT", "AS", "IDENTITY"):
+		column.ColumnIdentity = DEFAULT_IDENTITY
+	case s.peek("SET", "STATISTICS"), s.peek("SET", "STORAGE"), s.peek("SET", "COMPRESSION"), s.peek("SET", "("), s.peek("RESET", "("):
+	default:
+		return p.errorf(s, "ALTER TABLE %s: unsupported action ALTER COLUMN %s %s", table.TableName, columnName, s.text(s.pos, s.end))
+	}
+	return nil
+}
+
+// parseDrop parses a DROP statement. Dropping an object that was created by
+// an earlier statement is not supported (remove the statement that creates
+// it instead), but DROP ... IF EXISTS of an object that does not exist yet is
+// skipped so that dumps which drop every object before recreating it (e.g.
+// mysqldump) can be parsed.
+//
+//	DROP {TABLE | VIEW | MATERIALIZED VIEW | INDEX | SEQUENCE | TYPE | DOMAIN | SCHEMA | EXTENSION | ...} [IF EXISTS] name [, ...] [CASCADE | RESTRICT]
+//	DROP INDEX name ON table
+func (p *SQLParser) parseDrop(s *sqlStatement) error {
+	var objectType string
+	switch {
+	case s.accept("MATERIALIZED", "VIEW"):
+		objectType = "MATERIALIZED VIEW"
+	case s.accept("INDEX"):
+		objectType = "INDEX"
+		s.accept("CONCURRENTLY")
+	default:
+		objectType = strings.ToUpper(s.next())
+	}
+	ifExists := s.accept("IF", "EXISTS")
+	for _, element := range s.split(s.pos, s.end) {
+		s.pos = element[0]
+		schemaName, name := p.objectName(s.next())
+		schema := p.cache.GetSchema(p.catalog, schemaName)
+		var exists bool
+		switch objectType {
+		case "TABLE":
+			exists = p.cache.GetTable(schema, name) != nil
+		case "VIEW", "MATERIALIZED VIEW":
+			exists = p.cache.GetView(schema, name) != nil
+		case "INDEX":
+			if s.accept("ON") {
+				tableSchema, tableName := p.objectName(s.next())
+				schema = p.cache.GetSchema(p.catalog, tableSchema)
+				exists = p.cache.GetIndex(p.cache.GetTable(schema, tableName), name) != nil
+			} else if schema != nil {
+				for i := range schema.Tables {
+					if p.cache.GetIndex(&schema.Tables[i], name) != nil {
+						exists = true
+						break
+					}
+				}
+			}
+		case "SEQUENCE":
+			exists = p.cache.GetSequence(schema, name) != nil
+		case "TYPE":
+			exists = p.cache.GetEnum(schema, name) != nil
+		case "DOMAIN":
+			exists = p.cache.GetDomain(schema, name) != nil
+		case "SCHEMA":
+			exists = p.cache.GetSchema(p.catalog, name) != nil
+		case "EXTENSION":
+			exists = slices.Contains(p.catalog.Extensions, name)
+		}
+		if exists || !ifExists {
+			s.pos = element[0]
+			return p.errorf(s, "DROP %s %s: dropping objects is not supported, remove the statement that creates it instead", objectType, name)
+		}
+	}
+	return nil
+}
+
+// parseAttachPartition parses the ATTACH PARTITION action of a Postgres ALTER
+// TABLE statement, which turns an existing table into a partition of the
+// table.
+//
+//	ATTACH PARTITION partition {FOR VALUES ... | DEFAULT}
+func (p *SQLParser) parseAttachPartition(s *sqlStatement, table *Table) error {
+	partitionSchema, partitionName := p.objectName(s.next())
+	partition := p.cache.GetTable(p.cache.GetSchema(p.catalog, partitionSchema), partitionName)
+	if partition == nil {
+		return p.errorf(s, "ALTER TABLE %s: partition %s does not exist", table.TableName, partitionName)
+	}
+	partitionBound, ok := p.partitionBound(s)
+	if !ok {
+		return p.errorf(s, "ALTER TABLE %s: missing partition bound", table.TableName)
+	}
+	partition.PartitionOf = table.TableName
+	if table.TableSchema != partition.TableSchema {
+		partition.PartitionOf = table.TableSchema + "." + table.TableName
+	}
+	partition.PartitionBound = partitionBound
+	return nil
+}
+
+// parseCreatePolicy parses a Postgres CREATE POLICY statement.
+//
+//	CREATE POLICY name ON table [AS {PERMISSIVE | RESTRICTIVE}] [FOR command] [TO role [, ...]] [USING (expr)] [WITH CHECK (expr)]
+func (p *SQLParser) parseCreatePolicy(s *sqlStatement) error {
+	if p.dialect != DialectPostgres {
+		return nil
+	}
+	policyName := p.name(s.next())
+	if policyName == "" {
+		return p.errorf(s, "CREATE POLICY: missing policy name")
+	}
+	if !s.accept("ON") {
+		return p.errorf(s, "CREATE POLICY %s: expected ON", policyName)
+	}
+	tableSchema, tableName := p.objectName(s.next())
+	schema := p.cache.GetSchema(p.catalog, tableSchema)
+	table := p.cache.GetTable(schema, tableName)
+	if table == nil {
+		return p.errorf(s, "CREATE POLICY %s: table %s does not exist", policyName, tableName)
+	}
+	policy := Policy{
+		TableSchema: table.TableSchema,
+		TableName:   table.TableName,
+		PolicyName:  policyName,
+		Command:     "ALL",
+	}
+	for !s.done() {
+		switch {
+		case s.accept("AS", "PERMISSIVE"):
+			policy.IsRestrictive = false
+		case s.accept("AS", "RESTRICTIVE"):
+			policy.IsRestrictive = true
+		case s.accept("FOR"):
+			policy.Command = strings.ToUpper(s.next())
+		case s.accept("TO"):
+			for {
+				role := s.next()
+				if strings.EqualFold(role, "PUBLIC") {
+					policy.Roles = append(policy.Roles, "PUBLIC")
+				} else {
+					policy.Roles = append(policy.Roles, p.name(role))
+				}
+				if !s.accept(",") {
+					break
+				}
+			}
+		case s.accept("USING"):
+			policy.UsingExpr = p.groupText(s)
+		case s.accept("WITH", "CHECK"):
+			policy.CheckExpr = p.groupText(s)
+		default:
+			s.skip()
+		}
+	}
+	if len(policy.Roles) == 1 && policy.Roles[0] == "PUBLIC" {
+		policy.Roles = nil
+	}
+	schema.PoliciesValid = true
+	p.cache.AddOrUpdatePolicy(table, policy)
+	return nil
+}
+
+// parseGrant parses a GRANT statement on a schema, table, view or sequence.
+// Role memberships, column privileges and privileges on any other kind of
+// object are ignored.
+//
+//	GRANT privilege [, ...] ON [TABLE | SEQUENCE | SCHEMA] name [, ...] TO grantee [, ...] [WITH GRANT OPTION]
+//	GRANT privilege [, ...] ON {schema.* | [schema.]table} TO 'user'@'host' [, ...] [WITH GRANT OPTION] -- MySQL
+//	GRANT privilege [, ...] ON {SCHEMA::name | [OBJECT::]name} TO principal [, ...] [WITH GRANT OPTION] -- SQL Server
+func (p *SQLParser) parseGrant(s *sqlStatement) error {
+	if p.dialect == DialectSQLite {
+		return nil
+	}
+	start := s.pos
+	for !s.done() && !s.peek("ON") {
+		if s.peek("(") {
+			return nil
+		}
+		s.pos++
+	}
+	if s.done() {
+		return nil
+	}
+	var privileges []string
+	for _, element := range s.split(start, s.pos) {
+		privileges = append(privileges, strings.ToUpper(strings.Join(s.tokens[element[0]:element[1]], " ")))
+	}
+	s.accept("ON")
+	var objectType string
+	switch {
+	case s.accept("TABLE"):
+		objectType = "TABLE"
+	case s.accept("SEQUENCE"):
+		objectType = "SEQUENCE"
+	case s.accept("SCHEMA"):
+		objectType = "SCHEMA"
+		s.accept(":", ":")
+	case p.dialect == DialectSQLServer && s.accept("OBJECT", ":", ":"):
+	}
+	start = s.pos
+	for !s.done() && !s.peek("TO") {
+		s.pos++
+	}
+	objects := s.split(start, s.pos)
+	if !s.accept("TO") {
+		return nil
+	}
+	start = s.pos
+	for !s.done() && !s.peek("WITH") && !s.peek("AS") && !s.peek("GRANTED", "BY") {
+		s.pos++
+	}
+	grantees := s.split(start, s.pos)
+	isGrantable := s.accept("WITH", "GRANT", "OPTION")
+	for _, object := range objects {
+		grant := Grant{ObjectType: objectType, IsGrantable: isGrantable}
+		tokens := s.tokens[object[0]:object[1]]
+		switch {
+		case p.dialect == DialectMySQL && len(tokens) == 3 && tokens[1] == "." && tokens[2] == "*":
+			grant.ObjectType = "SCHEMA"
+			grant.GrantSchema = p.name(tokens[0])
+		case len(tokens) != 1:
+			// Privileges on functions, databases, *.* and so on.
+			return nil
+		case grant.ObjectType == "SCHEMA":
+			grant.GrantSchema = p.name(tokens[0])
+		default:
+			grant.GrantSchema, grant.ObjectName = p.objectName(tokens[0])
+		}
+		if grant.ObjectType == "SCHEMA" && grant.GrantSchema == "" {
+			return nil
+		}
+		if slices.Contains(privileges, "ALL") || slices.Contains(privileges, "ALL PRIVILEGES") {
+			return p.errorf(s, "GRANT ALL is not supported, list the privileges instead")
+		}
+		if grant.ObjectType == "" {
+			grant.ObjectType = "TABLE"
+		}
+		schema := p.cache.GetOrCreateSchema(p.catalog, grant.GrantSchema)
+		schema.GrantsValid = true
+		for _, grantee := range grantees {
+			grant.Grantee = p.grantee(s.tokens[grantee[0]:grantee[1]])
+			for _, privilege := range privileges {
+				grant.Privilege = privilege
+				p.cache.AddOrUpdateGrant(schema, grant)
+			}
+		}
+	}
+	return nil
+}
+
+// grantee returns the grantee made up of the tokens, in the form used by
+// Grant.Grantee. MySQL accounts are normalized to 'user'@'host'.
+func (p *SQLParser) grantee(tokens []string) string {
+	if len(tokens) > 1 && strings.EqualFold(tokens[0], "GROUP") {
+		tokens = tokens[1:]
+	}
+	if p.dialect == DialectMySQL {
+		user, host, _ := strings.Cut(strings.Join(tokens, ""), "@")
+		if host == "" {
+			host = "%"
+		}
+		return "'" + strings.Trim(user, "'\"`") + "'@'" + strings.Trim(host, "'\"`") + "'"
+	}
+	if len(tokens) == 0 {
+		return ""
+	}
+	if p.dialect == DialectPostgres && strings.EqualFold(tokens[0], "PUBLIC") {
+		return "PUBLIC"
+	}
+	return p.name(tokens[0])
+}
+
+// parseCommentOn parses a COMMENT ON statement.
+//
+//	COMMENT ON {SCHEMA name | TABLE name | [MATERIALIZED] VIEW name | COLUMN table.column} IS {'comment' | NULL}
+func (p *SQLParser) parseCommentOn(s *sqlStatement) error {
+	var objectType string
+	switch {
+	case s.accept("SCHEMA"):
+		objectType = "SCHEMA"
+	case s.accept("TABLE"):
+		objectType = "TABLE"
+	case s.accept("VIEW"), s.accept("MATERIALIZED", "VIEW"):
+		objectType = "VIEW"
+	case s.accept("COLUMN"):
+		objectType = "COLUMN"
+	default:
+		return nil
+	}
+	parts := p.identifier(s.next())
+	if !s.accept("IS") {
+		return p.errorf(s, "COMMENT ON %s: expected IS", objectType)
+	}
+	comment, _ := p.unquoteString(s.next())
+	switch objectType {
+	case "SCHEMA":
+		if len(parts) > 0 {
+			p.cache.GetOrCreateSchema(p.catalog, parts[len(parts)-1]).Comment = comment
+		}
+	case "TABLE":
+		return p.setComment(s, parts, "", comment)
+	case "VIEW":
+		if len(parts) == 0 {
+			return nil
+		}
+		viewSchema, viewName := p.catalog.CurrentSchema, parts[len(parts)-1]
+		if len(parts) > 1 {
+			viewSchema = parts[len(parts)-2]
+		}
+		view := p.cache.GetView(p.cache.GetSchema(p.catalog, viewSchema), viewName)
+		if view == nil {
+			return p.errorf(s, "comment: view %s does not exist", viewName)
+		}
+		view.Comment = comment
+	case "COLUMN":
+		if len(parts) < 2 {
+			return p.errorf(s, "COMMENT ON COLUMN: invalid column name")
+		}
+		return p.setComment(s, parts[:len(parts)-1], parts[len(parts)-1], comment)
+	}
+	return nil
+}
+
+// extendedPropertyArgs are the parameters of sp_addextendedproperty, in
+// order.
+var extendedPropertyArgs = []string{
+	"@name", "@value", "@level0type", "@level0name", "@level1type",
+	"@level1name", "@level2type", "@level2name",
+}
+
+// parseExec parses the SQL Server EXEC statements that define a schema: SQL
+// executed dynamically by EXEC('...') or sp_executesql, and comments added by
+// sp_addextendedproperty.
+//
+//	[IF condition] EXEC('sql')
+//	EXEC sp_executesql N'sql'
+//	EXEC sp_addextendedproperty @name = N'MS_Description', @value = N'comment', @level0type = N'SCHEMA', @level0name = N'dbo', ...
+func (p *SQLParser) parseExec(s *sqlStatement) error {
+	if p.dialect != DialectSQLServer {
+		return nil
+	}
+	for !s.done() && !s.peek("EXEC") && !s.peek("EXECUTE") {
+		s.skip()
+	}
+	if !s.accept("EXEC") && !s.accept("EXECUTE") {
+		return nil
+	}
+	if s.peek("(") {
+		end := s.end
+		s.pos, s.end = s.group()
+		sql, ok := p.unquoteString(s.next())
+		s.end = end
+		if !ok {
+			return nil
+		}
+		return p.parseSQL(s.filename, sql, s.currentLine())
+	}
+	procedure := lintObjectName(s.next())
+	switch procedure {
+	case "sp_executesql":
+		sql, ok := p.unquoteString(s.next())
+		if !ok {
+			return nil
+		}
+		return p.parseSQL(s.filename, sql, s.currentLine())
+	case "sp_addextendedproperty", "sp_updateextendedproperty":
+		args := make(map[string]string)
+		for i, arg := range s.split(s.pos, s.end) {
+			s.pos, s.end = arg[0], arg[1]
+			name := ""
+			if i < len(extendedPropertyArgs) {
+				name = extendedPropertyArgs[i]
+			}
+			if strings.HasPrefix(s.tokens[s.pos], "@") && s.pos+1 < s.end && s.tokens[s.pos+1] == "=" {
+				name = strings.ToLower(s.tokens[s.pos])
+				s.pos += 2
+			}
+			args[name], _ = p.unquoteString(s.next())
+		}
+		if args["@name"] != "MS_Description" || !strings.EqualFold(args["@level0type"], "SCHEMA") || !strings.EqualFold(args["@level1type"], "TABLE") {
+			return nil
+		}
+		parts := []string{args["@level0name"], args["@level1name"]}
+		if strings.EqualFold(args["@level2type"], "COLUMN") {
+			return p.setComment(s, parts, args["@level2name"], args["@value"])
+		}
+		return p.setComment(s, parts, "", args["@value"])
+	}
+	return nil
+}
+
+// setComment sets the comment of a table (or of its column if the columnName
+// is not empty).
+func (p *SQLParser) setComment(s *sqlStatement, tableParts []string, columnName, comment string) error {
+	if p.dialect == DialectSQLite || len(tableParts) == 0 {
+		return nil
+	}
+	tableSchema, tableName := p.catalog.CurrentSchema, tableParts[len(tableParts)-1]
+	if len(tableParts) > 1 {
+		tableSchema = tableParts[len(tableParts)-2]
+	}
+	table := p.cache.GetTable(p.cache.GetSchema(p.catalog, tableSchema), tableName)
+	if table == nil {
+		return p.errorf(s, "comment: table %s does not exist", tableName)
+	}
+	if columnName == "" {
+		table.Comment = comment
+		return nil
+	}
+	column := p.cache.GetColumn(table, columnName)
+	if column == nil {
+		return p.errorf(s, "comment: column %s.%s does not exist", tableName, columnName)
+	}
+	column.Comment = comment
+	return nil
+}
+
+// addConstraint adds a constraint to the table. Unnamed constraints are
+// named the same way the StructParser names them.
+func (p *SQLParser) addConstraint(table *Table, constraint Constraint) {
+	if constraint.ConstraintName == "" || (constraint.ConstraintType == PRIMARY_KEY && p.dialect == DialectMySQL) {
+		switch {
+		case constraint.ConstraintType == PRIMARY_KEY && p.dialect == DialectMySQL:
+			constraint.ConstraintName = "PRIMARY"
+		case constraint.ConstraintType == EXCLUDE:
+			constraint.ConstraintName = GenerateName(EXCLUDE, table.TableName, nil)
+		default:
+			constraint.ConstraintName = GenerateName(constraint.ConstraintType, table.TableName, constraint.Columns)
+		}
+	}
+	if constraint.ConstraintType == CHECK {
+		// Only column-level check constraints keep their column (it is used
+		// to name the constraint).
+		constraint.Columns = nil
+	}
+	p.cache.AddOrUpdateConstraint(table, constraint)
+}
+
+// resolveTables fills in the information about the parsed tables that
+// depends on other statements: the column flags of single-column
+// constraints, the columns of foreign keys that reference a primary key
+// implicitly, the NOT NULL-ness of primary key columns and the enum and
+// domain types of columns.
+func (p *SQLParser) resolveTables() {
+	for _, tableID := range p.tables {
+		table := p.cache.GetTable(p.cache.GetSchema(p.catalog, tableID[0]), tableID[1])
+		if table == nil || table.IsVirtual {
+			continue
+		}
+		for i := range table.Constraints {
+			constraint := &table.Constraints[i]
+			if constraint.ConstraintType == FOREIGN_KEY && len(constraint.ReferencesColumns) == 0 {
+				refTable := p.cache.GetTable(p.cache.GetSchema(p.catalog, constraint.ReferencesSchema), constraint.ReferencesTable)
+				if pkey := p.cache.GetPrimaryKey(refTable); pkey != nil {
+					constraint.ReferencesColumns = pkey.Columns
+				}
+			}
+			if len(constraint.Columns) != 1 {
+				continue
+			}
+			column := p.cache.GetColumn(table, constraint.Columns[0])
+			if column == nil {
+				continue
+			}
+			switch constraint.ConstraintType {
+			case PRIMARY_KEY:
+				column.IsPrimaryKey = true
+			case UNIQUE:
+				column.IsUnique = true
+			case FOREIGN_KEY:
+				if len(constraint.ReferencesColumns) != 1 {
+					continue
+				}
+				column.ReferencesSchema = constraint.ReferencesSchema
+				column.ReferencesTable = constraint.ReferencesTable
+				column.ReferencesColumn = constraint.ReferencesColumns[0]
+				column.UpdateRule = constraint.UpdateRule
+				column.DeleteRule = constraint.DeleteRule
+				column.IsDeferrable = constraint.IsDeferrable
+				column.IsInitiallyDeferred = constraint.IsInitiallyDeferred
+			}
+		}
+		// Primary key columns are implicitly NOT NULL (except in SQLite,
+		// which allows NULLs in primary keys that are not INTEGER PRIMARY
+		// KEY).
+		if pkey := p.cache.GetPrimaryKey(table); pkey != nil && p.dialect != DialectSQLite {
+			for _, columnName := range pkey.Columns {
+				if column := p.cache.GetColumn(table, columnName); column != nil {
+					column.IsNotNull = true
+				}
+			}
+		}
+		if p.dialect != DialectPostgres {
+			continue
+		}
+		for i := range table.Columns {
+			column := &table.Columns[i]
+			parts := p.identifier(strings.TrimSuffix(column.ColumnType, "[]"))
+			if len(parts) == 0 {
+				continue
+			}
+			typeSchema, typeName := table.TableSchema, parts[len(parts)-1]
+			if len(parts) > 1 {
+				typeSchema = parts[len(parts)-2]
+			}
+			schema := p.cache.GetSchema(p.catalog, typeSchema)
+			if p.cache.GetEnum(schema, typeName) != nil {
+				column.IsEnum = true
+			} else if domain := p.cache.GetDomain(schema, typeName); domain != nil {
+				column.DomainName = column.ColumnType
+				column.ColumnType = domain.UnderlyingType
+			}
+		}
+	}
+}
+
+// identifier returns the unquoted parts of a (possibly qualified and quoted)
+// identifier. Postgres folds unquoted identifiers to lowercase.
+func (p *SQLParser) identifier(token string) []string {
+	if token == "" || token[0] == '\'' || !(isIdentifierChar(token[0]) || token[0] == '"' || token[0] == '`' || token[0] == '[') {
+		return nil
+	}
+	if p.dialect == DialectPostgres {
+		b := []byte(token)
+		inQuotes := false
+		for i, char := range b {
+			if char == '"' {
+				inQuotes = !inQuotes
+			} else if !inQuotes && char >= 'A' && char <= 'Z' {
+				b[i] = char + 'a' - 'A'
+			}
+		}
+		token = string(b)
+	}
+	return splitIdentifier(token)
+}
+
+// name returns the unqualified name of an identifier.
+func (p *SQLParser) name(token string) string {
+	parts := p.identifier(token)
+	if len(parts) == 0 {
+		return ""
+	}
+	return parts[len(parts)-1]
+}
+
+// objectName returns the schema and name of a (possibly qualified) object
+// identifier. Unqualified objects belong to the Catalog's CurrentSchema.
+func (p *SQLParser) objectName(token string) (schemaName, name string) {
+	parts := p.identifier(token)
+	if len(parts) == 0 {
+		return "", ""
+	}
+	name = parts[len(parts)-1]
+	if p.dialect == DialectSQLite {
+		return "", name
+	}
+	if len(parts) > 1 {
+		return parts[len(parts)-2], name
+	}
+	return p.catalog.CurrentSchema, name
+}
+
+// columnNames parses a bracketed list of column names. Anything following a
+// column name (e.g. ASC, DESC or a MySQL index prefix length) is ignored.
+func (p *SQLParser) columnNames(s *sqlStatement) []string {
+	var columnNames []string
+	for _, element := range s.elements() {
+		if s.tokens[element[0]] == "(" {
+			columnNames = append(columnNames, s.text(element[0], element[1]))
+			continue
+		}
+		columnNames = append(columnNames, p.name(s.tokens[element[0]]))
+	}
+	return columnNames
+}
+
+// typeText returns the column type made up of the tokens between start and
+// end, e.g. NUMERIC ( 5 , 2 ) becomes NUMERIC(5,2).
+func (p *SQLParser) typeText(s *sqlStatement, start, end int) string {
+	var b strings.Builder
+	for i := start; i < end; i++ {
+		token := s.tokens[i]
+		if i > start {
+			switch token {
+			case "(", ")", ",", "[", "]":
+			default:
+				switch s.tokens[i-1] {
+				case "(", ",", "[":
+				default:
+					b.WriteString(" ")
+				}
+			}
+		}
+		if token[0] == '[' && p.dialect == DialectSQLServer {
+			token = strings.Join(splitIdentifier(token), ".")
+		}
+		b.WriteString(token)
+	}
+	return b.String()
+}
+
+// groupText returns the SQL inside the bracketed group at the current token,
+// and moves past the group.
+func (p *SQLParser) groupText(s *sqlStatement) string {
+	if !s.peek("(") {
+		return ""
+	}
+	start, end := s.group()
+	return s.text(start, end)
+}
+
+// expr parses an expression (e.g. a column default) that ends at the next
+// keyword or at the end of the statement.
+func (p *SQLParser) expr(s *sqlStatement, keywords map[string]bool) string {
+	start := s.pos
+	s.skip()
+	for !s.done() && !keywords[strings.ToUpper(s.tokens[s.pos])] {
+		s.skip()
+	}
+	return s.text(start, s.pos)
+}
+
+// number parses an integer, which may be preceded by a sign.
+func (p *SQLParser) number(s *sqlStatement) (int64, bool) {
+	sign := ""
+	if s.accept("-") {
+		sign = "-"
+	} else {
+		s.accept("+")
+	}
+	n, err := strconv.ParseInt(sign+s.next(), 10, 64)
+	return n, err == nil
+}
+
+// unquoteString unquotes an SQL string literal. It also accepts NULL, which
+// is returned as an empty string.
+func (p *SQLParser) unquoteString(token string) (string, bool) {
+	if strings.EqualFold(token, "NULL") {
+		return "", true
+	}
+	if len(token) < 2 || token[0] != '\'' || token[len(token)-1] != '\'' {
+		return "", false
+	}
+	str := strings.ReplaceAll(token[1:len(token)-1], "''", "'")
+	if p.dialect == DialectMySQL {
+		str = strings.ReplaceAll(str, `\\`, `\`)
+	}
+	return str, true
+}
+
+// errorf returns an error annotated with the filename and line number of the
+// current token.
+func (p *SQLParser) errorf(s *sqlStatement, format string, a ...any) error {
+	return fmt.Errorf("%s:%d: %s", s.filename, s.currentLine(), fmt.Sprintf(format, a...))
+}
+
+// done reports whether there are no tokens left.
+func (s *sqlStatement) done() bool {
+	return s.pos >= s.end
+}
+
+// peek reports whether the next tokens match the keywords (case
+// insensitive).
+func (s *sqlStatement) peek(keywords ...string) bool {
+	return s.pos < s.end && matchKeywords(s.tokens[s.pos:s.end], keywords...)
+}
+
+// accept moves past the keywords if the next tokens match them.
+func (s *sqlStatement) accept(keywords ...string) bool {
+	if !s.peek(keywords...) {
+		return false
+	}
+	s.pos += len(keywords)
+	return true
+}
+
+// next returns the next token and moves past it. If there are no tokens left,
+// it returns an empty string. SQL Server's N prefix of Unicode strings is
+// skipped.
+func (s *sqlStatement) next() string {
+	if s.done() {
+		return ""
+	}
+	token := s.tokens[s.pos]
+	s.pos++
+	if (token == "N" || token == "n") && !s.done() && s.tokens[s.pos][0] == '\'' && s.offsets[s.pos] == s.offsets[s.pos-1]+1 {
+		token = s.tokens[s.pos]
+		s.pos++
+	}
+	return token
+}
+
+// skip moves past the next token, or past the whole bracketed group if the
+// next token is an opening bracket.
+func (s *sqlStatement) skip() {
+	if s.peek("(") {
+		s.group()
+		return
+	}
+	s.pos++
+}
+
+// group moves past the bracketed group at the current token and returns the
+// range of tokens inside the brackets.
+func (s *sqlStatement) group() (start, end int) {
+	start = s.pos + 1
+	depth := 0
+	for s.pos < s.end {
+		switch s.tokens[s.pos] {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		}
+		s.pos++
+		if depth == 0 {
+			return start, s.pos - 1
+		}
+	}
+	return start, s.pos
+}
+
+// elements moves past the bracketed group at the current token and returns
+// the ranges of its comma-separated elements.
+func (s *sqlStatement) elements() [][2]int {
+	start, end := s.group()
+	return s.split(start, end)
+}
+
+// split returns the ranges of the comma-separated elements between start and
+// end. Commas inside brackets are ignored.
+func (s *sqlStatement) split(start, end int) [][2]int {
+	var ranges [][2]int
+	depth := 0
+	elementStart := start
+	for i := start; i < end; i++ {
+		switch s.tokens[i] {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case ",":
+			if depth == 0 {
+				if i > elementStart {
+					ranges = append(ranges, [2]int{elementStart, i})
+				}
+				elementStart = i + 1
+			}
+		}
+	}
+	if end > elementStart {
+		ranges = append(ranges, [2]int{elementStart, end})
+	}
+	return ranges
+}
+
+// text returns the SQL of the tokens between start and end, as written in the
+// source.
+func (s *sqlStatement) text(start, end int) string {
+	if start >= end {
+		return ""
+	}
+	return s.contents[s.offsets[start] : s.offsets[end-1]+len(s.tokens[end-1])]
+}
+
+// currentLine returns the line number of the current token.
+func (s *sqlStatement) currentLine() int {
+	if len(s.offsets) == 0 {
+		return s.line
+	}
+	i := min(s.pos, len(s.offsets)-1)
+	return s.line + strings.Count(s.contents[s.offsets[0]:s.offsets[i]], "\n")
+}
@@ -0,0 +1,886 @@
+package ddl
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/blink-io/sqddl/internal/testutil"
+)
+
+// parseSQLFiles parses the SQL files into a new Catalog.
+func parseSQLFiles(dialect, currentSchema string, filenames ...string) (*Catalog, error) {
+	p := NewSQLParser()
+	for _, filename := range filenames {
+		file, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		err = p.ParseFile(file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	catalog := &Catalog{Dialect: dialect, CurrentSchema: currentSchema}
+	err := p.WriteCatalog(catalog)
+	if err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
+
+// migrationSQL returns the SQL of the migration from srcCatalog to
+// destCatalog.
+func migrationSQL(srcCatalog, destCatalog *Catalog) (string, error) {
+	cmd := &GenerateCmd{
+		SrcCatalog:  srcCatalog,
+		DestCatalog: destCatalog,
+		DropObjects: true,
+		Dialect:     srcCatalog.Dialect,
+		Prefix:      "test",
+	}
+	files, _, err := cmd.Results()
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, file := range files {
+		if fileinfo, err := file.Stat(); err == nil && (strings.HasSuffix(fileinfo.Name(), ".undo.sql") || strings.HasSuffix(fileinfo.Name(), ".down.sql")) {
+			file.Close()
+			continue
+		}
+		_, err = io.Copy(&b, file)
+		file.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+func TestSQLParser(t *testing.T) {
+	// The migration generated from testdata/tables.go.txt should parse back
+	// into a Catalog that is identical to the one it was generated from.
+	for _, dialect := range []string{DialectSQLite, DialectPostgres, DialectMySQL, DialectSQLServer} {
+		dialect := dialect
+		t.Run(dialect, func(t *testing.T) {
+			t.Parallel()
+			file, err := os.Open("testdata/tables.go.txt")
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			defer file.Close()
+			p := NewStructParser(nil)
+			err = p.ParseFile(file)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			destCatalog := &Catalog{Dialect: dialect}
+			err = p.WriteCatalog(destCatalog)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			// Generated columns without an expression are not written by
+			// CREATE TABLE, so they cannot be parsed back.
+			for i := range destCatalog.Schemas {
+				for j := range destCatalog.Schemas[i].Tables {
+					table := &destCatalog.Schemas[i].Tables[j]
+					table.Columns = slices.DeleteFunc(table.Columns, func(column Column) bool {
+						return column.IsGenerated && column.GeneratedExpr == ""
+					})
+				}
+			}
+			createSQL, err := migrationSQL(&Catalog{Dialect: dialect}, destCatalog)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			sqlParser := NewSQLParser()
+			err = sqlParser.ParseFile(&bufferFile{name: "tables.sql", buf: bytes.NewBufferString(createSQL)})
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			srcCatalog := &Catalog{Dialect: dialect}
+			err = sqlParser.WriteCatalog(srcCatalog)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			gotSQL, err := migrationSQL(srcCatalog, destCatalog)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			if gotSQL != "" {
+				t.Errorf(testutil.Callers()+" expected no migration, got:\n%s", gotSQL)
+			}
+		})
+	}
+}
+
+func TestSQLParser_Dump(t *testing.T) {
+	// The SQL files written by `sqddl dump` should parse into the same
+	// tables, columns, constraints and indexes as the schema.json written
+	// alongside them.
+	tests := []struct {
+		dialect       string
+		currentSchema string
+	}{
+		{DialectSQLite, ""},
+		{DialectPostgres, "public"},
+		{DialectMySQL, "sakila"},
+		{DialectSQLServer, "dbo"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.dialect, func(t *testing.T) {
+			t.Parallel()
+			dir := "testdata/" + tt.dialect
+			gotCatalog, err := parseSQLFiles(tt.dialect, tt.currentSchema, dir+"/schema.sql", dir+"/indexes.sql", dir+"/constraints.sql")
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			wantCatalog := &Catalog{}
+			err = writeCatalog(wantCatalog, dirFS("."), "", dir+"/schema.json")
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			if diff := testutil.Diff(catalogOutline(gotCatalog), catalogOutline(wantCatalog)); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+		})
+	}
+}
+
+// catalogOutline describes the tables, columns, constraints and indexes of
+// a catalog, one line each. It leaves out what the SQL files written by
+// `sqddl dump` do not capture (views, the columns of virtual tables and the
+// types of generated columns) as well as SQLite constraint names, check
+// constraints and expression index columns, which testdata/sqlite/schema.json
+// predates.
+func catalogOutline(catalog *Catalog) []string {
+	var outline []string
+	for _, schema := range catalog.Schemas {
+		for _, table := range schema.Tables {
+			tableName := schema.SchemaName + "." + table.TableName
+			outline = append(outline, "TABLE "+tableName)
+			for _, column := range table.Columns {
+				if table.IsVirtual || strings.HasPrefix(table.SQL, "CREATE VIRTUAL TABLE") {
+					break
+				}
+				if column.IsGenerated || column.GeneratedExpr != "" {
+					outline = append(outline, "COLUMN "+tableName+"."+column.ColumnName+" generated")
+					continue
+				}
+				normalizedType, arg1, arg2 := normalizeColumnType(catalog.Dialect, column.ColumnType)
+				outline = append(outline, fmt.Sprintf("COLUMN %s.%s %s(%s,%s) notnull=%t pk=%t unique=%t references=%s.%s identity=%q default=%q",
+					tableName, column.ColumnName, normalizedType, arg1, arg2, column.IsNotNull, column.IsPrimaryKey, column.IsUnique,
+					column.ReferencesTable, column.ReferencesColumn, column.ColumnIdentity, column.ColumnDefault,
+				))
+			}
+			for _, constraint := range table.Constraints {
+				if catalog.Dialect == DialectSQLite {
+					if constraint.ConstraintType == CHECK {
+						continue
+					}
+					constraint.ConstraintName = ""
+				}
+				outline = append(outline, fmt.Sprintf("CONSTRAINT %s.%s %s %v references=%s%v",
+					tableName, constraint.ConstraintName, constraint.ConstraintType, constraint.Columns,
+					constraint.ReferencesTable, constraint.ReferencesColumns,
+				))
+			}
+			for _, index := range table.Indexes {
+				columns := fmt.Sprint(index.Columns)
+				if len(index.Columns) == 0 || slices.ContainsFunc(index.Columns, func(column string) bool {
+					return column == "" || column[0] == '('
+				}) {
+					// testdata/sqlite/schema.json does not have the columns
+					// of expression indexes.
+					columns = "<expr>"
+				}
+				outline = append(outline, fmt.Sprintf("INDEX %s.%s unique=%t %s", tableName, index.IndexName, index.IsUnique, columns))
+			}
+		}
+	}
+	slices.Sort(outline)
+	return outline
+}
+
+func TestSQLParser_Statements(t *testing.T) {
+	type TT struct {
+		description   string
+		dialect       string
+		currentSchema string
+		sql           string
+		check         func(t *testing.T, cache *CatalogCache, catalog *Catalog)
+	}
+
+	tests := []TT{{
+		description:   "postgres",
+		dialect:       DialectPostgres,
+		currentSchema: "public",
+		sql: `
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+CREATE TYPE mpaa_rating AS ENUM ('G', 'PG', 'it''s');
+CREATE DOMAIN year AS INT CHECK (VALUE >= 1901) CHECK (VALUE <= 2155);
+-- a comment; with a semicolon
+CREATE TABLE Film (
+    film_id INT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY
+    ,"Title" TEXT NOT NULL /* another comment */
+    ,release_year year
+    ,rating mpaa_rating DEFAULT 'G'::mpaa_rating
+    ,price NUMERIC(5, 2) DEFAULT 4.99 NOT NULL
+    ,search TSVECTOR GENERATED ALWAYS AS (to_tsvector('english', "Title")) STORED
+    ,EXCLUDE USING gist (film_id WITH =) WHERE (film_id > 0)
+);
+CREATE TABLE film_actor (
+    film_id INT REFERENCES film ON DELETE CASCADE
+    ,actor_id INT
+    ,PRIMARY KEY (film_id, actor_id)
+);
+ALTER TABLE film_actor ADD CONSTRAINT film_actor_actor_id_check CHECK (actor_id > 0) NOT VALID;
+CREATE UNIQUE INDEX film_title_idx ON public.film USING btree (lower("Title") DESC, price) INCLUDE (rating) WHERE price > 0;
+COMMENT ON TABLE film IS 'all the films';
+COMMENT ON COLUMN public.film."Title" IS 'the title';
+CREATE MATERIALIZED VIEW film_titles AS SELECT "Title" FROM film;
+COMMENT ON MATERIALIZED VIEW public.film_titles IS 'the film titles';
+`,
+		check: func(t *testing.T, cache *CatalogCache, catalog *Catalog) {
+			if diff := testutil.Diff(catalog.Extensions, []string{"pgcrypto"}); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+			schema := cache.GetSchema(catalog, "public")
+			if diff := testutil.Diff(cache.GetEnum(schema, "mpaa_rating").EnumLabels, []string{"G", "PG", "it's"}); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+			if diff := testutil.Diff(cache.GetDomain(schema, "year").CheckNames, []string{"year_check", "year_check1"}); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+			film := cache.GetTable(schema, "film")
+			if film == nil {
+				t.Fatal(testutil.Callers(), "table film not found")
+			}
+			if diff := testutil.Diff(film.Comment, "all the films"); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+			if view := cache.GetView(schema, "film_titles"); view == nil || view.Comment != "the film titles" {
+				t.Errorf(testutil.Callers()+" invalid view %+v", view)
+			}
+			wantColumns := []Column{
+				{TableSchema: "public", TableName: "film", ColumnName: "film_id", ColumnType: "INT", IsPrimaryKey: true, IsNotNull: true, ColumnIdentity: DEFAULT_IDENTITY},
+				{TableSchema: "public", TableName: "film", ColumnName: "Title", ColumnType: "TEXT", IsNotNull: true, Comment: "the title"},
+				{TableSchema: "public", TableName: "film", ColumnName: "release_year", ColumnType: "INT", DomainName: "year"},
+				{TableSchema: "public", TableName: "film", ColumnName: "rating", ColumnType: "mpaa_rating", IsEnum: true, ColumnDefault: "'G'::mpaa_rating"},
+				{TableSchema: "public", TableName: "film", ColumnName: "price", ColumnType: "NUMERIC(5,2)", NumericPrecision: "5", NumericScale: "2", IsNotNull: true, ColumnDefault: "4.99"},
+				{TableSchema: "public", TableName: "film", ColumnName: "search", ColumnType: "TSVECTOR", GeneratedExpr: `to_tsvector('english', "Title")`, GeneratedExprStored: true},
+			}
+			if diff := testutil.Diff(film.Columns, wantColumns); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+			exclude := cache.GetConstraint(film, "film_excl")
+			if exclude == nil {
+				t.Fatal(testutil.Callers(), "constraint film_excl not found")
+			}
+			if diff := testutil.Diff(exclude.ExclusionOperators, []string{"="}); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+			index := cache.GetIndex(film, "film_title_idx")
+			if index == nil {
+				t.Fatal(testutil.Callers(), "index film_title_idx not found")
+			}
+			wantIndex := Index{
+				TableSchema:    "public",
+				TableName:      "film",
+				IndexName:      "film_title_idx",
+				IndexType:      "btree",
+				IsUnique:       true,
+				Columns:        []string{`(lower("Title"))`, "price"},
+				Descending:     []bool{true, false},
+				IncludeColumns: []string{"rating"},
+				Predicate:      "price > 0",
+			}
+			if diff := testutil.Diff(*index, wantIndex); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+			filmActor := cache.GetTable(schema, "film_actor")
+			fkey := cache.GetConstraint(filmActor, "film_actor_film_id_fkey")
+			if fkey == nil {
+				t.Fatal(testutil.Callers(), "constraint film_actor_film_id_fkey not found")
+			}
+			if diff := testutil.Diff(fkey.ReferencesColumns, []string{"film_id"}); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+			if diff := testutil.Diff(fkey.DeleteRule, CASCADE); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+			check := cache.GetConstraint(filmActor, "film_actor_actor_id_check")
+			if check == nil || !check.IsNotValid || check.CheckExpr != "actor_id > 0" {
+				t.Errorf(testutil.Callers()+" invalid check constraint %+v", check)
+			}
+		},
+	}, {
+		description:   "postgres sequences",
+		dialect:       DialectPostgres,
+		currentSchema: "public",
+		sql: `
+CREATE SEQUENCE order_number_seq START WITH 1000;
+CREATE SEQUENCE IF NOT EXISTS invoice_seq AS INT INCREMENT BY 10 NO MAXVALUE CACHE 20 CYCLE;
+CREATE SEQUENCE countdown_seq INCREMENT -1;
+CREATE TEMP SEQUENCE scratch_seq;
+CREATE TABLE invoice (
+    invoice_number INT DEFAULT nextval('invoice_seq')
+);
+ALTER SEQUENCE invoice_seq OWNED BY invoice.invoice_number;
+ALTER SEQUENCE order_number_seq MAXVALUE 999999;
+`,
+		check: func(t *testing.T, cache *CatalogCache, catalog *Catalog) {
+			schema := cache.GetSchema(catalog, "public")
+			if !schema.SequencesValid {
+				t.Error(testutil.Callers(), "SequencesValid not set")
+			}
+			wantSequences := []Sequence{{
+				SequenceSchema: "public", SequenceName: "order_number_seq", DataType: "bigint",
+				StartValue: 1000, Increment: 1, MinValue: 1, MaxValue: 999999,
+			}, {
+				SequenceSchema: "public", SequenceName: "invoice_seq", DataType: "integer",
+				StartValue: 1, Increment: 10, MinValue: 1, MaxValue: 2147483647, IsCycle: true,
+				OwnedByTable: "invoice", OwnedByColumn: "invoice_number",
+			}, {
+				SequenceSchema: "public", SequenceName: "countdown_seq", DataType: "bigint",
+				StartValue: -1, Increment: -1, MinValue: -9223372036854775808, MaxValue: -1,
+			}}
+			if diff := testutil.Diff(schema.Sequences, wantSequences); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+		},
+	}, {
+		description:   "postgres partitions",
+		dialect:       DialectPostgres,
+		currentSchema: "public",
+		sql: `
+CREATE TABLE measurement (
+    measurement_id BIGINT NOT NULL
+    ,created_at TIMESTAMPTZ NOT NULL
+    ,PRIMARY KEY (measurement_id, created_at)
+) PARTITION BY RANGE (created_at);
+CREATE TABLE measurement_2024_01 PARTITION OF measurement FOR VALUES FROM ('2024-01-01') TO ('2024-02-01');
+CREATE TABLE measurement_default PARTITION OF measurement (
+    CONSTRAINT measurement_default_created_at_check CHECK (created_at IS NOT NULL)
+) DEFAULT;
+CREATE TABLE measurement_2024_02 (
+    measurement_id BIGINT NOT NULL
+    ,created_at TIMESTAMPTZ NOT NULL
+);
+ALTER TABLE measurement ATTACH PARTITION measurement_2024_02 FOR VALUES FROM ('2024-02-01') TO ('2024-03-01');
+`,
+		check: func(t *testing.T, cache *CatalogCache, catalog *Catalog) {
+			schema := cache.GetSchema(catalog, "public")
+			type partition struct {
+				TableName, PartitionBy, PartitionOf, PartitionBound string
+			}
+			var gotPartitions []partition
+			for _, table := range schema.Tables {
+				gotPartitions = append(gotPartitions, partition{table.TableName, table.PartitionBy, table.PartitionOf, table.PartitionBound})
+			}
+			wantPartitions := []partition{
+				{"measurement", "RANGE (created_at)", "", ""},
+				{"measurement_2024_01", "", "measurement", "FOR VALUES FROM ('2024-01-01') TO ('2024-02-01')"},
+				{"measurement_default", "", "measurement", "DEFAULT"},
+				{"measurement_2024_02", "", "measurement", "FOR VALUES FROM ('2024-02-01') TO ('2024-03-01')"},
+			}
+			if diff := testutil.Diff(gotPartitions, wantPartitions); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+			measurementDefault := cache.GetTable(schema, "measurement_default")
+			if cache.GetConstraint(measurementDefault, "measurement_default_created_at_check") == nil {
+				t.Error(testutil.Callers(), "constraint measurement_default_created_at_check not found")
+			}
+			// Partitions must be created after the table they are attached to.
+			gotSQL, err := migrationSQL(&Catalog{Dialect: DialectPostgres, CurrentSchema: "public"}, catalog)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			for _, want := range []string{
+				") PARTITION BY RANGE (created_at);",
+				"CREATE TABLE measurement_2024_01 PARTITION OF measurement FOR VALUES FROM ('2024-01-01') TO ('2024-02-01');",
+			} {
+				if !strings.Contains(gotSQL, want) {
+					t.Errorf(testutil.Callers()+" %q not found in\n%s", want, gotSQL)
+				}
+			}
+			if strings.Index(gotSQL, "CREATE TABLE measurement_2024_02") < strings.Index(gotSQL, "CREATE TABLE measurement (") {
+				t.Errorf(testutil.Callers()+" partition created before its parent:\n%s", gotSQL)
+			}
+		},
+	}, {
+		description:   "mysql table options",
+		dialect:       DialectMySQL,
+		currentSchema: "sakila",
+		sql: `
+CREATE TABLE actor (
+    actor_id INT AUTO_INCREMENT PRIMARY KEY
+) ENGINE=MyISAM AUTO_INCREMENT=201 DEFAULT CHARSET=latin1 ROW_FORMAT=compact COMMENT='actors';
+CREATE TABLE film (
+    film_id INT
+) ENGINE = InnoDB DEFAULT COLLATE = utf8mb4_0900_ai_ci;
+`,
+		check: func(t *testing.T, cache *CatalogCache, catalog *Catalog) {
+			schema := cache.GetSchema(catalog, "sakila")
+			type tableOptions struct {
+				Engine, CharacterSet, CollationName, RowFormat string
+				AutoIncrement                                  int64
+			}
+			for _, tt := range []struct {
+				tableName string
+				want      tableOptions
+			}{
+				{"actor", tableOptions{Engine: "MyISAM", CharacterSet: "latin1", RowFormat: "COMPACT", AutoIncrement: 201}},
+				{"film", tableOptions{Engine: "InnoDB", CharacterSet: "utf8mb4", CollationName: "utf8mb4_0900_ai_ci"}},
+			} {
+				table := cache.GetTable(schema, tt.tableName)
+				if table == nil {
+					t.Fatal(testutil.Callers(), "table "+tt.tableName+" not found")
+				}
+				got := tableOptions{table.Engine, table.CharacterSet, table.CollationName, table.RowFormat, table.AutoIncrement}
+				if diff := testutil.Diff(got, tt.want); diff != "" {
+					t.Error(testutil.Callers(), tt.tableName, diff)
+				}
+			}
+			if diff := testutil.Diff(cache.GetTable(schema, "actor").Comment, "actors"); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+		},
+	}, {
+		description:   "postgres alter table",
+		dialect:       DialectPostgres,
+		currentSchema: "public",
+		sql: `
+DROP TABLE IF EXISTS public.actor;
+DROP INDEX IF EXISTS actor_name_idx;
+CREATE TABLE public.actor (actor_id INT NOT NULL, name TEXT NOT NULL, nickname TEXT, last_update TIMESTAMPTZ);
+ALTER TABLE public.actor OWNER TO postgres;
+ALTER TABLE ONLY public.actor ALTER COLUMN actor_id ADD GENERATED BY DEFAULT AS IDENTITY (SEQUENCE NAME public.actor_actor_id_seq);
+ALTER TABLE ONLY public.actor ALTER COLUMN last_update SET DEFAULT now();
+ALTER TABLE public.actor ALTER COLUMN name DROP NOT NULL, ALTER nickname SET NOT NULL;
+ALTER TABLE public.actor ALTER COLUMN nickname SET STATISTICS 100, REPLICA IDENTITY FULL, CLUSTER ON actor_pkey;
+`,
+		check: func(t *testing.T, cache *CatalogCache, catalog *Catalog) {
+			table := cache.GetTable(cache.GetSchema(catalog, "public"), "actor")
+			if table == nil {
+				t.Fatal(testutil.Callers(), "table actor not found")
+			}
+			type column struct {
+				ColumnName, ColumnIdentity, ColumnDefault string
+				IsNotNull                                 bool
+			}
+			var gotColumns []column
+			for _, c := range table.Columns {
+				gotColumns = append(gotColumns, column{c.ColumnName, c.ColumnIdentity, c.ColumnDefault, c.IsNotNull})
+			}
+			wantColumns := []column{
+				{"actor_id", DEFAULT_IDENTITY, "", true},
+				{"name", "", "", false},
+				{"nickname", "", "", true},
+				{"last_update", "", "now()", false},
+			}
+			if diff := testutil.Diff(gotColumns, wantColumns); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+		},
+	}, {
+		description:   "mysql",
+		dialect:       DialectMySQL,
+		currentSchema: "sakila",
+		sql: `
+CREATE TABLE actor (
+    actor_id INT AUTO_INCREMENT
+    ,name VARCHAR(45) CHARACTER SET utf8mb4 NOT NULL COMMENT 'it''s \\ a name'
+    ,last_update TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+    ,PRIMARY KEY (actor_id)
+    ,KEY actor_name_idx (name(10))
+    ,FULLTEXT INDEX (name)
+) ENGINE=InnoDB COMMENT = 'actors';
+`,
+		check: func(t *testing.T, cache *CatalogCache, catalog *Catalog) {
+			actor := cache.GetTable(cache.GetSchema(catalog, "sakila"), "actor")
+			if actor == nil {
+				t.Fatal(testutil.Callers(), "table actor not found")
+			}
+			if diff := testutil.Diff(actor.Comment, "actors"); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+			actorID := cache.GetColumn(actor, "actor_id")
+			if !actorID.IsAutoincrement || !actorID.IsPrimaryKey || !actorID.IsNotNull {
+				t.Errorf(testutil.Callers()+" invalid column %+v", actorID)
+			}
+			if diff := testutil.Diff(cache.GetColumn(actor, "name").Comment, `it's \ a name`); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+			if !cache.GetColumn(actor, "last_update").OnUpdateCurrentTimestamp {
+				t.Error(testutil.Callers(), "expected last_update to have ON UPDATE CURRENT_TIMESTAMP")
+			}
+			if cache.GetConstraint(actor, "PRIMARY") == nil {
+				t.Error(testutil.Callers(), "constraint PRIMARY not found")
+			}
+			if index := cache.GetIndex(actor, "actor_name_idx"); index == nil || !slices.Equal(index.Columns, []string{"name"}) {
+				t.Errorf(testutil.Callers()+" invalid index %+v", index)
+			}
+			var fulltextIndex *Index
+			for i := range actor.Indexes {
+				if actor.Indexes[i].IndexType == "FULLTEXT" {
+					fulltextIndex = &actor.Indexes[i]
+				}
+			}
+			if fulltextIndex == nil {
+				t.Error(testutil.Callers(), "FULLTEXT index not found")
+			}
+		},
+	}, {
+		description:   "sqlserver",
+		dialect:       DialectSQLServer,
+		currentSchema: "dbo",
+		sql: `
+CREATE TABLE [dbo].[actor] (
+    actor_id INT IDENTITY(1, 1) NOT NULL
+    ,[name] [nvarchar](45) NOT NULL
+    ,full_name AS (upper([name])) PERSISTED
+    ,CONSTRAINT actor_actor_id_pkey PRIMARY KEY NONCLUSTERED (actor_id)
+)
+GO
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'actor_name_idx')
+    EXEC('CREATE INDEX actor_name_idx ON actor (name) WHERE (name <> N''it''''s'')')
+GO
+EXEC sp_addextendedproperty @name = N'MS_Description', @value = N'the name', @level0type = N'SCHEMA', @level0name = N'dbo', @level1type = N'TABLE', @level1name = N'actor', @level2type = N'COLUMN', @level2name = N'name';
+EXEC sp_addextendedproperty N'MS_Description', N'actors', N'SCHEMA', N'dbo', N'TABLE', N'actor';
+`,
+		check: func(t *testing.T, cache *CatalogCache, catalog *Catalog) {
+			actor := cache.GetTable(cache.GetSchema(catalog, "dbo"), "actor")
+			if actor == nil {
+				t.Fatal(testutil.Callers(), "table actor not found")
+			}
+			if diff := testutil.Diff(actor.Comment, "actors"); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+			name := cache.GetColumn(actor, "name")
+			if diff := testutil.Diff([]string{name.ColumnType, name.CharacterLength, name.Comment}, []string{"nvarchar(45)", "45", "the name"}); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+			if diff := testutil.Diff(cache.GetColumn(actor, "actor_id").ColumnIdentity, IDENTITY); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+			fullName := cache.GetColumn(actor, "full_name")
+			if fullName.GeneratedExpr != "upper([name])" || !fullName.GeneratedExprStored {
+				t.Errorf(testutil.Callers()+" invalid column %+v", fullName)
+			}
+			if pkey := cache.GetPrimaryKey(actor); pkey == nil || pkey.IsClustered {
+				t.Errorf(testutil.Callers()+" invalid primary key %+v", pkey)
+			}
+			index := cache.GetIndex(actor, "actor_name_idx")
+			if index == nil {
+				t.Fatal(testutil.Callers(), "index actor_name_idx not found")
+			}
+			if diff := testutil.Diff(index.Predicate, "(name <> N'it''s')"); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+		},
+	}, {
+		description: "sqlite table options",
+		dialect:     DialectSQLite,
+		sql: `
+CREATE TABLE actor (actor_id INTEGER PRIMARY KEY) STRICT;
+CREATE TABLE language_code (code TEXT PRIMARY KEY) without rowid;
+CREATE TABLE film_category (
+    film_id INT
+    ,category_id INT
+    ,PRIMARY KEY (film_id, category_id)
+) WITHOUT ROWID, STRICT;
+CREATE TABLE film (film_id INTEGER PRIMARY KEY);
+`,
+		check: func(t *testing.T, cache *CatalogCache, catalog *Catalog) {
+			schema := cache.GetSchema(catalog, "")
+			for _, tt := range []struct {
+				tableName string
+				want      [2]bool
+			}{
+				{"actor", [2]bool{true, false}},
+				{"language_code", [2]bool{false, true}},
+				{"film_category", [2]bool{true, true}},
+				{"film", [2]bool{false, false}},
+			} {
+				table := cache.GetTable(schema, tt.tableName)
+				if table == nil {
+					t.Fatal(testutil.Callers(), "table "+tt.tableName+" not found")
+				}
+				if diff := testutil.Diff([2]bool{table.IsStrict, table.IsWithoutRowid}, tt.want); diff != "" {
+					t.Error(testutil.Callers(), tt.tableName, diff)
+				}
+			}
+		},
+	}, {
+		description: "sqlite",
+		dialect:     DialectSQLite,
+		sql: `
+CREATE TABLE IF NOT EXISTS main.actor (
+    actor_id INTEGER PRIMARY KEY AUTOINCREMENT
+    ,name TEXT NOT NULL UNIQUE
+    ,name_length INT AS (length(name))
+);
+CREATE TABLE IF NOT EXISTS actor (actor_id INTEGER PRIMARY KEY);
+CREATE VIRTUAL TABLE actor_fts USING fts5 (name, content='actor');
+CREATE VIEW actor_names AS SELECT name FROM actor;
+`,
+		check: func(t *testing.T, cache *CatalogCache, catalog *Catalog) {
+			schema := cache.GetSchema(catalog, "")
+			actor := cache.GetTable(schema, "actor")
+			if actor == nil {
+				t.Fatal(testutil.Callers(), "table actor not found")
+			}
+			if len(actor.Columns) != 3 {
+				t.Errorf(testutil.Callers()+" expected 3 columns, got %d", len(actor.Columns))
+			}
+			if !strings.HasPrefix(actor.SQL, "CREATE TABLE IF NOT EXISTS main.actor (") || !strings.HasSuffix(actor.SQL, ");") {
+				t.Errorf(testutil.Callers()+" invalid table SQL %q", actor.SQL)
+			}
+			actorID := cache.GetColumn(actor, "actor_id")
+			if !actorID.IsAutoincrement || !actorID.IsPrimaryKey || actorID.IsNotNull {
+				t.Errorf(testutil.Callers()+" invalid column %+v", actorID)
+			}
+			if !cache.GetColumn(actor, "name").IsUnique {
+				t.Error(testutil.Callers(), "expected name to be unique")
+			}
+			if diff := testutil.Diff(cache.GetColumn(actor, "name_length").GeneratedExpr, "length(name)"); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+			if actorFTS := cache.GetTable(schema, "actor_fts"); actorFTS == nil || !actorFTS.IsVirtual {
+				t.Errorf(testutil.Callers()+" invalid virtual table %+v", actorFTS)
+			}
+			view := cache.GetView(schema, "actor_names")
+			if view == nil || view.SQL != "SELECT name FROM actor" || !schema.ViewsValid {
+				t.Errorf(testutil.Callers()+" invalid view %+v", view)
+			}
+		},
+	}, {
+		description:   "postgres grants and policies",
+		dialect:       DialectPostgres,
+		currentSchema: "public",
+		sql: `
+CREATE SCHEMA app;
+CREATE TABLE app.document (document_id INT, owner TEXT);
+CREATE SEQUENCE app.document_seq;
+ALTER TABLE app.document ENABLE ROW LEVEL SECURITY;
+CREATE POLICY document_owner ON app.document AS RESTRICTIVE FOR UPDATE TO Editor, "Reviewer" USING (owner = current_user) WITH CHECK (owner IS NOT NULL);
+CREATE POLICY document_read ON app.document TO PUBLIC USING (true);
+GRANT USAGE ON SCHEMA app TO PUBLIC;
+GRANT SELECT, UPDATE ON TABLE app.document TO editor, GROUP "Reviewer" WITH GRANT OPTION;
+GRANT USAGE ON SEQUENCE app.document_seq TO editor;
+GRANT SELECT (owner) ON app.document TO editor;
+GRANT EXECUTE ON FUNCTION app.f() TO editor;
+GRANT editor TO reviewer;
+`,
+		check: func(t *testing.T, cache *CatalogCache, catalog *Catalog) {
+			schema := cache.GetSchema(catalog, "app")
+			if !schema.GrantsValid || !schema.PoliciesValid {
+				t.Errorf(testutil.Callers()+" expected GrantsValid and PoliciesValid, got %v and %v", schema.GrantsValid, schema.PoliciesValid)
+			}
+			wantGrants := []Grant{
+				{GrantSchema: "app", ObjectType: "SCHEMA", Grantee: "PUBLIC", Privilege: "USAGE"},
+				{GrantSchema: "app", ObjectType: "TABLE", ObjectName: "document", Grantee: "editor", Privilege: "SELECT", IsGrantable: true},
+				{GrantSchema: "app", ObjectType: "TABLE", ObjectName: "document", Grantee: "editor", Privilege: "UPDATE", IsGrantable: true},
+				{GrantSchema: "app", ObjectType: "TABLE", ObjectName: "document", Grantee: "Reviewer", Privilege: "SELECT", IsGrantable: true},
+				{GrantSchema: "app", ObjectType: "TABLE", ObjectName: "document", Grantee: "Reviewer", Privilege: "UPDATE", IsGrantable: true},
+				{GrantSchema: "app", ObjectType: "SEQUENCE", ObjectName: "document_seq", Grantee: "editor", Privilege: "USAGE"},
+			}
+			if diff := testutil.Diff(schema.Grants, wantGrants); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+			document := cache.GetTable(schema, "document")
+			if !document.RowSecurityEnabled {
+				t.Error(testutil.Callers(), "expected row-level security to be enabled")
+			}
+			wantPolicies := []Policy{{
+				TableSchema:   "app",
+				TableName:     "document",
+				PolicyName:    "document_owner",
+				IsRestrictive: true,
+				Command:       "UPDATE",
+				Roles:         []string{"editor", "Reviewer"},
+				UsingExpr:     "owner = current_user",
+				CheckExpr:     "owner IS NOT NULL",
+			}, {
+				TableSchema: "app",
+				TableName:   "document",
+				PolicyName:  "document_read",
+				Command:     "ALL",
+				UsingExpr:   "true",
+			}}
+			if diff := testutil.Diff(document.Policies, wantPolicies); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+		},
+	}, {
+		description: "mysql grants",
+		dialect:     DialectMySQL,
+		sql: `
+CREATE TABLE actor (actor_id INT);
+GRANT SELECT, CREATE VIEW ON sakila.* TO 'app'@'%';
+GRANT SELECT ON actor TO app@localhost WITH GRANT OPTION;
+GRANT ALL PRIVILEGES ON *.* TO 'root'@'%';
+`,
+		check: func(t *testing.T, cache *CatalogCache, catalog *Catalog) {
+			wantGrants := []Grant{
+				{GrantSchema: "sakila", ObjectType: "SCHEMA", Grantee: "'app'@'%'", Privilege: "SELECT"},
+				{GrantSchema: "sakila", ObjectType: "SCHEMA", Grantee: "'app'@'%'", Privilege: "CREATE VIEW"},
+			}
+			if diff := testutil.Diff(cache.GetSchema(catalog, "sakila").Grants, wantGrants); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+			wantGrants = []Grant{
+				{ObjectType: "TABLE", ObjectName: "actor", Grantee: "'app'@'localhost'", Privilege: "SELECT", IsGrantable: true},
+			}
+			if diff := testutil.Diff(cache.GetSchema(catalog, "").Grants, wantGrants); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+		},
+	}, {
+		description:   "sqlserver grants",
+		dialect:       DialectSQLServer,
+		currentSchema: "dbo",
+		sql: `
+CREATE TABLE actor (actor_id INT);
+GRANT SELECT ON SCHEMA::dbo TO [app];
+GRANT INSERT, UPDATE ON OBJECT::dbo.actor TO app WITH GRANT OPTION;
+`,
+		check: func(t *testing.T, cache *CatalogCache, catalog *Catalog) {
+			wantGrants := []Grant{
+				{GrantSchema: "dbo", ObjectType: "SCHEMA", Grantee: "app", Privilege: "SELECT"},
+				{GrantSchema: "dbo", ObjectType: "TABLE", ObjectName: "actor", Grantee: "app", Privilege: "INSERT", IsGrantable: true},
+				{GrantSchema: "dbo", ObjectType: "TABLE", ObjectName: "actor", Grantee: "app", Privilege: "UPDATE", IsGrantable: true},
+			}
+			if diff := testutil.Diff(cache.GetSchema(catalog, "dbo").Grants, wantGrants); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+		},
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.description, func(t *testing.T) {
+			t.Parallel()
+			p := NewSQLParser()
+			err := p.ParseFile(&bufferFile{name: "schema.sql", buf: bytes.NewBufferString(tt.sql)})
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			catalog := &Catalog{Dialect: tt.dialect, CurrentSchema: tt.currentSchema}
+			err = p.WriteCatalog(catalog)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			tt.check(t, NewCatalogCache(catalog), catalog)
+		})
+	}
+}
+
+func TestSQLParser_Errors(t *testing.T) {
+	tests := []struct {
+		description string
+		dialect     string
+		sql         string
+		wantErr     string
+	}{{
+		description: "no dialect",
+		sql:         "CREATE TABLE actor (actor_id INT);",
+		wantErr:     "dialect is required",
+	}, {
+		description: "table created twice",
+		dialect:     DialectSQLite,
+		sql:         "CREATE TABLE actor (actor_id INT);\n\nCREATE TABLE actor (actor_id INT);",
+		wantErr:     "schema.sql:3: table actor is created more than once",
+	}, {
+		description: "index on missing table",
+		dialect:     DialectPostgres,
+		sql:         "CREATE INDEX actor_name_idx ON actor (name);",
+		wantErr:     "table actor does not exist",
+	}, {
+		description: "alter missing table",
+		dialect:     DialectMySQL,
+		sql:         "ALTER TABLE actor ADD CONSTRAINT actor_name_key UNIQUE (name);",
+		wantErr:     "table actor does not exist",
+	}, {
+		description: "policy on missing table",
+		dialect:     DialectPostgres,
+		sql:         "CREATE POLICY actor_owner ON actor USING (true);",
+		wantErr:     "table actor does not exist",
+	}, {
+		description: "grant all",
+		dialect:     DialectPostgres,
+		sql:         "CREATE TABLE actor (actor_id INT);\nGRANT ALL ON actor TO app;",
+		wantErr:     "schema.sql:2: GRANT ALL is not supported",
+	}, {
+		description: "drop column",
+		dialect:     DialectPostgres,
+		sql:         "CREATE TABLE actor (actor_id INT, name TEXT);\n\nALTER TABLE actor\n    DROP COLUMN name;",
+		wantErr:     "schema.sql:4: ALTER TABLE actor: unsupported action DROP COLUMN",
+	}, {
+		description: "alter column type",
+		dialect:     DialectSQLServer,
+		sql:         "CREATE TABLE actor (actor_id INT, name NVARCHAR(50));\nALTER TABLE actor ALTER COLUMN name NVARCHAR(100);",
+		wantErr:     "schema.sql:2: ALTER TABLE actor: unsupported action ALTER COLUMN name NVARCHAR(100)",
+	}, {
+		description: "drop created table",
+		dialect:     DialectMySQL,
+		sql:         "CREATE TABLE actor (actor_id INT);\nDROP TABLE IF EXISTS actor;",
+		wantErr:     "schema.sql:2: DROP TABLE actor: dropping objects is not supported",
+	}, {
+		description: "drop missing index",
+		dialect:     DialectPostgres,
+		sql:         "CREATE TABLE actor (actor_id INT);\nDROP INDEX actor_name_idx;",
+		wantErr:     "schema.sql:2: DROP INDEX actor_name_idx: dropping objects is not supported",
+	}}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.description, func(t *testing.T) {
+			t.Parallel()
+			p := NewSQLParser()
+			err := p.ParseFile(&bufferFile{name: "schema.sql", buf: bytes.NewBufferString(tt.sql)})
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			err = p.WriteCatalog(&Catalog{Dialect: tt.dialect})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf(testutil.Callers()+" expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSQLParser_writeCatalog(t *testing.T) {
+	fsys := fstest.MapFS{
+		"schema.sql":       {Data: []byte("CREATE TABLE actor (actor_id INT PRIMARY KEY, name TEXT);")},
+		"dump/schema.sql":  {Data: []byte("CREATE TABLE actor (actor_id INT PRIMARY KEY, name TEXT);")},
+		"dump/indexes.sql": {Data: []byte("CREATE INDEX actor_name_idx ON actor (name);")},
+	}
+	for _, source := range []string{"schema.sql", "dump"} {
+		catalog := &Catalog{Dialect: DialectPostgres}
+		err := writeCatalog(catalog, fsys, "sqddl_history", source)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		cache := NewCatalogCache(catalog)
+		table := cache.GetTable(cache.GetSchema(catalog, ""), "actor")
+		if table == nil {
+			t.Fatalf(testutil.Callers()+" %s: table actor not found", source)
+		}
+		if source == "dump" && cache.GetIndex(table, "actor_name_idx") == nil {
+			t.Errorf(testutil.Callers()+" %s: index actor_name_idx not found", source)
+		}
+	}
+	err := writeCatalog(&Catalog{}, fsys, "sqddl_history", "schema.sql")
+	if err == nil || !strings.Contains(err.Error(), "-dialect") {
+		t.Errorf(testutil.Callers()+" expected an error asking for -dialect, got %v", err)
+	}
+}
@@ -2,18 +2,29 @@ package ddl
 
 import (
 	"bytes"
+	"fmt"
+	"slices"
 	"strings"
 )
 
 type sqliteMigration struct {
+	versionNums  VersionNums
 	dropTables   []*Table
 	createTables []*Table
 	alterTables  []sqliteAlterTable
+	views        viewMigration
+	routines     routineMigration
+	warnings     []Warning
+
+	// undoScopes are the objects changed by each file written by sql(), in
+	// order. The single migration file touches the whole schema.
+	undoScopes []undoScope
 }
 
 type sqliteAlterTable struct {
 	srcTable        *Table
 	destTable       *Table
+	renameColumns   [][2]*Column
 	dropIndexes     []*Index
 	dropConstraints []*Constraint
 	dropColumns     []*Column
@@ -23,11 +34,19 @@ type sqliteAlterTable struct {
 	createIndexes   []*Index
 	columnIsDropped map[string]bool
 	columnIsAdded   map[string]bool
+
+	// changeOptions indicates that the STRICT or WITHOUT ROWID option of the
+	// table changed, which can only be done by rebuilding the table.
+	changeOptions bool
 }
 
 func newSQLiteMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) sqliteMigration {
 	const dialect = DialectSQLite
-	m := sqliteMigration{}
+	m := sqliteMigration{
+		versionNums: srcCatalog.VersionNums,
+		views:       newViewMigration(dialect, srcCatalog, destCatalog, dropObjects),
+	}
+	m.routines = newRoutineMigration(dialect, srcCatalog, destCatalog, dropObjects, &m.views)
 	if len(srcCatalog.Schemas) == 0 && len(destCatalog.Schemas) == 0 {
 		return m
 	}
@@ -56,7 +75,19 @@ func newSQLiteMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) sqli
 
 	// Because SQLite doesn't support constraint names, we have to generate it
 	// ourselves (we need constraint names because that's how we identify the
-	// existence of a constraint).
+	// existence of a constraint). The constraints of a table that is being
+	// renamed are named after its new name, so that they match up with the
+	// constraints of the dest table.
+	isSrcTable := make(map[string]bool)
+	for _, srcTable := range srcCatalog.Schemas[0].Tables {
+		isSrcTable[srcTable.TableName] = true
+	}
+	renamedTo := make(map[string]string)
+	for _, destTable := range destCatalog.Schemas[0].Tables {
+		if destTable.RenamedFrom != "" && isSrcTable[destTable.RenamedFrom] && !isSrcTable[destTable.TableName] {
+			renamedTo[destTable.RenamedFrom] = destTable.TableName
+		}
+	}
 	for i := range srcCatalog.Schemas {
 		srcSchema := &srcCatalog.Schemas[i]
 		for j := range srcSchema.Tables {
@@ -69,7 +100,11 @@ func newSQLiteMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) sqli
 				if srcConstraint.ConstraintType != PRIMARY_KEY && srcConstraint.ConstraintType != UNIQUE && srcConstraint.ConstraintType != FOREIGN_KEY {
 					continue
 				}
-				srcConstraint.ConstraintName = GenerateName(srcConstraint.ConstraintType, srcConstraint.TableName, srcConstraint.Columns)
+				tableName := srcConstraint.TableName
+				if newName, ok := renamedTo[tableName]; ok {
+					tableName = newName
+				}
+				srcConstraint.ConstraintName = GenerateName(srcConstraint.ConstraintType, tableName, srcConstraint.Columns)
 			}
 		}
 	}
@@ -92,10 +127,12 @@ func newSQLiteMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) sqli
 
 	srcCache, destCache := NewCatalogCache(srcCatalog), NewCatalogCache(destCatalog)
 	srcSchema, destSchema := &srcCatalog.Schemas[0], &destCatalog.Schemas[0]
+	m.warnings = renamedFromWarnings(srcCache, srcCatalog, destCatalog)
 	if dropObjects {
+		isRenamedTable := renamedTables(srcCache, srcSchema, destSchema)
 		for i := range srcSchema.Tables {
 			srcTable := &srcSchema.Tables[i]
-			if srcTable.Ignore {
+			if srcTable.Ignore || isRenamedTable[srcTable.TableName] {
 				continue
 			}
 			if isVirtualTable(srcTable) {
@@ -116,7 +153,7 @@ func newSQLiteMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) sqli
 		if isVirtualTable(destTable) {
 			continue
 		}
-		srcTable := srcCache.GetTable(srcSchema, destTable.TableName)
+		srcTable, _ := findSrcTable(srcCache, srcSchema, destTable)
 		if srcTable == nil {
 			// CREATE TABLE.
 			m.createTables = append(m.createTables, destTable)
@@ -151,9 +188,10 @@ func newSQLiteMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) sqli
 				alterTable.dropIndexes = append(alterTable.dropIndexes, srcIndex)
 			}
 		}
+		isRenamedColumn := renamedColumns(srcCache, srcTable, destTable)
 		for j := range srcTable.Columns {
 			srcColumn := &srcTable.Columns[j]
-			if srcColumn.Ignore {
+			if srcColumn.Ignore || isRenamedColumn[srcColumn.ColumnName] {
 				continue
 			}
 			destColumn := destCache.GetColumn(destTable, srcColumn.ColumnName)
@@ -168,13 +206,17 @@ func newSQLiteMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) sqli
 			if destColumn.Ignore {
 				continue
 			}
-			srcColumn := srcCache.GetColumn(srcTable, destColumn.ColumnName)
+			srcColumn, isRenamed := findSrcColumn(srcCache, srcTable, destColumn)
 			if srcColumn == nil {
 				// ADD COLUMN.
 				alterTable.addColumns = append(alterTable.addColumns, destColumn)
 				alterTable.columnIsAdded[destColumn.ColumnName] = true
 				continue
 			}
+			if isRenamed {
+				// RENAME COLUMN.
+				alterTable.renameColumns = append(alterTable.renameColumns, [2]*Column{srcColumn, destColumn})
+			}
 			columnsAreDifferent := func() bool {
 				if srcColumn.ColumnType != destColumn.ColumnType {
 					return true
@@ -184,7 +226,8 @@ func newSQLiteMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) sqli
 				if srcDefault != destDefault {
 					return true
 				}
-				if srcColumn.IsNotNull != destColumn.IsNotNull {
+				// Primary key columns are implicitly NOT NULL.
+				if srcColumn.IsNotNull != destColumn.IsNotNull && !(srcColumn.IsNotNull && destColumn.IsPrimaryKey) {
 					return true
 				}
 				return false
@@ -203,6 +246,10 @@ func newSQLiteMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) sqli
 			if srcIndex == nil {
 				// CREATE INDEX.
 				alterTable.createIndexes = append(alterTable.createIndexes, destIndex)
+			} else if !srcIndex.Ignore && !indexesAreEqual(dialect, srcIndex, destIndex) {
+				// DROP INDEX, CREATE INDEX.
+				alterTable.dropIndexes = append(alterTable.dropIndexes, srcIndex)
+				alterTable.createIndexes = append(alterTable.createIndexes, destIndex)
 			}
 		}
 		for j := range destTable.Constraints {
@@ -214,10 +261,19 @@ func newSQLiteMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) sqli
 			if srcConstraint == nil {
 				// ADD CONSTRAINT.
 				alterTable.addConstraints = append(alterTable.addConstraints, destConstraint)
+			} else if destConstraint.ConstraintType == CHECK && !srcConstraint.Ignore && !checksAreEqual(dialect, srcConstraint, destConstraint) {
+				// DROP CHECK, ADD CHECK.
+				alterTable.dropConstraints = append(alterTable.dropConstraints, srcConstraint)
+				alterTable.addConstraints = append(alterTable.addConstraints, destConstraint)
 			}
 		}
-		if len(alterTable.dropConstraints) > 0 ||
+		alterTable.changeOptions = srcTable.IsStrict != destTable.IsStrict || srcTable.IsWithoutRowid != destTable.IsWithoutRowid
+		isRenamed := srcTable.TableName != destTable.TableName || len(alterTable.renameColumns) > 0
+		if isRenamed ||
+			alterTable.changeOptions ||
+			len(alterTable.dropConstraints) > 0 ||
 			len(alterTable.dropIndexes) > 0 ||
+			len(alterTable.dropColumns) > 0 ||
 			len(alterTable.addColumns) > 0 ||
 			len(alterTable.alterColumns) > 0 ||
 			len(alterTable.createIndexes) > 0 ||
@@ -228,10 +284,37 @@ func newSQLiteMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) sqli
 			} else {
 				// Else we run alter table only if we are adding any columns or
 				// creating any indexes -- the other operations all involve
-				// dropping objects. Zero them out so that only adding columns
-				// and creating indexes are left behind.
-				if len(alterTable.addColumns) > 0 || len(alterTable.createIndexes) > 0 {
-					alterTable.dropIndexes = alterTable.dropIndexes[:0]
+				// dropping objects. Zero them out so that only renaming tables
+				// and columns, adding columns and creating indexes are left
+				// behind (indexes that are dropped in order to be recreated
+				// are kept).
+				if len(alterTable.renameColumns) > 0 && m.versionNums.LowerThan(3, 25) {
+					// Renaming columns before SQLite 3.25 requires rebuilding
+					// the table, which would drop objects.
+					m.warnings = append(m.warnings, Warning{
+						Code:     WarnSQLiteRenameColumnUnsupported,
+						Severity: SeverityHigh,
+						Object:   destTable.TableName,
+						Message:  fmt.Sprintf("%s: renaming columns requires SQLite 3.25 or higher (or -drop-objects to rebuild the table), skipping", QuoteIdentifier(dialect, destTable.TableName)),
+					})
+					alterTable.renameColumns = alterTable.renameColumns[:0]
+					isRenamed = srcTable.TableName != destTable.TableName
+				}
+				if alterTable.changeOptions {
+					// Changing STRICT or WITHOUT ROWID requires rebuilding
+					// the table, which would drop objects.
+					m.warnings = append(m.warnings, Warning{
+						Code:     WarnSQLiteChangeTableOptions,
+						Severity: SeverityHigh,
+						Object:   destTable.TableName,
+						Message:  fmt.Sprintf("%s: changing STRICT or WITHOUT ROWID requires rebuilding the table (use -drop-objects), skipping", QuoteIdentifier(dialect, destTable.TableName)),
+					})
+					alterTable.changeOptions = false
+				}
+				if isRenamed || len(alterTable.addColumns) > 0 || len(alterTable.createIndexes) > 0 {
+					alterTable.dropIndexes = slices.DeleteFunc(alterTable.dropIndexes, func(index *Index) bool {
+						return destCache.GetIndex(destTable, index.IndexName) == nil
+					})
 					alterTable.dropConstraints = alterTable.dropConstraints[:0]
 					alterTable.dropColumns = alterTable.dropColumns[:0]
 					alterTable.alterColumns = alterTable.alterColumns[:0]
@@ -244,7 +327,7 @@ func newSQLiteMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) sqli
 	return m
 }
 
-func (m *sqliteMigration) sql(prefix string) (filenames []string, bufs []*bytes.Buffer, warnings []string) {
+func (m *sqliteMigration) sql(prefix string) (filenames []string, bufs []*bytes.Buffer, warnings []Warning) {
 	const (
 		dialect          = DialectSQLite
 		currentSchema    = ""
@@ -252,15 +335,23 @@ func (m *sqliteMigration) sql(prefix string) (filenames []string, bufs []*bytes.
 	)
 	// ${prefix}.sql
 	filenames = append(filenames, prefix+".sql")
+	m.undoScopes = []undoScope{{all: true}}
 	buf := bufpool.Get().(*bytes.Buffer)
 	buf.Reset()
 	bufs = append(bufs, buf)
 
+	warnings = append(warnings, m.warnings...)
+
 	// Figure out which tables have to be copied.
 	copyTable := make([]bool, len(m.alterTables))
 	hasCopyTable := false
 	for i, alterTable := range m.alterTables {
-		if len(alterTable.alterColumns) > 0 {
+		if len(alterTable.alterColumns) > 0 || alterTable.changeOptions {
+			copyTable[i], hasCopyTable = true, true
+			continue
+		}
+		if len(alterTable.renameColumns) > 0 && m.versionNums.LowerThan(3, 25) {
+			// RENAME COLUMN is only supported from SQLite 3.25 onwards.
 			copyTable[i], hasCopyTable = true, true
 			continue
 		}
@@ -286,6 +377,12 @@ func (m *sqliteMigration) sql(prefix string) (filenames []string, bufs []*bytes.
 		}
 	}
 
+	// DROP TRIGGER.
+	m.routines.writeDropTriggers(buf)
+
+	// DROP VIEW.
+	m.views.writeDropViews(buf)
+
 	if hasCopyTable {
 		if buf.Len() > 0 {
 			buf.WriteString("\n")
@@ -317,16 +414,37 @@ func (m *sqliteMigration) sql(prefix string) (filenames []string, bufs []*bytes.
 	}
 
 	// ALTER TABLE | COPY TABLE.
+	isCreatedTrigger := make(map[*Trigger]bool)
 	for i, alterTable := range m.alterTables {
 		if copyTable[i] {
 			// COPY TABLE.
 			if buf.Len() > 0 {
 				buf.WriteString("\n")
 			}
-			alterTable.copyTable(buf)
+			triggers := m.routines.rebuiltTableTriggers(alterTable.srcTable, alterTable.destTable)
+			for _, trigger := range triggers {
+				isCreatedTrigger[trigger] = true
+			}
+			alterTable.copyTable(buf, triggers)
 			continue
 		}
 		tableName := QuoteIdentifier(dialect, alterTable.destTable.TableName)
+		// RENAME TO.
+		if alterTable.srcTable.TableName != alterTable.destTable.TableName {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			buf.WriteString("ALTER TABLE " + QuoteIdentifier(dialect, alterTable.srcTable.TableName) + " RENAME TO " + tableName + ";\n")
+		}
+		// RENAME COLUMN.
+		for _, columns := range alterTable.renameColumns {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			srcColumnName := QuoteIdentifier(dialect, columns[0].ColumnName)
+			destColumnName := QuoteIdentifier(dialect, columns[1].ColumnName)
+			buf.WriteString("ALTER TABLE " + tableName + " RENAME COLUMN " + srcColumnName + " TO " + destColumnName + ";\n")
+		}
 		// DROP INDEX.
 		for _, index := range alterTable.dropIndexes {
 			if buf.Len() > 0 {
@@ -368,18 +486,32 @@ func (m *sqliteMigration) sql(prefix string) (filenames []string, bufs []*bytes.
 		buf.WriteString("PRAGMA legacy_alter_table = OFF;\n")
 	}
 
+	// CREATE VIEW.
+	m.views.writeCreateViews(buf)
+
+	// CREATE TRIGGER.
+	routines := m.routines
+	routines.createTriggers = slices.DeleteFunc(slices.Clone(routines.createTriggers), func(trigger *Trigger) bool {
+		return isCreatedTrigger[trigger]
+	})
+	routines.writeCreateTriggers(buf)
+
 	if bufs[0].Len() == 0 {
 		filenames = filenames[1:]
+		m.undoScopes = m.undoScopes[1:]
 	}
 	return filenames, bufs, warnings
 }
 
-func (alterTable *sqliteAlterTable) copyTable(buf *bytes.Buffer) {
+// copyTable rebuilds the table by copying it into a new table, then
+// recreates the table's indexes and triggers.
+func (alterTable *sqliteAlterTable) copyTable(buf *bytes.Buffer, triggers []*Trigger) {
 	const (
 		dialect          = DialectSQLite
 		currentSchema    = ""
 		defaultCollation = ""
 	)
+	srcTableName := QuoteIdentifier(dialect, alterTable.srcTable.TableName)
 	tableName := QuoteIdentifier(dialect, alterTable.destTable.TableName)
 	tableNameNew := QuoteIdentifier(dialect, alterTable.destTable.TableName+"_new")
 
@@ -397,12 +529,23 @@ func (alterTable *sqliteAlterTable) copyTable(buf *bytes.Buffer) {
 		}
 		isDestColumn[destColumn.ColumnName] = true
 	}
+	// renamedTo maps the old name of each renamed column to its new name.
+	renamedTo := make(map[string]string)
+	for _, columns := range alterTable.renameColumns {
+		renamedTo[columns[0].ColumnName] = columns[1].ColumnName
+	}
 	insertColumns := make([]string, 0, len(alterTable.srcTable.Columns))
+	selectColumns := make([]string, 0, len(alterTable.srcTable.Columns))
 	for _, srcColumn := range alterTable.srcTable.Columns {
-		if !isDestColumn[srcColumn.ColumnName] {
+		columnName := srcColumn.ColumnName
+		if newName, ok := renamedTo[columnName]; ok {
+			columnName = newName
+		}
+		if !isDestColumn[columnName] {
 			continue
 		}
-		insertColumns = append(insertColumns, srcColumn.ColumnName)
+		insertColumns = append(insertColumns, columnName)
+		selectColumns = append(selectColumns, srcColumn.ColumnName)
 	}
 	buf.WriteString("INSERT INTO " + tableNameNew + "\n    (")
 	for i, insertColumn := range insertColumns {
@@ -412,16 +555,16 @@ func (alterTable *sqliteAlterTable) copyTable(buf *bytes.Buffer) {
 		buf.WriteString(QuoteIdentifier(dialect, insertColumn))
 	}
 	buf.WriteString(")\nSELECT\n    ")
-	for i, insertColumn := range insertColumns {
+	for i, selectColumn := range selectColumns {
 		if i > 0 {
 			buf.WriteString(", ")
 		}
-		buf.WriteString(QuoteIdentifier(dialect, insertColumn))
+		buf.WriteString(QuoteIdentifier(dialect, selectColumn))
 	}
-	buf.WriteString("\nFROM\n    " + tableName + "\n;\n")
+	buf.WriteString("\nFROM\n    " + srcTableName + "\n;\n")
 
 	// DROP "$TableName".
-	buf.WriteString("DROP TABLE " + tableName + ";\n")
+	buf.WriteString("DROP TABLE " + srcTableName + ";\n")
 
 	// ALTER "${TableName}_new" RENAME TO "$TableName".
 	buf.WriteString("ALTER TABLE " + tableNameNew + " RENAME TO " + tableName + ";\n")
@@ -436,18 +579,10 @@ func (alterTable *sqliteAlterTable) copyTable(buf *bytes.Buffer) {
 	}
 
 	// CREATE TRIGGER.
-	triggers := alterTable.destTable.Triggers
-	if len(triggers) == 0 {
-		// If we cannot find triggers in the destTable, fall back to the
-		// srcTable. This will often be the case if destTable was derived from
-		// Go structs (which don't support defining triggers) and srcTable was
-		// derived from a database.
-		triggers = alterTable.srcTable.Triggers
-	}
 	for _, trigger := range triggers {
 		if buf.Len() > 0 {
 			buf.WriteString("\n")
 		}
-		buf.WriteString(trigger.SQL + "\n")
+		writeCreateRoutine(dialect, buf, trigger.SQL, false)
 	}
 }
@@ -22,6 +22,12 @@ func Test_sqliteMigration(t *testing.T) {
 		{"testdata/sqlite_create_schema", true},
 		{"testdata/sqlite_misc", true},
 		{"testdata/sqlite_ignore", true},
+		{"testdata/sqlite_check", true},
+		{"testdata/sqlite_check_expr", true},
+		{"testdata/sqlite_index", false},
+		{"testdata/sqlite_rename", true},
+		{"testdata/sqlite_table_options", true},
+		{"testdata/sqlite_table_options_skip", false},
 	}
 	newCatalog := func(t *testing.T, filename string) *Catalog {
 		file, err := os.Open(filename)
@@ -69,7 +75,7 @@ func Test_sqliteMigration(t *testing.T) {
 			}
 			wantWarnings = string(bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n")))
 			if len(warnings) > 0 {
-				gotWarnings = strings.Join(warnings, "\n")
+				gotWarnings = strings.Join(warningMessages(warnings), "\n")
 			}
 			if diff := testutil.Diff(gotWarnings, wantWarnings); diff != "" {
 				t.Error(testutil.Callers(), diff)
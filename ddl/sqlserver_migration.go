@@ -3,6 +3,7 @@ package ddl
 import (
 	"bytes"
 	"fmt"
+	"slices"
 	"strings"
 )
 
@@ -10,7 +11,7 @@ type sqlserverMigration struct {
 	versionNums      VersionNums
 	currentSchema    string
 	defaultCollation string
-	warnings         []string
+	warnings         []Warning
 
 	// 1. Drop the foreign keys.
 	dropFkeys [][]*Constraint
@@ -32,6 +33,18 @@ type sqlserverMigration struct {
 
 	// 6. Add foreign keys for existing tables.
 	addFkeys [][]*Constraint
+
+	// Drop the views that depend on changed tables before step 1, and create
+	// the views after step 6.
+	views viewMigration
+
+	// Drop the changed triggers before step 1. Create the routines before the
+	// views and the triggers after the views, then drop the removed routines.
+	routines routineMigration
+
+	// undoScopes are the objects changed by each file written by sql(), in
+	// order. Undo scripts are not included.
+	undoScopes []undoScope
 }
 
 type sqlserverAlterTable struct {
@@ -40,6 +53,21 @@ type sqlserverAlterTable struct {
 	columnTypes map[string]string
 	pkey        *Constraint
 
+	// destTable is the table in the destCatalog being altered to.
+	destTable *Table
+
+	// renameFrom is the old name of the table if it is being renamed.
+	renameFrom string
+
+	// renameColumns are the columns to rename as {oldName, newName}.
+	renameColumns [][2]string
+
+	// renameConstraints and renameIndexes are the constraints and indexes
+	// whose names were generated from the old table name, to rename as
+	// {srcObject, destObject}.
+	renameConstraints [][2]*Constraint
+	renameIndexes     [][2]*Index
+
 	// Do these in one transaction.
 	dropIndexes     []*Index
 	dropConstraints []*Constraint
@@ -47,6 +75,10 @@ type sqlserverAlterTable struct {
 	addColumns      []*Column
 	alterColumns    [][2]*Column
 
+	// comments are the comments to change as {columnName, srcComment,
+	// destComment}. The columnName is empty for the table comment.
+	comments [][3]string
+
 	// Create indexes individually outside a transaction.
 	createIndexes []*Index
 
@@ -60,8 +92,11 @@ func newSQLServerMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) s
 		versionNums:      srcCatalog.VersionNums,
 		currentSchema:    srcCatalog.CurrentSchema,
 		defaultCollation: srcCatalog.DefaultCollation,
+		views:            newViewMigration(dialect, srcCatalog, destCatalog, dropObjects),
 	}
+	m.routines = newRoutineMigration(dialect, srcCatalog, destCatalog, dropObjects, &m.views)
 	srcCache, destCache := NewCatalogCache(srcCatalog), NewCatalogCache(destCatalog)
+	m.warnings = renamedFromWarnings(srcCache, srcCatalog, destCatalog)
 	dropFkeysPos := make(map[[4]string]int)    // Track tablesID position in m.dropFkeys.
 	addFastFkeysPos := make(map[[4]string]int) // Track tablesID position in m.dropFastFkeys.
 	addFkeysPos := make(map[[4]string]int)     // Track tablesID position in m.addFkeys.
@@ -154,9 +189,10 @@ func newSQLServerMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) s
 		}
 
 		if dropObjects {
+			isRenamedTable := renamedTables(srcCache, srcSchema, destSchema)
 			for j := range srcSchema.Tables {
 				srcTable := &srcSchema.Tables[j]
-				if srcTable.Ignore {
+				if srcTable.Ignore || isRenamedTable[srcTable.TableName] {
 					continue
 				}
 				destTable := destCache.GetTable(destSchema, srcTable.TableName)
@@ -184,7 +220,7 @@ func newSQLServerMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) s
 			if destTable.Ignore {
 				continue
 			}
-			srcTable := srcCache.GetTable(srcSchema, destTable.TableName)
+			srcTable, isRenamed := findSrcTable(srcCache, srcSchema, destTable)
 			if srcTable == nil {
 				// CREATE TABLE.
 				m.createTables = append(m.createTables, destTable)
@@ -208,6 +244,7 @@ func newSQLServerMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) s
 				tableSchema: destTable.TableSchema,
 				tableName:   destTable.TableName,
 				columnTypes: make(map[string]string),
+				destTable:   destTable,
 			}
 			if alterTable.tableSchema == "" {
 				alterTable.tableSchema = m.currentSchema
@@ -215,20 +252,30 @@ func newSQLServerMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) s
 			if alterTable.tableSchema == "" {
 				alterTable.tableSchema = "dbo"
 			}
+			if isRenamed {
+				// sp_rename.
+				alterTable.renameFrom = srcTable.TableName
+			}
+			// sp_addextendedproperty | sp_updateextendedproperty |
+			// sp_dropextendedproperty.
+			if commentIsChanged(srcTable.Comment, destTable.Comment, dropObjects) {
+				alterTable.comments = append(alterTable.comments, [3]string{"", srcTable.Comment, destTable.Comment})
+			}
 			droppedIndex := make(map[*Index]bool)
 			droppedConstraint := make(map[*Constraint]bool)
 
 			if dropObjects {
+				isRenamedConstraint := renamedConstraints(srcCache, srcTable, destTable)
 				for k := range srcTable.Constraints {
 					srcConstraint := &srcTable.Constraints[k]
-					if srcConstraint.Ignore {
+					if srcConstraint.Ignore || isRenamedConstraint[srcConstraint.ConstraintName] {
 						continue
 					}
 					destConstraint := destCache.GetConstraint(destTable, srcConstraint.ConstraintName)
 					if destConstraint == nil {
 						switch srcConstraint.ConstraintType {
-						case PRIMARY_KEY, UNIQUE:
-							// DROP PRIMARY KEY, DROP UNIQUE.
+						case PRIMARY_KEY, UNIQUE, CHECK:
+							// DROP PRIMARY KEY, DROP UNIQUE, DROP CHECK.
 							alterTable.dropConstraints = append(alterTable.dropConstraints, srcConstraint)
 						case FOREIGN_KEY:
 							// DROP FOREIGN KEY.
@@ -244,9 +291,10 @@ func newSQLServerMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) s
 						droppedConstraint[srcConstraint] = true
 					}
 				}
+				isRenamedIndex := renamedIndexes(srcCache, srcTable, destTable)
 				for k := range srcTable.Indexes {
 					srcIndex := &srcTable.Indexes[k]
-					if srcIndex.Ignore {
+					if srcIndex.Ignore || isRenamedIndex[srcIndex.IndexName] {
 						continue
 					}
 					destIndex := destCache.GetIndex(destTable, srcIndex.IndexName)
@@ -256,9 +304,10 @@ func newSQLServerMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) s
 						droppedIndex[srcIndex] = true
 					}
 				}
+				isRenamedColumn := renamedColumns(srcCache, srcTable, destTable)
 				for k := range srcTable.Columns {
 					srcColumn := &srcTable.Columns[k]
-					if srcColumn.Ignore {
+					if srcColumn.Ignore || isRenamedColumn[srcColumn.ColumnName] {
 						continue
 					}
 					destColumn := destCache.GetColumn(destTable, srcColumn.ColumnName)
@@ -274,20 +323,35 @@ func newSQLServerMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) s
 				if destColumn.Ignore {
 					continue
 				}
-				srcColumn := srcCache.GetColumn(srcTable, destColumn.ColumnName)
+				srcColumn, isRenamed := findSrcColumn(srcCache, srcTable, destColumn)
 				alterTable.columnTypes[destColumn.ColumnName] = destColumn.ColumnType
 				if srcColumn == nil {
 					// ADD COLUMN.
 					alterTable.addColumns = append(alterTable.addColumns, destColumn)
+					if destColumn.Comment != "" {
+						alterTable.comments = append(alterTable.comments, [3]string{destColumn.ColumnName, "", destColumn.Comment})
+					}
 					continue
 				}
+				if isRenamed {
+					// sp_rename.
+					alterTable.renameColumns = append(alterTable.renameColumns, [2]string{srcColumn.ColumnName, destColumn.ColumnName})
+				}
+				if commentIsChanged(srcColumn.Comment, destColumn.Comment, dropObjects) {
+					alterTable.comments = append(alterTable.comments, [3]string{destColumn.ColumnName, srcColumn.Comment, destColumn.Comment})
+				}
 				if srcColumn.ColumnIdentity == "" && destColumn.ColumnIdentity != "" {
 					tableName := QuoteIdentifier(dialect, destTable.TableName)
 					if destSchema.SchemaName != "" && destSchema.SchemaName != m.currentSchema {
 						tableName = QuoteIdentifier(dialect, destSchema.SchemaName) + "."
 					}
 					columnName := QuoteIdentifier(dialect, destColumn.ColumnName)
-					m.warnings = append(m.warnings, fmt.Sprintf("%s: column %s: identity cannot be added to an existing column, skipping", tableName, columnName))
+					m.warnings = append(m.warnings, Warning{
+						Code:     WarnSQLServerAddIdentityUnsupported,
+						Severity: SeverityHigh,
+						Object:   warningObject(m.currentSchema, destSchema.SchemaName, destTable.TableName, destColumn.ColumnName),
+						Message:  fmt.Sprintf("%s: column %s: identity cannot be added to an existing column, skipping", tableName, columnName),
+					})
 				}
 				columnsAreDifferent := func() bool {
 					srcType, srcArg1, srcArg2 := normalizeColumnType(dialect, srcColumn.ColumnType)
@@ -300,7 +364,8 @@ func newSQLServerMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) s
 					if srcDefault != destDefault {
 						return true
 					}
-					if srcColumn.IsNotNull != destColumn.IsNotNull {
+					// Primary key columns are implicitly NOT NULL.
+					if srcColumn.IsNotNull != destColumn.IsNotNull && !(srcColumn.IsNotNull && destColumn.IsPrimaryKey) {
 						return true
 					}
 					srcCollation := srcColumn.CollationName
@@ -327,10 +392,18 @@ func newSQLServerMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) s
 				if destIndex.Ignore {
 					continue
 				}
-				srcIndex := srcCache.GetIndex(srcTable, destIndex.IndexName)
+				srcIndex, isRenamed := findSrcIndex(srcCache, srcTable, destTable, destIndex)
 				if srcIndex == nil {
 					// CREATE INDEX.
 					alterTable.createIndexes = append(alterTable.createIndexes, destIndex)
+				} else if !srcIndex.Ignore && !indexesAreEqual(dialect, srcIndex, destIndex) {
+					// DROP INDEX, CREATE INDEX.
+					alterTable.dropIndexes = append(alterTable.dropIndexes, srcIndex)
+					alterTable.createIndexes = append(alterTable.createIndexes, destIndex)
+					droppedIndex[srcIndex] = true
+				} else if isRenamed {
+					// sp_rename.
+					alterTable.renameIndexes = append(alterTable.renameIndexes, [2]*Index{srcIndex, destIndex})
 				}
 			}
 
@@ -339,14 +412,25 @@ func newSQLServerMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) s
 				if destConstraint.Ignore {
 					continue
 				}
-				srcConstraint := srcCache.GetConstraint(srcTable, destConstraint.ConstraintName)
+				srcConstraint, isRenamed := findSrcConstraint(srcCache, srcTable, destTable, destConstraint)
 				if destConstraint.ConstraintType == PRIMARY_KEY {
 					alterTable.pkey = destConstraint
 				}
+				if srcConstraint != nil && destConstraint.ConstraintType == CHECK && !srcConstraint.Ignore && !checksAreEqual(DialectSQLServer, srcConstraint, destConstraint) {
+					// DROP CHECK, ADD CHECK.
+					alterTable.dropConstraints = append(alterTable.dropConstraints, srcConstraint)
+					alterTable.addConstraints = append(alterTable.addConstraints, destConstraint)
+					droppedConstraint[srcConstraint] = true
+					continue
+				}
+				if isRenamed {
+					// sp_rename.
+					alterTable.renameConstraints = append(alterTable.renameConstraints, [2]*Constraint{srcConstraint, destConstraint})
+				}
 				if srcConstraint == nil {
 					switch destConstraint.ConstraintType {
-					case PRIMARY_KEY, UNIQUE:
-						// ADD PRIMARY KEY | ADD UNIQUE.
+					case PRIMARY_KEY, UNIQUE, CHECK:
+						// ADD PRIMARY KEY | ADD UNIQUE | ADD CHECK.
 						alterTable.addConstraints = append(alterTable.addConstraints, destConstraint)
 					case FOREIGN_KEY:
 						// ADD FOREIGN KEY.
@@ -365,12 +449,15 @@ func newSQLServerMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) s
 
 			// If we aren't configured to drop constraints, we have to manually
 			// drop the existing primary key if a new primary key is being
-			// added because there can only be one primary key at a time.
+			// added because there can only be one primary key at a time (a
+			// primary key that is only being renamed is not new).
 			destPkey := destCache.GetPrimaryKey(destTable)
 			srcPkey := srcCache.GetPrimaryKey(srcTable)
 			if !dropObjects && srcPkey != nil && destPkey != nil && srcPkey.ConstraintName != destPkey.ConstraintName {
-				alterTable.dropConstraints = append(alterTable.dropConstraints, srcPkey)
-				droppedConstraint[srcPkey] = true
+				if renamedPkey, _ := findSrcConstraint(srcCache, srcTable, destTable, destPkey); renamedPkey != srcPkey {
+					alterTable.dropConstraints = append(alterTable.dropConstraints, srcPkey)
+					droppedConstraint[srcPkey] = true
+				}
 			}
 
 			// If any of the columns we are altering have indexes or
@@ -396,6 +483,10 @@ func newSQLServerMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) s
 					columnConstraintDependencies[column] = append(columnConstraintDependencies[column], srcConstraint)
 				}
 			}
+			// If the table or any of its columns are renamed, the indexes and
+			// constraints must be recreated using the new names (and the ones
+			// that are recreated no longer need to be renamed).
+			isRenaming := alterTable.renameFrom != "" || len(alterTable.renameColumns) > 0
 			for _, columnpair := range alterTable.alterColumns {
 				columnName := columnpair[0].ColumnName
 				for _, index := range columnIndexDependencies[columnName] {
@@ -403,16 +494,38 @@ func newSQLServerMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) s
 						continue
 					}
 					alterTable.dropIndexes = append(alterTable.dropIndexes, index)
-					alterTable.createIndexes = append(alterTable.createIndexes, index)
+					recreateIndex := index
+					if destIndex := destCache.GetIndex(destTable, index.IndexName); isRenaming && destIndex != nil {
+						recreateIndex = destIndex
+					}
+					for i, indexes := range alterTable.renameIndexes {
+						if indexes[0] == index {
+							recreateIndex = indexes[1]
+							alterTable.renameIndexes = slices.Delete(alterTable.renameIndexes, i, i+1)
+							break
+						}
+					}
+					alterTable.createIndexes = append(alterTable.createIndexes, recreateIndex)
 				}
 				for _, constraint := range columnConstraintDependencies[columnName] {
 					if droppedConstraint[constraint] {
 						continue
 					}
+					recreateConstraint := constraint
+					if destConstraint := destCache.GetConstraint(destTable, constraint.ConstraintName); isRenaming && destConstraint != nil {
+						recreateConstraint = destConstraint
+					}
+					for i, constraints := range alterTable.renameConstraints {
+						if constraints[0] == constraint {
+							recreateConstraint = constraints[1]
+							alterTable.renameConstraints = slices.Delete(alterTable.renameConstraints, i, i+1)
+							break
+						}
+					}
 					switch constraint.ConstraintType {
-					case PRIMARY_KEY, UNIQUE:
+					case PRIMARY_KEY, UNIQUE, CHECK:
 						alterTable.dropConstraints = append(alterTable.dropConstraints, constraint)
-						alterTable.addConstraints = append(alterTable.addConstraints, constraint)
+						alterTable.addConstraints = append(alterTable.addConstraints, recreateConstraint)
 					case FOREIGN_KEY:
 						tablesID := getTablesID(constraint)
 						n1, ok1 := dropFkeysPos[tablesID]
@@ -423,18 +536,24 @@ func newSQLServerMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) s
 							m.dropFkeys = append(m.dropFkeys, []*Constraint{constraint})
 						}
 						if ok2 {
-							m.addFkeys[n2] = append(m.addFastFkeys[n2], constraint)
+							m.addFkeys[n2] = append(m.addFastFkeys[n2], recreateConstraint)
 						} else {
-							m.addFkeys = append(m.addFkeys, []*Constraint{constraint})
+							m.addFkeys = append(m.addFkeys, []*Constraint{recreateConstraint})
 						}
 					}
 				}
 			}
 
-			if len(alterTable.dropConstraints) > 0 ||
+			if alterTable.renameFrom != "" ||
+				len(alterTable.renameColumns) > 0 ||
+				len(alterTable.renameConstraints) > 0 ||
+				len(alterTable.renameIndexes) > 0 ||
+				len(alterTable.dropConstraints) > 0 ||
 				len(alterTable.dropIndexes) > 0 ||
+				len(alterTable.dropColumns) > 0 ||
 				len(alterTable.addColumns) > 0 ||
 				len(alterTable.alterColumns) > 0 ||
+				len(alterTable.comments) > 0 ||
 				len(alterTable.createIndexes) > 0 ||
 				len(alterTable.addConstraints) > 0 {
 				m.alterTables = append(m.alterTables, alterTable)
@@ -444,10 +563,11 @@ func newSQLServerMigration(srcCatalog, destCatalog *Catalog, dropObjects bool) s
 	return m
 }
 
-func (m *sqlserverMigration) sql(prefix string) (filenames []string, bufs []*bytes.Buffer, warnings []string) {
+func (m *sqlserverMigration) sql(prefix string) (filenames []string, bufs []*bytes.Buffer, warnings []Warning) {
 	const dialect = DialectSQLServer
 	warnings = m.warnings
 	n := 0
+	m.undoScopes = m.undoScopes[:0]
 	getTablesName := func(fkey *Constraint) string {
 		var b strings.Builder
 		b.Grow(len(fkey.TableSchema) + len(fkey.TableName) + len(fkey.ReferencesSchema) + len(fkey.ReferencesTable) + 4)
@@ -462,12 +582,37 @@ func (m *sqlserverMigration) sql(prefix string) (filenames []string, bufs []*byt
 		return b.String()
 	}
 
+	// DROP TRIGGER.
+	if m.routines.hasDropTriggers() {
+		n++
+		// ${prefix}_${n}_drop_triggers.sql
+		filenames = append(filenames, fmt.Sprintf("%s_%02d_drop_triggers.sql", prefix, n))
+		m.undoScopes = append(m.undoScopes, m.routines.undoDropTriggers())
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		m.routines.writeDropTriggers(buf)
+	}
+
+	// DROP VIEW.
+	if m.views.hasDropViews() {
+		n++
+		// ${prefix}_${n}_drop_views.sql
+		filenames = append(filenames, fmt.Sprintf("%s_%02d_drop_views.sql", prefix, n))
+		m.undoScopes = append(m.undoScopes, m.views.undoDropViews())
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		m.views.writeDropViews(buf)
+	}
+
 	// DROP FOREIGN KEY.
 	for _, fkeys := range m.dropFkeys {
 		n++
 		name := getTablesName(fkeys[0])
 		// ${prefix}_${n}_drop_${table1}_${table2}_fkeys.tx.sql
 		filenames = append(filenames, fmt.Sprintf("%s_%02d_drop_%s_fkeys.tx.sql", prefix, n, name))
+		m.undoScopes = append(m.undoScopes, undoScope{dropFkeys: fkeys})
 		buf := bufpool.Get().(*bytes.Buffer)
 		buf.Reset()
 		bufs = append(bufs, buf)
@@ -489,6 +634,7 @@ func (m *sqlserverMigration) sql(prefix string) (filenames []string, bufs []*byt
 		n++
 		// ${prefix}_${n}_drop_${schema}.sql
 		filenames = append(filenames, fmt.Sprintf("%s_%02d_drop_%s.sql", prefix, n, schema.SchemaName))
+		m.undoScopes = append(m.undoScopes, undoScope{schemas: []string{schema.SchemaName}})
 		buf := bufpool.Get().(*bytes.Buffer)
 		buf.Reset()
 		bufs = append(bufs, buf)
@@ -533,6 +679,7 @@ func (m *sqlserverMigration) sql(prefix string) (filenames []string, bufs []*byt
 		n++
 		// ${prefix}_${n}_create_schemas.sql
 		filenames = append(filenames, fmt.Sprintf("%s_%02d_create_schemas.sql", prefix, n))
+		m.undoScopes = append(m.undoScopes, undoScope{schemas: m.createSchemas})
 		buf := bufpool.Get().(*bytes.Buffer)
 		buf.Reset()
 		bufs = append(bufs, buf)
@@ -544,11 +691,24 @@ func (m *sqlserverMigration) sql(prefix string) (filenames []string, bufs []*byt
 		}
 	}
 
+	// CREATE FUNCTION | CREATE PROCEDURE (called by tables).
+	if m.routines.hasCreateTableRoutines() {
+		n++
+		// ${prefix}_${n}_routines.sql
+		filenames = append(filenames, fmt.Sprintf("%s_%02d_routines.sql", prefix, n))
+		m.undoScopes = append(m.undoScopes, m.routines.undoCreateTableRoutines())
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		m.routines.writeCreateTableRoutines(buf)
+	}
+
 	// DROP TABLE + CREATE TABLE.
 	if len(m.dropTables) > 0 || len(m.createTables) > 0 {
 		n++
 		// ${prefix}_${n}_tables.sql
 		filenames = append(filenames, fmt.Sprintf("%s_%02d_tables.sql", prefix, n))
+		m.undoScopes = append(m.undoScopes, undoScope{tables: append(slices.Clone(m.dropTables), m.createTables...)})
 		buf := bufpool.Get().(*bytes.Buffer)
 		buf.Reset()
 		bufs = append(bufs, buf)
@@ -593,10 +753,42 @@ func (m *sqlserverMigration) sql(prefix string) (filenames []string, bufs []*byt
 		}
 		// ${prefix}_${n}_alter_${table}.tx.sql
 		filenames = append(filenames, fmt.Sprintf("%s_%02d_alter_%s.tx.sql", prefix, n, name))
+		m.undoScopes = append(m.undoScopes, undoScope{
+			tables:          []*Table{alterTable.destTable},
+			skipIndexes:     alterTable.createIndexes,
+			skipConstraints: alterTable.addConstraints,
+		})
 		buf := bufpool.Get().(*bytes.Buffer)
 		buf.Reset()
 		bufs = append(bufs, buf)
 
+		// sp_rename.
+		if alterTable.renameFrom != "" {
+			oldName := EscapeQuote(alterTable.tableSchema+"."+alterTable.renameFrom, '\'')
+			buf.WriteString("EXEC sp_rename N'" + oldName + "', N'" + EscapeQuote(alterTable.tableName, '\'') + "';\n")
+		}
+		for _, names := range alterTable.renameColumns {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			oldName := EscapeQuote(alterTable.tableSchema+"."+alterTable.tableName+"."+names[0], '\'')
+			buf.WriteString("EXEC sp_rename N'" + oldName + "', N'" + EscapeQuote(names[1], '\'') + "', N'COLUMN';\n")
+		}
+		for _, constraints := range alterTable.renameConstraints {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			oldName := EscapeQuote(alterTable.tableSchema+"."+constraints[0].ConstraintName, '\'')
+			buf.WriteString("EXEC sp_rename N'" + oldName + "', N'" + EscapeQuote(constraints[1].ConstraintName, '\'') + "', N'OBJECT';\n")
+		}
+		for _, indexes := range alterTable.renameIndexes {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			oldName := EscapeQuote(alterTable.tableSchema+"."+alterTable.tableName+"."+indexes[0].IndexName, '\'')
+			buf.WriteString("EXEC sp_rename N'" + oldName + "', N'" + EscapeQuote(indexes[1].IndexName, '\'') + "', N'INDEX';\n")
+		}
+
 		// DROP INDEX.
 		for _, index := range alterTable.dropIndexes {
 			if buf.Len() > 0 {
@@ -607,6 +799,13 @@ func (m *sqlserverMigration) sql(prefix string) (filenames []string, bufs []*byt
 			if index.TableSchema != "" && index.TableSchema != m.currentSchema {
 				tableName = QuoteIdentifier(dialect, index.TableSchema) + "." + tableName
 			}
+			if alterTable.renameFrom != "" {
+				// The table has already been renamed.
+				tableName = QuoteIdentifier(dialect, alterTable.tableName)
+				if alterTable.tableSchema != m.currentSchema {
+					tableName = QuoteIdentifier(dialect, alterTable.tableSchema) + "." + tableName
+				}
+			}
 			buf.WriteString("DROP INDEX " + indexName + " ON " + tableName + ";\n")
 		}
 
@@ -670,7 +869,12 @@ func (m *sqlserverMigration) sql(prefix string) (filenames []string, bufs []*byt
 			}
 			if alterType || collation != "" || nullability != "" {
 				if alterType {
-					warnings = append(warnings, fmt.Sprintf("%s: column %s changing type from %q to %q may be unsafe", tableName, columnName, srcColumn.ColumnType, destColumn.ColumnType))
+					warnings = append(warnings, Warning{
+						Code:     WarnSQLServerChangeColumnType,
+						Severity: SeverityMedium,
+						Object:   warningObject(m.currentSchema, alterTable.tableSchema, alterTable.tableName, destColumn.ColumnName),
+						Message:  fmt.Sprintf("%s: column %s changing type from %q to %q may be unsafe", tableName, columnName, srcColumn.ColumnType, destColumn.ColumnType),
+					})
 				}
 				if buf.Len() > 0 {
 					buf.WriteString("\n")
@@ -720,11 +924,21 @@ END;
 			}
 		}
 
+		// sp_addextendedproperty | sp_updateextendedproperty |
+		// sp_dropextendedproperty.
+		for _, comment := range alterTable.comments {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			writeComment(dialect, buf, m.currentSchema, alterTable.tableSchema, alterTable.tableName, comment[0], comment[1], comment[2])
+		}
+
 		// CREATE INDEX.
 		for _, index := range alterTable.createIndexes {
 			n++
 			// ${prefix}_${n}_create_${index}.tx.sql
 			filenames = append(filenames, fmt.Sprintf("%s_%02d_create_%s.tx.sql", prefix, n, index.IndexName))
+			m.undoScopes = append(m.undoScopes, undoScope{indexes: []*Index{index}})
 			buf := bufpool.Get().(*bytes.Buffer)
 			buf.Reset()
 			bufs = append(bufs, buf)
@@ -736,6 +950,7 @@ END;
 			n++
 			// ${prefix}_${n}_add_${constraint}.tx.sql
 			filenames = append(filenames, fmt.Sprintf("%s_%02d_add_%s.tx.sql", prefix, n, constraint.ConstraintName))
+			m.undoScopes = append(m.undoScopes, undoScope{constraints: []*Constraint{constraint}})
 			buf := bufpool.Get().(*bytes.Buffer)
 			buf.Reset()
 			bufs = append(bufs, buf)
@@ -751,6 +966,7 @@ END;
 		name := getTablesName(fkeys[0])
 		// ${prefix}_${n}_add_${table1}_${table2}_fkeys.tx.sql
 		filenames = append(filenames, fmt.Sprintf("%s_%02d_add_%s_fkeys.tx.sql", prefix, n, name))
+		m.undoScopes = append(m.undoScopes, undoScope{constraints: fkeys})
 		buf := bufpool.Get().(*bytes.Buffer)
 		buf.Reset()
 		bufs = append(bufs, buf)
@@ -774,6 +990,7 @@ END;
 		name := getTablesName(fkeys[0])
 		// ${prefix}_${n}_add_${table1}_${table2}_fkeys.tx.sql
 		filenames = append(filenames, fmt.Sprintf("%s_%02d_add_%s_fkeys.tx.sql", prefix, n, name))
+		m.undoScopes = append(m.undoScopes, undoScope{constraints: fkeys})
 		buf := bufpool.Get().(*bytes.Buffer)
 		buf.Reset()
 		bufs = append(bufs, buf)
@@ -791,5 +1008,53 @@ END;
 		}
 	}
 
+	// CREATE FUNCTION | CREATE PROCEDURE.
+	if m.routines.hasCreateRoutines() {
+		n++
+		// ${prefix}_${n}_routines.sql
+		filenames = append(filenames, fmt.Sprintf("%s_%02d_routines.sql", prefix, n))
+		m.undoScopes = append(m.undoScopes, m.routines.undoCreateRoutines())
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		m.routines.writeCreateRoutines(buf)
+	}
+
+	// CREATE VIEW.
+	if m.views.hasCreateViews() {
+		n++
+		// ${prefix}_${n}_views.sql
+		filenames = append(filenames, fmt.Sprintf("%s_%02d_views.sql", prefix, n))
+		m.undoScopes = append(m.undoScopes, m.views.undoCreateViews())
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		m.views.writeCreateViews(buf)
+	}
+
+	// CREATE TRIGGER.
+	if m.routines.hasCreateTriggers() {
+		n++
+		// ${prefix}_${n}_triggers.sql
+		filenames = append(filenames, fmt.Sprintf("%s_%02d_triggers.sql", prefix, n))
+		m.undoScopes = append(m.undoScopes, m.routines.undoCreateTriggers())
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		m.routines.writeCreateTriggers(buf)
+	}
+
+	// DROP FUNCTION | DROP PROCEDURE.
+	if m.routines.hasDropRoutines() {
+		n++
+		// ${prefix}_${n}_drop_routines.sql
+		filenames = append(filenames, fmt.Sprintf("%s_%02d_drop_routines.sql", prefix, n))
+		m.undoScopes = append(m.undoScopes, m.routines.undoDropRoutines())
+		buf := bufpool.Get().(*bytes.Buffer)
+		buf.Reset()
+		bufs = append(bufs, buf)
+		m.routines.writeDropRoutines(buf)
+	}
+
 	return filenames, bufs, warnings
 }
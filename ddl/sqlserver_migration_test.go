@@ -23,6 +23,10 @@ func Test_sqlserverMigration(t *testing.T) {
 		{"testdata/sqlserver_add", false},
 		{"testdata/sqlserver_alter", false},
 		{"testdata/sqlserver_ignore", true},
+		{"testdata/sqlserver_check", true},
+		{"testdata/sqlserver_index", false},
+		{"testdata/sqlserver_comment", true},
+		{"testdata/sqlserver_rename", true},
 	}
 	newCatalog := func(t *testing.T, filename string) *Catalog {
 		file, err := os.Open(filename)
@@ -70,7 +74,7 @@ func Test_sqlserverMigration(t *testing.T) {
 			}
 			wantWarnings = string(bytes.TrimSpace(bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n"))))
 			if len(warnings) > 0 {
-				gotWarnings = strings.Join(warnings, "\n")
+				gotWarnings = strings.Join(warningMessages(warnings), "\n")
 			}
 			if diff := testutil.Diff(gotWarnings, wantWarnings); diff != "" {
 				t.Error(testutil.Callers(), diff)
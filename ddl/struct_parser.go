@@ -6,8 +6,12 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"go/types"
 	"io/fs"
+	"os"
+	"path/filepath"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"unicode"
@@ -15,16 +19,54 @@ import (
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/packages"
 )
 
-// StructParser is used to parse Go source code into TableStructs.
+// sqImportPath is the import path of the package that table structs are
+// defined with.
+const sqImportPath = "github.com/blink-io/sq"
+
+// StructParser is used to parse Go source code into TableStructs and
+// ViewStructs.
 type StructParser struct {
-	TableStructs       TableStructs
+	TableStructs TableStructs
+	ViewStructs  ViewStructs
+
+	// FS is used to read the files referenced by the sqlfile modifier of view
+	// structs. It should be the directory containing the Go source code
+	// passed to ParseFile. ParsePackages reads the files relative to the
+	// directory of each Go file instead.
+	FS fs.FS
+
 	parserDiagnostics  *parserDiagnostics
 	dialect            string
 	locations          map[[2]string]location
 	columnExplicitType map[[3]string]struct{}
 	cache              *CatalogCache
+
+	// Used to resolve the labels returned by Enumerate() methods.
+	stringConsts   map[string]string   // Constant name -> string value.
+	stringSlices   map[string]ast.Expr // Variable name -> []string literal.
+	enumerateExprs map[string]ast.Expr // Type name -> Enumerate() result.
+
+	// Used to look up the doc comment of an ungrouped type declaration
+	// (which is attached to the *ast.GenDecl instead of the *ast.TypeSpec).
+	typeDocs map[*ast.TypeSpec]*ast.CommentGroup
+
+	// Mixin name -> mixins. Mixins are plain structs whose fields are
+	// flattened into the table structs that embed them. Mixins from
+	// different packages may share a name, in which case they are told apart
+	// by their package path.
+	mixins map[string][]TableStruct
+
+	// Used to resolve the field types from the sq package regardless of how
+	// it was imported.
+	typesInfo    *types.Info // Type information of the current package, if any.
+	sqImportName string      // Name of the sq import in the current file.
+	pkgName      string      // Package name of the current file.
+
+	// Directory of the current file, if known. Overrides FS.
+	fileFS fs.FS
 }
 
 // NewStructParser creates a new StructParser. An existing token.Fileset can be
@@ -42,18 +84,98 @@ func NewStructParser(fset *token.FileSet) *StructParser {
 // VisitNode is a callback function that populates the TableStructs when passed
 // to ast.Inspect().
 func (p *StructParser) VisitNode(node ast.Node) bool {
-	switch node.(type) {
-	case *ast.File, *ast.GenDecl:
+	switch node := node.(type) {
+	case *ast.File:
+		p.sqImportName = ""
+		p.pkgName = node.Name.Name
+		for _, importSpec := range node.Imports {
+			if path, err := strconv.Unquote(importSpec.Path.Value); err != nil || path != sqImportPath {
+				continue
+			}
+			if importSpec.Name != nil {
+				p.sqImportName = importSpec.Name.Name
+			}
+		}
+		return true
+	case *ast.GenDecl:
+		p.VisitStruct(node)
 		return true
 	}
 	p.VisitStruct(node)
+	p.VisitEnum(node)
 	return false
 }
 
-// VisitStruct is a callback function that populates the TableStructs when
-// passed to inspect.Inspector.Preorder(). It expects the node to be of type
-// *ast.TypeSpec.
+// VisitEnum is a callback function that records the Enumerate() methods (and
+// the constants and variables they may refer to) used to resolve the labels
+// of enum types, when passed to inspect.Inspector.Preorder(). It expects the
+// node to be of type *ast.ValueSpec or *ast.FuncDecl.
+//
+//	func (r Rating) Enumerate() []string {
+//	    return []string{"G", "PG", "PG-13", "R", "NC-17"}
+//	}
+func (p *StructParser) VisitEnum(node ast.Node) {
+	if p.stringConsts == nil {
+		p.stringConsts = make(map[string]string)
+		p.stringSlices = make(map[string]ast.Expr)
+		p.enumerateExprs = make(map[string]ast.Expr)
+	}
+	switch node := node.(type) {
+	case *ast.ValueSpec:
+		for i, name := range node.Names {
+			if i >= len(node.Values) {
+				break
+			}
+			switch value := node.Values[i].(type) {
+			case *ast.BasicLit:
+				if value.Kind != token.STRING {
+					continue
+				}
+				if str, err := strconv.Unquote(value.Value); err == nil {
+					p.stringConsts[name.Name] = str
+				}
+			case *ast.CompositeLit:
+				p.stringSlices[name.Name] = value
+			}
+		}
+	case *ast.FuncDecl:
+		if node.Name.Name != "Enumerate" || node.Recv == nil || len(node.Recv.List) != 1 || node.Body == nil {
+			return
+		}
+		recvType := node.Recv.List[0].Type
+		if starExpr, ok := recvType.(*ast.StarExpr); ok {
+			recvType = starExpr.X
+		}
+		ident, ok := recvType.(*ast.Ident)
+		if !ok {
+			return
+		}
+		for _, stmt := range node.Body.List {
+			if returnStmt, ok := stmt.(*ast.ReturnStmt); ok && len(returnStmt.Results) == 1 {
+				p.enumerateExprs[ident.Name] = returnStmt.Results[0]
+			}
+		}
+	}
+}
+
+// VisitStruct is a callback function that populates the TableStructs and
+// ViewStructs when passed to inspect.Inspector.Preorder(). It expects the node to be of type
+// *ast.TypeSpec (or *ast.GenDecl, which is only used to pick up the doc
+// comment of the type declaration).
 func (p *StructParser) VisitStruct(node ast.Node) {
+	// Is it an ungrouped type declaration with a doc comment?
+	if genDecl, ok := node.(*ast.GenDecl); ok {
+		if genDecl.Tok != token.TYPE || genDecl.Doc == nil || len(genDecl.Specs) != 1 {
+			return
+		}
+		if typeSpec, ok := genDecl.Specs[0].(*ast.TypeSpec); ok && typeSpec.Doc == nil {
+			if p.typeDocs == nil {
+				p.typeDocs = make(map[*ast.TypeSpec]*ast.CommentGroup)
+			}
+			p.typeDocs[typeSpec] = genDecl.Doc
+		}
+		return
+	}
 	// Is it a type declaration?
 	typeSpec, ok := node.(*ast.TypeSpec)
 	if !ok {
@@ -72,6 +194,22 @@ func (p *StructParser) VisitStruct(node ast.Node) {
 		Name:   typeSpec.Name.Name,
 		Fields: make([]StructField, 0, len(structType.Fields.List)),
 	}
+	tableStruct.pkgPath = p.pkgName
+	if p.typesInfo != nil {
+		if obj := p.typesInfo.Defs[typeSpec.Name]; obj != nil && obj.Pkg() != nil {
+			tableStruct.pkgPath = obj.Pkg().Path()
+		}
+	}
+	if typeSpec.Doc != nil {
+		tableStruct.Comment = strings.TrimSpace(typeSpec.Doc.Text())
+	} else if doc := p.typeDocs[typeSpec]; doc != nil {
+		tableStruct.Comment = strings.TrimSpace(doc.Text())
+	}
+	// The tag errors are only reported once we know that the struct is a
+	// table struct or a mixin.
+	var errLocs []location
+	var errMsgs []string
+	var isTableStruct, isViewStruct, isMixin bool
 	for i, astField := range structType.Fields.List {
 		var structField StructField
 		// Name
@@ -79,16 +217,20 @@ func (p *StructParser) VisitStruct(node ast.Node) {
 			structField.Name = astField.Names[0].Name
 		}
 		// Type
-		if typ, ok := astField.Type.(*ast.SelectorExpr); ok {
-			if x, ok := typ.X.(*ast.Ident); ok {
-				structField.Type = x.Name + "." + typ.Sel.Name
-			}
-		} else if typ, ok := astField.Type.(*ast.Ident); ok {
-			structField.Type = typ.Name
-		} else if typ, ok := astField.Type.(*ast.StructType); ok {
-			if typ.Fields == nil || typ.Fields.List == nil {
-				structField.Type = "struct{}"
-			}
+		structField.Type = p.fieldType(astField.Type)
+		if structField.Name == "" {
+			structField.typePkgPath = p.fieldTypePkgPath(astField.Type)
+		}
+		if i == 0 {
+			isTableStruct = structField.Name == "" && structField.Type == "sq.TableStruct"
+			isViewStruct = structField.Name == "" && structField.Type == "sq.ViewStruct"
+		}
+		if strings.HasPrefix(structField.Type, "sq.") || structField.Name == "" || (structField.Name == "_" && structField.Type == "struct{}") {
+			isMixin = true
+		}
+		// Comment
+		if astField.Doc != nil {
+			structField.Comment = strings.TrimSpace(astField.Doc.Text())
 		}
 		// Tag
 		if astField.Tag != nil {
@@ -97,38 +239,210 @@ func (p *StructParser) VisitStruct(node ast.Node) {
 				structField.NameTag = reflect.StructTag(tag).Get("sq")
 				structField.Modifiers, err = NewModifiers(reflect.StructTag(tag).Get("ddl"))
 				if err != nil {
-					loc := location{
+					errLocs = append(errLocs, location{
 						pos:        structField.tagPos,
 						structName: tableStruct.Name,
 						fieldName:  structField.Name,
-					}
-					p.report(loc, err.Error())
+					})
+					errMsgs = append(errMsgs, err.Error())
 					continue
 				}
 			}
 		}
-		// If the first field is not sq.TableStruct, skip this struct entirely.
-		if i == 0 {
-			if structField.Name != "" || structField.Type != "sq.TableStruct" {
-				structNameIsUppercase := true
-				for _, char := range tableStruct.Name {
-					if !unicode.IsUpper(char) {
-						structNameIsUppercase = false
-						break
-					}
-				}
-				if structNameIsUppercase && structField.Type != "sq.TableStruct" {
-					loc := location{pos: structField.tagPos}
-					p.report(loc, "struct "+tableStruct.Name+" is all uppercase but no sq.TableStruct field was found")
+		tableStruct.Fields = append(tableStruct.Fields, structField)
+	}
+	if isViewStruct {
+		for i, loc := range errLocs {
+			p.report(loc, errMsgs[i])
+		}
+		viewStruct := ViewStruct{
+			Name:   tableStruct.Name,
+			Fields: tableStruct.Fields,
+			fsys:   p.FS,
+		}
+		if p.fileFS != nil {
+			viewStruct.fsys = p.fileFS
+		}
+		p.ViewStructs = append(p.ViewStructs, viewStruct)
+		return
+	}
+	// If the first field is not sq.TableStruct, the struct can only be used
+	// as a mixin (embedded in table structs). All uppercase structs are
+	// assumed to be table structs that are missing their sq.TableStruct.
+	if !isTableStruct {
+		structNameIsUppercase := true
+		for _, char := range tableStruct.Name {
+			if !unicode.IsUpper(char) {
+				structNameIsUppercase = false
+				break
+			}
+		}
+		if structNameIsUppercase {
+			firstField := structType.Fields.List[0]
+			if p.fieldType(firstField.Type) != "sq.TableStruct" {
+				var loc location
+				if firstField.Tag != nil {
+					loc.pos = firstField.Tag.Pos()
 				}
-				return
+				p.report(loc, "struct "+tableStruct.Name+" is all uppercase but no sq.TableStruct field was found")
 			}
+			return
 		}
-		tableStruct.Fields = append(tableStruct.Fields, structField)
+		if !isMixin {
+			return
+		}
+	}
+	for i, loc := range errLocs {
+		p.report(loc, errMsgs[i])
+	}
+	if !isTableStruct {
+		if p.mixins == nil {
+			p.mixins = make(map[string][]TableStruct)
+		}
+		mixins := p.mixins[tableStruct.Name]
+		i := slices.IndexFunc(mixins, func(mixin TableStruct) bool { return mixin.pkgPath == tableStruct.pkgPath })
+		if i >= 0 {
+			mixins[i] = tableStruct
+		} else {
+			p.mixins[tableStruct.Name] = append(mixins, tableStruct)
+		}
+		return
 	}
 	p.TableStructs.Tables = append(p.TableStructs.Tables, tableStruct)
 }
 
+// expandMixins returns the fields of the tableStruct with every embedded
+// mixin replaced by the fields of the mixin (recursively). Mixins may be
+// embedded by their name or by a qualified name e.g. Timestamps or
+// common.Timestamps. The fields belong to a struct declared in the package
+// pkgPath.
+func (p *StructParser) expandMixins(tableStruct TableStruct, pkgPath string, fields []StructField, mixinKeys []string) []StructField {
+	expandedFields := make([]StructField, 0, len(fields))
+	for _, structField := range fields {
+		if structField.Name != "" || structField.Type == "" || structField.Type == "sq.TableStruct" {
+			expandedFields = append(expandedFields, structField)
+			continue
+		}
+		mixinName := structField.Type[strings.LastIndexByte(structField.Type, '.')+1:]
+		loc := location{
+			pos:        structField.tagPos,
+			structName: tableStruct.Name,
+			fieldName:  mixinName,
+		}
+		mixin, ok, isAmbiguous := p.lookupMixin(pkgPath, structField, mixinName)
+		if isAmbiguous {
+			p.report(loc, "mixin "+mixinName+" is ambiguous, it is declared in more than one package")
+			continue
+		}
+		if !ok {
+			expandedFields = append(expandedFields, structField)
+			continue
+		}
+		mixinKey := mixin.pkgPath + "." + mixinName
+		if slices.Contains(mixinKeys, mixinKey) {
+			p.report(loc, "mixin "+mixinName+" embeds itself")
+			continue
+		}
+		if len(structField.Modifiers) > 0 {
+			p.report(loc, "modifiers are not allowed on an embedded mixin (put them inside the mixin instead)")
+		}
+		for _, mixinField := range p.expandMixins(tableStruct, mixin.pkgPath, mixin.Fields, append(mixinKeys, mixinKey)) {
+			if mixinField.mixinName == "" {
+				mixinField.mixinName = mixinName
+			}
+			expandedFields = append(expandedFields, mixinField)
+		}
+	}
+	return expandedFields
+}
+
+// lookupMixin returns the mixin embedded by the structField, which belongs
+// to a struct declared in the package pkgPath. If the type of the structField
+// was resolved, only a mixin from the same package matches. Otherwise the
+// mixin is looked up by name, preferring one from the package pkgPath for an
+// unqualified name, and isAmbiguous is true if more than one mixin matches.
+func (p *StructParser) lookupMixin(pkgPath string, structField StructField, mixinName string) (mixin TableStruct, ok bool, isAmbiguous bool) {
+	mixins := p.mixins[mixinName]
+	if structField.typePkgPath != "" {
+		i := slices.IndexFunc(mixins, func(mixin TableStruct) bool { return mixin.pkgPath == structField.typePkgPath })
+		if i < 0 {
+			return TableStruct{}, false, false
+		}
+		return mixins[i], true, false
+	}
+	switch len(mixins) {
+	case 0:
+		return TableStruct{}, false, false
+	case 1:
+		return mixins[0], true, false
+	}
+	if pkgPath != "" && !strings.Contains(structField.Type, ".") {
+		i := slices.IndexFunc(mixins, func(mixin TableStruct) bool { return mixin.pkgPath == pkgPath })
+		if i >= 0 {
+			return mixins[i], true, false
+		}
+	}
+	return TableStruct{}, false, true
+}
+
+// fieldTypePkgPath returns the import path of the package the type of a
+// struct field was declared in, or an empty string if the type could not be
+// resolved.
+func (p *StructParser) fieldTypePkgPath(expr ast.Expr) string {
+	if p.typesInfo == nil {
+		return ""
+	}
+	if named, ok := types.Unalias(p.typesInfo.TypeOf(expr)).(*types.Named); ok && named.Obj().Pkg() != nil {
+		return named.Obj().Pkg().Path()
+	}
+	return ""
+}
+
+// fieldType returns the type of a struct field. Types from the sq package
+// are always returned as "sq.Xxx", even if the package was imported under a
+// different name or dot-imported.
+func (p *StructParser) fieldType(expr ast.Expr) string {
+	if p.typesInfo != nil {
+		if typ := p.typesInfo.TypeOf(expr); typ != nil && typ != types.Typ[types.Invalid] {
+			if named, ok := types.Unalias(typ).(*types.Named); ok {
+				obj := named.Obj()
+				if obj.Pkg() != nil && obj.Pkg().Path() == sqImportPath {
+					return "sq." + obj.Name()
+				}
+			}
+			// The type is known not to come from the sq package, so don't
+			// guess from the import name.
+			if ident, ok := expr.(*ast.Ident); ok {
+				return ident.Name
+			}
+		}
+	}
+	switch expr := expr.(type) {
+	case *ast.SelectorExpr:
+		x, ok := expr.X.(*ast.Ident)
+		if !ok {
+			return ""
+		}
+		if p.sqImportName != "" && p.sqImportName != "." && x.Name == p.sqImportName {
+			return "sq." + expr.Sel.Name
+		}
+		return x.Name + "." + expr.Sel.Name
+	case *ast.Ident:
+		// A dot-imported sq package cannot coexist with a local type of the
+		// same name, so any exported identifier that is not a local type is
+		// assumed to come from the sq package.
+		if p.sqImportName == "." && ast.IsExported(expr.Name) && expr.Obj == nil {
+			return "sq." + expr.Name
+		}
+		return expr.Name
+	case *ast.StructType:
+		if expr.Fields == nil || expr.Fields.List == nil {
+			return "struct{}"
+		}
+	}
+	return ""
+}
+
 // ParseFile parses an fs.File containing Go source code and populates the
 // TableStructs.
 func (p *StructParser) ParseFile(f fs.File) error {
@@ -136,7 +450,7 @@ func (p *StructParser) ParseFile(f fs.File) error {
 	if err != nil {
 		return err
 	}
-	file, err := parser.ParseFile(p.parserDiagnostics.fset, fileinfo.Name(), f, 0)
+	file, err := parser.ParseFile(p.parserDiagnostics.fset, fileinfo.Name(), f, parser.ParseComments)
 	if err != nil {
 		return err
 	}
@@ -144,6 +458,47 @@ func (p *StructParser) ParseFile(f fs.File) error {
 	return p.Error()
 }
 
+// ParsePackages loads the Go packages matching the patterns (e.g. "./tables"
+// or "./internal/tables/...") relative to dir and populates the
+// TableStructs. Unlike ParseFile, field types are resolved by their import
+// path and table structs may be spread across multiple files. Positions in
+// the parser errors refer to the file each struct was declared in.
+func (p *StructParser) ParsePackages(dir string, patterns ...string) error {
+	// Dependencies are type-checked from source (NeedDeps) rather than
+	// loaded from export data, which go/packages cannot read if the installed
+	// Go toolchain is newer than the version of golang.org/x/tools that sqddl
+	// was built with.
+	config := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo,
+		Dir:  dir,
+		Fset: p.parserDiagnostics.fset,
+	}
+	pkgs, err := packages.Load(config, patterns...)
+	if err != nil {
+		return err
+	}
+	if len(pkgs) == 0 {
+		return fmt.Errorf("no packages found for %s", strings.Join(patterns, " "))
+	}
+	for _, pkg := range pkgs {
+		// Type errors are tolerated: field types that could not be resolved
+		// fall back to being matched by their import name.
+		for _, pkgErr := range pkg.Errors {
+			if pkgErr.Kind != packages.TypeError {
+				return pkgErr
+			}
+		}
+		p.typesInfo = pkg.TypesInfo
+		for _, file := range pkg.Syntax {
+			p.fileFS = os.DirFS(filepath.Dir(p.parserDiagnostics.fset.File(file.Pos()).Name()))
+			ast.Inspect(file, p.VisitNode)
+		}
+	}
+	p.typesInfo = nil
+	p.fileFS = nil
+	return p.Error()
+}
+
 // WriteCatalog populates the Catalog using the StructParser's TableStructs.
 func (p *StructParser) WriteCatalog(catalog *Catalog) error {
 	p.dialect = catalog.Dialect
@@ -169,22 +524,32 @@ func (p *StructParser) WriteCatalog(catalog *Catalog) error {
 
 		schema := p.cache.GetOrCreateSchema(catalog, tableSchema)
 		table := p.cache.GetOrCreateTable(schema, tableName)
+		if tableStruct.Comment != "" && p.dialect != DialectSQLite {
+			table.Comment = tableStruct.Comment
+		}
 
 		// The main loop.
-		for _, structField := range tableStruct.Fields {
+		var tableLoc location
+		columnLocs := make(map[string]location)
+		for _, structField := range p.expandMixins(tableStruct, tableStruct.pkgPath, tableStruct.Fields, nil) {
 			loc := location{
 				pos:        structField.tagPos,
 				structName: tableStruct.Name,
 				fieldName:  structField.Name,
+				mixinName:  structField.mixinName,
 			}
 			if (structField.Name == "" && structField.Type == "sq.TableStruct") || (structField.Name == "_" && structField.Type == "struct{}") {
-				p.parseTableModifiers(table, loc, structField.Modifiers)
+				p.parseTableModifiers(catalog, table, loc, structField.Modifiers)
+				if structField.Type == "sq.TableStruct" || slices.ContainsFunc(structField.Modifiers, func(m Modifier) bool { return m.Name == "withoutrowid" }) {
+					tableLoc = loc
+				}
 				continue
 			}
 			columnName := strings.ToLower(structField.Name)
 			if structField.NameTag != "" {
 				columnName = structField.NameTag
 			}
+			columnLocs[columnName] = loc
 			var columnType, characterLength string
 			switch structField.Type {
 			case "sq.AnyField":
@@ -273,7 +638,46 @@ func (p *StructParser) WriteCatalog(catalog *Catalog) error {
 				column := p.cache.GetOrCreateColumn(table, columnName, columnType)
 				column.CharacterLength = characterLength
 			}
+			if structField.Comment != "" && p.dialect != DialectSQLite {
+				column := p.cache.GetOrCreateColumn(table, columnName, columnType)
+				column.Comment = structField.Comment
+			}
 			p.parseColumnModifiers(table, columnName, columnType, loc, structField.Modifiers)
+			if structField.Type == "sq.EnumField" {
+				p.parseEnumColumn(catalog, table, columnName, tableStruct.Name, structField, loc)
+			}
+		}
+
+		// STRICT tables only allow the INT, INTEGER, REAL, TEXT, BLOB and ANY
+		// column types.
+		if catalog.Dialect == DialectSQLite && table.IsStrict && !table.Ignore {
+			for i := range table.Columns {
+				column := &table.Columns[i]
+				switch strings.ToUpper(column.ColumnType) {
+				case "", "INT", "INTEGER", "REAL", "TEXT", "BLOB", "ANY":
+					continue
+				}
+				loc := columnLocs[column.ColumnName]
+				if _, ok := p.columnExplicitType[[3]string{table.TableSchema, table.TableName, column.ColumnName}]; ok {
+					loc.keys = []string{"type"}
+				}
+				p.report(loc, "column type "+column.ColumnType+" is not allowed in a STRICT table, use type=INT, INTEGER, REAL, TEXT, BLOB or ANY")
+			}
+		}
+
+		// WITHOUT ROWID tables must have a PRIMARY KEY, and cannot have an
+		// AUTOINCREMENT column.
+		if catalog.Dialect == DialectSQLite && table.IsWithoutRowid && !table.Ignore {
+			loc := tableLoc
+			loc.keys = []string{"withoutrowid"}
+			if !slices.ContainsFunc(table.Constraints, func(c Constraint) bool { return !c.Ignore && c.ConstraintType == PRIMARY_KEY }) {
+				p.report(loc, "a WITHOUT ROWID table must have a PRIMARY KEY")
+			}
+			for i := range table.Columns {
+				if table.Columns[i].IsAutoincrement {
+					p.report(loc, table.Columns[i].ColumnName+": AUTOINCREMENT is not allowed in a WITHOUT ROWID table")
+				}
+			}
 		}
 
 		// Validate column existence for PRIMARY KEY and UNIQUE constraints.
@@ -283,6 +687,10 @@ func (p *StructParser) WriteCatalog(catalog *Catalog) error {
 			}
 			hasInvalidColumn := false
 			for _, columnName := range constraint.Columns {
+				// EXCLUDE constraints may contain expressions.
+				if constraint.ConstraintType == EXCLUDE && strings.ContainsAny(columnName, "( ") {
+					continue
+				}
 				column := p.cache.GetColumn(table, columnName)
 				if column == nil {
 					hasInvalidColumn = true
@@ -352,6 +760,32 @@ func (p *StructParser) WriteCatalog(catalog *Catalog) error {
 		}
 	}
 
+	// Columns whose type is a declared domain type take on the domain's
+	// underlying type (the same way they are introspected).
+	if p.dialect == DialectPostgres {
+		for i := range catalog.Schemas {
+			for j := range catalog.Schemas[i].Tables {
+				table := &catalog.Schemas[i].Tables[j]
+				for k := range table.Columns {
+					column := &table.Columns[k]
+					if _, ok := p.columnExplicitType[[3]string{table.TableSchema, table.TableName, column.ColumnName}]; !ok || column.DomainName != "" {
+						continue
+					}
+					domainSchema, domainName := table.TableSchema, column.ColumnType
+					if i := strings.IndexByte(domainName, '.'); i >= 0 {
+						domainSchema, domainName = domainName[:i], domainName[i+1:]
+					}
+					domain := p.cache.GetDomain(p.cache.GetSchema(catalog, domainSchema), domainName)
+					if domain == nil {
+						continue
+					}
+					column.DomainName = column.ColumnType
+					column.ColumnType = domain.UnderlyingType
+				}
+			}
+		}
+	}
+
 	// Validate column existence for FOREIGN KEY constraints.
 	for _, schema := range catalog.Schemas {
 		for _, table := range schema.Tables {
@@ -395,9 +829,106 @@ func (p *StructParser) WriteCatalog(catalog *Catalog) error {
 			}
 		}
 	}
+
+	for _, viewStruct := range p.ViewStructs {
+		var viewSchema string
+		viewName := strings.ToLower(viewStruct.Name)
+		if viewStruct.Fields[0].NameTag != "" {
+			viewName = viewStruct.Fields[0].NameTag
+		}
+		if i := strings.IndexByte(viewName, '.'); i >= 0 {
+			viewSchema, viewName = viewName[:i], viewName[i+1:]
+		}
+		if viewSchema == "" && catalog.CurrentSchema != "" {
+			viewSchema = catalog.CurrentSchema
+		}
+		schema := p.cache.GetOrCreateSchema(catalog, viewSchema)
+		// The schema's views are declared by its view structs, so views
+		// missing from them no longer exist.
+		schema.ViewsValid = true
+		view := p.cache.GetOrCreateView(schema, viewName)
+		view.ViewSchema = viewSchema
+		loc := location{
+			pos:        viewStruct.Fields[0].tagPos,
+			structName: viewStruct.Name,
+		}
+		p.parseViewModifiers(viewStruct, view, loc, viewStruct.Fields[0].Modifiers)
+		if view.SQL == "" && !view.Ignore {
+			p.report(loc, "view struct "+viewStruct.Name+" has no sql or sqlfile modifier")
+		}
+		if p.dialect == DialectPostgres && view.SQL != "" {
+			view.Comment = viewSQLHash(p.dialect, view.SQL)
+		}
+	}
 	return p.Error()
 }
 
+// parseViewModifiers parses the modifiers of the sq.ViewStruct field of a
+// view struct.
+func (p *StructParser) parseViewModifiers(viewStruct ViewStruct, view *View, loc location, modifiers []Modifier) {
+	var dialects []string
+	for i := range modifiers {
+		modifier := &modifiers[i]
+		if len(modifier.Dialects) == 0 {
+			modifier.Dialects = dialects
+		}
+		loc.keys = []string{modifier.Name}
+		switch modifier.Name {
+		case "dialect":
+			if modifier.RawValue == "" {
+				p.report(loc, "dialect value cannot be blank")
+				continue
+			}
+			view.Ignore = true
+			dialects = strings.Split(modifier.RawValue, ",")
+			for _, dialect := range dialects {
+				if p.dialect == dialect {
+					view.Ignore = false
+					break
+				}
+			}
+		case "sql":
+			if modifier.ExcludesDialect(p.dialect) {
+				continue
+			}
+			view.SQL = trimViewSQL(modifier.RawValue)
+		case "sqlfile":
+			if modifier.ExcludesDialect(p.dialect) {
+				continue
+			}
+			if modifier.RawValue == "" {
+				p.report(loc, "sqlfile value cannot be blank")
+				continue
+			}
+			if viewStruct.fsys == nil {
+				p.report(loc, "cannot read "+modifier.RawValue+": StructParser.FS is nil")
+				continue
+			}
+			b, err := fs.ReadFile(viewStruct.fsys, filepath.ToSlash(modifier.RawValue))
+			if err != nil {
+				p.report(loc, err.Error())
+				continue
+			}
+			view.SQL = trimViewSQL(string(b))
+		case "materialized":
+			if p.dialect != DialectPostgres || modifier.ExcludesDialect(p.dialect) {
+				continue
+			}
+			view.IsMaterialized = true
+		default:
+			p.report(loc, "unknown modifier "+strconv.Quote(modifier.Name))
+		}
+	}
+}
+
+// trimViewSQL trims the whitespace and trailing semicolon of a view
+// definition.
+func trimViewSQL(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimSuffix(s, ";")
+	return strings.TrimSpace(s)
+}
+
 func (p *StructParser) parseIndexModifier(table *Table, columnNames []string, loc location, m *Modifier) {
 	err := m.ParseRawValue()
 	if err != nil {
@@ -411,6 +942,16 @@ func (p *StructParser) parseIndexModifier(table *Table, columnNames []string, lo
 		p.report(loc, "no column provided")
 	}
 	indexName := GenerateName(INDEX, table.TableName, columnNames)
+	for i := range m.Submodifiers {
+		submodifier := &m.Submodifiers[i]
+		if submodifier.Name == "name" && submodifier.RawValue != "" && !submodifier.ExcludesDialect(p.dialect) {
+			if loc.mixinName != "" {
+				p.report(loc, "index name cannot be set in mixin "+loc.mixinName+" (it is generated for each table)")
+				continue
+			}
+			indexName = submodifier.RawValue
+		}
+	}
 	p.locations[[2]string{table.TableSchema, indexName}] = loc
 	index := p.cache.GetOrCreateIndex(table, indexName, columnNames)
 	index.TableSchema = table.TableSchema
@@ -429,6 +970,47 @@ func (p *StructParser) parseIndexModifier(table *Table, columnNames []string, lo
 				continue
 			}
 			index.IndexType = submodifier.RawValue
+		case "name":
+			// Handled above.
+		case "desc":
+			index.Descending = make([]bool, len(columnNames))
+			if submodifier.RawValue == "" {
+				for j := range index.Descending {
+					index.Descending[j] = true
+				}
+				continue
+			}
+			for _, columnName := range strings.Split(submodifier.RawValue, ",") {
+				j := slices.Index(columnNames, columnName)
+				if j < 0 {
+					loc.keys = append(loc.keys, submodifier.Name)
+					p.report(loc, strconv.Quote(columnName)+" is not an index column")
+					continue
+				}
+				index.Descending[j] = true
+			}
+		case "include":
+			if p.dialect != DialectPostgres && p.dialect != DialectSQLServer {
+				continue
+			}
+			index.IncludeColumns = strings.Split(submodifier.RawValue, ",")
+		case "where":
+			if p.dialect != DialectSQLite && p.dialect != DialectPostgres && p.dialect != DialectSQLServer {
+				continue
+			}
+			index.Predicate = submodifier.RawValue
+		case "opclass":
+			if p.dialect != DialectPostgres {
+				continue
+			}
+			opclasses := strings.Split(submodifier.RawValue, ",")
+			if len(opclasses) > len(columnNames) {
+				loc.keys = append(loc.keys, submodifier.Name)
+				p.report(loc, "more opclasses than index columns")
+				continue
+			}
+			index.Opclasses = make([]string, len(columnNames))
+			copy(index.Opclasses, opclasses)
 		default:
 			p.report(loc, "unknown modifier "+strconv.Quote(submodifier.Name))
 		}
@@ -683,11 +1265,189 @@ func (p *StructParser) parseReferencesModifier(table *Table, columnName string,
 	}
 }
 
+// parseCheckModifier parses a check modifier into a CHECK constraint. A
+// column-level check is named using GenerateName (with a numeric suffix if a
+// column has more than one check), while a table-level check must be named
+// explicitly (a table-level check in a mixin is named using GenerateName with
+// the explicit name standing in for the columns).
+//
+//	check={price > 0}                              // column-level
+//	check={film_rental_rate_check rental_rate > 0} // table-level
+func (p *StructParser) parseCheckModifier(table *Table, columnName string, loc location, m *Modifier) {
+	checkExpr := strings.TrimSpace(m.RawValue)
+	var constraintName string
+	var columnNames []string
+	if columnName != "" {
+		columnNames = []string{columnName}
+		constraintName = GenerateName(CHECK, table.TableName, columnNames)
+		for n := 1; ; n++ {
+			constraint := p.cache.GetConstraint(table, constraintName)
+			if constraint == nil || constraint.CheckExpr == checkExpr {
+				break
+			}
+			constraintName = GenerateName(CHECK, table.TableName, columnNames) + strconv.Itoa(n)
+		}
+	} else {
+		var ok bool
+		constraintName, checkExpr, ok = strings.Cut(checkExpr, " ")
+		if !ok {
+			p.report(loc, "check modifier must be of the form check={name expr}")
+			return
+		}
+		checkExpr = strings.TrimSpace(checkExpr)
+		// A mixin is embedded in multiple tables, so its checks are named
+		// for each table.
+		if loc.mixinName != "" {
+			constraintName = GenerateName(CHECK, table.TableName, []string{constraintName})
+		}
+	}
+	if checkExpr == "" {
+		p.report(loc, "check expression cannot be blank")
+		return
+	}
+	p.locations[[2]string{table.TableSchema, constraintName}] = loc
+	constraint := p.cache.GetOrCreateConstraint(table, constraintName, CHECK, columnNames)
+	constraint.TableSchema = table.TableSchema
+	constraint.TableName = table.TableName
+	constraint.CheckExpr = checkExpr
+	constraint.Ignore = m.ExcludesDialect(p.dialect)
+}
+
+// parseExcludeModifier parses a table-level exclude modifier into an EXCLUDE
+// constraint (Postgres only). Each exclusion element is written as
+// expr:operator, with the expr wrapped in braces if it contains spaces.
+//
+//	exclude={using=gist room_id:= during:&&}
+//	exclude={using=gist room_id:= {tsrange(start_at, end_at)}:&& where={NOT is_cancelled}}
+func (p *StructParser) parseExcludeModifier(table *Table, loc location, m *Modifier) {
+	var columnNames, operators []string
+	var submodifiers []Modifier
+	remainder := m.RawValue
+	for {
+		remainder = strings.TrimLeft(remainder, " ")
+		if remainder == "" {
+			break
+		}
+		expr, operator, rest, ok := popExclusionElement(remainder)
+		if ok {
+			columnNames = append(columnNames, expr)
+			operators = append(operators, operator)
+			remainder = rest
+			continue
+		}
+		var submodifier Modifier
+		var err error
+		submodifier, remainder, err = popModifier(remainder)
+		if err != nil {
+			p.report(loc, err.Error())
+			return
+		}
+		submodifiers = append(submodifiers, submodifier)
+	}
+	if len(columnNames) == 0 {
+		p.report(loc, "no exclusion elements provided (e.g. exclude={using=gist room_id:= during:&&})")
+		return
+	}
+	constraintName := generateExclusionName(table.TableName, columnNames)
+	for i := range submodifiers {
+		submodifier := &submodifiers[i]
+		if submodifier.Name == "name" && submodifier.RawValue != "" && !submodifier.ExcludesDialect(p.dialect) {
+			if loc.mixinName != "" {
+				p.report(loc, "constraint name cannot be set in mixin "+loc.mixinName+" (it is generated for each table)")
+				continue
+			}
+			constraintName = submodifier.RawValue
+		}
+	}
+	p.locations[[2]string{table.TableSchema, constraintName}] = loc
+	constraint := p.cache.GetOrCreateConstraint(table, constraintName, EXCLUDE, columnNames)
+	constraint.TableSchema = table.TableSchema
+	constraint.TableName = table.TableName
+	constraint.ExclusionOperators = operators
+	constraint.Ignore = m.ExcludesDialect(p.dialect)
+	for i := range submodifiers {
+		submodifier := &submodifiers[i]
+		if submodifier.ExcludesDialect(p.dialect) {
+			continue
+		}
+		switch submodifier.Name {
+		case "using":
+			constraint.ExclusionIndexType = submodifier.RawValue
+		case "where":
+			constraint.ExclusionPredicate = submodifier.RawValue
+		case "name":
+			// Handled above.
+		case "deferrable":
+			constraint.IsDeferrable = true
+		case "deferred":
+			constraint.IsDeferrable = true
+			constraint.IsInitiallyDeferred = true
+		default:
+			p.report(loc, "unknown modifier "+strconv.Quote(submodifier.Name))
+		}
+	}
+}
+
+// generateExclusionName generates the default name of an EXCLUDE constraint.
+// Like Postgres, an expression element is named after its function (or
+// "expr" if there is none).
+func generateExclusionName(tableName string, columnNames []string) string {
+	names := make([]string, len(columnNames))
+	for i, columnName := range columnNames {
+		if !strings.ContainsAny(columnName, "( ") {
+			names[i] = columnName
+			continue
+		}
+		name, _, _ := strings.Cut(columnName, "(")
+		if name == "" || strings.ContainsAny(name, " ") {
+			name = "expr"
+		}
+		names[i] = name
+	}
+	return GenerateName(EXCLUDE, tableName, names)
+}
+
+// popExclusionElement pops an exclusion element (expr:operator) from a string
+// and returns the expr, operator and remainder. If the string does not start
+// with an exclusion element, ok is false.
+func popExclusionElement(s string) (expr, operator, remainder string, ok bool) {
+	if strings.HasPrefix(s, "{") {
+		bracelevel := 0
+		for i, char := range s {
+			if char == '{' {
+				bracelevel++
+			} else if char == '}' {
+				bracelevel--
+			}
+			if bracelevel == 0 {
+				expr, remainder = s[1:i], s[i+1:]
+				break
+			}
+		}
+		if !strings.HasPrefix(remainder, ":") {
+			return "", "", s, false
+		}
+		remainder = remainder[1:]
+	} else {
+		i := strings.IndexAny(s, ": ")
+		if i <= 0 || s[i] != ':' {
+			return "", "", s, false
+		}
+		expr, remainder = s[:i], s[i+1:]
+	}
+	operator, remainder, _ = strings.Cut(remainder, " ")
+	// Operators are made up of a restricted set of characters, which tells
+	// them apart from dialect-prefixed submodifiers e.g. postgres:using=gist.
+	if operator == "" || strings.Trim(operator, "+-*/<>=~!@#%^&|`?") != "" {
+		return "", "", s, false
+	}
+	return expr, operator, remainder, true
+}
+
 func (p *StructParser) parseColumnModifiers(table *Table, columnName, columnType string, loc location, modifiers []Modifier) {
 	column := p.cache.GetOrCreateColumn(table, columnName, columnType)
 	column.TableSchema = table.TableSchema
 	column.TableName = table.TableName
-	column.IsEnum = columnType == "sq.EnumField"
 
 	var dialects []string
 	for i := range modifiers {
@@ -807,13 +1567,261 @@ func (p *StructParser) parseColumnModifiers(table *Table, columnName, columnType
 		case "references":
 			loc.keys = []string{modifier.Name}
 			p.parseReferencesModifier(table, columnName, loc, modifier)
+		case "check":
+			loc.keys = []string{modifier.Name}
+			p.parseCheckModifier(table, columnName, loc, modifier)
+		case "comment":
+			if p.dialect == DialectSQLite {
+				continue
+			}
+			column.Comment = modifier.RawValue
+		case "renamedfrom":
+			column.RenamedFrom = modifier.RawValue
+		case "acceptwarning":
+			if modifier.RawValue == "" {
+				loc.keys = []string{modifier.Name}
+				p.report(loc, "acceptwarning value cannot be blank")
+				continue
+			}
+			column.AcceptWarnings = append(column.AcceptWarnings, strings.Split(modifier.RawValue, ",")...)
+		case "enum":
+			// Handled by parseEnumColumn.
 		default:
 			p.report(loc, "unknown modifier "+strconv.Quote(modifier.Name))
 		}
 	}
 }
 
-func (p *StructParser) parseTableModifiers(table *Table, loc location, modifiers []Modifier) {
+// parseDomainModifier parses a domain modifier into a Domain. Unnamed check
+// constraints are named {domain}_check, {domain}_check1, {domain}_check2 etc
+// (the same way Postgres names them).
+//
+//	domain={email type=TEXT notnull check={VALUE ~ '@'}}
+func (p *StructParser) parseDomainModifier(catalog *Catalog, table *Table, loc location, m *Modifier) {
+	err := m.ParseRawValue()
+	if err != nil {
+		p.report(loc, err.Error())
+		return
+	}
+	if m.Value == "" {
+		p.report(loc, "domain name cannot be blank")
+		return
+	}
+	domain := Domain{DomainSchema: table.TableSchema, DomainName: m.Value}
+	if i := strings.IndexByte(domain.DomainName, '.'); i >= 0 {
+		domain.DomainSchema, domain.DomainName = domain.DomainName[:i], domain.DomainName[i+1:]
+	}
+	for _, submodifier := range m.Submodifiers {
+		switch submodifier.Name {
+		case "type":
+			domain.UnderlyingType = submodifier.RawValue
+		case "notnull":
+			domain.IsNotNull = true
+		case "default":
+			domain.ColumnDefault = submodifier.RawValue
+		case "collate":
+			domain.CollationName = submodifier.RawValue
+		case "check":
+			if submodifier.RawValue == "" {
+				p.report(loc, "domain check cannot be blank")
+				continue
+			}
+			checkName := domain.DomainName + "_check"
+			if n := len(domain.CheckNames); n > 0 {
+				checkName += strconv.Itoa(n)
+			}
+			domain.CheckNames = append(domain.CheckNames, checkName)
+			domain.CheckExprs = append(domain.CheckExprs, submodifier.RawValue)
+		default:
+			p.report(loc, "unknown domain submodifier "+strconv.Quote(submodifier.Name))
+		}
+	}
+	if domain.UnderlyingType == "" {
+		p.report(loc, "domain "+domain.DomainName+" has no type")
+		return
+	}
+	schema := p.cache.GetOrCreateSchema(catalog, domain.DomainSchema)
+	schema.DomainsValid = true
+	if existingDomain := p.cache.GetDomain(schema, domain.DomainName); existingDomain != nil {
+		if !reflect.DeepEqual(*existingDomain, domain) {
+			p.report(loc, "domain "+domain.DomainName+" is declared more than once with different definitions")
+		}
+		return
+	}
+	p.cache.AddOrUpdateDomain(schema, domain)
+}
+
+// parseSequenceModifier parses a sequence modifier into a Sequence. The
+// ownedby submodifier is a column of the table, or table.column for a column
+// of another table in the same schema.
+//
+//	sequence={order_number_seq type=INT start=1000 ownedby=order_number}
+func (p *StructParser) parseSequenceModifier(catalog *Catalog, table *Table, loc location, m *Modifier) {
+	err := m.ParseRawValue()
+	if err != nil {
+		p.report(loc, err.Error())
+		return
+	}
+	if m.Value == "" {
+		p.report(loc, "sequence name cannot be blank")
+		return
+	}
+	sequence := Sequence{SequenceSchema: table.TableSchema, SequenceName: m.Value}
+	if i := strings.IndexByte(sequence.SequenceName, '.'); i >= 0 {
+		sequence.SequenceSchema, sequence.SequenceName = sequence.SequenceName[:i], sequence.SequenceName[i+1:]
+	}
+	var hasMinValue, hasMaxValue, hasStartValue bool
+	for _, submodifier := range m.Submodifiers {
+		var value *int64
+		switch submodifier.Name {
+		case "type":
+			sequence.DataType = submodifier.RawValue
+		case "increment":
+			value = &sequence.Increment
+		case "minvalue":
+			value, hasMinValue = &sequence.MinValue, true
+		case "maxvalue":
+			value, hasMaxValue = &sequence.MaxValue, true
+		case "start":
+			value, hasStartValue = &sequence.StartValue, true
+		case "cycle":
+			sequence.IsCycle = true
+		case "ownedby":
+			sequence.OwnedByTable, sequence.OwnedByColumn = table.TableName, submodifier.RawValue
+			if i := strings.IndexByte(submodifier.RawValue, '.'); i >= 0 {
+				sequence.OwnedByTable, sequence.OwnedByColumn = submodifier.RawValue[:i], submodifier.RawValue[i+1:]
+			}
+		default:
+			p.report(loc, "unknown sequence submodifier "+strconv.Quote(submodifier.Name))
+		}
+		if value == nil {
+			continue
+		}
+		n, err := strconv.ParseInt(submodifier.RawValue, 10, 64)
+		if err != nil {
+			p.report(loc, "sequence "+submodifier.Name+" "+strconv.Quote(submodifier.RawValue)+" is not an integer")
+			continue
+		}
+		if value == &sequence.Increment && n == 0 {
+			p.report(loc, "sequence increment cannot be zero")
+			continue
+		}
+		*value = n
+	}
+	setSequenceDefaults(&sequence, hasMinValue, hasMaxValue, hasStartValue)
+	schema := p.cache.GetOrCreateSchema(catalog, sequence.SequenceSchema)
+	schema.SequencesValid = true
+	if existingSequence := p.cache.GetSequence(schema, sequence.SequenceName); existingSequence != nil {
+		if !reflect.DeepEqual(*existingSequence, sequence) {
+			p.report(loc, "sequence "+sequence.SequenceName+" is declared more than once with different definitions")
+		}
+		return
+	}
+	p.cache.AddOrUpdateSequence(schema, sequence)
+}
+
+// parseEnumColumn marks an sq.EnumField column as an enum column. The labels
+// of the enum are read from the Enumerate() method of the Go type named by the
+// enum modifier, or Enum{StructName}{FieldName} if there is no enum modifier.
+// For Postgres, if the column has an explicit type the enum type is added to
+// the catalog using that type name.
+func (p *StructParser) parseEnumColumn(catalog *Catalog, table *Table, columnName, structName string, structField StructField, loc location) {
+	column := p.cache.GetColumn(table, columnName)
+	if column == nil {
+		return
+	}
+	column.IsEnum = true
+	typeName := normalizeEnumName(structName, structField.Name)
+	hasEnumModifier := false
+	for _, modifier := range structField.Modifiers {
+		if modifier.Name != "enum" || modifier.ExcludesDialect(p.dialect) {
+			continue
+		}
+		typeName, hasEnumModifier = modifier.RawValue, true
+		loc.keys = []string{modifier.Name}
+	}
+	labels, ok := p.enumLabels(typeName)
+	if !ok {
+		if hasEnumModifier {
+			p.report(loc, "could not find the labels of enum "+typeName+" (it needs an Enumerate() method returning a []string literal)")
+		}
+		return
+	}
+	if p.dialect != DialectPostgres {
+		return
+	}
+	if _, ok := p.columnExplicitType[[3]string{table.TableSchema, table.TableName, columnName}]; !ok {
+		return
+	}
+	enumSchema, enumName := table.TableSchema, strings.TrimSuffix(column.ColumnType, "[]")
+	if i := strings.IndexByte(enumName, '.'); i >= 0 {
+		enumSchema, enumName = enumName[:i], enumName[i+1:]
+	}
+	schema := p.cache.GetOrCreateSchema(catalog, enumSchema)
+	schema.EnumsValid = true
+	if enum := p.cache.GetEnum(schema, enumName); enum != nil {
+		if !slices.Equal(enum.EnumLabels, labels) {
+			p.report(loc, "enum "+enumName+" has conflicting labels ("+strings.Join(enum.EnumLabels, ",")+" and "+strings.Join(labels, ",")+")")
+		}
+		return
+	}
+	enum := p.cache.GetOrCreateEnum(schema, enumName)
+	enum.EnumLabels = labels
+}
+
+// enumLabels returns the labels returned by the Enumerate() method of the Go
+// type, and whether they could be resolved.
+func (p *StructParser) enumLabels(typeName string) (labels []string, ok bool) {
+	expr, ok := p.enumerateExprs[typeName]
+	if !ok {
+		return nil, false
+	}
+	if ident, isIdent := expr.(*ast.Ident); isIdent {
+		expr, ok = p.stringSlices[ident.Name]
+		if !ok {
+			return nil, false
+		}
+	}
+	compositeLit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return nil, false
+	}
+	labels = make([]string, 0, len(compositeLit.Elts))
+	for _, elt := range compositeLit.Elts {
+		label, ok := p.enumLabel(elt)
+		if !ok {
+			return nil, false
+		}
+		labels = append(labels, label)
+	}
+	return labels, true
+}
+
+// enumLabel resolves an element of a []string literal into a string. The
+// element may be a string literal, a string constant or a conversion of either
+// e.g. string(RatingG).
+func (p *StructParser) enumLabel(expr ast.Expr) (label string, ok bool) {
+	switch expr := expr.(type) {
+	case *ast.BasicLit:
+		if expr.Kind != token.STRING {
+			return "", false
+		}
+		label, err := strconv.Unquote(expr.Value)
+		return label, err == nil
+	case *ast.Ident:
+		label, ok = p.stringConsts[expr.Name]
+		return label, ok
+	case *ast.CallExpr:
+		if len(expr.Args) == 1 {
+			return p.enumLabel(expr.Args[0])
+		}
+	case *ast.ParenExpr:
+		return p.enumLabel(expr.X)
+	}
+	return "", false
+}
+
+func (p *StructParser) parseTableModifiers(catalog *Catalog, table *Table, loc location, modifiers []Modifier) {
 	var dialects []string
 	for i := range modifiers {
 		modifier := &modifiers[i]
@@ -844,11 +1852,121 @@ func (p *StructParser) parseTableModifiers(table *Table, loc location, modifiers
 		case "foreignkey":
 			loc.keys = []string{modifier.Name}
 			p.parseForeignKeyModifier(table, loc, modifier)
+		case "check":
+			loc.keys = []string{modifier.Name}
+			p.parseCheckModifier(table, "", loc, modifier)
+		case "exclude":
+			if p.dialect != DialectPostgres || modifier.ExcludesDialect(p.dialect) {
+				continue
+			}
+			loc.keys = []string{modifier.Name}
+			p.parseExcludeModifier(table, loc, modifier)
+		case "comment":
+			if p.dialect == DialectSQLite || modifier.ExcludesDialect(p.dialect) {
+				continue
+			}
+			table.Comment = modifier.RawValue
+		case "renamedfrom":
+			if modifier.ExcludesDialect(p.dialect) {
+				continue
+			}
+			table.RenamedFrom = modifier.RawValue
+		case "acceptwarning":
+			if modifier.ExcludesDialect(p.dialect) {
+				continue
+			}
+			if modifier.RawValue == "" {
+				loc.keys = []string{modifier.Name}
+				p.report(loc, "acceptwarning value cannot be blank")
+				continue
+			}
+			table.AcceptWarnings = append(table.AcceptWarnings, strings.Split(modifier.RawValue, ",")...)
 		case "virtual":
 			if p.dialect != DialectSQLite {
 				continue
 			}
 			table.IsVirtual = true
+		case "strict":
+			if p.dialect != DialectSQLite || modifier.ExcludesDialect(p.dialect) {
+				continue
+			}
+			table.IsStrict = true
+		case "withoutrowid":
+			if p.dialect != DialectSQLite || modifier.ExcludesDialect(p.dialect) {
+				continue
+			}
+			table.IsWithoutRowid = true
+		case "extension":
+			if p.dialect != DialectPostgres || modifier.ExcludesDialect(p.dialect) {
+				continue
+			}
+			if modifier.RawValue == "" {
+				loc.keys = []string{modifier.Name}
+				p.report(loc, "extension value cannot be blank")
+				continue
+			}
+			catalog.ExtensionsValid = true
+			for _, extension := range strings.Split(modifier.RawValue, ",") {
+				if !slices.Contains(catalog.Extensions, extension) {
+					catalog.Extensions = append(catalog.Extensions, extension)
+				}
+			}
+		case "domain":
+			if p.dialect != DialectPostgres || modifier.ExcludesDialect(p.dialect) {
+				continue
+			}
+			loc.keys = []string{modifier.Name}
+			p.parseDomainModifier(catalog, table, loc, modifier)
+		case "sequence":
+			if p.dialect != DialectPostgres || modifier.ExcludesDialect(p.dialect) {
+				continue
+			}
+			loc.keys = []string{modifier.Name}
+			p.parseSequenceModifier(catalog, table, loc, modifier)
+		case "partitionby":
+			if p.dialect != DialectPostgres || modifier.ExcludesDialect(p.dialect) {
+				continue
+			}
+			partitionBy, err := normalizePartitionKey(modifier.RawValue)
+			if err != nil {
+				loc.keys = []string{modifier.Name}
+				p.report(loc, err.Error())
+				continue
+			}
+			table.PartitionBy = partitionBy
+		case "engine", "charset", "collate", "rowformat":
+			if p.dialect != DialectMySQL || modifier.ExcludesDialect(p.dialect) {
+				continue
+			}
+			if modifier.RawValue == "" {
+				loc.keys = []string{modifier.Name}
+				p.report(loc, modifier.Name+" value cannot be blank")
+				continue
+			}
+			switch modifier.Name {
+			case "engine":
+				table.Engine = modifier.RawValue
+			case "charset":
+				table.CharacterSet = strings.ToLower(modifier.RawValue)
+			case "collate":
+				table.CollationName = strings.ToLower(modifier.RawValue)
+				if table.CharacterSet == "" {
+					table.CharacterSet = collationCharacterSet(table.CollationName)
+				}
+			case "rowformat":
+				table.RowFormat = strings.ToUpper(modifier.RawValue)
+			}
+		case "auto_increment":
+			if p.dialect != DialectMySQL || modifier.ExcludesDialect(p.dialect) {
+				continue
+			}
+			autoIncrement, err := strconv.ParseInt(modifier.RawValue, 10, 64)
+			if err != nil || autoIncrement <= 0 {
+				loc.keys = []string{modifier.Name}
+				p.report(loc, "auto_increment value "+strconv.Quote(modifier.RawValue)+" is not a positive integer")
+				continue
+			}
+			table.AutoIncrement = autoIncrement
 		default:
 			p.report(loc, "unknown modifier "+strconv.Quote(modifier.Name))
 		}
@@ -864,6 +1982,7 @@ type location struct {
 	pos        token.Pos
 	structName string
 	fieldName  string
+	mixinName  string // Set if the field was declared in a mixin.
 	keys       []string
 }
 
@@ -958,9 +2077,13 @@ var Analyzer = &analysis.Analyzer{
 	Requires: []*analysis.Analyzer{inspect.Analyzer},
 	Run: func(pass *analysis.Pass) (any, error) {
 		inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
-		nodeFilter := []ast.Node{(*ast.TypeSpec)(nil)}
+		nodeFilter := []ast.Node{(*ast.GenDecl)(nil), (*ast.TypeSpec)(nil), (*ast.ValueSpec)(nil), (*ast.FuncDecl)(nil)}
 		p := NewStructParser(pass.Fset)
-		inspect.Preorder(nodeFilter, p.VisitStruct)
+		p.typesInfo = pass.TypesInfo
+		inspect.Preorder(nodeFilter, func(node ast.Node) {
+			p.VisitStruct(node)
+			p.VisitEnum(node)
+		})
 		var catalog Catalog
 		_ = p.WriteCatalog(&catalog)
 		positions, msgs := p.Diagnostics()
@@ -1,9 +1,16 @@
 package ddl
 
 import (
+	"database/sql"
 	"encoding/json"
+	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/blink-io/sqddl/internal/testutil"
 )
@@ -37,3 +44,1587 @@ func TestStructParser(t *testing.T) {
 		t.Error(testutil.Callers(), diff)
 	}
 }
+
+func TestStructParser_Enum(t *testing.T) {
+	dirFS := fstest.MapFS{
+		"tables.go": &fstest.MapFile{Data: []byte(`package tables
+
+type Rating string
+
+const (
+	RatingG  Rating = "G"
+	RatingPG Rating = "PG"
+)
+
+var RatingValues = []string{string(RatingG), string(RatingPG), "R"}
+
+func (r Rating) Enumerate() []string { return RatingValues }
+
+type EnumFilmMood string
+
+func (e *EnumFilmMood) Enumerate() []string {
+	return []string{"sad", "happy"}
+}
+
+type FILM struct {
+	sq.TableStruct
+	FILM_ID sq.NumberField
+	RATING  sq.EnumField ` + "`ddl:\"type=mpaa_rating enum=Rating\"`" + `
+	MOOD    sq.EnumField ` + "`ddl:\"type=extra.film_mood\"`" + `
+	STATUS  sq.EnumField
+}
+`)},
+		"bad_tables.go": &fstest.MapFile{Data: []byte(`package tables
+
+type ACTOR struct {
+	sq.TableStruct
+	ACTOR_ID sq.NumberField
+	STATUS   sq.EnumField ` + "`ddl:\"type=actor_status enum=ActorStatus\"`" + `
+}
+`)},
+	}
+	newCatalog := func(t *testing.T, filename, dialect string) (*Catalog, error) {
+		file, err := dirFS.Open(filename)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		defer file.Close()
+		p := NewStructParser(nil)
+		err = p.ParseFile(file)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		catalog := &Catalog{Dialect: dialect, CurrentSchema: "public"}
+		if dialect != DialectPostgres {
+			catalog.CurrentSchema = ""
+		}
+		return catalog, p.WriteCatalog(catalog)
+	}
+
+	t.Run("postgres", func(t *testing.T) {
+		t.Parallel()
+		catalog, err := newCatalog(t, "tables.go", DialectPostgres)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		cache := NewCatalogCache(catalog)
+		gotEnums := make(map[string][]string)
+		for _, schema := range catalog.Schemas {
+			for _, enum := range schema.Enums {
+				gotEnums[enum.EnumSchema+"."+enum.EnumName] = enum.EnumLabels
+			}
+		}
+		wantEnums := map[string][]string{
+			"public.mpaa_rating": {"G", "PG", "R"},
+			"extra.film_mood":    {"sad", "happy"},
+		}
+		if diff := testutil.Diff(gotEnums, wantEnums); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		table := cache.GetTable(cache.GetSchema(catalog, "public"), "film")
+		for _, columnName := range []string{"rating", "mood", "status"} {
+			if column := cache.GetColumn(table, columnName); column == nil || !column.IsEnum {
+				t.Errorf(testutil.Callers()+" column %s is not an enum column", columnName)
+			}
+		}
+	})
+
+	t.Run("sqlite", func(t *testing.T) {
+		t.Parallel()
+		catalog, err := newCatalog(t, "tables.go", DialectSQLite)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		for _, schema := range catalog.Schemas {
+			if len(schema.Enums) > 0 {
+				t.Errorf(testutil.Callers()+" expected no enums, got %v", schema.Enums)
+			}
+		}
+	})
+
+	t.Run("unknown enum", func(t *testing.T) {
+		t.Parallel()
+		_, err := newCatalog(t, "bad_tables.go", DialectPostgres)
+		if err == nil || !strings.Contains(err.Error(), "could not find the labels of enum ActorStatus") {
+			t.Errorf(testutil.Callers()+" expected an unknown enum error, got %v", err)
+		}
+	})
+}
+
+func TestStructParser_Check(t *testing.T) {
+	dirFS := fstest.MapFS{
+		"tables.go": &fstest.MapFile{Data: []byte(`package tables
+
+type FILM struct {
+	sq.TableStruct
+	FILM_ID     sq.NumberField
+	LENGTH      sq.NumberField ` + "`ddl:\"check={length > 0} check={length < 1000}\"`" + `
+	RENTAL_RATE sq.NumberField ` + "`ddl:\"type=NUMERIC(4,2) check=rental_rate>=0 mysql:check={rental_rate < 100}\"`" + `
+	_           struct{}       ` + "`ddl:\"check={film_length_rental_rate_check length > rental_rate}\"`" + `
+}
+`)},
+		"bad_tables.go": &fstest.MapFile{Data: []byte(`package tables
+
+type ACTOR struct {
+	sq.TableStruct
+	ACTOR_ID sq.NumberField
+	_        struct{} ` + "`ddl:\"check=actor_id>0\"`" + `
+}
+`)},
+	}
+	newCatalog := func(t *testing.T, file fs.File) (*Catalog, error) {
+		defer file.Close()
+		p := NewStructParser(nil)
+		err := p.ParseFile(file)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		catalog := &Catalog{Dialect: DialectPostgres, CurrentSchema: "public"}
+		return catalog, p.WriteCatalog(catalog)
+	}
+	getChecks := func(catalog *Catalog) map[string]Constraint {
+		checks := make(map[string]Constraint)
+		for _, schema := range catalog.Schemas {
+			for _, table := range schema.Tables {
+				for _, constraint := range table.Constraints {
+					if constraint.ConstraintType == CHECK && !constraint.Ignore {
+						checks[constraint.ConstraintName] = constraint
+					}
+				}
+			}
+		}
+		return checks
+	}
+	wantChecks := map[string]Constraint{
+		"film_length_check": {
+			TableSchema: "public", TableName: "film", ConstraintName: "film_length_check",
+			ConstraintType: CHECK, Columns: []string{"length"}, CheckExpr: "length > 0",
+		},
+		"film_length_check1": {
+			TableSchema: "public", TableName: "film", ConstraintName: "film_length_check1",
+			ConstraintType: CHECK, Columns: []string{"length"}, CheckExpr: "length < 1000",
+		},
+		"film_rental_rate_check": {
+			TableSchema: "public", TableName: "film", ConstraintName: "film_rental_rate_check",
+			ConstraintType: CHECK, Columns: []string{"rental_rate"}, CheckExpr: "rental_rate>=0",
+		},
+		"film_length_rental_rate_check": {
+			TableSchema: "public", TableName: "film", ConstraintName: "film_length_rental_rate_check",
+			ConstraintType: CHECK, CheckExpr: "length > rental_rate",
+		},
+	}
+
+	t.Run("postgres", func(t *testing.T) {
+		t.Parallel()
+		file, err := dirFS.Open("tables.go")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		catalog, err := newCatalog(t, file)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(getChecks(catalog), wantChecks); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		file, err := dirFS.Open("tables.go")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		catalog, err := newCatalog(t, file)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		var tableStructs TableStructs
+		err = tableStructs.ReadCatalog(catalog)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		text, err := tableStructs.MarshalText()
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		source := append([]byte("package tables\n\n"), text...)
+		file, err = fstest.MapFS{"tables.go": &fstest.MapFile{Data: source}}.Open("tables.go")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		catalog, err = newCatalog(t, file)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		// Only check constraints named the same way as column-level checks
+		// are written back as column-level checks, so compare the check
+		// expressions instead.
+		gotCheckExprs, wantCheckExprs := make(map[string]string), make(map[string]string)
+		for name, constraint := range getChecks(catalog) {
+			gotCheckExprs[name] = constraint.CheckExpr
+		}
+		for name, constraint := range wantChecks {
+			wantCheckExprs[name] = constraint.CheckExpr
+		}
+		if diff := testutil.Diff(gotCheckExprs, wantCheckExprs); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("unnamed table check", func(t *testing.T) {
+		t.Parallel()
+		file, err := dirFS.Open("bad_tables.go")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		_, err = newCatalog(t, file)
+		if err == nil || !strings.Contains(err.Error(), "check modifier must be of the form check={name expr}") {
+			t.Errorf(testutil.Callers()+" expected an unnamed check error, got %v", err)
+		}
+	})
+}
+
+func TestStructParser_Exclude(t *testing.T) {
+	dirFS := fstest.MapFS{
+		"tables.go": &fstest.MapFile{Data: []byte(`package tables
+
+type BOOKING struct {
+	sq.TableStruct ` + "`ddl:\"exclude={using=gist room_id:= during:&& where={NOT is_cancelled}} mysql:exclude=room_id:=\"`" + `
+	BOOKING_ID     sq.NumberField
+	ROOM_ID        sq.NumberField
+	DURING         sq.AnyField ` + "`ddl:\"type=TSRANGE\"`" + `
+	IS_CANCELLED   sq.BooleanField
+	_              struct{} ` + "`ddl:\"exclude={using=gist room_id:= {tsrange(lower(during), upper(during))}:&& name=booking_hold_excl deferred}\"`" + `
+}
+`)},
+		"bad_tables.go": &fstest.MapFile{Data: []byte(`package tables
+
+type BOOKING struct {
+	sq.TableStruct ` + "`ddl:\"exclude={using=gist} exclude={room_id:= colour=red}\"`" + `
+	BOOKING_ID     sq.NumberField
+	ROOM_ID        sq.NumberField
+}
+`)},
+	}
+	newCatalog := func(t *testing.T, dialect string, file fs.File) (*Catalog, error) {
+		defer file.Close()
+		p := NewStructParser(nil)
+		err := p.ParseFile(file)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		catalog := &Catalog{Dialect: dialect, CurrentSchema: "public"}
+		return catalog, p.WriteCatalog(catalog)
+	}
+	getExclusions := func(catalog *Catalog) map[string]Constraint {
+		exclusions := make(map[string]Constraint)
+		for _, schema := range catalog.Schemas {
+			for _, table := range schema.Tables {
+				for _, constraint := range table.Constraints {
+					if constraint.ConstraintType == EXCLUDE && !constraint.Ignore {
+						exclusions[constraint.ConstraintName] = constraint
+					}
+				}
+			}
+		}
+		return exclusions
+	}
+	wantExclusions := map[string]Constraint{
+		"booking_room_id_during_excl": {
+			TableSchema: "public", TableName: "booking", ConstraintName: "booking_room_id_during_excl",
+			ConstraintType: EXCLUDE, Columns: []string{"room_id", "during"},
+			ExclusionOperators: []string{"=", "&&"}, ExclusionIndexType: "gist",
+			ExclusionPredicate: "NOT is_cancelled",
+		},
+		"booking_hold_excl": {
+			TableSchema: "public", TableName: "booking", ConstraintName: "booking_hold_excl",
+			ConstraintType: EXCLUDE, Columns: []string{"room_id", "tsrange(lower(during), upper(during))"},
+			ExclusionOperators: []string{"=", "&&"}, ExclusionIndexType: "gist",
+			IsDeferrable: true, IsInitiallyDeferred: true,
+		},
+	}
+
+	t.Run("postgres", func(t *testing.T) {
+		t.Parallel()
+		file, err := dirFS.Open("tables.go")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		catalog, err := newCatalog(t, DialectPostgres, file)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(getExclusions(catalog), wantExclusions); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("other dialects", func(t *testing.T) {
+		t.Parallel()
+		for _, dialect := range []string{DialectSQLite, DialectMySQL, DialectSQLServer} {
+			file, err := dirFS.Open("tables.go")
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			catalog, err := newCatalog(t, dialect, file)
+			if err != nil {
+				t.Fatal(testutil.Callers(), dialect, err)
+			}
+			if exclusions := getExclusions(catalog); len(exclusions) > 0 {
+				t.Errorf(testutil.Callers()+" %s: expected no exclusion constraints, got %v", dialect, exclusions)
+			}
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		file, err := dirFS.Open("tables.go")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		catalog, err := newCatalog(t, DialectPostgres, file)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		var tableStructs TableStructs
+		err = tableStructs.ReadCatalog(catalog)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		text, err := tableStructs.MarshalText()
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		source := append([]byte("package tables\n\n"), text...)
+		file, err = fstest.MapFS{"tables.go": &fstest.MapFile{Data: source}}.Open("tables.go")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		catalog, err = newCatalog(t, DialectPostgres, file)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err, "\n"+string(source))
+		}
+		if diff := testutil.Diff(getExclusions(catalog), wantExclusions); diff != "" {
+			t.Error(testutil.Callers(), diff, "\n"+string(source))
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		file, err := dirFS.Open("bad_tables.go")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		_, err = newCatalog(t, DialectPostgres, file)
+		if err == nil {
+			t.Fatal(testutil.Callers(), "expected error, got nil")
+		}
+		for _, want := range []string{
+			"no exclusion elements provided",
+			`unknown modifier "colour"`,
+		} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf(testutil.Callers()+" expected %q in error, got %v", want, err)
+			}
+		}
+	})
+}
+
+func TestStructParser_Sequence(t *testing.T) {
+	t.Parallel()
+	newCatalog := func(t *testing.T, source string) (*Catalog, error) {
+		file, err := fstest.MapFS{"tables.go": &fstest.MapFile{Data: []byte(source)}}.Open("tables.go")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		defer file.Close()
+		p := NewStructParser(nil)
+		err = p.ParseFile(file)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		catalog := &Catalog{Dialect: DialectPostgres, CurrentSchema: "public"}
+		return catalog, p.WriteCatalog(catalog)
+	}
+
+	t.Run("basic", func(t *testing.T) {
+		t.Parallel()
+		catalog, err := newCatalog(t, `package tables
+
+type INVOICE struct {
+	sq.TableStruct `+"`ddl:\"sequence={invoice_seq type=INT increment=10 ownedby=invoice_number} sequence={billing.countdown_seq increment=-1 cycle}\"`"+`
+	INVOICE_NUMBER sq.NumberField
+}
+`)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		var gotSequences []Sequence
+		for _, schema := range catalog.Schemas {
+			if !schema.SequencesValid {
+				t.Errorf(testutil.Callers()+" schema %q: SequencesValid not set", schema.SchemaName)
+			}
+			gotSequences = append(gotSequences, schema.Sequences...)
+		}
+		wantSequences := []Sequence{{
+			SequenceSchema: "public", SequenceName: "invoice_seq", DataType: "integer",
+			StartValue: 1, Increment: 10, MinValue: 1, MaxValue: 2147483647,
+			OwnedByTable: "invoice", OwnedByColumn: "invoice_number",
+		}, {
+			SequenceSchema: "billing", SequenceName: "countdown_seq", DataType: "bigint",
+			StartValue: -1, Increment: -1, MinValue: -9223372036854775808, MaxValue: -1, IsCycle: true,
+		}}
+		if diff := testutil.Diff(gotSequences, wantSequences); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := newCatalog(t, `package tables
+
+type INVOICE struct {
+	sq.TableStruct `+"`ddl:\"sequence={invoice_seq increment=0} sequence={other_seq start=one colour=red} sequence={invoice_seq increment=2}\"`"+`
+	INVOICE_NUMBER sq.NumberField
+}
+`)
+		if err == nil {
+			t.Fatal(testutil.Callers(), "expected error, got nil")
+		}
+		for _, want := range []string{
+			"sequence increment cannot be zero",
+			`sequence start "one" is not an integer`,
+			`unknown sequence submodifier "colour"`,
+			"sequence invoice_seq is declared more than once with different definitions",
+		} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf(testutil.Callers()+" expected %q in error, got %v", want, err)
+			}
+		}
+	})
+}
+
+func TestStructParser_PartitionBy(t *testing.T) {
+	t.Parallel()
+	newCatalog := func(t *testing.T, source string) (*Catalog, error) {
+		file, err := fstest.MapFS{"tables.go": &fstest.MapFile{Data: []byte(source)}}.Open("tables.go")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		defer file.Close()
+		p := NewStructParser(nil)
+		err = p.ParseFile(file)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		catalog := &Catalog{Dialect: DialectPostgres, CurrentSchema: "public"}
+		return catalog, p.WriteCatalog(catalog)
+	}
+
+	t.Run("basic", func(t *testing.T) {
+		t.Parallel()
+		catalog, err := newCatalog(t, `package tables
+
+type MEASUREMENT struct {
+	sq.TableStruct `+"`ddl:\"partitionby=range(created_at) primarykey=measurement_id,created_at\"`"+`
+	MEASUREMENT_ID sq.NumberField
+	CREATED_AT     sq.TimeField
+}
+`)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		table := catalog.Schemas[0].Tables[0]
+		if diff := testutil.Diff(table.PartitionBy, "RANGE (created_at)"); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		gotSQL, err := migrationSQL(&Catalog{Dialect: DialectPostgres, CurrentSchema: "public"}, catalog)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if !strings.Contains(gotSQL, "\n) PARTITION BY RANGE (created_at);\n") {
+			t.Errorf(testutil.Callers()+" PARTITION BY clause not found in\n%s", gotSQL)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := newCatalog(t, `package tables
+
+type MEASUREMENT struct {
+	sq.TableStruct `+"`ddl:\"partitionby=created_at\"`"+`
+	CREATED_AT sq.TimeField
+}
+
+type EVENT struct {
+	sq.TableStruct `+"`ddl:\"partitionby=interval(created_at)\"`"+`
+	CREATED_AT sq.TimeField
+}
+`)
+		if err == nil {
+			t.Fatal(testutil.Callers(), "expected error, got nil")
+		}
+		for _, want := range []string{
+			`invalid partition key "created_at"`,
+			`invalid partition strategy "INTERVAL"`,
+		} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf(testutil.Callers()+" expected %q in error, got %v", want, err)
+			}
+		}
+	})
+}
+
+func TestStructParser_TableOptions(t *testing.T) {
+	t.Parallel()
+	newCatalog := func(t *testing.T, source string) (*Catalog, error) {
+		file, err := fstest.MapFS{"tables.go": &fstest.MapFile{Data: []byte(source)}}.Open("tables.go")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		defer file.Close()
+		p := NewStructParser(nil)
+		err = p.ParseFile(file)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		catalog := &Catalog{Dialect: DialectMySQL, CurrentSchema: "sakila", DefaultCollation: "utf8mb4_0900_ai_ci"}
+		return catalog, p.WriteCatalog(catalog)
+	}
+
+	t.Run("basic", func(t *testing.T) {
+		t.Parallel()
+		catalog, err := newCatalog(t, `package tables
+
+type ACTOR struct {
+	sq.TableStruct `+"`ddl:\"engine=MyISAM collate=latin1_swedish_ci rowformat=compressed auto_increment=1000\"`"+`
+	ACTOR_ID sq.NumberField
+}
+`)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		table := catalog.Schemas[0].Tables[0]
+		got := [4]string{table.Engine, table.CharacterSet, table.CollationName, table.RowFormat}
+		want := [4]string{"MyISAM", "latin1", "latin1_swedish_ci", "COMPRESSED"}
+		if diff := testutil.Diff(got, want); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		if diff := testutil.Diff(table.AutoIncrement, int64(1000)); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		// The table options (except AUTO_INCREMENT) survive a round trip
+		// through the table structs.
+		tableStructs := &TableStructs{}
+		err = tableStructs.ReadCatalog(catalog)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		var gotModifiers []string
+		for _, modifier := range tableStructs.Tables[0].Fields[0].Modifiers {
+			gotModifiers = append(gotModifiers, modifier.Name+"="+modifier.RawValue)
+		}
+		wantModifiers := []string{"engine=MyISAM", "charset=latin1", "collate=latin1_swedish_ci", "rowformat=COMPRESSED"}
+		if diff := testutil.Diff(gotModifiers, wantModifiers); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := newCatalog(t, `package tables
+
+type ACTOR struct {
+	sq.TableStruct `+"`ddl:\"engine charset= auto_increment=one\"`"+`
+	ACTOR_ID sq.NumberField
+}
+`)
+		if err == nil {
+			t.Fatal(testutil.Callers(), "expected error, got nil")
+		}
+		for _, want := range []string{
+			"engine value cannot be blank",
+			"charset value cannot be blank",
+			`auto_increment value "one" is not a positive integer`,
+		} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf(testutil.Callers()+" expected %q in error, got %v", want, err)
+			}
+		}
+	})
+}
+
+func TestStructParser_SQLiteTableOptions(t *testing.T) {
+	newCatalog := func(t *testing.T, source string) (*Catalog, error) {
+		file, err := fstest.MapFS{"tables.go": &fstest.MapFile{Data: []byte(source)}}.Open("tables.go")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		defer file.Close()
+		p := NewStructParser(nil)
+		err = p.ParseFile(file)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		catalog := &Catalog{Dialect: DialectSQLite}
+		err = p.WriteCatalog(catalog)
+		return catalog, err
+	}
+
+	t.Run("basic", func(t *testing.T) {
+		t.Parallel()
+		catalog, err := newCatalog(t, `package tables
+
+type ACTOR struct {
+	sq.TableStruct `+"`ddl:\"strict withoutrowid\"`"+`
+	ACTOR_ID    sq.NumberField `+"`ddl:\"primarykey\"`"+`
+	FIRST_NAME  sq.StringField
+	LAST_UPDATE sq.TimeField   `+"`ddl:\"type=TEXT\"`"+`
+	METADATA    sq.JSONField   `+"`ddl:\"type=ANY\"`"+`
+}
+`)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		table := catalog.Schemas[0].Tables[0]
+		if diff := testutil.Diff([2]bool{table.IsStrict, table.IsWithoutRowid}, [2]bool{true, true}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		var gotTypes []string
+		for _, column := range table.Columns {
+			gotTypes = append(gotTypes, column.ColumnType)
+		}
+		wantTypes := []string{"INTEGER", "TEXT", "TEXT", "ANY"}
+		if diff := testutil.Diff(gotTypes, wantTypes); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		tableStructs := &TableStructs{}
+		err = tableStructs.ReadCatalog(catalog)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		var gotModifiers []string
+		for _, modifier := range tableStructs.Tables[0].Fields[0].Modifiers {
+			gotModifiers = append(gotModifiers, modifier.Name)
+		}
+		if diff := testutil.Diff(gotModifiers, []string{"strict", "withoutrowid"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := newCatalog(t, `package tables
+
+type ACTOR struct {
+	sq.TableStruct `+"`ddl:\"strict\"`"+`
+	ACTOR_ID    sq.NumberField `+"`ddl:\"primarykey\"`"+`
+	LAST_UPDATE sq.TimeField
+	IS_ACTIVE   sq.BooleanField
+	METADATA    sq.JSONField   `+"`ddl:\"type=JSON\"`"+`
+}
+
+type TAG struct {
+	sq.TableStruct `+"`ddl:\"withoutrowid\"`"+`
+	NAME           sq.StringField
+}
+
+type FILM struct {
+	sq.TableStruct `+"`ddl:\"withoutrowid\"`"+`
+	FILM_ID        sq.NumberField `+"`ddl:\"primarykey autoincrement\"`"+`
+}
+`)
+		if err == nil {
+			t.Fatal(testutil.Callers(), "expected error, got nil")
+		}
+		for _, want := range []string{
+			"column type DATETIME is not allowed in a STRICT table",
+			"column type BOOLEAN is not allowed in a STRICT table",
+			"column type JSON is not allowed in a STRICT table",
+			"a WITHOUT ROWID table must have a PRIMARY KEY",
+			"film_id: AUTOINCREMENT is not allowed in a WITHOUT ROWID table",
+		} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf(testutil.Callers()+" expected %q in error, got %v", want, err)
+			}
+		}
+	})
+}
+
+func TestStructParser_Comment(t *testing.T) {
+	source := []byte(`package tables
+
+// FILM lists every film in the store.
+type FILM struct {
+	sq.TableStruct
+	FILM_ID sq.NumberField
+	// The title of the film.
+	//
+	// Titles are not unique.
+	TITLE  sq.StringField
+	LENGTH sq.NumberField ` + "`ddl:\"comment={Length in minutes}\"`" + `
+	// Overridden by the comment modifier.
+	RATING sq.StringField ` + "`ddl:\"comment={MPAA film rating}\"`" + `
+}
+
+type (
+	// ACTOR lists the actors.
+	ACTOR struct {
+		sq.TableStruct ` + "`ddl:\"comment={Actors appearing in films}\"`" + `
+		ACTOR_ID sq.NumberField
+	}
+)
+`)
+	newCatalog := func(t *testing.T, source []byte) *Catalog {
+		file, err := fstest.MapFS{"tables.go": &fstest.MapFile{Data: source}}.Open("tables.go")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		defer file.Close()
+		p := NewStructParser(nil)
+		err = p.ParseFile(file)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		catalog := &Catalog{Dialect: DialectPostgres, CurrentSchema: "public"}
+		err = p.WriteCatalog(catalog)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		return catalog
+	}
+	getComments := func(catalog *Catalog) map[string]string {
+		comments := make(map[string]string)
+		for _, schema := range catalog.Schemas {
+			for _, table := range schema.Tables {
+				if table.Comment != "" {
+					comments[table.TableName] = table.Comment
+				}
+				for _, column := range table.Columns {
+					if column.Comment != "" {
+						comments[table.TableName+"."+column.ColumnName] = column.Comment
+					}
+				}
+			}
+		}
+		return comments
+	}
+	wantComments := map[string]string{
+		"film":        "FILM lists every film in the store.",
+		"film.title":  "The title of the film.\n\nTitles are not unique.",
+		"film.length": "Length in minutes",
+		"film.rating": "MPAA film rating",
+		"actor":       "Actors appearing in films",
+	}
+
+	t.Run("postgres", func(t *testing.T) {
+		t.Parallel()
+		catalog := newCatalog(t, source)
+		if diff := testutil.Diff(getComments(catalog), wantComments); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		catalog := newCatalog(t, source)
+		var tableStructs TableStructs
+		err := tableStructs.ReadCatalog(catalog)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		text, err := tableStructs.MarshalText()
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		catalog = newCatalog(t, append([]byte("package tables\n\n"), text...))
+		if diff := testutil.Diff(getComments(catalog), wantComments); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+}
+
+func TestStructParser_Index(t *testing.T) {
+	source := []byte(`package tables
+
+type USERS struct {
+	sq.TableStruct
+	USER_ID    sq.NumberField
+	EMAIL      sq.StringField ` + "`ddl:\"index={. unique where={deleted_at IS NULL}}\"`" + `
+	NAME       sq.StringField
+	CREATED_AT sq.TimeField ` + "`ddl:\"index={. desc}\"`" + `
+	DELETED_AT sq.TimeField
+	_          struct{} ` + "`ddl:\"index={email,created_at desc=created_at include=name where={deleted_at IS NULL} name=users_active_email_idx opclass=text_pattern_ops}\"`" + `
+}
+`)
+	newCatalog := func(t *testing.T, source []byte) *Catalog {
+		file, err := fstest.MapFS{"tables.go": &fstest.MapFile{Data: source}}.Open("tables.go")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		defer file.Close()
+		p := NewStructParser(nil)
+		err = p.ParseFile(file)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		catalog := &Catalog{Dialect: DialectPostgres, CurrentSchema: "public"}
+		err = p.WriteCatalog(catalog)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		return catalog
+	}
+	wantIndexes := []Index{{
+		TableSchema: "public", TableName: "users", IndexName: "users_email_idx",
+		IsUnique: true, Columns: []string{"email"}, Predicate: "deleted_at IS NULL",
+	}, {
+		TableSchema: "public", TableName: "users", IndexName: "users_created_at_idx",
+		Columns: []string{"created_at"}, Descending: []bool{true},
+	}, {
+		TableSchema: "public", TableName: "users", IndexName: "users_active_email_idx",
+		Columns: []string{"email", "created_at"}, IncludeColumns: []string{"name"},
+		Descending: []bool{false, true}, Opclasses: []string{"text_pattern_ops", ""},
+		Predicate: "deleted_at IS NULL",
+	}}
+
+	t.Run("postgres", func(t *testing.T) {
+		t.Parallel()
+		catalog := newCatalog(t, source)
+		if diff := testutil.Diff(catalog.Schemas[0].Tables[0].Indexes, wantIndexes); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		catalog := newCatalog(t, source)
+		var tableStructs TableStructs
+		err := tableStructs.ReadCatalog(catalog)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		text, err := tableStructs.MarshalText()
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		catalog = newCatalog(t, append([]byte("package tables\n\n"), text...))
+		indexes := catalog.Schemas[0].Tables[0].Indexes
+		for i := range indexes {
+			if !indexesAreEqual(DialectPostgres, &indexes[i], &wantIndexes[i]) {
+				t.Errorf(testutil.Callers()+" index %d: got %+v, want %+v", i, indexes[i], wantIndexes[i])
+			}
+		}
+		if len(indexes) != len(wantIndexes) {
+			t.Errorf(testutil.Callers()+" got %d indexes, want %d", len(indexes), len(wantIndexes))
+		}
+	})
+
+	t.Run("unknown desc column", func(t *testing.T) {
+		t.Parallel()
+		file, err := fstest.MapFS{"tables.go": &fstest.MapFile{Data: []byte(`package tables
+
+type USERS struct {
+	sq.TableStruct
+	EMAIL sq.StringField ` + "`ddl:\"index={. desc=name}\"`" + `
+}
+`)}}.Open("tables.go")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		defer file.Close()
+		p := NewStructParser(nil)
+		err = p.ParseFile(file)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		err = p.WriteCatalog(&Catalog{Dialect: DialectPostgres})
+		if err == nil || !strings.Contains(err.Error(), `"name" is not an index column`) {
+			t.Errorf(testutil.Callers()+" expected an index column error, got %v", err)
+		}
+	})
+}
+
+func TestStructParser_RenamedFrom(t *testing.T) {
+	file, err := fstest.MapFS{"tables.go": &fstest.MapFile{Data: []byte(`package tables
+
+type CLIENTS struct {
+	sq.TableStruct ` + "`ddl:\"renamedfrom=customers\"`" + `
+	CLIENT_ID sq.NumberField ` + "`ddl:\"primarykey renamedfrom=customer_id\"`" + `
+	NAME      sq.StringField ` + "`ddl:\"sqlite:renamedfrom=full_name\"`" + `
+}
+`)}}.Open("tables.go")
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+	defer file.Close()
+	p := NewStructParser(nil)
+	err = p.ParseFile(file)
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+	catalog := &Catalog{Dialect: DialectPostgres, CurrentSchema: "public"}
+	err = p.WriteCatalog(catalog)
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+	table := catalog.Schemas[0].Tables[0]
+	if diff := testutil.Diff(table.RenamedFrom, "customers"); diff != "" {
+		t.Error(testutil.Callers(), diff)
+	}
+	var renamedFrom []string
+	for _, column := range table.Columns {
+		renamedFrom = append(renamedFrom, column.RenamedFrom)
+	}
+	if diff := testutil.Diff(renamedFrom, []string{"customer_id", ""}); diff != "" {
+		t.Error(testutil.Callers(), diff)
+	}
+
+	// Dialect-specific renamedfrom modifiers are ignored by other dialects.
+	file, err = fstest.MapFS{"tables.go": &fstest.MapFile{Data: []byte(`package tables
+
+type CLIENTS struct {
+	sq.TableStruct ` + "`ddl:\"postgres:renamedfrom=customers\"`" + `
+	CLIENT_ID sq.NumberField ` + "`ddl:\"primarykey postgres:renamedfrom=customer_id\"`" + `
+}
+`)}}.Open("tables.go")
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+	defer file.Close()
+	p = NewStructParser(nil)
+	err = p.ParseFile(file)
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+	catalog = &Catalog{Dialect: DialectMySQL}
+	err = p.WriteCatalog(catalog)
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+	table = catalog.Schemas[0].Tables[0]
+	if diff := testutil.Diff(table.RenamedFrom, ""); diff != "" {
+		t.Error(testutil.Callers(), diff)
+	}
+	if diff := testutil.Diff(table.Columns[0].RenamedFrom, ""); diff != "" {
+		t.Error(testutil.Callers(), diff)
+	}
+}
+
+func TestStructParser_Packages(t *testing.T) {
+	// columnTypes returns the "table.column type" of every column in the
+	// catalog.
+	columnTypes := func(catalog *Catalog) []string {
+		var columns []string
+		for _, schema := range catalog.Schemas {
+			for _, table := range schema.Tables {
+				for _, column := range table.Columns {
+					columns = append(columns, table.TableName+"."+column.ColumnName+" "+column.ColumnType)
+				}
+			}
+		}
+		sort.Strings(columns)
+		return columns
+	}
+	wantColumns := []string{
+		"person.name TEXT",
+		"person.person_id INT",
+		"person.rating mpaa_rating",
+		"pet.owner_id INT",
+		"pet.pet_id INT",
+	}
+
+	t.Run("package", func(t *testing.T) {
+		t.Parallel()
+		catalog := &Catalog{Dialect: DialectPostgres, CurrentSchema: "public"}
+		err := writeCatalog(catalog, dirFS("testdata/struct_parser_packages"), "", "tables")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(columnTypes(catalog), wantColumns); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		// The enum labels are declared in a different file from the struct.
+		var enumLabels []string
+		for _, enum := range catalog.Schemas[0].Enums {
+			enumLabels = append(enumLabels, enum.EnumLabels...)
+		}
+		if diff := testutil.Diff(enumLabels, []string{"G", "PG", "R"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("pattern", func(t *testing.T) {
+		t.Parallel()
+		catalog := &Catalog{Dialect: DialectPostgres, CurrentSchema: "public"}
+		err := writeCatalog(catalog, dirFS("testdata/struct_parser_packages"), "", "./tables/...")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		gotColumns := columnTypes(catalog)
+		wantColumns := append([]string{"audit_log.audit_log_id INT", "audit_log.message TEXT"}, wantColumns...)
+		if diff := testutil.Diff(gotColumns, wantColumns); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("import alias", func(t *testing.T) {
+		t.Parallel()
+		// Without type checking, the sq package is still recognised under an
+		// import alias.
+		file, err := os.Open("testdata/struct_parser_packages/tables/person.go")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		defer file.Close()
+		p := NewStructParser(nil)
+		err = p.ParseFile(file)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		var fieldTypes []string
+		for _, field := range p.TableStructs.Tables[0].Fields {
+			fieldTypes = append(fieldTypes, field.Type)
+		}
+		wantTypes := []string{"sq.TableStruct", "sq.NumberField", "sq.StringField", "sq.EnumField"}
+		if diff := testutil.Diff(fieldTypes, wantTypes); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("position", func(t *testing.T) {
+		t.Parallel()
+		p := NewStructParser(nil)
+		err := p.ParsePackages("testdata/struct_parser_packages", "./invalid")
+		if err == nil {
+			err = p.WriteCatalog(&Catalog{Dialect: DialectPostgres})
+		}
+		if err == nil {
+			t.Fatal(testutil.Callers(), "expected error, got nil")
+		}
+		wantErr := filepath.Join("invalid", "film.go") + ":8:26: FILM.ACTOR_ID: acceptwarning: acceptwarning value cannot be blank"
+		if !strings.HasSuffix(err.Error(), wantErr) {
+			t.Errorf(testutil.Callers()+" expected error ending with %q, got %q", wantErr, err.Error())
+		}
+	})
+
+	t.Run("no packages", func(t *testing.T) {
+		t.Parallel()
+		p := NewStructParser(nil)
+		err := p.ParsePackages("testdata/struct_parser_packages", "./missing")
+		if err == nil {
+			t.Error(testutil.Callers(), "expected error, got nil")
+		}
+	})
+}
+
+func TestStructParser_Mixin(t *testing.T) {
+	dirFS := fstest.MapFS{
+		"tables.go": &fstest.MapFile{Data: []byte(`package tables
+
+type TENANT struct {
+	sq.TableStruct
+	TENANT_ID sq.NumberField ` + "`ddl:\"primarykey\"`" + `
+}
+
+type PERSON struct {
+	sq.TableStruct
+	PERSON_ID sq.NumberField ` + "`ddl:\"primarykey\"`" + `
+	TenantTimestamps
+}
+
+type PET struct {
+	sq.TableStruct
+	PET_ID sq.NumberField ` + "`ddl:\"primarykey\"`" + `
+	Tenant
+	Timestamps
+}
+
+// Mixins may be declared after the table structs that embed them, and may
+// embed other mixins.
+type TenantTimestamps struct {
+	_ struct{} ` + "`ddl:\"index=tenant_id,created_at\"`" + `
+	Tenant
+	Timestamps
+}
+
+type Tenant struct {
+	TENANT_ID sq.NumberField ` + "`ddl:\"notnull references=tenant.tenant_id index\"`" + `
+}
+
+type Timestamps struct {
+	_          struct{}     ` + "`ddl:\"check={deleted_after_created deleted_at IS NULL OR deleted_at >= created_at}\"`" + `
+	CREATED_AT sq.TimeField ` + "`ddl:\"notnull default=CURRENT_TIMESTAMP\"`" + `
+	UPDATED_AT sq.TimeField
+	DELETED_AT sq.TimeField
+}
+`)},
+		"bad_tables.go": &fstest.MapFile{Data: []byte(`package tables
+
+type ACTOR struct {
+	sq.TableStruct
+	ACTOR_ID sq.NumberField
+	Named
+	Loop
+	Timestamps ` + "`ddl:\"index=created_at\"`" + `
+}
+
+type Named struct {
+	NAME sq.StringField ` + "`ddl:\"index={. name=actor_name_idx}\"`" + `
+}
+
+type Loop struct {
+	Loop
+}
+
+type Timestamps struct {
+	CREATED_AT sq.TimeField
+}
+`)},
+	}
+	newCatalog := func(t *testing.T, filename string) (*Catalog, error) {
+		file, err := dirFS.Open(filename)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		defer file.Close()
+		p := NewStructParser(nil)
+		err = p.ParseFile(file)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		catalog := &Catalog{Dialect: DialectPostgres, CurrentSchema: "public"}
+		return catalog, p.WriteCatalog(catalog)
+	}
+
+	t.Run("flattened", func(t *testing.T) {
+		t.Parallel()
+		catalog, err := newCatalog(t, "tables.go")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		var gotTables []string
+		gotObjects := make(map[string][]string)
+		for _, table := range catalog.Schemas[0].Tables {
+			gotTables = append(gotTables, table.TableName)
+			for _, column := range table.Columns {
+				gotObjects[table.TableName] = append(gotObjects[table.TableName], column.ColumnName)
+			}
+			for _, constraint := range table.Constraints {
+				gotObjects[table.TableName] = append(gotObjects[table.TableName], constraint.ConstraintName)
+			}
+			for _, index := range table.Indexes {
+				gotObjects[table.TableName] = append(gotObjects[table.TableName], index.IndexName)
+			}
+		}
+		// Mixins are not tables.
+		if diff := testutil.Diff(gotTables, []string{"tenant", "person", "pet"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		wantObjects := map[string][]string{
+			"tenant": {"tenant_id", "tenant_tenant_id_pkey"},
+			"person": {
+				"person_id", "tenant_id", "created_at", "updated_at", "deleted_at",
+				"person_person_id_pkey", "person_tenant_id_fkey", "person_deleted_after_created_check",
+				"person_tenant_id_created_at_idx", "person_tenant_id_idx",
+			},
+			"pet": {
+				"pet_id", "tenant_id", "created_at", "updated_at", "deleted_at",
+				"pet_pet_id_pkey", "pet_tenant_id_fkey", "pet_deleted_after_created_check",
+				"pet_tenant_id_idx",
+			},
+		}
+		if diff := testutil.Diff(gotObjects, wantObjects); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		cache := NewCatalogCache(catalog)
+		column := cache.GetColumn(cache.GetTable(&catalog.Schemas[0], "pet"), "created_at")
+		if !column.IsNotNull || column.ColumnDefault != "CURRENT_TIMESTAMP" {
+			t.Errorf(testutil.Callers()+" pet.created_at: expected NOT NULL DEFAULT CURRENT_TIMESTAMP, got %+v", column)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := newCatalog(t, "bad_tables.go")
+		if err == nil {
+			t.Fatal(testutil.Callers(), "expected error, got nil")
+		}
+		for _, wantErr := range []string{
+			"ACTOR.NAME: index: index name cannot be set in mixin Named (it is generated for each table)",
+			"ACTOR.Loop: mixin Loop embeds itself",
+			"ACTOR.Timestamps: modifiers are not allowed on an embedded mixin (put them inside the mixin instead)",
+		} {
+			if !strings.Contains(err.Error(), wantErr) {
+				t.Errorf(testutil.Callers()+" expected error %q, got %q", wantErr, err.Error())
+			}
+		}
+	})
+
+	t.Run("packages", func(t *testing.T) {
+		t.Parallel()
+		// billing.Base and common.Base share a name but are different mixins.
+		p := NewStructParser(nil)
+		err := p.ParsePackages("testdata/struct_parser_packages", "./mixins/...")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		catalog := &Catalog{Dialect: DialectPostgres, CurrentSchema: "public"}
+		err = p.WriteCatalog(catalog)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		var gotColumns []string
+		for _, table := range catalog.Schemas[0].Tables {
+			for _, column := range table.Columns {
+				gotColumns = append(gotColumns, table.TableName+"."+column.ColumnName)
+			}
+		}
+		sort.Strings(gotColumns)
+		wantColumns := []string{
+			"customer.customer_id",
+			"customer.tenant_id",
+			"invoice.currency",
+			"invoice.invoice_id",
+		}
+		if diff := testutil.Diff(gotColumns, wantColumns); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("ambiguous", func(t *testing.T) {
+		t.Parallel()
+		// Without type checking, an unqualified mixin name is resolved to
+		// the mixin in the same package but a qualified one cannot be told
+		// apart from mixins with the same name in other packages.
+		p := NewStructParser(nil)
+		for _, filename := range []string{
+			"testdata/struct_parser_packages/mixins/common/common.go",
+			"testdata/struct_parser_packages/mixins/billing/billing.go",
+			"testdata/struct_parser_packages/mixins/crm/crm.go",
+		} {
+			file, err := os.Open(filename)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			err = p.ParseFile(file)
+			file.Close()
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+		}
+		err := p.WriteCatalog(&Catalog{Dialect: DialectPostgres, CurrentSchema: "public"})
+		if err == nil {
+			t.Fatal(testutil.Callers(), "expected error, got nil")
+		}
+		wantErr := "CUSTOMER.Base: mixin Base is ambiguous, it is declared in more than one package"
+		if !strings.Contains(err.Error(), wantErr) {
+			t.Errorf(testutil.Callers()+" expected error %q, got %q", wantErr, err.Error())
+		}
+		if strings.Contains(err.Error(), "INVOICE") {
+			t.Errorf(testutil.Callers()+" unexpected error for INVOICE: %q", err.Error())
+		}
+	})
+}
+
+func TestStructParser_View(t *testing.T) {
+	dirFS := fstest.MapFS{
+		"tables.go": &fstest.MapFile{Data: []byte(`package tables
+
+type ACCOUNT struct {
+	sq.TableStruct
+	ACCOUNT_ID sq.NumberField ` + "`ddl:\"primarykey\"`" + `
+	EMAIL      sq.StringField
+	IS_ACTIVE  sq.BooleanField
+}
+
+type ACTIVE_ACCOUNTS struct {
+	sq.ViewStruct ` + "`ddl:\"sqlfile=views/active_accounts.sql\"`" + `
+	ACCOUNT_ID    sq.NumberField
+	EMAIL         sq.StringField
+}
+
+type ACCOUNT_COUNT struct {
+	sq.ViewStruct ` + "`sq:\"reports.account_count\" ddl:\"sql={SELECT COUNT(*) AS n FROM account} materialized\"`" + `
+	N             sq.NumberField
+}
+
+type INACTIVE_ACCOUNTS struct {
+	sq.ViewStruct ` + "`ddl:\"dialect=postgres sql={SELECT * FROM account WHERE NOT is_active}\"`" + `
+}
+`)},
+		"views/active_accounts.sql": &fstest.MapFile{Data: []byte("SELECT account_id, email\nFROM account\nWHERE is_active;\n")},
+		"bad_tables.go": &fstest.MapFile{Data: []byte(`package tables
+
+type V1 struct {
+	sq.ViewStruct
+}
+
+type V2 struct {
+	sq.ViewStruct ` + "`ddl:\"sqlfile=views/missing.sql\"`" + `
+}
+
+type V3 struct {
+	sq.ViewStruct ` + "`ddl:\"sql={SELECT 1} replace\"`" + `
+}
+`)},
+	}
+	newCatalog := func(t *testing.T, dialect, name string) (*Catalog, error) {
+		file, err := dirFS.Open(name)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		defer file.Close()
+		p := NewStructParser(nil)
+		p.FS = dirFS
+		err = p.ParseFile(file)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		catalog := &Catalog{Dialect: dialect}
+		if dialect == DialectPostgres {
+			catalog.CurrentSchema = "public"
+		}
+		return catalog, p.WriteCatalog(catalog)
+	}
+	getViews := func(catalog *Catalog) map[string]View {
+		views := make(map[string]View)
+		for _, schema := range catalog.Schemas {
+			for _, view := range schema.Views {
+				if !view.Ignore {
+					views[view.ViewSchema+"."+view.ViewName] = view
+				}
+			}
+		}
+		return views
+	}
+
+	t.Run("postgres", func(t *testing.T) {
+		t.Parallel()
+		catalog, err := newCatalog(t, DialectPostgres, "tables.go")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		wantViews := map[string]View{
+			"public.active_accounts": {
+				ViewSchema: "public", ViewName: "active_accounts",
+				SQL:     "SELECT account_id, email\nFROM account\nWHERE is_active",
+				Comment: viewSQLHash(DialectPostgres, "SELECT account_id, email FROM account WHERE is_active"),
+			},
+			"reports.account_count": {
+				ViewSchema: "reports", ViewName: "account_count", IsMaterialized: true,
+				SQL:     "SELECT COUNT(*) AS n FROM account",
+				Comment: viewSQLHash(DialectPostgres, "SELECT COUNT(*) AS n FROM account"),
+			},
+			"public.inactive_accounts": {
+				ViewSchema: "public", ViewName: "inactive_accounts",
+				SQL:     "SELECT * FROM account WHERE NOT is_active",
+				Comment: viewSQLHash(DialectPostgres, "SELECT * FROM account WHERE NOT is_active"),
+			},
+		}
+		if diff := testutil.Diff(getViews(catalog), wantViews); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		for _, schema := range catalog.Schemas {
+			if !schema.ViewsValid {
+				t.Errorf(testutil.Callers()+" schema %q: expected ViewsValid", schema.SchemaName)
+			}
+		}
+		// The view structs are not mistaken for tables.
+		var tableNames []string
+		for _, schema := range catalog.Schemas {
+			for _, table := range schema.Tables {
+				tableNames = append(tableNames, table.TableName)
+			}
+		}
+		if diff := testutil.Diff(tableNames, []string{"account"}); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("postgres idempotent", func(t *testing.T) {
+		t.Parallel()
+		generate := func(srcCatalog *Catalog) map[string]string {
+			generateCmd := &GenerateCmd{
+				SrcCatalog: srcCatalog,
+				DirFS:      fstest.MapFS{"tables/tables.go": dirFS["tables.go"], "tables/views/active_accounts.sql": dirFS["views/active_accounts.sql"]},
+				Filenames:  []string{"tables/tables.go"},
+				Dialect:    DialectPostgres,
+				Prefix:     "v",
+				NoDown:     true,
+			}
+			files, _, err := generateCmd.Results()
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			gotFiles := make(map[string]string)
+			for _, file := range files {
+				fileinfo, _ := file.Stat()
+				b, _ := io.ReadAll(file)
+				gotFiles[fileinfo.Name()] = string(b)
+			}
+			return gotFiles
+		}
+		// The views are created along with the hash of their declared query.
+		var output string
+		for _, contents := range generate(&Catalog{Dialect: DialectPostgres, CurrentSchema: "public"}) {
+			output += contents
+		}
+		for _, want := range []string{
+			"COMMENT ON VIEW inactive_accounts IS '" + viewSQLHash(DialectPostgres, "SELECT * FROM account WHERE NOT is_active") + "';",
+			"COMMENT ON MATERIALIZED VIEW reports.account_count IS '" + viewSQLHash(DialectPostgres, "SELECT COUNT(*) AS n FROM account") + "';",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf(testutil.Callers()+" expected %q in output, got %s", want, output)
+			}
+		}
+		// Postgres reports the views back with their queries rewritten by
+		// pg_get_viewdef, but with the comments that were written.
+		srcCatalog, err := newCatalog(t, DialectPostgres, "tables.go")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		viewdefs := map[string]string{
+			"active_accounts":   " SELECT account.account_id,\n    account.email\n   FROM account\n  WHERE account.is_active;",
+			"account_count":     " SELECT count(*) AS n\n   FROM account;",
+			"inactive_accounts": " SELECT account.account_id,\n    account.email,\n    account.is_active\n   FROM account\n  WHERE NOT account.is_active;",
+		}
+		for i := range srcCatalog.Schemas {
+			for j := range srcCatalog.Schemas[i].Views {
+				view := &srcCatalog.Schemas[i].Views[j]
+				view.SQL = viewdefs[view.ViewName]
+			}
+		}
+		if gotFiles := generate(srcCatalog); len(gotFiles) > 0 {
+			t.Errorf(testutil.Callers()+" expected no migrations, got %q", gotFiles)
+		}
+		// A view without the hash (e.g. one created by hand) is recreated
+		// once.
+		for i := range srcCatalog.Schemas {
+			for j := range srcCatalog.Schemas[i].Views {
+				if view := &srcCatalog.Schemas[i].Views[j]; view.ViewName == "inactive_accounts" {
+					view.Comment = ""
+				}
+			}
+		}
+		wantFiles := map[string]string{
+			"v_01_drop_views.sql": "DROP VIEW IF EXISTS inactive_accounts;\n",
+			"v_02_views.sql": "CREATE VIEW inactive_accounts AS SELECT * FROM account WHERE NOT is_active;\n" +
+				"COMMENT ON VIEW inactive_accounts IS '" + viewSQLHash(DialectPostgres, "SELECT * FROM account WHERE NOT is_active") + "';\n",
+		}
+		if diff := testutil.Diff(generate(srcCatalog), wantFiles); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("sqlite", func(t *testing.T) {
+		t.Parallel()
+		catalog, err := newCatalog(t, DialectSQLite, "tables.go")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		wantViews := map[string]View{
+			".active_accounts": {
+				ViewName: "active_accounts",
+				SQL:      "SELECT account_id, email\nFROM account\nWHERE is_active",
+			},
+			"reports.account_count": {
+				ViewSchema: "reports", ViewName: "account_count",
+				SQL: "SELECT COUNT(*) AS n FROM account",
+			},
+		}
+		if diff := testutil.Diff(getViews(catalog), wantViews); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := newCatalog(t, DialectPostgres, "bad_tables.go")
+		if err == nil {
+			t.Fatal(testutil.Callers(), "expected error, got nil")
+		}
+		for _, want := range []string{
+			"view struct V1 has no sql or sqlfile modifier",
+			"views/missing.sql",
+			`unknown modifier "replace"`,
+		} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf(testutil.Callers()+" expected %q in error, got %v", want, err)
+			}
+		}
+	})
+
+	t.Run("automigrate", func(t *testing.T) {
+		t.Parallel()
+		db, err := sql.Open("sqlite3", "file:/"+t.Name()+".db?vfs=memdb&_foreign_keys=true")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		defer db.Close()
+		// SQLite has no reports schema, so keep every view in the main schema.
+		tables := strings.ReplaceAll(string(dirFS["tables.go"].Data), `sq:"reports.account_count" `, "")
+		automigrateCmd := &AutomigrateCmd{
+			DB:      db,
+			Dialect: DialectSQLite,
+			DirFS: fstest.MapFS{
+				"tables/tables.go":                 &fstest.MapFile{Data: []byte(tables)},
+				"tables/views/active_accounts.sql": dirFS["views/active_accounts.sql"],
+			},
+			Filenames: []string{"tables/tables.go"},
+			Stderr:    io.Discard,
+		}
+		err = automigrateCmd.Run()
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		_, err = db.Exec("INSERT INTO account (account_id, email, is_active) VALUES (1, 'a@example.com', TRUE), (2, 'b@example.com', FALSE)")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		var email string
+		err = db.QueryRow("SELECT email FROM active_accounts").Scan(&email)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(email, "a@example.com"); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		var n int
+		err = db.QueryRow("SELECT n FROM account_count").Scan(&n)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if diff := testutil.Diff(n, 2); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		// The views are up to date, so there is nothing left to migrate.
+		generateCmd := &GenerateCmd{
+			SrcCatalog: &Catalog{},
+			DirFS:      automigrateCmd.DirFS,
+			Filenames:  []string{"tables/tables.go"},
+			Dialect:    DialectSQLite,
+		}
+		dbi := NewDatabaseIntrospector(DialectSQLite, db)
+		dbi.ObjectTypes = []string{"TABLES", "VIEWS"}
+		dbi.ExcludeTables = []string{"sqddl_history"}
+		err = dbi.WriteCatalog(generateCmd.SrcCatalog)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		files, _, err := generateCmd.Results()
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		if len(files) > 0 {
+			var names []string
+			for _, file := range files {
+				fileinfo, _ := file.Stat()
+				b, _ := io.ReadAll(file)
+				names = append(names, fileinfo.Name()+":\n"+string(b))
+			}
+			t.Errorf(testutil.Callers()+" expected no migrations, got %q", names)
+		}
+	})
+}
@@ -29,11 +29,19 @@ type TableStruct struct {
 	// Name is the name of the table struct.
 	Name string
 
+	// Comment is the doc comment of the table struct.
+	Comment string
+
 	// Fields are the table struct fields.
 	Fields []StructField
 
 	// PKFields are the table struct fields for primary keys.
 	PKFields []StructField
+
+	// pkgPath is the import path of the package the struct was declared in
+	// (or only its package name, if the package was not type checked). Used
+	// to tell apart mixins with the same name.
+	pkgPath string
 }
 
 // StructField represents a struct field within a table struct.
@@ -56,9 +64,20 @@ type StructField struct {
 	// Modifiers are the parsed modifiers for the "ddl" struct tag.
 	Modifiers []Modifier
 
+	// Comment is the doc comment of the struct field.
+	Comment string
+
 	// tagPos tracks where in the source code the struct tag appeared in. Used
 	// for error reporting.
 	tagPos token.Pos
+
+	// mixinName is the name of the mixin the struct field was declared in, if
+	// any.
+	mixinName string
+
+	// typePkgPath is the import path of the package the struct field's type
+	// was declared in, if known. Used to look up embedded mixins.
+	typePkgPath string
 }
 
 // NewTableStructs introspects a database connection and returns a slice of
@@ -94,8 +113,9 @@ func (s *TableStructs) ReadCatalog(catalog *Catalog) error {
 	for _, schema := range catalog.Schemas {
 		for _, table := range schema.Tables {
 			tableStruct := TableStruct{
-				Name:   strings.ToUpper(strings.ReplaceAll(table.TableName, " ", "_")),
-				Fields: make([]StructField, 0, len(table.Columns)+1),
+				Name:    strings.ToUpper(strings.ReplaceAll(table.TableName, " ", "_")),
+				Comment: table.Comment,
+				Fields:  make([]StructField, 0, len(table.Columns)+1),
 			}
 			// sq.TableStruct `ddl:"primarykey=iid,sid"`
 			// sq.TableStruct
@@ -112,12 +132,41 @@ func (s *TableStructs) ReadCatalog(catalog *Catalog) error {
 			if catalog.Dialect == DialectSQLite && isVirtualTable(&table) {
 				firstField.Modifiers = append(firstField.Modifiers, Modifier{Name: "virtual"})
 			}
+			if catalog.Dialect == DialectSQLite && table.IsStrict {
+				firstField.Modifiers = append(firstField.Modifiers, Modifier{Name: "strict"})
+			}
+			if catalog.Dialect == DialectSQLite && table.IsWithoutRowid {
+				firstField.Modifiers = append(firstField.Modifiers, Modifier{Name: "withoutrowid"})
+			}
+			if catalog.Dialect == DialectPostgres && table.PartitionBy != "" {
+				// RANGE (created_at) => range(created_at)
+				strategy, columns, _ := strings.Cut(table.PartitionBy, " ")
+				firstField.Modifiers = append(firstField.Modifiers, Modifier{Name: "partitionby", RawValue: strings.ToLower(strategy) + columns})
+			}
+			if catalog.Dialect == DialectMySQL {
+				// Table options that are the same as the server defaults are
+				// left out. The AUTO_INCREMENT value is the state of the
+				// table's counter, not part of its definition.
+				if table.Engine != "" && !strings.EqualFold(table.Engine, "InnoDB") {
+					firstField.Modifiers = append(firstField.Modifiers, Modifier{Name: "engine", RawValue: table.Engine})
+				}
+				if table.CollationName != "" && table.CollationName != catalog.DefaultCollation {
+					if table.CharacterSet != "" {
+						firstField.Modifiers = append(firstField.Modifiers, Modifier{Name: "charset", RawValue: table.CharacterSet})
+					}
+					firstField.Modifiers = append(firstField.Modifiers, Modifier{Name: "collate", RawValue: table.CollationName})
+				}
+				if table.RowFormat != "" {
+					firstField.Modifiers = append(firstField.Modifiers, Modifier{Name: "rowformat", RawValue: table.RowFormat})
+				}
+			}
 			constraintModifierList := make([]*Modifier, 0, len(table.Constraints))
 			indexModifierList := make([]*Modifier, 0, len(table.Indexes))
 			var primaryKeyModifier *Modifier
 			uniqueModifiers := make(map[string]*Modifier)
 			foreignkeyModifiers := make(map[string]*Modifier)
 			indexModifiers := make(map[string]*Modifier)
+			checkModifiers := make(map[string]*Modifier)
 			addedModifier := make(map[*Modifier]bool)
 			var primaryKeyColumns []string
 			for _, constraint := range table.Constraints {
@@ -172,6 +221,36 @@ func (s *TableStructs) ReadCatalog(catalog *Catalog) error {
 							Value: strings.ToLower(strings.ReplaceAll(constraint.DeleteRule, " ", "")),
 						})
 					}
+				case CHECK:
+					// Skip check expressions that cannot be represented in a
+					// struct tag.
+					if constraint.CheckExpr == "" || strings.ContainsAny(constraint.CheckExpr, "`\"{}") {
+						continue
+					}
+					m.Name = "check"
+					m.Value = ""
+					m.RawValue = constraint.ConstraintName + " " + constraint.CheckExpr
+					// If the check constraint is named the same way the
+					// column-level check modifier would name it, we can
+					// attach it to the column instead.
+					for _, column := range table.Columns {
+						if constraint.ConstraintName == GenerateName(CHECK, table.TableName, []string{column.ColumnName}) {
+							checkModifiers[column.ColumnName] = m
+							break
+						}
+					}
+				case EXCLUDE:
+					m.Name = "exclude"
+					m.Value = ""
+					m.RawValue = exclusionRawValue(table.TableName, constraint)
+					// Skip exclusion constraints that cannot be represented in
+					// a struct tag.
+					if m.RawValue == "" {
+						continue
+					}
+					// The deferrable submodifiers are part of the RawValue.
+					constraintModifierList = append(constraintModifierList, m)
+					continue
 				default:
 					continue
 				}
@@ -186,12 +265,14 @@ func (s *TableStructs) ReadCatalog(catalog *Catalog) error {
 				constraintModifierList = append(constraintModifierList, m)
 			}
 			for _, index := range table.Indexes {
-				if index.Ignore || !isSimpleIndex(index) {
+				if index.Ignore || !isRepresentableIndex(index) {
 					continue
 				}
 				columnNames := strings.Join(index.Columns, ",")
 				m := &Modifier{Name: "index", Value: columnNames}
-				indexModifiers[columnNames] = m
+				if indexModifiers[columnNames] == nil {
+					indexModifiers[columnNames] = m
+				}
 				// unique
 				if index.IsUnique {
 					m.Submodifiers = append(m.Submodifiers, Modifier{Name: "unique"})
@@ -200,6 +281,45 @@ func (s *TableStructs) ReadCatalog(catalog *Catalog) error {
 				if index.IndexType != "" && !strings.EqualFold(index.IndexType, "BTREE") {
 					m.Submodifiers = append(m.Submodifiers, Modifier{Name: "using", RawValue: index.IndexType})
 				}
+				// name
+				if index.IndexName != GenerateName(INDEX, table.TableName, index.Columns) {
+					m.Submodifiers = append(m.Submodifiers, Modifier{Name: "name", RawValue: index.IndexName})
+				}
+				// desc
+				var descendingColumns []string
+				for i, isDescending := range index.Descending {
+					if isDescending && i < len(index.Columns) {
+						descendingColumns = append(descendingColumns, index.Columns[i])
+					}
+				}
+				if len(descendingColumns) == len(index.Columns) {
+					m.Submodifiers = append(m.Submodifiers, Modifier{Name: "desc"})
+				} else if len(descendingColumns) > 0 {
+					m.Submodifiers = append(m.Submodifiers, Modifier{Name: "desc", RawValue: strings.Join(descendingColumns, ",")})
+				}
+				// include
+				if len(index.IncludeColumns) > 0 {
+					m.Submodifiers = append(m.Submodifiers, Modifier{Name: "include", RawValue: strings.Join(index.IncludeColumns, ",")})
+				}
+				// where
+				if index.Predicate != "" {
+					m.Submodifiers = append(m.Submodifiers, Modifier{Name: "where", RawValue: index.Predicate})
+				}
+				// opclass
+				opclasses := make([]string, 0, len(index.Columns))
+				for i := range index.Columns {
+					if i < len(index.Opclasses) && index.Opclasses[i] != "" {
+						opclasses = append(opclasses, index.Opclasses[i])
+					} else {
+						opclasses = append(opclasses, "")
+					}
+				}
+				for len(opclasses) > 0 && opclasses[len(opclasses)-1] == "" {
+					opclasses = opclasses[:len(opclasses)-1]
+				}
+				if len(opclasses) > 0 {
+					m.Submodifiers = append(m.Submodifiers, Modifier{Name: "opclass", RawValue: strings.Join(opclasses, ",")})
+				}
 				// foreignkey.index
 				if foreignkeyModifier := foreignkeyModifiers[columnNames]; foreignkeyModifier != nil {
 					addedModifier[m] = true
@@ -218,6 +338,7 @@ func (s *TableStructs) ReadCatalog(catalog *Catalog) error {
 					Type:      getFieldType(catalog.Dialect, &table.Columns[i]),
 					NewGoType: fieldGoType.NewGoType,
 					RawGoType: fieldGoType.RawGoType,
+					Comment:   column.Comment,
 				}
 				if needsQuoting(column.ColumnName) {
 					structField.NameTag = column.ColumnName
@@ -357,6 +478,12 @@ func (s *TableStructs) ReadCatalog(catalog *Catalog) error {
 				if column.ColumnDefault != "" && !strings.ContainsRune(column.ColumnDefault, '`') {
 					structField.Modifiers = append(structField.Modifiers, Modifier{Name: "default", RawValue: unwrapBrackets(column.ColumnDefault)})
 				}
+				// check
+				if checkModifier := checkModifiers[column.ColumnName]; checkModifier != nil {
+					addedModifier[checkModifier] = true
+					_, checkExpr, _ := strings.Cut(checkModifier.RawValue, " ")
+					structField.Modifiers = append(structField.Modifiers, Modifier{Name: "check", RawValue: checkExpr})
+				}
 				// onupdatecurrenttimestamp
 				if column.OnUpdateCurrentTimestamp {
 					structField.Modifiers = append(structField.Modifiers, Modifier{Name: "onupdatecurrenttimestamp"})
@@ -420,6 +547,22 @@ func (s *TableStructs) ReadCatalog(catalog *Catalog) error {
 	return nil
 }
 
+// writeDocComment writes a comment as a Go doc comment, wrapping each line of
+// the comment with the prefix and suffix.
+func writeDocComment(buf *bytes.Buffer, prefix, suffix, comment string) {
+	if comment == "" {
+		return
+	}
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			buf.WriteString(prefix + "//" + suffix)
+		} else {
+			buf.WriteString(prefix + "// " + line + suffix)
+		}
+	}
+}
+
 // MarshalText converts the TableStructs into Go source code.
 func (s *TableStructs) MarshalText() (text []byte, err error) {
 	buf := bufpool.Get().(*bytes.Buffer)
@@ -481,8 +624,10 @@ func (s *TableStructs) MarshalText() (text []byte, err error) {
 		if buf.Len() > 0 {
 			buf.WriteString("\n")
 		}
+		writeDocComment(buf, "", "\n", tableStruct.Comment)
 		buf.WriteString("type " + tableStruct.Name + " struct {")
 		for _, structField := range tableStruct.Fields {
+			writeDocComment(buf, "\n\t", "", structField.Comment)
 			if structField.Name != "" {
 				buf.WriteString("\n\t" + structField.Name + " " + structField.Type)
 			} else {
@@ -733,7 +878,7 @@ func isSimpleIndex(index Index) bool {
 		}
 	}
 	for _, opclass := range index.Opclasses {
-		if strings.Count(opclass, "_") > 1 {
+		if opclass != "" {
 			return false
 		}
 	}
@@ -754,3 +899,77 @@ func isSimpleIndex(index Index) bool {
 	}
 	return true
 }
+
+// isRepresentableIndex reports if an index can be represented by an index
+// modifier. Expression indexes and indexes whose predicates cannot be written
+// inside a struct tag are not representable.
+func isRepresentableIndex(index Index) bool {
+	if len(index.Columns) == 0 {
+		return false
+	}
+	for _, column := range index.Columns {
+		if strings.HasPrefix(column, "(") || column == "" {
+			return false
+		}
+	}
+	if strings.ContainsAny(index.Predicate, "`\"{}") {
+		return false
+	}
+	// If the index SQL mentions a clause that isn't reflected in the index
+	// fields, we can't represent it.
+	upperSQL := strings.ToUpper(index.SQL)
+	if index.Predicate == "" && strings.Contains(upperSQL, " WHERE ") {
+		return false
+	}
+	if len(index.IncludeColumns) == 0 && strings.Contains(upperSQL, " INCLUDE ") {
+		return false
+	}
+	if !slices.Contains(index.Descending, true) && strings.Contains(upperSQL, " DESC") {
+		return false
+	}
+	return true
+}
+
+// exclusionRawValue returns the exclude modifier value for an EXCLUDE
+// constraint e.g. "using=gist room_id:= during:&&". If the constraint cannot
+// be represented in a struct tag, an empty string is returned.
+func exclusionRawValue(tableName string, constraint Constraint) string {
+	if len(constraint.Columns) == 0 || len(constraint.Columns) != len(constraint.ExclusionOperators) ||
+		strings.ContainsAny(constraint.ExclusionPredicate, "`\"{}") {
+		return ""
+	}
+	var b strings.Builder
+	if constraint.ExclusionIndexType != "" && !strings.EqualFold(constraint.ExclusionIndexType, "BTREE") {
+		b.WriteString("using=" + strings.ToLower(constraint.ExclusionIndexType))
+	}
+	for i, column := range constraint.Columns {
+		operator := constraint.ExclusionOperators[i]
+		if column == "" || strings.ContainsAny(column, "`\"{}") ||
+			operator == "" || strings.Trim(operator, "+-*/<>=~!@#%^&|`?") != "" {
+			return ""
+		}
+		if b.Len() > 0 {
+			b.WriteString(" ")
+		}
+		if strings.ContainsAny(column, " :") {
+			b.WriteString("{" + column + "}:" + operator)
+		} else {
+			b.WriteString(column + ":" + operator)
+		}
+	}
+	if constraint.ExclusionPredicate != "" {
+		b.WriteString(" where={" + constraint.ExclusionPredicate + "}")
+	}
+	if constraint.ConstraintName != generateExclusionName(tableName, constraint.Columns) {
+		if strings.ContainsAny(constraint.ConstraintName, " `\"{}") {
+			return ""
+		}
+		b.WriteString(" name=" + constraint.ConstraintName)
+	}
+	if constraint.IsInitiallyDeferred {
+		b.WriteString(" deferred")
+	} else if constraint.IsDeferrable {
+		b.WriteString(" deferrable")
+	}
+	return b.String()
+}
@@ -0,0 +1,7 @@
+CREATE TABLE category (
+    category_id INT NOT NULL
+    ,name VARCHAR(255) NOT NULL
+
+    ,PRIMARY KEY (category_id)
+    ,CONSTRAINT category_name_check CHECK (length(name) > 0)
+);
@@ -0,0 +1,9 @@
+ALTER TABLE film
+    DROP CONSTRAINT film_length_check
+    ,DROP CONSTRAINT film_rental_rate_check
+    ,ADD COLUMN replacement_cost NUMERIC(5,2)
+    ,ADD CONSTRAINT film_title_check CHECK (title <> '')
+    ,ADD CONSTRAINT film_rental_rate_check CHECK (rental_rate >= 0)
+    ,ADD CONSTRAINT film_replacement_cost_check CHECK (replacement_cost > 0)
+    ,ADD CONSTRAINT film_cost_check CHECK (replacement_cost >= rental_rate)
+;
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS category;
+
+CREATE TABLE actor (
+    actor_id INT NOT NULL
+    ,name VARCHAR(255) NOT NULL COMMENT 'The actor''s full name.'
+
+    ,PRIMARY KEY (actor_id)
+) COMMENT = 'ACTOR lists the actors appearing in films.
+
+Actors may appear in more than one film.';
@@ -0,0 +1,6 @@
+ALTER TABLE film
+    MODIFY COLUMN length INT COMMENT 'Length in minutes.'
+    ,MODIFY COLUMN rating VARCHAR(255) COMMENT 'Film rating.'
+    ,MODIFY COLUMN release_year INT
+    ,COMMENT = 'The films in the store.'
+;
@@ -0,0 +1,7 @@
+ALTER TABLE users
+    DROP INDEX users_email_idx
+    ,DROP INDEX users_email_created_at_idx
+    ,ADD UNIQUE INDEX users_email_idx (email)
+    ,ADD INDEX users_recent_idx (created_at DESC)
+    ,ADD INDEX users_email_created_at_idx (email, created_at DESC)
+;
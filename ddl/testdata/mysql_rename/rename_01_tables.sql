@@ -0,0 +1,5 @@
+CREATE TABLE invoices (
+    invoice_id INT NOT NULL
+
+    ,PRIMARY KEY (invoice_id)
+);
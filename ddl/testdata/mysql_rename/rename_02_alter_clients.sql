@@ -0,0 +1,6 @@
+RENAME TABLE customers TO clients;
+
+ALTER TABLE clients
+    RENAME INDEX customers_email_idx TO clients_email_idx
+    ,CHANGE COLUMN full_name name VARCHAR(255)
+;
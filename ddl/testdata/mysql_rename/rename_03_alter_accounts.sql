@@ -0,0 +1,4 @@
+ALTER TABLE accounts
+    CHANGE COLUMN name display_name VARCHAR(255)
+    ,MODIFY COLUMN balance DECIMAL(12,2)
+;
@@ -0,0 +1,5 @@
+CREATE TABLE invoice (
+    invoice_id INT AUTO_INCREMENT NOT NULL
+
+    ,PRIMARY KEY (invoice_id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 AUTO_INCREMENT = 1000;
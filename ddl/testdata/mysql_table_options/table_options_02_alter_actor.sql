@@ -0,0 +1,5 @@
+ALTER TABLE actor
+    ENGINE = InnoDB
+    ,CONVERT TO CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci
+    ,ROW_FORMAT = DYNAMIC
+;
@@ -0,0 +1,13 @@
+ALTER TABLE film DROP CONSTRAINT IF EXISTS film_length_check;
+
+ALTER TABLE film DROP CONSTRAINT IF EXISTS film_rental_rate_check;
+
+ALTER TABLE film ADD COLUMN replacement_cost NUMERIC(5,2);
+
+ALTER TABLE film ADD CONSTRAINT film_title_check CHECK (title <> '') NOT VALID;
+
+ALTER TABLE film ADD CONSTRAINT film_rental_rate_check CHECK (rental_rate >= 0) NOT VALID;
+
+ALTER TABLE film ADD CONSTRAINT film_replacement_cost_check CHECK (replacement_cost > 0) NOT VALID;
+
+ALTER TABLE film ADD CONSTRAINT film_cost_check CHECK (replacement_cost >= rental_rate) NOT VALID;
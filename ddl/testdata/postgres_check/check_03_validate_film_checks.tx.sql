@@ -0,0 +1,7 @@
+ALTER TABLE film VALIDATE CONSTRAINT film_title_check;
+
+ALTER TABLE film VALIDATE CONSTRAINT film_rental_rate_check;
+
+ALTER TABLE film VALIDATE CONSTRAINT film_replacement_cost_check;
+
+ALTER TABLE film VALIDATE CONSTRAINT film_cost_check;
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS category;
+
+CREATE TABLE actor (
+    actor_id INT NOT NULL
+    ,name TEXT NOT NULL
+
+    ,CONSTRAINT actor_actor_id_pkey PRIMARY KEY (actor_id)
+);
+COMMENT ON TABLE actor IS 'ACTOR lists the actors appearing in films.
+
+Actors may appear in more than one film.';
+COMMENT ON COLUMN actor.name IS 'The actor''s full name.';
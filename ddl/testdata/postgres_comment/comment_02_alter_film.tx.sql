@@ -0,0 +1,7 @@
+COMMENT ON TABLE film IS 'The films in the store.';
+
+COMMENT ON COLUMN film.length IS 'Length in minutes.';
+
+COMMENT ON COLUMN film.rating IS 'Film rating.';
+
+COMMENT ON COLUMN film.release_year IS NULL;
@@ -0,0 +1 @@
+CREATE DOMAIN phone AS TEXT CONSTRAINT phone_check CHECK (VALUE ~ '^[0-9+ -]+$');
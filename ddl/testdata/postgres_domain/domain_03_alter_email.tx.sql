@@ -0,0 +1,5 @@
+ALTER DOMAIN email DROP CONSTRAINT IF EXISTS email_check1;
+
+ALTER DOMAIN email SET NOT NULL;
+
+ALTER DOMAIN email ADD CONSTRAINT email_check1 CHECK (length(VALUE) <= 255) NOT VALID;
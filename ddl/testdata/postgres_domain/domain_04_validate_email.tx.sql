@@ -0,0 +1 @@
+ALTER DOMAIN email VALIDATE CONSTRAINT email_check1;
@@ -0,0 +1 @@
+ALTER DOMAIN zipcode DROP DEFAULT;
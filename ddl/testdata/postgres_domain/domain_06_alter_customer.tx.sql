@@ -0,0 +1,3 @@
+ALTER TABLE customer DROP COLUMN IF EXISTS birth_year;
+
+ALTER TABLE customer ADD COLUMN phone phone;
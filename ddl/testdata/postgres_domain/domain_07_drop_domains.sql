@@ -0,0 +1 @@
+DROP DOMAIN IF EXISTS year;
@@ -0,0 +1 @@
+CREATE TYPE genre AS ENUM ('action', 'comedy', 'drama');
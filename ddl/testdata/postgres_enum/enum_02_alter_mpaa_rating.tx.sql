@@ -0,0 +1,5 @@
+ALTER TYPE mpaa_rating ADD VALUE IF NOT EXISTS 'X' BEFORE 'G';
+
+ALTER TYPE mpaa_rating ADD VALUE IF NOT EXISTS 'PG-13' AFTER 'PG';
+
+ALTER TYPE mpaa_rating ADD VALUE IF NOT EXISTS 'NC-17';
@@ -0,0 +1,3 @@
+ALTER TABLE film DROP COLUMN IF EXISTS format;
+
+ALTER TABLE film ADD COLUMN genre genre;
@@ -0,0 +1 @@
+DROP TYPE IF EXISTS film_format;
@@ -0,0 +1,9 @@
+CREATE TABLE hold (
+    hold_id INT NOT NULL
+    ,room_id INT NOT NULL
+    ,start_at TIMESTAMPTZ NOT NULL
+    ,end_at TIMESTAMPTZ NOT NULL
+
+    ,CONSTRAINT hold_room_id_tsrange_excl EXCLUDE USING gist (room_id WITH =, tsrange(start_at, end_at) WITH &&) DEFERRABLE
+    ,CONSTRAINT hold_hold_id_pkey PRIMARY KEY (hold_id)
+);
@@ -0,0 +1,5 @@
+ALTER TABLE booking DROP CONSTRAINT IF EXISTS booking_room_id_during_excl;
+
+ALTER TABLE booking ADD COLUMN is_cancelled BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE booking ADD CONSTRAINT booking_no_overlap EXCLUDE USING gist (room_id WITH =, during WITH &&) WHERE (NOT is_cancelled);
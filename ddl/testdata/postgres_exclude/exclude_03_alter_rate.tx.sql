@@ -0,0 +1,3 @@
+ALTER TABLE rate DROP CONSTRAINT IF EXISTS rate_no_overlap;
+
+ALTER TABLE rate ADD CONSTRAINT rate_no_overlap EXCLUDE USING gist (room_id WITH =, rate_plan_id WITH =, during WITH &&);
@@ -0,0 +1 @@
+CREATE INDEX CONCURRENTLY users_recent_idx ON users (created_at DESC);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS users_recent_idx;
@@ -0,0 +1 @@
+CREATE INDEX CONCURRENTLY users_email_created_at_idx ON users (email text_pattern_ops, created_at DESC) INCLUDE (name);
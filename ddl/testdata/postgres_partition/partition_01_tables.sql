@@ -0,0 +1,4 @@
+CREATE TABLE reading (
+    sensor_id INT NOT NULL
+    ,value INT
+) PARTITION BY LIST (sensor_id);
@@ -0,0 +1 @@
+ALTER TABLE measurement ADD CONSTRAINT measurement_measurement_id_created_at_pkey PRIMARY KEY (measurement_id, created_at);
@@ -0,0 +1 @@
+CREATE INDEX measurement_created_at_idx ON measurement (created_at);
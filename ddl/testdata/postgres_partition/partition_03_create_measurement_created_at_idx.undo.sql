@@ -0,0 +1 @@
+DROP INDEX IF EXISTS measurement_created_at_idx;
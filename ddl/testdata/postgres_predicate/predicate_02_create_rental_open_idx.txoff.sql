@@ -0,0 +1 @@
+CREATE INDEX CONCURRENTLY rental_open_idx ON rental (staff_id) WHERE return_date IS NULL OR (is_overdue AND NOT is_deleted);
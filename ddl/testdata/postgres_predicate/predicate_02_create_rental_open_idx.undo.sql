@@ -0,0 +1 @@
+DROP INDEX IF EXISTS rental_open_idx;
@@ -0,0 +1,7 @@
+ALTER TABLE customers RENAME TO clients;
+
+ALTER TABLE clients RENAME COLUMN full_name TO name;
+
+ALTER TABLE clients RENAME CONSTRAINT customers_customer_id_pkey TO clients_customer_id_pkey;
+
+ALTER INDEX customers_email_idx RENAME TO clients_email_idx;
@@ -0,0 +1,3 @@
+ALTER TABLE accounts RENAME COLUMN name TO display_name;
+
+ALTER TABLE accounts ALTER COLUMN balance TYPE DECIMAL(12,2);
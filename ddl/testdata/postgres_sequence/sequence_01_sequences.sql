@@ -0,0 +1 @@
+CREATE SEQUENCE ticket_seq INCREMENT BY -1;
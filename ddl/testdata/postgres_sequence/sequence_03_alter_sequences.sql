@@ -0,0 +1,5 @@
+ALTER SEQUENCE order_number_seq MAXVALUE 999999 CYCLE;
+
+ALTER SEQUENCE invoice_seq AS bigint MINVALUE 1 MAXVALUE 9223372036854775807;
+
+ALTER SEQUENCE ticket_seq OWNED BY orders.ticket_number;
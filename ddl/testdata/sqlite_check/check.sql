@@ -0,0 +1,32 @@
+PRAGMA legacy_alter_table = ON;
+
+CREATE TABLE category (
+    category_id INTEGER PRIMARY KEY
+    ,name TEXT NOT NULL
+
+    ,CONSTRAINT category_name_check CHECK (length(name) > 0)
+);
+
+CREATE TABLE film_new (
+    film_id INTEGER PRIMARY KEY
+    ,title TEXT NOT NULL
+    ,length INT
+    ,rental_rate INT
+    ,replacement_cost INT
+
+    ,CONSTRAINT film_title_check CHECK (title <> '')
+    ,CONSTRAINT film_rental_rate_check CHECK (rental_rate >= 0)
+    ,CONSTRAINT film_replacement_cost_check CHECK (replacement_cost > 0)
+    ,CONSTRAINT film_cost_check CHECK (replacement_cost >= rental_rate)
+);
+INSERT INTO film_new
+    (film_id, title, length, rental_rate)
+SELECT
+    film_id, title, length, rental_rate
+FROM
+    film
+;
+DROP TABLE film;
+ALTER TABLE film_new RENAME TO film;
+
+PRAGMA legacy_alter_table = OFF;
@@ -0,0 +1,22 @@
+PRAGMA legacy_alter_table = ON;
+
+CREATE TABLE film_new (
+    film_id INTEGER PRIMARY KEY
+    ,title TEXT NOT NULL
+    ,length INT
+    ,rental_rate INT
+
+    ,CONSTRAINT film_length_check CHECK (length > 0)
+    ,CONSTRAINT film_rental_rate_check CHECK (rental_rate >= 0)
+);
+INSERT INTO film_new
+    (film_id, title, length, rental_rate)
+SELECT
+    film_id, title, length, rental_rate
+FROM
+    film
+;
+DROP TABLE film;
+ALTER TABLE film_new RENAME TO film;
+
+PRAGMA legacy_alter_table = OFF;
@@ -0,0 +1,9 @@
+DROP INDEX users_email_idx;
+
+DROP INDEX users_email_created_at_idx;
+
+CREATE UNIQUE INDEX users_email_idx ON users (email) WHERE deleted_at IS NULL;
+
+CREATE INDEX users_recent_idx ON users (created_at DESC);
+
+CREATE INDEX users_email_created_at_idx ON users (email, created_at DESC);
@@ -0,0 +1,30 @@
+PRAGMA legacy_alter_table = ON;
+
+CREATE TABLE invoices (
+    invoice_id INTEGER PRIMARY KEY
+);
+
+ALTER TABLE customers RENAME TO clients;
+
+ALTER TABLE clients RENAME COLUMN full_name TO name;
+
+DROP INDEX customers_email_idx;
+
+CREATE INDEX clients_email_idx ON clients (email);
+
+CREATE TABLE accounts_new (
+    account_id INTEGER PRIMARY KEY
+    ,display_name TEXT
+    ,balance DECIMAL(12,2)
+);
+INSERT INTO accounts_new
+    (account_id, display_name, balance)
+SELECT
+    account_id, name, balance
+FROM
+    accounts
+;
+DROP TABLE accounts;
+ALTER TABLE accounts_new RENAME TO accounts;
+
+PRAGMA legacy_alter_table = OFF;
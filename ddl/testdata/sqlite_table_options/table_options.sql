@@ -0,0 +1,41 @@
+PRAGMA legacy_alter_table = ON;
+
+CREATE TABLE film_category (
+    film_id INT NOT NULL
+    ,category_id INT NOT NULL
+
+    ,CONSTRAINT film_category_film_id_category_id_pkey PRIMARY KEY (film_id, category_id)
+) WITHOUT ROWID;
+
+CREATE TABLE actor_new (
+    actor_id INTEGER PRIMARY KEY
+    ,first_name TEXT NOT NULL
+    ,last_update TEXT
+) STRICT;
+INSERT INTO actor_new
+    (actor_id, first_name, last_update)
+SELECT
+    actor_id, first_name, last_update
+FROM
+    actor
+;
+DROP TABLE actor;
+ALTER TABLE actor_new RENAME TO actor;
+
+CREATE INDEX actor_last_update_idx ON actor (last_update);
+
+CREATE TABLE language_code_new (
+    code TEXT PRIMARY KEY NOT NULL
+    ,name TEXT NOT NULL
+) WITHOUT ROWID, STRICT;
+INSERT INTO language_code_new
+    (code, name)
+SELECT
+    code, name
+FROM
+    language_code
+;
+DROP TABLE language_code;
+ALTER TABLE language_code_new RENAME TO language_code;
+
+PRAGMA legacy_alter_table = OFF;
@@ -0,0 +1,6 @@
+CREATE TABLE film_category (
+    film_id INT NOT NULL
+    ,category_id INT NOT NULL
+
+    ,CONSTRAINT film_category_film_id_category_id_pkey PRIMARY KEY (film_id, category_id)
+) WITHOUT ROWID;
@@ -0,0 +1,7 @@
+CREATE TABLE category (
+    category_id INT NOT NULL
+    ,name NVARCHAR(255) NOT NULL
+
+    ,CONSTRAINT category_category_id_pkey PRIMARY KEY (category_id)
+    ,CONSTRAINT category_name_check CHECK (LEN(name) > 0)
+);
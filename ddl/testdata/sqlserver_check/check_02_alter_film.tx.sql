@@ -0,0 +1,5 @@
+ALTER TABLE film DROP CONSTRAINT film_length_check;
+
+ALTER TABLE film DROP CONSTRAINT film_rental_rate_check;
+
+ALTER TABLE film ADD replacement_cost NUMERIC(5,2);
@@ -0,0 +1 @@
+ALTER TABLE film ADD CONSTRAINT film_title_check CHECK (title <> '');
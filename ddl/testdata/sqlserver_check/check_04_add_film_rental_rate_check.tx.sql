@@ -0,0 +1 @@
+ALTER TABLE film ADD CONSTRAINT film_rental_rate_check CHECK (rental_rate >= 0);
@@ -0,0 +1 @@
+ALTER TABLE film ADD CONSTRAINT film_replacement_cost_check CHECK (replacement_cost > 0);
@@ -0,0 +1 @@
+ALTER TABLE film ADD CONSTRAINT film_cost_check CHECK (replacement_cost >= rental_rate);
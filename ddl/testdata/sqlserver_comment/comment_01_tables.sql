@@ -0,0 +1,12 @@
+DROP TABLE category;
+
+CREATE TABLE actor (
+    actor_id INT NOT NULL
+    ,name NVARCHAR(255) NOT NULL
+
+    ,CONSTRAINT actor_actor_id_pkey PRIMARY KEY (actor_id)
+);
+EXEC sp_addextendedproperty @name = N'MS_Description', @value = N'ACTOR lists the actors appearing in films.
+
+Actors may appear in more than one film.', @level0type = N'SCHEMA', @level0name = N'dbo', @level1type = N'TABLE', @level1name = N'actor';
+EXEC sp_addextendedproperty @name = N'MS_Description', @value = N'The actor''s full name.', @level0type = N'SCHEMA', @level0name = N'dbo', @level1type = N'TABLE', @level1name = N'actor', @level2type = N'COLUMN', @level2name = N'name';
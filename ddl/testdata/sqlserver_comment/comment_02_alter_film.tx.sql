@@ -0,0 +1,7 @@
+EXEC sp_updateextendedproperty @name = N'MS_Description', @value = N'The films in the store.', @level0type = N'SCHEMA', @level0name = N'dbo', @level1type = N'TABLE', @level1name = N'film';
+
+EXEC sp_updateextendedproperty @name = N'MS_Description', @value = N'Length in minutes.', @level0type = N'SCHEMA', @level0name = N'dbo', @level1type = N'TABLE', @level1name = N'film', @level2type = N'COLUMN', @level2name = N'length';
+
+EXEC sp_updateextendedproperty @name = N'MS_Description', @value = N'Film rating.', @level0type = N'SCHEMA', @level0name = N'dbo', @level1type = N'TABLE', @level1name = N'film', @level2type = N'COLUMN', @level2name = N'rating';
+
+EXEC sp_dropextendedproperty @name = N'MS_Description', @level0type = N'SCHEMA', @level0name = N'dbo', @level1type = N'TABLE', @level1name = N'film', @level2type = N'COLUMN', @level2name = N'release_year';
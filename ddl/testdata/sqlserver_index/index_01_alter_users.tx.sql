@@ -0,0 +1,3 @@
+DROP INDEX users_email_idx ON users;
+
+DROP INDEX users_email_created_at_idx ON users;
@@ -0,0 +1 @@
+CREATE UNIQUE INDEX users_email_idx ON users (email) WHERE deleted_at IS NULL;
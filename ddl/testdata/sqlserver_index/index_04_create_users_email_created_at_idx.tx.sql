@@ -0,0 +1 @@
+CREATE INDEX users_email_created_at_idx ON users (email, created_at DESC) INCLUDE (name);
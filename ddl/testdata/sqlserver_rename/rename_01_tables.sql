@@ -0,0 +1,5 @@
+CREATE TABLE invoices (
+    invoice_id INT NOT NULL
+
+    ,CONSTRAINT invoices_invoice_id_pkey PRIMARY KEY (invoice_id)
+);
@@ -0,0 +1,7 @@
+EXEC sp_rename N'dbo.customers', N'clients';
+
+EXEC sp_rename N'dbo.clients.full_name', N'name', N'COLUMN';
+
+EXEC sp_rename N'dbo.customers_customer_id_pkey', N'clients_customer_id_pkey', N'OBJECT';
+
+EXEC sp_rename N'dbo.clients.customers_email_idx', N'clients_email_idx', N'INDEX';
@@ -0,0 +1,3 @@
+EXEC sp_rename N'dbo.accounts.name', N'display_name', N'COLUMN';
+
+ALTER TABLE accounts ALTER COLUMN balance DECIMAL(12,2);
@@ -0,0 +1,7 @@
+module example.com/tables
+
+go 1.24
+
+require github.com/blink-io/sq v0.0.0
+
+replace github.com/blink-io/sq => ./sq
@@ -0,0 +1,8 @@
+package invalid
+
+import "github.com/blink-io/sq"
+
+type ACTOR struct {
+	sq.TableStruct
+	ACTOR_ID sq.NumberField `ddl:"primarykey"`
+}
@@ -0,0 +1,9 @@
+package invalid
+
+import "github.com/blink-io/sq"
+
+type FILM struct {
+	sq.TableStruct
+	FILM_ID  sq.NumberField `ddl:"primarykey"`
+	ACTOR_ID sq.NumberField `ddl:"references=actor acceptwarning"`
+}
@@ -0,0 +1,14 @@
+package billing
+
+import "github.com/blink-io/sq"
+
+// Base has the same name as common.Base.
+type Base struct {
+	CURRENCY sq.StringField `ddl:"notnull"`
+}
+
+type INVOICE struct {
+	sq.TableStruct
+	INVOICE_ID sq.NumberField `ddl:"primarykey"`
+	Base
+}
@@ -0,0 +1,7 @@
+package common
+
+import "github.com/blink-io/sq"
+
+type Base struct {
+	TENANT_ID sq.NumberField `ddl:"notnull"`
+}
@@ -0,0 +1,12 @@
+package crm
+
+import (
+	"example.com/tables/mixins/common"
+	"github.com/blink-io/sq"
+)
+
+type CUSTOMER struct {
+	sq.TableStruct
+	CUSTOMER_ID sq.NumberField `ddl:"primarykey"`
+	common.Base
+}
@@ -0,0 +1,3 @@
+module github.com/blink-io/sq
+
+go 1.24
@@ -0,0 +1,10 @@
+// Package sq stubs out the table struct types of github.com/blink-io/sq.
+package sq
+
+type TableStruct struct{}
+
+type NumberField struct{}
+
+type StringField struct{}
+
+type EnumField struct{}
@@ -0,0 +1,9 @@
+package audit
+
+import "github.com/blink-io/sq"
+
+type AUDIT_LOG struct {
+	sq.TableStruct
+	AUDIT_LOG_ID sq.NumberField `ddl:"primarykey"`
+	MESSAGE      sq.StringField
+}
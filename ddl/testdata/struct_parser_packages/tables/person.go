@@ -0,0 +1,10 @@
+package tables
+
+import sqx "github.com/blink-io/sq"
+
+type PERSON struct {
+	sqx.TableStruct
+	PERSON_ID sqx.NumberField `ddl:"primarykey"`
+	NAME      sqx.StringField `ddl:"notnull"`
+	RATING    sqx.EnumField   `ddl:"type=mpaa_rating enum=Rating"`
+}
@@ -0,0 +1,9 @@
+package tables
+
+import . "github.com/blink-io/sq"
+
+type PET struct {
+	TableStruct
+	PET_ID   ID          `ddl:"primarykey"`
+	OWNER_ID NumberField `ddl:"references=person.person_id"`
+}
@@ -0,0 +1,12 @@
+package tables
+
+import "github.com/blink-io/sq"
+
+// ID is a type alias, which can only be resolved by type checking.
+type ID = sq.NumberField
+
+type Rating string
+
+func (r Rating) Enumerate() []string {
+	return []string{"G", "PG", "R"}
+}
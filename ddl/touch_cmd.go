@@ -327,7 +327,7 @@ func sortAndFilterFilenames(filenames []string) []string {
 	repeatable := make([]string, 0, len(filenames))
 	for _, filename := range filenames {
 		filename = filepath.ToSlash(filename)
-		if !strings.HasSuffix(filename, ".sql") || strings.HasSuffix(filename, ".undo.sql") {
+		if !strings.HasSuffix(filename, ".sql") || strings.HasSuffix(filename, ".undo.sql") || strings.HasSuffix(filename, ".down.sql") {
 			continue
 		}
 		basename := filepath.Base(filename)
@@ -0,0 +1,671 @@
+package ddl
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// undoScope is the set of objects changed by a single migration file. The
+// down script of the file is worked out by diffing the objects in the scope
+// from the destination catalog back to the source catalog.
+type undoScope struct {
+	// all is true if the file changes everything (SQLite puts the whole
+	// migration in one file).
+	all bool
+
+	// extensions are the extensions created or dropped by the file.
+	extensions []string
+
+	// schemas are the schemas created or dropped by the file. A dropped
+	// schema is restored together with everything that was in it.
+	schemas []string
+
+	// enums, domains and sequences are the enum types, domain types and
+	// sequences created, altered or dropped by the file. sequenceOwners are
+	// the sequences created by an earlier file that are given their owner by
+	// the file.
+	enums          []*Enum
+	domains        []*Domain
+	sequences      []*Sequence
+	sequenceOwners []*Sequence
+
+	// tables are the tables created, altered or dropped by the file, without
+	// their foreign keys. skipIndexes and skipConstraints are the indexes and
+	// constraints of those tables that are created by a later file.
+	tables          []*Table
+	skipIndexes     []*Index
+	skipConstraints []*Constraint
+
+	// indexes and constraints are the indexes and constraints (including
+	// foreign keys) created by the file. dropFkeys are the foreign keys
+	// dropped by the file.
+	indexes     []*Index
+	constraints []*Constraint
+	dropFkeys   []*Constraint
+
+	// srcViews are the views dropped or replaced by the file and destViews
+	// are the views created or replaced by the file.
+	srcViews  []*View
+	destViews []*View
+
+	// srcRoutines are the routines dropped or replaced by the file and
+	// destRoutines are the routines created or replaced by the file.
+	srcRoutines  []*Routine
+	destRoutines []*Routine
+
+	// srcTriggers are the triggers dropped by the file and destTriggers are
+	// the triggers created by the file.
+	srcTriggers  []*Trigger
+	destTriggers []*Trigger
+}
+
+// undoCatalogs holds the state of the objects in an undoScope before and
+// after a migration file is run.
+type undoCatalogs struct {
+	srcCatalog      *Catalog
+	destCatalog     *Catalog
+	srcCache        *CatalogCache
+	destCache       *CatalogCache
+	keepFkeys       bool
+	keepTriggers    bool
+	dropObjects     bool
+	skipIndexes     map[[3]string]bool
+	skipConstraints map[[3]string]bool
+	before          *Catalog
+	after           *Catalog
+}
+
+// downMigration adds a down script after every migration file. The down
+// script of a file is generated by migrating the objects changed by the file
+// from the destCatalog back to the srcCatalog. Unlike the undo scripts
+// written by the migration, which only clean up after a failed file and are
+// run automatically by MigrateCmd, down scripts are never run automatically.
+// The returned warnings report the down scripts that cannot restore dropped
+// data.
+func downMigration(dialect string, srcCatalog, destCatalog *Catalog, dropObjects bool, filenames []string, bufs []*bytes.Buffer, scopes []undoScope) ([]string, []*bytes.Buffer, []Warning) {
+	var warnings []Warning
+	downFilenames := make([]string, 0, len(filenames)*2)
+	downBufs := make([]*bytes.Buffer, 0, len(bufs)*2)
+	n := 0
+	for i := 0; i < len(filenames); i++ {
+		filename := filenames[i]
+		downFilenames = append(downFilenames, filename)
+		downBufs = append(downBufs, bufs[i])
+		if strings.HasSuffix(filename, ".undo.sql") || n >= len(scopes) {
+			continue
+		}
+		scope := scopes[n]
+		n++
+		downfile := downFilename(filename)
+		u := newUndoCatalogs(srcCatalog, destCatalog, scope, dropObjects)
+		downbuf := undoSQL(dialect, u.before, u.after)
+		if downbuf.Len() == 0 {
+			bufpool.Put(downbuf)
+			continue
+		}
+		// The down script goes after the file's undo script, if any.
+		if i+1 < len(filenames) && strings.HasSuffix(filenames[i+1], ".undo.sql") {
+			i++
+			downFilenames = append(downFilenames, filenames[i])
+			downBufs = append(downBufs, bufs[i])
+		}
+		downFilenames = append(downFilenames, downfile)
+		downBufs = append(downBufs, downbuf)
+		warnings = append(warnings, u.lossyWarnings(downfile)...)
+	}
+	return downFilenames, downBufs, warnings
+}
+
+// downFilename returns the name of the down script of a migration file i.e.
+// <name>.down.sql, where <name> is obtained from either <name>.sql,
+// <name>.tx.sql or <name>.txoff.sql.
+func downFilename(filename string) string {
+	name := strings.TrimSuffix(filename, ".sql")
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".txoff"), ".tx")
+	return name + ".down.sql"
+}
+
+// undoSQL returns the SQL that migrates the after catalog back to the before
+// catalog, as a single script.
+func undoSQL(dialect string, before, after *Catalog) *bytes.Buffer {
+	var filenames []string
+	var bufs []*bytes.Buffer
+	switch dialect {
+	case DialectSQLite:
+		m := newSQLiteMigration(after, before, true)
+		filenames, bufs, _ = m.sql("undo")
+	case DialectPostgres:
+		m := newPostgresMigration(after, before, true)
+		filenames, bufs, _ = m.sql("undo")
+	case DialectMySQL:
+		m := newMySQLMigration(after, before, true)
+		filenames, bufs, _ = m.sql("undo")
+	case DialectSQLServer:
+		m := newSQLServerMigration(after, before, true)
+		filenames, bufs, _ = m.sql("undo")
+	}
+	undobuf := bufpool.Get().(*bytes.Buffer)
+	undobuf.Reset()
+	for i, filename := range filenames {
+		if !strings.HasSuffix(filename, ".undo.sql") && bufs[i].Len() > 0 {
+			if undobuf.Len() > 0 {
+				undobuf.WriteString("\n")
+			}
+			undobuf.Write(bufs[i].Bytes())
+		}
+		bufpool.Put(bufs[i])
+	}
+	return undobuf
+}
+
+// newUndoCatalogs works out the state of the objects in the scope before and
+// after the migration file is run.
+func newUndoCatalogs(srcCatalog, destCatalog *Catalog, scope undoScope, dropObjects bool) *undoCatalogs {
+	u := &undoCatalogs{
+		srcCatalog:      srcCatalog,
+		destCatalog:     destCatalog,
+		srcCache:        NewCatalogCache(srcCatalog),
+		destCache:       NewCatalogCache(destCatalog),
+		keepFkeys:       scope.all,
+		keepTriggers:    scope.all,
+		dropObjects:     dropObjects,
+		skipIndexes:     make(map[[3]string]bool),
+		skipConstraints: make(map[[3]string]bool),
+		before: &Catalog{
+			Dialect:          srcCatalog.Dialect,
+			VersionNums:      srcCatalog.VersionNums,
+			CurrentSchema:    srcCatalog.CurrentSchema,
+			DefaultCollation: srcCatalog.DefaultCollation,
+			ExtensionsValid:  true,
+		},
+		after: &Catalog{
+			Dialect:          srcCatalog.Dialect,
+			VersionNums:      srcCatalog.VersionNums,
+			CurrentSchema:    srcCatalog.CurrentSchema,
+			DefaultCollation: srcCatalog.DefaultCollation,
+			ExtensionsValid:  true,
+		},
+	}
+	for _, index := range scope.skipIndexes {
+		u.skipIndexes[[3]string{index.TableSchema, index.TableName, index.IndexName}] = true
+	}
+	for _, constraint := range scope.skipConstraints {
+		u.skipConstraints[[3]string{constraint.TableSchema, constraint.TableName, constraint.ConstraintName}] = true
+	}
+	if scope.all {
+		for _, catalog := range []*Catalog{srcCatalog, destCatalog} {
+			for i := range catalog.Schemas {
+				schema := &catalog.Schemas[i]
+				if schema.Ignore {
+					continue
+				}
+				for j := range schema.Tables {
+					scope.tables = append(scope.tables, &schema.Tables[j])
+				}
+				for j := range schema.Views {
+					if catalog == srcCatalog {
+						scope.srcViews = append(scope.srcViews, &schema.Views[j])
+					} else {
+						scope.destViews = append(scope.destViews, &schema.Views[j])
+					}
+				}
+			}
+		}
+	}
+	for _, extension := range scope.extensions {
+		if slices.Contains(srcCatalog.Extensions, extension) {
+			u.before.Extensions = append(u.before.Extensions, extension)
+		}
+		if slices.Contains(destCatalog.Extensions, extension) {
+			u.after.Extensions = append(u.after.Extensions, extension)
+		}
+	}
+	for _, schemaName := range scope.schemas {
+		srcSchema := u.srcCache.GetSchema(srcCatalog, schemaName)
+		destSchema := u.destCache.GetSchema(destCatalog, schemaName)
+		if srcSchema != nil && destSchema == nil {
+			// A dropped schema is restored with everything in it.
+			schema := u.schema(u.before, schemaName)
+			for i := range srcSchema.Enums {
+				schema.Enums = append(schema.Enums, srcSchema.Enums[i])
+			}
+			for i := range srcSchema.Domains {
+				schema.Domains = append(schema.Domains, srcSchema.Domains[i])
+			}
+			for i := range srcSchema.Sequences {
+				schema.Sequences = append(schema.Sequences, srcSchema.Sequences[i])
+			}
+			for i := range srcSchema.Views {
+				schema.Views = append(schema.Views, srcSchema.Views[i])
+			}
+			for i := range srcSchema.Routines {
+				schema.Routines = append(schema.Routines, srcSchema.Routines[i])
+			}
+			for i := range srcSchema.Tables {
+				u.addTable(u.before, &srcSchema.Tables[i], nil)
+			}
+		} else if srcSchema == nil && destSchema != nil {
+			// The objects in a created schema are created (and undone) by
+			// the other files.
+			u.schema(u.after, schemaName)
+		}
+	}
+	for _, enum := range scope.enums {
+		srcEnum := u.srcCache.GetEnum(u.srcCache.GetSchema(srcCatalog, enum.EnumSchema), enum.EnumName)
+		destEnum := u.destCache.GetEnum(u.destCache.GetSchema(destCatalog, enum.EnumSchema), enum.EnumName)
+		beforeSchema, afterSchema := u.schema(u.before, enum.EnumSchema), u.schema(u.after, enum.EnumSchema)
+		if srcEnum != nil {
+			beforeSchema.Enums = append(beforeSchema.Enums, *srcEnum)
+		}
+		if destEnum != nil {
+			afterSchema.Enums = append(afterSchema.Enums, *destEnum)
+		}
+	}
+	for _, domain := range scope.domains {
+		srcDomain := u.srcCache.GetDomain(u.srcCache.GetSchema(srcCatalog, domain.DomainSchema), domain.DomainName)
+		destDomain := u.destCache.GetDomain(u.destCache.GetSchema(destCatalog, domain.DomainSchema), domain.DomainName)
+		beforeSchema, afterSchema := u.schema(u.before, domain.DomainSchema), u.schema(u.after, domain.DomainSchema)
+		if srcDomain != nil {
+			beforeSchema.Domains = append(beforeSchema.Domains, *srcDomain)
+		}
+		if destDomain != nil {
+			afterSchema.Domains = append(afterSchema.Domains, *destDomain)
+		}
+	}
+	for _, sequence := range scope.sequences {
+		srcSequence := u.srcCache.GetSequence(u.srcCache.GetSchema(srcCatalog, sequence.SequenceSchema), sequence.SequenceName)
+		destSequence := u.destCache.GetSequence(u.destCache.GetSchema(destCatalog, sequence.SequenceSchema), sequence.SequenceName)
+		beforeSchema, afterSchema := u.schema(u.before, sequence.SequenceSchema), u.schema(u.after, sequence.SequenceSchema)
+		if srcSequence != nil {
+			beforeSchema.Sequences = append(beforeSchema.Sequences, *srcSequence)
+		}
+		if destSequence != nil {
+			afterSchema.Sequences = append(afterSchema.Sequences, *destSequence)
+		}
+	}
+	for _, sequence := range scope.sequenceOwners {
+		beforeSchema, afterSchema := u.schema(u.before, sequence.SequenceSchema), u.schema(u.after, sequence.SequenceSchema)
+		unowned := *sequence
+		unowned.OwnedByTable, unowned.OwnedByColumn = "", ""
+		beforeSchema.Sequences = append(beforeSchema.Sequences, unowned)
+		afterSchema.Sequences = append(afterSchema.Sequences, *sequence)
+	}
+	for _, table := range scope.tables {
+		srcSchema := u.srcCache.GetSchema(srcCatalog, table.TableSchema)
+		destSchema := u.destCache.GetSchema(destCatalog, table.TableSchema)
+		destTable := u.destCache.GetTable(destSchema, table.TableName)
+		var srcTable *Table
+		if destTable != nil {
+			srcTable, _ = findSrcTable(u.srcCache, srcSchema, destTable)
+		} else {
+			srcTable = u.srcCache.GetTable(srcSchema, table.TableName)
+		}
+		u.schema(u.before, table.TableSchema)
+		u.schema(u.after, table.TableSchema)
+		if srcTable != nil && !srcTable.Ignore {
+			u.addTable(u.before, srcTable, destTable)
+		}
+		if destTable != nil && !destTable.Ignore {
+			u.addTable(u.after, destTable, srcTable)
+		}
+	}
+	for _, index := range scope.indexes {
+		u.table(u.before, index.TableSchema, index.TableName)
+		table := u.table(u.after, index.TableSchema, index.TableName)
+		table.Indexes = append(table.Indexes, *index)
+	}
+	for _, constraint := range scope.constraints {
+		u.table(u.before, constraint.TableSchema, constraint.TableName)
+		table := u.table(u.after, constraint.TableSchema, constraint.TableName)
+		table.Constraints = append(table.Constraints, *constraint)
+	}
+	for _, fkey := range scope.dropFkeys {
+		u.table(u.after, fkey.TableSchema, fkey.TableName)
+		table := u.table(u.before, fkey.TableSchema, fkey.TableName)
+		table.Constraints = append(table.Constraints, *fkey)
+	}
+	for _, view := range scope.srcViews {
+		schema := u.schema(u.before, view.ViewSchema)
+		schema.Views = append(schema.Views, u.view(u.before, view))
+		u.schema(u.after, view.ViewSchema)
+	}
+	for _, view := range scope.destViews {
+		u.schema(u.before, view.ViewSchema)
+		schema := u.schema(u.after, view.ViewSchema)
+		schema.Views = append(schema.Views, u.view(u.after, view))
+	}
+	for _, routine := range scope.srcRoutines {
+		schema := u.schema(u.before, routine.RoutineSchema)
+		schema.Routines = append(schema.Routines, *routine)
+		u.schema(u.after, routine.RoutineSchema)
+	}
+	for _, routine := range scope.destRoutines {
+		u.schema(u.before, routine.RoutineSchema)
+		schema := u.schema(u.after, routine.RoutineSchema)
+		schema.Routines = append(schema.Routines, *routine)
+	}
+	for _, trigger := range scope.srcTriggers {
+		u.triggerParent(u.after, trigger)
+		triggers := u.triggerParent(u.before, trigger)
+		*triggers = append(*triggers, *trigger)
+	}
+	for _, trigger := range scope.destTriggers {
+		u.triggerParent(u.before, trigger)
+		triggers := u.triggerParent(u.after, trigger)
+		*triggers = append(*triggers, *trigger)
+	}
+	if !dropObjects {
+		u.keepUndroppedObjects(scope.all)
+	}
+	u.reverseRenames()
+	return u
+}
+
+// schema returns the schema with the given name in the catalog, creating it
+// if it doesn't exist.
+func (u *undoCatalogs) schema(catalog *Catalog, schemaName string) *Schema {
+	if schema := u.findSchema(catalog, schemaName); schema != nil {
+		return schema
+	}
+	catalog.Schemas = append(catalog.Schemas, Schema{
+		SchemaName:     schemaName,
+		ViewsValid:     true,
+		EnumsValid:     true,
+		DomainsValid:   true,
+		SequencesValid: true,
+		RoutinesValid:  true,
+		TriggersValid:  true,
+	})
+	return &catalog.Schemas[len(catalog.Schemas)-1]
+}
+
+// findSchema returns the schema with the given name in the catalog, or nil
+// if it doesn't exist.
+func (u *undoCatalogs) findSchema(catalog *Catalog, schemaName string) *Schema {
+	for i := range catalog.Schemas {
+		if catalog.Schemas[i].SchemaName == schemaName {
+			return &catalog.Schemas[i]
+		}
+	}
+	return nil
+}
+
+// table returns the table with the given name in the catalog, creating an
+// empty table if it doesn't exist.
+func (u *undoCatalogs) table(catalog *Catalog, schemaName, tableName string) *Table {
+	schema := u.schema(catalog, schemaName)
+	for i := range schema.Tables {
+		if schema.Tables[i].TableName == tableName {
+			return &schema.Tables[i]
+		}
+	}
+	schema.Tables = append(schema.Tables, Table{
+		TableSchema: schemaName,
+		TableName:   tableName,
+	})
+	return &schema.Tables[len(schema.Tables)-1]
+}
+
+// addTable copies the table into the catalog. Foreign keys are left out
+// because they are added and dropped by their own files (unless the other
+// table also has them, in which case they are altered in place), and so are
+// the indexes and constraints created by a later file.
+func (u *undoCatalogs) addTable(catalog *Catalog, table, otherTable *Table) {
+	dest := u.table(catalog, table.TableSchema, table.TableName)
+	*dest = *table
+	dest.Columns = slices.Clone(table.Columns)
+	dest.Triggers = u.triggers(catalog, table.TableSchema, table.Triggers, otherTable)
+	dest.Constraints, dest.Indexes = nil, nil
+	for _, constraint := range table.Constraints {
+		if constraint.ConstraintType == FOREIGN_KEY && !u.keepFkeys && (otherTable == nil || !slices.ContainsFunc(otherTable.Constraints, func(c Constraint) bool {
+			return c.ConstraintType == FOREIGN_KEY && c.ConstraintName == constraint.ConstraintName
+		})) {
+			continue
+		}
+		if catalog == u.after && u.skipConstraints[[3]string{constraint.TableSchema, constraint.TableName, constraint.ConstraintName}] {
+			continue
+		}
+		dest.Constraints = append(dest.Constraints, constraint)
+	}
+	for _, index := range table.Indexes {
+		if catalog == u.after && u.skipIndexes[[3]string{index.TableSchema, index.TableName, index.IndexName}] {
+			continue
+		}
+		dest.Indexes = append(dest.Indexes, index)
+	}
+}
+
+// view returns a copy of the view to be added to the catalog. The view's
+// triggers are left out because they are created and dropped by their own
+// file.
+func (u *undoCatalogs) view(catalog *Catalog, view *View) View {
+	var otherTriggers []Trigger
+	if catalog == u.after {
+		otherView := u.srcCache.GetView(u.srcCache.GetSchema(u.srcCatalog, view.ViewSchema), view.ViewName)
+		if otherView != nil {
+			otherTriggers = otherView.Triggers
+		}
+	}
+	v := *view
+	v.Triggers = u.triggers(catalog, view.ViewSchema, view.Triggers, &Table{Triggers: otherTriggers})
+	return v
+}
+
+// triggers returns the triggers of a table or view to be added to the
+// catalog. Triggers are only kept if the file changes everything, in which
+// case the triggers of the other (src) table or view that the migration
+// does not drop are kept in the after catalog too.
+func (u *undoCatalogs) triggers(catalog *Catalog, schemaName string, triggers []Trigger, otherTable *Table) []Trigger {
+	if !u.keepTriggers {
+		return nil
+	}
+	triggers = slices.Clone(triggers)
+	if catalog != u.after || otherTable == nil {
+		return triggers
+	}
+	destSchema := u.destCache.GetSchema(u.destCatalog, schemaName)
+	if u.dropObjects && destSchema != nil && destSchema.TriggersValid {
+		return triggers
+	}
+	for _, trigger := range otherTable.Triggers {
+		if !slices.ContainsFunc(triggers, func(t Trigger) bool { return t.TriggerName == trigger.TriggerName }) {
+			triggers = append(triggers, trigger)
+		}
+	}
+	return triggers
+}
+
+// triggerParent returns the triggers of the table or view that the trigger
+// is on, creating the table or view in the catalog if it doesn't exist. A
+// created view is a copy of the view in the destCatalog (or srcCatalog)
+// without its triggers, so that it is the same in the before and after
+// catalogs.
+func (u *undoCatalogs) triggerParent(catalog *Catalog, trigger *Trigger) *[]Trigger {
+	if !trigger.IsViewTrigger {
+		return &u.table(catalog, trigger.TableSchema, trigger.TableName).Triggers
+	}
+	schema := u.schema(catalog, trigger.TableSchema)
+	for i := range schema.Views {
+		if schema.Views[i].ViewName == trigger.TableName {
+			return &schema.Views[i].Triggers
+		}
+	}
+	view := u.destCache.GetView(u.destCache.GetSchema(u.destCatalog, trigger.TableSchema), trigger.TableName)
+	if view == nil {
+		view = u.srcCache.GetView(u.srcCache.GetSchema(u.srcCatalog, trigger.TableSchema), trigger.TableName)
+	}
+	if view == nil {
+		view = &View{ViewSchema: trigger.TableSchema, ViewName: trigger.TableName}
+	}
+	v := *view
+	v.Triggers = nil
+	schema.Views = append(schema.Views, v)
+	return &schema.Views[len(schema.Views)-1].Triggers
+}
+
+// keepUndroppedObjects copies the objects that only exist before the
+// migration file into the after catalog. If objects are not dropped, they
+// still exist after the migration file is run.
+func (u *undoCatalogs) keepUndroppedObjects(all bool) {
+	beforeCache := NewCatalogCache(u.before)
+	for i := range u.before.Schemas {
+		beforeSchema := &u.before.Schemas[i]
+		afterSchema := u.schema(u.after, beforeSchema.SchemaName)
+		for j := range beforeSchema.Tables {
+			beforeTable := &beforeSchema.Tables[j]
+			afterTable := u.afterTable(afterSchema, beforeTable.TableName)
+			if afterTable == nil {
+				if all {
+					u.addTable(u.after, beforeTable, nil)
+					afterSchema = u.schema(u.after, beforeSchema.SchemaName)
+				}
+				continue
+			}
+			isRenamedColumn := renamedColumns(beforeCache, beforeTable, afterTable)
+			for _, column := range beforeTable.Columns {
+				if !isRenamedColumn[column.ColumnName] && !slices.ContainsFunc(afterTable.Columns, func(c Column) bool { return c.ColumnName == column.ColumnName }) {
+					afterTable.Columns = append(afterTable.Columns, column)
+				}
+			}
+			isRenamedConstraint := renamedConstraints(beforeCache, beforeTable, afterTable)
+			for _, constraint := range beforeTable.Constraints {
+				if !isRenamedConstraint[constraint.ConstraintName] && !slices.ContainsFunc(afterTable.Constraints, func(c Constraint) bool { return c.ConstraintName == constraint.ConstraintName }) {
+					afterTable.Constraints = append(afterTable.Constraints, constraint)
+				}
+			}
+			isRenamedIndex := renamedIndexes(beforeCache, beforeTable, afterTable)
+			for _, index := range beforeTable.Indexes {
+				if !isRenamedIndex[index.IndexName] && !slices.ContainsFunc(afterTable.Indexes, func(idx Index) bool { return idx.IndexName == index.IndexName }) {
+					afterTable.Indexes = append(afterTable.Indexes, index)
+				}
+			}
+		}
+		if !all {
+			continue
+		}
+		for _, view := range beforeSchema.Views {
+			if !slices.ContainsFunc(afterSchema.Views, func(v View) bool { return v.ViewName == view.ViewName }) {
+				afterSchema.Views = append(afterSchema.Views, view)
+			}
+		}
+	}
+}
+
+// afterTable returns the table in the after schema that corresponds to the
+// table in the before catalog with the given name.
+func (u *undoCatalogs) afterTable(afterSchema *Schema, tableName string) *Table {
+	for i := range afterSchema.Tables {
+		if afterSchema.Tables[i].TableName == tableName {
+			return &afterSchema.Tables[i]
+		}
+	}
+	for i := range afterSchema.Tables {
+		if afterSchema.Tables[i].RenamedFrom == tableName {
+			return &afterSchema.Tables[i]
+		}
+	}
+	return nil
+}
+
+// reverseRenames moves the renamedfrom hints from the after catalog to the
+// before catalog, so that the down script renames the tables and columns
+// back instead of dropping and recreating them.
+func (u *undoCatalogs) reverseRenames() {
+	for i := range u.before.Schemas {
+		for j := range u.before.Schemas[i].Tables {
+			beforeTable := &u.before.Schemas[i].Tables[j]
+			beforeTable.RenamedFrom = ""
+			for k := range beforeTable.Columns {
+				beforeTable.Columns[k].RenamedFrom = ""
+			}
+		}
+	}
+	for i := range u.after.Schemas {
+		afterSchema := &u.after.Schemas[i]
+		beforeSchema := u.findSchema(u.before, afterSchema.SchemaName)
+		if beforeSchema == nil {
+			continue
+		}
+		for j := range afterSchema.Tables {
+			afterTable := &afterSchema.Tables[j]
+			var beforeTable *Table
+			for k := range beforeSchema.Tables {
+				if beforeSchema.Tables[k].TableName == afterTable.TableName {
+					beforeTable = &beforeSchema.Tables[k]
+				}
+			}
+			if beforeTable == nil && afterTable.RenamedFrom != "" {
+				for k := range beforeSchema.Tables {
+					if beforeSchema.Tables[k].TableName == afterTable.RenamedFrom {
+						beforeTable = &beforeSchema.Tables[k]
+						beforeTable.RenamedFrom = afterTable.TableName
+					}
+				}
+			}
+			afterTable.RenamedFrom = ""
+			for k := range afterTable.Columns {
+				afterColumn := &afterTable.Columns[k]
+				if beforeTable != nil && afterColumn.RenamedFrom != "" && !slices.ContainsFunc(beforeTable.Columns, func(c Column) bool { return c.ColumnName == afterColumn.ColumnName }) {
+					for l := range beforeTable.Columns {
+						if beforeTable.Columns[l].ColumnName == afterColumn.RenamedFrom {
+							beforeTable.Columns[l].RenamedFrom = afterColumn.ColumnName
+						}
+					}
+				}
+				afterColumn.RenamedFrom = ""
+			}
+		}
+	}
+}
+
+// lossyWarnings returns a warning for every table and column that the down
+// script recreates without its data.
+func (u *undoCatalogs) lossyWarnings(downfile string) []Warning {
+	var warnings []Warning
+	for i := range u.before.Schemas {
+		beforeSchema := &u.before.Schemas[i]
+		afterSchema := u.findSchema(u.after, beforeSchema.SchemaName)
+		for j := range beforeSchema.Tables {
+			beforeTable := &beforeSchema.Tables[j]
+			tableName := beforeTable.TableName
+			if beforeTable.TableSchema != "" && beforeTable.TableSchema != u.before.CurrentSchema {
+				tableName = beforeTable.TableSchema + "." + tableName
+			}
+			var afterTable *Table
+			if afterSchema != nil {
+				for k := range afterSchema.Tables {
+					name := afterSchema.Tables[k].TableName
+					if name == beforeTable.TableName || name == beforeTable.RenamedFrom {
+						afterTable = &afterSchema.Tables[k]
+					}
+				}
+			}
+			if afterTable == nil {
+				if len(beforeTable.Columns) > 0 {
+					warnings = append(warnings, Warning{
+						Code:     WarnDownDataLost,
+						Severity: SeverityLow,
+						Object:   tableName,
+						Message:  fmt.Sprintf("%s: its data is lost, %s recreates the table empty", tableName, downfile),
+					})
+				}
+				continue
+			}
+			for _, column := range beforeTable.Columns {
+				if !slices.ContainsFunc(afterTable.Columns, func(c Column) bool {
+					return c.ColumnName == column.ColumnName || c.ColumnName == column.RenamedFrom
+				}) {
+					warnings = append(warnings, Warning{
+						Code:     WarnDownDataLost,
+						Severity: SeverityLow,
+						Object:   tableName + "." + column.ColumnName,
+						Message:  fmt.Sprintf("%s: column %q: its data is lost, %s recreates the column empty", tableName, column.ColumnName, downfile),
+					})
+				}
+			}
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,273 @@
+package ddl
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/blink-io/sqddl/internal/testutil"
+)
+
+func TestDownMigration(t *testing.T) {
+	type TT struct {
+		dialect      string
+		dir          string
+		wantDown     map[string][]string
+		wantWarnings []string
+	}
+	tests := []TT{{
+		dialect: "postgres",
+		dir:     "testdata/postgres_drop",
+		wantDown: map[string][]string{
+			"drop_01_drop_movie_category_fkeys.down.sql": {
+				"ALTER TABLE movie ADD CONSTRAINT movie_category_fkey FOREIGN KEY (category) REFERENCES category (category) NOT VALID;",
+				"ALTER TABLE movie VALIDATE CONSTRAINT movie_category_fkey;",
+			},
+			"drop_02_alter_movie.down.sql": {
+				"ALTER TABLE movie ADD COLUMN metadata JSONB;",
+				"CREATE INDEX CONCURRENTLY movie_category_idx ON movie (category);",
+				"ALTER TABLE movie ADD CONSTRAINT movie_title_key UNIQUE USING INDEX movie_title_key;",
+			},
+		},
+		wantWarnings: []string{
+			`movie: column "metadata": its data is lost, drop_02_alter_movie.down.sql recreates the column empty`,
+		},
+	}, {
+		dialect: "postgres",
+		dir:     "testdata/postgres_alter",
+		wantDown: map[string][]string{
+			"alter_01_alter_person.down.sql": {
+				"ALTER TABLE person ALTER COLUMN person_id TYPE INT;",
+				"ALTER TABLE person ALTER COLUMN email DROP NOT NULL;",
+				"ALTER TABLE person ALTER COLUMN password SET DEFAULT 'password';",
+				"ALTER TABLE person ALTER CONSTRAINT person_country_id_fkey NOT DEFERRABLE;",
+			},
+			"alter_03_alter_country.down.sql": {
+				`ALTER TABLE country ALTER COLUMN country TYPE TEXT COLLATE "default";`,
+			},
+		},
+	}, {
+		dialect: "postgres",
+		dir:     "testdata/postgres_rename",
+		wantDown: map[string][]string{
+			"rename_01_tables.down.sql": {
+				"DROP TABLE IF EXISTS invoices;",
+			},
+			"rename_02_alter_clients.down.sql": {
+				"ALTER TABLE clients RENAME TO customers;",
+				"ALTER TABLE customers RENAME COLUMN name TO full_name;",
+			},
+		},
+	}, {
+		dialect: "mysql",
+		dir:     "testdata/mysql_drop",
+		wantDown: map[string][]string{
+			"drop_01_drop_movie_category_fkey.down.sql": {
+				"ALTER TABLE movie ADD CONSTRAINT movie_category_fkey FOREIGN KEY (category) REFERENCES category (category);",
+			},
+			"drop_03_alter_movie.down.sql": {
+				"ADD COLUMN metadata JSON",
+				",ADD INDEX movie_category_idx (category)",
+				",ADD PRIMARY KEY (movie_id)",
+			},
+		},
+		wantWarnings: []string{
+			`movie: column "metadata": its data is lost, drop_03_alter_movie.down.sql recreates the column empty`,
+		},
+	}, {
+		dialect: "sqlserver",
+		dir:     "testdata/sqlserver_drop",
+		wantDown: map[string][]string{
+			"drop_02_alter_dbo_movie.down.sql": {
+				"ALTER TABLE dbo.movie ADD metadata NVARCHAR(MAX);",
+				"CREATE INDEX movie_category_idx ON movie (category);",
+				"ALTER TABLE dbo.movie ADD CONSTRAINT movie_title_key UNIQUE (title);",
+			},
+		},
+	}, {
+		dialect: "sqlite",
+		dir:     "testdata/sqlite_misc",
+		wantDown: map[string][]string{
+			"misc.down.sql": {
+				"DROP TABLE country;",
+				"CREATE TABLE residence (",
+				"ALTER TABLE post ADD COLUMN metadata TEXT;",
+				"CREATE INDEX post_metadata_idx ON post (metadata);",
+			},
+		},
+		wantWarnings: []string{
+			`post: column "metadata": its data is lost, misc.down.sql recreates the column empty`,
+			`residence: its data is lost, misc.down.sql recreates the table empty`,
+		},
+	}}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.dir, func(t *testing.T) {
+			t.Parallel()
+			generateCmd, err := GenerateCommand(
+				"-src", filepath.Join(tt.dir, "src.go.txt"),
+				"-dest", filepath.Join(tt.dir, "dest.go.txt"),
+				"-prefix", strings.TrimPrefix(tt.dir, "testdata/"+tt.dialect+"_"),
+				"-drop-objects",
+				"-dialect", tt.dialect,
+			)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			files, warnings, err := generateCmd.Results()
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			gotDown := make(map[string]string)
+			var prevName string
+			for _, file := range files {
+				fileinfo, err := file.Stat()
+				if err != nil {
+					t.Fatal(testutil.Callers(), err)
+				}
+				b, err := io.ReadAll(file)
+				if err != nil {
+					t.Fatal(testutil.Callers(), err)
+				}
+				name := fileinfo.Name()
+				if strings.HasSuffix(name, ".undo.sql") {
+					continue
+				}
+				if strings.HasSuffix(name, ".down.sql") {
+					// Every down script comes right after its migration file
+					// (and the migration file's undo script).
+					wantName := downFilename(prevName)
+					if name != wantName {
+						t.Errorf(testutil.Callers()+" down script %s does not follow its migration file (got %s)", wantName, name)
+					}
+					gotDown[name] = string(b)
+				}
+				prevName = name
+			}
+			for name, wantStmts := range tt.wantDown {
+				content, ok := gotDown[name]
+				if !ok {
+					t.Errorf(testutil.Callers()+" missing down script %s", name)
+					continue
+				}
+				for _, stmt := range wantStmts {
+					if !strings.Contains(content, stmt) {
+						t.Errorf(testutil.Callers()+" %s: missing %q in\n%s", name, stmt, content)
+					}
+				}
+			}
+			for _, wantWarning := range tt.wantWarnings {
+				found := false
+				for _, warning := range warnings {
+					if warning.Message == wantWarning {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf(testutil.Callers()+" missing warning %q in %q", wantWarning, warnings)
+				}
+			}
+		})
+	}
+
+	t.Run("routines", func(t *testing.T) {
+		t.Parallel()
+		fsys := fstest.MapFS{
+			"schema.sql": {Data: []byte("CREATE TABLE actor (actor_id INT, last_update TIMESTAMPTZ);")},
+			"src/last_update_trg.sql": {Data: []byte("CREATE FUNCTION last_update_trg() RETURNS trigger AS $$ BEGIN\n" +
+				"    NEW.last_update = NOW();\n    RETURN NEW;\nEND; $$ LANGUAGE plpgsql;")},
+			"src/actor_count.sql": {Data: []byte("CREATE FUNCTION actor_count() RETURNS int AS $$ SELECT count(*) FROM actor $$ LANGUAGE sql;")},
+			"src/actor.sql":       {Data: []byte("CREATE TRIGGER actor_last_update_trg BEFORE UPDATE ON actor FOR EACH ROW EXECUTE FUNCTION last_update_trg();")},
+			"dest/last_update_trg.sql": {Data: []byte("CREATE FUNCTION last_update_trg() RETURNS trigger AS $$ BEGIN\n" +
+				"    NEW.last_update = CURRENT_TIMESTAMP;\n    RETURN NEW;\nEND; $$ LANGUAGE plpgsql;")},
+			"dest/actor.sql": {Data: []byte("CREATE TRIGGER actor_last_update_trg BEFORE INSERT OR UPDATE ON actor FOR EACH ROW EXECUTE FUNCTION last_update_trg();")},
+		}
+		srcCatalog := &Catalog{Dialect: DialectPostgres, CurrentSchema: "public"}
+		err := writeCatalog(srcCatalog, fsys, "sqddl_history", "schema.sql")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		err = writeRoutines(srcCatalog, fsys, "src")
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		generateCmd := &GenerateCmd{
+			Dialect:     DialectPostgres,
+			SrcCatalog:  srcCatalog,
+			DirFS:       fsys,
+			Filenames:   []string{"schema.sql"},
+			RoutinesDir: "dest",
+			DropObjects: true,
+			Prefix:      "routines",
+		}
+		files, _, err := generateCmd.Results()
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		gotFiles := make(map[string]string)
+		for _, file := range files {
+			fileinfo, err := file.Stat()
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			b, err := io.ReadAll(file)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			gotFiles[fileinfo.Name()] = string(b)
+		}
+		wantFiles := map[string]string{
+			"routines_01_drop_triggers.sql":      "DROP TRIGGER IF EXISTS actor_last_update_trg ON actor;\n",
+			"routines_01_drop_triggers.down.sql": "CREATE TRIGGER actor_last_update_trg BEFORE UPDATE ON actor FOR EACH ROW EXECUTE FUNCTION last_update_trg();\n",
+			"routines_02_routines.sql": "CREATE OR REPLACE FUNCTION last_update_trg() RETURNS trigger AS $$ BEGIN\n" +
+				"    NEW.last_update = CURRENT_TIMESTAMP;\n    RETURN NEW;\nEND; $$ LANGUAGE plpgsql;\n",
+			"routines_02_routines.down.sql": "CREATE OR REPLACE FUNCTION last_update_trg() RETURNS trigger AS $$ BEGIN\n" +
+				"    NEW.last_update = NOW();\n    RETURN NEW;\nEND; $$ LANGUAGE plpgsql;\n",
+			"routines_03_triggers.sql":           "CREATE TRIGGER actor_last_update_trg BEFORE INSERT OR UPDATE ON actor FOR EACH ROW EXECUTE FUNCTION last_update_trg();\n",
+			"routines_03_triggers.down.sql":      "DROP TRIGGER IF EXISTS actor_last_update_trg ON actor;\n",
+			"routines_04_drop_routines.sql":      "DROP FUNCTION IF EXISTS actor_count();\n",
+			"routines_04_drop_routines.down.sql": "CREATE FUNCTION actor_count() RETURNS int AS $$ SELECT count(*) FROM actor $$ LANGUAGE sql;\n",
+		}
+		if diff := testutil.Diff(gotFiles, wantFiles); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+	})
+
+	for _, flag := range []string{"-no-undo", "-no-down"} {
+		flag := flag
+		t.Run(flag[1:], func(t *testing.T) {
+			t.Parallel()
+			generateCmd, err := GenerateCommand(
+				"-src", "testdata/postgres_drop/src.go.txt",
+				"-dest", "testdata/postgres_drop/dest.go.txt",
+				"-prefix", "drop",
+				"-drop-objects",
+				"-dialect", "postgres",
+				flag,
+			)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			files, warnings, err := generateCmd.Results()
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			for _, file := range files {
+				fileinfo, err := file.Stat()
+				if err != nil {
+					t.Fatal(testutil.Callers(), err)
+				}
+				if strings.HasSuffix(fileinfo.Name(), ".down.sql") {
+					t.Errorf(testutil.Callers()+" unexpected down script %s", fileinfo.Name())
+				}
+			}
+			for _, warning := range warnings {
+				if warning.Code == WarnDownDataLost {
+					t.Errorf(testutil.Callers()+" unexpected warning %q", warning)
+				}
+			}
+		})
+	}
+}
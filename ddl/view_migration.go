@@ -0,0 +1,911 @@
+package ddl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+)
+
+// viewMigration contains the view changes of a migration. Views that depend
+// on a table being changed incompatibly (a dropped table, a dropped column or
+// a changed column type) are dropped before the table changes and recreated
+// after them.
+type viewMigration struct {
+	dialect       string
+	currentSchema string
+
+	// 1. Drop the views, dependent views first. This happens before any table
+	// is changed.
+	dropViews []*View
+
+	// 2. Create (or replace) the views, dependencies first. This happens
+	// after every table has been changed. replaceViews maps each replaced
+	// view to its current definition.
+	createViews  []*View
+	replaceViews map[*View]*View
+
+	// 3. Drop and create the indexes of existing materialized views.
+	// indexedViews are the {srcView, destView} pairs of the materialized
+	// views whose indexes are changed.
+	dropViewIndexes   []*Index
+	createViewIndexes []*Index
+	indexedViews      [][2]*View
+
+	// 4. Refresh the existing materialized views whose tables were altered.
+	refreshViews []*View
+}
+
+// viewNode is a view together with the tables and views it references.
+type viewNode struct {
+	view   *View
+	tables [][2]string
+	views  [][2]string
+}
+
+func newViewMigration(dialect string, srcCatalog, destCatalog *Catalog, dropObjects bool) viewMigration {
+	vm := viewMigration{
+		dialect:       dialect,
+		currentSchema: srcCatalog.CurrentSchema,
+		replaceViews:  make(map[*View]*View),
+	}
+	srcCache, destCache := NewCatalogCache(srcCatalog), NewCatalogCache(destCatalog)
+	srcKeys, srcNodes := newViewNodes(dialect, srcCatalog)
+	destKeys, destNodes := newViewNodes(dialect, destCatalog)
+
+	// Find the tables that are changed in ways that break dependent views
+	// (changedTables) or that change the data a view would see (alteredTables).
+	changedTables := make(map[[2]string]bool)
+	alteredTables := make(map[[2]string]bool)
+	for i := range srcCatalog.Schemas {
+		srcSchema := &srcCatalog.Schemas[i]
+		if srcSchema.Ignore {
+			continue
+		}
+		destSchema := destCache.GetSchema(destCatalog, srcSchema.SchemaName)
+		for j := range srcSchema.Tables {
+			srcTable := &srcSchema.Tables[j]
+			if srcTable.Ignore {
+				continue
+			}
+			key := viewKey(srcTable.TableSchema, srcTable.TableName)
+			var destTable *Table
+			if destSchema != nil && !destSchema.Ignore {
+				destTable = destCache.GetTable(destSchema, srcTable.TableName)
+			}
+			if destTable == nil || destTable.Ignore {
+				if dropObjects {
+					changedTables[key] = true
+				}
+				continue
+			}
+			if len(destTable.Columns) != len(srcTable.Columns) {
+				alteredTables[key] = true
+			}
+			for k := range srcTable.Columns {
+				srcColumn := &srcTable.Columns[k]
+				if srcColumn.Ignore {
+					continue
+				}
+				destColumn := destCache.GetColumn(destTable, srcColumn.ColumnName)
+				if destColumn == nil || destColumn.Ignore {
+					if dropObjects {
+						changedTables[key] = true
+					}
+					continue
+				}
+				srcType, srcArg1, srcArg2 := normalizeColumnType(dialect, srcColumn.ColumnType)
+				destType, destArg1, destArg2 := normalizeColumnType(dialect, destColumn.ColumnType)
+				if [3]string{srcType, srcArg1, srcArg2} != [3]string{destType, destArg1, destArg2} {
+					// SQLite only rebuilds a table to change its column types
+					// when dropping objects.
+					if dialect != DialectSQLite || dropObjects {
+						changedTables[key] = true
+					}
+					continue
+				}
+				if normalizeColumnDefault(dialect, srcColumn.ColumnDefault) != normalizeColumnDefault(dialect, destColumn.ColumnDefault) ||
+					srcColumn.IsNotNull != destColumn.IsNotNull {
+					alteredTables[key] = true
+				}
+			}
+		}
+	}
+
+	// Decide which of the existing views have to be dropped, recreated or
+	// replaced. targetNodes holds the definition each view should end up
+	// with.
+	dropped := make(map[[2]string]bool)
+	droppedWithSchema := make(map[[2]string]bool)
+	recreated := make(map[[2]string]bool)
+	replaced := make(map[[2]string]bool)
+	targetNodes := make(map[[2]string]*viewNode)
+	for key, node := range destNodes {
+		targetNodes[key] = node
+	}
+	for _, key := range srcKeys {
+		srcNode := srcNodes[key]
+		destNode := destNodes[key]
+		if destNode == nil {
+			destSchema := destCache.GetSchema(destCatalog, srcNode.view.ViewSchema)
+			if destSchema == nil || destSchema.ViewsValid {
+				if dropObjects {
+					dropped[key] = true
+					// Dropping a schema already drops the views inside it
+					// (SQLite has no schemas to drop).
+					droppedWithSchema[key] = destSchema == nil && dialect != DialectSQLite
+					continue
+				}
+			}
+			// The view is not known in the destination catalog, keep its
+			// current definition.
+			targetNodes[key] = srcNode
+		} else if destNode.view.SQL == "" {
+			// The view's definition is unknown, keep its current definition.
+			targetNodes[key] = srcNode
+		} else if viewSQLIsChanged(dialect, srcNode.view, destNode.view) ||
+			srcNode.view.IsMaterialized != destNode.view.IsMaterialized {
+			if canReplaceView(dialect, srcNode.view, destNode.view) {
+				replaced[key] = true
+			} else {
+				recreated[key] = true
+			}
+		}
+		for _, table := range srcNode.tables {
+			if changedTables[table] {
+				recreated[key] = true
+				delete(replaced, key)
+				break
+			}
+		}
+	}
+	// A view that depends on a dropped or recreated view must be recreated
+	// too.
+	for changed := true; changed; {
+		changed = false
+		for _, key := range srcKeys {
+			if dropped[key] || recreated[key] {
+				continue
+			}
+			for _, dependency := range srcNodes[key].views {
+				if dropped[dependency] || recreated[dependency] {
+					recreated[key] = true
+					delete(replaced, key)
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	// DROP VIEW.
+	sortedSrcKeys := sortViews(srcKeys, srcNodes)
+	for i := len(sortedSrcKeys) - 1; i >= 0; i-- {
+		key := sortedSrcKeys[i]
+		if (dropped[key] && !droppedWithSchema[key]) || recreated[key] {
+			vm.dropViews = append(vm.dropViews, srcNodes[key].view)
+		}
+	}
+
+	// CREATE VIEW.
+	targetKeys := make([][2]string, 0, len(destKeys)+len(srcKeys))
+	for _, key := range destKeys {
+		targetKeys = append(targetKeys, key)
+	}
+	for _, key := range srcKeys {
+		if destNodes[key] == nil && targetNodes[key] != nil {
+			targetKeys = append(targetKeys, key)
+		}
+	}
+	for _, key := range sortViews(targetKeys, targetNodes) {
+		node := targetNodes[key]
+		if node == nil || node.view.SQL == "" {
+			continue
+		}
+		isNew := srcNodes[key] == nil
+		if !isNew && !recreated[key] && !replaced[key] {
+			continue
+		}
+		vm.createViews = append(vm.createViews, node.view)
+		if replaced[key] {
+			vm.replaceViews[node.view] = srcNodes[key].view
+		}
+	}
+
+	// Materialized view indexes and refreshes only apply to existing
+	// materialized views that are not being recreated.
+	if dialect != DialectPostgres {
+		return vm
+	}
+	for _, key := range srcKeys {
+		srcNode, destNode := srcNodes[key], destNodes[key]
+		if dropped[key] || recreated[key] || !srcNode.view.IsMaterialized {
+			continue
+		}
+		if destNode != nil {
+			numIndexChanges := len(vm.dropViewIndexes) + len(vm.createViewIndexes)
+			for i := range destNode.view.Indexes {
+				destIndex := &destNode.view.Indexes[i]
+				if destIndex.Ignore {
+					continue
+				}
+				srcIndex := srcCache.GetViewIndex(srcNode.view, destIndex.IndexName)
+				if srcIndex == nil || srcIndex.Ignore {
+					vm.createViewIndexes = append(vm.createViewIndexes, destIndex)
+					continue
+				}
+				if !indexesAreEqual(dialect, srcIndex, destIndex) {
+					vm.dropViewIndexes = append(vm.dropViewIndexes, srcIndex)
+					vm.createViewIndexes = append(vm.createViewIndexes, destIndex)
+				}
+			}
+			if dropObjects {
+				for i := range srcNode.view.Indexes {
+					srcIndex := &srcNode.view.Indexes[i]
+					if srcIndex.Ignore {
+						continue
+					}
+					if destCache.GetViewIndex(destNode.view, srcIndex.IndexName) == nil {
+						vm.dropViewIndexes = append(vm.dropViewIndexes, srcIndex)
+					}
+				}
+			}
+			if len(vm.dropViewIndexes)+len(vm.createViewIndexes) > numIndexChanges {
+				vm.indexedViews = append(vm.indexedViews, [2]*View{srcNode.view, destNode.view})
+			}
+		}
+		for _, table := range srcNode.tables {
+			if alteredTables[table] {
+				vm.refreshViews = append(vm.refreshViews, srcNode.view)
+				break
+			}
+		}
+	}
+	return vm
+}
+
+// newViewNodes returns the views in the catalog (keyed by viewKey) together
+// with the tables and views each of them references. The keys are returned
+// in catalog order.
+func newViewNodes(dialect string, catalog *Catalog) (keys [][2]string, nodes map[[2]string]*viewNode) {
+	nodes = make(map[[2]string]*viewNode)
+	tables := make(map[[2]string]bool)
+	for i := range catalog.Schemas {
+		schema := &catalog.Schemas[i]
+		if schema.Ignore {
+			continue
+		}
+		for j := range schema.Tables {
+			if !schema.Tables[j].Ignore {
+				tables[viewKey(schema.Tables[j].TableSchema, schema.Tables[j].TableName)] = true
+			}
+		}
+		for j := range schema.Views {
+			view := &schema.Views[j]
+			if view.Ignore {
+				continue
+			}
+			key := viewKey(view.ViewSchema, view.ViewName)
+			keys = append(keys, key)
+			nodes[key] = &viewNode{view: view}
+		}
+	}
+	for _, key := range keys {
+		node := nodes[key]
+		seen := make(map[[2]string]bool)
+		for _, stmt := range splitViewSQL(dialect, node.view.SQL) {
+			for _, token := range stmt.tokens {
+				if token == "" || token[0] == '\'' || token[0] == '$' || !(isIdentifierChar(token[0]) || token[0] == '"' || token[0] == '`' || token[0] == '[') {
+					continue
+				}
+				// The token may be a table name (actor, public.actor) or a
+				// column name (actor.actor_id, public.actor.actor_id), so
+				// look at every plausible qualified name.
+				parts := splitIdentifier(token)
+				var candidates [][2]string
+				for n := len(parts); n > 0 && n >= len(parts)-1; n-- {
+					if n >= 2 {
+						candidates = append(candidates, viewKey(parts[n-2], parts[n-1]))
+					}
+					candidates = append(candidates, viewKey(node.view.ViewSchema, parts[n-1]))
+					candidates = append(candidates, viewKey(catalog.CurrentSchema, parts[n-1]))
+				}
+				for _, candidate := range candidates {
+					if seen[candidate] || candidate == key {
+						continue
+					}
+					if tables[candidate] {
+						seen[candidate] = true
+						node.tables = append(node.tables, candidate)
+					} else if nodes[candidate] != nil {
+						seen[candidate] = true
+						node.views = append(node.views, candidate)
+					}
+				}
+			}
+		}
+	}
+	return keys, nodes
+}
+
+// viewKey returns the case-insensitive key used to identify a table or view.
+func viewKey(schemaName, name string) [2]string {
+	return [2]string{strings.ToLower(schemaName), strings.ToLower(name)}
+}
+
+// sortViews sorts the keys so that every view comes after the views it
+// references. Views that are part of a reference cycle are kept in their
+// original order.
+func sortViews(keys [][2]string, nodes map[[2]string]*viewNode) [][2]string {
+	sorted := make([][2]string, 0, len(keys))
+	visited := make(map[[2]string]bool)
+	var visit func(key [2]string)
+	visit = func(key [2]string) {
+		if visited[key] {
+			return
+		}
+		visited[key] = true
+		if node := nodes[key]; node != nil {
+			for _, dependency := range node.views {
+				visit(dependency)
+			}
+		}
+		sorted = append(sorted, key)
+	}
+	for _, key := range keys {
+		visit(key)
+	}
+	return sorted
+}
+
+// canReplaceView reports if the src view can be replaced in-place by the dest
+// view instead of being dropped and recreated.
+func canReplaceView(dialect string, srcView, destView *View) bool {
+	switch dialect {
+	case DialectPostgres:
+		// CREATE OR REPLACE VIEW may only add new columns to the end of the
+		// column list, the existing columns must keep their names and types.
+		if srcView.IsMaterialized || destView.IsMaterialized {
+			return false
+		}
+		if len(srcView.Columns) == 0 || len(destView.Columns) < len(srcView.Columns) {
+			return false
+		}
+		for i, columnName := range srcView.Columns {
+			if columnName != destView.Columns[i] {
+				return false
+			}
+			if i < len(srcView.ColumnTypes) && i < len(destView.ColumnTypes) {
+				srcType, srcArg1, srcArg2 := normalizeColumnType(dialect, srcView.ColumnTypes[i])
+				destType, destArg1, destArg2 := normalizeColumnType(dialect, destView.ColumnTypes[i])
+				if [3]string{srcType, srcArg1, srcArg2} != [3]string{destType, destArg1, destArg2} {
+					return false
+				}
+			}
+		}
+		return true
+	case DialectMySQL, DialectSQLServer:
+		return true
+	default:
+		return false
+	}
+}
+
+// indexesAreEqual reports if two indexes of the same name have the same
+// definition.
+func indexesAreEqual(dialect string, srcIndex, destIndex *Index) bool {
+	if srcIndex.IsUnique != destIndex.IsUnique || len(srcIndex.Columns) != len(destIndex.Columns) {
+		return false
+	}
+	if !strings.EqualFold(srcIndex.IndexType, destIndex.IndexType) && srcIndex.IndexType != "" && destIndex.IndexType != "" {
+		return false
+	}
+	for i := range srcIndex.Columns {
+		if srcIndex.Columns[i] != destIndex.Columns[i] {
+			return false
+		}
+		srcIsDescending := i < len(srcIndex.Descending) && srcIndex.Descending[i]
+		destIsDescending := i < len(destIndex.Descending) && destIndex.Descending[i]
+		if srcIsDescending != destIsDescending {
+			return false
+		}
+		if dialect == DialectPostgres {
+			var srcOpclass, destOpclass string
+			if i < len(srcIndex.Opclasses) && srcIndex.Opclasses[i] != "" {
+				srcOpclass = srcIndex.Opclasses[i]
+			}
+			if i < len(destIndex.Opclasses) && destIndex.Opclasses[i] != "" {
+				destOpclass = destIndex.Opclasses[i]
+			}
+			if srcOpclass != destOpclass {
+				return false
+			}
+		}
+	}
+	if !slices.Equal(srcIndex.IncludeColumns, destIndex.IncludeColumns) {
+		return false
+	}
+	return normalizeIndexPredicate(dialect, srcIndex.Predicate) == normalizeIndexPredicate(dialect, destIndex.Predicate)
+}
+
+// checksAreEqual reports if two CHECK constraints of the same name have the
+// same expression.
+func checksAreEqual(dialect string, srcConstraint, destConstraint *Constraint) bool {
+	return normalizeIndexPredicate(dialect, srcConstraint.CheckExpr) == normalizeIndexPredicate(dialect, destConstraint.CheckExpr)
+}
+
+// exclusionsAreEqual reports if two EXCLUDE constraints of the same name have
+// the same elements, operators, index type and predicate.
+func exclusionsAreEqual(dialect string, srcConstraint, destConstraint *Constraint) bool {
+	if len(srcConstraint.Columns) != len(destConstraint.Columns) {
+		return false
+	}
+	// btree is the index type Postgres uses if none is given.
+	srcIndexType, destIndexType := srcConstraint.ExclusionIndexType, destConstraint.ExclusionIndexType
+	if srcIndexType == "" {
+		srcIndexType = "btree"
+	}
+	if destIndexType == "" {
+		destIndexType = "btree"
+	}
+	if !strings.EqualFold(srcIndexType, destIndexType) {
+		return false
+	}
+	for i := range srcConstraint.Columns {
+		if normalizeIndexPredicate(dialect, srcConstraint.Columns[i]) != normalizeIndexPredicate(dialect, destConstraint.Columns[i]) {
+			return false
+		}
+		var srcOperator, destOperator string
+		if i < len(srcConstraint.ExclusionOperators) {
+			srcOperator = strings.TrimSpace(srcConstraint.ExclusionOperators[i])
+		}
+		if i < len(destConstraint.ExclusionOperators) {
+			destOperator = strings.TrimSpace(destConstraint.ExclusionOperators[i])
+		}
+		if srcOperator != destOperator {
+			return false
+		}
+	}
+	return normalizeIndexPredicate(dialect, srcConstraint.ExclusionPredicate) == normalizeIndexPredicate(dialect, destConstraint.ExclusionPredicate)
+}
+
+// normalizeIndexPredicate normalizes an index predicate for comparison. On
+// top of what normalizeViewSQL does, it also strips the type casts and the
+// redundant parentheses that Postgres and SQL Server add to predicates they
+// report back. Parentheses that change how the predicate groups are kept.
+func normalizeIndexPredicate(dialect string, predicate string) string {
+	var tokens []string
+	for _, stmt := range splitViewSQL(dialect, predicate) {
+		for i := 0; i < len(stmt.tokens); i++ {
+			token := stmt.tokens[i]
+			if token == ":" && i+2 < len(stmt.tokens) && stmt.tokens[i+1] == ":" {
+				// Skip the cast e.g. ::text, ::character varying, ::numeric(10,2)
+				// or ::text[].
+				i += 2
+				if i+1 < len(stmt.tokens) && (strings.EqualFold(stmt.tokens[i+1], "varying") || strings.EqualFold(stmt.tokens[i+1], "precision")) {
+					i++
+				}
+				if i+1 < len(stmt.tokens) && stmt.tokens[i+1] == "(" {
+					for i+1 < len(stmt.tokens) && stmt.tokens[i] != ")" {
+						i++
+					}
+				}
+				for i+2 < len(stmt.tokens) && stmt.tokens[i+1] == "[" && stmt.tokens[i+2] == "]" {
+					i += 2
+				}
+				continue
+			}
+			if token[0] == '\'' || token[0] == '$' || token == "(" || token == ")" || token == "[" || token == "]" {
+				tokens = append(tokens, token)
+				continue
+			}
+			var b strings.Builder
+			for j, part := range splitIdentifier(token) {
+				if j > 0 {
+					b.WriteString(".")
+				}
+				b.WriteString(strings.ToLower(part))
+			}
+			tokens = append(tokens, b.String())
+		}
+	}
+	if dialect == DialectPostgres {
+		tokens = postgresCanonicalTokens(tokens)
+	}
+	for {
+		i, j := redundantParens(tokens)
+		if i < 0 {
+			break
+		}
+		tokens = append(tokens[:i], append(tokens[i+1:j], tokens[j+1:]...)...)
+	}
+	return strings.Join(tokens, " ")
+}
+
+// postgresCanonicalTokens rewrites the expression tokens into the form that
+// Postgres reports an expression back in: != becomes <>, x IN (a, b) becomes
+// x = ANY (ARRAY[a, b]), x NOT IN (a, b) becomes x <> ALL (ARRAY[a, b]) and x
+// BETWEEN a AND b becomes (x >= a AND x <= b).
+func postgresCanonicalTokens(tokens []string) []string {
+	canonical := make([]string, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		switch {
+		case token == "!" && i+1 < len(tokens) && tokens[i+1] == "=":
+			canonical = append(canonical, "<", ">")
+			i++
+		case token == "in" && i+2 < len(tokens) && tokens[i+1] == "(" && tokens[i+2] != "select":
+			depth, j := 0, i+1
+			for ; j < len(tokens); j++ {
+				if tokens[j] == "(" {
+					depth++
+				} else if tokens[j] == ")" {
+					depth--
+					if depth == 0 {
+						break
+					}
+				}
+			}
+			if n := len(canonical); n > 0 && canonical[n-1] == "not" {
+				canonical = append(canonical[:n-1], "<", ">", "all")
+			} else {
+				canonical = append(canonical, "=", "any")
+			}
+			canonical = append(canonical, "(", "array", "[")
+			canonical = append(canonical, postgresCanonicalTokens(tokens[i+2:min(j, len(tokens))])...)
+			canonical = append(canonical, "]", ")")
+			i = j
+		case token == "between" && len(canonical) > 0 && i+3 < len(tokens) && tokens[i+2] == "and" &&
+			canonical[len(canonical)-1] != ")" && tokens[i+1] != "(" && tokens[i+3] != "(":
+			operand := canonical[len(canonical)-1]
+			canonical = append(canonical[:len(canonical)-1], "(", operand, ">", "=", tokens[i+1], "and", operand, "<", "=", tokens[i+3], ")")
+			i += 3
+		default:
+			canonical = append(canonical, token)
+		}
+	}
+	return canonical
+}
+
+// redundantParens returns the positions of the first pair of parentheses in
+// tokens that can be removed without changing how the expression groups, or
+// -1, -1 if there are none.
+func redundantParens(tokens []string) (start, end int) {
+	// precedence returns the precedence of a boolean operator, or 0 if the
+	// token is not one.
+	precedence := func(token string) int {
+		switch token {
+		case "or":
+			return 1
+		case "and":
+			return 2
+		case "not":
+			return 3
+		}
+		return 0
+	}
+	var stack []int
+	for j, token := range tokens {
+		if token == "(" {
+			stack = append(stack, j)
+			continue
+		}
+		if token != ")" || len(stack) == 0 {
+			continue
+		}
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		var prev, next string
+		if i > 0 {
+			prev = tokens[i-1]
+		}
+		if j+1 < len(tokens) {
+			next = tokens[j+1]
+		}
+		// Parentheses directly after a word belong to a function call or an
+		// IN list e.g. f(x) or IN (1), and are never redundant.
+		if prev != "" && precedence(prev) == 0 && isIdentifierChar(prev[0]) {
+			continue
+		}
+		if j == i+2 {
+			return i, j
+		}
+		if prev != "" && prev != "(" && precedence(prev) == 0 {
+			continue
+		}
+		if next != "" && next != ")" && next != "and" && next != "or" {
+			continue
+		}
+		// The parentheses are redundant if the loosest operator inside them
+		// binds at least as tightly as the operators on either side.
+		inner, depth := 4, 0
+		for _, token := range tokens[i+1 : j] {
+			switch token {
+			case "(":
+				depth++
+			case ")":
+				depth--
+			default:
+				if n := precedence(token); depth == 0 && n > 0 && n < inner {
+					inner = n
+				}
+			}
+		}
+		if inner >= precedence(prev) && inner >= precedence(next) {
+			return i, j
+		}
+	}
+	return -1, -1
+}
+
+// splitViewSQL returns the statements of a view's SQL, with the leading
+// `CREATE VIEW name AS` (if any) removed.
+func splitViewSQL(dialect string, sql string) []lintStatement {
+	stmts, _ := splitStatements(dialect, sql)
+	if len(stmts) == 0 || !strings.EqualFold(stmts[0].tokens[0], "CREATE") {
+		return stmts
+	}
+	depth := 0
+	for i, token := range stmts[0].tokens {
+		switch token {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		}
+		if depth == 0 && strings.EqualFold(token, "AS") {
+			stmts[0].tokens = stmts[0].tokens[i+1:]
+			stmts[0].offsets = stmts[0].offsets[i+1:]
+			break
+		}
+	}
+	return stmts
+}
+
+// viewQuery returns the query of a view i.e. its SQL without the leading
+// `CREATE VIEW name AS` and the trailing semicolon.
+func viewQuery(dialect string, sql string) string {
+	stmts := splitViewSQL(dialect, sql)
+	if len(stmts) == 0 || len(stmts[0].tokens) == 0 {
+		return ""
+	}
+	first, last := stmts[0], stmts[len(stmts)-1]
+	end := last.offsets[len(last.offsets)-1] + len(last.tokens[len(last.tokens)-1])
+	return strings.TrimSpace(sql[first.offsets[0]:end])
+}
+
+// normalizeViewSQL normalizes the query of a view so that it can be
+// meaningfully compared. Comments and whitespace are ignored, and keywords
+// and identifiers are compared case-insensitively.
+func normalizeViewSQL(dialect string, sql string) string {
+	var b strings.Builder
+	for _, stmt := range splitViewSQL(dialect, sql) {
+		for _, token := range stmt.tokens {
+			if b.Len() > 0 {
+				b.WriteString(" ")
+			}
+			if token[0] == '\'' || token[0] == '$' {
+				b.WriteString(token)
+				continue
+			}
+			for i, part := range splitIdentifier(token) {
+				if i > 0 {
+					b.WriteString(".")
+				}
+				b.WriteString(strings.ToLower(part))
+			}
+		}
+		b.WriteString(";")
+	}
+	return b.String()
+}
+
+// viewHashPrefix prefixes the hash of a view's declared query, which is stored
+// as the comment of a Postgres view.
+const viewHashPrefix = "sqddl:sha256="
+
+// viewSQLHash returns the comment that records the hash of a view's declared
+// query. Postgres does not keep the query of a view as it was written (it
+// expands * and qualifies every column), so the hash is what a view declared
+// in a view struct is compared against once it has been created.
+func viewSQLHash(dialect string, sql string) string {
+	sum := sha256.Sum256([]byte(normalizeViewSQL(dialect, sql)))
+	return viewHashPrefix + hex.EncodeToString(sum[:])
+}
+
+// viewSQLIsChanged reports if the query of the src view differs from the query
+// of the dest view. If both views carry the hash of their declared query, the
+// hashes are compared instead of the queries.
+func viewSQLIsChanged(dialect string, srcView, destView *View) bool {
+	if dialect == DialectPostgres && strings.HasPrefix(srcView.Comment, viewHashPrefix) && strings.HasPrefix(destView.Comment, viewHashPrefix) {
+		return srcView.Comment != destView.Comment
+	}
+	return normalizeViewSQL(dialect, srcView.SQL) != normalizeViewSQL(dialect, destView.SQL)
+}
+
+// splitIdentifier splits a (possibly qualified and quoted) identifier into
+// its unquoted parts e.g. "public"."actor" becomes [public actor].
+func splitIdentifier(identifier string) []string {
+	var parts []string
+	for i := 0; i < len(identifier); {
+		var part string
+		switch identifier[i] {
+		case '"', '`', '[':
+			closing := identifier[i]
+			if closing == '[' {
+				closing = ']'
+			}
+			j := scanQuoted(identifier, i, closing)
+			part = identifier[i+1 : j]
+			if len(part) > 0 && part[len(part)-1] == closing {
+				part = part[:len(part)-1]
+			}
+			part = strings.ReplaceAll(part, string([]byte{closing, closing}), string(closing))
+			i = j
+		default:
+			j := strings.IndexByte(identifier[i:], '.')
+			if j < 0 {
+				j = len(identifier) - i
+			}
+			part = identifier[i : i+j]
+			i += j
+		}
+		parts = append(parts, part)
+		if i < len(identifier) && identifier[i] == '.' {
+			i++
+		}
+	}
+	return parts
+}
+
+// writeCreateView writes the CREATE VIEW statement of a view. If replace is
+// true, the view replaces an existing view of the same name.
+func writeCreateView(dialect string, buf *bytes.Buffer, currentSchema string, view *View, replace bool) {
+	viewName := QuoteIdentifier(dialect, view.ViewName)
+	if view.ViewSchema != "" && view.ViewSchema != currentSchema {
+		viewName = QuoteIdentifier(dialect, view.ViewSchema) + "." + viewName
+	}
+	query := viewQuery(dialect, view.SQL)
+	switch dialect {
+	case DialectSQLServer:
+		// CREATE VIEW must be the only statement in its batch, so it is
+		// executed dynamically.
+		stmt := "CREATE VIEW "
+		if replace {
+			stmt = "CREATE OR ALTER VIEW "
+		}
+		// Every quote is doubled (unlike EscapeQuote, which leaves doubled
+		// quotes as they are) because the query may contain string literals.
+		buf.WriteString("EXEC('" + strings.ReplaceAll(stmt+viewName+" AS "+query, "'", "''") + "');\n")
+	default:
+		buf.WriteString("CREATE ")
+		if replace {
+			buf.WriteString("OR REPLACE ")
+		}
+		if view.IsMaterialized && dialect == DialectPostgres {
+			buf.WriteString("MATERIALIZED ")
+		}
+		buf.WriteString("VIEW " + viewName + " AS " + query + ";\n")
+	}
+	// Recreating a view drops its comment and replacing it leaves the old
+	// one behind, so the comment is always written again.
+	if dialect == DialectPostgres && view.Comment != "" {
+		buf.WriteString("COMMENT ON ")
+		if view.IsMaterialized {
+			buf.WriteString("MATERIALIZED ")
+		}
+		buf.WriteString("VIEW " + viewName + " IS " + quoteComment(dialect, view.Comment) + ";\n")
+	}
+}
+
+// writeDropViews writes the DROP VIEW statements of the migration.
+func (vm *viewMigration) writeDropViews(buf *bytes.Buffer) {
+	for _, view := range vm.dropViews {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString("DROP ")
+		if view.IsMaterialized && vm.dialect == DialectPostgres {
+			buf.WriteString("MATERIALIZED ")
+		}
+		buf.WriteString("VIEW IF EXISTS " + vm.viewName(view) + ";\n")
+	}
+}
+
+// writeCreateViews writes the CREATE VIEW statements of the migration,
+// followed by any changes to the indexes of materialized views.
+func (vm *viewMigration) writeCreateViews(buf *bytes.Buffer) {
+	for _, view := range vm.createViews {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		writeCreateView(vm.dialect, buf, vm.currentSchema, view, vm.replaceViews[view] != nil)
+		if !view.IsMaterialized || vm.dialect != DialectPostgres {
+			continue
+		}
+		for i := range view.Indexes {
+			if view.Indexes[i].Ignore {
+				continue
+			}
+			writeCreateIndex(vm.dialect, buf, vm.currentSchema, &view.Indexes[i], false)
+		}
+	}
+	for _, index := range vm.dropViewIndexes {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		indexName := QuoteIdentifier(vm.dialect, index.IndexName)
+		if index.TableSchema != "" && index.TableSchema != vm.currentSchema {
+			indexName = QuoteIdentifier(vm.dialect, index.TableSchema) + "." + indexName
+		}
+		buf.WriteString("DROP INDEX IF EXISTS " + indexName + ";\n")
+	}
+	for _, index := range vm.createViewIndexes {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		writeCreateIndex(vm.dialect, buf, vm.currentSchema, index, false)
+	}
+	for _, view := range vm.refreshViews {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString("REFRESH MATERIALIZED VIEW " + vm.viewName(view) + ";\n")
+	}
+}
+
+// writeUndoCreateViews writes the DROP VIEW statements that undo the views
+// created (but not replaced) by the migration.
+func (vm *viewMigration) writeUndoCreateViews(buf *bytes.Buffer) {
+	for i := len(vm.createViews) - 1; i >= 0; i-- {
+		view := vm.createViews[i]
+		if vm.replaceViews[view] != nil {
+			continue
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString("DROP VIEW IF EXISTS " + vm.viewName(view) + ";\n")
+	}
+}
+
+// undoDropViews returns the undo scope of the DROP VIEW statements.
+func (vm *viewMigration) undoDropViews() undoScope {
+	return undoScope{srcViews: vm.dropViews}
+}
+
+// undoCreateViews returns the undo scope of the CREATE VIEW statements and
+// the changes to the indexes of materialized views.
+func (vm *viewMigration) undoCreateViews() undoScope {
+	scope := undoScope{destViews: vm.createViews}
+	for _, view := range vm.createViews {
+		if srcView := vm.replaceViews[view]; srcView != nil {
+			scope.srcViews = append(scope.srcViews, srcView)
+		}
+	}
+	for _, views := range vm.indexedViews {
+		scope.srcViews = append(scope.srcViews, views[0])
+		scope.destViews = append(scope.destViews, views[1])
+	}
+	return scope
+}
+
+func (vm *viewMigration) viewName(view *View) string {
+	viewName := QuoteIdentifier(vm.dialect, view.ViewName)
+	if view.ViewSchema != "" && view.ViewSchema != vm.currentSchema {
+		viewName = QuoteIdentifier(vm.dialect, view.ViewSchema) + "." + viewName
+	}
+	return viewName
+}
+
+// hasDropViews reports if the migration drops any views.
+func (vm *viewMigration) hasDropViews() bool {
+	return len(vm.dropViews) > 0
+}
+
+// hasCreateViews reports if the migration creates, replaces or refreshes any
+// views.
+func (vm *viewMigration) hasCreateViews() bool {
+	return len(vm.createViews) > 0 || len(vm.dropViewIndexes) > 0 || len(vm.createViewIndexes) > 0 || len(vm.refreshViews) > 0
+}
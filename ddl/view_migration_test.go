@@ -0,0 +1,443 @@
+package ddl
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/blink-io/sqddl/internal/testutil"
+)
+
+func Test_viewMigration(t *testing.T) {
+	type TT struct {
+		description string
+		dialect     string
+		srcCatalog  *Catalog
+		destCatalog *Catalog
+		dropObjects bool
+		wantFiles   map[string]string
+	}
+
+	actorTable := func(nameType string) Table {
+		return Table{
+			TableSchema: "public",
+			TableName:   "actor",
+			Columns: []Column{
+				{TableSchema: "public", TableName: "actor", ColumnName: "actor_id", ColumnType: "INT"},
+				{TableSchema: "public", TableName: "actor", ColumnName: "name", ColumnType: nameType},
+			},
+		}
+	}
+
+	tests := []TT{{
+		description: "postgres unchanged views",
+		dialect:     DialectPostgres,
+		srcCatalog: &Catalog{CurrentSchema: "public", Schemas: []Schema{{
+			SchemaName: "public",
+			Tables:     []Table{actorTable("TEXT")},
+			Views: []View{{
+				ViewSchema: "public",
+				ViewName:   "actor_names",
+				SQL:        " SELECT actor.name\n   FROM actor;",
+			}},
+			ViewsValid: true,
+		}}},
+		destCatalog: &Catalog{CurrentSchema: "public", Schemas: []Schema{{
+			SchemaName: "public",
+			Tables:     []Table{actorTable("TEXT")},
+			Views: []View{{
+				ViewSchema: "public",
+				ViewName:   "actor_names",
+				SQL:        "CREATE VIEW actor_names AS\nselect ACTOR.NAME -- the actor's name\nfrom actor",
+			}},
+			ViewsValid: true,
+		}}},
+		wantFiles: map[string]string{},
+	}, {
+		description: "postgres column type change",
+		dialect:     DialectPostgres,
+		srcCatalog: &Catalog{CurrentSchema: "public", Schemas: []Schema{{
+			SchemaName: "public",
+			Tables:     []Table{actorTable("TEXT")},
+			Views: []View{{
+				ViewSchema: "public",
+				ViewName:   "actor_name_lengths",
+				SQL:        "SELECT length(actor_names.name) FROM actor_names",
+			}, {
+				ViewSchema: "public",
+				ViewName:   "actor_names",
+				SQL:        "SELECT actor.name FROM actor",
+			}, {
+				ViewSchema:     "public",
+				ViewName:       "actor_ids",
+				IsMaterialized: true,
+				SQL:            "SELECT actor.actor_id FROM public.actor",
+				Indexes: []Index{{
+					TableSchema: "public",
+					TableName:   "actor_ids",
+					IndexName:   "actor_ids_idx",
+					IsViewIndex: true,
+					Columns:     []string{"actor_id"},
+				}},
+			}},
+			ViewsValid: true,
+		}}},
+		destCatalog: &Catalog{CurrentSchema: "public", Schemas: []Schema{{
+			SchemaName: "public",
+			Tables:     []Table{actorTable("VARCHAR(255)")},
+			Views: []View{{
+				ViewSchema: "public",
+				ViewName:   "actor_name_lengths",
+				SQL:        "SELECT length(actor_names.name) FROM actor_names",
+			}, {
+				ViewSchema: "public",
+				ViewName:   "actor_names",
+				SQL:        "SELECT actor.name FROM actor",
+			}, {
+				ViewSchema:     "public",
+				ViewName:       "actor_ids",
+				IsMaterialized: true,
+				SQL:            "SELECT actor.actor_id FROM public.actor",
+				Indexes: []Index{{
+					TableSchema: "public",
+					TableName:   "actor_ids",
+					IndexName:   "actor_ids_idx",
+					IsViewIndex: true,
+					Columns:     []string{"actor_id"},
+				}},
+			}},
+			ViewsValid: true,
+		}}},
+		wantFiles: map[string]string{
+			"v_01_drop_views.sql": "DROP MATERIALIZED VIEW IF EXISTS actor_ids;\n" +
+				"\nDROP VIEW IF EXISTS actor_name_lengths;\n" +
+				"\nDROP VIEW IF EXISTS actor_names;\n",
+			"v_02_alter_actor.tx.sql": "ALTER TABLE actor ALTER COLUMN name TYPE VARCHAR(255);\n",
+			"v_03_views.sql": "CREATE VIEW actor_names AS SELECT actor.name FROM actor;\n" +
+				"\nCREATE VIEW actor_name_lengths AS SELECT length(actor_names.name) FROM actor_names;\n" +
+				"\nCREATE MATERIALIZED VIEW actor_ids AS SELECT actor.actor_id FROM public.actor;\n" +
+				"CREATE INDEX actor_ids_idx ON actor_ids (actor_id);\n",
+		},
+	}, {
+		description: "postgres replace and refresh",
+		dialect:     DialectPostgres,
+		srcCatalog: &Catalog{CurrentSchema: "public", Schemas: []Schema{{
+			SchemaName: "public",
+			Tables:     []Table{actorTable("TEXT")},
+			Views: []View{{
+				ViewSchema:  "public",
+				ViewName:    "actor_names",
+				SQL:         "SELECT actor.name FROM actor",
+				Columns:     []string{"name"},
+				ColumnTypes: []string{"TEXT"},
+			}, {
+				ViewSchema:     "public",
+				ViewName:       "actor_ids",
+				IsMaterialized: true,
+				SQL:            "SELECT actor.actor_id FROM actor",
+			}},
+			ViewsValid: true,
+		}}},
+		destCatalog: &Catalog{CurrentSchema: "public", Schemas: []Schema{{
+			SchemaName: "public",
+			Tables: []Table{{
+				TableSchema: "public",
+				TableName:   "actor",
+				Columns: []Column{
+					{TableSchema: "public", TableName: "actor", ColumnName: "actor_id", ColumnType: "INT"},
+					{TableSchema: "public", TableName: "actor", ColumnName: "name", ColumnType: "TEXT"},
+					{TableSchema: "public", TableName: "actor", ColumnName: "last_update", ColumnType: "TIMESTAMPTZ"},
+				},
+			}},
+			Views: []View{{
+				ViewSchema:  "public",
+				ViewName:    "actor_names",
+				SQL:         "SELECT actor.name, actor.last_update FROM actor",
+				Columns:     []string{"name", "last_update"},
+				ColumnTypes: []string{"TEXT", "TIMESTAMPTZ"},
+			}, {
+				ViewSchema:     "public",
+				ViewName:       "actor_ids",
+				IsMaterialized: true,
+				SQL:            "SELECT actor.actor_id FROM actor",
+				Indexes: []Index{{
+					TableSchema: "public",
+					TableName:   "actor_ids",
+					IndexName:   "actor_ids_idx",
+					IsViewIndex: true,
+					IsUnique:    true,
+					Columns:     []string{"actor_id"},
+				}},
+			}},
+			ViewsValid: true,
+		}}},
+		wantFiles: map[string]string{
+			"v_01_alter_actor.tx.sql": "ALTER TABLE actor ADD COLUMN last_update TIMESTAMPTZ;\n",
+			"v_02_views.sql": "CREATE OR REPLACE VIEW actor_names AS SELECT actor.name, actor.last_update FROM actor;\n" +
+				"\nCREATE UNIQUE INDEX actor_ids_idx ON actor_ids (actor_id);\n" +
+				"\nREFRESH MATERIALIZED VIEW actor_ids;\n",
+		},
+	}, {
+		description: "mysql create and drop views",
+		dialect:     DialectMySQL,
+		dropObjects: true,
+		srcCatalog: &Catalog{CurrentSchema: "sakila", Schemas: []Schema{{
+			SchemaName: "sakila",
+			Views: []View{{
+				ViewSchema: "sakila",
+				ViewName:   "old_view",
+				SQL:        "select 1 AS `one`",
+			}, {
+				ViewSchema: "sakila",
+				ViewName:   "changed_view",
+				SQL:        "select 1 AS `one`",
+			}},
+			ViewsValid: true,
+		}}},
+		destCatalog: &Catalog{CurrentSchema: "sakila", Schemas: []Schema{{
+			SchemaName: "sakila",
+			Views: []View{{
+				ViewSchema: "sakila",
+				ViewName:   "changed_view",
+				SQL:        "select 2 AS `two`",
+			}, {
+				ViewSchema: "sakila",
+				ViewName:   "new_view",
+				SQL:        "SELECT 3 AS `three`;",
+			}},
+			ViewsValid: true,
+		}}},
+		wantFiles: map[string]string{
+			"v_01_drop_views.sql": "DROP VIEW IF EXISTS old_view;\n",
+			"v_02_views.sql": "CREATE OR REPLACE VIEW changed_view AS select 2 AS `two`;\n" +
+				"\nCREATE VIEW new_view AS SELECT 3 AS `three`;\n",
+			"v_02_views.undo.sql": "DROP VIEW IF EXISTS new_view;\n",
+		},
+	}, {
+		description: "sqlserver column type change",
+		dialect:     DialectSQLServer,
+		dropObjects: true,
+		srcCatalog: &Catalog{CurrentSchema: "dbo", Schemas: []Schema{{
+			SchemaName: "dbo",
+			Tables: []Table{{
+				TableSchema: "dbo",
+				TableName:   "actor",
+				Columns: []Column{
+					{TableSchema: "dbo", TableName: "actor", ColumnName: "actor_id", ColumnType: "INT"},
+					{TableSchema: "dbo", TableName: "actor", ColumnName: "name", ColumnType: "NVARCHAR(255)"},
+				},
+			}},
+			Views: []View{{
+				ViewSchema: "dbo",
+				ViewName:   "actor_names",
+				SQL:        "CREATE VIEW [dbo].[actor_names] AS SELECT [actor_id], 'n''a' AS n FROM [dbo].[actor];",
+			}},
+			ViewsValid: true,
+		}}},
+		destCatalog: &Catalog{CurrentSchema: "dbo", Schemas: []Schema{{
+			SchemaName: "dbo",
+			Tables: []Table{{
+				TableSchema: "dbo",
+				TableName:   "actor",
+				Columns: []Column{
+					{TableSchema: "dbo", TableName: "actor", ColumnName: "actor_id", ColumnType: "INT"},
+					{TableSchema: "dbo", TableName: "actor", ColumnName: "name", ColumnType: "NVARCHAR(100)"},
+				},
+			}},
+			Views: []View{{
+				ViewSchema: "dbo",
+				ViewName:   "actor_names",
+				SQL:        "CREATE VIEW [dbo].[actor_names] AS SELECT [actor_id], 'n''a' AS n FROM [dbo].[actor];",
+			}},
+			ViewsValid: true,
+		}}},
+		wantFiles: map[string]string{
+			"v_01_drop_views.sql":     "DROP VIEW IF EXISTS actor_names;\n",
+			"v_02_alter_actor.tx.sql": "ALTER TABLE actor ALTER COLUMN name NVARCHAR(100);\n",
+			"v_03_views.sql":          "EXEC('CREATE VIEW actor_names AS SELECT [actor_id], ''n''''a'' AS n FROM [dbo].[actor]');\n",
+		},
+	}, {
+		description: "sqlite column type change",
+		dialect:     DialectSQLite,
+		dropObjects: true,
+		srcCatalog: &Catalog{Schemas: []Schema{{
+			Tables: []Table{{
+				TableName: "actor",
+				Columns: []Column{
+					{TableName: "actor", ColumnName: "actor_id", ColumnType: "INT"},
+				},
+			}},
+			Views: []View{{
+				ViewName: "actor_ids",
+				SQL:      "CREATE VIEW actor_ids AS SELECT actor_id FROM actor;",
+			}},
+			ViewsValid: true,
+		}}},
+		destCatalog: &Catalog{Schemas: []Schema{{
+			Tables: []Table{{
+				TableName: "actor",
+				Columns: []Column{
+					{TableName: "actor", ColumnName: "actor_id", ColumnType: "TEXT"},
+				},
+			}},
+		}}},
+		wantFiles: map[string]string{
+			"v.sql": "DROP VIEW IF EXISTS actor_ids;\n" +
+				"\nPRAGMA legacy_alter_table = ON;\n" +
+				"\nCREATE TABLE actor_new (\n    actor_id TEXT\n);\n" +
+				"INSERT INTO actor_new\n    (actor_id)\nSELECT\n    actor_id\nFROM\n    actor\n;\n" +
+				"DROP TABLE actor;\n" +
+				"ALTER TABLE actor_new RENAME TO actor;\n" +
+				"\nPRAGMA legacy_alter_table = OFF;\n" +
+				"\nCREATE VIEW actor_ids AS SELECT actor_id FROM actor;\n",
+		},
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.description, func(t *testing.T) {
+			t.Parallel()
+			var filenames []string
+			var bufs []*bytes.Buffer
+			switch tt.dialect {
+			case DialectSQLite:
+				m := newSQLiteMigration(tt.srcCatalog, tt.destCatalog, tt.dropObjects)
+				filenames, bufs, _ = m.sql("v")
+			case DialectPostgres:
+				m := newPostgresMigration(tt.srcCatalog, tt.destCatalog, tt.dropObjects)
+				filenames, bufs, _ = m.sql("v")
+			case DialectMySQL:
+				m := newMySQLMigration(tt.srcCatalog, tt.destCatalog, tt.dropObjects)
+				filenames, bufs, _ = m.sql("v")
+			case DialectSQLServer:
+				m := newSQLServerMigration(tt.srcCatalog, tt.destCatalog, tt.dropObjects)
+				filenames, bufs, _ = m.sql("v")
+			}
+			gotFiles := make(map[string]string)
+			for i, filename := range filenames {
+				gotFiles[filename] = bufs[i].String()
+			}
+			if diff := testutil.Diff(gotFiles, tt.wantFiles); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+		})
+	}
+}
+
+func Test_normalizeViewSQL(t *testing.T) {
+	type TT struct {
+		dialect string
+		sql1    string
+		sql2    string
+		equal   bool
+	}
+	tests := []TT{
+		{DialectPostgres, "SELECT 1", "select  1;", true},
+		{DialectPostgres, "CREATE OR REPLACE VIEW v (a) AS SELECT 1", "SELECT 1", true},
+		{DialectPostgres, `SELECT "actor".name FROM actor /* comment */`, "SELECT actor.name\n FROM actor", true},
+		{DialectPostgres, "SELECT 'A'", "SELECT 'a'", false},
+		{DialectMySQL, "select `sakila`.`actor`.`actor_id` AS `actor_id` from `sakila`.`actor`", "SELECT sakila.actor.actor_id AS actor_id FROM sakila.actor", true},
+		{DialectSQLServer, "CREATE VIEW dbo.v WITH SCHEMABINDING AS SELECT [a] FROM [dbo].[t]", "SELECT a FROM dbo.t", true},
+	}
+	for _, tt := range tests {
+		got := normalizeViewSQL(tt.dialect, tt.sql1) == normalizeViewSQL(tt.dialect, tt.sql2)
+		if got != tt.equal {
+			t.Errorf(testutil.Callers()+" %q, %q: got equal=%v, want %v", tt.sql1, tt.sql2, got, tt.equal)
+		}
+	}
+}
+
+func Test_normalizeIndexPredicate(t *testing.T) {
+	type TT struct {
+		dialect    string
+		predicate1 string
+		predicate2 string
+		equal      bool
+	}
+	tests := []TT{
+		{DialectPostgres, "deleted_at IS NULL", "(deleted_at IS NULL)", true},
+		{DialectPostgres, "status = 'active'", "(status = 'active'::text)", true},
+		{DialectPostgres, "name = 'a'", "((name)::character varying = 'a'::character varying)", true},
+		{DialectPostgres, "status = 'active'", "status = 'inactive'", false},
+		{DialectSQLServer, "deleted_at IS NULL", "([deleted_at] IS NULL)", true},
+		{DialectPostgres, "(a OR b) AND c", "((a OR b) AND c)", true},
+		{DialectPostgres, "a OR (b AND c)", "(a OR (b AND c))", true},
+		{DialectPostgres, "a OR b AND c", "(a OR (b AND c))", true},
+		{DialectPostgres, "(a OR b) AND c", "a OR (b AND c)", false},
+		{DialectPostgres, "NOT (a AND b)", "NOT a AND b", false},
+		{DialectPostgres, "price > 0", "((price > (0)::numeric))", true},
+		{DialectPostgres, "lower(email) IS NOT NULL", "lower email IS NOT NULL", false},
+		{DialectPostgres, "id IN (1, 2)", "(id IN (1, 2))", true},
+		{DialectPostgres, "status IN ('a', 'b')", "(status = ANY (ARRAY['a'::text, 'b'::text]))", true},
+		{DialectPostgres, "status NOT IN ('a', 'b')", "(status <> ALL (ARRAY['a'::text, 'b'::text]))", true},
+		{DialectPostgres, "status != 'a'", "(status <> 'a'::text)", true},
+		{DialectPostgres, "length BETWEEN 1 AND 10", "((length >= 1) AND (length <= 10))", true},
+		{DialectPostgres, "rental_rate > 0", "(rental_rate >= (0)::numeric)", false},
+		{DialectMySQL, "rental_rate >= 0", "(`rental_rate` >= 0)", true},
+	}
+	for _, tt := range tests {
+		got := normalizeIndexPredicate(tt.dialect, tt.predicate1) == normalizeIndexPredicate(tt.dialect, tt.predicate2)
+		if got != tt.equal {
+			t.Errorf(testutil.Callers()+" %q, %q: got equal=%v, want %v", tt.predicate1, tt.predicate2, got, tt.equal)
+		}
+	}
+}
+
+func Test_exclusionsAreEqual(t *testing.T) {
+	constraint := Constraint{
+		ConstraintType:     EXCLUDE,
+		Columns:            []string{"room_id", "tsrange(start_at, end_at)"},
+		ExclusionOperators: []string{"=", "&&"},
+		ExclusionIndexType: "gist",
+		ExclusionPredicate: "NOT is_cancelled",
+	}
+	type TT struct {
+		description string
+		change      func(c *Constraint)
+		equal       bool
+	}
+	tests := []TT{
+		{"introspected", func(c *Constraint) {
+			c.Columns = []string{"room_id", "tsrange(start_at,end_at)"}
+			c.ExclusionIndexType = "GIST"
+			c.ExclusionPredicate = "(NOT is_cancelled)"
+		}, true},
+		{"column", func(c *Constraint) { c.Columns = []string{"room_id", "tstzrange(start_at, end_at)"} }, false},
+		{"operator", func(c *Constraint) { c.ExclusionOperators = []string{"<>", "&&"} }, false},
+		{"index type", func(c *Constraint) { c.ExclusionIndexType = "" }, false},
+		{"predicate", func(c *Constraint) { c.ExclusionPredicate = "" }, false},
+	}
+	for _, tt := range tests {
+		destConstraint := constraint
+		tt.change(&destConstraint)
+		got := exclusionsAreEqual(DialectPostgres, &constraint, &destConstraint)
+		if got != tt.equal {
+			t.Errorf(testutil.Callers()+" %s: got equal=%v, want %v", tt.description, got, tt.equal)
+		}
+	}
+}
+
+func Test_indexesAreEqual_opclasses(t *testing.T) {
+	// Introspected default opclasses are empty strings, so any opclass name
+	// is a non-default one regardless of how it is spelled.
+	type TT struct {
+		srcOpclasses  []string
+		destOpclasses []string
+		equal         bool
+	}
+	tests := []TT{
+		{nil, nil, true},
+		{[]string{"", ""}, nil, true},
+		{[]string{"text_pattern_ops", ""}, []string{"text_pattern_ops"}, true},
+		{nil, []string{"int4_minmax_ops"}, false},
+		{[]string{"gist_int4_ops", ""}, []string{"gist_int4_ops", ""}, true},
+		{[]string{"pg_lsn_ops"}, []string{"text_pattern_ops"}, false},
+	}
+	for _, tt := range tests {
+		srcIndex := &Index{IndexName: "idx", IndexType: "btree", Columns: []string{"a", "b"}, Opclasses: tt.srcOpclasses}
+		destIndex := &Index{IndexName: "idx", IndexType: "btree", Columns: []string{"a", "b"}, Opclasses: tt.destOpclasses}
+		got := indexesAreEqual(DialectPostgres, srcIndex, destIndex)
+		if got != tt.equal {
+			t.Errorf(testutil.Callers()+" %q, %q: got equal=%v, want %v", tt.srcOpclasses, tt.destOpclasses, got, tt.equal)
+		}
+	}
+}
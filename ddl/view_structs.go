@@ -3,6 +3,7 @@ package ddl
 import (
 	"bytes"
 	"database/sql"
+	"io/fs"
 	"strconv"
 	"strings"
 )
@@ -12,6 +13,9 @@ type ViewStructs []ViewStruct
 type ViewStruct struct {
 	Name   string
 	Fields []StructField
+
+	// Used to read the files referenced by the sqlfile modifier.
+	fsys fs.FS
 }
 
 // NewViewStructs introspects a database connection and returns a slice of
@@ -0,0 +1,232 @@
+package ddl
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Warning codes. A warning can be accepted individually with the
+// `-accept-warning CODE[:table.column]` flag or the
+// `ddl:"acceptwarning=CODE"` modifier on the offending table or column.
+const (
+	// WarnPGAddColumnDefaultLargeTable flags (Postgres 10 and below) adding
+	// a column with a DEFAULT, which rewrites the entire table.
+	WarnPGAddColumnDefaultLargeTable = "PG_ADD_COLUMN_DEFAULT_LARGE_TABLE"
+
+	// WarnPGAddColumnIdentityLargeTable flags (Postgres 10 and below) adding
+	// an identity column, which rewrites the entire table.
+	WarnPGAddColumnIdentityLargeTable = "PG_ADD_COLUMN_IDENTITY_LARGE_TABLE"
+
+	// WarnPGSetNotNullLargeTable flags (Postgres 11 and below) setting NOT
+	// NULL on an existing column, which scans the entire table while holding
+	// an exclusive lock.
+	WarnPGSetNotNullLargeTable = "PG_SET_NOT_NULL_LARGE_TABLE"
+
+	// WarnPGLimitTextColumn flags changing a TEXT column to a VARCHAR with a
+	// limit, which rewrites the entire table.
+	WarnPGLimitTextColumn = "PG_LIMIT_TEXT_COLUMN"
+
+	// WarnPGDecreaseColumnLimit flags decreasing the limit of a VARCHAR
+	// column, which rewrites the entire table.
+	WarnPGDecreaseColumnLimit = "PG_DECREASE_COLUMN_LIMIT"
+
+	// WarnPGChangeColumnScale flags changing the scale of a NUMERIC column,
+	// which rewrites the entire table.
+	WarnPGChangeColumnScale = "PG_CHANGE_COLUMN_SCALE"
+
+	// WarnPGDecreaseColumnPrecision flags decreasing the precision of a
+	// NUMERIC column, which rewrites the entire table.
+	WarnPGDecreaseColumnPrecision = "PG_DECREASE_COLUMN_PRECISION"
+
+	// WarnPGChangeColumnType flags any other column type change, which may
+	// rewrite the entire table.
+	WarnPGChangeColumnType = "PG_CHANGE_COLUMN_TYPE"
+
+	// WarnPGEnumRemoveLabel flags an enum label that cannot be removed
+	// without recreating the enum type.
+	WarnPGEnumRemoveLabel = "PG_ENUM_REMOVE_LABEL"
+
+	// WarnPGEnumReorderLabels flags enum labels that cannot be reordered
+	// without recreating the enum type.
+	WarnPGEnumReorderLabels = "PG_ENUM_REORDER_LABELS"
+
+	// WarnPGDomainChangeType flags a change to the underlying type or
+	// collation of a domain, which cannot be done without recreating the
+	// domain type.
+	WarnPGDomainChangeType = "PG_DOMAIN_CHANGE_TYPE"
+
+	// WarnPGChangePartitioning flags a change to the partition key of a
+	// table or to the partitioned table that a partition belongs to, which
+	// cannot be done without recreating the table.
+	WarnPGChangePartitioning = "PG_CHANGE_PARTITIONING"
+
+	// WarnPGPartitionedIndexLock flags adding a PRIMARY KEY or UNIQUE
+	// constraint to a partitioned table that has partitions, which builds
+	// the index on every partition while blocking writes (it cannot be done
+	// concurrently).
+	WarnPGPartitionedIndexLock = "PG_PARTITIONED_INDEX_LOCK"
+
+	// WarnPGAddExclusionLock flags adding an EXCLUDE constraint to an
+	// existing table, which builds its index while holding a lock that
+	// blocks reads and writes (it cannot be done concurrently).
+	WarnPGAddExclusionLock = "PG_ADD_EXCLUSION_LOCK"
+
+	// WarnMySQLChangeVarcharLimit flags changing the limit of a VARCHAR
+	// column across 255 characters, which rewrites the entire table.
+	WarnMySQLChangeVarcharLimit = "MYSQL_CHANGE_VARCHAR_LIMIT"
+
+	// WarnMySQLChangeColumnType flags any other column type change, which
+	// may rewrite the entire table.
+	WarnMySQLChangeColumnType = "MYSQL_CHANGE_COLUMN_TYPE"
+
+	// WarnMySQLChangeEngine flags changing the storage engine of a table,
+	// which copies the entire table.
+	WarnMySQLChangeEngine = "MYSQL_CHANGE_ENGINE"
+
+	// WarnMySQLConvertCharset flags converting a table to another character
+	// set, which rewrites every text column and may lose characters that the
+	// new character set cannot represent.
+	WarnMySQLConvertCharset = "MYSQL_CONVERT_CHARSET"
+
+	// WarnSQLiteRenameColumnUnsupported flags (SQLite 3.24 and below) a
+	// column rename that is skipped because it requires rebuilding the
+	// table.
+	WarnSQLiteRenameColumnUnsupported = "SQLITE_RENAME_COLUMN_UNSUPPORTED"
+
+	// WarnSQLiteChangeTableOptions flags a change to the STRICT or WITHOUT
+	// ROWID option of a table that is skipped because it requires rebuilding
+	// the table.
+	WarnSQLiteChangeTableOptions = "SQLITE_CHANGE_TABLE_OPTIONS"
+
+	// WarnSQLServerAddIdentityUnsupported flags an identity that is skipped
+	// because it cannot be added to an existing column.
+	WarnSQLServerAddIdentityUnsupported = "SQLSERVER_ADD_IDENTITY_UNSUPPORTED"
+
+	// WarnSQLServerChangeColumnType flags a column type change, which may
+	// rewrite the entire table.
+	WarnSQLServerChangeColumnType = "SQLSERVER_CHANGE_COLUMN_TYPE"
+
+	// WarnRenamedFromNotFound flags a renamedfrom hint whose table or column
+	// does not exist, so the hint can be removed.
+	WarnRenamedFromNotFound = "RENAMEDFROM_NOT_FOUND"
+
+	// WarnDownDataLost flags a dropped table or column that a down script
+	// recreates without its data.
+	WarnDownDataLost = "DOWN_DATA_LOST"
+)
+
+// Warning severities.
+const (
+	// SeverityHigh is a change that locks or rewrites a large table, or that
+	// cannot be generated at all.
+	SeverityHigh = "high"
+
+	// SeverityMedium is a change that may lock or rewrite a large table
+	// depending on the data, or that has to be completed manually.
+	SeverityMedium = "medium"
+
+	// SeverityLow is informational.
+	SeverityLow = "low"
+)
+
+// Warning is a warning raised when generating migrations.
+type Warning struct {
+	// Code is the warning code e.g. "PG_SET_NOT_NULL_LARGE_TABLE".
+	Code string
+
+	// Severity is the warning severity e.g. "high".
+	Severity string
+
+	// Object is the path of the object the warning is about e.g.
+	// "users.email". The schema is omitted if it is the current schema.
+	Object string
+
+	// Message describes the warning.
+	Message string
+
+	// Accepted reports if the warning was accepted by an -accept-warning flag
+	// or an acceptwarning modifier.
+	Accepted bool
+}
+
+// String returns the warning in the format `[code] message`.
+func (w Warning) String() string {
+	return "[" + w.Code + "] " + w.Message
+}
+
+// warningObject returns the object path of a warning. The schemaName is
+// omitted if it is the current schema.
+func warningObject(currentSchema, schemaName string, names ...string) string {
+	if schemaName != "" && schemaName != currentSchema {
+		names = append([]string{schemaName}, names...)
+	}
+	return strings.Join(names, ".")
+}
+
+// acceptWarnings marks the warnings accepted by any of the acceptWarning
+// values (in the format CODE[:object]) or by the acceptwarning modifiers of
+// the tables and columns in the destCatalog. An object accepts the warnings
+// of every object nested under it i.e. CODE:users accepts the warnings of
+// every column of users.
+func acceptWarnings(warnings []Warning, acceptWarning []string, destCatalog *Catalog) error {
+	type rule struct {
+		code, object string
+	}
+	var rules []rule
+	for _, value := range acceptWarning {
+		code, object, _ := strings.Cut(value, ":")
+		if code == "" {
+			return fmt.Errorf("invalid -accept-warning %q: empty code", value)
+		}
+		rules = append(rules, rule{code: code, object: object})
+	}
+	if destCatalog != nil {
+		for _, schema := range destCatalog.Schemas {
+			for _, table := range schema.Tables {
+				if table.Ignore {
+					continue
+				}
+				tableObject := warningObject(destCatalog.CurrentSchema, table.TableSchema, table.TableName)
+				for _, code := range table.AcceptWarnings {
+					rules = append(rules, rule{code: code, object: tableObject})
+				}
+				for _, column := range table.Columns {
+					if column.Ignore {
+						continue
+					}
+					for _, code := range column.AcceptWarnings {
+						rules = append(rules, rule{code: code, object: tableObject + "." + column.ColumnName})
+					}
+				}
+			}
+		}
+	}
+	for i := range warnings {
+		warning := &warnings[i]
+		warning.Accepted = slices.ContainsFunc(rules, func(r rule) bool {
+			return r.code == warning.Code && (r.object == "" || r.object == warning.Object || strings.HasPrefix(warning.Object, r.object+"."))
+		})
+	}
+	return nil
+}
+
+// checkWarnings returns an error listing the warnings that were not accepted,
+// unless acceptAll or dryRun is true. Otherwise the warnings are written to
+// stderr.
+func checkWarnings(stderr io.Writer, warnings []Warning, acceptAll, dryRun bool) error {
+	var unaccepted []string
+	for _, warning := range warnings {
+		if !warning.Accepted {
+			unaccepted = append(unaccepted, warning.String())
+		}
+	}
+	if len(unaccepted) > 0 && !acceptAll && !dryRun {
+		return fmt.Errorf("%s", "warnings present (to proceed despite the warnings, use the -accept-warnings flag or accept them individually with -accept-warning CODE[:table.column]):\n"+strings.Join(unaccepted, "\n"))
+	}
+	for _, warning := range warnings {
+		fmt.Fprintln(stderr, warning)
+	}
+	return nil
+}
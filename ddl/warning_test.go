@@ -0,0 +1,155 @@
+package ddl
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/blink-io/sqddl/internal/testutil"
+)
+
+// warningMessages returns the message of every warning.
+func warningMessages(warnings []Warning) []string {
+	messages := make([]string, len(warnings))
+	for i, warning := range warnings {
+		messages[i] = warning.Message
+	}
+	return messages
+}
+
+func TestAcceptWarnings(t *testing.T) {
+	type TT struct {
+		description string
+		args        []string
+		dest        string
+		wantErr     bool
+		// wantUnaccepted is the list of object paths of the warnings that are
+		// not accepted.
+		wantUnaccepted []string
+	}
+
+	// testdata/postgres_alter raises the warnings:
+	//
+	// [PG_CHANGE_COLUMN_TYPE] person: column "person_id" changing type from "INT" to "BIGINT" may be unsafe
+	// [PG_LIMIT_TEXT_COLUMN] person: column "email" changing type from "TEXT" to "VARCHAR(255)" is unsafe
+	// [PG_DECREASE_COLUMN_LIMIT] person: column "bio" decreasing limit from "VARCHAR(1000)" to "VARCHAR(255)" is unsafe
+	// [PG_CHANGE_COLUMN_SCALE] person: column "height_meters" changing scale from "NUMERIC(3,1)" to "NUMERIC(3,2)" is unsafe
+	// [PG_DECREASE_COLUMN_PRECISION] person: column "weight_kilos" decreasing precision from "NUMERIC(5,2)" to "NUMERIC(3,2)" is unsafe
+	b, err := os.ReadFile("testdata/postgres_alter/dest.go.txt")
+	if err != nil {
+		t.Fatal(testutil.Callers(), err)
+	}
+	destTables := string(b)
+
+	tests := []TT{{
+		description: "none accepted",
+		wantUnaccepted: []string{
+			"person.person_id",
+			"person.email",
+			"person.bio",
+			"person.height_meters",
+			"person.weight_kilos",
+		},
+	}, {
+		description: "flags",
+		args: []string{
+			"-accept-warning", WarnPGChangeColumnType,
+			"-accept-warning", WarnPGLimitTextColumn + ":person.email",
+			"-accept-warning", WarnPGDecreaseColumnLimit + ":person",
+			"-accept-warning", WarnPGChangeColumnScale + ":person.height_meters",
+			// Wrong column.
+			"-accept-warning", WarnPGDecreaseColumnPrecision + ":person.height_meters",
+		},
+		wantUnaccepted: []string{"person.weight_kilos"},
+	}, {
+		description: "modifiers",
+		dest: strings.NewReplacer(
+			"sq.TableStruct\n\tPERSON_ID", "sq.TableStruct `ddl:\"acceptwarning="+WarnPGDecreaseColumnLimit+"\"`\n\tPERSON_ID",
+			`collate=C notnull"`, `collate=C notnull acceptwarning=`+WarnPGLimitTextColumn+`"`,
+			`type=NUMERIC(3,2)"`, `type=NUMERIC(3,2) acceptwarning=`+WarnPGChangeColumnScale+`,`+WarnPGDecreaseColumnPrecision+`"`,
+		).Replace(destTables),
+		wantUnaccepted: []string{"person.person_id"},
+	}, {
+		description: "invalid flag",
+		args:        []string{"-accept-warning", ":person.email"},
+		wantErr:     true,
+	}}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.description, func(t *testing.T) {
+			t.Parallel()
+			args := []string{
+				"-src", "testdata/postgres_alter/src.go.txt",
+				"-dest", "testdata/postgres_alter/dest.go.txt",
+				"-dialect", "postgres",
+				"-no-undo",
+			}
+			generateCmd, err := GenerateCommand(append(args, tt.args...)...)
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			if tt.dest != "" {
+				generateCmd.DestCatalog = nil
+				generateCmd.DirFS = fstest.MapFS{"dest.go.txt": {Data: []byte(tt.dest)}}
+				generateCmd.Filenames = []string{"dest.go.txt"}
+			}
+			_, warnings, err := generateCmd.Results()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal(testutil.Callers(), "expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(testutil.Callers(), err)
+			}
+			if len(warnings) != 5 {
+				t.Fatalf(testutil.Callers()+" expected 5 warnings, got %q", warningMessages(warnings))
+			}
+			var gotUnaccepted []string
+			for _, warning := range warnings {
+				if !warning.Accepted {
+					gotUnaccepted = append(gotUnaccepted, warning.Object)
+				}
+			}
+			if diff := testutil.Diff(gotUnaccepted, tt.wantUnaccepted); diff != "" {
+				t.Error(testutil.Callers(), diff)
+			}
+		})
+	}
+
+	t.Run("run", func(t *testing.T) {
+		t.Parallel()
+		generateCmd, err := GenerateCommand(
+			"-src", "testdata/postgres_alter/src.go.txt",
+			"-dest", "testdata/postgres_alter/dest.go.txt",
+			"-dialect", "postgres",
+			"-output-dir", t.TempDir(),
+			"-accept-warning", WarnPGChangeColumnType,
+			"-accept-warning", WarnPGLimitTextColumn,
+			"-accept-warning", WarnPGDecreaseColumnLimit,
+			"-accept-warning", WarnPGChangeColumnScale,
+		)
+		if err != nil {
+			t.Fatal(testutil.Callers(), err)
+		}
+		generateCmd.Stderr = io.Discard
+		err = generateCmd.Run()
+		if err == nil {
+			t.Fatal(testutil.Callers(), "expected error, got nil")
+		}
+		// Only the unaccepted warning is reported.
+		wantErr := "warnings present (to proceed despite the warnings, use the -accept-warnings flag or accept them individually with -accept-warning CODE[:table.column]):\n" +
+			`[PG_DECREASE_COLUMN_PRECISION] person: column "weight_kilos" decreasing precision from "NUMERIC(5,2)" to "NUMERIC(3,2)" is unsafe`
+		if diff := testutil.Diff(err.Error(), wantErr); diff != "" {
+			t.Error(testutil.Callers(), diff)
+		}
+		generateCmd.AcceptWarning = append(generateCmd.AcceptWarning, WarnPGDecreaseColumnPrecision+":person.weight_kilos")
+		err = generateCmd.Run()
+		if err != nil {
+			t.Error(testutil.Callers(), err)
+		}
+	})
+}
@@ -236,6 +236,22 @@ func (cmd *WipeCmd) Run() error {
 			cmd.buf.WriteString(QuoteIdentifier(cmd.Dialect, domain.DomainName) + " CASCADE;\n")
 		}
 
+		// DROP SEQUENCE.
+		sequences, err := dbi.GetSequences()
+		if err != nil {
+			return err
+		}
+		for _, sequence := range sequences {
+			if cmd.buf.Len() > 0 {
+				cmd.buf.WriteString("\n")
+			}
+			cmd.buf.WriteString("DROP SEQUENCE IF EXISTS ")
+			if sequence.SequenceSchema != "" && sequence.SequenceSchema != currentSchema {
+				cmd.buf.WriteString(QuoteIdentifier(cmd.Dialect, sequence.SequenceSchema) + ".")
+			}
+			cmd.buf.WriteString(QuoteIdentifier(cmd.Dialect, sequence.SequenceName) + " CASCADE;\n")
+		}
+
 		// DROP EXTENSION.
 		extensions, err := dbi.GetExtensions()
 		if err != nil {
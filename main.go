@@ -23,18 +23,22 @@ func init() {
 }
 
 const helptext = `Usage:
-  sqddl migrate     # Run pending migrations.
-  sqddl ls          # Show pending migrations.
-  sqddl touch       # Upsert migrations into to the history table. Does not run them.
-  sqddl rm          # Remove migrations from the history table.
-  sqddl mv          # Rename migrations in the history table.
-  sqddl tables      # Generate table structs from database.
-  sqddl views       # Generate view structs from database.
-  sqddl generate    # Generate migrations from table structs.
-  sqddl wipe        # Wipe a database of all views, tables, routines, enums, domains and extensions.
-  sqddl dump        # Dump a database as sql scripts and csv files.
-  sqddl load        # Load database dumps, sql scripts or csv files into a database.
-  sqddl automigrate # Automatically migrate a database according to table structs.
+  sqddl migrate      # Run pending migrations.
+  sqddl ls           # Show pending migrations.
+  sqddl touch        # Upsert migrations into to the history table. Does not run them.
+  sqddl rm           # Remove migrations from the history table.
+  sqddl mv           # Rename migrations in the history table.
+  sqddl history-diff # Compare the history tables of two databases.
+  sqddl lint         # Check migrations for unsafe operations.
+  sqddl tables       # Generate table structs from database.
+  sqddl views        # Generate view structs from database.
+  sqddl generate     # Generate migrations from table structs.
+  sqddl wipe         # Wipe a database of all views, tables, routines, enums, domains and extensions.
+  sqddl dump         # Dump a database as sql scripts and csv files.
+  sqddl convert      # Convert a schema into another database dialect.
+  sqddl load         # Load database dumps, sql scripts or csv files into a database.
+  sqddl automigrate  # Automatically migrate a database according to table structs.
+  sqddl check        # Check a database for schema drift against table structs.
 
 To learn more about each subcommand, pass in the -h flag to the subcommand e.g. sqddl migrate -h
 
@@ -127,6 +131,24 @@ func main() {
 		if err != nil {
 			exit(subcmd, err)
 		}
+	case "history-diff":
+		historyDiffCmd, err := ddl.HistoryDiffCommand(args...)
+		if err != nil {
+			exit(subcmd, err)
+		}
+		err = historyDiffCmd.Run()
+		if err != nil {
+			exit(subcmd, err)
+		}
+	case "lint":
+		lintCmd, err := ddl.LintCommand(args...)
+		if err != nil {
+			exit(subcmd, err)
+		}
+		err = lintCmd.Run()
+		if err != nil {
+			exit(subcmd, err)
+		}
 	case "tables":
 		tablesCmd, err := ddl.TablesCommand(args...)
 		if err != nil {
@@ -172,6 +194,15 @@ func main() {
 		if err != nil {
 			exit(subcmd, err)
 		}
+	case "convert":
+		convertCmd, err := ddl.ConvertCommand(args...)
+		if err != nil {
+			exit(subcmd, err)
+		}
+		err = convertCmd.Run()
+		if err != nil {
+			exit(subcmd, err)
+		}
 	case "load":
 		loadCmd, err := ddl.LoadCommand(args...)
 		if err != nil {
@@ -190,6 +221,15 @@ func main() {
 		if err != nil {
 			exit(subcmd, err)
 		}
+	case "check":
+		checkCmd, err := ddl.CheckCommand(args...)
+		if err != nil {
+			exit(subcmd, err)
+		}
+		err = checkCmd.Run()
+		if err != nil {
+			exit(subcmd, err)
+		}
 	default:
 		fmt.Fprintf(os.Stderr, "unrecognized subcommand %q\n", subcmd)
 		return